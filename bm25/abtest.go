@@ -0,0 +1,279 @@
+package bm25
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultRBOPersistence is the default persistence of the rank-biased overlap, which
+// weighs the top 10 ranks at about 86% of the total.
+const defaultRBOPersistence = 0.9
+
+// ABTestOptions configures an ABTest.
+type ABTestOptions struct {
+	// ServeB is the fraction of queries, between 0 and 1, whose results are served from
+	// variant B. Queries are assigned by a hash of their terms, so the same query is
+	// always served by the same variant.
+	ServeB float64
+
+	// Record, if set, is called with the comparison of both rankings for every search.
+	// It is called synchronously and must be safe for concurrent use.
+	Record func(ABComparison)
+
+	// RBOPersistence is the persistence p of the rank-biased overlap, between 0
+	// (exclusive) and 1 (exclusive). Defaults to 0.9.
+	RBOPersistence float64
+}
+
+// ABComparison compares the rankings of a query by two variants.
+type ABComparison struct {
+	Query   []string
+	A, B    []int // Ranked document IDs
+	TookA   time.Duration
+	TookB   time.Duration
+	ServedB bool
+
+	Overlap float64 // Fraction of documents both rankings have in common
+	RBO     float64 // Rank-biased overlap, which weighs agreement at the top ranks higher
+}
+
+// JudgedQuery is a query with graded relevance judgments of its documents.
+type JudgedQuery struct {
+	Query     []string
+	Relevance map[int]float64 // Relevance grade of each judged document; others count as 0
+}
+
+// ABSummary aggregates the comparisons of a set of judged queries.
+type ABSummary struct {
+	Queries     int
+	MeanOverlap float64
+	MeanRBO     float64
+
+	// NDCGA and NDCGB are the mean NDCG of each variant, NDCGDelta their difference
+	// (B minus A), and Wins, Losses and Ties count the queries on which B did better,
+	// worse or the same as A.
+	NDCGA     float64
+	NDCGB     float64
+	NDCGDelta float64
+	Wins      int
+	Losses    int
+	Ties      int
+}
+
+// ABTest routes searches to two ranking variants, e.g. BM25L and BM25+ or one variant
+// with different parameters, and compares their rankings, to support safe rollouts of
+// ranking changes. Both variants must index the same corpus.
+type ABTest struct {
+	a, b BM25
+	opts ABTestOptions
+}
+
+// NewABTest creates a new ABTest of variant A, the control, against variant B.
+func NewABTest(a, b BM25, opts ABTestOptions) (*ABTest, error) {
+	if a == nil || b == nil {
+		return nil, ErrNilBase
+	}
+
+	if opts.ServeB < 0 || opts.ServeB > 1 {
+		return nil, invalidParam("ServeB", opts.ServeB, "must be between 0 and 1")
+	}
+
+	if opts.RBOPersistence == 0 {
+		opts.RBOPersistence = defaultRBOPersistence
+	}
+	if opts.RBOPersistence <= 0 || opts.RBOPersistence >= 1 {
+		return nil, invalidParam("RBOPersistence", opts.RBOPersistence, "must be between 0 and 1 (exclusive)")
+	}
+
+	return &ABTest{a: a, b: b, opts: opts}, nil
+}
+
+// Search runs the request against both variants, records their comparison and returns
+// the response of the variant the query is assigned to.
+func (t *ABTest) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	respA, respB, cmp, err := t.compare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.opts.Record != nil {
+		t.opts.Record(cmp)
+	}
+
+	if cmp.ServedB {
+		return respB, nil
+	}
+	return respA, nil
+}
+
+// Compare runs the request against both variants and returns the comparison of their
+// rankings, without recording it.
+func (t *ABTest) Compare(ctx context.Context, req SearchRequest) (ABComparison, error) {
+	_, _, cmp, err := t.compare(ctx, req)
+	return cmp, err
+}
+
+// Evaluate compares both variants on a set of judged queries, returning the top n
+// results of each, and aggregates their agreement and NDCG@n.
+func (t *ABTest) Evaluate(ctx context.Context, queries []JudgedQuery, n int) (ABSummary, error) {
+	var summary ABSummary
+	for _, query := range queries {
+		cmp, err := t.Compare(ctx, SearchRequest{Query: query.Query, N: n})
+		if err != nil {
+			return ABSummary{}, err
+		}
+
+		ndcgA, ndcgB := NDCG(cmp.A, query.Relevance, n), NDCG(cmp.B, query.Relevance, n)
+		summary.Queries++
+		summary.MeanOverlap += cmp.Overlap
+		summary.MeanRBO += cmp.RBO
+		summary.NDCGA += ndcgA
+		summary.NDCGB += ndcgB
+		switch {
+		case ndcgB > ndcgA:
+			summary.Wins++
+		case ndcgB < ndcgA:
+			summary.Losses++
+		default:
+			summary.Ties++
+		}
+	}
+
+	if summary.Queries > 0 {
+		count := float64(summary.Queries)
+		summary.MeanOverlap /= count
+		summary.MeanRBO /= count
+		summary.NDCGA /= count
+		summary.NDCGB /= count
+		summary.NDCGDelta = summary.NDCGB - summary.NDCGA
+	}
+	return summary, nil
+}
+
+// compare runs the request against both variants and compares their rankings.
+func (t *ABTest) compare(ctx context.Context, req SearchRequest) (*SearchResponse, *SearchResponse, ABComparison, error) {
+	respA, err := t.a.Search(ctx, req)
+	if err != nil {
+		return nil, nil, ABComparison{}, err
+	}
+	respB, err := t.b.Search(ctx, req)
+	if err != nil {
+		return nil, nil, ABComparison{}, err
+	}
+
+	cmp := ABComparison{
+		Query:   req.Query,
+		A:       resultDocIDs(respA),
+		B:       resultDocIDs(respB),
+		TookA:   respA.Took,
+		TookB:   respB.Took,
+		ServedB: t.servesB(req.Query),
+	}
+	cmp.Overlap = overlap(cmp.A, cmp.B)
+	cmp.RBO = RankBiasedOverlap(cmp.A, cmp.B, t.opts.RBOPersistence)
+	return respA, respB, cmp, nil
+}
+
+// servesB reports whether the query is assigned to variant B.
+func (t *ABTest) servesB(query []string) bool {
+	if t.opts.ServeB == 0 {
+		return false
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(query, "\x00")))
+	return float64(h.Sum64()%10000) < t.opts.ServeB*10000
+}
+
+// resultDocIDs returns the document IDs of the results of a search, in ranked order.
+func resultDocIDs(resp *SearchResponse) []int {
+	docIDs := make([]int, len(resp.Results))
+	for i, result := range resp.Results {
+		docIDs[i] = result.DocID
+	}
+	return docIDs
+}
+
+// overlap returns the fraction of documents two rankings have in common.
+func overlap(a, b []int) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	inA := make(map[int]struct{}, len(a))
+	for _, docID := range a {
+		inA[docID] = struct{}{}
+	}
+	common := 0
+	for _, docID := range b {
+		if _, ok := inA[docID]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(max(len(a), len(b)))
+}
+
+// RankBiasedOverlap returns the rank-biased overlap of two rankings with persistence p,
+// normalized so that identical rankings score 1 and disjoint ones 0. Agreement at rank d
+// is weighted by p^(d-1), so the top of the rankings matters most.
+func RankBiasedOverlap(a, b []int, p float64) float64 {
+	depth := max(len(a), len(b))
+	if depth == 0 {
+		return 1
+	}
+
+	seenA := make(map[int]struct{}, len(a))
+	seenB := make(map[int]struct{}, len(b))
+	var common int
+	var sum, norm float64
+	for d := 0; d < depth; d++ {
+		if d < len(a) {
+			if _, ok := seenB[a[d]]; ok {
+				common++
+			}
+			seenA[a[d]] = struct{}{}
+		}
+		if d < len(b) {
+			if _, ok := seenA[b[d]]; ok {
+				common++
+			}
+			seenB[b[d]] = struct{}{}
+		}
+
+		weight := math.Pow(p, float64(d))
+		sum += weight * float64(common) / float64(d+1)
+		norm += weight
+	}
+	return sum / norm
+}
+
+// NDCG returns the normalized discounted cumulative gain of the top n documents of a
+// ranking, given graded relevance judgments. Rankings of queries without any relevant
+// document score 0.
+func NDCG(ranking []int, relevance map[int]float64, n int) float64 {
+	var dcg float64
+	for i, docID := range ranking[:Min(n, len(ranking))] {
+		dcg += relevance[docID] / math.Log2(float64(i+2))
+	}
+
+	ideal := make([]float64, 0, len(relevance))
+	for _, grade := range relevance {
+		if grade > 0 {
+			ideal = append(ideal, grade)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(ideal)))
+
+	var idcg float64
+	for i, grade := range ideal[:Min(n, len(ideal))] {
+		idcg += grade / math.Log2(float64(i+2))
+	}
+	if idcg == 0 {
+		return 0
+	}
+	return dcg / idcg
+}
@@ -0,0 +1,155 @@
+package bm25
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrOverloaded is returned when a search is rejected because the queue of an
+	// AdmissionController is full.
+	ErrOverloaded = errors.New("too many concurrent searches")
+
+	// ErrQueueTimeout is returned when a search waited longer than the QueueTimeout of an
+	// AdmissionController.
+	ErrQueueTimeout = errors.New("search timed out in queue")
+)
+
+// AdmissionOptions configures an AdmissionController.
+type AdmissionOptions struct {
+	// MaxConcurrent is the number of searches running at once.
+	MaxConcurrent int
+
+	// MaxQueued is the number of searches waiting for one of the running searches to
+	// finish. Searches beyond it are rejected with ErrOverloaded. Zero rejects every
+	// search that cannot run immediately.
+	MaxQueued int
+
+	// QueueTimeout, if positive, bounds the time a search waits in the queue before it
+	// fails with ErrQueueTimeout.
+	QueueTimeout time.Duration
+
+	// OnRejected, if set, is called with the error of every rejected search, e.g. to
+	// export it as a metric.
+	OnRejected func(err error)
+}
+
+// AdmissionStats holds the counters of an AdmissionController.
+type AdmissionStats struct {
+	Running  int // Searches currently running
+	Waiting  int // Searches currently waiting in the queue
+	Admitted uint64
+	Queued   uint64 // Searches that had to wait before they were admitted or rejected
+	Rejected uint64 // Searches rejected because the queue was full
+	TimedOut uint64 // Searches that waited longer than QueueTimeout
+	Canceled uint64 // Searches whose context was done while they were waiting
+}
+
+// AdmissionController limits the number of concurrent searches and queues the searches
+// beyond the limit, so load spikes lead to bounded queueing and fast rejections rather
+// than to every search slowing down. It is safe for concurrent use, and can guard any
+// number of indexes.
+type AdmissionController struct {
+	opts  AdmissionOptions
+	slots chan struct{}
+
+	waiting  atomic.Int64
+	admitted atomic.Uint64
+	queued   atomic.Uint64
+	rejected atomic.Uint64
+	timedOut atomic.Uint64
+	canceled atomic.Uint64
+}
+
+// NewAdmissionController creates a new AdmissionController.
+func NewAdmissionController(opts AdmissionOptions) (*AdmissionController, error) {
+	if opts.MaxConcurrent <= 0 {
+		return nil, invalidParam("MaxConcurrent", opts.MaxConcurrent, "must be a positive integer")
+	}
+	if opts.MaxQueued < 0 {
+		return nil, invalidParam("MaxQueued", opts.MaxQueued, "must be non-negative")
+	}
+	if opts.QueueTimeout < 0 {
+		return nil, invalidParam("QueueTimeout", opts.QueueTimeout, "must be non-negative")
+	}
+
+	return &AdmissionController{opts: opts, slots: make(chan struct{}, opts.MaxConcurrent)}, nil
+}
+
+// Acquire admits a search, waiting in the queue if needed. On success, the caller must
+// call the returned function once the search has finished.
+func (a *AdmissionController) Acquire(ctx context.Context) (func(), error) {
+	select {
+	case a.slots <- struct{}{}:
+		a.admitted.Add(1)
+		return a.release, nil
+	default:
+	}
+
+	if a.waiting.Add(1) > int64(a.opts.MaxQueued) {
+		a.waiting.Add(-1)
+		a.rejected.Add(1)
+		return nil, a.reject(ErrOverloaded)
+	}
+	defer a.waiting.Add(-1)
+	a.queued.Add(1)
+
+	var timeout <-chan time.Time
+	if a.opts.QueueTimeout > 0 {
+		timer := time.NewTimer(a.opts.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case a.slots <- struct{}{}:
+		a.admitted.Add(1)
+		return a.release, nil
+	case <-timeout:
+		a.timedOut.Add(1)
+		return nil, a.reject(fmt.Errorf("%w after %v", ErrQueueTimeout, a.opts.QueueTimeout))
+	case <-ctx.Done():
+		a.canceled.Add(1)
+		return nil, ctx.Err()
+	}
+}
+
+// release frees the slot of a finished search.
+func (a *AdmissionController) release() {
+	<-a.slots
+}
+
+// reject reports a rejected search to the OnRejected hook.
+func (a *AdmissionController) reject(err error) error {
+	if a.opts.OnRejected != nil {
+		a.opts.OnRejected(err)
+	}
+	return err
+}
+
+// Search runs a search against the index once it is admitted.
+func (a *AdmissionController) Search(ctx context.Context, index BM25, req SearchRequest) (*SearchResponse, error) {
+	release, err := a.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return index.Search(ctx, req)
+}
+
+// Stats returns the current counters.
+func (a *AdmissionController) Stats() AdmissionStats {
+	return AdmissionStats{
+		Running:  len(a.slots),
+		Waiting:  int(a.waiting.Load()),
+		Admitted: a.admitted.Load(),
+		Queued:   a.queued.Load(),
+		Rejected: a.rejected.Load(),
+		TimedOut: a.timedOut.Load(),
+		Canceled: a.canceled.Load(),
+	}
+}
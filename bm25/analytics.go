@@ -0,0 +1,237 @@
+package bm25
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TermCount is the number of searches a query term appeared in.
+type TermCount struct {
+	Term  string
+	Count int
+}
+
+// QueryCount aggregates the searches of a single query.
+type QueryCount struct {
+	Query    string // Query terms joined by spaces
+	Searches int
+	Clicks   int
+}
+
+// DocClicks is the number of clicks on a document.
+type DocClicks struct {
+	DocID  int
+	Clicks int
+}
+
+// AnalyticsReport summarizes the searches recorded by an Analytics collector since it
+// was started or last reset.
+type AnalyticsReport struct {
+	Start, End  time.Time
+	Searches    int
+	ZeroResults int // Searches that succeeded without results
+	Clicks      int
+
+	TopTerms          []TermCount
+	TopQueries        []QueryCount
+	ZeroResultQueries []QueryCount // The main input for curating synonyms
+	TopClickedDocs    []DocClicks
+}
+
+// ClickThroughRate returns the number of clicks per search.
+func (r AnalyticsReport) ClickThroughRate() float64 {
+	if r.Searches == 0 {
+		return 0
+	}
+	return float64(r.Clicks) / float64(r.Searches)
+}
+
+// Analytics aggregates search traffic into popular terms and queries, zero-result queries
+// and click-through counts. It is a QueryLogSink, so it is enabled by setting it as the
+// sink of a QueryLog; clicks are reported by the application with RecordClick. It is safe
+// for concurrent use.
+type Analytics struct {
+	mu          sync.Mutex
+	start       time.Time
+	searches    int
+	zeroResults int
+	clicks      int
+	terms       map[string]int
+	queries     map[string]*QueryCount
+	zeroQueries map[string]*QueryCount
+	docClicks   map[int]int
+}
+
+// NewAnalytics creates a new Analytics collector.
+func NewAnalytics() *Analytics {
+	a := &Analytics{}
+	a.reset(time.Now())
+	return a
+}
+
+// LogQuery records a search. Failed searches are ignored.
+func (a *Analytics) LogQuery(entry QueryLogEntry) {
+	if entry.Err != nil {
+		return
+	}
+
+	key := strings.Join(entry.Query, " ")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.searches++
+	seen := make(map[string]struct{}, len(entry.Query))
+	for _, term := range entry.Query {
+		if _, ok := seen[term]; !ok {
+			a.terms[term]++
+			seen[term] = struct{}{}
+		}
+	}
+	countQuery(a.queries, key).Searches++
+
+	if entry.Results == 0 {
+		a.zeroResults++
+		countQuery(a.zeroQueries, key).Searches++
+	}
+}
+
+// RecordClick records that the given result of a query was clicked.
+func (a *Analytics) RecordClick(query []string, docID int) {
+	key := strings.Join(query, " ")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.clicks++
+	countQuery(a.queries, key).Clicks++
+	a.docClicks[docID]++
+}
+
+// Report returns a report of the searches recorded so far, listing the top n terms and
+// queries of each kind.
+func (a *Analytics) Report(n int) AnalyticsReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.report(n, time.Now())
+}
+
+// ReportAndReset returns a report like Report and starts a new reporting period.
+func (a *Analytics) ReportAndReset(n int) AnalyticsReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	report := a.report(n, now)
+	a.reset(now)
+	return report
+}
+
+// StartReporting calls fn with a report of every period of the given length, resetting
+// the collector after each one, until the returned stop function is called.
+func (a *Analytics) StartReporting(interval time.Duration, n int, fn func(AnalyticsReport)) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, invalidParam("interval", interval, "must be positive")
+	}
+	if fn == nil {
+		return nil, invalidParam("fn", fn, "must not be nil")
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				fn(a.ReportAndReset(n))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}, nil
+}
+
+// report builds a report. The caller must hold the lock.
+func (a *Analytics) report(n int, end time.Time) AnalyticsReport {
+	report := AnalyticsReport{
+		Start:       a.start,
+		End:         end,
+		Searches:    a.searches,
+		ZeroResults: a.zeroResults,
+		Clicks:      a.clicks,
+	}
+
+	for term, count := range a.terms {
+		report.TopTerms = append(report.TopTerms, TermCount{Term: term, Count: count})
+	}
+	sort.Slice(report.TopTerms, func(i, j int) bool {
+		ti, tj := report.TopTerms[i], report.TopTerms[j]
+		if ti.Count != tj.Count {
+			return ti.Count > tj.Count
+		}
+		return ti.Term < tj.Term
+	})
+	report.TopTerms = report.TopTerms[:Min(max(n, 0), len(report.TopTerms))]
+
+	report.TopQueries = topQueries(a.queries, n)
+	report.ZeroResultQueries = topQueries(a.zeroQueries, n)
+
+	for docID, clicks := range a.docClicks {
+		report.TopClickedDocs = append(report.TopClickedDocs, DocClicks{DocID: docID, Clicks: clicks})
+	}
+	sort.Slice(report.TopClickedDocs, func(i, j int) bool {
+		di, dj := report.TopClickedDocs[i], report.TopClickedDocs[j]
+		if di.Clicks != dj.Clicks {
+			return di.Clicks > dj.Clicks
+		}
+		return di.DocID < dj.DocID
+	})
+	report.TopClickedDocs = report.TopClickedDocs[:Min(max(n, 0), len(report.TopClickedDocs))]
+	return report
+}
+
+// reset starts a new reporting period. The caller must hold the lock.
+func (a *Analytics) reset(start time.Time) {
+	a.start = start
+	a.searches, a.zeroResults, a.clicks = 0, 0, 0
+	a.terms = make(map[string]int)
+	a.queries = make(map[string]*QueryCount)
+	a.zeroQueries = make(map[string]*QueryCount)
+	a.docClicks = make(map[int]int)
+}
+
+// countQuery returns the counts of a query, creating them if needed.
+func countQuery(queries map[string]*QueryCount, key string) *QueryCount {
+	count, ok := queries[key]
+	if !ok {
+		count = &QueryCount{Query: key}
+		queries[key] = count
+	}
+	return count
+}
+
+// topQueries returns the n most searched queries.
+func topQueries(queries map[string]*QueryCount, n int) []QueryCount {
+	top := make([]QueryCount, 0, len(queries))
+	for _, count := range queries {
+		top = append(top, *count)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Searches != top[j].Searches {
+			return top[i].Searches > top[j].Searches
+		}
+		return top[i].Query < top[j].Query
+	})
+	return top[:Min(max(n, 0), len(top))]
+}
@@ -0,0 +1,173 @@
+package bm25
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CharFilter transforms a text before it is tokenized, e.g. to decode HTML entities or to
+// remove URLs, so common cleanups do not require a custom tokenizer.
+type CharFilter func(text string) string
+
+// TokenFilter transforms the tokens produced by a tokenizer, e.g. to remove stopwords or
+// to stem them. Filters may modify the slice they are given in place.
+type TokenFilter func(tokens []string) []string
+
+// Analyzer turns a text into tokens: the text is passed through the char filters in
+// order, split by the tokenizer, and the tokens are passed through the filters in order.
+// The same analyzer has to be applied to the documents and to the queries searching them.
+type Analyzer struct {
+	CharFilters []CharFilter
+	Tokenizer   func(string) []string
+	Filters     []TokenFilter
+}
+
+// Analyze returns the tokens of the text.
+func (a Analyzer) Analyze(text string) []string {
+	for _, filter := range a.CharFilters {
+		text = filter(text)
+	}
+	tokens := a.Tokenizer(text)
+	for _, filter := range a.Filters {
+		tokens = filter(tokens)
+	}
+	return tokens
+}
+
+// validate checks that the analyzer has a tokenizer.
+func (a Analyzer) validate() error {
+	if a.Tokenizer == nil {
+		return ErrNilTokenizer
+	}
+	return nil
+}
+
+// KeywordTokenizer returns the whole text, without leading and trailing whitespace, as a
+// single token, for fields such as IDs or tags that are matched exactly.
+func KeywordTokenizer(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	return []string{text}
+}
+
+// urlPattern matches the http, https and www URLs removed by URLStripFilter.
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"']+`)
+
+// RegexpReplaceFilter returns a char filter replacing the matches of the regular
+// expression with the replacement, which can refer to submatches like
+// regexp.Regexp.ReplaceAllString, e.g. `(\d+)-(\d+)` with "$1$2".
+func RegexpReplaceFilter(pattern *regexp.Regexp, replacement string) CharFilter {
+	return func(text string) string {
+		return pattern.ReplaceAllString(text, replacement)
+	}
+}
+
+// HTMLEntityFilter decodes HTML entities such as "&amp;" or "&#233;". Tags are left as
+// they are.
+func HTMLEntityFilter(text string) string {
+	if !strings.Contains(text, "&") {
+		return text
+	}
+	return html.UnescapeString(text)
+}
+
+// URLStripFilter replaces http, https and www URLs with a space, so their fragments do
+// not end up as tokens.
+func URLStripFilter(text string) string {
+	return urlPattern.ReplaceAllString(text, " ")
+}
+
+// LowercaseFilter lowercases every token.
+func LowercaseFilter(tokens []string) []string {
+	for i, token := range tokens {
+		tokens[i] = strings.ToLower(token)
+	}
+	return tokens
+}
+
+// StopwordFilter returns a filter removing the given stopwords. Unlike ExcludeStopwords,
+// which only skips them while scoring, the stopwords are not indexed at all, so they do
+// not count towards the document lengths either.
+func StopwordFilter(stopwords []string) TokenFilter {
+	set := make(map[string]struct{}, len(stopwords))
+	for _, word := range stopwords {
+		set[word] = struct{}{}
+	}
+
+	return func(tokens []string) []string {
+		return keepTokens(tokens, func(token string) bool {
+			_, ok := set[token]
+			return !ok
+		})
+	}
+}
+
+// LengthFilter returns a filter removing the tokens shorter than minLength or longer than
+// maxLength characters, such as single letters or encoded blobs, which pollute the
+// statistics. A maxLength of 0 means no upper limit.
+func LengthFilter(minLength int, maxLength int) TokenFilter {
+	return func(tokens []string) []string {
+		return keepTokens(tokens, func(token string) bool {
+			n := utf8.RuneCountInString(token)
+			return n >= minLength && (maxLength == 0 || n <= maxLength)
+		})
+	}
+}
+
+// NumericFilter removes the tokens made of digits and number punctuation only, such as
+// "42", "3.14" or "1,000".
+func NumericFilter(tokens []string) []string {
+	return keepTokens(tokens, func(token string) bool {
+		digits := false
+		for _, r := range token {
+			switch {
+			case unicode.IsDigit(r):
+				digits = true
+			case !strings.ContainsRune(".,-+", r):
+				return true
+			}
+		}
+		return !digits
+	})
+}
+
+// KeepPatternFilter returns a filter keeping only the tokens matching the regular
+// expression.
+func KeepPatternFilter(pattern *regexp.Regexp) TokenFilter {
+	return func(tokens []string) []string {
+		return keepTokens(tokens, pattern.MatchString)
+	}
+}
+
+// DropPatternFilter returns a filter removing the tokens matching the regular expression.
+func DropPatternFilter(pattern *regexp.Regexp) TokenFilter {
+	return func(tokens []string) []string {
+		return keepTokens(tokens, func(token string) bool { return !pattern.MatchString(token) })
+	}
+}
+
+// keepTokens removes the tokens for which keep returns false, in place.
+func keepTokens(tokens []string, keep func(token string) bool) []string {
+	kept := tokens[:0]
+	for _, token := range tokens {
+		if keep(token) {
+			kept = append(kept, token)
+		}
+	}
+	return kept
+}
+
+// StemFilter returns a filter replacing every token with its stem.
+func StemFilter(stem func(string) string) TokenFilter {
+	return func(tokens []string) []string {
+		for i, token := range tokens {
+			tokens[i] = stem(token)
+		}
+		return tokens
+	}
+}
@@ -0,0 +1,259 @@
+package bm25
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// ApproxOptions configures the memory bounds of an ApproxIndex.
+type ApproxOptions struct {
+	// Width and Depth size the count-min sketch tracking the document frequencies. With
+	// w = e/ε and d = ln(1/δ), an estimate exceeds the true frequency by more than ε
+	// times the number of documents with probability at most δ. Estimates never
+	// undercount. Default to 2^16 and 4.
+	Width int
+	Depth int
+
+	// ReservoirSize is the number of document lengths kept to estimate their
+	// distribution. Defaults to 1024.
+	ReservoirSize int
+
+	// Seed seeds the reservoir sampling, making it reproducible.
+	Seed int64
+}
+
+// Defaults for the ApproxOptions.
+const (
+	defaultSketchWidth   = 1 << 16
+	defaultSketchDepth   = 4
+	defaultReservoirSize = 1024
+)
+
+// ApproxIndex is an approximate index mode for extreme-scale streaming corpora. It keeps
+// no documents and no vocabulary: document frequencies are tracked with a count-min
+// sketch and document lengths with a running total and a reservoir sample, so its memory
+// is bounded regardless of the size of the stream. It scores documents passed to it
+// against the statistics of everything it has seen, using the Okapi BM25 formula. It is
+// safe for concurrent use.
+type ApproxIndex struct {
+	mu        sync.RWMutex
+	tokenizer func(string) []string
+	k1        float64
+	b         float64
+
+	width, depth int
+	counters     []uint32
+	corpusSize   int
+	totalDocLen  int64
+	reservoir    []int
+	rng          *rand.Rand
+}
+
+// NewApproxIndex creates a new, empty ApproxIndex.
+func NewApproxIndex(tokenizer func(string) []string, k1 float64, b float64, opts ApproxOptions) (*ApproxIndex, error) {
+	if tokenizer == nil {
+		return nil, ErrNilTokenizer
+	}
+
+	if err := validateBM25OkapiParams(k1, b); err != nil {
+		return nil, err
+	}
+
+	for name, value := range map[string]int{"Width": opts.Width, "Depth": opts.Depth, "ReservoirSize": opts.ReservoirSize} {
+		if value < 0 {
+			return nil, invalidParam(name, value, "must be non-negative")
+		}
+	}
+	if opts.Width == 0 {
+		opts.Width = defaultSketchWidth
+	}
+	if opts.Depth == 0 {
+		opts.Depth = defaultSketchDepth
+	}
+	if opts.ReservoirSize == 0 {
+		opts.ReservoirSize = defaultReservoirSize
+	}
+
+	return &ApproxIndex{
+		tokenizer: tokenizer,
+		k1:        k1,
+		b:         b,
+		width:     opts.Width,
+		depth:     opts.Depth,
+		counters:  make([]uint32, opts.Width*opts.Depth),
+		reservoir: make([]int, 0, opts.ReservoirSize),
+		rng:       rand.New(rand.NewSource(opts.Seed)),
+	}, nil
+}
+
+// Add tokenizes a document and adds it to the corpus statistics.
+func (x *ApproxIndex) Add(doc string) error {
+	return x.AddTokens(x.tokenizer(doc))
+}
+
+// AddTokens adds a tokenized document to the corpus statistics.
+func (x *ApproxIndex) AddTokens(tokens []string) error {
+	if len(tokens) == 0 {
+		return ErrEmptyDocument
+	}
+
+	seen := make(map[string]struct{}, len(tokens))
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for _, token := range tokens {
+		if _, ok := seen[token]; !ok {
+			x.increment(token)
+			seen[token] = struct{}{}
+		}
+	}
+
+	x.corpusSize++
+	x.totalDocLen += int64(len(tokens))
+
+	// Reservoir sampling keeps a uniform sample of all document lengths seen so far
+	if len(x.reservoir) < cap(x.reservoir) {
+		x.reservoir = append(x.reservoir, len(tokens))
+	} else if i := x.rng.Int63n(int64(x.corpusSize)); i < int64(len(x.reservoir)) {
+		x.reservoir[i] = len(tokens)
+	}
+	return nil
+}
+
+// CorpusSize returns the number of documents added so far.
+func (x *ApproxIndex) CorpusSize() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return x.corpusSize
+}
+
+// AvgDocLen returns the average document length. It is exact.
+func (x *ApproxIndex) AvgDocLen() float64 {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return x.avgDocLen()
+}
+
+// DocLengthPercentiles returns the estimated distribution of the document lengths.
+func (x *ApproxIndex) DocLengthPercentiles() Percentiles {
+	x.mu.RLock()
+	lengths := append([]int(nil), x.reservoir...)
+	x.mu.RUnlock()
+
+	return computePercentiles(lengths)
+}
+
+// DocFreq returns the estimated number of documents containing the term. The estimate
+// is never lower than the true frequency.
+func (x *ApproxIndex) DocFreq(term string) int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return x.docFreq(term)
+}
+
+// IDF returns the IDF of the term, based on its estimated document frequency.
+func (x *ApproxIndex) IDF(term string) (float64, error) {
+	if term == "" {
+		return 0, ErrEmptyTerm
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return okapiIDF(x.corpusSize, x.docFreq(term)), nil
+}
+
+// Score returns the BM25 score of a tokenized document for the given query, against the
+// current corpus statistics. The document does not need to have been added.
+func (x *ApproxIndex) Score(query []string, doc []string) (float64, error) {
+	if len(query) == 0 {
+		return 0, ErrEmptyQuery
+	}
+
+	if len(doc) == 0 {
+		return 0, ErrEmptyDocument
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	if x.corpusSize == 0 {
+		return 0, ErrEmptyCorpus
+	}
+
+	freqs := make(map[string]float64, len(doc))
+	for _, token := range doc {
+		freqs[token]++
+	}
+
+	k := x.k1 * (1 - x.b + x.b*float64(len(doc))/x.avgDocLen())
+	var score float64
+	for _, q := range query {
+		freq := freqs[q]
+		if freq == 0 {
+			continue
+		}
+		score += okapiIDF(x.corpusSize, x.docFreq(q)) * (freq * (x.k1 + 1)) / (freq + k)
+	}
+	return score, nil
+}
+
+// MemoryBytes returns the memory held by the sketch and the reservoir, which is fixed
+// when the index is created.
+func (x *ApproxIndex) MemoryBytes() int64 {
+	return int64(len(x.counters))*4 + int64(cap(x.reservoir))*intBytes
+}
+
+// avgDocLen returns the average document length. The caller must hold the lock.
+func (x *ApproxIndex) avgDocLen() float64 {
+	if x.corpusSize == 0 {
+		return 0
+	}
+	return float64(x.totalDocLen) / float64(x.corpusSize)
+}
+
+// increment adds one document to the sketch counters of a term, using conservative
+// updates: only the counters holding the current minimum are raised, which reduces the
+// overestimation caused by collisions. The caller must hold the write lock.
+func (x *ApproxIndex) increment(term string) {
+	estimate := x.estimate(term)
+	h1, h2 := sketchHashes(term)
+	for row := 0; row < x.depth; row++ {
+		i := row*x.width + int((h1+uint64(row)*h2)%uint64(x.width))
+		if x.counters[i] == estimate && x.counters[i] < math.MaxUint32 {
+			x.counters[i]++
+		}
+	}
+}
+
+// docFreq returns the estimated document frequency of a term, capped at the corpus size.
+// The caller must hold the lock.
+func (x *ApproxIndex) docFreq(term string) int {
+	return min(int(x.estimate(term)), x.corpusSize)
+}
+
+// estimate returns the smallest sketch counter of a term. The caller must hold the lock.
+func (x *ApproxIndex) estimate(term string) uint32 {
+	h1, h2 := sketchHashes(term)
+	estimate := uint32(math.MaxUint32)
+	for row := 0; row < x.depth; row++ {
+		i := row*x.width + int((h1+uint64(row)*h2)%uint64(x.width))
+		estimate = min(estimate, x.counters[i])
+	}
+	return estimate
+}
+
+// sketchHashes returns the two hashes from which the counter of every sketch row is
+// derived by double hashing.
+func sketchHashes(term string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(term))
+	sum := h.Sum64()
+	return sum, sum>>32 | 1 // An odd second hash cycles through all columns of a power-of-two width
+}
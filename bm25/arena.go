@@ -0,0 +1,38 @@
+package bm25
+
+import "strings"
+
+// arenaChunkSize is the number of tokens allocated at once by a tokenArena.
+const arenaChunkSize = 64 * 1024
+
+// tokenArena hands out token slices carved from large shared chunks, so building an
+// index allocates a few big blocks instead of one slice per document.
+type tokenArena struct {
+	chunk []string
+}
+
+// alloc returns a slice of n tokens backed by the current chunk.
+func (a *tokenArena) alloc(n int) []string {
+	if n > cap(a.chunk)-len(a.chunk) {
+		a.chunk = make([]string, 0, max(arenaChunkSize, n))
+	}
+
+	start := len(a.chunk)
+	a.chunk = a.chunk[:start+n]
+	return a.chunk[start : start+n : start+n]
+}
+
+// interner deduplicates token strings, so every occurrence of a term shares one copy.
+type interner map[string]string
+
+// intern returns the canonical copy of s. The first occurrence is cloned, so the
+// returned string never pins the memory of the document it was tokenized from.
+func (in interner) intern(s string) string {
+	if canonical, ok := in[s]; ok {
+		return canonical
+	}
+
+	s = strings.Clone(s)
+	in[s] = s
+	return s
+}
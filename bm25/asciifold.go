@@ -0,0 +1,95 @@
+package bm25
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ASCIIFoldingFilter replaces the accented and special Latin letters of every token with
+// their ASCII equivalents, e.g. "é" with "e" or "ß" with "ss", so words match regardless
+// of accents. See FoldASCII.
+func ASCIIFoldingFilter(tokens []string) []string {
+	for i, token := range tokens {
+		tokens[i] = FoldASCII(token)
+	}
+	return tokens
+}
+
+// FoldASCII returns the text with the accented and special Latin letters, ligatures and
+// typographic punctuation replaced by their ASCII equivalents, and combining marks
+// removed. Characters without an ASCII equivalent, e.g. of non-Latin scripts, are kept.
+func FoldASCII(text string) string {
+	if isASCII(text) {
+		return text
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(text))
+	for _, r := range text {
+		switch folded, ok := asciiFolds[r]; {
+		case ok:
+			sb.WriteString(folded)
+		case unicode.Is(unicode.Mn, r):
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// isASCII reports whether the text consists of ASCII characters only.
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// asciiFolds maps the characters folded by FoldASCII to their ASCII equivalents.
+var asciiFolds = func() map[rune]string {
+	folds := map[rune]string{
+		'ß': "ss", 'ẞ': "SS",
+		'Æ': "AE", 'æ': "ae", 'Œ': "OE", 'œ': "oe", 'Ĳ': "IJ", 'ĳ': "ij",
+		'Þ': "TH", 'þ': "th",
+		'ﬀ': "ff", 'ﬁ': "fi", 'ﬂ': "fl", 'ﬃ': "ffi", 'ﬄ': "ffl", 'ﬅ': "st", 'ﬆ': "st",
+		'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'",
+		'“': `"`, '”': `"`, '„': `"`, '‟': `"`, '″': `"`, '«': `"`, '»': `"`,
+		'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-",
+		'…': "...",
+	}
+	for base, letters := range map[string]string{
+		"A": "ÀÁÂÃÄÅĀĂĄǍǺȀȂȦḀẠẢẤẦẨẪẬẮẰẲẴẶ", "a": "àáâãäåāăąǎǻȁȃȧḁạảấầẩẫậắằẳẵặ",
+		"B": "ƁḂḄḆ", "b": "ƀɓḃḅḇ",
+		"C": "ÇĆĈĊČƇ", "c": "çćĉċčƈ",
+		"D": "ĎĐƉƊḊḌḎḐḒÐ", "d": "ďđɖɗḋḍḏḑḓð",
+		"E": "ÈÉÊËĒĔĖĘĚȄȆȨẸẺẼẾỀỂỄỆ", "e": "èéêëēĕėęěȅȇȩẹẻẽếềểễệ",
+		"F": "ƑḞ", "f": "ƒḟ",
+		"G": "ĜĞĠĢǤǦǴḠ", "g": "ĝğġģǥǧǵḡ",
+		"H": "ĤĦȞḢḤḦḨḪ", "h": "ĥħȟḣḥḧḩḫẖ",
+		"I": "ÌÍÎÏĨĪĬĮİǏȈȊỈỊ", "i": "ìíîïĩīĭįıǐȉȋỉị",
+		"J": "Ĵ", "j": "ĵǰȷ",
+		"K": "ĶƘǨḰḲḴ", "k": "ķƙǩḱḳḵ",
+		"L": "ĹĻĽĿŁḶḸḺḼ", "l": "ĺļľŀłḷḹḻḽ",
+		"M": "ḾṀṂ", "m": "ḿṁṃ",
+		"N": "ÑŃŅŇǸṄṆṈṊŊ", "n": "ñńņňŉǹṅṇṉṋŋ",
+		"O": "ÒÓÔÕÖØŌŎŐƠǑǾȌȎȮỌỎỐỒỔỖỘỚỜỞỠỢ", "o": "òóôõöøōŏőơǒǿȍȏȯọỏốồổỗộớờởỡợ",
+		"P": "ƤṔṖ", "p": "ƥṕṗ",
+		"R": "ŔŖŘȐȒṘṚṜṞ", "r": "ŕŗřȑȓṙṛṝṟ",
+		"S": "ŚŜŞŠȘṠṢ", "s": "śŝşšșṡṣſ",
+		"T": "ŢŤŦȚṪṬṮṰ", "t": "ţťŧțṫṭṯṱẗ",
+		"U": "ÙÚÛÜŨŪŬŮŰŲƯǓǕǗǙǛȔȖỤỦỨỪỬỮỰ", "u": "ùúûüũūŭůűųưǔǖǘǚǜȕȗụủứừửữự",
+		"V": "ṼṾ", "v": "ṽṿ",
+		"W": "ŴẀẂẄẆẈ", "w": "ŵẁẃẅẇẉẘ",
+		"X": "ẊẌ", "x": "ẋẍ",
+		"Y": "ÝŶŸƳȲẎỲỴỶỸ", "y": "ýÿŷƴȳẏẙỳỵỷỹ",
+		"Z": "ŹŻŽƵȤẐẒẔ", "z": "źżžƶȥẑẓẕ",
+	} {
+		for _, r := range letters {
+			folds[r] = base
+		}
+	}
+	return folds
+}()
@@ -1,18 +1,22 @@
 package bm25
 
-import (
-	"errors"
-	"sync"
-)
+import "sync"
 
 // GetScoresBatched returns the BM25 scores for the given query using parallel computation with batching.
 func (b *Bm25Base) GetScoresBatched(query []string, bm25 BM25, batchSize int) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
 	if batchSize <= 0 {
-		return nil, errors.New("batch size must be a positive integer")
+		return nil, invalidParam("batchSize", batchSize, "must be a positive integer")
+	}
+
+	if b.cachesWritable() {
+		// Fill the IDF cache upfront, so the batches scored concurrently only read from it
+		for _, q := range query {
+			_, _ = b.IDF(q)
+		}
 	}
 
 	var wg sync.WaitGroup
@@ -25,53 +29,55 @@ func (b *Bm25Base) GetScoresBatched(query []string, bm25 BM25, batchSize int) ([
 		end := Min(start+batchSize, b.corpusSize)
 		go func(start, end int) {
 			defer wg.Done()
+			sum := b.newTermSum(scores[start:end])
 			for _, q := range query {
+				if b.isStopword(q) {
+					continue
+				}
+
 				qFreq := make([]float64, end-start)
 				for j := start; j < end; j++ {
-					doc := JoinTokens(b.corpus[j], " ")
-					freq, err := CountTermFreq(q, doc, b.tokenizer)
-					if err != nil {
-						if b.logger != nil {
-							b.logger.Printf("Error counting term frequency for term '%s' in document %d: %v", q, j, err)
-						}
-						continue
-					}
-					qFreq[j-start] = float64(freq)
+					qFreq[j-start] = b.termFrequency(j, q)
 				}
 
-				idf, err := b.IDF(q)
+				idf, err := b.queryIDF(q)
 				if err != nil {
-					if b.logger != nil {
-						b.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-					}
+					b.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 					continue
 				}
 
 				for j := start; j < end; j++ {
-					docLen := b.docLengths[j]
-					k := computeK(bm25, docLen)
-					scores[j] += idf * computeScore(bm25, qFreq[j-start], k)
+					qFreq[j-start] = idf * computeScore(bm25, qFreq[j-start], computeK(bm25, b.docLengths[j]))
 				}
+				sum.add(qFreq)
 			}
 		}(start, end)
 	}
 
 	wg.Wait()
+	b.excludeExpired(scores, nil)
 	return scores, nil
 }
 
 // GetBatchScoresBatched returns the BM25 scores for the given query and a subset of documents using parallel computation with batching.
 func (b *Bm25Base) GetBatchScoresBatched(query []string, docIDs []int, bm25 BM25, batchSize int) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
-	if len(docIDs) == 0 {
-		return nil, errors.New("document IDs cannot be empty")
+	if err := b.validateDocIDs(docIDs); err != nil {
+		return nil, err
 	}
 
 	if batchSize <= 0 {
-		return nil, errors.New("batch size must be a positive integer")
+		return nil, invalidParam("batchSize", batchSize, "must be a positive integer")
+	}
+
+	if b.cachesWritable() {
+		// Fill the IDF cache upfront, so the batches scored concurrently only read from it
+		for _, q := range query {
+			_, _ = b.IDF(q)
+		}
 	}
 
 	var wg sync.WaitGroup
@@ -84,67 +90,50 @@ func (b *Bm25Base) GetBatchScoresBatched(query []string, docIDs []int, bm25 BM25
 		end := Min(start+batchSize, len(docIDs))
 		go func(start, end int) {
 			defer wg.Done()
+			sum := b.newTermSum(scores[start:end])
 			for _, q := range query {
+				if b.isStopword(q) {
+					continue
+				}
+
 				qFreq := make([]float64, end-start)
 				for j := start; j < end; j++ {
 					docID := docIDs[j]
-					if docID < 0 || docID >= b.corpusSize {
-						if b.logger != nil {
-							b.logger.Printf("Invalid document ID: %d", docID)
-						}
-						continue
-					}
-					doc := JoinTokens(b.corpus[docID], " ")
-					freq, err := CountTermFreq(q, doc, b.tokenizer)
-					if err != nil {
-						if b.logger != nil {
-							b.logger.Printf("Error counting term frequency for term '%s' in document %d: %v", q, docID, err)
-						}
-						continue
-					}
-					qFreq[j-start] = float64(freq)
+					qFreq[j-start] = b.termFrequency(docID, q)
 				}
 
-				idf, err := b.IDF(q)
+				idf, err := b.queryIDF(q)
 				if err != nil {
-					if b.logger != nil {
-						b.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-					}
+					b.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 					continue
 				}
 
 				for j := start; j < end; j++ {
-					docID := docIDs[j]
-					if docID < 0 || docID >= b.corpusSize {
-						continue
-					}
-					docLen := b.docLengths[docID]
-					k := computeK(bm25, docLen)
-					scores[j-start] += idf * computeScore(bm25, qFreq[j-start], k)
+					qFreq[j-start] = idf * computeScore(bm25, qFreq[j-start], computeK(bm25, b.docLengths[docIDs[j]]))
 				}
+				sum.add(qFreq)
 			}
 		}(start, end)
 	}
 
 	wg.Wait()
+	b.excludeExpired(scores, docIDs)
 	return scores, nil
 }
 
 // GetTopNBatched returns the top N documents for the given query using parallel computation with batching.
 func (b *Bm25Base) GetTopNBatched(query []string, n int, bm25 BM25, batchSize int) ([]string, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
 	if n <= 0 {
-		if b.logger != nil {
-			b.logger.Printf("Invalid value for n: %d. Returning empty slice.", n)
-		}
+		b.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
 		return []string{}, nil
 	}
 
 	if batchSize <= 0 {
-		return nil, errors.New("batch size must be a positive integer")
+		return nil, invalidParam("batchSize", batchSize, "must be a positive integer")
 	}
 
 	scores, err := b.GetScoresBatched(query, bm25, batchSize)
@@ -159,7 +148,11 @@ func (b *Bm25Base) GetTopNBatched(query []string, n int, bm25 BM25, batchSize in
 
 	topDocs := make([]string, len(topNIndices))
 	for i, idx := range topNIndices {
-		topDocs[i] = JoinTokens(b.corpus[idx], " ")
+		doc, err := b.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
 	}
 
 	return topDocs, nil
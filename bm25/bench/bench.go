@@ -0,0 +1,203 @@
+// Package bench benchmarks the BM25 variants on a corpus and a query sample, e.g. a
+// sample of the MS MARCO passage ranking dataset, and reports indexing throughput, query
+// latency percentiles and memory, so performance changes can be evaluated consistently.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/corpusio"
+)
+
+// ErrNoQueries is returned when a benchmark is run without queries.
+var ErrNoQueries = errors.New("at least one query is required")
+
+// Variant is a BM25 variant to benchmark, created on top of the shared index.
+type Variant struct {
+	Name string
+	New  func(base *bm25.Bm25Base) (bm25.BM25, error)
+}
+
+// DefaultVariants returns the Okapi, L, Plus, Adpt and T variants with the default values
+// of their parameters.
+func DefaultVariants() []Variant {
+	return []Variant{
+		{Name: "okapi", New: func(base *bm25.Bm25Base) (bm25.BM25, error) {
+			return bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+		}},
+		{Name: "l", New: func(base *bm25.Bm25Base) (bm25.BM25, error) {
+			return bm25.NewBM25LFromBase(base, 1.5, 0.75)
+		}},
+		{Name: "plus", New: func(base *bm25.Bm25Base) (bm25.BM25, error) {
+			return bm25.NewBM25PlusFromBase(base, 1.5, 0.75, 1, 0.25)
+		}},
+		{Name: "adpt", New: func(base *bm25.Bm25Base) (bm25.BM25, error) {
+			return bm25.NewBM25AdptFromBase(base, 1.5, 0.75, 0.5)
+		}},
+		{Name: "t", New: func(base *bm25.Bm25Base) (bm25.BM25, error) {
+			return bm25.NewBM25TFromBase(base, 1.5, 0.75, 0.5)
+		}},
+	}
+}
+
+// Config configures a benchmark run.
+type Config struct {
+	// Tokenizer tokenizes the documents. The queries are expected to be tokenized with it.
+	Tokenizer func(string) []string
+
+	// Variants are the variants to benchmark. Defaults to DefaultVariants.
+	Variants []Variant
+
+	// N is the number of results per query. Defaults to 10.
+	N int
+
+	// Rounds is the number of times every query is run per variant. Defaults to 1.
+	Rounds int
+}
+
+// Report holds the results of a benchmark run.
+type Report struct {
+	Docs          int           `json:"docs"`
+	Tokens        int           `json:"tokens"`
+	IndexTime     time.Duration `json:"indexTime"`
+	DocsPerSecond float64       `json:"docsPerSecond"`
+
+	// IndexBytes is the estimated size of the index, see bm25.MemStats, and HeapBytes the
+	// growth of the live heap while it was built.
+	IndexBytes int64  `json:"indexBytes"`
+	HeapBytes  uint64 `json:"heapBytes"`
+
+	Variants []VariantReport `json:"variants"`
+}
+
+// VariantReport holds the query latencies of a variant.
+type VariantReport struct {
+	Name    string        `json:"name"`
+	Queries int           `json:"queries"`
+	P50     time.Duration `json:"p50"`
+	P90     time.Duration `json:"p90"`
+	P99     time.Duration `json:"p99"`
+	Max     time.Duration `json:"max"`
+	QPS     float64       `json:"qps"`
+}
+
+// Run indexes the documents, then runs the queries against every variant, one at a time.
+func Run(ctx context.Context, docs corpusio.Reader, queries [][]string, cfg Config) (*Report, error) {
+	if cfg.Tokenizer == nil {
+		return nil, bm25.ErrNilTokenizer
+	}
+	if len(queries) == 0 {
+		return nil, ErrNoQueries
+	}
+	variants := cfg.Variants
+	if len(variants) == 0 {
+		variants = DefaultVariants()
+	}
+	n := cfg.N
+	if n <= 0 {
+		n = 10
+	}
+	rounds := max(1, cfg.Rounds)
+
+	heapBefore := liveHeap()
+	start := time.Now()
+	builder, err := bm25.NewBuilder(cfg.Tokenizer, nil, bm25.BuildOptions{})
+	if err != nil {
+		return nil, err
+	}
+	count, err := corpusio.Load(docs, builder)
+	if err != nil {
+		return nil, fmt.Errorf("indexing: %w", err)
+	}
+	base, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("indexing: %w", err)
+	}
+
+	report := &Report{Docs: count, IndexTime: time.Since(start)}
+	report.DocsPerSecond = float64(count) / report.IndexTime.Seconds()
+	for _, length := range base.DocLengths() {
+		report.Tokens += length
+	}
+	report.IndexBytes = base.MemStats().TotalBytes
+	if heapAfter := liveHeap(); heapAfter > heapBefore {
+		report.HeapBytes = heapAfter - heapBefore
+	}
+
+	for _, variant := range variants {
+		index, err := variant.New(base)
+		if err != nil {
+			return nil, fmt.Errorf("creating variant %s: %w", variant.Name, err)
+		}
+
+		latencies := make([]time.Duration, 0, len(queries)*rounds)
+		for round := 0; round < rounds; round++ {
+			for _, query := range queries {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+
+				queryStart := time.Now()
+				if _, err := index.Search(ctx, bm25.SearchRequest{Query: query, N: n}); err != nil {
+					return nil, fmt.Errorf("variant %s, query %q: %w", variant.Name, query, err)
+				}
+				latencies = append(latencies, time.Since(queryStart))
+			}
+		}
+		report.Variants = append(report.Variants, newVariantReport(variant.Name, latencies))
+	}
+	return report, nil
+}
+
+// newVariantReport summarizes the query latencies of a variant.
+func newVariantReport(name string, latencies []time.Duration) VariantReport {
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+
+	return VariantReport{
+		Name:    name,
+		Queries: len(latencies),
+		P50:     percentile(latencies, 50),
+		P90:     percentile(latencies, 90),
+		P99:     percentile(latencies, 99),
+		Max:     latencies[len(latencies)-1],
+		QPS:     float64(len(latencies)) / total.Seconds(),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(0, rank-1)]
+}
+
+// liveHeap returns the size of the live heap after a garbage collection.
+func liveHeap() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// WriteText writes the report as a human-readable table.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Indexed %d documents (%d tokens) in %v, %.0f docs/s\n", r.Docs, r.Tokens, r.IndexTime.Round(time.Millisecond), r.DocsPerSecond)
+	fmt.Fprintf(tw, "Index size: %d bytes estimated, %d bytes heap\n\n", r.IndexBytes, r.HeapBytes)
+	fmt.Fprintln(tw, "variant\tqueries\tp50\tp90\tp99\tmax\tqps")
+	for _, v := range r.Variants {
+		fmt.Fprintf(tw, "%s\t%d\t%v\t%v\t%v\t%v\t%.0f\n", v.Name, v.Queries, v.P50, v.P90, v.P99, v.Max, v.QPS)
+	}
+	return tw.Flush()
+}
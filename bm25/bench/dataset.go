@@ -0,0 +1,152 @@
+package bench
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// TSVReader reads documents from a tab-separated file without a header row, with the
+// document ID in the first column and the text in the second, like the collection.tsv
+// and queries.tsv files of MS MARCO. Unlike corpusio.CSVReader, it does not interpret
+// quotes, which appear unbalanced in such files.
+type TSVReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewTSVReader creates a new TSVReader.
+func NewTSVReader(r io.Reader) *TSVReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &TSVReader{scanner: scanner}
+}
+
+// Next returns the next document.
+func (r *TSVReader) Next() (bm25.Document, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := strings.TrimRight(r.scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		id, text, ok := strings.Cut(line, "\t")
+		if !ok {
+			return bm25.Document{}, fmt.Errorf("line %d: missing tab between ID and text", r.line)
+		}
+		return bm25.Document{ID: id, Text: text}, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return bm25.Document{}, err
+	}
+	return bm25.Document{}, io.EOF
+}
+
+// ReadQueries reads up to limit queries, or all if limit is not positive, from a
+// tab-separated file in the format of TSVReader, and tokenizes them.
+func ReadQueries(r io.Reader, tokenizer func(string) []string, limit int) ([][]string, error) {
+	reader := NewTSVReader(r)
+	var queries [][]string
+	for limit <= 0 || len(queries) < limit {
+		doc, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if query := tokenizer(doc.Text); len(query) > 0 {
+			queries = append(queries, query)
+		}
+	}
+	return queries, nil
+}
+
+// Fetch downloads the file at url into dir, unless it has been downloaded before, and
+// returns its path. The file is named after the last element of the URL path.
+func Fetch(ctx context.Context, client *http.Client, url, dir string) (string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	name := path.Base(strings.SplitN(url, "?", 2)[0])
+	if name == "/" || name == "." {
+		return "", fmt.Errorf("cannot name the file of %s", url)
+	}
+	target := filepath.Join(dir, name)
+	if _, err := os.Stat(target); err == nil {
+		return target, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+
+	// Download to a temporary file first, so an interrupted download is not mistaken for
+	// a complete one
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("downloading %s: %w", url, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// Open opens a dataset file, decompressing it if its name ends in .gz.
+func Open(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(name, ".gz") {
+		return f, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: gz, file: f}, nil
+}
+
+// gzipFile closes both the gzip reader and the underlying file.
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	return errors.Join(g.Reader.Close(), g.file.Close())
+}
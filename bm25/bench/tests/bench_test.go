@@ -0,0 +1,161 @@
+package bench_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/bench"
+)
+
+const collection = "0\tthe quick brown fox\n1\tjumps over the \"lazy dog\n\n2\ta quick test\n"
+
+func TestTSVReader(t *testing.T) {
+	// Test case: Reading a headerless TSV file with an unbalanced quote
+	reader := bench.NewTSVReader(strings.NewReader(collection))
+	var docs []bm25.Document
+	for {
+		doc, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		docs = append(docs, doc)
+	}
+	expected := []bm25.Document{
+		{ID: "0", Text: "the quick brown fox"},
+		{ID: "1", Text: "jumps over the \"lazy dog"},
+		{ID: "2", Text: "a quick test"},
+	}
+	if !reflect.DeepEqual(docs, expected) {
+		t.Errorf("Expected %v, but got %v", expected, docs)
+	}
+
+	// Test case: A line without a tab
+	_, err := bench.NewTSVReader(strings.NewReader("no tab\n")).Next()
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected an error for line 1, but got %v", err)
+	}
+
+	// Test case: Reading a limited number of queries
+	queries, err := bench.ReadQueries(strings.NewReader(collection), strings.Fields, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(queries) != 2 || queries[1][0] != "jumps" {
+		t.Errorf("Expected 2 queries, but got %v", queries)
+	}
+}
+
+func TestRun(t *testing.T) {
+	queries := [][]string{{"quick"}, {"lazy", "fox"}}
+
+	// Test case: Running without queries
+	_, err := bench.Run(context.Background(), bench.NewTSVReader(strings.NewReader(collection)), nil, bench.Config{Tokenizer: strings.Fields})
+	if !errors.Is(err, bench.ErrNoQueries) {
+		t.Errorf("Expected ErrNoQueries, but got %v", err)
+	}
+
+	// Test case: Benchmarking all default variants
+	report, err := bench.Run(context.Background(), bench.NewTSVReader(strings.NewReader(collection)), queries, bench.Config{Tokenizer: strings.Fields, Rounds: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Docs != 3 || report.Tokens != 12 {
+		t.Errorf("Expected 3 documents and 12 tokens, but got %d and %d", report.Docs, report.Tokens)
+	}
+	if report.IndexBytes <= 0 {
+		t.Errorf("Expected a positive index size, but got %d", report.IndexBytes)
+	}
+	if len(report.Variants) != len(bench.DefaultVariants()) {
+		t.Fatalf("Expected %d variant reports, but got %d", len(bench.DefaultVariants()), len(report.Variants))
+	}
+	for _, v := range report.Variants {
+		if v.Queries != 6 {
+			t.Errorf("Expected 6 queries for %s, but got %d", v.Name, v.Queries)
+		}
+		if v.P50 > v.P90 || v.P90 > v.P99 || v.P99 > v.Max {
+			t.Errorf("Expected ordered percentiles for %s, but got %v", v.Name, v)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteText(&out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Indexed 3 documents") || !strings.Contains(out.String(), "okapi") {
+		t.Errorf("Unexpected report:\n%s", out.String())
+	}
+
+	// Test case: A canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = bench.Run(ctx, bench.NewTSVReader(strings.NewReader(collection)), queries, bench.Config{Tokenizer: strings.Fields})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, but got %v", err)
+	}
+}
+
+func TestFetchAndOpen(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(collection))
+	gz.Close()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/collection.tsv.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	// Test case: Downloading a file twice only fetches it once
+	for i := 0; i < 2; i++ {
+		path, err := bench.Fetch(context.Background(), nil, server.URL+"/collection.tsv.gz", dir)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if path != filepath.Join(dir, "collection.tsv.gz") {
+			t.Errorf("Unexpected path %s", path)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request, but got %d", requests)
+	}
+
+	// Test case: Opening a gzipped file
+	f, err := bench.Open(filepath.Join(dir, "collection.tsv.gz"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil || string(data) != collection {
+		t.Errorf("Expected the decompressed collection, but got %q, %v", data, err)
+	}
+
+	// Test case: A failed download leaves no file behind
+	if _, err := bench.Fetch(context.Background(), nil, server.URL+"/missing.tsv", dir); err == nil {
+		t.Errorf("Expected an error, but got nil")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.tsv")); !os.IsNotExist(err) {
+		t.Errorf("Expected no file, but got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+// Package bleveio migrates token statistics between Bleve indexes and BM25 indexes, so a
+// corpus indexed by one does not have to be crawled again for the other.
+//
+// The package does not depend on Bleve. Import reads a field of a Bleve index through
+// the FieldReader interface, which a few lines around a Bleve index.IndexReader
+// implement:
+//
+//	func (r bleveField) Terms() ([]string, error) {
+//		dict, err := r.reader.FieldDict(r.field)
+//		// Collect entry.Term of every dict.Next() until it returns nil
+//	}
+//
+//	func (r bleveField) Postings(term string) ([]bleveio.Posting, error) {
+//		tfr, err := r.reader.TermFieldReader(ctx, []byte(term), r.field, true, false, true)
+//		// For every tfr.Next(nil): r.reader.ExternalID(doc.ID), doc.Freq and the
+//		// Pos of doc.Vectors
+//	}
+//
+// Export writes documents to anything with the Index method of bleve.Index and
+// *bleve.Batch.
+package bleveio
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// ErrEmptyIndex is returned when importing a field without any postings.
+var ErrEmptyIndex = errors.New("field has no postings")
+
+// Posting is an occurrence of a term in a document of a Bleve index.
+type Posting struct {
+	// DocID is the external ID of the document.
+	DocID string
+
+	// Freq is the number of occurrences of the term in the document.
+	Freq int
+
+	// Positions are the 1-based positions of the occurrences, if the field was indexed
+	// with term vectors. They restore the order of the tokens of the document.
+	Positions []int
+}
+
+// FieldReader reads the inverted index of a field.
+type FieldReader interface {
+	// Terms returns the terms of the field dictionary.
+	Terms() ([]string, error)
+
+	// Postings returns the documents containing the term.
+	Postings(term string) ([]Posting, error)
+}
+
+// positionedToken is a token of an imported document.
+type positionedToken struct {
+	term     string
+	position int // Zero if unknown
+}
+
+// Import builds an index from the postings of a field. The tokens of every document are
+// restored in their original order if positions are available; otherwise the terms of a
+// document are grouped, which yields the same BM25 statistics. Documents are added in
+// the order of their IDs, which become their external IDs. The tokenizer is used for
+// queries and documents added later, so it should match the analyzer of the field.
+func Import(r FieldReader, tokenizer func(string) []string, logger *log.Logger) (*bm25.Bm25Base, error) {
+	terms, err := r.Terms()
+	if err != nil {
+		return nil, fmt.Errorf("reading terms: %w", err)
+	}
+
+	docs := make(map[string][]positionedToken)
+	for _, term := range terms {
+		postings, err := r.Postings(term)
+		if err != nil {
+			return nil, fmt.Errorf("reading postings of %q: %w", term, err)
+		}
+
+		for _, posting := range postings {
+			for i := 0; i < posting.Freq; i++ {
+				token := positionedToken{term: term}
+				if i < len(posting.Positions) {
+					token.position = posting.Positions[i]
+				}
+				docs[posting.DocID] = append(docs[posting.DocID], token)
+			}
+		}
+	}
+	if len(docs) == 0 {
+		return nil, ErrEmptyIndex
+	}
+
+	ids := make([]string, 0, len(docs))
+	for id := range docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	builder, err := bm25.NewBuilder(tokenizer, logger, bm25.BuildOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		positioned := docs[id]
+		// Tokens without a position keep their term order, after the positioned ones
+		sort.SliceStable(positioned, func(i, j int) bool {
+			pi, pj := positioned[i].position, positioned[j].position
+			if pi == 0 || pj == 0 {
+				return pi != 0 && pj == 0
+			}
+			return pi < pj
+		})
+
+		tokens := make([]string, len(positioned))
+		for i, token := range positioned {
+			tokens[i] = token.term
+		}
+		if _, err := builder.AddTokens(tokens, bm25.Document{ID: id}); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Build()
+}
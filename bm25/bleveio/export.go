@@ -0,0 +1,49 @@
+package bleveio
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// Indexer indexes documents, like bleve.Index and *bleve.Batch do.
+type Indexer interface {
+	Index(id string, data interface{}) error
+}
+
+// Export indexes the documents of a BM25 index into a Bleve index or batch. The tokens
+// of every document are joined with spaces into the given field, so a field mapped to
+// Bleve's "whitespace" analyzer reproduces them exactly; the metadata of the document
+// is added as further fields. Documents are identified by their external ID, or by
+// their internal ID if they have none. Expired documents are skipped. It returns the
+// number of exported documents.
+func Export(base *bm25.Bm25Base, field string, dst Indexer) (int, error) {
+	exported := 0
+	for docID := 0; docID < base.CorpusSize(); docID++ {
+		if base.Expired(docID) {
+			continue
+		}
+
+		tokens, err := base.DocumentTokens(docID)
+		if err != nil {
+			return exported, err
+		}
+
+		data := make(map[string]interface{}, len(base.Metadata(docID))+1)
+		for key, value := range base.Metadata(docID) {
+			data[key] = value
+		}
+		data[field] = strings.Join(tokens, " ")
+
+		id := base.ExternalID(docID)
+		if id == "" {
+			id = strconv.Itoa(docID)
+		}
+		if err := dst.Index(id, data); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+	return exported, nil
+}
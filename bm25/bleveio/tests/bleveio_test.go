@@ -0,0 +1,117 @@
+package bleveio_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/bleveio"
+)
+
+// memIndex stands in for a Bleve index using the whitespace analyzer.
+type memIndex struct {
+	docs map[string]map[string]interface{}
+}
+
+func (m *memIndex) Index(id string, data interface{}) error {
+	m.docs[id] = data.(map[string]interface{})
+	return nil
+}
+
+// field returns a FieldReader over a field of the index, with or without positions.
+func (m *memIndex) field(name string, positions bool) memField {
+	postings := make(map[string][]bleveio.Posting)
+	for id, doc := range m.docs {
+		text, _ := doc[name].(string)
+		byTerm := make(map[string]*bleveio.Posting)
+		for i, term := range strings.Fields(text) {
+			if byTerm[term] == nil {
+				byTerm[term] = &bleveio.Posting{DocID: id}
+			}
+			byTerm[term].Freq++
+			if positions {
+				byTerm[term].Positions = append(byTerm[term].Positions, i+1)
+			}
+		}
+		for term, posting := range byTerm {
+			postings[term] = append(postings[term], *posting)
+		}
+	}
+	return memField{postings}
+}
+
+type memField struct {
+	postings map[string][]bleveio.Posting
+}
+
+func (f memField) Terms() ([]string, error) {
+	terms := make([]string, 0, len(f.postings))
+	for term := range f.postings {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	return terms, nil
+}
+
+func (f memField) Postings(term string) ([]bleveio.Posting, error) {
+	return f.postings[term], nil
+}
+
+func TestExportImport(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	builder, _ := bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{})
+	builder.Add(bm25.Document{ID: "a", Text: "the quick brown fox jumps", Metadata: map[string]any{"lang": "en"}})
+	builder.Add(bm25.Document{ID: "b", Text: "the lazy dog the end"})
+	builder.Add(bm25.Document{ID: "c", Text: "quick quick fox"})
+	base, _ := builder.Build()
+
+	// Test case: Exporting documents with their metadata
+	index := &memIndex{docs: make(map[string]map[string]interface{})}
+	n, err := bleveio.Export(base, "body", index)
+	if err != nil || n != 3 {
+		t.Fatalf("Expected 3 exported documents, but got %d (%v)", n, err)
+	}
+	if index.docs["a"]["body"] != "the quick brown fox jumps" || index.docs["a"]["lang"] != "en" {
+		t.Errorf("Unexpected exported document %v", index.docs["a"])
+	}
+
+	// Test case: Importing with positions restores the token order
+	imported, err := bleveio.Import(index.field("body", true), tokenizer, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for docID := 0; docID < base.CorpusSize(); docID++ {
+		want, _ := base.DocumentTokens(docID)
+		importedID, _ := imported.LookupID(base.ExternalID(docID))
+		got, _ := imported.DocumentTokens(importedID)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected tokens %v, but got %v", want, got)
+		}
+	}
+
+	// Test case: Importing without positions yields the same scores
+	imported, err = bleveio.Import(index.field("body", false), tokenizer, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	original, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	restored, _ := bm25.NewBM25OkapiFromBase(imported, 1.5, 0.75)
+	query := []string{"quick", "the"}
+	want, _ := original.GetScores(query)
+	got, _ := restored.GetScores(query)
+	for docID := range want {
+		importedID, _ := imported.LookupID(base.ExternalID(docID))
+		if math.Abs(got[importedID]-want[docID]) > 1e-9 {
+			t.Errorf("Expected score %f for document %s, but got %f", want[docID], base.ExternalID(docID), got[importedID])
+		}
+	}
+
+	// Test case: Importing an empty field
+	if _, err := bleveio.Import(index.field("title", true), tokenizer, nil); !errors.Is(err, bleveio.ErrEmptyIndex) {
+		t.Errorf("Expected ErrEmptyIndex, but got %v", err)
+	}
+}
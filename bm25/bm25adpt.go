@@ -1,8 +1,9 @@
 package bm25
 
 import (
-	"errors"
+	"context"
 	"log"
+	"math"
 )
 
 // BM25Adpt is an implementation of the BM25Adpt variant.
@@ -15,20 +16,25 @@ type BM25Adpt struct {
 
 // NewBM25Adpt creates a new instance of the BM25Adpt struct.
 func NewBM25Adpt(corpus []string, tokenizer func(string) []string, k1 float64, b float64, delta float64, logger *log.Logger) (*BM25Adpt, error) {
-	if k1 < 0 {
-		return nil, errors.New("k1 must be non-negative")
+	if err := validateBM25AdptParams(k1, b, delta); err != nil {
+		return nil, err
 	}
 
-	if b < 0 || b > 1 {
-		return nil, errors.New("b must be between 0 and 1")
+	base, err := NewBM25Base(corpus, tokenizer, logger)
+	if err != nil {
+		return nil, err
 	}
 
-	if delta < 0 {
-		return nil, errors.New("delta must be non-negative")
+	return NewBM25AdptFromBase(base, k1, b, delta)
+}
+
+// NewBM25AdptFromBase creates a new instance of the BM25Adpt struct on top of an existing Bm25Base.
+func NewBM25AdptFromBase(base *Bm25Base, k1 float64, b float64, delta float64) (*BM25Adpt, error) {
+	if base == nil {
+		return nil, ErrNilBase
 	}
 
-	base, err := NewBM25Base(corpus, tokenizer, logger)
-	if err != nil {
+	if err := validateBM25AdptParams(k1, b, delta); err != nil {
 		return nil, err
 	}
 
@@ -40,101 +46,142 @@ func NewBM25Adpt(corpus []string, tokenizer func(string) []string, k1 float64, b
 	}, nil
 }
 
+// BM25AdptParamSpecs returns the specs of the parameters of the BM25Adpt variant.
+func BM25AdptParamSpecs() []ParamSpec {
+	return []ParamSpec{
+		k1ParamSpec,
+		bParamSpec,
+		{
+			Name:        "delta",
+			Default:     0.5,
+			Min:         0,
+			Max:         math.Inf(1),
+			Description: "Constant added to the contribution of every query term.",
+		},
+	}
+}
+
+// validateBM25AdptParams validates the parameters of the BM25Adpt variant.
+func validateBM25AdptParams(k1 float64, b float64, delta float64) error {
+	return validateSpecs(BM25AdptParamSpecs(), k1, b, delta)
+}
+
+// Params returns the parameters of the index.
+func (a *BM25Adpt) Params() map[string]float64 {
+	return map[string]float64{
+		"k1":    a.k1,
+		"b":     a.b,
+		"delta": a.delta,
+	}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (a *BM25Adpt) ParamSpecs() []ParamSpec {
+	return BM25AdptParamSpecs()
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params.
+func (a *BM25Adpt) withParams(params map[string]float64) (BM25, error) {
+	overridden, err := NewBM25AdptFromBase(a.Bm25Base, params["k1"], params["b"], params["delta"])
+	if err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
 // GetScores returns the BM25 scores for the given query.
 func (a *BM25Adpt) GetScores(query []string) ([]float64, error) {
+	return a.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (a *BM25Adpt) GetScoresInto(dst []float64, query []string) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
-	scores := make([]float64, a.corpusSize)
+	scores := a.scoreBuffer(dst)
 	for _, q := range query {
-		qFreq := make([]float64, a.corpusSize)
-		for i, doc := range a.corpus {
-			docStr := JoinTokens(doc, " ")
-			freq, _ := CountTermFreq(q, docStr, a.tokenizer) // Ignore the error for now
-			qFreq[i] = float64(freq)
+		if a.isStopword(q) {
+			continue
 		}
 
-		idf, err := a.IDF(q)
+		idf, err := a.queryIDF(q)
 		if err != nil {
-			if a.logger != nil {
-				a.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-			}
+			a.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 			continue
 		}
 
 		for i, docLen := range a.docLengths {
+			tf := a.termFrequency(i, q)
 			k := a.k1 * (1 - a.b + a.b*float64(docLen)/a.avgDocLen)
-			scores[i] += idf * (a.delta + (qFreq[i]*(1+k))/(qFreq[i]+k))
+			scores[i] += idf * (a.delta + (tf*(1+k))/(tf+k))
 		}
 	}
 
+	a.excludeExpired(scores, nil)
 	return scores, nil
 }
 
 // GetBatchScores returns the BM25 scores for the given query and a subset of documents.
 func (a *BM25Adpt) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
-	if len(docIDs) == 0 {
-		return nil, errors.New("document IDs cannot be empty")
+	if err := a.validateDocIDs(docIDs); err != nil {
+		return nil, err
 	}
 
 	scores := make([]float64, len(docIDs))
 	for _, q := range query {
+		if a.isStopword(q) {
+			continue
+		}
+
 		qFreq := make([]float64, len(docIDs))
 		for i, docID := range docIDs {
-			if docID < 0 || docID >= a.corpusSize {
-				if a.logger != nil {
-					a.logger.Printf("Invalid document ID: %d", docID)
-				}
-				continue
-			}
-			docStr := JoinTokens(a.corpus[docID], " ")
-			freq, _ := CountTermFreq(q, docStr, a.tokenizer) // Ignore the error for now
-			qFreq[i] = float64(freq)
+			qFreq[i] = a.termFrequency(docID, q)
 		}
 
-		idf, err := a.IDF(q)
+		idf, err := a.queryIDF(q)
 		if err != nil {
-			if a.logger != nil {
-				a.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-			}
+			a.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 			continue
 		}
 
 		for i, docID := range docIDs {
-			if docID < 0 || docID >= a.corpusSize {
-				continue
-			}
 			docLen := a.docLengths[docID]
 			k := a.k1 * (1 - a.b + a.b*float64(docLen)/a.avgDocLen)
 			scores[i] += idf * (a.delta + (qFreq[i]*(1+k))/(qFreq[i]+k))
 		}
 	}
 
+	a.excludeExpired(scores, docIDs)
 	return scores, nil
 }
 
 // GetTopN returns the top N documents for the given query.
 func (a *BM25Adpt) GetTopN(query []string, n int) ([]string, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
 	if n <= 0 {
-		if a.logger != nil {
-			a.logger.Printf("Invalid value for n: %d. Returning empty slice.", n)
-		}
+		a.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
 		return []string{}, nil
 	}
 
-	scores, err := a.GetScores(query)
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := a.GetScoresInto(*buf, query)
 	if err != nil {
 		return nil, err
 	}
+	*buf = scores
 
 	topNIndices, err := TopNIndices(scores, n)
 	if err != nil {
@@ -143,8 +190,37 @@ func (a *BM25Adpt) GetTopN(query []string, n int) ([]string, error) {
 
 	topDocs := make([]string, len(topNIndices))
 	for i, idx := range topNIndices {
-		topDocs[i] = JoinTokens(a.corpus[idx], " ")
+		doc, err := a.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
 	}
 
 	return topDocs, nil
 }
+
+// Search runs the given search request against the index.
+func (a *BM25Adpt) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return a.search(ctx, a, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (a *BM25Adpt) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return a.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the BM25Adpt instance.
+func (a *BM25Adpt) Clone() *BM25Adpt {
+	clone := *a
+	clone.Bm25Base = a.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the BM25Adpt instance that is safe for concurrent use.
+func (a *BM25Adpt) Freeze() *BM25Adpt {
+	frozen := *a
+	frozen.Bm25Base = a.Bm25Base.Freeze()
+	return &frozen
+}
@@ -0,0 +1,325 @@
+package bm25
+
+import (
+	"context"
+	"log"
+	"math"
+)
+
+// maxAdaptiveK1 bounds the k1 fitted per term by BM25AdptK1 and BM25TK1.
+const maxAdaptiveK1 = 10
+
+// BM25AdptK1 is an implementation of BM25-adpt by Lv and Zhai, which derives k1 per term
+// from the information gain of its occurrences instead of using the same k1 for all
+// terms, unlike BM25Adpt. The gain of the r-th occurrence of a term, with frequencies
+// normalized by the document length, is how much more likely a document containing it r
+// times is to contain it once more, compared to containing it at all; k1 is fitted so the
+// saturation curve of the term follows the sums of its gains, and the gain of a single
+// occurrence replaces the IDF.
+//
+// Terms whose gains cannot be fitted, because too few documents contain them more than
+// once, fall back to the k1 of the index.
+type BM25AdptK1 struct {
+	*Bm25Base
+	k1 float64
+	b  float64
+}
+
+// NewBM25AdptK1 creates a new instance of the BM25AdptK1 struct.
+func NewBM25AdptK1(corpus []string, tokenizer func(string) []string, k1 float64, b float64, logger *log.Logger) (*BM25AdptK1, error) {
+	if err := validateBM25AdptK1Params(k1, b); err != nil {
+		return nil, err
+	}
+
+	base, err := NewBM25Base(corpus, tokenizer, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBM25AdptK1FromBase(base, k1, b)
+}
+
+// NewBM25AdptK1FromBase creates a new instance of the BM25AdptK1 struct on top of an existing Bm25Base.
+func NewBM25AdptK1FromBase(base *Bm25Base, k1 float64, b float64) (*BM25AdptK1, error) {
+	if base == nil {
+		return nil, ErrNilBase
+	}
+
+	if err := validateBM25AdptK1Params(k1, b); err != nil {
+		return nil, err
+	}
+
+	return &BM25AdptK1{
+		Bm25Base: base,
+		k1:       k1,
+		b:        b,
+	}, nil
+}
+
+// BM25AdptK1ParamSpecs returns the specs of the parameters of the BM25AdptK1 variant.
+func BM25AdptK1ParamSpecs() []ParamSpec {
+	k1 := k1ParamSpec
+	k1.Description = "Term frequency saturation of the terms whose k1 cannot be fitted."
+	return []ParamSpec{k1, bParamSpec}
+}
+
+// validateBM25AdptK1Params validates the parameters of the BM25AdptK1 variant.
+func validateBM25AdptK1Params(k1 float64, b float64) error {
+	return validateSpecs(BM25AdptK1ParamSpecs(), k1, b)
+}
+
+// Params returns the parameters of the index.
+func (a *BM25AdptK1) Params() map[string]float64 {
+	return map[string]float64{
+		"k1": a.k1,
+		"b":  a.b,
+	}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (a *BM25AdptK1) ParamSpecs() []ParamSpec {
+	return BM25AdptK1ParamSpecs()
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params.
+func (a *BM25AdptK1) withParams(params map[string]float64) (BM25, error) {
+	overridden, err := NewBM25AdptK1FromBase(a.Bm25Base, params["k1"], params["b"])
+	if err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
+// TermK1 returns the k1 fitted to the gains of a term, or the k1 of the index if it
+// cannot be fitted.
+func (a *BM25AdptK1) TermK1(term string) float64 {
+	_, k1, _ := a.termModel(term)
+	return k1
+}
+
+// normalizedFrequency returns the frequency of a term in a document of the given length,
+// normalized by the length.
+func (a *BM25AdptK1) normalizedFrequency(tf float64, docLen int) float64 {
+	return tf / (1 - a.b + a.b*float64(docLen)/a.avgDocLen)
+}
+
+// termModel returns the gain of a single occurrence of a term, its fitted k1, and false
+// if the term is in no document.
+func (a *BM25AdptK1) termModel(term string) (float64, float64, bool) {
+	docFreq, _ := a.docFreq(term)
+	if docFreq == 0 {
+		return 0, a.k1, false
+	}
+
+	// dfs[r] is the number of documents whose normalized frequency of the term rounds to
+	// at least r; every document counts for r = 0, and every document containing it for
+	// r = 1
+	dfs := []int{a.corpusSize, docFreq, 0}
+	for i, docLen := range a.docLengths {
+		tf := a.termFrequency(i, term)
+		if tf == 0 {
+			continue
+		}
+		ctd := a.normalizedFrequency(tf, docLen)
+		for r := 2; float64(r)-0.5 <= ctd; r++ {
+			if r+1 >= len(dfs) {
+				dfs = append(dfs, 0)
+			}
+			dfs[r]++
+		}
+	}
+
+	prior := math.Log2((float64(docFreq) + 0.5) / (float64(a.corpusSize) + 1))
+	gain := func(r int) float64 {
+		return math.Log2((float64(dfs[r+1])+0.5)/(float64(dfs[r])+1)) - prior
+	}
+	gain1 := gain(1)
+
+	// The gain of r occurrences is the sum of the gains of each of them. The gains are
+	// estimated while at least two documents reach the frequency, and the gain of a
+	// single occurrence always matches the curve, so k1 is only fitted with at least one
+	// more frequency
+	var ratios []float64
+	cumulative := gain1
+	for r := 2; r+1 < len(dfs) && dfs[r] > 1; r++ {
+		cumulative += gain(r)
+		ratios = append(ratios, cumulative/gain1)
+	}
+	if len(ratios) == 0 || gain1 == 0 {
+		return gain1, a.k1, true
+	}
+
+	return gain1, fitK1(ratios), true
+}
+
+// fitK1 returns the k1 in [0, maxAdaptiveK1] for which the saturation curve
+// (k1+1)r/(k1+r) is closest to the given ratios of the gain of r occurrences to the gain
+// of one, for r starting at 2, by least squares. The error is minimized with a
+// golden-section search.
+func fitK1(ratios []float64) float64 {
+	loss := func(k1 float64) float64 {
+		var sum float64
+		for i, ratio := range ratios {
+			r := float64(i + 2)
+			diff := ratio - (k1+1)*r/(k1+r)
+			sum += diff * diff
+		}
+		return sum
+	}
+
+	invPhi := (math.Sqrt(5) - 1) / 2
+	lo, hi := 0.0, float64(maxAdaptiveK1)
+	x1, x2 := hi-invPhi*(hi-lo), lo+invPhi*(hi-lo)
+	f1, f2 := loss(x1), loss(x2)
+	for hi-lo > 1e-6 {
+		if f1 <= f2 {
+			hi, x2, f2 = x2, x1, f1
+			x1 = hi - invPhi*(hi-lo)
+			f1 = loss(x1)
+		} else {
+			lo, x1, f1 = x1, x2, f2
+			x2 = lo + invPhi*(hi-lo)
+			f2 = loss(x2)
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// termScore returns the score of a term occurring tf times in a document of the given
+// length, given the gain and the k1 of the term.
+func (a *BM25AdptK1) termScore(tf float64, docLen int, gain float64, k1 float64) float64 {
+	if tf == 0 {
+		return 0
+	}
+	ctd := a.normalizedFrequency(tf, docLen)
+	return gain * (k1 + 1) * ctd / (k1 + ctd)
+}
+
+// GetScores returns the BM25 scores for the given query.
+func (a *BM25AdptK1) GetScores(query []string) ([]float64, error) {
+	return a.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (a *BM25AdptK1) GetScoresInto(dst []float64, query []string) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	scores := a.scoreBuffer(dst)
+	for _, q := range query {
+		if a.isStopword(q) {
+			continue
+		}
+
+		gain, k1, ok := a.termModel(q)
+		if !ok {
+			a.logf(LogDebug, "Term '%s' is not in the corpus", q)
+			continue
+		}
+		gain *= a.termWeight(q)
+
+		for i, docLen := range a.docLengths {
+			scores[i] += a.termScore(a.termFrequency(i, q), docLen, gain, k1)
+		}
+	}
+
+	a.excludeExpired(scores, nil)
+	return scores, nil
+}
+
+// GetBatchScores returns the BM25 scores for the given query and a subset of documents.
+func (a *BM25AdptK1) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if err := a.validateDocIDs(docIDs); err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(docIDs))
+	for _, q := range query {
+		if a.isStopword(q) {
+			continue
+		}
+
+		gain, k1, ok := a.termModel(q)
+		if !ok {
+			a.logf(LogDebug, "Term '%s' is not in the corpus", q)
+			continue
+		}
+		gain *= a.termWeight(q)
+
+		for i, docID := range docIDs {
+			scores[i] += a.termScore(a.termFrequency(docID, q), a.docLengths[docID], gain, k1)
+		}
+	}
+
+	a.excludeExpired(scores, docIDs)
+	return scores, nil
+}
+
+// GetTopN returns the top N documents for the given query.
+func (a *BM25AdptK1) GetTopN(query []string, n int) ([]string, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if n <= 0 {
+		a.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
+		return []string{}, nil
+	}
+
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := a.GetScoresInto(*buf, query)
+	if err != nil {
+		return nil, err
+	}
+	*buf = scores
+
+	topNIndices, err := TopNIndices(scores, n)
+	if err != nil {
+		return nil, err
+	}
+
+	topDocs := make([]string, len(topNIndices))
+	for i, idx := range topNIndices {
+		doc, err := a.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
+	}
+
+	return topDocs, nil
+}
+
+// Search runs the given search request against the index.
+func (a *BM25AdptK1) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return a.search(ctx, a, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (a *BM25AdptK1) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return a.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the BM25AdptK1 instance.
+func (a *BM25AdptK1) Clone() *BM25AdptK1 {
+	clone := *a
+	clone.Bm25Base = a.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the BM25AdptK1 instance that is safe for concurrent use.
+func (a *BM25AdptK1) Freeze() *BM25AdptK1 {
+	frozen := *a
+	frozen.Bm25Base = a.Bm25Base.Freeze()
+	return &frozen
+}
@@ -0,0 +1,542 @@
+package bm25
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// BM25F is an implementation of the BM25F variant for documents made of several fields.
+// The term frequency in every field is normalized by the length of the field relative to
+// its average length, weighted, and summed before the saturation is applied, so a term in
+// a heavily weighted field, e.g. a title, counts as several occurrences in the body. Every
+// field has its own length normalization parameter b, which defaults to the b of the
+// index. Query terms can be restricted to a single field with the "field:term" syntax.
+type BM25F struct {
+	*Bm25Base
+	k1      float64
+	b       float64
+	fields  []string
+	weights []float64
+	fieldB  []float64
+
+	// fieldBounds holds, per document, the offsets of each field within its tokens, and
+	// avgFieldLengths the average length of every field. Both are never modified after
+	// construction and are shared between clones.
+	fieldBounds     [][]int
+	avgFieldLengths []float64
+
+	// analyzers holds the analyzer of every field, or nil for the fields tokenized with
+	// the tokenizer of the index. It is never modified after construction.
+	analyzers []*Analyzer
+}
+
+// FieldTerm is a query term, optionally restricted to a single field.
+type FieldTerm struct {
+	Field string // Empty for all fields
+	Term  string
+}
+
+// NewBM25F creates a new instance of the BM25F struct. Every document maps field names to
+// their text; weights lists the indexed fields with their weights. Fields that are
+// missing from weights are not indexed.
+func NewBM25F(corpus []map[string]string, tokenizer func(string) []string, weights map[string]float64, k1 float64, b float64, logger *log.Logger) (*BM25F, error) {
+	return NewBM25FWithAnalyzers(corpus, tokenizer, weights, nil, k1, b, logger)
+}
+
+// NewBM25FWithAnalyzers creates a new instance of the BM25F struct whose fields are
+// analyzed with their own analyzer, e.g. a keyword analyzer for tags and a stemming
+// analyzer for the body. Fields without an analyzer are tokenized with the tokenizer.
+// Queries have to be tokenized with ParseQuery, which applies the same analyzers.
+func NewBM25FWithAnalyzers(corpus []map[string]string, tokenizer func(string) []string, weights map[string]float64, analyzers map[string]Analyzer, k1 float64, b float64, logger *log.Logger) (*BM25F, error) {
+	if err := validateBM25FParams(k1, b, weights); err != nil {
+		return nil, err
+	}
+	for field, analyzer := range analyzers {
+		if _, ok := weights[field]; !ok {
+			return nil, invalidParam("analyzers", field, "is not an indexed field")
+		}
+		if err := analyzer.validate(); err != nil {
+			return nil, fmt.Errorf("analyzer for field %q: %w", field, err)
+		}
+	}
+
+	if len(corpus) == 0 {
+		return nil, ErrEmptyCorpus
+	}
+
+	builder, err := NewBuilder(tokenizer, logger, BuildOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	f := &BM25F{
+		k1:          k1,
+		b:           b,
+		fields:      make([]string, 0, len(weights)),
+		fieldBounds: make([][]int, len(corpus)),
+	}
+	for field := range weights {
+		f.fields = append(f.fields, field)
+	}
+	sort.Strings(f.fields)
+	for _, field := range f.fields {
+		f.weights = append(f.weights, weights[field])
+		f.fieldB = append(f.fieldB, b)
+	}
+	if len(analyzers) > 0 {
+		f.analyzers = make([]*Analyzer, len(f.fields))
+		for j, field := range f.fields {
+			if analyzer, ok := analyzers[field]; ok {
+				f.analyzers[j] = &analyzer
+			}
+		}
+	}
+
+	f.avgFieldLengths = make([]float64, len(f.fields))
+	for i, doc := range corpus {
+		bounds := make([]int, len(f.fields)+1)
+		var tokens []string
+		for j, field := range f.fields {
+			bounds[j] = len(tokens)
+			if text, ok := doc[field]; ok {
+				tokens = append(tokens, f.analyzeField(j, text, tokenizer)...)
+			}
+			f.avgFieldLengths[j] += float64(len(tokens) - bounds[j])
+		}
+		bounds[len(f.fields)] = len(tokens)
+
+		if _, err := builder.AddTokens(tokens, Document{}); err != nil {
+			return nil, err
+		}
+		f.fieldBounds[i] = bounds
+	}
+	for j := range f.avgFieldLengths {
+		f.avgFieldLengths[j] /= float64(len(corpus))
+	}
+
+	if f.Bm25Base, err = builder.Build(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// BM25FParamSpecs returns the specs of the parameters of the BM25F variant. The field
+// weights are validated separately, as their names depend on the corpus.
+func BM25FParamSpecs() []ParamSpec {
+	return []ParamSpec{k1ParamSpec, bParamSpec}
+}
+
+// validateBM25FParams validates the parameters of the BM25F variant.
+func validateBM25FParams(k1 float64, b float64, weights map[string]float64) error {
+	if err := validateSpecs(BM25FParamSpecs(), k1, b); err != nil {
+		return err
+	}
+
+	if len(weights) == 0 {
+		return invalidParam("weights", weights, "must contain at least one field")
+	}
+	for field, weight := range weights {
+		if field == "" || strings.Contains(field, ":") {
+			return invalidParam("weights", field, "must not contain empty field names or field names with colons")
+		}
+		if weight < 0 {
+			return invalidParam("weights."+field, weight, "must be non-negative")
+		}
+	}
+	return nil
+}
+
+// Params returns the parameters of the index, including the weight of every field as
+// "weights.<field>" and its length normalization as "b.<field>".
+func (f *BM25F) Params() map[string]float64 {
+	params := map[string]float64{
+		"k1": f.k1,
+		"b":  f.b,
+	}
+	for j, field := range f.fields {
+		params["weights."+field] = f.weights[j]
+		params["b."+field] = f.fieldB[j]
+	}
+	return params
+}
+
+// SetFieldB sets the length normalization parameter b of a single field, from none (0)
+// to full (1), overriding the b of the index for that field.
+func (f *BM25F) SetFieldB(field string, b float64) error {
+	if f.frozen {
+		return ErrFrozen
+	}
+
+	j := f.fieldIndex(field)
+	if j < 0 {
+		return invalidParam("field", field, "is not an indexed field")
+	}
+	spec := bParamSpec
+	spec.Name = "b." + field
+	if err := spec.Validate(b); err != nil {
+		return err
+	}
+
+	// Clones share the slice, so it is copied rather than modified in place
+	f.fieldB = append([]float64(nil), f.fieldB...)
+	f.fieldB[j] = b
+	return nil
+}
+
+// FieldAvgLen returns the average length of a field across the corpus, or 0 if it is not
+// an indexed field.
+func (f *BM25F) FieldAvgLen(field string) float64 {
+	j := f.fieldIndex(field)
+	if j < 0 {
+		return 0
+	}
+	return f.avgFieldLengths[j]
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (f *BM25F) ParamSpecs() []ParamSpec {
+	return BM25FParamSpecs()
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params. Overriding b overrides the b of every field.
+func (f *BM25F) withParams(params map[string]float64) (BM25, error) {
+	if err := validateSpecs(BM25FParamSpecs(), params["k1"], params["b"]); err != nil {
+		return nil, err
+	}
+
+	overridden := *f
+	overridden.k1 = params["k1"]
+	if params["b"] != f.b {
+		overridden.b = params["b"]
+		overridden.fieldB = make([]float64, len(f.fields))
+		for j := range overridden.fieldB {
+			overridden.fieldB[j] = params["b"]
+		}
+	}
+	return &overridden, nil
+}
+
+// Fields returns the names of the indexed fields, sorted alphabetically.
+func (f *BM25F) Fields() []string {
+	return append([]string(nil), f.fields...)
+}
+
+// ParseQuery tokenizes a query string in which clauses can be restricted to a field, e.g.
+// `title:rust body:"async runtime" tokio`. The tokens of a restricted clause are returned
+// as "field:term", the form GetScores understands. Prefixes that are not indexed field
+// names are treated as part of the query text.
+//
+// Clauses are analyzed like the fields they match. If the fields have different
+// analyzers that produce different tokens for an unrestricted clause, the clause is
+// matched in every field separately, with the tokens of that field, so its frequencies
+// are saturated per field rather than summed across the fields.
+func (f *BM25F) ParseQuery(query string) []string {
+	var tokens []string
+	for _, clause := range splitClauses(query) {
+		field, text, ok := strings.Cut(clause, ":")
+		if j := f.fieldIndex(field); ok && j >= 0 {
+			for _, token := range f.analyzeField(j, strings.Trim(text, `"`), f.tokenizer) {
+				tokens = append(tokens, field+":"+token)
+			}
+			continue
+		}
+
+		text = strings.Trim(clause, `"`)
+		perField, same := f.analyzeFields(text)
+		if same {
+			tokens = append(tokens, perField[0]...)
+			continue
+		}
+		for j, fieldTokens := range perField {
+			for _, token := range fieldTokens {
+				tokens = append(tokens, f.fields[j]+":"+token)
+			}
+		}
+	}
+	return tokens
+}
+
+// analyzeField returns the tokens of the text of the field with the given index.
+func (f *BM25F) analyzeField(j int, text string, tokenizer func(string) []string) []string {
+	if f.analyzers != nil && f.analyzers[j] != nil {
+		return f.analyzers[j].Analyze(text)
+	}
+	return tokenizer(text)
+}
+
+// analyzeFields returns the tokens of a query clause for every field, and whether they
+// are the same for all fields.
+func (f *BM25F) analyzeFields(text string) ([][]string, bool) {
+	if f.analyzers == nil {
+		return [][]string{f.tokenizer(text)}, true
+	}
+
+	perField := make([][]string, len(f.fields))
+	same := true
+	for j := range f.fields {
+		perField[j] = f.analyzeField(j, text, f.tokenizer)
+		same = same && slices.Equal(perField[j], perField[0])
+	}
+	return perField, same
+}
+
+// splitClauses splits a query string at whitespace outside of double quotes.
+func splitClauses(query string) []string {
+	var clauses []string
+	var sb strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			sb.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			if sb.Len() > 0 {
+				clauses = append(clauses, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() > 0 {
+		clauses = append(clauses, sb.String())
+	}
+	return clauses
+}
+
+// parseFieldTerm splits a "field:term" query token into its field and term. Tokens
+// without an indexed field prefix apply to all fields.
+func (f *BM25F) parseFieldTerm(token string) FieldTerm {
+	if field, term, ok := strings.Cut(token, ":"); ok && term != "" && f.fieldIndex(field) >= 0 {
+		return FieldTerm{Field: field, Term: term}
+	}
+	return FieldTerm{Term: token}
+}
+
+// fieldIndex returns the index of the given field, or -1 if it is not indexed.
+func (f *BM25F) fieldIndex(field string) int {
+	i := sort.SearchStrings(f.fields, field)
+	if i < len(f.fields) && f.fields[i] == field {
+		return i
+	}
+	return -1
+}
+
+// GetScores returns the BM25F scores for the given query. Query tokens of the form
+// "field:term" only match the term within that field.
+func (f *BM25F) GetScores(query []string) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	docIDs := make([]int, f.corpusSize)
+	for i := range docIDs {
+		docIDs[i] = i
+	}
+	scores := f.scoreDocs(query, docIDs)
+	f.excludeExpired(scores, nil)
+	return scores, nil
+}
+
+// GetBatchScores returns the BM25F scores for the given query and a subset of documents.
+func (f *BM25F) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if err := f.validateDocIDs(docIDs); err != nil {
+		return nil, err
+	}
+
+	scores := f.scoreDocs(query, docIDs)
+	f.excludeExpired(scores, docIDs)
+	return scores, nil
+}
+
+// GetFieldScores returns the BM25F scores for the given field terms.
+func (f *BM25F) GetFieldScores(terms []FieldTerm) ([]float64, error) {
+	query := make([]string, len(terms))
+	for i, term := range terms {
+		if term.Field != "" && f.fieldIndex(term.Field) < 0 {
+			return nil, invalidParam("field", term.Field, "is not an indexed field")
+		}
+		query[i] = term.Term
+		if term.Field != "" {
+			query[i] = term.Field + ":" + term.Term
+		}
+	}
+	return f.GetScores(query)
+}
+
+// scoreDocs computes the BM25F scores of the given documents.
+func (f *BM25F) scoreDocs(query []string, docIDs []int) []float64 {
+	scores := make([]float64, len(docIDs))
+	for _, token := range query {
+		q := f.parseFieldTerm(token)
+		if f.isStopword(q.Term) {
+			continue
+		}
+
+		idf, err := f.queryIDF(q.Term)
+		if err != nil {
+			f.logf(LogDebug, "Error calculating IDF for term '%s': %v", q.Term, err)
+			continue
+		}
+
+		field := -1
+		if q.Field != "" {
+			field = f.fieldIndex(q.Field)
+		}
+
+		for i, docID := range docIDs {
+			tf := f.saturate(f.weightedTermFreq(docID, q.Term, field))
+			if tf == 0 {
+				continue
+			}
+			scores[i] += idf * (tf * (f.k1 + 1)) / (tf + f.k1)
+		}
+	}
+	return scores
+}
+
+// termDocs returns the documents containing a query term, within its field if it is
+// field-scoped, or an empty set if the term is a stopword.
+func (f *BM25F) termDocs(token string) *Bitmap {
+	docs := NewBitmap(f.corpusSize)
+	q := f.parseFieldTerm(token)
+	if f.isStopword(q.Term) {
+		return docs
+	}
+	field := -1
+	if q.Field != "" {
+		field = f.fieldIndex(q.Field)
+	}
+	for docID := range f.corpus {
+		if f.weightedTermFreq(docID, q.Term, field) > 0 {
+			docs.Add(docID)
+		}
+	}
+	return docs
+}
+
+// explainTerm returns the IDF of the term of a query token and its frequency in a
+// document, within its field if it is field-scoped.
+func (f *BM25F) explainTerm(docID int, token string) (float64, int) {
+	doc, term := f.locateTerm(docID, token)
+	if term == "" {
+		return 0, 0
+	}
+	idf, _ := f.IDF(term)
+	return idf, countTokens(doc, term)
+}
+
+// locateTerm returns the tokens of a document a query token is matched against, which
+// are those of its field if it is field-scoped, and its term.
+func (f *BM25F) locateTerm(docID int, token string) ([]string, string) {
+	q := f.parseFieldTerm(token)
+	doc := f.corpus[docID]
+	if q.Field != "" {
+		bounds, j := f.fieldBounds[docID], f.fieldIndex(q.Field)
+		doc = doc[bounds[j]:bounds[j+1]]
+	}
+	return doc, q.Term
+}
+
+// weightedTermFreq returns the weighted, length-normalized frequency of the term in a
+// document, counting either all fields or only the field with the given index.
+func (f *BM25F) weightedTermFreq(docID int, term string, field int) float64 {
+	doc, bounds := f.corpus[docID], f.fieldBounds[docID]
+
+	var tf float64
+	for j := range f.fields {
+		if field >= 0 && j != field {
+			continue
+		}
+
+		fieldTF := countTokens(doc[bounds[j]:bounds[j+1]], term)
+		if fieldTF == 0 {
+			continue
+		}
+		norm := 1.0
+		if f.avgFieldLengths[j] > 0 {
+			norm = 1 - f.fieldB[j] + f.fieldB[j]*float64(bounds[j+1]-bounds[j])/f.avgFieldLengths[j]
+		}
+		tf += f.weights[j] * float64(fieldTF) / norm
+	}
+	return tf
+}
+
+// GetTopN returns the top N documents for the given query.
+func (f *BM25F) GetTopN(query []string, n int) ([]string, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if n <= 0 {
+		f.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
+		return []string{}, nil
+	}
+
+	scores, err := f.GetScores(query)
+	if err != nil {
+		return nil, err
+	}
+
+	topNIndices, err := TopNIndices(scores, n)
+	if err != nil {
+		return nil, err
+	}
+
+	topDocs := make([]string, len(topNIndices))
+	for i, idx := range topNIndices {
+		doc, err := f.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
+	}
+
+	return topDocs, nil
+}
+
+// Search runs the given search request against the index.
+func (f *BM25F) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return f.search(ctx, f, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (f *BM25F) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return f.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the BM25F instance.
+func (f *BM25F) Clone() *BM25F {
+	clone := *f
+	clone.Bm25Base = f.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the BM25F instance that is safe for concurrent use.
+func (f *BM25F) Freeze() *BM25F {
+	frozen := *f
+	frozen.Bm25Base = f.Bm25Base.Freeze()
+	return &frozen
+}
+
+// AddDocument is not supported by BM25F, whose documents are made of several fields.
+func (f *BM25F) AddDocument(doc Document) (int, error) {
+	return 0, ErrNotImplemented
+}
+
+// Compact is not supported by BM25F, as it would invalidate the field offsets.
+func (f *BM25F) Compact() (int, error) {
+	return 0, ErrNotImplemented
+}
@@ -1,7 +1,7 @@
 package bm25
 
 import (
-	"errors"
+	"context"
 	"log"
 )
 
@@ -14,12 +14,8 @@ type BM25L struct {
 
 // NewBM25L creates a new instance of the BM25L struct.
 func NewBM25L(corpus []string, tokenizer func(string) []string, k1 float64, b float64, logger *log.Logger) (*BM25L, error) {
-	if k1 < 0 {
-		return nil, errors.New("k1 must be non-negative")
-	}
-
-	if b < 0 || b > 1 {
-		return nil, errors.New("b must be between 0 and 1")
+	if err := validateBM25LParams(k1, b); err != nil {
+		return nil, err
 	}
 
 	base, err := NewBM25Base(corpus, tokenizer, logger)
@@ -27,6 +23,19 @@ func NewBM25L(corpus []string, tokenizer func(string) []string, k1 float64, b fl
 		return nil, err
 	}
 
+	return NewBM25LFromBase(base, k1, b)
+}
+
+// NewBM25LFromBase creates a new instance of the BM25L struct on top of an existing Bm25Base.
+func NewBM25LFromBase(base *Bm25Base, k1 float64, b float64) (*BM25L, error) {
+	if base == nil {
+		return nil, ErrNilBase
+	}
+
+	if err := validateBM25LParams(k1, b); err != nil {
+		return nil, err
+	}
+
 	return &BM25L{
 		Bm25Base: base,
 		k1:       k1,
@@ -34,101 +43,131 @@ func NewBM25L(corpus []string, tokenizer func(string) []string, k1 float64, b fl
 	}, nil
 }
 
+// BM25LParamSpecs returns the specs of the parameters of the BM25L variant.
+func BM25LParamSpecs() []ParamSpec {
+	return []ParamSpec{k1ParamSpec, bParamSpec}
+}
+
+// validateBM25LParams validates the parameters of the BM25L variant.
+func validateBM25LParams(k1 float64, b float64) error {
+	return validateSpecs(BM25LParamSpecs(), k1, b)
+}
+
+// Params returns the parameters of the index.
+func (l *BM25L) Params() map[string]float64 {
+	return map[string]float64{
+		"k1": l.k1,
+		"b":  l.b,
+	}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (l *BM25L) ParamSpecs() []ParamSpec {
+	return BM25LParamSpecs()
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params.
+func (l *BM25L) withParams(params map[string]float64) (BM25, error) {
+	overridden, err := NewBM25LFromBase(l.Bm25Base, params["k1"], params["b"])
+	if err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
 // GetScores returns the BM25 scores for the given query.
 func (l *BM25L) GetScores(query []string) ([]float64, error) {
+	return l.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (l *BM25L) GetScoresInto(dst []float64, query []string) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
-	scores := make([]float64, l.corpusSize)
+	scores := l.scoreBuffer(dst)
 	for _, q := range query {
-		qFreq := make([]float64, l.corpusSize)
-		for i, doc := range l.corpus {
-			docStr := JoinTokens(doc, " ")
-			freq, _ := CountTermFreq(q, docStr, l.tokenizer) // Ignore the error for now
-			qFreq[i] = float64(freq)
+		if l.isStopword(q) {
+			continue
 		}
 
-		idf, err := l.IDF(q)
+		idf, err := l.queryIDF(q)
 		if err != nil {
-			if l.logger != nil {
-				l.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-			}
+			l.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 			continue
 		}
 
 		for i, docLen := range l.docLengths {
+			tf := l.termFrequency(i, q)
 			k := l.k1 * (1 - l.b + l.b*float64(docLen)/l.avgDocLen)
-			scores[i] += idf * (qFreq[i] / (qFreq[i] + k))
+			scores[i] += idf * (tf / (tf + k))
 		}
 	}
 
+	l.excludeExpired(scores, nil)
 	return scores, nil
 }
 
 // GetBatchScores returns the BM25 scores for the given query and a subset of documents.
 func (l *BM25L) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
-	if len(docIDs) == 0 {
-		return nil, errors.New("document IDs cannot be empty")
+	if err := l.validateDocIDs(docIDs); err != nil {
+		return nil, err
 	}
 
 	scores := make([]float64, len(docIDs))
 	for _, q := range query {
+		if l.isStopword(q) {
+			continue
+		}
+
 		qFreq := make([]float64, len(docIDs))
 		for i, docID := range docIDs {
-			if docID < 0 || docID >= l.corpusSize {
-				if l.logger != nil {
-					l.logger.Printf("Invalid document ID: %d", docID)
-				}
-				continue
-			}
-			docStr := JoinTokens(l.corpus[docID], " ")
-			freq, _ := CountTermFreq(q, docStr, l.tokenizer) // Ignore the error for now
-			qFreq[i] = float64(freq)
+			qFreq[i] = l.termFrequency(docID, q)
 		}
 
-		idf, err := l.IDF(q)
+		idf, err := l.queryIDF(q)
 		if err != nil {
-			if l.logger != nil {
-				l.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-			}
+			l.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 			continue
 		}
 
 		for i, docID := range docIDs {
-			if docID < 0 || docID >= l.corpusSize {
-				continue
-			}
 			docLen := l.docLengths[docID]
 			k := l.k1 * (1 - l.b + l.b*float64(docLen)/l.avgDocLen)
 			scores[i] += idf * (qFreq[i] / (qFreq[i] + k))
 		}
 	}
 
+	l.excludeExpired(scores, docIDs)
 	return scores, nil
 }
 
 // GetTopN returns the top N documents for the given query.
 func (l *BM25L) GetTopN(query []string, n int) ([]string, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
 	if n <= 0 {
-		if l.logger != nil {
-			l.logger.Printf("Invalid value for n: %d. Returning empty slice.", n)
-		}
+		l.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
 		return []string{}, nil
 	}
 
-	scores, err := l.GetScores(query)
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := l.GetScoresInto(*buf, query)
 	if err != nil {
 		return nil, err
 	}
+	*buf = scores
 
 	topNIndices, err := TopNIndices(scores, n)
 	if err != nil {
@@ -137,8 +176,37 @@ func (l *BM25L) GetTopN(query []string, n int) ([]string, error) {
 
 	topDocs := make([]string, len(topNIndices))
 	for i, idx := range topNIndices {
-		topDocs[i] = JoinTokens(l.corpus[idx], " ")
+		doc, err := l.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
 	}
 
 	return topDocs, nil
 }
+
+// Search runs the given search request against the index.
+func (l *BM25L) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return l.search(ctx, l, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (l *BM25L) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return l.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the BM25L instance.
+func (l *BM25L) Clone() *BM25L {
+	clone := *l
+	clone.Bm25Base = l.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the BM25L instance that is safe for concurrent use.
+func (l *BM25L) Freeze() *BM25L {
+	frozen := *l
+	frozen.Bm25Base = l.Bm25Base.Freeze()
+	return &frozen
+}
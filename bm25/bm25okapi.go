@@ -1,7 +1,7 @@
 package bm25
 
 import (
-	"errors"
+	"context"
 	"log"
 )
 
@@ -14,12 +14,8 @@ type BM25Okapi struct {
 
 // NewBM25Okapi creates a new instance of the BM25Okapi struct.
 func NewBM25Okapi(corpus []string, tokenizer func(string) []string, k1 float64, b float64, logger *log.Logger) (*BM25Okapi, error) {
-	if k1 < 0 {
-		return nil, errors.New("k1 must be non-negative")
-	}
-
-	if b < 0 || b > 1 {
-		return nil, errors.New("b must be between 0 and 1")
+	if err := validateBM25OkapiParams(k1, b); err != nil {
+		return nil, err
 	}
 
 	base, err := NewBM25Base(corpus, tokenizer, logger)
@@ -27,6 +23,19 @@ func NewBM25Okapi(corpus []string, tokenizer func(string) []string, k1 float64,
 		return nil, err
 	}
 
+	return NewBM25OkapiFromBase(base, k1, b)
+}
+
+// NewBM25OkapiFromBase creates a new instance of the BM25Okapi struct on top of an existing Bm25Base.
+func NewBM25OkapiFromBase(base *Bm25Base, k1 float64, b float64) (*BM25Okapi, error) {
+	if base == nil {
+		return nil, ErrNilBase
+	}
+
+	if err := validateBM25OkapiParams(k1, b); err != nil {
+		return nil, err
+	}
+
 	return &BM25Okapi{
 		Bm25Base: base,
 		k1:       k1,
@@ -34,101 +43,149 @@ func NewBM25Okapi(corpus []string, tokenizer func(string) []string, k1 float64,
 	}, nil
 }
 
+// NewBM25OkapiWithEpsilon creates a new instance of the BM25Okapi struct that floors
+// negative IDF values at epsilon times the average IDF, like rank_bm25 does.
+func NewBM25OkapiWithEpsilon(corpus []string, tokenizer func(string) []string, k1 float64, b float64, epsilon float64, logger *log.Logger) (*BM25Okapi, error) {
+	if epsilon < 0 {
+		return nil, invalidParam("epsilon", epsilon, "must be non-negative")
+	}
+
+	okapi, err := NewBM25Okapi(corpus, tokenizer, k1, b, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := okapi.SetEpsilon(epsilon); err != nil {
+		return nil, err
+	}
+	return okapi, nil
+}
+
+// BM25OkapiParamSpecs returns the specs of the parameters of the BM25Okapi variant.
+func BM25OkapiParamSpecs() []ParamSpec {
+	return []ParamSpec{k1ParamSpec, bParamSpec}
+}
+
+// validateBM25OkapiParams validates the parameters of the BM25Okapi variant.
+func validateBM25OkapiParams(k1 float64, b float64) error {
+	return validateSpecs(BM25OkapiParamSpecs(), k1, b)
+}
+
+// Params returns the parameters of the index.
+func (o *BM25Okapi) Params() map[string]float64 {
+	return map[string]float64{
+		"k1": o.k1,
+		"b":  o.b,
+	}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (o *BM25Okapi) ParamSpecs() []ParamSpec {
+	return BM25OkapiParamSpecs()
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params.
+func (o *BM25Okapi) withParams(params map[string]float64) (BM25, error) {
+	overridden, err := NewBM25OkapiFromBase(o.Bm25Base, params["k1"], params["b"])
+	if err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
 // GetScores returns the BM25 scores for the given query.
 func (o *BM25Okapi) GetScores(query []string) ([]float64, error) {
+	return o.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (o *BM25Okapi) GetScoresInto(dst []float64, query []string) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
-	scores := make([]float64, o.corpusSize)
+	scores := o.scoreBuffer(dst)
 	for _, q := range query {
-		qFreq := make([]float64, o.corpusSize)
-		for i, doc := range o.corpus {
-			docStr := JoinTokens(doc, " ")
-			freq, _ := CountTermFreq(q, docStr, o.tokenizer) // Ignore the error for now
-			qFreq[i] = float64(freq)
+		if o.isStopword(q) {
+			continue
 		}
 
-		idf, err := o.IDF(q)
+		idf, err := o.queryIDF(q)
 		if err != nil {
-			if o.logger != nil {
-				o.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-			}
+			o.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 			continue
 		}
 
 		for i, docLen := range o.docLengths {
+			tf := o.termFrequency(i, q)
 			k := o.k1 * (1 - o.b + o.b*float64(docLen)/o.avgDocLen)
-			scores[i] += idf * ((qFreq[i] * (o.k1 + 1)) / (qFreq[i] + k))
+			scores[i] += idf * ((tf * (o.k1 + 1)) / (tf + k))
 		}
 	}
 
+	o.excludeExpired(scores, nil)
 	return scores, nil
 }
 
 // GetBatchScores returns the BM25 scores for the given query and a subset of documents.
 func (o *BM25Okapi) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
-	if len(docIDs) == 0 {
-		return nil, errors.New("document IDs cannot be empty")
+	if err := o.validateDocIDs(docIDs); err != nil {
+		return nil, err
 	}
 
 	scores := make([]float64, len(docIDs))
 	for _, q := range query {
+		if o.isStopword(q) {
+			continue
+		}
+
 		qFreq := make([]float64, len(docIDs))
 		for i, docID := range docIDs {
-			if docID < 0 || docID >= o.corpusSize {
-				if o.logger != nil {
-					o.logger.Printf("Invalid document ID: %d", docID)
-				}
-				continue
-			}
-			docStr := JoinTokens(o.corpus[docID], " ")
-			freq, _ := CountTermFreq(q, docStr, o.tokenizer) // Ignore the error for now
-			qFreq[i] = float64(freq)
+			qFreq[i] = o.termFrequency(docID, q)
 		}
 
-		idf, err := o.IDF(q)
+		idf, err := o.queryIDF(q)
 		if err != nil {
-			if o.logger != nil {
-				o.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-			}
+			o.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 			continue
 		}
 
 		for i, docID := range docIDs {
-			if docID < 0 || docID >= o.corpusSize {
-				continue
-			}
 			docLen := o.docLengths[docID]
 			k := o.k1 * (1 - o.b + o.b*float64(docLen)/o.avgDocLen)
-			scores[i] += idf * (qFreq[i] / (qFreq[i] + k))
+			scores[i] += idf * ((qFreq[i] * (o.k1 + 1)) / (qFreq[i] + k))
 		}
 	}
 
+	o.excludeExpired(scores, docIDs)
 	return scores, nil
 }
 
 // GetTopN returns the top N documents for the given query.
 func (o *BM25Okapi) GetTopN(query []string, n int) ([]string, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
 	if n <= 0 {
-		if o.logger != nil {
-			o.logger.Printf("Invalid value for n: %d. Returning empty slice.", n)
-		}
+		o.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
 		return []string{}, nil
 	}
 
-	scores, err := o.GetScores(query)
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := o.GetScoresInto(*buf, query)
 	if err != nil {
 		return nil, err
 	}
+	*buf = scores
 
 	topNIndices, err := TopNIndices(scores, n)
 	if err != nil {
@@ -137,8 +194,37 @@ func (o *BM25Okapi) GetTopN(query []string, n int) ([]string, error) {
 
 	topDocs := make([]string, len(topNIndices))
 	for i, idx := range topNIndices {
-		topDocs[i] = JoinTokens(o.corpus[idx], " ")
+		doc, err := o.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
 	}
 
 	return topDocs, nil
 }
+
+// Search runs the given search request against the index.
+func (o *BM25Okapi) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return o.search(ctx, o, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (o *BM25Okapi) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return o.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the BM25Okapi instance.
+func (o *BM25Okapi) Clone() *BM25Okapi {
+	clone := *o
+	clone.Bm25Base = o.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the BM25Okapi instance that is safe for concurrent use.
+func (o *BM25Okapi) Freeze() *BM25Okapi {
+	frozen := *o
+	frozen.Bm25Base = o.Bm25Base.Freeze()
+	return &frozen
+}
@@ -1,8 +1,9 @@
 package bm25
 
 import (
-	"errors"
+	"context"
 	"log"
+	"math"
 )
 
 // BM25Plus is an implementation of the BM25Plus variant.
@@ -16,24 +17,25 @@ type BM25Plus struct {
 
 // NewBM25Plus creates a new instance of the BM25Plus struct.
 func NewBM25Plus(corpus []string, tokenizer func(string) []string, k1 float64, b float64, delta float64, epsilon float64, logger *log.Logger) (*BM25Plus, error) {
-	if k1 < 0 {
-		return nil, errors.New("k1 must be non-negative")
+	if err := validateBM25PlusParams(k1, b, delta, epsilon); err != nil {
+		return nil, err
 	}
 
-	if b < 0 || b > 1 {
-		return nil, errors.New("b must be between 0 and 1")
+	base, err := NewBM25Base(corpus, tokenizer, logger)
+	if err != nil {
+		return nil, err
 	}
 
-	if delta < 0 {
-		return nil, errors.New("delta must be non-negative")
-	}
+	return NewBM25PlusFromBase(base, k1, b, delta, epsilon)
+}
 
-	if epsilon < 0 {
-		return nil, errors.New("epsilon must be non-negative")
+// NewBM25PlusFromBase creates a new instance of the BM25Plus struct on top of an existing Bm25Base.
+func NewBM25PlusFromBase(base *Bm25Base, k1 float64, b float64, delta float64, epsilon float64) (*BM25Plus, error) {
+	if base == nil {
+		return nil, ErrNilBase
 	}
 
-	base, err := NewBM25Base(corpus, tokenizer, logger)
-	if err != nil {
+	if err := validateBM25PlusParams(k1, b, delta, epsilon); err != nil {
 		return nil, err
 	}
 
@@ -46,101 +48,150 @@ func NewBM25Plus(corpus []string, tokenizer func(string) []string, k1 float64, b
 	}, nil
 }
 
+// BM25PlusParamSpecs returns the specs of the parameters of the BM25Plus variant.
+func BM25PlusParamSpecs() []ParamSpec {
+	return []ParamSpec{
+		k1ParamSpec,
+		bParamSpec,
+		{
+			Name:        "delta",
+			Default:     1.0,
+			Min:         0,
+			Max:         math.Inf(1),
+			Description: "Lower bound added to the contribution of every query term, so long documents are not over-penalized.",
+		},
+		{
+			Name:        "epsilon",
+			Default:     0.25,
+			Min:         0,
+			Max:         math.Inf(1),
+			Description: "Reserved for flooring negative IDF values; not used by the BM25Plus scoring.",
+		},
+	}
+}
+
+// validateBM25PlusParams validates the parameters of the BM25Plus variant.
+func validateBM25PlusParams(k1 float64, b float64, delta float64, epsilon float64) error {
+	return validateSpecs(BM25PlusParamSpecs(), k1, b, delta, epsilon)
+}
+
+// Params returns the parameters of the index.
+func (p *BM25Plus) Params() map[string]float64 {
+	return map[string]float64{
+		"k1":      p.k1,
+		"b":       p.b,
+		"delta":   p.delta,
+		"epsilon": p.epsilon,
+	}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (p *BM25Plus) ParamSpecs() []ParamSpec {
+	return BM25PlusParamSpecs()
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params.
+func (p *BM25Plus) withParams(params map[string]float64) (BM25, error) {
+	overridden, err := NewBM25PlusFromBase(p.Bm25Base, params["k1"], params["b"], params["delta"], params["epsilon"])
+	if err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
 // GetScores returns the BM25 scores for the given query.
 func (p *BM25Plus) GetScores(query []string) ([]float64, error) {
+	return p.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (p *BM25Plus) GetScoresInto(dst []float64, query []string) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
-	scores := make([]float64, p.corpusSize)
+	scores := p.scoreBuffer(dst)
 	for _, q := range query {
-		qFreq := make([]float64, p.corpusSize)
-		for i, doc := range p.corpus {
-			docStr := JoinTokens(doc, " ")
-			freq, _ := CountTermFreq(q, docStr, p.tokenizer) // Ignore the error for now
-			qFreq[i] = float64(freq)
+		if p.isStopword(q) {
+			continue
 		}
 
-		idf, err := p.IDF(q)
+		idf, err := p.queryIDF(q)
 		if err != nil {
-			if p.logger != nil {
-				p.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-			}
+			p.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 			continue
 		}
 
 		for i, docLen := range p.docLengths {
+			tf := p.termFrequency(i, q)
 			k := p.k1 * (1 - p.b + p.b*float64(docLen)/p.avgDocLen)
-			scores[i] += idf * (p.delta + (qFreq[i] / (qFreq[i] + k)))
+			scores[i] += idf * (p.delta + (tf / (tf + k)))
 		}
 	}
 
+	p.excludeExpired(scores, nil)
 	return scores, nil
 }
 
 // GetBatchScores returns the BM25 scores for the given query and a subset of documents.
 func (p *BM25Plus) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
-	if len(docIDs) == 0 {
-		return nil, errors.New("document IDs cannot be empty")
+	if err := p.validateDocIDs(docIDs); err != nil {
+		return nil, err
 	}
 
 	scores := make([]float64, len(docIDs))
 	for _, q := range query {
+		if p.isStopword(q) {
+			continue
+		}
+
 		qFreq := make([]float64, len(docIDs))
 		for i, docID := range docIDs {
-			if docID < 0 || docID >= p.corpusSize {
-				if p.logger != nil {
-					p.logger.Printf("Invalid document ID: %d", docID)
-				}
-				continue
-			}
-			docStr := JoinTokens(p.corpus[docID], " ")
-			freq, _ := CountTermFreq(q, docStr, p.tokenizer) // Ignore the error for now
-			qFreq[i] = float64(freq)
+			qFreq[i] = p.termFrequency(docID, q)
 		}
 
-		idf, err := p.IDF(q)
+		idf, err := p.queryIDF(q)
 		if err != nil {
-			if p.logger != nil {
-				p.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-			}
+			p.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 			continue
 		}
 
 		for i, docID := range docIDs {
-			if docID < 0 || docID >= p.corpusSize {
-				continue
-			}
 			docLen := p.docLengths[docID]
 			k := p.k1 * (1 - p.b + p.b*float64(docLen)/p.avgDocLen)
 			scores[i] += idf * (p.delta + (qFreq[i] / (qFreq[i] + k)))
 		}
 	}
 
+	p.excludeExpired(scores, docIDs)
 	return scores, nil
 }
 
 // GetTopN returns the top N documents for the given query.
 func (p *BM25Plus) GetTopN(query []string, n int) ([]string, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
 	if n <= 0 {
-		if p.logger != nil {
-			p.logger.Printf("Invalid value for n: %d. Returning empty slice.", n)
-		}
+		p.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
 		return []string{}, nil
 	}
 
-	scores, err := p.GetScores(query)
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := p.GetScoresInto(*buf, query)
 	if err != nil {
 		return nil, err
 	}
+	*buf = scores
 
 	topNIndices, err := TopNIndices(scores, n)
 	if err != nil {
@@ -149,8 +200,37 @@ func (p *BM25Plus) GetTopN(query []string, n int) ([]string, error) {
 
 	topDocs := make([]string, len(topNIndices))
 	for i, idx := range topNIndices {
-		topDocs[i] = JoinTokens(p.corpus[idx], " ")
+		doc, err := p.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
 	}
 
 	return topDocs, nil
 }
+
+// Search runs the given search request against the index.
+func (p *BM25Plus) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return p.search(ctx, p, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (p *BM25Plus) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return p.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the BM25Plus instance.
+func (p *BM25Plus) Clone() *BM25Plus {
+	clone := *p
+	clone.Bm25Base = p.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the BM25Plus instance that is safe for concurrent use.
+func (p *BM25Plus) Freeze() *BM25Plus {
+	frozen := *p
+	frozen.Bm25Base = p.Bm25Base.Freeze()
+	return &frozen
+}
@@ -1,8 +1,9 @@
 package bm25
 
 import (
-	"errors"
+	"context"
 	"log"
+	"math"
 )
 
 // BM25T is an implementation of the BM25T variant.
@@ -15,20 +16,25 @@ type BM25T struct {
 
 // NewBM25T creates a new instance of the BM25T struct.
 func NewBM25T(corpus []string, tokenizer func(string) []string, k1 float64, b float64, delta float64, logger *log.Logger) (*BM25T, error) {
-	if k1 < 0 {
-		return nil, errors.New("k1 must be non-negative")
+	if err := validateBM25TParams(k1, b, delta); err != nil {
+		return nil, err
 	}
 
-	if b < 0 || b > 1 {
-		return nil, errors.New("b must be between 0 and 1")
+	base, err := NewBM25Base(corpus, tokenizer, logger)
+	if err != nil {
+		return nil, err
 	}
 
-	if delta < 0 {
-		return nil, errors.New("delta must be non-negative")
+	return NewBM25TFromBase(base, k1, b, delta)
+}
+
+// NewBM25TFromBase creates a new instance of the BM25T struct on top of an existing Bm25Base.
+func NewBM25TFromBase(base *Bm25Base, k1 float64, b float64, delta float64) (*BM25T, error) {
+	if base == nil {
+		return nil, ErrNilBase
 	}
 
-	base, err := NewBM25Base(corpus, tokenizer, logger)
-	if err != nil {
+	if err := validateBM25TParams(k1, b, delta); err != nil {
 		return nil, err
 	}
 
@@ -40,101 +46,142 @@ func NewBM25T(corpus []string, tokenizer func(string) []string, k1 float64, b fl
 	}, nil
 }
 
+// BM25TParamSpecs returns the specs of the parameters of the BM25T variant.
+func BM25TParamSpecs() []ParamSpec {
+	return []ParamSpec{
+		k1ParamSpec,
+		bParamSpec,
+		{
+			Name:        "delta",
+			Default:     0.5,
+			Min:         0,
+			Max:         math.Inf(1),
+			Description: "Constant added to the contribution of every query term.",
+		},
+	}
+}
+
+// validateBM25TParams validates the parameters of the BM25T variant.
+func validateBM25TParams(k1 float64, b float64, delta float64) error {
+	return validateSpecs(BM25TParamSpecs(), k1, b, delta)
+}
+
+// Params returns the parameters of the index.
+func (t *BM25T) Params() map[string]float64 {
+	return map[string]float64{
+		"k1":    t.k1,
+		"b":     t.b,
+		"delta": t.delta,
+	}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (t *BM25T) ParamSpecs() []ParamSpec {
+	return BM25TParamSpecs()
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params.
+func (t *BM25T) withParams(params map[string]float64) (BM25, error) {
+	overridden, err := NewBM25TFromBase(t.Bm25Base, params["k1"], params["b"], params["delta"])
+	if err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
 // GetScores returns the BM25 scores for the given query.
 func (t *BM25T) GetScores(query []string) ([]float64, error) {
+	return t.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (t *BM25T) GetScoresInto(dst []float64, query []string) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
-	scores := make([]float64, t.corpusSize)
+	scores := t.scoreBuffer(dst)
 	for _, q := range query {
-		qFreq := make([]float64, t.corpusSize)
-		for i, doc := range t.corpus {
-			docStr := JoinTokens(doc, " ")
-			freq, _ := CountTermFreq(q, docStr, t.tokenizer) // Ignore the error for now
-			qFreq[i] = float64(freq)
+		if t.isStopword(q) {
+			continue
 		}
 
-		idf, err := t.IDF(q)
+		idf, err := t.queryIDF(q)
 		if err != nil {
-			if t.logger != nil {
-				t.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-			}
+			t.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 			continue
 		}
 
 		for i, docLen := range t.docLengths {
+			tf := t.termFrequency(i, q)
 			k := t.k1 * (1 - t.b + t.b*float64(docLen)/t.avgDocLen)
-			scores[i] += idf * (t.delta + (qFreq[i]*(1+k))/(qFreq[i]+k))
+			scores[i] += idf * (t.delta + (tf*(1+k))/(tf+k))
 		}
 	}
 
+	t.excludeExpired(scores, nil)
 	return scores, nil
 }
 
 // GetBatchScores returns the BM25 scores for the given query and a subset of documents.
 func (t *BM25T) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
-	if len(docIDs) == 0 {
-		return nil, errors.New("document IDs cannot be empty")
+	if err := t.validateDocIDs(docIDs); err != nil {
+		return nil, err
 	}
 
 	scores := make([]float64, len(docIDs))
 	for _, q := range query {
+		if t.isStopword(q) {
+			continue
+		}
+
 		qFreq := make([]float64, len(docIDs))
 		for i, docID := range docIDs {
-			if docID < 0 || docID >= t.corpusSize {
-				if t.logger != nil {
-					t.logger.Printf("Invalid document ID: %d", docID)
-				}
-				continue
-			}
-			docStr := JoinTokens(t.corpus[docID], " ")
-			freq, _ := CountTermFreq(q, docStr, t.tokenizer) // Ignore the error for now
-			qFreq[i] = float64(freq)
+			qFreq[i] = t.termFrequency(docID, q)
 		}
 
-		idf, err := t.IDF(q)
+		idf, err := t.queryIDF(q)
 		if err != nil {
-			if t.logger != nil {
-				t.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-			}
+			t.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 			continue
 		}
 
 		for i, docID := range docIDs {
-			if docID < 0 || docID >= t.corpusSize {
-				continue
-			}
 			docLen := t.docLengths[docID]
 			k := t.k1 * (1 - t.b + t.b*float64(docLen)/t.avgDocLen)
 			scores[i] += idf * (t.delta + (qFreq[i]*(1+k))/(qFreq[i]+k))
 		}
 	}
 
+	t.excludeExpired(scores, docIDs)
 	return scores, nil
 }
 
 // GetTopN returns the top N documents for the given query.
 func (t *BM25T) GetTopN(query []string, n int) ([]string, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
 	if n <= 0 {
-		if t.logger != nil {
-			t.logger.Printf("Invalid value for n: %d. Returning empty slice.", n)
-		}
+		t.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
 		return []string{}, nil
 	}
 
-	scores, err := t.GetScores(query)
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := t.GetScoresInto(*buf, query)
 	if err != nil {
 		return nil, err
 	}
+	*buf = scores
 
 	topNIndices, err := TopNIndices(scores, n)
 	if err != nil {
@@ -143,8 +190,37 @@ func (t *BM25T) GetTopN(query []string, n int) ([]string, error) {
 
 	topDocs := make([]string, len(topNIndices))
 	for i, idx := range topNIndices {
-		topDocs[i] = JoinTokens(t.corpus[idx], " ")
+		doc, err := t.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
 	}
 
 	return topDocs, nil
 }
+
+// Search runs the given search request against the index.
+func (t *BM25T) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return t.search(ctx, t, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (t *BM25T) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return t.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the BM25T instance.
+func (t *BM25T) Clone() *BM25T {
+	clone := *t
+	clone.Bm25Base = t.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the BM25T instance that is safe for concurrent use.
+func (t *BM25T) Freeze() *BM25T {
+	frozen := *t
+	frozen.Bm25Base = t.Bm25Base.Freeze()
+	return &frozen
+}
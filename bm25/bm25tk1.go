@@ -0,0 +1,272 @@
+package bm25
+
+import (
+	"context"
+	"log"
+	"math"
+)
+
+// BM25TK1 is an implementation of BM25T by Lv and Zhai, which derives k1 per term from
+// the log-logistic distribution of its frequencies in the documents containing it,
+// instead of using the same k1 for all terms, unlike BM25T. k1 is chosen so that the mean
+// of log(1 + tf) over those documents, with frequencies normalized by the document
+// length, equals k1 log(k1) / (k1 - 1), which is its expected value under a log-logistic
+// distribution with that k1.
+type BM25TK1 struct {
+	*Bm25Base
+	b float64
+}
+
+// NewBM25TK1 creates a new instance of the BM25TK1 struct.
+func NewBM25TK1(corpus []string, tokenizer func(string) []string, b float64, logger *log.Logger) (*BM25TK1, error) {
+	if err := validateBM25TK1Params(b); err != nil {
+		return nil, err
+	}
+
+	base, err := NewBM25Base(corpus, tokenizer, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBM25TK1FromBase(base, b)
+}
+
+// NewBM25TK1FromBase creates a new instance of the BM25TK1 struct on top of an existing Bm25Base.
+func NewBM25TK1FromBase(base *Bm25Base, b float64) (*BM25TK1, error) {
+	if base == nil {
+		return nil, ErrNilBase
+	}
+
+	if err := validateBM25TK1Params(b); err != nil {
+		return nil, err
+	}
+
+	return &BM25TK1{
+		Bm25Base: base,
+		b:        b,
+	}, nil
+}
+
+// BM25TK1ParamSpecs returns the specs of the parameters of the BM25TK1 variant.
+func BM25TK1ParamSpecs() []ParamSpec {
+	return []ParamSpec{bParamSpec}
+}
+
+// validateBM25TK1Params validates the parameters of the BM25TK1 variant.
+func validateBM25TK1Params(b float64) error {
+	return validateSpecs(BM25TK1ParamSpecs(), b)
+}
+
+// Params returns the parameters of the index.
+func (t *BM25TK1) Params() map[string]float64 {
+	return map[string]float64{
+		"b": t.b,
+	}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (t *BM25TK1) ParamSpecs() []ParamSpec {
+	return BM25TK1ParamSpecs()
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params.
+func (t *BM25TK1) withParams(params map[string]float64) (BM25, error) {
+	overridden, err := NewBM25TK1FromBase(t.Bm25Base, params["b"])
+	if err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
+// TermK1 returns the k1 of a term, or 0 if the term is in no document.
+func (t *BM25TK1) TermK1(term string) float64 {
+	return t.termK1(term)
+}
+
+// normalizedFrequency returns the frequency of a term in a document of the given length,
+// normalized by the length.
+func (t *BM25TK1) normalizedFrequency(tf float64, docLen int) float64 {
+	return tf / (1 - t.b + t.b*float64(docLen)/t.avgDocLen)
+}
+
+// termK1 returns the k1 of a term, or 0 if the term is in no document.
+func (t *BM25TK1) termK1(term string) float64 {
+	var sum float64
+	var elite int
+	for i, docLen := range t.docLengths {
+		tf := t.termFrequency(i, term)
+		if tf == 0 {
+			continue
+		}
+		sum += math.Log1p(t.normalizedFrequency(tf, docLen))
+		elite++
+	}
+	if elite == 0 {
+		return 0
+	}
+	return solveLogLogisticK1(sum / float64(elite))
+}
+
+// solveLogLogisticK1 returns the k1 in (0, maxAdaptiveK1] for which k1 log(k1) / (k1 - 1)
+// equals the given mean, by bisection, as the function increases with k1.
+func solveLogLogisticK1(mean float64) float64 {
+	g := func(k1 float64) float64 {
+		if k1 == 1 {
+			return 1
+		}
+		return k1 * math.Log(k1) / (k1 - 1)
+	}
+
+	lo, hi := 0.0, float64(maxAdaptiveK1)
+	if g(hi) <= mean {
+		return hi
+	}
+	for hi-lo > 1e-9 {
+		mid := (lo + hi) / 2
+		if g(mid) < mean {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// termScore returns the score of a term occurring tf times in a document of the given
+// length, given the IDF and the k1 of the term.
+func (t *BM25TK1) termScore(tf float64, docLen int, idf float64, k1 float64) float64 {
+	if tf == 0 {
+		return 0
+	}
+	ctd := t.normalizedFrequency(tf, docLen)
+	return idf * (k1 + 1) * ctd / (k1 + ctd)
+}
+
+// GetScores returns the BM25 scores for the given query.
+func (t *BM25TK1) GetScores(query []string) ([]float64, error) {
+	return t.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (t *BM25TK1) GetScoresInto(dst []float64, query []string) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	scores := t.scoreBuffer(dst)
+	for _, q := range query {
+		if t.isStopword(q) {
+			continue
+		}
+
+		idf, err := t.queryIDF(q)
+		if err != nil {
+			t.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
+			continue
+		}
+		k1 := t.termK1(q)
+
+		for i, docLen := range t.docLengths {
+			scores[i] += t.termScore(t.termFrequency(i, q), docLen, idf, k1)
+		}
+	}
+
+	t.excludeExpired(scores, nil)
+	return scores, nil
+}
+
+// GetBatchScores returns the BM25 scores for the given query and a subset of documents.
+func (t *BM25TK1) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if err := t.validateDocIDs(docIDs); err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(docIDs))
+	for _, q := range query {
+		if t.isStopword(q) {
+			continue
+		}
+
+		idf, err := t.queryIDF(q)
+		if err != nil {
+			t.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
+			continue
+		}
+		k1 := t.termK1(q)
+
+		for i, docID := range docIDs {
+			scores[i] += t.termScore(t.termFrequency(docID, q), t.docLengths[docID], idf, k1)
+		}
+	}
+
+	t.excludeExpired(scores, docIDs)
+	return scores, nil
+}
+
+// GetTopN returns the top N documents for the given query.
+func (t *BM25TK1) GetTopN(query []string, n int) ([]string, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if n <= 0 {
+		t.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
+		return []string{}, nil
+	}
+
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := t.GetScoresInto(*buf, query)
+	if err != nil {
+		return nil, err
+	}
+	*buf = scores
+
+	topNIndices, err := TopNIndices(scores, n)
+	if err != nil {
+		return nil, err
+	}
+
+	topDocs := make([]string, len(topNIndices))
+	for i, idx := range topNIndices {
+		doc, err := t.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
+	}
+
+	return topDocs, nil
+}
+
+// Search runs the given search request against the index.
+func (t *BM25TK1) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return t.search(ctx, t, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (t *BM25TK1) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return t.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the BM25TK1 instance.
+func (t *BM25TK1) Clone() *BM25TK1 {
+	clone := *t
+	clone.Bm25Base = t.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the BM25TK1 instance that is safe for concurrent use.
+func (t *BM25TK1) Freeze() *BM25TK1 {
+	frozen := *t
+	frozen.Bm25Base = t.Bm25Base.Freeze()
+	return &frozen
+}
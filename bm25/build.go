@@ -0,0 +1,284 @@
+package bm25
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// defaultProgressInterval is the default number of documents between progress callbacks.
+const defaultProgressInterval = 1000
+
+// BuildOptions configures the construction of a Bm25Base.
+type BuildOptions struct {
+	// Workers is the number of goroutines used to tokenize the corpus and accumulate its
+	// statistics. Values below 2 build the index on the calling goroutine.
+	Workers int
+
+	// Progress, if set, is called periodically while the corpus is being indexed. Calls
+	// are serialized, even when building with multiple workers.
+	Progress func(BuildProgress)
+
+	// ProgressInterval is the number of documents each worker processes between progress
+	// callbacks. Defaults to 1000.
+	ProgressInterval int
+
+	// Extractor, if set, extracts the plain text of every document before it is
+	// tokenized, e.g. HTMLExtractor or MarkdownExtractor.
+	Extractor Extractor
+
+	// LanguageAnalyzers, if set, are the analyzers of the documents tagged with a language,
+	// see SetLanguageAnalyzers. They apply to the documents added by a Builder or with
+	// AddDocument, as the strings of a plain corpus have no language.
+	LanguageAnalyzers map[string]Analyzer
+
+	// LanguageDetector, if set, detects the language of the documents without a language
+	// tag, see SetLanguageDetector.
+	LanguageDetector LanguageDetector
+
+	// Schema, if set, is the schema the metadata of the documents added by a Builder or
+	// with AddDocument is validated against, see SetSchema.
+	Schema *Schema
+
+	// IngestHooks, if set, transform, enrich or reject the documents added by a Builder or
+	// with AddDocument before they are tokenized, see OnIngest.
+	IngestHooks []IngestHook
+}
+
+// BuildProgress reports the progress of an index construction.
+type BuildProgress struct {
+	DocsProcessed int
+	TotalDocs     int
+	TokensSeen    int
+	Elapsed       time.Duration
+}
+
+// progressReporter aggregates the progress of all build workers and forwards it to the
+// progress callback. A nil progressReporter discards all updates.
+type progressReporter struct {
+	mu       sync.Mutex
+	fn       func(BuildProgress)
+	start    time.Time
+	interval int
+	progress BuildProgress
+}
+
+// newProgressReporter creates a progressReporter for the given options, or returns nil
+// if no progress callback is configured.
+func newProgressReporter(opts BuildOptions, totalDocs int) *progressReporter {
+	if opts.Progress == nil {
+		return nil
+	}
+
+	interval := opts.ProgressInterval
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+
+	return &progressReporter{
+		fn:       opts.Progress,
+		start:    time.Now(),
+		interval: interval,
+		progress: BuildProgress{TotalDocs: totalDocs},
+	}
+}
+
+// add records the given number of processed documents and tokens and reports the new totals.
+func (r *progressReporter) add(docs, tokens int) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.DocsProcessed += docs
+	r.progress.TokensSeen += tokens
+	r.progress.Elapsed = time.Since(r.start)
+	r.fn(r.progress)
+}
+
+// buildShard holds the statistics accumulated by one worker over a range of documents.
+type buildShard struct {
+	start, end  int
+	indexer     *tokenIndexer
+	totalDocLen int
+	err         error
+}
+
+// tokenIndexer copies tokenized documents into the index and counts their document frequencies.
+type tokenIndexer struct {
+	// Intern the tokens and copy them into an arena to keep GC pressure low on large corpora
+	arena      tokenArena
+	strs       interner
+	seenTokens map[string]struct{}
+	termFreqs  map[string]int
+}
+
+// newTokenIndexer creates a tokenIndexer counting document frequencies into termFreqs.
+func newTokenIndexer(termFreqs map[string]int) *tokenIndexer {
+	return &tokenIndexer{
+		strs:       make(interner),
+		seenTokens: make(map[string]struct{}),
+		termFreqs:  termFreqs,
+	}
+}
+
+// index returns an interned, arena-backed copy of the tokens of a document and counts
+// each of its distinct terms once.
+func (ix *tokenIndexer) index(tokens []string) []string {
+	docTokens := ix.arena.alloc(len(tokens))
+	for j, token := range tokens {
+		docTokens[j] = ix.strs.intern(token)
+	}
+
+	// Use a map or set to ensure each term is only counted once per document
+	clear(ix.seenTokens)
+	for _, token := range docTokens {
+		if _, seen := ix.seenTokens[token]; !seen {
+			ix.termFreqs[token]++
+			ix.seenTokens[token] = struct{}{}
+		}
+	}
+	return docTokens
+}
+
+// NewBM25BaseWithOptions creates a new instance of the Bm25Base struct using the given build options.
+func NewBM25BaseWithOptions(corpus []string, tokenizer func(string) []string, logger *log.Logger, opts BuildOptions) (*Bm25Base, error) {
+	if len(corpus) == 0 {
+		return nil, ErrEmptyCorpus
+	}
+
+	if tokenizer == nil {
+		return nil, ErrNilTokenizer
+	}
+
+	base := &Bm25Base{
+		corpus:     make([][]string, len(corpus)),
+		docLengths: make([]int, len(corpus)),
+		termFreqs:  make(map[string]int),
+		idfCache:   make(map[string]float64),
+		tokenizer:  tokenizer,
+		logger:     logger,
+	}
+	if err := base.SetLanguageAnalyzers(opts.LanguageAnalyzers); err != nil {
+		return nil, err
+	}
+	base.detector = opts.LanguageDetector
+	if err := base.SetSchema(opts.Schema); err != nil {
+		return nil, err
+	}
+	base.ingestHooks = slices.Clone(opts.IngestHooks)
+
+	workers := max(1, min(opts.Workers, len(corpus)))
+	shardSize := (len(corpus) + workers - 1) / workers
+	shards := make([]*buildShard, 0, workers)
+	for start := 0; start < len(corpus); start += shardSize {
+		shards = append(shards, &buildShard{
+			start:   start,
+			end:     Min(start+shardSize, len(corpus)),
+			indexer: newTokenIndexer(make(map[string]int)),
+		})
+	}
+	if len(shards) == 1 {
+		shards[0].indexer.termFreqs = base.termFreqs
+	}
+
+	progress := newProgressReporter(opts, len(corpus))
+	base.forEachShard(shards, func(shard *buildShard) {
+		base.tokenizeShard(corpus, shard, progress, opts.Extractor)
+	})
+
+	// Report the error of the lowest failing document, independent of scheduling
+	var totalDocLen int
+	for _, shard := range shards {
+		if shard.err != nil {
+			return nil, shard.err
+		}
+		totalDocLen += shard.totalDocLen
+	}
+
+	if len(shards) > 1 {
+		base.mergeShards(shards)
+	}
+
+	base.corpusSize = len(corpus)
+	base.avgDocLen = float64(totalDocLen) / float64(base.corpusSize)
+
+	if base.logger != nil {
+		base.logger.Printf("Corpus size: %d, Average document length: %.2f", base.corpusSize, base.avgDocLen)
+	}
+
+	return base, nil
+}
+
+// forEachShard runs fn for every shard, concurrently if there is more than one.
+func (b *Bm25Base) forEachShard(shards []*buildShard, fn func(shard *buildShard)) {
+	if len(shards) == 1 {
+		fn(shards[0])
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(shards))
+	for _, shard := range shards {
+		go func(shard *buildShard) {
+			defer wg.Done()
+			fn(shard)
+		}(shard)
+	}
+	wg.Wait()
+}
+
+// tokenizeShard tokenizes the documents of a shard and accumulates their statistics.
+func (b *Bm25Base) tokenizeShard(corpus []string, shard *buildShard, progress *progressReporter, extractor Extractor) {
+	var pendingDocs, pendingTokens int
+	defer func() {
+		if pendingDocs > 0 {
+			progress.add(pendingDocs, pendingTokens)
+		}
+	}()
+
+	for i := shard.start; i < shard.end; i++ {
+		tokens := b.tokenizer(extract(extractor, corpus[i]))
+		if len(tokens) == 0 {
+			shard.err = fmt.Errorf("tokenizer function returned an empty slice for document at index %d: %w", i, ErrEmptyDocument)
+			return
+		}
+		docTokens := shard.indexer.index(tokens)
+		b.corpus[i] = docTokens
+		b.docLengths[i] = len(docTokens)
+		shard.totalDocLen += len(docTokens)
+
+		pendingDocs++
+		pendingTokens += len(docTokens)
+		if progress != nil && pendingDocs >= progress.interval {
+			progress.add(pendingDocs, pendingTokens)
+			pendingDocs, pendingTokens = 0, 0
+		}
+	}
+}
+
+// mergeShards merges the document frequencies of all shards into the base and rewrites
+// the tokens of every shard to a single canonical copy of each term.
+func (b *Bm25Base) mergeShards(shards []*buildShard) {
+	canonical := make(map[string]string)
+	for _, shard := range shards {
+		for term, termFreq := range shard.indexer.termFreqs {
+			if _, ok := canonical[term]; !ok {
+				canonical[term] = term
+			}
+			b.termFreqs[canonical[term]] += termFreq
+		}
+		shard.indexer = nil
+	}
+
+	b.forEachShard(shards, func(shard *buildShard) {
+		for i := shard.start; i < shard.end; i++ {
+			for j, token := range b.corpus[i] {
+				b.corpus[i][j] = canonical[token]
+			}
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package bm25
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+)
+
+// ErrBuilderDone is returned when a Builder is used after Build was called.
+var ErrBuilderDone = errors.New("builder has already built its index")
+
+// Builder builds an index incrementally from a stream of documents, so the raw corpus
+// never has to be held in memory at once. Only the tokens of the documents are kept.
+type Builder struct {
+	base        *Bm25Base
+	indexer     *tokenIndexer
+	totalDocLen int
+	extractor   Extractor
+
+	progress      *progressReporter
+	pendingDocs   int
+	pendingTokens int
+}
+
+// NewBuilder creates a new Builder. The Workers option is ignored, since documents are
+// added one at a time; the progress callback reports a TotalDocs of zero, as the size of
+// the stream is not known upfront.
+func NewBuilder(tokenizer func(string) []string, logger *log.Logger, opts BuildOptions) (*Builder, error) {
+	if tokenizer == nil {
+		return nil, ErrNilTokenizer
+	}
+
+	base := &Bm25Base{
+		termFreqs: make(map[string]int),
+		idfCache:  make(map[string]float64),
+		tokenizer: tokenizer,
+		logger:    logger,
+	}
+	if err := base.SetLanguageAnalyzers(opts.LanguageAnalyzers); err != nil {
+		return nil, err
+	}
+	base.detector = opts.LanguageDetector
+	if err := base.SetSchema(opts.Schema); err != nil {
+		return nil, err
+	}
+	base.ingestHooks = slices.Clone(opts.IngestHooks)
+
+	return &Builder{
+		base:      base,
+		indexer:   newTokenIndexer(base.termFreqs),
+		extractor: opts.Extractor,
+		progress:  newProgressReporter(opts, 0),
+	}, nil
+}
+
+// Add tokenizes a document and adds it to the index under construction. It returns the
+// internal ID of the document.
+func (bl *Builder) Add(doc Document) (int, error) {
+	if bl.base == nil {
+		return 0, ErrBuilderDone
+	}
+
+	doc, err := bl.base.ingest(doc)
+	if err != nil {
+		return 0, err
+	}
+	if doc.Tokens == nil {
+		return bl.AddTokens(bl.base.Analyze(extract(bl.extractor, doc.Text), doc.Language), doc)
+	}
+
+	tokens, payloads, err := splitTokens(doc.Tokens)
+	if err != nil {
+		return 0, err
+	}
+	docID, err := bl.AddTokens(tokens, doc)
+	if err != nil {
+		return 0, err
+	}
+	bl.base.setPayloads(docID, payloads)
+	return docID, nil
+}
+
+// AddTokens adds an already tokenized document to the index under construction, e.g. a
+// document imported from another index. The text and tokens of the document are ignored.
+func (bl *Builder) AddTokens(tokens []string, doc Document) (int, error) {
+	if bl.base == nil {
+		return 0, ErrBuilderDone
+	}
+
+	docID := len(bl.base.corpus)
+	if len(tokens) == 0 {
+		return 0, fmt.Errorf("tokenizer function returned an empty slice for document at index %d: %w", docID, ErrEmptyDocument)
+	}
+
+	if err := bl.base.setDocumentInfo(docID, doc); err != nil {
+		return 0, fmt.Errorf("%w: %q", err, doc.ID)
+	}
+
+	docTokens := bl.indexer.index(tokens)
+	bl.base.corpus = append(bl.base.corpus, docTokens)
+	bl.base.docLengths = append(bl.base.docLengths, len(docTokens))
+	bl.totalDocLen += len(docTokens)
+
+	bl.pendingDocs++
+	bl.pendingTokens += len(docTokens)
+	if bl.progress != nil && bl.pendingDocs >= bl.progress.interval {
+		bl.progress.add(bl.pendingDocs, bl.pendingTokens)
+		bl.pendingDocs, bl.pendingTokens = 0, 0
+	}
+
+	return docID, nil
+}
+
+// Build finalizes the corpus statistics and returns the index. The Builder cannot be
+// used anymore afterwards.
+func (bl *Builder) Build() (*Bm25Base, error) {
+	if bl.base == nil {
+		return nil, ErrBuilderDone
+	}
+
+	base := bl.base
+	if len(base.corpus) == 0 {
+		return nil, ErrEmptyCorpus
+	}
+
+	if bl.pendingDocs > 0 {
+		bl.progress.add(bl.pendingDocs, bl.pendingTokens)
+	}
+
+	base.corpusSize = len(base.corpus)
+	base.avgDocLen = float64(bl.totalDocLen) / float64(base.corpusSize)
+	bl.base, bl.indexer = nil, nil
+
+	if base.logger != nil {
+		base.logger.Printf("Corpus size: %d, Average document length: %.2f", base.corpusSize, base.avgDocLen)
+	}
+
+	return base, nil
+}
@@ -0,0 +1,158 @@
+package bm25
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DocumentUpdate is an operation of BulkUpdate, see UpdateDocument.
+type DocumentUpdate struct {
+	Doc      Document
+	Expected uint64 // Expected version, or 0 to skip the check
+}
+
+// DocumentDelete is an operation of BulkDelete, see DeleteDocument.
+type DocumentDelete struct {
+	ID       string
+	Expected uint64 // Expected version, or 0 to skip the check
+}
+
+// BulkItem is the result of an operation of a bulk mutation.
+type BulkItem struct {
+	ID      string `json:"id,omitempty"`
+	DocID   int    `json:"docId"`             // Internal ID of the added document, or -1
+	Version uint64 `json:"version,omitempty"` // Version of the document after the operation
+	Err     error  `json:"-"`
+}
+
+// BulkResult holds the results of the operations of a bulk mutation, in the order of the
+// operations.
+type BulkResult struct {
+	Items  []BulkItem `json:"items"`
+	Failed int        `json:"failed"`
+}
+
+// Err returns the errors of the failed operations joined into one, each prefixed with the
+// position of its operation, or nil if all operations succeeded.
+func (r *BulkResult) Err() error {
+	var errs []error
+	for i, item := range r.Items {
+		if item.Err != nil {
+			errs = append(errs, fmt.Errorf("operation %d: %w", i, item.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// record appends the result of an operation.
+func (r *BulkResult) record(item BulkItem) {
+	if item.Err != nil {
+		item.DocID = -1
+		r.Failed++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// BulkAdd adds a batch of documents, like AddDocument for every document, but updates the
+// corpus statistics and the derived caches once for the whole batch, which is much faster
+// for large batches. A document that cannot be added does not stop the batch; its error
+// is reported in its item of the result. The error is only set if the index is frozen.
+func (b *Bm25Base) BulkAdd(docs []Document) (*BulkResult, error) {
+	return b.bulk(len(docs), func(i int) BulkItem {
+		docID, err := b.addDocument(docs[i])
+		item := BulkItem{ID: docs[i].ID, DocID: docID, Err: err}
+		if err == nil && docs[i].ID != "" {
+			item.Version, _ = b.Version(docs[i].ID)
+		}
+		return item
+	})
+}
+
+// BulkUpdate applies a batch of updates, like UpdateDocument for every update, in order,
+// updating the corpus statistics once for the whole batch. Updates that fail, e.g. with
+// ErrVersionConflict, do not stop the batch. The error is only set if the index is frozen.
+func (b *Bm25Base) BulkUpdate(updates []DocumentUpdate) (*BulkResult, error) {
+	return b.bulk(len(updates), func(i int) BulkItem {
+		docID, version, err := b.updateDocument(updates[i].Doc, updates[i].Expected)
+		return BulkItem{ID: updates[i].Doc.ID, DocID: docID, Version: version, Err: err}
+	})
+}
+
+// BulkDelete applies a batch of deletions, like DeleteDocument for every deletion, in
+// order. Deletions that fail do not stop the batch. The error is only set if the index
+// is frozen.
+func (b *Bm25Base) BulkDelete(deletes []DocumentDelete) (*BulkResult, error) {
+	return b.bulk(len(deletes), func(i int) BulkItem {
+		version, _ := b.Version(deletes[i].ID)
+		err := b.deleteDocument(deletes[i].ID, deletes[i].Expected)
+		return BulkItem{ID: deletes[i].ID, DocID: -1, Version: version, Err: err}
+	})
+}
+
+// bulk applies n operations with apply and commits the documents they added.
+func (b *Bm25Base) bulk(n int, apply func(i int) BulkItem) (*BulkResult, error) {
+	if b.frozen {
+		return nil, ErrFrozen
+	}
+
+	totalDocLen := b.avgDocLen * float64(b.corpusSize)
+	result := &BulkResult{Items: make([]BulkItem, 0, n)}
+	var docIDs []int
+	for i := 0; i < n; i++ {
+		item := apply(i)
+		result.record(item)
+		if item.Err == nil && item.DocID >= 0 {
+			docIDs = append(docIDs, item.DocID)
+		}
+	}
+	b.commitDocuments(totalDocLen, docIDs)
+
+	if b.logger != nil && n > 0 {
+		b.logger.Printf("Applied %d bulk operations (%d failed), corpus size: %d", n, result.Failed, b.corpusSize)
+	}
+	return result, nil
+}
+
+// BulkAdd adds a batch of documents like Bm25Base.BulkAdd and publishes them together.
+func (c *CopyOnWriteIndex) BulkAdd(docs []Document) (*BulkResult, error) {
+	if err := c.checkDocuments(); err != nil {
+		return nil, err
+	}
+	return c.bulk(func(base *Bm25Base) (*BulkResult, error) { return base.BulkAdd(docs) })
+}
+
+// BulkUpdate applies a batch of updates like Bm25Base.BulkUpdate and publishes them
+// together.
+func (c *CopyOnWriteIndex) BulkUpdate(updates []DocumentUpdate) (*BulkResult, error) {
+	if err := c.checkDocuments(); err != nil {
+		return nil, err
+	}
+	return c.bulk(func(base *Bm25Base) (*BulkResult, error) { return base.BulkUpdate(updates) })
+}
+
+// BulkDelete applies a batch of deletions like Bm25Base.BulkDelete and publishes them
+// together.
+func (c *CopyOnWriteIndex) BulkDelete(deletes []DocumentDelete) (*BulkResult, error) {
+	return c.bulk(func(base *Bm25Base) (*BulkResult, error) { return base.BulkDelete(deletes) })
+}
+
+// bulk applies a bulk mutation to the working copy and publishes the result.
+func (c *CopyOnWriteIndex) bulk(fn func(base *Bm25Base) (*BulkResult, error)) (*BulkResult, error) {
+	var result *BulkResult
+	err := c.Update(func(base *Bm25Base) error {
+		var err error
+		result, err = fn(base)
+		return err
+	})
+	return result, err
+}
+
+// BulkAdd is not supported by BM25F, whose documents are made of several fields.
+func (f *BM25F) BulkAdd(docs []Document) (*BulkResult, error) {
+	return nil, ErrNotImplemented
+}
+
+// BulkUpdate is not supported by BM25F, whose documents are made of several fields.
+func (f *BM25F) BulkUpdate(updates []DocumentUpdate) (*BulkResult, error) {
+	return nil, ErrNotImplemented
+}
@@ -0,0 +1,288 @@
+package bm25
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Searcher is implemented by everything that runs search requests: the BM25 variants,
+// CopyOnWriteIndex and OnlineIndex.
+type Searcher interface {
+	Search(ctx context.Context, req SearchRequest) (*SearchResponse, error)
+}
+
+// versionedIndex is implemented by the indexes that count the versions they publish, like
+// CopyOnWriteIndex.
+type versionedIndex interface {
+	Version() uint64
+}
+
+// ResultCache caches the responses of the searches of an index, keyed by everything in
+// the request that affects the results: the query and its analysis, N, the range and
+// keyword filters, the boosts, the sort keys and the parameter overrides. Requests with a
+// Filter function or an Analyzer cannot be keyed and always run against the index. It is
+// safe for concurrent use if the wrapped index is.
+//
+// Documents added to a wrapped variant invalidate the whole cache, and a wrapped
+// CopyOnWriteIndex invalidates it whenever it publishes a new version. The cache checks
+// the state of the index on every search rather than registering hooks on it, so it can
+// be dropped like any other value. Changes to the settings of a wrapped variant, e.g. its term weights, saturation or stopwords, and
+// updates of the QueryDictionary of either invalidate it too, and responses are dropped
+// once the first of their documents expires. Other changes, e.g. deleted documents or
+// changed metadata, must be invalidated with Invalidate or InvalidateFields.
+type ResultCache struct {
+	index    Searcher
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Most recently used first
+	hits    uint64
+	misses  uint64
+}
+
+// ResultCacheStats holds the statistics of a ResultCache.
+type ResultCacheStats struct {
+	Entries int    `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+}
+
+// cacheEntry is a cached response with the metadata fields its request depends on.
+type cacheEntry struct {
+	key       string
+	resp      *SearchResponse
+	state     cacheState
+	expiresAt time.Time // When the first of the returned documents expires, or zero
+	fields    []string
+	allFields bool // The request depends on fields that cannot be listed
+}
+
+// cacheState is the state of an index that a cached response is valid for.
+type cacheState struct {
+	version    uint64 // The published version of a CopyOnWriteIndex
+	settings   uint64 // The settings changes and added documents of a variant
+	dictionary uint64 // The version of the QueryDictionary
+}
+
+// NewResultCache wraps an index in a ResultCache holding the responses of up to capacity
+// requests, evicting the least recently used ones.
+func NewResultCache(index Searcher, capacity int) (*ResultCache, error) {
+	if capacity <= 0 {
+		return nil, invalidParam("capacity", capacity, "must be a positive integer")
+	}
+
+	return &ResultCache{
+		index:    index,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}, nil
+}
+
+// Search returns the cached response of an identical request, or runs the request against
+// the index and caches its response. Responses served from the cache have their Cached
+// flag set; their explanations are shared between callers and must not be modified.
+func (c *ResultCache) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	start := time.Now()
+	key, ok := cacheKey(req)
+	if !ok {
+		return c.index.Search(ctx, req)
+	}
+
+	state := c.state()
+	if resp, ok := c.get(key, state); ok {
+		cached := *resp
+		cached.Results = slices.Clone(resp.Results)
+		cached.Took = time.Since(start)
+		cached.Cached = true
+		return &cached, nil
+	}
+
+	resp, err := c.index.Search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	stored := *resp
+	stored.Results = slices.Clone(resp.Results)
+	fields, allFields := requestFields(req)
+	c.put(&cacheEntry{key: key, resp: &stored, state: state, expiresAt: c.expiresAt(resp), fields: fields, allFields: allFields})
+	return resp, nil
+}
+
+// Warmup runs a sample of expected requests against the index and caches their
+// responses, so the first identical requests are served from the cache. It stops at the
+// first failing request and returns its error.
+func (c *ResultCache) Warmup(ctx context.Context, reqs []SearchRequest) error {
+	for _, req := range reqs {
+		if _, err := c.Search(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Invalidate removes all cached responses.
+func (c *ResultCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.lru.Init()
+}
+
+// InvalidateFields removes the cached responses of the requests that depend on any of the
+// given metadata fields through their filters, boosts, sort keys or score expression.
+// Call it after changing the metadata of documents.
+func (c *ResultCache) InvalidateFields(fields ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		entry := elem.Value.(*cacheEntry)
+		if entry.allFields || slices.ContainsFunc(entry.fields, func(field string) bool {
+			return slices.Contains(fields, field)
+		}) {
+			c.remove(elem)
+		}
+		elem = next
+	}
+}
+
+// Stats returns the statistics of the cache.
+func (c *ResultCache) Stats() ResultCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return ResultCacheStats{Entries: c.lru.Len(), Hits: c.hits, Misses: c.misses}
+}
+
+// base returns the base of the index that is searched, or nil if it has none.
+func (c *ResultCache) base() *Bm25Base {
+	index := c.index
+	if cow, ok := index.(*CopyOnWriteIndex); ok {
+		index = cow.Current()
+	}
+	if adder, ok := index.(documentAdder); ok {
+		return adder.baseIndex()
+	}
+	return nil
+}
+
+// state returns the current state of the index.
+func (c *ResultCache) state() cacheState {
+	var state cacheState
+	if versioned, ok := c.index.(versionedIndex); ok {
+		state.version = versioned.Version()
+	}
+	if base := c.base(); base != nil {
+		state.settings = base.settings
+		if base.dictionary != nil {
+			state.dictionary = base.dictionary.Version()
+		}
+	}
+	return state
+}
+
+// expiresAt returns when the first of the documents of a response expires, or zero if
+// none of them has a TTL.
+func (c *ResultCache) expiresAt(resp *SearchResponse) time.Time {
+	base := c.base()
+	if base == nil {
+		return time.Time{}
+	}
+
+	var first time.Time
+	for _, result := range resp.Results {
+		if result.DocID >= len(base.expiresAt) {
+			continue
+		}
+		if t := base.expiresAt[result.DocID]; !t.IsZero() && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	return first
+}
+
+// get returns the cached response for a key, unless it was cached for another state of
+// the index or one of its documents has expired since.
+func (c *ResultCache) get(key string, state cacheState) (*SearchResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if ok && !elem.Value.(*cacheEntry).valid(state, time.Now()) {
+		c.remove(elem)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).resp, true
+}
+
+// valid reports whether an entry is still valid for the given state of the index.
+func (e *cacheEntry) valid(state cacheState, now time.Time) bool {
+	return e.state == state && (e.expiresAt.IsZero() || now.Before(e.expiresAt))
+}
+
+// put caches a response, evicting the least recently used one if the cache is full.
+func (c *ResultCache) put(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[entry.key]; ok {
+		c.remove(elem)
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.capacity {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove removes an entry from the cache.
+func (c *ResultCache) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+	c.lru.Remove(elem)
+}
+
+// cacheKey returns the key of a request, or false if the request cannot be cached.
+func cacheKey(req SearchRequest) (string, bool) {
+	if req.Filter != nil || req.Analyzer != nil {
+		return "", false
+	}
+	key, err := json.Marshal(req)
+	if err != nil {
+		return "", false
+	}
+	return string(key), true
+}
+
+// requestFields returns the metadata fields a request depends on, and whether it depends
+// on fields that cannot be listed, i.e. it has a score expression.
+func requestFields(req SearchRequest) ([]string, bool) {
+	var fields []string
+	for _, r := range req.Ranges {
+		fields = append(fields, r.Field)
+	}
+	for _, k := range req.Keywords {
+		fields = append(fields, k.Field)
+	}
+	for _, k := range req.KeywordBoosts {
+		fields = append(fields, k.Field)
+	}
+	for _, s := range req.Sort {
+		if s.Field != ScoreField {
+			fields = append(fields, s.Field)
+		}
+	}
+	return fields, req.ScoreExpression != ""
+}
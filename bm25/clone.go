@@ -0,0 +1,78 @@
+package bm25
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// Clone returns a deep copy of the Bm25Base. The copy is mutable, even if the original is frozen.
+func (b *Bm25Base) Clone() *Bm25Base {
+	clone := *b
+	clone.frozen = false
+	clone.shared = false
+
+	clone.corpus = make([][]string, len(b.corpus))
+	for i, doc := range b.corpus {
+		clone.corpus[i] = append([]string(nil), doc...)
+	}
+	clone.docLengths = append([]int(nil), b.docLengths...)
+	clone.termFreqs = maps.Clone(b.termFreqs)
+	clone.idfCache = maps.Clone(b.idfCache)
+	clone.collFreqs = maps.Clone(b.collFreqs)
+	clone.stopwords = maps.Clone(b.stopwords)
+	clone.termWeights = maps.Clone(b.termWeights)
+	clone.externalIDs = append([]string(nil), b.externalIDs...)
+	clone.idIndex = maps.Clone(b.idIndex)
+	clone.versions = maps.Clone(b.versions)
+	if b.metadata != nil {
+		clone.metadata = make([]map[string]any, len(b.metadata))
+		for i, metadata := range b.metadata {
+			clone.metadata[i] = maps.Clone(metadata)
+		}
+	}
+	clone.expiresAt = append([]time.Time(nil), b.expiresAt...)
+	clone.docValues = maps.Clone(b.docValues) // The indexes themselves are never modified
+	clone.keywords = maps.Clone(b.keywords)
+	clone.payloads = maps.Clone(b.payloads) // The payloads of a document are never modified
+	clone.ingestHooks = slices.Clip(b.ingestHooks)
+	clone.queryHooks = slices.Clip(b.queryHooks)
+	clone.addHooks = nil
+
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the Bm25Base that is safe for concurrent
+// use by any number of goroutines. Methods that would modify a frozen index return an error.
+func (b *Bm25Base) Freeze() *Bm25Base {
+	if b.frozen {
+		return b
+	}
+
+	frozen := b.Clone()
+	frozen.Vocabulary()
+	if frozen.epsilonSet {
+		frozen.averageIDF()
+	}
+	frozen.frozen = true
+	return frozen
+}
+
+// IsFrozen reports whether the Bm25Base is an immutable snapshot created by Freeze.
+func (b *Bm25Base) IsFrozen() bool {
+	return b.frozen
+}
+
+// cacheIDF stores the IDF of a term in the cache, unless the caches are read-only.
+func (b *Bm25Base) cacheIDF(term string, idf float64) {
+	if b.cachesWritable() {
+		b.idfCache[term] = idf
+	}
+}
+
+// cachesWritable reports whether lookups may populate the caches of the index. Frozen
+// and shared indexes only read from their caches, so concurrent lookups never write to
+// them.
+func (b *Bm25Base) cachesWritable() bool {
+	return !b.frozen && !b.shared
+}
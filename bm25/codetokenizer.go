@@ -0,0 +1,128 @@
+package bm25
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CodeTokenizerOptions configures a tokenizer created by NewCodeTokenizer.
+type CodeTokenizerOptions struct {
+	// Lowercase lowercases all tokens, so "getUser" and "get_user" yield the same terms.
+	Lowercase bool
+
+	// KeepIdentifiers additionally emits every compound identifier as a whole, before
+	// its parts, so exact identifier matches outrank matches of the parts alone.
+	KeepIdentifiers bool
+
+	// Symbols emits operators such as "==", "->" or "::" as tokens. Delimiters such as
+	// brackets, commas, semicolons, dots and quotes are always dropped.
+	Symbols bool
+
+	// MinLength drops identifier parts shorter than the given number of characters.
+	MinLength int
+}
+
+// codeOperators lists the multi-character operators recognized by the code tokenizer,
+// longest first so that they are matched greedily.
+var codeOperators = []string{
+	"<<=", ">>=", "===", "!==", "...", "&^=", "**=", "//=", "??=",
+	"::", "->", "=>", "==", "!=", "<=", ">=", "&&", "||", "++", "--", "<<", ">>", ":=",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<-", "**", "??", "?.", "&^",
+}
+
+// codeDelimiters lists the punctuation the code tokenizer never emits.
+const codeDelimiters = "()[]{},;.\"'`"
+
+// NewCodeTokenizer creates a tokenizer for source code. It splits identifiers at
+// underscores, camelCase boundaries and acronyms, so "parseHTTPRequest" and
+// "parse_http_request" both yield "parse", "HTTP" and "Request". Splitting is applied
+// consistently to queries and documents; when KeepIdentifiers is set, the whole
+// identifiers are emitted alongside their parts.
+func NewCodeTokenizer(opts CodeTokenizerOptions) func(string) []string {
+	return func(text string) []string {
+		tokens := []string{}
+		emit := func(token string) {
+			if opts.Lowercase {
+				token = strings.ToLower(token)
+			}
+			tokens = append(tokens, token)
+		}
+
+		runes := []rune(text)
+		for i := 0; i < len(runes); {
+			r := runes[i]
+			switch {
+			case unicode.IsSpace(r):
+				i++
+
+			case isIdentRune(r):
+				start := i
+				for i < len(runes) && isIdentRune(runes[i]) {
+					i++
+				}
+				ident := string(runes[start:i])
+				parts := splitIdentifier(runes[start:i])
+				if opts.KeepIdentifiers && len(parts) > 1 {
+					emit(ident)
+				}
+				for _, part := range parts {
+					if len([]rune(part)) >= opts.MinLength {
+						emit(part)
+					}
+				}
+
+			default:
+				op := string(r)
+				for _, candidate := range codeOperators {
+					if strings.HasPrefix(string(runes[i:min(i+len(candidate), len(runes))]), candidate) {
+						op = candidate
+						break
+					}
+				}
+				i += len([]rune(op))
+				if opts.Symbols && !strings.Contains(codeDelimiters, op) {
+					emit(op)
+				}
+			}
+		}
+		return tokens
+	}
+}
+
+// isIdentRune reports whether the rune can be part of an identifier.
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// splitIdentifier splits an identifier into its parts at underscores, lower-to-upper
+// case transitions and the end of acronyms ("HTTPServer" becomes "HTTP", "Server").
+// Digits stay attached to the preceding part.
+func splitIdentifier(ident []rune) []string {
+	var parts []string
+	start := -1
+	for i, r := range ident {
+		if r == '_' {
+			if start >= 0 {
+				parts = append(parts, string(ident[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+
+		prev := ident[i-1]
+		boundary := unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) ||
+			unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(ident) && unicode.IsLower(ident[i+1])
+		if boundary {
+			parts = append(parts, string(ident[start:i]))
+			start = i
+		}
+	}
+	if start >= 0 {
+		parts = append(parts, string(ident[start:]))
+	}
+	return parts
+}
@@ -0,0 +1,470 @@
+// Package config builds indexes from declarative JSON configuration files, which select
+// the variant and its parameters, the analyzer, the corpus and where the index is
+// persisted, so an index setup can be reviewed and versioned like code. Configurations
+// are decoded strictly: unknown keys are an error, so typos do not go unnoticed.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/corpusio"
+)
+
+// ErrNoCorpus is returned when an index is built from a configuration with neither a
+// corpus nor an existing snapshot.
+var ErrNoCorpus = errors.New("configuration has neither a corpus nor an existing snapshot")
+
+// Config is the configuration of an index.
+type Config struct {
+	// Preset names a built-in preset, e.g. "web-short-docs", "long-documents" or
+	// "code-search", see Presets. It sets the variant, the parameters and the analyzer
+	// unless they are set explicitly; parameters set explicitly override those of the
+	// preset.
+	Preset string `json:"preset,omitempty"`
+
+	// Variant is the BM25 variant: "okapi" (default), "l", "plus", "adpt", "adptk1", "t",
+	// "tk1", "lgd", "pl2", "dph", "ql" (query likelihood with Dirichlet smoothing), "qljm"
+	// (query likelihood with Jelinek-Mercer smoothing), "tfidf" (TF-IDF cosine), or any
+	// other name or alias of a variant registered with bm25.RegisterVariant.
+	Variant string `json:"variant,omitempty"`
+
+	// Params are the parameters of the variant, see its ParamSpecs. Missing parameters
+	// take their default value.
+	Params map[string]float64 `json:"params,omitempty"`
+
+	Analyzer AnalyzerConfig `json:"analyzer"`
+
+	// Languages lists the languages whose built-in analyzer is applied to the documents
+	// tagged with them, see bm25.LanguageAnalyzer.
+	Languages []string `json:"languages,omitempty"`
+
+	Corpus *CorpusConfig `json:"corpus,omitempty"`
+
+	// Snapshot is the path of the snapshot of the index. If it exists, the index is loaded
+	// from it instead of being built from the corpus; otherwise it is written once the
+	// index is built. Relative paths are relative to the configuration file.
+	Snapshot string `json:"snapshot,omitempty"`
+
+	// ExcludeStopwords lists the terms excluded from scoring, see ExcludeStopwords.
+	ExcludeStopwords []string `json:"excludeStopwords,omitempty"`
+}
+
+// AnalyzerConfig configures the analyzer of the documents and the queries.
+type AnalyzerConfig struct {
+	// Language selects a built-in analyzer, see bm25.LanguageAnalyzer. It cannot be
+	// combined with the other settings.
+	Language string `json:"language,omitempty"`
+
+	// Tokenizer is "whitespace" (default), "words", which splits on characters other than
+	// letters and digits, "keyword" or "code", see bm25.NewCodeTokenizer.
+	Tokenizer string `json:"tokenizer,omitempty"`
+
+	// CharFilters are applied to the text before it is tokenized, in order: "html", which
+	// decodes HTML entities, "urls", which removes URLs, or "replace", which applies the
+	// Replacements.
+	CharFilters []string `json:"charFilters,omitempty"`
+
+	// Filters are applied to the tokens in order: "lowercase", "asciifolding", which
+	// removes accents, "units", which normalizes numbers and units, "stopwords", which
+	// removes the Stopwords, "length", which removes the tokens shorter than MinLength or longer
+	// than MaxLength, "numeric", which removes numbers, "keep", which keeps only the tokens
+	// matching Keep, "drop", which removes the tokens matching Drop, "decompound", which
+	// splits compound words into the words of the Dictionary, or "shingles", which adds
+	// runs of 2 to ShingleSize words, see bm25.ShingleFilter.
+	Filters []string `json:"filters,omitempty"`
+
+	Stopwords    []string      `json:"stopwords,omitempty"`
+	Replacements []Replacement `json:"replacements,omitempty"`
+	MinLength    int           `json:"minLength,omitempty"`
+	MaxLength    int           `json:"maxLength,omitempty"`
+	Keep         string        `json:"keep,omitempty"`
+	Drop         string        `json:"drop,omitempty"`
+
+	// Dictionary and LinkingElements configure the "decompound" filter, see
+	// bm25.DecompoundFilter.
+	Dictionary      []string `json:"dictionary,omitempty"`
+	LinkingElements []string `json:"linkingElements,omitempty"`
+
+	// ShingleSize is the maximum number of words of the shingles. Defaults to 2.
+	ShingleSize int `json:"shingleSize,omitempty"`
+}
+
+// Replacement is a regular expression replacement of the "replace" char filter, see
+// bm25.RegexpReplaceFilter.
+type Replacement struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// CorpusConfig configures the corpus an index is built from.
+type CorpusConfig struct {
+	// Path is the path of the corpus file. Relative paths are relative to the
+	// configuration file.
+	Path string `json:"path"`
+
+	// Format is "jsonl" (default), "csv" or "bulk", see the readers of package corpusio.
+	Format string `json:"format,omitempty"`
+
+	// Text, ID and Metadata map the fields of the records onto the documents, see
+	// corpusio.Fields.
+	Text     []string `json:"text"`
+	ID       string   `json:"id,omitempty"`
+	Metadata []string `json:"metadata,omitempty"`
+}
+
+// Read reads and validates a configuration. Relative paths are kept as they are.
+func Read(r io.Reader) (*Config, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var c Config
+	if err := decoder.Decode(&c); err != nil {
+		return nil, fmt.Errorf("decoding configuration: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Load reads and validates a configuration file, and resolves its relative paths against
+// the directory of the file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	if c.Snapshot != "" && !filepath.IsAbs(c.Snapshot) {
+		c.Snapshot = filepath.Join(dir, c.Snapshot)
+	}
+	if c.Corpus != nil && !filepath.IsAbs(c.Corpus.Path) {
+		c.Corpus.Path = filepath.Join(dir, c.Corpus.Path)
+	}
+	return c, nil
+}
+
+// Validate checks the configuration without building the index.
+func (c *Config) Validate() error {
+	c, err := c.withPreset()
+	if err != nil {
+		return err
+	}
+	specs, err := bm25.VariantParamSpecs(variantName(c.Variant))
+	if err != nil {
+		return err
+	}
+	if err := bm25.ValidateParams(specs, c.Params); err != nil {
+		return err
+	}
+	if _, err := c.Analyzer.Build(); err != nil {
+		return err
+	}
+	for _, language := range c.Languages {
+		if _, ok := bm25.LanguageAnalyzer(language); !ok {
+			return fmt.Errorf("languages: no built-in analyzer for %q", language)
+		}
+	}
+
+	if c.Corpus != nil {
+		if c.Corpus.Path == "" {
+			return errors.New("corpus: path is required")
+		}
+		if len(c.Corpus.Text) == 0 {
+			return fmt.Errorf("corpus: %w", corpusio.ErrNoTextFields)
+		}
+		switch c.Corpus.Format {
+		case "", "jsonl", "csv", "bulk":
+		default:
+			return fmt.Errorf("corpus: unknown format %q", c.Corpus.Format)
+		}
+	}
+	if c.Corpus == nil && c.Snapshot == "" {
+		return ErrNoCorpus
+	}
+	return nil
+}
+
+// Build returns the analyzer of the configuration.
+func (a AnalyzerConfig) Build() (bm25.Analyzer, error) {
+	if a.Language != "" {
+		if a.Tokenizer != "" || len(a.Filters) > 0 || len(a.Stopwords) > 0 {
+			return bm25.Analyzer{}, errors.New("analyzer: language cannot be combined with a tokenizer, filters or stopwords")
+		}
+		analyzer, ok := bm25.LanguageAnalyzer(a.Language)
+		if !ok {
+			return bm25.Analyzer{}, fmt.Errorf("analyzer: no built-in analyzer for %q", a.Language)
+		}
+		charFilters, err := a.charFilters()
+		if err != nil {
+			return bm25.Analyzer{}, err
+		}
+		analyzer.CharFilters = charFilters
+		return analyzer, nil
+	}
+
+	charFilters, err := a.charFilters()
+	if err != nil {
+		return bm25.Analyzer{}, err
+	}
+	analyzer := bm25.Analyzer{CharFilters: charFilters}
+	switch a.Tokenizer {
+	case "", "whitespace":
+		analyzer.Tokenizer = strings.Fields
+	case "words":
+		analyzer.Tokenizer = splitWords
+	case "keyword":
+		analyzer.Tokenizer = bm25.KeywordTokenizer
+	case "code":
+		analyzer.Tokenizer = bm25.NewCodeTokenizer(bm25.CodeTokenizerOptions{})
+	default:
+		return bm25.Analyzer{}, fmt.Errorf("analyzer: unknown tokenizer %q", a.Tokenizer)
+	}
+
+	for _, filter := range a.Filters {
+		switch filter {
+		case "lowercase":
+			analyzer.Filters = append(analyzer.Filters, bm25.LowercaseFilter)
+		case "asciifolding":
+			analyzer.Filters = append(analyzer.Filters, bm25.ASCIIFoldingFilter)
+		case "units":
+			analyzer.Filters = append(analyzer.Filters, bm25.NumberUnitFilter(nil))
+		case "stopwords":
+			if len(a.Stopwords) == 0 {
+				return bm25.Analyzer{}, errors.New("analyzer: the stopwords filter requires stopwords")
+			}
+			analyzer.Filters = append(analyzer.Filters, bm25.StopwordFilter(a.Stopwords))
+		case "length":
+			if a.MinLength < 0 || a.MaxLength < 0 || (a.MaxLength > 0 && a.MaxLength < a.MinLength) {
+				return bm25.Analyzer{}, fmt.Errorf("analyzer: invalid token length range [%d, %d]", a.MinLength, a.MaxLength)
+			}
+			analyzer.Filters = append(analyzer.Filters, bm25.LengthFilter(a.MinLength, a.MaxLength))
+		case "decompound":
+			if len(a.Dictionary) == 0 {
+				return bm25.Analyzer{}, errors.New("analyzer: the decompound filter requires a dictionary")
+			}
+			decompound, err := bm25.DecompoundFilter(a.Dictionary, bm25.DecompoundOptions{LinkingElements: a.LinkingElements})
+			if err != nil {
+				return bm25.Analyzer{}, fmt.Errorf("analyzer: decompound: %w", err)
+			}
+			analyzer.Filters = append(analyzer.Filters, decompound)
+		case "shingles":
+			shingles, err := bm25.ShingleFilter(bm25.ShingleOptions{MaxSize: a.ShingleSize})
+			if err != nil {
+				return bm25.Analyzer{}, fmt.Errorf("analyzer: shingles: %w", err)
+			}
+			analyzer.Filters = append(analyzer.Filters, shingles)
+		case "numeric":
+			analyzer.Filters = append(analyzer.Filters, bm25.NumericFilter)
+		case "keep":
+			pattern, err := tokenPattern(filter, a.Keep)
+			if err != nil {
+				return bm25.Analyzer{}, err
+			}
+			analyzer.Filters = append(analyzer.Filters, bm25.KeepPatternFilter(pattern))
+		case "drop":
+			pattern, err := tokenPattern(filter, a.Drop)
+			if err != nil {
+				return bm25.Analyzer{}, err
+			}
+			analyzer.Filters = append(analyzer.Filters, bm25.DropPatternFilter(pattern))
+		default:
+			return bm25.Analyzer{}, fmt.Errorf("analyzer: unknown filter %q", filter)
+		}
+	}
+	return analyzer, nil
+}
+
+// tokenPattern compiles the pattern of a keep or drop filter.
+func tokenPattern(filter string, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("analyzer: the %s filter requires a pattern", filter)
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("analyzer: %s: %w", filter, err)
+	}
+	return pattern, nil
+}
+
+// charFilters returns the char filters of the configuration.
+func (a AnalyzerConfig) charFilters() ([]bm25.CharFilter, error) {
+	var filters []bm25.CharFilter
+	for _, filter := range a.CharFilters {
+		switch filter {
+		case "html":
+			filters = append(filters, bm25.HTMLEntityFilter)
+		case "urls":
+			filters = append(filters, bm25.URLStripFilter)
+		case "replace":
+			if len(a.Replacements) == 0 {
+				return nil, errors.New("analyzer: the replace char filter requires replacements")
+			}
+			for _, r := range a.Replacements {
+				pattern, err := regexp.Compile(r.Pattern)
+				if err != nil {
+					return nil, fmt.Errorf("analyzer: replacement: %w", err)
+				}
+				filters = append(filters, bm25.RegexpReplaceFilter(pattern, r.Replacement))
+			}
+		default:
+			return nil, fmt.Errorf("analyzer: unknown char filter %q", filter)
+		}
+	}
+	return filters, nil
+}
+
+// splitWords splits a text on characters other than letters and digits: it is the
+// tokenizer of the built-in language analyzers.
+var splitWords = func() func(string) []string {
+	analyzer, _ := bm25.LanguageAnalyzer("en")
+	return analyzer.Tokenizer
+}()
+
+// Build builds the index of the configuration: it is loaded from the snapshot if it
+// exists, and built from the corpus and saved to the snapshot otherwise.
+func (c *Config) Build(logger *log.Logger) (bm25.BM25, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	c, _ = c.withPreset()
+	analyzer, _ := c.Analyzer.Build()
+
+	base, err := c.loadSnapshot(analyzer, logger)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		if base, err = c.buildCorpus(analyzer, logger); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := base.ExcludeStopwords(c.ExcludeStopwords); err != nil {
+		return nil, err
+	}
+	if err := base.SetLanguageAnalyzers(c.languageAnalyzers()); err != nil {
+		return nil, err
+	}
+	return bm25.New(variantName(c.Variant), bm25.WithBase(base), bm25.WithParams(c.Params))
+}
+
+// languageAnalyzers returns the built-in analyzers of the languages, or nil if there are
+// none.
+func (c *Config) languageAnalyzers() map[string]bm25.Analyzer {
+	if len(c.Languages) == 0 {
+		return nil
+	}
+	analyzers := make(map[string]bm25.Analyzer, len(c.Languages))
+	for _, language := range c.Languages {
+		analyzers[language], _ = bm25.LanguageAnalyzer(language)
+	}
+	return analyzers
+}
+
+// loadSnapshot reads the snapshot of the configuration, or returns nil if there is none.
+func (c *Config) loadSnapshot(analyzer bm25.Analyzer, logger *log.Logger) (*bm25.Bm25Base, error) {
+	if c.Snapshot == "" {
+		return nil, nil
+	}
+	f, err := os.Open(c.Snapshot)
+	if errors.Is(err, fs.ErrNotExist) {
+		if c.Corpus == nil {
+			return nil, ErrNoCorpus
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	base, err := bm25.ReadSnapshot(f, analyzer.Analyze, logger)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Snapshot, err)
+	}
+	return base, nil
+}
+
+// buildCorpus builds the index from the corpus and writes the snapshot, if any.
+func (c *Config) buildCorpus(analyzer bm25.Analyzer, logger *log.Logger) (*bm25.Bm25Base, error) {
+	f, err := os.Open(c.Corpus.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fields := corpusio.Fields{Text: c.Corpus.Text, ID: c.Corpus.ID, Metadata: c.Corpus.Metadata}
+	var reader corpusio.Reader
+	switch c.Corpus.Format {
+	case "", "jsonl":
+		reader, err = corpusio.NewJSONLReader(f, fields)
+	case "csv":
+		reader, err = corpusio.NewCSVReader(f, fields, 0)
+	case "bulk":
+		reader, err = corpusio.NewBulkReader(f, fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	builder, err := bm25.NewBuilder(analyzer.Analyze, logger, bm25.BuildOptions{LanguageAnalyzers: c.languageAnalyzers()})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := corpusio.Load(reader, builder); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.Corpus.Path, err)
+	}
+	base, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Snapshot != "" {
+		if err := writeSnapshot(base, c.Snapshot); err != nil {
+			return nil, err
+		}
+	}
+	return base, nil
+}
+
+// writeSnapshot writes the snapshot to a temporary file first, so an interrupted write
+// is not mistaken for a complete snapshot.
+func writeSnapshot(base *bm25.Bm25Base, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := base.WriteSnapshot(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// variantName returns the name of a variant in the registry, see bm25.New.
+func variantName(variant string) string {
+	if variant == "" {
+		return "okapi"
+	}
+	return variant
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// Preset is a named starting point for the configuration of an index in a domain: a
+// variant, parameters and an analyzer that work well there, for users who do not want to
+// tune them, see Config.Preset.
+type Preset struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Variant     string             `json:"variant"`
+	Params      map[string]float64 `json:"params"`
+	Analyzer    AnalyzerConfig     `json:"analyzer"`
+}
+
+// presets are the built-in presets, by name.
+var presets = map[string]Preset{
+	"web-short-docs": {
+		Description: "Short web content such as titles, snippets and product names. Repeated terms and length differences carry little signal, so saturation and length normalization are weaker.",
+		Variant:     "okapi",
+		Params:      map[string]float64{"k1": 0.9, "b": 0.4},
+		Analyzer: AnalyzerConfig{
+			Tokenizer:   "words",
+			CharFilters: []string{"html", "urls"},
+			Filters:     []string{"lowercase", "asciifolding"},
+		},
+	},
+	"long-documents": {
+		Description: "Long documents such as articles, reports and books. BM25+ keeps a lower bound on the score of a matching term, so long documents are not ranked below short ones that do not match.",
+		Variant:     "plus",
+		Params:      map[string]float64{"k1": 1.2, "b": 0.75, "delta": 1},
+		Analyzer: AnalyzerConfig{
+			Tokenizer: "words",
+			Filters:   []string{"lowercase", "asciifolding", "units"},
+		},
+	},
+	"code-search": {
+		Description: "Source code. Identifiers are split at underscores and camelCase boundaries, identifiers repeated in a file keep adding to its score, and file length matters less.",
+		Variant:     "okapi",
+		Params:      map[string]float64{"k1": 1.5, "b": 0.3},
+		Analyzer: AnalyzerConfig{
+			Tokenizer: "code",
+			Filters:   []string{"lowercase"},
+		},
+	},
+}
+
+// Presets returns the built-in presets, sorted by name.
+func Presets() []Preset {
+	names := slices.Sorted(maps.Keys(presets))
+	result := make([]Preset, len(names))
+	for i, name := range names {
+		result[i], _ = LookupPreset(name)
+	}
+	return result
+}
+
+// LookupPreset returns the built-in preset with the given name, and false if there is
+// none.
+func LookupPreset(name string) (Preset, bool) {
+	preset, ok := presets[name]
+	if !ok {
+		return Preset{}, false
+	}
+	preset.Name = name
+	preset.Params = maps.Clone(preset.Params)
+	preset.Analyzer.CharFilters = slices.Clone(preset.Analyzer.CharFilters)
+	preset.Analyzer.Filters = slices.Clone(preset.Analyzer.Filters)
+	return preset, true
+}
+
+// withPreset returns a copy of the configuration with the settings of its preset filling
+// in the variant, the parameters and the analyzer it leaves unset. The parameters of the
+// preset are only used with its own variant.
+func (c *Config) withPreset() (*Config, error) {
+	if c.Preset == "" {
+		return c, nil
+	}
+	preset, ok := LookupPreset(c.Preset)
+	if !ok {
+		names := slices.Sorted(maps.Keys(presets))
+		return nil, fmt.Errorf("preset: unknown preset %q, expected one of %s", c.Preset, strings.Join(names, ", "))
+	}
+
+	resolved := *c
+	if resolved.Variant == "" {
+		resolved.Variant = preset.Variant
+	}
+	variant, err := bm25.VariantName(resolved.Variant)
+	if err != nil {
+		return nil, err
+	}
+	presetVariant, err := bm25.VariantName(preset.Variant)
+	if err != nil {
+		return nil, err
+	}
+	if variant == presetVariant {
+		maps.Copy(preset.Params, c.Params)
+		resolved.Params = preset.Params
+	}
+	if reflect.ValueOf(resolved.Analyzer).IsZero() {
+		resolved.Analyzer = preset.Analyzer
+	}
+	return &resolved, nil
+}
@@ -0,0 +1,226 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/config"
+)
+
+func TestConfig(t *testing.T) {
+	dir := t.TempDir()
+	corpus := `{"id": "a", "body": "The Quick brown fox"}
+{"id": "b", "body": "A lazy dog, sleeping"}
+{"id": "c", "body": "the fox and the dog"}
+`
+	if err := os.WriteFile(filepath.Join(dir, "corpus.jsonl"), []byte(corpus), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	path := filepath.Join(dir, "index.json")
+	cfg := `{
+  "variant": "plus",
+  "params": {"k1": 1.2, "delta": 0.5},
+  "analyzer": {"tokenizer": "words", "filters": ["lowercase", "stopwords"], "stopwords": ["the", "a"]},
+  "corpus": {"path": "corpus.jsonl", "text": ["body"], "id": "id"},
+  "snapshot": "index.snapshot"
+}`
+	if err := os.WriteFile(path, []byte(cfg), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Building from the corpus writes the snapshot
+	c, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c.Snapshot != filepath.Join(dir, "index.snapshot") {
+		t.Errorf("Expected the snapshot path to be resolved, but got %q", c.Snapshot)
+	}
+	index, err := c.Build(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	params := index.Params()
+	if _, ok := index.(*bm25.BM25Plus); !ok || params["k1"] != 1.2 || params["b"] != 0.75 || params["delta"] != 0.5 {
+		t.Errorf("Expected BM25Plus with the configured and default parameters, but got %T %v", index, params)
+	}
+	resp, err := index.Search(context.Background(), bm25.SearchRequest{Text: "The FOX", N: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.UnknownTerms != nil || resp.Results[0].Doc != "quick brown fox" {
+		t.Errorf("Expected the analyzed document, but got %+v", resp)
+	}
+	if _, err := os.Stat(c.Snapshot); err != nil {
+		t.Errorf("Expected the snapshot to be written, but got %v", err)
+	}
+
+	// Test case: An existing snapshot is loaded instead of the corpus
+	os.Remove(filepath.Join(dir, "corpus.jsonl"))
+	index, err = c.Build(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if index.CorpusSize() != 3 {
+		t.Errorf("Expected 3 documents from the snapshot, but got %d", index.CorpusSize())
+	}
+
+	// Test case: Invalid configurations are rejected
+	for name, cfg := range map[string]string{
+		"unknown key":       `{"variant": "okapi", "snapshot": "x", "k1": 1}`,
+		"unknown variant":   `{"variant": "bm26", "snapshot": "x"}`,
+		"invalid parameter": `{"params": {"b": 2}, "snapshot": "x"}`,
+		"unknown parameter": `{"variant": "l", "params": {"delta": 1}, "snapshot": "x"}`,
+		"unknown tokenizer": `{"analyzer": {"tokenizer": "ngram"}, "snapshot": "x"}`,
+		"language mix":      `{"analyzer": {"language": "en", "filters": ["lowercase"]}, "snapshot": "x"}`,
+		"no stopwords":      `{"analyzer": {"filters": ["stopwords"]}, "snapshot": "x"}`,
+		"char filter":       `{"analyzer": {"charFilters": ["markdown"]}, "snapshot": "x"}`,
+		"no replacements":   `{"analyzer": {"charFilters": ["replace"]}, "snapshot": "x"}`,
+		"length range":      `{"analyzer": {"filters": ["length"], "minLength": 5, "maxLength": 2}, "snapshot": "x"}`,
+		"no dictionary":     `{"analyzer": {"filters": ["decompound"]}, "snapshot": "x"}`,
+		"shingle size":      `{"analyzer": {"filters": ["shingles"], "shingleSize": 1}, "snapshot": "x"}`,
+		"no keep pattern":   `{"analyzer": {"filters": ["keep"]}, "snapshot": "x"}`,
+		"bad drop pattern":  `{"analyzer": {"filters": ["drop"], "drop": "["}, "snapshot": "x"}`,
+		"bad replacement":   `{"analyzer": {"charFilters": ["replace"], "replacements": [{"pattern": "("}]}, "snapshot": "x"}`,
+		"unknown language":  `{"languages": ["xx"], "snapshot": "x"}`,
+		"corpus format":     `{"corpus": {"path": "c", "text": ["body"], "format": "xml"}}`,
+		"corpus fields":     `{"corpus": {"path": "c"}}`,
+	} {
+		if _, err := config.Read(strings.NewReader(cfg)); err == nil {
+			t.Errorf("Expected an error for %s, but got nil", name)
+		}
+	}
+	if _, err := config.Read(strings.NewReader(`{}`)); !errors.Is(err, config.ErrNoCorpus) {
+		t.Errorf("Expected ErrNoCorpus, but got %v", err)
+	}
+	c.Snapshot = filepath.Join(dir, "missing.snapshot")
+	c.Corpus = nil
+	if _, err := c.Build(nil); !errors.Is(err, config.ErrNoCorpus) {
+		t.Errorf("Expected ErrNoCorpus for a missing snapshot, but got %v", err)
+	}
+}
+
+func TestAnalyzerConfigCharFilters(t *testing.T) {
+	cfg := config.AnalyzerConfig{
+		CharFilters:  []string{"urls", "html", "replace"},
+		Tokenizer:    "whitespace",
+		Replacements: []config.Replacement{{Pattern: `(\d+)-(\d+)`, Replacement: "$1$2"}},
+	}
+
+	// Test case: Char filters are applied in order before tokenizing
+	analyzer, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tokens := analyzer.Analyze("call 555-1234 at https://example.com/a?b=c Fish&amp;Chips")
+	expected := []string{"call", "5551234", "at", "Fish&Chips"}
+	if strings.Join(tokens, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected tokens %v, but got %v", expected, tokens)
+	}
+
+	// Test case: Char filters can be combined with a built-in language analyzer
+	analyzer, err = config.AnalyzerConfig{Language: "en", CharFilters: []string{"html"}}.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(analyzer.CharFilters) != 1 {
+		t.Errorf("Expected 1 char filter, but got %d", len(analyzer.CharFilters))
+	}
+}
+
+func TestAnalyzerConfigTokenFilters(t *testing.T) {
+	cfg := config.AnalyzerConfig{
+		Filters:   []string{"asciifolding", "length", "numeric", "drop"},
+		MinLength: 2,
+		MaxLength: 10,
+		Drop:      `^[a-f0-9]{8}$`,
+	}
+
+	// Test case: Short, long, numeric and matching tokens are removed
+	analyzer, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tokens := analyzer.Analyze("a commit deadbeef of 1,024 lines aGVsbG8gd29ybGQgaGVsbG8= Straße")
+	expected := []string{"commit", "of", "lines", "Strasse"}
+	if strings.Join(tokens, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected tokens %v, but got %v", expected, tokens)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	dir := t.TempDir()
+	corpus := `{"body": "func parseHTTPRequest(r *Request)"}
+{"body": "type userStore struct"}
+`
+	if err := os.WriteFile(filepath.Join(dir, "code.jsonl"), []byte(corpus), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: The built-in presets are listed by name, and all of them are valid
+	presets := config.Presets()
+	if len(presets) != 3 || presets[0].Name != "code-search" {
+		t.Errorf("Expected 3 presets starting with code-search, but got %+v", presets)
+	}
+	for _, preset := range presets {
+		c := config.Config{Preset: preset.Name, Snapshot: "x"}
+		if err := c.Validate(); err != nil {
+			t.Errorf("Expected preset %s to be valid, but got %v", preset.Name, err)
+		}
+	}
+
+	// Test case: A preset sets the variant, parameters and analyzer
+	c, err := config.Read(strings.NewReader(`{"preset": "code-search", "corpus": {"path": "` + filepath.Join(dir, "code.jsonl") + `", "text": ["body"]}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	index, err := c.Build(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params := index.Params(); params["b"] != 0.3 {
+		t.Errorf("Expected b 0.3 from the preset, but got %v", params)
+	}
+	resp, err := index.Search(context.Background(), bm25.SearchRequest{Text: "http request", N: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].DocID != 0 {
+		t.Errorf("Expected the split identifiers to match document 0, but got %+v", resp.Results)
+	}
+
+	// Test case: Explicit parameters override those of the preset
+	c.Params = map[string]float64{"k1": 2}
+	index, err = c.Build(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params := index.Params(); params["k1"] != 2 || params["b"] != 0.3 {
+		t.Errorf("Expected k1 2 and b 0.3, but got %v", params)
+	}
+
+	// Test case: The preset parameters apply when the variant is named by an alias or in another case
+	for _, variant := range []string{"bm25plus", "Plus"} {
+		c, err := config.Read(strings.NewReader(`{"preset": "long-documents", "variant": "` + variant + `", "corpus": {"path": "` + filepath.Join(dir, "code.jsonl") + `", "text": ["body"]}}`))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		index, err := c.Build(nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if params := index.Params(); params["k1"] != 1.2 || params["delta"] != 1 {
+			t.Errorf("Expected k1 1.2 and delta 1 from the preset for variant %s, but got %v", variant, params)
+		}
+	}
+
+	// Test case: Unknown presets are rejected
+	if _, err := config.Read(strings.NewReader(`{"preset": "legal", "snapshot": "x"}`)); err == nil {
+		t.Errorf("Expected an error for an unknown preset, but got nil")
+	}
+}
@@ -0,0 +1,62 @@
+package bm25
+
+import "math"
+
+// coordinator computes the coordination factor of a search: the fraction of the distinct
+// query terms a document matches, raised to a power. Synonyms and subword expansions of a
+// term count as that term, so a document matching any of them matches the term once.
+type coordinator struct {
+	groups   map[string]int
+	hits     [][]bool
+	exponent float64
+}
+
+// newCoordinator returns the coordinator of a query with the given exponent, or nil if
+// the exponent is zero and no coordination factor applies. expand returns the terms a
+// query term is expanded to.
+func (b *Bm25Base) newCoordinator(query []string, exponent float64, expand func([]string) []string) *coordinator {
+	if exponent == 0 {
+		return nil
+	}
+
+	c := &coordinator{groups: make(map[string]int), exponent: exponent}
+	distinct := make(map[string]bool)
+	for _, term := range query {
+		if distinct[term] {
+			continue
+		}
+		distinct[term] = true
+
+		group := len(c.hits)
+		c.hits = append(c.hits, make([]bool, b.corpusSize))
+		for _, expanded := range expand([]string{term}) {
+			if _, ok := c.groups[expanded]; !ok {
+				c.groups[expanded] = group
+			}
+		}
+	}
+	return c
+}
+
+// record marks the documents matching a term of the expanded query, given the documents
+// containing it.
+func (c *coordinator) record(term string, docs *Bitmap) {
+	group, ok := c.groups[term]
+	if !ok {
+		return
+	}
+	for _, docID := range docs.AppendDocIDs(nil) {
+		c.hits[group][docID] = true
+	}
+}
+
+// factor returns the coordination factor of a document.
+func (c *coordinator) factor(docID int) float64 {
+	matched := 0
+	for _, hits := range c.hits {
+		if hits[docID] {
+			matched++
+		}
+	}
+	return math.Pow(float64(matched)/float64(len(c.hits)), c.exponent)
+}
@@ -0,0 +1,121 @@
+package corpusio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// BulkReader reads documents from the NDJSON body of an Elasticsearch _bulk request, e.g.
+// a data dump. Every index or create action yields a document built from the source
+// line following it. The _id of the action becomes the document ID, unless Fields.ID
+// selects a source field. Delete and update actions are skipped, as partial updates
+// cannot be applied to a stream of documents.
+type BulkReader struct {
+	scanner *bufio.Scanner
+	fields  Fields
+	line    int
+}
+
+// bulkAction is the metadata of a bulk action.
+type bulkAction struct {
+	ID any `json:"_id"`
+}
+
+// NewBulkReader creates a new BulkReader.
+func NewBulkReader(r io.Reader, fields Fields) (*BulkReader, error) {
+	if len(fields.Text) == 0 {
+		return nil, ErrNoTextFields
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLLineSize)
+	return &BulkReader{scanner: scanner, fields: fields}, nil
+}
+
+// Next returns the document of the next index or create action.
+func (r *BulkReader) Next() (bm25.Document, error) {
+	for {
+		line, err := r.nextLine()
+		if err != nil {
+			return bm25.Document{}, err
+		}
+
+		var actions map[string]bulkAction
+		if err := json.Unmarshal(line, &actions); err != nil || len(actions) != 1 {
+			return bm25.Document{}, fmt.Errorf("line %d: expected a bulk action, got %s", r.line, truncate(line))
+		}
+
+		for name, action := range actions {
+			switch name {
+			case "delete":
+				continue
+			case "update":
+				if _, err := r.nextSource(); err != nil {
+					return bm25.Document{}, err
+				}
+				continue
+			case "index", "create":
+				record, err := r.nextSource()
+				if err != nil {
+					return bm25.Document{}, err
+				}
+
+				doc := r.fields.toDocument(func(field string) (any, bool) {
+					return lookupPath(record, field)
+				})
+				if r.fields.ID == "" && action.ID != nil {
+					doc.ID = fmt.Sprint(action.ID)
+				}
+				return doc, nil
+			default:
+				return bm25.Document{}, fmt.Errorf("line %d: unknown bulk action %q", r.line, name)
+			}
+		}
+	}
+}
+
+// nextSource reads the source line following an action.
+func (r *BulkReader) nextSource() (map[string]any, error) {
+	line, err := r.nextLine()
+	if err == io.EOF {
+		return nil, fmt.Errorf("line %d: %w", r.line, io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, fmt.Errorf("line %d: %w", r.line, err)
+	}
+	return record, nil
+}
+
+// nextLine returns the next non-empty line, or io.EOF.
+func (r *BulkReader) nextLine() ([]byte, error) {
+	for r.scanner.Scan() {
+		r.line++
+		if line := bytes.TrimSpace(r.scanner.Bytes()); len(line) > 0 {
+			return line, nil
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// truncate shortens a line for an error message.
+func truncate(line []byte) string {
+	const maxLen = 64
+	if len(line) > maxLen {
+		return string(line[:maxLen]) + "..."
+	}
+	return string(line)
+}
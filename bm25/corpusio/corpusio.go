@@ -0,0 +1,83 @@
+// Package corpusio streams documents from corpus files into a bm25.Builder, so large
+// corpora can be indexed without being materialized in memory.
+package corpusio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// ErrNoTextFields is returned when a reader is created without any text fields.
+var ErrNoTextFields = errors.New("at least one text field is required")
+
+// Reader streams documents from a corpus source. Next returns io.EOF once all documents
+// have been read.
+type Reader interface {
+	Next() (bm25.Document, error)
+}
+
+// Fields selects how the fields of a record are mapped onto a document.
+type Fields struct {
+	// Text lists the fields that are joined, separated by a space, into the document text.
+	Text []string
+
+	// ID is the optional field holding the external document ID.
+	ID string
+
+	// Metadata lists the optional fields copied into the document metadata.
+	Metadata []string
+}
+
+// Load reads all documents from the reader and adds them to the builder. It returns the
+// number of documents added.
+func Load(r Reader, builder *bm25.Builder) (int, error) {
+	count := 0
+	for {
+		doc, err := r.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if _, err := builder.Add(doc); err != nil {
+			return count, fmt.Errorf("record %d: %w", count+1, err)
+		}
+		count++
+	}
+}
+
+// toDocument maps the fields of a record onto a document, using lookup to read a field.
+func (f Fields) toDocument(lookup func(field string) (any, bool)) bm25.Document {
+	var doc bm25.Document
+
+	texts := make([]string, 0, len(f.Text))
+	for _, field := range f.Text {
+		if value, ok := lookup(field); ok && value != nil {
+			texts = append(texts, fmt.Sprint(value))
+		}
+	}
+	doc.Text = strings.Join(texts, " ")
+
+	if f.ID != "" {
+		if value, ok := lookup(f.ID); ok && value != nil {
+			doc.ID = fmt.Sprint(value)
+		}
+	}
+
+	for _, field := range f.Metadata {
+		if value, ok := lookup(field); ok {
+			if doc.Metadata == nil {
+				doc.Metadata = make(map[string]any, len(f.Metadata))
+			}
+			doc.Metadata[field] = value
+		}
+	}
+
+	return doc
+}
@@ -0,0 +1,64 @@
+package corpusio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// CSVReader reads documents from a CSV file whose first row holds the column names.
+type CSVReader struct {
+	reader  *csv.Reader
+	fields  Fields
+	columns map[string]int
+}
+
+// NewCSVReader creates a new CSVReader and reads the header row. Use comma to select a
+// different field delimiter, e.g. '\t' for TSV files; zero defaults to ','.
+func NewCSVReader(r io.Reader, fields Fields, comma rune) (*CSVReader, error) {
+	if len(fields.Text) == 0 {
+		return nil, ErrNoTextFields
+	}
+
+	reader := csv.NewReader(r)
+	if comma != 0 {
+		reader.Comma = comma
+	}
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+
+	for _, field := range append(append([]string{fields.ID}, fields.Text...), fields.Metadata...) {
+		if _, ok := columns[field]; field != "" && !ok {
+			return nil, fmt.Errorf("CSV header has no column %q", field)
+		}
+	}
+
+	return &CSVReader{reader: reader, fields: fields, columns: columns}, nil
+}
+
+// Next returns the next document.
+func (r *CSVReader) Next() (bm25.Document, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return bm25.Document{}, err
+	}
+
+	return r.fields.toDocument(func(field string) (any, bool) {
+		i, ok := r.columns[field]
+		if !ok || i >= len(record) {
+			return nil, false
+		}
+		return record[i], true
+	}), nil
+}
@@ -0,0 +1,114 @@
+package corpusio
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// DefaultIncludes are the glob patterns used when FSOptions.Include is empty.
+var DefaultIncludes = []string{"**/*.txt", "**/*.md"}
+
+// FSOptions controls which files an FSReader reads. Patterns use the syntax of
+// path.Match and are matched against the slash-separated path of a file relative to the
+// root, with "**" additionally matching any number of directories.
+type FSOptions struct {
+	// Include lists the patterns a file must match one of; defaults to DefaultIncludes.
+	Include []string
+
+	// Exclude lists the patterns of files to skip, taking precedence over Include.
+	Exclude []string
+}
+
+// FSReader reads documents from the files of a file system, using the path of each file
+// as the document ID. The paths are collected upfront, while the files are only read as
+// the documents are requested.
+type FSReader struct {
+	fsys  fs.FS
+	paths []string
+}
+
+// NewFSReader creates a new FSReader over all matching files in fsys, in lexical order.
+func NewFSReader(fsys fs.FS, opts FSOptions) (*FSReader, error) {
+	include := opts.Include
+	if len(include) == 0 {
+		include = DefaultIncludes
+	}
+
+	for _, pattern := range append(append([]string{}, include...), opts.Exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &FSReader{fsys: fsys}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if matchAny(include, name) && !matchAny(opts.Exclude, name) {
+			r.paths = append(r.paths, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Next returns the next document.
+func (r *FSReader) Next() (bm25.Document, error) {
+	if len(r.paths) == 0 {
+		return bm25.Document{}, io.EOF
+	}
+
+	name := r.paths[0]
+	r.paths = r.paths[1:]
+	data, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return bm25.Document{}, err
+	}
+
+	return bm25.Document{ID: name, Text: string(data)}, nil
+}
+
+// matchAny reports whether the name matches any of the patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(strings.Split(pattern, "/"), strings.Split(name, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches the segments of a path against the segments of a pattern.
+func matchGlob(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlob(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,74 @@
+package corpusio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// maxJSONLLineSize is the maximum size of a single line in a JSONL file.
+const maxJSONLLineSize = 64 * 1024 * 1024
+
+// JSONLReader reads documents from a JSON Lines file, one JSON object per line. Nested
+// fields are selected with dotted paths, e.g. "meta.title". Empty lines are skipped.
+type JSONLReader struct {
+	scanner *bufio.Scanner
+	fields  Fields
+	line    int
+}
+
+// NewJSONLReader creates a new JSONLReader.
+func NewJSONLReader(r io.Reader, fields Fields) (*JSONLReader, error) {
+	if len(fields.Text) == 0 {
+		return nil, ErrNoTextFields
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLLineSize)
+	return &JSONLReader{scanner: scanner, fields: fields}, nil
+}
+
+// Next returns the next document.
+func (r *JSONLReader) Next() (bm25.Document, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var record map[string]any
+		if err := json.Unmarshal(line, &record); err != nil {
+			return bm25.Document{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+
+		return r.fields.toDocument(func(field string) (any, bool) {
+			return lookupPath(record, field)
+		}), nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return bm25.Document{}, err
+	}
+	return bm25.Document{}, io.EOF
+}
+
+// lookupPath resolves a dotted path in a decoded JSON object.
+func lookupPath(record map[string]any, path string) (any, bool) {
+	var value any = record
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,213 @@
+package corpusio_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/corpusio"
+)
+
+func TestJSONLReader(t *testing.T) {
+	input := `{"id": "a", "title": "Hello", "body": "hello world", "meta": {"lang": "en"}}
+
+{"id": 2, "body": "this is a test"}
+`
+
+	// Test case: Creating a reader without text fields
+	_, err := corpusio.NewJSONLReader(strings.NewReader(input), corpusio.Fields{})
+	if !errors.Is(err, corpusio.ErrNoTextFields) {
+		t.Errorf("Expected ErrNoTextFields, but got %v", err)
+	}
+
+	fields := corpusio.Fields{Text: []string{"title", "body"}, ID: "id", Metadata: []string{"meta.lang"}}
+	reader, err := corpusio.NewJSONLReader(strings.NewReader(input), fields)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Reading documents with nested fields, skipping empty lines
+	doc, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if doc.ID != "a" || doc.Text != "Hello hello world" || doc.Metadata["meta.lang"] != "en" {
+		t.Errorf("Unexpected first document: %+v", doc)
+	}
+	doc, err = reader.Next()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if doc.ID != "2" || doc.Text != "this is a test" || doc.Metadata != nil {
+		t.Errorf("Unexpected second document: %+v", doc)
+	}
+	if _, err = reader.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, but got %v", err)
+	}
+
+	// Test case: Reading a malformed line
+	reader, _ = corpusio.NewJSONLReader(strings.NewReader("{\"body\": \"ok\"}\n{broken\n"), fields)
+	reader.Next()
+	_, err = reader.Next()
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error for line 2, but got %v", err)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	input := "id;title;body\na;Hello;hello world\nb;;this is a test\n"
+
+	// Test case: Creating a reader for a column missing from the header
+	_, err := corpusio.NewCSVReader(strings.NewReader(input), corpusio.Fields{Text: []string{"text"}}, ';')
+	if err == nil {
+		t.Errorf("Expected an error for a missing column, but got nil")
+	}
+
+	// Test case: Reading documents with a custom delimiter
+	fields := corpusio.Fields{Text: []string{"body"}, ID: "id", Metadata: []string{"title"}}
+	reader, err := corpusio.NewCSVReader(strings.NewReader(input), fields, ';')
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	doc, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if doc.ID != "a" || doc.Text != "hello world" || doc.Metadata["title"] != "Hello" {
+		t.Errorf("Unexpected first document: %+v", doc)
+	}
+	reader.Next()
+	if _, err = reader.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, but got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	input := "{\"id\": \"a\", \"text\": \"hello world\"}\n{\"id\": \"b\", \"text\": \"hello there\"}\n"
+	reader, _ := corpusio.NewJSONLReader(strings.NewReader(input), corpusio.Fields{Text: []string{"text"}, ID: "id"})
+	builder, _ := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+
+	// Test case: Loading all documents into a builder
+	count, err := corpusio.Load(reader, builder)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 documents, but got %d", count)
+	}
+	base, _ := builder.Build()
+	if docID, ok := base.LookupID("b"); !ok || docID != 1 {
+		t.Errorf("Expected document ID 1 for 'b', but got %d (present: %v)", docID, ok)
+	}
+
+	// Test case: Loading a document with a duplicate ID reports the record
+	reader, _ = corpusio.NewJSONLReader(strings.NewReader(input+input), corpusio.Fields{Text: []string{"text"}, ID: "id"})
+	builder, _ = bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	count, err = corpusio.Load(reader, builder)
+	if !errors.Is(err, bm25.ErrDuplicateID) || count != 2 {
+		t.Errorf("Expected ErrDuplicateID after 2 documents, but got %v after %d", err, count)
+	}
+}
+
+func TestFSReader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README.md":          {Data: []byte("hello readme")},
+		"notes/a.txt":        {Data: []byte("hello world")},
+		"notes/deep/b.txt":   {Data: []byte("this is a test")},
+		"notes/draft/c.txt":  {Data: []byte("unfinished")},
+		"notes/image.png":    {Data: []byte{0x89}},
+		"other/deep/note.md": {Data: []byte("hello there")},
+	}
+
+	// Test case: Creating a reader with an invalid pattern
+	_, err := corpusio.NewFSReader(fsys, corpusio.FSOptions{Include: []string{"[a"}})
+	if err == nil {
+		t.Errorf("Expected an error for an invalid pattern, but got nil")
+	}
+
+	// Test case: Reading the default text and Markdown files, minus the excluded ones
+	reader, err := corpusio.NewFSReader(fsys, corpusio.FSOptions{Exclude: []string{"**/draft/**"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var ids []string
+	for {
+		doc, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		ids = append(ids, doc.ID)
+	}
+	expected := []string{"README.md", "notes/a.txt", "notes/deep/b.txt", "other/deep/note.md"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected %v, but got %v", expected, ids)
+	}
+
+	// Test case: Restricting the files to a directory and building an index from them
+	reader, _ = corpusio.NewFSReader(fsys, corpusio.FSOptions{Include: []string{"notes/*.txt"}})
+	builder, _ := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	count, err := corpusio.Load(reader, builder)
+	if err != nil || count != 1 {
+		t.Errorf("Expected 1 document, but got %d (error: %v)", count, err)
+	}
+	base, _ := builder.Build()
+	if id := base.ExternalID(0); id != "notes/a.txt" {
+		t.Errorf("Expected the ID 'notes/a.txt', but got %q", id)
+	}
+}
+
+func TestBulkReader(t *testing.T) {
+	input := `{"index": {"_index": "articles", "_id": "1"}}
+{"title": "Hello", "body": "hello world", "lang": "en"}
+{"delete": {"_index": "articles", "_id": "2"}}
+{"update": {"_index": "articles", "_id": "3"}}
+{"doc": {"body": "ignored"}}
+
+{"create": {"_index": "articles", "_id": 4}}
+{"body": "created document"}
+`
+	fields := corpusio.Fields{Text: []string{"title", "body"}, Metadata: []string{"lang"}}
+
+	// Test case: Reading index and create actions, skipping deletes and updates
+	reader, err := corpusio.NewBulkReader(strings.NewReader(input), fields)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var docs []bm25.Document
+	for {
+		doc, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		docs = append(docs, doc)
+	}
+	expected := []bm25.Document{
+		{ID: "1", Text: "Hello hello world", Metadata: map[string]any{"lang": "en"}},
+		{ID: "4", Text: "created document"},
+	}
+	if !reflect.DeepEqual(docs, expected) {
+		t.Errorf("Expected documents %v, but got %v", expected, docs)
+	}
+
+	// Test case: An action without its source line
+	reader, _ = corpusio.NewBulkReader(strings.NewReader(`{"index": {"_id": "1"}}`), fields)
+	if _, err := reader.Next(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected io.ErrUnexpectedEOF, but got %v", err)
+	}
+
+	// Test case: A line that is not an action
+	reader, _ = corpusio.NewBulkReader(strings.NewReader(`{"body": "no action"}`), fields)
+	if _, err := reader.Next(); err == nil {
+		t.Errorf("Expected an error for a line that is not an action")
+	}
+}
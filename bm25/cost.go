@@ -0,0 +1,83 @@
+package bm25
+
+import "fmt"
+
+// LatencyClass is the approximate latency of a query, see EstimateCost.
+type LatencyClass int
+
+const (
+	// LatencyLow is a query whose terms are rare: it scans less than a tenth of the
+	// corpus.
+	LatencyLow LatencyClass = iota
+	// LatencyMedium is a query that scans up to one posting per document of the corpus.
+	LatencyMedium
+	// LatencyHigh is a query that scans more postings than the corpus has documents, e.g.
+	// one made of several very common terms, or expanded into many terms.
+	LatencyHigh
+)
+
+// String returns the name of the latency class.
+func (c LatencyClass) String() string {
+	switch c {
+	case LatencyLow:
+		return "low"
+	case LatencyMedium:
+		return "medium"
+	case LatencyHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("LatencyClass(%d)", int(c))
+	}
+}
+
+// QueryCost is the estimated cost of a query, see EstimateCost.
+type QueryCost struct {
+	// Terms is the number of terms the query is scored with, after the stopwords of the
+	// QueryDictionary are removed and the synonyms and subwords are expanded.
+	Terms int `json:"terms"`
+
+	// Stopwords is the number of those terms skipped because they are excluded from
+	// scoring, see ExcludeStopwords.
+	Stopwords int `json:"stopwords"`
+
+	// Postings is the number of postings to scan: the sum of the document frequencies of
+	// the scored terms.
+	Postings int `json:"postings"`
+
+	// Class is the latency class of the query, given by the postings per document.
+	Class LatencyClass `json:"class"`
+}
+
+// EstimateCost estimates the cost of a query from the document frequencies of its terms,
+// without running it, so servers can reject or deprioritize expensive queries upfront.
+// The query is rewritten like a search rewrites it.
+func (b *Bm25Base) EstimateCost(query []string) QueryCost {
+	query = b.rewriteTerms(query)
+
+	cost := QueryCost{Terms: len(query)}
+	for _, term := range query {
+		if b.isStopword(term) {
+			cost.Stopwords++
+			continue
+		}
+		docFreq, _ := b.docFreq(term)
+		cost.Postings += docFreq
+	}
+
+	switch {
+	case cost.Postings == 0 || cost.Postings*10 < b.corpusSize:
+		cost.Class = LatencyLow
+	case cost.Postings <= b.corpusSize:
+		cost.Class = LatencyMedium
+	default:
+		cost.Class = LatencyHigh
+	}
+	return cost
+}
+
+// rewriteTerms rewrites query terms like a search does: the stopwords of the
+// QueryDictionary are removed, and the synonyms and subwords are expanded.
+func (b *Bm25Base) rewriteTerms(query []string) []string {
+	dictionary := b.dictionary.snapshot()
+	return b.ExpandQuery(dictionary.expandSynonyms(dictionary.removeStopwords(query)))
+}
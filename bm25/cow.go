@@ -0,0 +1,127 @@
+package bm25
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// freezableIndex is implemented by the BM25 variants that support adding documents, whose
+// Freeze returns a copy of their own type.
+type freezableIndex[T BM25] interface {
+	documentAdder
+	Freeze() T
+}
+
+// cowVersion is a published, immutable version of a CopyOnWriteIndex.
+type cowVersion struct {
+	index   BM25
+	version uint64
+}
+
+// CopyOnWriteIndex serves searches from an immutable, frozen copy of an index while
+// writers modify a private working copy, so searches never wait for writers. After every
+// batch of mutations, a new frozen copy is published atomically; searches that started
+// before keep the version they started with. It is safe for concurrent use.
+//
+// Reads are eventually consistent: a search sees the state as of the last published
+// batch, never a partially applied one. A writer reads its own writes once Add or
+// Update has returned, as both publish before returning. Publishing copies the whole
+// index, so mutations should be applied in batches rather than one document at a time.
+// Writers are serialized with each other.
+type CopyOnWriteIndex struct {
+	mu      sync.Mutex // Serializes writers
+	writer  documentAdder
+	freeze  func() BM25
+	current atomic.Pointer[cowVersion]
+}
+
+// NewCopyOnWriteIndex wraps a BM25 variant in a CopyOnWriteIndex and publishes its
+// current state. The index must not be used directly anymore afterwards.
+func NewCopyOnWriteIndex[T freezableIndex[T]](index T) (*CopyOnWriteIndex, error) {
+	if index.baseIndex().frozen {
+		return nil, ErrFrozen
+	}
+
+	c := &CopyOnWriteIndex{
+		writer: index,
+		freeze: func() BM25 { return index.Freeze() },
+	}
+	c.current.Store(&cowVersion{index: c.freeze()})
+	return c, nil
+}
+
+// Add adds a batch of documents to the index and publishes them together. It returns
+// their internal IDs. If a document cannot be added, the documents before it are still
+// published and the error is returned.
+func (c *CopyOnWriteIndex) Add(docs ...Document) ([]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.publish()
+
+	docIDs := make([]int, 0, len(docs))
+	for _, doc := range docs {
+		docID, err := c.writer.AddDocument(doc)
+		if err != nil {
+			return docIDs, err
+		}
+		docIDs = append(docIDs, docID)
+	}
+	return docIDs, nil
+}
+
+// Update applies a batch of mutations to the working copy of the index, e.g. changing
+// its settings or compacting it, and publishes the result. The working copy must not be
+// retained after fn returns. Changes made before fn returns an error are published too.
+func (c *CopyOnWriteIndex) Update(fn func(base *Bm25Base) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.publish()
+
+	return fn(c.writer.baseIndex())
+}
+
+// checkDocuments returns ErrNotImplemented if the index does not support adding single
+// documents, like BM25F, whose documents are made of several fields.
+func (c *CopyOnWriteIndex) checkDocuments() error {
+	if _, ok := c.writer.(*BM25F); ok {
+		return ErrNotImplemented
+	}
+	return nil
+}
+
+// publish freezes the working copy and makes it the current version.
+func (c *CopyOnWriteIndex) publish() {
+	c.current.Store(&cowVersion{index: c.freeze(), version: c.current.Load().version + 1})
+}
+
+// Current returns the currently published version of the index. It is frozen, so
+// several calls on it see a consistent state while writers move on.
+func (c *CopyOnWriteIndex) Current() BM25 {
+	return c.current.Load().index
+}
+
+// Version returns the number of batches published since the index was wrapped.
+func (c *CopyOnWriteIndex) Version() uint64 {
+	return c.current.Load().version
+}
+
+// Search runs the given search request against the currently published version.
+func (c *CopyOnWriteIndex) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return c.Current().Search(ctx, req)
+}
+
+// GetScores returns the scores of all documents of the currently published version.
+func (c *CopyOnWriteIndex) GetScores(query []string) ([]float64, error) {
+	return c.Current().GetScores(query)
+}
+
+// GetTopN returns the top N documents of the currently published version.
+func (c *CopyOnWriteIndex) GetTopN(query []string, n int) ([]string, error) {
+	return c.Current().GetTopN(query, n)
+}
+
+// CorpusSize returns the number of documents of the currently published version.
+func (c *CopyOnWriteIndex) CorpusSize() int {
+	return c.Current().CorpusSize()
+}
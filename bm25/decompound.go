@@ -0,0 +1,153 @@
+package bm25
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DecompoundOptions configures DecompoundFilter.
+type DecompoundOptions struct {
+	// MinWordLength is the minimum length, in characters, of the tokens that are
+	// decomposed. Defaults to 6.
+	MinWordLength int
+
+	// MinPartLength is the minimum length, in characters, of the parts. Defaults to 3.
+	MinPartLength int
+
+	// LinkingElements are the letters that may join two parts without belonging to
+	// either, e.g. "s" and "es" for German, as in "Arbeitsplatz".
+	LinkingElements []string
+}
+
+// DecompoundFilter returns a filter decomposing compound words into the words of a
+// dictionary, for languages such as German, Dutch or the Scandinavian languages, so e.g.
+// "fußballweltmeisterschaft" also matches "fußball". A token that can be split entirely
+// into dictionary words, optionally joined by linking elements, is followed by its parts;
+// the split with the fewest parts is used. The token itself is kept, so exact matches
+// still score highest. The dictionary is matched case-insensitively, and the parts are
+// lowercase. As the parts are additional tokens, they count towards the document length.
+func DecompoundFilter(dictionary []string, opts DecompoundOptions) (TokenFilter, error) {
+	if opts.MinWordLength == 0 {
+		opts.MinWordLength = 6
+	}
+	if opts.MinPartLength == 0 {
+		opts.MinPartLength = 3
+	}
+	if opts.MinWordLength < 1 {
+		return nil, invalidParam("MinWordLength", opts.MinWordLength, "must be a positive integer")
+	}
+	if opts.MinPartLength < 1 {
+		return nil, invalidParam("MinPartLength", opts.MinPartLength, "must be a positive integer")
+	}
+	for _, link := range opts.LinkingElements {
+		if link == "" {
+			return nil, invalidParam("LinkingElements", link, "must not contain empty elements")
+		}
+	}
+
+	d := &decompounder{opts: opts, words: make(map[string]struct{}, len(dictionary))}
+	for _, word := range dictionary {
+		word = strings.ToLower(word)
+		if n := utf8.RuneCountInString(word); n >= opts.MinPartLength {
+			d.words[word] = struct{}{}
+			d.maxPartLength = max(d.maxPartLength, n)
+		}
+	}
+	d.opts.LinkingElements = make([]string, len(opts.LinkingElements))
+	for i, link := range opts.LinkingElements {
+		d.opts.LinkingElements[i] = strings.ToLower(link)
+	}
+
+	return func(tokens []string) []string {
+		var decomposed []string
+		for i, token := range tokens {
+			parts := d.split(token)
+			if parts == nil {
+				if decomposed != nil {
+					decomposed = append(decomposed, token)
+				}
+				continue
+			}
+			if decomposed == nil {
+				decomposed = append(make([]string, 0, len(tokens)+len(parts)), tokens[:i]...)
+			}
+			decomposed = append(decomposed, token)
+			decomposed = append(decomposed, parts...)
+		}
+		if decomposed == nil {
+			return tokens
+		}
+		return decomposed
+	}, nil
+}
+
+// decompounder splits compound words into the words of a dictionary.
+type decompounder struct {
+	opts          DecompoundOptions
+	words         map[string]struct{}
+	maxPartLength int
+}
+
+// split returns the parts of a compound word, or nil if it cannot be split into two or
+// more dictionary words.
+func (d *decompounder) split(token string) []string {
+	word := []rune(strings.ToLower(token))
+	n := len(word)
+	if n < d.opts.MinWordLength || n < 2*d.opts.MinPartLength {
+		return nil
+	}
+
+	// ends[j] is the fewest parts covering word[:j] with a part ending at j, which starts
+	// at partStart[j]; links[k] is the same for a part followed by a linking element
+	// ending at k, whose part ends at linkStart[k]
+	ends, partStart := make([]int, n+1), make([]int, n+1)
+	links, linkStart := make([]int, n+1), make([]int, n+1)
+	for i := range ends {
+		ends[i], links[i] = -1, -1
+	}
+	from := make([]int, n+1) // End of the last part before a part starting at i
+
+	for i := 0; i < n; i++ {
+		count := -1
+		switch {
+		case i == 0:
+			count = 0
+		case ends[i] >= 0 && (links[i] < 0 || ends[i] <= links[i]):
+			count, from[i] = ends[i], i
+		case links[i] >= 0:
+			count, from[i] = links[i], linkStart[i]
+		}
+		if count < 0 {
+			continue
+		}
+
+		for length := d.opts.MinPartLength; length <= d.maxPartLength && i+length <= n; length++ {
+			if _, ok := d.words[string(word[i:i+length])]; !ok {
+				continue
+			}
+			if end := i + length; ends[end] < 0 || count+1 < ends[end] {
+				ends[end], partStart[end] = count+1, i
+			}
+		}
+		if ends[i] < 0 {
+			continue
+		}
+		for _, link := range d.opts.LinkingElements {
+			end := i + utf8.RuneCountInString(link)
+			if end < n && strings.HasPrefix(string(word[i:]), link) && (links[end] < 0 || ends[i] < links[end]) {
+				links[end], linkStart[end] = ends[i], i
+			}
+		}
+	}
+	if ends[n] < 2 {
+		return nil
+	}
+
+	split := make([]string, ends[n])
+	for end, k := n, ends[n]-1; k >= 0; k-- {
+		start := partStart[end]
+		split[k] = string(word[start:end])
+		end = from[start]
+	}
+	return split
+}
@@ -0,0 +1,28 @@
+package bm25
+
+import "math"
+
+// log2E is log2(e), the factor converting natural logarithms to binary ones.
+const log2E = 1 / math.Ln2
+
+// collectionFrequency returns the number of occurrences of a term in the whole corpus,
+// which the Divergence From Randomness models, PL2 and DPH, and QueryLikelihood compare
+// the frequency of the term in a document with. Counting them scans the corpus, so they
+// are cached with the other corpus statistics, unless the caches are read-only.
+func (b *Bm25Base) collectionFrequency(term string) float64 {
+	if freq, ok := b.collFreqs[term]; ok {
+		return freq
+	}
+
+	var freq float64
+	for i := range b.docLengths {
+		freq += b.termFrequency(i, term)
+	}
+	if b.cachesWritable() {
+		if b.collFreqs == nil {
+			b.collFreqs = make(map[string]float64)
+		}
+		b.collFreqs[term] = freq
+	}
+	return freq
+}
@@ -0,0 +1,137 @@
+package bm25
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+)
+
+// TermStatus tells how a query term is used by the ranking.
+type TermStatus int
+
+const (
+	// TermMatched is a vocabulary term that contributes to the scores.
+	TermMatched TermStatus = iota
+	// TermOutOfVocabulary is a term that appears in no document, so it matches nothing.
+	// It is often caused by a typo, or by a query tokenized differently from the documents.
+	TermOutOfVocabulary
+	// TermStopword is a term excluded from scoring with ExcludeStopwords.
+	TermStopword
+	// TermLowIDF is a term so common in the corpus that it barely affects the ranking, or
+	// lowers the scores of the documents containing it if its IDF is negative.
+	TermLowIDF
+)
+
+// String returns a short description of the status.
+func (s TermStatus) String() string {
+	switch s {
+	case TermMatched:
+		return "matched"
+	case TermOutOfVocabulary:
+		return "out of vocabulary"
+	case TermStopword:
+		return "stopword"
+	case TermLowIDF:
+		return "low IDF"
+	default:
+		return "unknown"
+	}
+}
+
+// TermDiagnostic holds the diagnosis of a single query term.
+type TermDiagnostic struct {
+	Term    string
+	Status  TermStatus
+	DocFreq int
+	IDF     float64
+}
+
+// QueryDiagnostics is a report on how the terms of a query are used by the ranking, meant
+// to explain empty or unexpected results to developers and end users.
+type QueryDiagnostics struct {
+	// Terms holds the distinct query terms, in query order.
+	Terms []TermDiagnostic
+}
+
+// DiagnoseQuery reports which terms of a tokenized query are out of the vocabulary, which
+// are excluded as stopwords, and which have an IDF of at most maxIDF, e.g. 0.1, so they
+// contribute next to nothing to the scores. Stopwords are reported as such even if they
+// are not in the vocabulary. Stopwords removed by an analyzer never reach the query, and
+// so are not reported; compare the query text with the tokens of Analyze to find them.
+func (b *Bm25Base) DiagnoseQuery(query []string, maxIDF float64) (*QueryDiagnostics, error) {
+	if math.IsNaN(maxIDF) {
+		return nil, invalidParam("maxIDF", maxIDF, "must not be NaN")
+	}
+
+	diagnostics := &QueryDiagnostics{}
+	seen := make(map[string]struct{}, len(query))
+	for _, term := range query {
+		if term == "" {
+			return nil, ErrEmptyTerm
+		}
+		if _, ok := seen[term]; ok {
+			continue
+		}
+		seen[term] = struct{}{}
+
+		diagnostic := TermDiagnostic{Term: term}
+		diagnostic.DocFreq, _ = b.docFreq(term)
+		switch {
+		case b.isStopword(term):
+			diagnostic.Status = TermStopword
+		case diagnostic.DocFreq == 0:
+			diagnostic.Status = TermOutOfVocabulary
+		default:
+			diagnostic.IDF, _ = b.IDF(term) // Empty terms are rejected above
+			if diagnostic.IDF <= maxIDF {
+				diagnostic.Status = TermLowIDF
+			}
+		}
+		diagnostics.Terms = append(diagnostics.Terms, diagnostic)
+	}
+	return diagnostics, nil
+}
+
+// Matched returns the terms that contribute to the scores.
+func (d *QueryDiagnostics) Matched() []string {
+	return d.terms(TermMatched)
+}
+
+// OutOfVocabulary returns the terms that appear in no document.
+func (d *QueryDiagnostics) OutOfVocabulary() []string {
+	return d.terms(TermOutOfVocabulary)
+}
+
+// Stopwords returns the terms excluded from scoring.
+func (d *QueryDiagnostics) Stopwords() []string {
+	return d.terms(TermStopword)
+}
+
+// LowIDF returns the terms whose IDF is too low to affect the ranking.
+func (d *QueryDiagnostics) LowIDF() []string {
+	return d.terms(TermLowIDF)
+}
+
+// terms returns the terms with the given status, in query order.
+func (d *QueryDiagnostics) terms(status TermStatus) []string {
+	var terms []string
+	for _, term := range d.Terms {
+		if term.Status == status {
+			terms = append(terms, term.Term)
+		}
+	}
+	return terms
+}
+
+// String formats the report as a human-readable table.
+func (d *QueryDiagnostics) String() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Term\tStatus\tDocument frequency\tIDF\n")
+	for _, term := range d.Terms {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.4f\n", term.Term, term.Status, term.DocFreq, term.IDF)
+	}
+	w.Flush()
+	return sb.String()
+}
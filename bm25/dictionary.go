@@ -0,0 +1,233 @@
+package bm25
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// QueryDictionary holds the stopwords removed from queries, the synonyms they are
+// expanded with and the entities whose matches are boosted. Unlike ExcludeStopwords or an analyzer, it only applies at query time
+// and can be updated while the index is being searched, without rebuilding or
+// republishing it: the frozen copies of an index share its dictionary, so an update takes
+// effect on all of them at once. Every update publishes a new version atomically, and a
+// search uses the version current when it started from beginning to end, so it never
+// sees the stopwords of one version with the synonyms of another. It is safe for
+// concurrent use.
+type QueryDictionary struct {
+	mu      sync.Mutex // Serializes updates
+	current atomic.Pointer[dictionaryVersion]
+}
+
+// dictionaryVersion is a published, immutable version of a QueryDictionary.
+type dictionaryVersion struct {
+	version   uint64
+	stopwords map[string]struct{}
+	synonyms  map[string][]string
+	entities  map[string]float64
+}
+
+// NewQueryDictionary creates a new, empty QueryDictionary.
+func NewQueryDictionary() *QueryDictionary {
+	d := &QueryDictionary{}
+	d.current.Store(&dictionaryVersion{})
+	return d
+}
+
+// SetStopwords replaces the stopwords and returns the new version of the dictionary.
+// Passing nil or an empty slice clears them.
+func (d *QueryDictionary) SetStopwords(stopwords []string) uint64 {
+	set := make(map[string]struct{}, len(stopwords))
+	for _, term := range stopwords {
+		set[term] = struct{}{}
+	}
+
+	return d.update(func(v *dictionaryVersion) { v.stopwords = set })
+}
+
+// SetSynonyms replaces the synonyms and returns the new version of the dictionary. A
+// query term is expanded with its synonyms, in the given order; synonyms are one-way, so
+// equivalent terms have to list each other. Passing nil or an empty map clears them.
+func (d *QueryDictionary) SetSynonyms(synonyms map[string][]string) (uint64, error) {
+	copied := make(map[string][]string, len(synonyms))
+	for term, expansions := range synonyms {
+		if term == "" || slices.Contains(expansions, "") {
+			return d.Version(), invalidParam("synonyms", term, "must not contain empty terms")
+		}
+		copied[term] = slices.Clone(expansions)
+	}
+
+	return d.update(func(v *dictionaryVersion) { v.synonyms = copied }), nil
+}
+
+// SetEntities replaces the entities and returns the new version of the dictionary. An
+// entity is a term, or a phrase of terms joined by ShingleSeparator, e.g. a product name
+// or a person recognized upstream, mapped to a boost. The scores of the query terms that
+// are entities, or part of an entity phrase occurring in the query, are multiplied by its
+// boost, as is the score of the phrase itself, which matches if the index has shingles.
+// The index is not changed. Passing nil or an empty map clears them.
+func (d *QueryDictionary) SetEntities(entities map[string]float64) (uint64, error) {
+	copied := make(map[string]float64, len(entities))
+	for entity, boost := range entities {
+		if slices.Contains(strings.Split(entity, ShingleSeparator), "") {
+			return d.Version(), invalidParam("entities", entity, "must not contain empty terms")
+		}
+		if boost < 0 || math.IsNaN(boost) || math.IsInf(boost, 0) {
+			return d.Version(), invalidParam("entities."+entity, boost, "must be a non-negative finite number")
+		}
+		copied[entity] = boost
+	}
+
+	return d.update(func(v *dictionaryVersion) { v.entities = copied }), nil
+}
+
+// update publishes a copy of the current version modified by fn.
+func (d *QueryDictionary) update(fn func(v *dictionaryVersion)) uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	next := *d.current.Load()
+	next.version++
+	fn(&next)
+	d.current.Store(&next)
+	return next.version
+}
+
+// Version returns the number of updates since the dictionary was created.
+func (d *QueryDictionary) Version() uint64 {
+	return d.current.Load().version
+}
+
+// Stopwords returns the current stopwords, sorted alphabetically.
+func (d *QueryDictionary) Stopwords() []string {
+	stopwords := slices.Collect(maps.Keys(d.current.Load().stopwords))
+	sort.Strings(stopwords)
+	return stopwords
+}
+
+// Synonyms returns a copy of the current synonyms.
+func (d *QueryDictionary) Synonyms() map[string][]string {
+	synonyms := make(map[string][]string)
+	for term, expansions := range d.current.Load().synonyms {
+		synonyms[term] = slices.Clone(expansions)
+	}
+	return synonyms
+}
+
+// Entities returns a copy of the current entities and their boosts.
+func (d *QueryDictionary) Entities() map[string]float64 {
+	entities := maps.Clone(d.current.Load().entities)
+	if entities == nil {
+		entities = map[string]float64{}
+	}
+	return entities
+}
+
+// snapshot returns the current version, or nil if there is no dictionary.
+func (d *QueryDictionary) snapshot() *dictionaryVersion {
+	if d == nil {
+		return nil
+	}
+	return d.current.Load()
+}
+
+// removeStopwords returns the query without its stopwords. A query made of stopwords only
+// is returned unchanged, so it still finds the documents containing them.
+func (v *dictionaryVersion) removeStopwords(query []string) []string {
+	if v == nil || len(v.stopwords) == 0 {
+		return query
+	}
+
+	kept := make([]string, 0, len(query))
+	for _, term := range query {
+		if _, ok := v.stopwords[term]; !ok {
+			kept = append(kept, term)
+		}
+	}
+	if len(kept) == 0 {
+		return query
+	}
+	return kept
+}
+
+// expandSynonyms returns the query with every term followed by its synonyms that are not
+// in the query already.
+func (v *dictionaryVersion) expandSynonyms(query []string) []string {
+	if v == nil || len(v.synonyms) == 0 {
+		return query
+	}
+
+	seen := make(map[string]bool, len(query))
+	for _, term := range query {
+		seen[term] = true
+	}
+	expanded := make([]string, 0, len(query))
+	for _, term := range query {
+		expanded = append(expanded, term)
+		for _, synonym := range v.synonyms[term] {
+			if !seen[synonym] {
+				seen[synonym] = true
+				expanded = append(expanded, synonym)
+			}
+		}
+	}
+	return expanded
+}
+
+// entityBoosts returns the boosts of the query terms that are entities or part of an
+// entity phrase occurring in the query, and of the phrases themselves, or nil if there
+// are none. Terms of several entities get the highest of their boosts.
+func (v *dictionaryVersion) entityBoosts(query []string) map[string]float64 {
+	if v == nil || len(v.entities) == 0 {
+		return nil
+	}
+
+	var boosts map[string]float64
+	boost := func(term string, factor float64) {
+		if boosts == nil {
+			boosts = make(map[string]float64)
+		}
+		if current, ok := boosts[term]; !ok || factor > current {
+			boosts[term] = factor
+		}
+	}
+	for entity, factor := range v.entities {
+		// A phrase occurs as consecutive terms, or as a shingle of a query analyzed with
+		// the shingle filter of the index
+		words := strings.Split(entity, ShingleSeparator)
+		matched := slices.Contains(query, entity)
+		for i := 0; !matched && i+len(words) <= len(query); i++ {
+			matched = slices.Equal(query[i:i+len(words)], words)
+		}
+		if matched {
+			for _, word := range words {
+				boost(word, factor)
+			}
+			boost(entity, factor)
+		}
+	}
+	return boosts
+}
+
+// SetQueryDictionary sets the dictionary applied to the queries of Search, see
+// QueryDictionary. Passing nil removes it. The dictionary is shared with the frozen copies
+// and clones of the index, so it can be set on a CopyOnWriteIndex with Update once and
+// updated directly afterwards.
+func (b *Bm25Base) SetQueryDictionary(dictionary *QueryDictionary) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	b.dictionary = dictionary
+	b.settings++
+	return nil
+}
+
+// QueryDictionary returns the dictionary applied to the queries of Search, or nil.
+func (b *Bm25Base) QueryDictionary() *QueryDictionary {
+	return b.dictionary
+}
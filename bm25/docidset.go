@@ -0,0 +1,185 @@
+package bm25
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+)
+
+// DocIDSet is a set of document IDs, e.g. the candidates derived from a filter.
+type DocIDSet interface {
+	// AppendDocIDs appends the IDs in the set to ids, in any order, and returns the
+	// extended slice.
+	AppendDocIDs(ids []int) []int
+}
+
+// DocIDs is a DocIDSet holding an explicit list of document IDs, which may contain
+// duplicates.
+type DocIDs []int
+
+// AppendDocIDs appends the IDs in the list to ids.
+func (d DocIDs) AppendDocIDs(ids []int) []int {
+	return append(ids, d...)
+}
+
+// DocIDRange is a DocIDSet holding the document IDs from Start up to, but not including, End.
+type DocIDRange struct {
+	Start, End int
+}
+
+// AppendDocIDs appends the IDs in the range to ids.
+func (r DocIDRange) AppendDocIDs(ids []int) []int {
+	for docID := r.Start; docID < r.End; docID++ {
+		ids = append(ids, docID)
+	}
+	return ids
+}
+
+// Bitmap is a dense DocIDSet with one bit per document ID. The zero value is an empty set,
+// and a nil *Bitmap is an empty set that cannot be added to.
+type Bitmap struct {
+	words []uint64
+}
+
+// NewBitmap creates an empty Bitmap with room for the document IDs below n.
+func NewBitmap(n int) *Bitmap {
+	return &Bitmap{words: make([]uint64, (n+63)/64)}
+}
+
+// Add adds a document ID to the set. Negative IDs are ignored.
+func (m *Bitmap) Add(docID int) {
+	if docID < 0 {
+		return
+	}
+	m.words = growTo(m.words, docID/64)
+	m.words[docID/64] |= 1 << (docID % 64)
+}
+
+// Remove removes a document ID from the set.
+func (m *Bitmap) Remove(docID int) {
+	if m != nil && docID >= 0 && docID/64 < len(m.words) {
+		m.words[docID/64] &^= 1 << (docID % 64)
+	}
+}
+
+// Contains reports whether a document ID is in the set.
+func (m *Bitmap) Contains(docID int) bool {
+	return m != nil && docID >= 0 && docID/64 < len(m.words) && m.words[docID/64]&(1<<(docID%64)) != 0
+}
+
+// addAll adds the document IDs of another set to the set.
+func (m *Bitmap) addAll(other *Bitmap) {
+	if len(other.words) > 0 {
+		m.words = growTo(m.words, len(other.words)-1)
+	}
+	for i, word := range other.words {
+		m.words[i] |= word
+	}
+}
+
+// Len returns the number of document IDs in the set.
+func (m *Bitmap) Len() int {
+	if m == nil {
+		return 0
+	}
+	n := 0
+	for _, word := range m.words {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
+
+// AppendDocIDs appends the IDs in the set to ids, in ascending order.
+func (m *Bitmap) AppendDocIDs(ids []int) []int {
+	if m == nil {
+		return ids
+	}
+	for i, word := range m.words {
+		for word != 0 {
+			ids = append(ids, i*64+bits.TrailingZeros64(word))
+			word &= word - 1
+		}
+	}
+	return ids
+}
+
+// RoaringSet adapts a compressed bitmap, such as a *roaring.Bitmap from
+// github.com/RoaringBitmap/roaring, to a DocIDSet.
+func RoaringSet(bitmap interface{ ToArray() []uint32 }) DocIDSet {
+	return roaringSet{bitmap}
+}
+
+// roaringSet is the DocIDSet returned by RoaringSet.
+type roaringSet struct {
+	bitmap interface{ ToArray() []uint32 }
+}
+
+// AppendDocIDs appends the IDs in the bitmap to ids.
+func (r roaringSet) AppendDocIDs(ids []int) []int {
+	for _, docID := range r.bitmap.ToArray() {
+		ids = append(ids, int(docID))
+	}
+	return ids
+}
+
+// sortedDocIDSet is implemented by the DocIDSets whose AppendDocIDs appends distinct IDs
+// in ascending order, which need no sorting.
+type sortedDocIDSet interface {
+	DocIDSet
+	sortedDocIDs()
+}
+
+func (DocIDRange) sortedDocIDs() {}
+func (*Bitmap) sortedDocIDs()    {}
+
+// resolveDocIDs returns the distinct IDs of a set in ascending order, checking that they
+// are all within a corpus of the given size.
+func resolveDocIDs(set DocIDSet, corpusSize int) ([]int, error) {
+	if set == nil {
+		return nil, ErrEmptyDocIDs
+	}
+
+	// A range is checked before it is expanded, so an invalid one allocates nothing
+	if r, ok := set.(DocIDRange); ok && r.Start < r.End {
+		for _, docID := range []int{r.Start, r.End - 1} {
+			if docID < 0 || docID >= corpusSize {
+				return nil, fmt.Errorf("%w: %d", ErrInvalidDocID, docID)
+			}
+		}
+	}
+
+	docIDs := set.AppendDocIDs(nil)
+	if _, ok := set.(sortedDocIDSet); !ok {
+		slices.Sort(docIDs)
+		docIDs = slices.Compact(docIDs)
+	}
+
+	if len(docIDs) == 0 {
+		return nil, ErrEmptyDocIDs
+	}
+
+	// Only the bounds need checking once the IDs are sorted
+	for _, docID := range []int{docIDs[0], docIDs[len(docIDs)-1]} {
+		if docID < 0 || docID >= corpusSize {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidDocID, docID)
+		}
+	}
+	return docIDs, nil
+}
+
+// GetBatchScoresSet returns the scores of an index for the query and the documents in a
+// set, together with the IDs of the scored documents. Duplicate IDs are scored once, and
+// the IDs are returned in ascending order. Ranges and bitmaps are already ordered, so
+// they are scored without sorting their IDs.
+func GetBatchScoresSet(index BM25, query []string, set DocIDSet) ([]int, []float64, error) {
+	docIDs, err := resolveDocIDs(set, index.CorpusSize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scores, err := index.GetBatchScores(query, docIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return docIDs, scores, nil
+}
@@ -0,0 +1,49 @@
+package bm25
+
+import "fmt"
+
+// DocStore fetches the original text of documents by their internal ID, so it can live in
+// an external store, e.g. a SQL database, S3 or a key-value store, and only be fetched
+// for the documents that are returned.
+type DocStore interface {
+	Get(docID int) (string, error)
+}
+
+// DocStoreFunc adapts a function to the DocStore interface.
+type DocStoreFunc func(docID int) (string, error)
+
+// Get calls the function.
+func (f DocStoreFunc) Get(docID int) (string, error) {
+	return f(docID)
+}
+
+// SetDocStore sets the store GetTopN and Search fetch the text of the returned documents
+// from, instead of joining their stored tokens. A nil store restores the default. As
+// Compact renumbers documents, stores of compacted indexes should be keyed by external ID,
+// looked up with ExternalID.
+func (b *Bm25Base) SetDocStore(store DocStore) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+	b.docStore = store
+	return nil
+}
+
+// DocStore returns the store set with SetDocStore, or nil if there is none.
+func (b *Bm25Base) DocStore() DocStore {
+	return b.docStore
+}
+
+// docText returns the text of a document for a result: from the document store if one
+// is set, otherwise its stored tokens joined by spaces.
+func (b *Bm25Base) docText(docID int) (string, error) {
+	if b.docStore == nil {
+		return JoinTokens(b.doc(docID), " "), nil
+	}
+
+	text, err := b.docStore.Get(docID)
+	if err != nil {
+		return "", fmt.Errorf("fetching document %d: %w", docID, err)
+	}
+	return text, nil
+}
@@ -0,0 +1,249 @@
+package bm25
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDuplicateID is returned when a document is added with an external ID that is already in use.
+var ErrDuplicateID = errors.New("duplicate document ID")
+
+// Document is a document to be added to an index.
+type Document struct {
+	// ID is an optional external ID, e.g. a file path or a primary key.
+	ID string
+
+	// Text is the raw text of the document, tokenized with the index tokenizer.
+	Text string
+
+	// Tokens, if set, are the tokens of the document, which is then indexed as is
+	// instead of analyzing Text. Their payloads scale their contribution to scoring.
+	Tokens []Token
+
+	// Language is an optional language tag, e.g. "en". The text of a document with a
+	// language is tokenized with the analyzer set for it with SetLanguageAnalyzers.
+	Language string
+
+	// Metadata holds optional attributes of the document.
+	Metadata map[string]any
+
+	// TTL, if positive, is the time after which the document expires. Expired documents
+	// are excluded from scoring and reclaimed by Compact.
+	TTL time.Duration
+}
+
+// ExternalID returns the external ID of the document with the given internal ID, or an
+// empty string if it has none.
+func (b *Bm25Base) ExternalID(docID int) string {
+	if docID < 0 || docID >= len(b.externalIDs) {
+		return ""
+	}
+	return b.externalIDs[docID]
+}
+
+// LookupID returns the internal ID of the document with the given external ID.
+func (b *Bm25Base) LookupID(id string) (int, bool) {
+	docID, ok := b.idIndex[id]
+	return docID, ok
+}
+
+// Metadata returns the metadata of the document with the given internal ID, or nil if it has none.
+func (b *Bm25Base) Metadata(docID int) map[string]any {
+	if docID < 0 || docID >= len(b.metadata) {
+		return nil
+	}
+	return b.metadata[docID]
+}
+
+// DocumentTokens returns a copy of the tokens of the document with the given internal ID.
+func (b *Bm25Base) DocumentTokens(docID int) ([]string, error) {
+	if docID < 0 || docID >= b.corpusSize {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidDocID, docID)
+	}
+	return append([]string(nil), b.doc(docID)...), nil
+}
+
+// HasTerm reports whether the document with the given internal ID contains the term.
+func (b *Bm25Base) HasTerm(docID int, term string) bool {
+	if docID < 0 || docID >= b.corpusSize {
+		return false
+	}
+	return countTokens(b.doc(docID), term) > 0
+}
+
+// TermFrequencies returns the number of occurrences of every term in the document with
+// the given internal ID.
+func (b *Bm25Base) TermFrequencies(docID int) (map[string]int, error) {
+	if docID < 0 || docID >= b.corpusSize {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidDocID, docID)
+	}
+
+	termFreqs := make(map[string]int)
+	for _, token := range b.doc(docID) {
+		termFreqs[token]++
+	}
+	return termFreqs, nil
+}
+
+// setDocumentInfo records the external ID and metadata of the document with the given
+// internal ID. Storage is only allocated once a document actually carries either.
+func (b *Bm25Base) setDocumentInfo(docID int, doc Document) error {
+	if doc.TTL < 0 {
+		return invalidParam("TTL", doc.TTL, "must be non-negative")
+	}
+	if b.schema != nil {
+		if err := b.schema.ValidateMetadata(doc.Metadata); err != nil {
+			return err
+		}
+	}
+
+	if doc.ID != "" {
+		if _, ok := b.idIndex[doc.ID]; ok {
+			return ErrDuplicateID
+		}
+		if b.idIndex == nil {
+			b.idIndex = make(map[string]int)
+		}
+		b.externalIDs = growTo(b.externalIDs, docID)
+		b.externalIDs[docID] = doc.ID
+		b.idIndex[doc.ID] = docID
+		if version, ok := b.versions[doc.ID]; ok {
+			b.versions[doc.ID] = version + 1 // Added again after being deleted
+		}
+	}
+
+	if doc.Metadata != nil {
+		b.metadata = growTo(b.metadata, docID)
+		b.metadata[docID] = doc.Metadata
+	}
+
+	if doc.TTL > 0 {
+		b.expiresAt = growTo(b.expiresAt, docID)
+		b.expiresAt[docID] = time.Now().Add(doc.TTL)
+	}
+	return nil
+}
+
+// AddDocument tokenizes a document and adds it to the index, updating the corpus
+// statistics. It returns the internal ID of the document and notifies the hooks
+// registered with OnDocumentAdded once the document is searchable.
+func (b *Bm25Base) AddDocument(doc Document) (int, error) {
+	if b.frozen {
+		return 0, ErrFrozen
+	}
+
+	totalDocLen := b.avgDocLen * float64(b.corpusSize)
+	docID, err := b.addDocument(doc)
+	if err != nil {
+		return 0, err
+	}
+	b.commitDocuments(totalDocLen, []int{docID})
+	return docID, nil
+}
+
+// addDocument tokenizes a document and adds it to the corpus and the document
+// frequencies. The average document length, the derived caches and the hooks are only
+// updated by commitDocuments, so a batch of documents updates them once.
+func (b *Bm25Base) addDocument(doc Document) (int, error) {
+	doc, err := b.ingest(doc)
+	if err != nil {
+		return 0, err
+	}
+	return b.indexDocument(doc)
+}
+
+// indexDocument adds a document that went through the ingest hooks, see addDocument.
+func (b *Bm25Base) indexDocument(doc Document) (int, error) {
+	docID := b.corpusSize
+	tokens, payloads, err := b.analyzeDocument(doc)
+	if err != nil {
+		return 0, err
+	}
+	if len(tokens) == 0 {
+		return 0, fmt.Errorf("tokenizer function returned an empty slice for document at index %d: %w", docID, ErrEmptyDocument)
+	}
+
+	if err := b.setDocumentInfo(docID, doc); err != nil {
+		return 0, fmt.Errorf("%w: %q", err, doc.ID)
+	}
+
+	b.ensureTermFreqs()
+	forEachDistinct(tokens, func(token string) {
+		b.termFreqs[token]++
+	})
+
+	b.corpus = append(b.corpus, tokens)
+	b.docLengths = append(b.docLengths, len(tokens))
+	b.setPayloads(docID, payloads)
+	b.corpusSize++
+	return docID, nil
+}
+
+// commitDocuments completes the addition of documents with addDocument: it derives the
+// average document length from the total length of the documents before them, resets
+// the derived caches and notifies the hooks.
+func (b *Bm25Base) commitDocuments(totalDocLen float64, docIDs []int) {
+	if len(docIDs) == 0 {
+		return
+	}
+
+	for _, docID := range docIDs {
+		totalDocLen += float64(b.docLengths[docID])
+	}
+	b.avgDocLen = totalDocLen / float64(b.corpusSize)
+	b.invalidateStats()
+
+	if len(b.addHooks) == 0 {
+		return
+	}
+	for _, docID := range docIDs {
+		event := IndexEvent{
+			DocID:      docID,
+			ID:         b.ExternalID(docID),
+			Length:     b.docLengths[docID],
+			CorpusSize: b.corpusSize,
+			AvgDocLen:  b.avgDocLen,
+		}
+		forEachDistinct(b.doc(docID), func(string) { event.UniqueTerms++ })
+		for _, hook := range b.addHooks {
+			hook(event)
+		}
+	}
+}
+
+// OnDocumentAdded registers a hook that is called with the internal ID of every document
+// added with AddDocument. Hooks are called synchronously, in registration order, and are
+// not carried over to clones.
+func (b *Bm25Base) OnDocumentAdded(hook func(docID int)) {
+	b.OnDocumentIndexed(func(event IndexEvent) { hook(event.DocID) })
+}
+
+// ensureTermFreqs expands a compacted vocabulary back into a map before the document
+// frequencies are updated, as the TermDict cannot be updated in place.
+func (b *Bm25Base) ensureTermFreqs() {
+	if b.termFreqs != nil {
+		return
+	}
+
+	termFreqs := make(map[string]int, b.termDict.Len())
+	b.forEachTerm(func(term string, termFreq int) {
+		termFreqs[term] = termFreq
+	})
+	b.termFreqs = termFreqs
+}
+
+// invalidateStats drops all state derived from the corpus statistics after a change.
+func (b *Bm25Base) invalidateStats() {
+	clear(b.idfCache)
+	clear(b.collFreqs)
+	b.termDict = nil
+	b.docValues = nil
+	b.subwords = nil
+	b.impacts = nil
+	b.tfidfNorms = nil
+	b.avgIDFSet = false
+	b.rankAvgIDF = nil
+	b.keywords = nil
+	b.settings++
+}
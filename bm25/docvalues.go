@@ -0,0 +1,278 @@
+package bm25
+
+import (
+	"cmp"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// RangeFilter restricts a search to the documents whose metadata field lies within a
+// range. Numeric fields take bounds of any integer or float type, date fields bounds of
+// type time.Time or RFC 3339 strings. A nil bound leaves that side of the range open.
+// Documents without a value for the field never match.
+type RangeFilter struct {
+	Field string
+	Min   any
+	Max   any
+
+	// ExclusiveMin and ExclusiveMax exclude the bounds themselves from the range.
+	ExclusiveMin bool
+	ExclusiveMax bool
+}
+
+// Contains reports whether a metadata value lies within the range. Values whose type
+// does not match the bounds never do.
+func (r RangeFilter) Contains(value any) bool {
+	kind, num, t, ok := toDocValue(value)
+	if !ok {
+		return false
+	}
+
+	if r.Min != nil {
+		c, ok := compareDocValue(kind, num, t, r.Min)
+		if !ok || c < 0 || c == 0 && r.ExclusiveMin {
+			return false
+		}
+	}
+	if r.Max != nil {
+		c, ok := compareDocValue(kind, num, t, r.Max)
+		if !ok || c > 0 || c == 0 && r.ExclusiveMax {
+			return false
+		}
+	}
+	return true
+}
+
+// compareDocValue compares a value, as returned by toDocValue, to a bound. It reports
+// false if the bound is of another kind.
+func compareDocValue(kind docValueKind, num float64, t int64, bound any) (int, bool) {
+	boundKind, boundNum, boundT, ok := toDocValue(bound)
+	if !ok || boundKind != kind {
+		return 0, false
+	}
+	if kind == numericValue {
+		return cmp.Compare(num, boundNum), true
+	}
+	return cmp.Compare(t, boundT), true
+}
+
+// docValueKind is the type of the values of a metadata field.
+type docValueKind int
+
+const (
+	numericValue docValueKind = iota
+	timeValue
+)
+
+// docValues is a column of the values of a metadata field, sorted by value, so a range
+// maps to a contiguous run of documents found by binary search.
+type docValues struct {
+	kind   docValueKind
+	docIDs []int
+	nums   []float64 // Values of numeric fields
+	times  []int64   // Values of date fields, as Unix nanoseconds
+}
+
+// IndexDocValues builds the sorted doc-value indexes of the given metadata fields. Range
+// filters build the index of a field on first use, so calling this upfront only moves
+// that cost out of the first search, and makes it available to frozen copies.
+func (b *Bm25Base) IndexDocValues(fields ...string) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	for _, field := range fields {
+		if _, err := b.docValuesFor(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// docValuesFor returns the doc-value index of a metadata field, building it if needed.
+// The index is cached unless the caches are read-only.
+func (b *Bm25Base) docValuesFor(field string) (*docValues, error) {
+	if dv, ok := b.docValues[field]; ok {
+		return dv, nil
+	}
+
+	dv := &docValues{}
+	kindSet := false
+	for docID, metadata := range b.metadata {
+		value, ok := metadata[field]
+		if !ok || value == nil {
+			continue
+		}
+
+		kind, num, t, ok := toDocValue(value)
+		if !ok {
+			return nil, invalidParam("metadata."+field, value, "must be a number, a time.Time or an RFC 3339 date")
+		}
+		if kindSet && kind != dv.kind {
+			return nil, invalidParam("metadata."+field, value, "must have the same type in all documents")
+		}
+		dv.kind, kindSet = kind, true
+
+		dv.docIDs = append(dv.docIDs, docID)
+		if kind == numericValue {
+			dv.nums = append(dv.nums, num)
+		} else {
+			dv.times = append(dv.times, t)
+		}
+	}
+	sort.Sort(dv)
+
+	if b.cachesWritable() {
+		if b.docValues == nil {
+			b.docValues = make(map[string]*docValues)
+		}
+		b.docValues[field] = dv
+	}
+	return dv, nil
+}
+
+// Len, Less and Swap sort the docValues by value, then by document ID.
+func (dv *docValues) Len() int { return len(dv.docIDs) }
+
+func (dv *docValues) Less(i, j int) bool {
+	if dv.kind == numericValue && dv.nums[i] != dv.nums[j] {
+		return dv.nums[i] < dv.nums[j]
+	}
+	if dv.kind == timeValue && dv.times[i] != dv.times[j] {
+		return dv.times[i] < dv.times[j]
+	}
+	return dv.docIDs[i] < dv.docIDs[j]
+}
+
+func (dv *docValues) Swap(i, j int) {
+	dv.docIDs[i], dv.docIDs[j] = dv.docIDs[j], dv.docIDs[i]
+	if dv.kind == numericValue {
+		dv.nums[i], dv.nums[j] = dv.nums[j], dv.nums[i]
+	} else {
+		dv.times[i], dv.times[j] = dv.times[j], dv.times[i]
+	}
+}
+
+// search returns the index of the first value that is greater than (or, if inclusive,
+// at least) the given bound.
+func (dv *docValues) search(num float64, t int64, inclusive bool) int {
+	return sort.Search(dv.Len(), func(i int) bool {
+		if dv.kind == numericValue {
+			return dv.nums[i] > num || inclusive && dv.nums[i] == num
+		}
+		return dv.times[i] > t || inclusive && dv.times[i] == t
+	})
+}
+
+// ranks returns the rank of the value of every document in sort order, with equal
+// values sharing a rank, or -1 for documents without a value.
+func (dv *docValues) ranks(corpusSize int) []int {
+	ranks := make([]int, corpusSize)
+	for i := range ranks {
+		ranks[i] = -1
+	}
+
+	rank := 0
+	for i, docID := range dv.docIDs {
+		if i > 0 && (dv.kind == numericValue && dv.nums[i] != dv.nums[i-1] || dv.kind == timeValue && dv.times[i] != dv.times[i-1]) {
+			rank++
+		}
+		ranks[docID] = rank
+	}
+	return ranks
+}
+
+// matchRange marks the documents matching the range filter in the given mask.
+func (b *Bm25Base) matchRange(r RangeFilter, mask []bool) error {
+	dv, err := b.docValuesFor(r.Field)
+	if err != nil {
+		return err
+	}
+
+	lo, hi := 0, dv.Len()
+	if r.Min != nil {
+		kind, num, t, ok := toDocValue(r.Min)
+		if !ok || dv.Len() > 0 && kind != dv.kind {
+			return invalidParam("min", r.Min, "must match the type of the field "+r.Field)
+		}
+		lo = dv.search(num, t, !r.ExclusiveMin)
+	}
+	if r.Max != nil {
+		kind, num, t, ok := toDocValue(r.Max)
+		if !ok || dv.Len() > 0 && kind != dv.kind {
+			return invalidParam("max", r.Max, "must match the type of the field "+r.Field)
+		}
+		hi = dv.search(num, t, r.ExclusiveMax)
+	}
+
+	for i := lo; i < hi; i++ {
+		mask[dv.docIDs[i]] = true
+	}
+	return nil
+}
+
+// rangeMask returns a mask of the documents matching all range filters, or nil if there
+// are none.
+func (b *Bm25Base) rangeMask(ranges []RangeFilter) ([]bool, error) {
+	if len(ranges) == 0 {
+		return nil, nil
+	}
+
+	var mask []bool
+	for _, r := range ranges {
+		matches := make([]bool, b.corpusSize)
+		if err := b.matchRange(r, matches); err != nil {
+			return nil, err
+		}
+
+		if mask == nil {
+			mask = matches
+			continue
+		}
+		for docID, ok := range matches {
+			mask[docID] = mask[docID] && ok
+		}
+	}
+	return mask, nil
+}
+
+// toDocValue converts a metadata value or range bound to its kind and sortable value.
+func toDocValue(value any) (docValueKind, float64, int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return numericValue, float64(v), 0, true
+	case int8:
+		return numericValue, float64(v), 0, true
+	case int16:
+		return numericValue, float64(v), 0, true
+	case int32:
+		return numericValue, float64(v), 0, true
+	case int64:
+		return numericValue, float64(v), 0, true
+	case uint:
+		return numericValue, float64(v), 0, true
+	case uint8:
+		return numericValue, float64(v), 0, true
+	case uint16:
+		return numericValue, float64(v), 0, true
+	case uint32:
+		return numericValue, float64(v), 0, true
+	case uint64:
+		return numericValue, float64(v), 0, true
+	case float32:
+		return numericValue, float64(v), 0, true
+	case float64:
+		return numericValue, v, 0, true
+	case json.Number:
+		f, err := v.Float64()
+		return numericValue, f, 0, err == nil
+	case time.Time:
+		return timeValue, 0, v.UnixNano(), true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return timeValue, 0, t.UnixNano(), err == nil
+	default:
+		return 0, 0, 0, false
+	}
+}
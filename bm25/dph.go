@@ -0,0 +1,197 @@
+package bm25
+
+import (
+	"context"
+	"log"
+	"math"
+)
+
+// DPH is an implementation of the DPH model of the Divergence From Randomness framework
+// by Amati, as implemented by Terrier. It is a hypergeometric model with a Popper
+// normalization and has no parameters, so it needs no tuning, which makes it a robust
+// baseline for corpora without relevance judgments.
+type DPH struct {
+	*Bm25Base
+}
+
+// NewDPH creates a new instance of the DPH struct.
+func NewDPH(corpus []string, tokenizer func(string) []string, logger *log.Logger) (*DPH, error) {
+	base, err := NewBM25Base(corpus, tokenizer, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDPHFromBase(base)
+}
+
+// NewDPHFromBase creates a new instance of the DPH struct on top of an existing Bm25Base.
+func NewDPHFromBase(base *Bm25Base) (*DPH, error) {
+	if base == nil {
+		return nil, ErrNilBase
+	}
+
+	return &DPH{Bm25Base: base}, nil
+}
+
+// DPHParamSpecs returns the specs of the parameters of the DPH variant, which has none.
+func DPHParamSpecs() []ParamSpec {
+	return []ParamSpec{}
+}
+
+// Params returns the parameters of the index, which has none.
+func (d *DPH) Params() map[string]float64 {
+	return map[string]float64{}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (d *DPH) ParamSpecs() []ParamSpec {
+	return DPHParamSpecs()
+}
+
+// withParams returns the index itself, as it has no parameters, see
+// SearchRequest.Params.
+func (d *DPH) withParams(params map[string]float64) (BM25, error) {
+	return d, nil
+}
+
+// termScore returns the score of a term occurring tf times in a document of the given
+// length, given the number of occurrences of the term in the corpus.
+func (d *DPH) termScore(tf float64, docLen int, collectionFreq float64) float64 {
+	if tf == 0 || docLen == 0 {
+		return 0
+	}
+	f := tf / float64(docLen)
+	if f >= 1 {
+		return 0
+	}
+	norm := (1 - f) * (1 - f) / (tf + 1)
+	return norm * (tf*math.Log2(tf*d.avgDocLen/float64(docLen)*float64(d.corpusSize)/collectionFreq) + 0.5*math.Log2(2*math.Pi*tf*(1-f)))
+}
+
+// GetScores returns the DPH scores for the given query.
+func (d *DPH) GetScores(query []string) ([]float64, error) {
+	return d.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (d *DPH) GetScoresInto(dst []float64, query []string) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	scores := d.scoreBuffer(dst)
+	for _, q := range query {
+		if d.isStopword(q) {
+			continue
+		}
+
+		collectionFreq := d.collectionFrequency(q)
+		if collectionFreq == 0 {
+			d.logf(LogDebug, "Term '%s' is not in the corpus", q)
+			continue
+		}
+		weight := d.termWeight(q)
+
+		for i, docLen := range d.docLengths {
+			scores[i] += weight * d.termScore(d.termFrequency(i, q), docLen, collectionFreq)
+		}
+	}
+
+	d.excludeExpired(scores, nil)
+	return scores, nil
+}
+
+// GetBatchScores returns the DPH scores for the given query and a subset of documents.
+func (d *DPH) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if err := d.validateDocIDs(docIDs); err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(docIDs))
+	for _, q := range query {
+		if d.isStopword(q) {
+			continue
+		}
+
+		collectionFreq := d.collectionFrequency(q)
+		if collectionFreq == 0 {
+			d.logf(LogDebug, "Term '%s' is not in the corpus", q)
+			continue
+		}
+		weight := d.termWeight(q)
+
+		for i, docID := range docIDs {
+			scores[i] += weight * d.termScore(d.termFrequency(docID, q), d.docLengths[docID], collectionFreq)
+		}
+	}
+
+	d.excludeExpired(scores, docIDs)
+	return scores, nil
+}
+
+// GetTopN returns the top N documents for the given query.
+func (d *DPH) GetTopN(query []string, n int) ([]string, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if n <= 0 {
+		d.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
+		return []string{}, nil
+	}
+
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := d.GetScoresInto(*buf, query)
+	if err != nil {
+		return nil, err
+	}
+	*buf = scores
+
+	topNIndices, err := TopNIndices(scores, n)
+	if err != nil {
+		return nil, err
+	}
+
+	topDocs := make([]string, len(topNIndices))
+	for i, idx := range topNIndices {
+		doc, err := d.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
+	}
+
+	return topDocs, nil
+}
+
+// Search runs the given search request against the index.
+func (d *DPH) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return d.search(ctx, d, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (d *DPH) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return d.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the DPH instance.
+func (d *DPH) Clone() *DPH {
+	clone := *d
+	clone.Bm25Base = d.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the DPH instance that is safe for concurrent use.
+func (d *DPH) Freeze() *DPH {
+	frozen := *d
+	frozen.Bm25Base = d.Bm25Base.Freeze()
+	return &frozen
+}
@@ -0,0 +1,63 @@
+package bm25
+
+import "math"
+
+// SetEpsilon enables the epsilon floor for negative IDF values, as used by rank_bm25.
+// Terms that appear in every document then get an IDF of epsilon times the average IDF
+// of the vocabulary instead of a negative value, so they never produce negative scores.
+func (b *Bm25Base) SetEpsilon(epsilon float64) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	if epsilon < 0 {
+		return invalidParam("epsilon", epsilon, "must be non-negative")
+	}
+
+	b.epsilon = epsilon
+	b.epsilonSet = true
+	b.settings++
+	clear(b.idfCache)
+	return nil
+}
+
+// Epsilon returns the epsilon floor for negative IDF values and whether it is enabled.
+func (b *Bm25Base) Epsilon() (float64, bool) {
+	return b.epsilon, b.epsilonSet
+}
+
+// rawIDF computes the IDF of a term appearing in termFreq documents, without the epsilon floor.
+func (b *Bm25Base) rawIDF(termFreq int) float64 {
+	return okapiIDF(b.corpusSize, termFreq)
+}
+
+// okapiIDF computes the IDF of a term appearing in termFreq of corpusSize documents.
+func okapiIDF(corpusSize int, termFreq int) float64 {
+	if termFreq == corpusSize {
+		return math.Log(0.5 / (float64(termFreq) + 0.5))
+	}
+	return math.Log(((float64(corpusSize) - float64(termFreq) + 0.5) / (float64(termFreq) + 0.5)) + 1.0)
+}
+
+// averageIDF returns the average raw IDF over the vocabulary. It is computed on first use
+// and cached, unless the caches are read-only.
+func (b *Bm25Base) averageIDF() float64 {
+	if b.avgIDFSet {
+		return b.avgIDF
+	}
+
+	var sum float64
+	var count int
+	b.forEachTerm(func(term string, termFreq int) {
+		sum += b.rawIDF(termFreq)
+		count++
+	})
+	if count > 0 {
+		sum /= float64(count)
+	}
+
+	if b.cachesWritable() {
+		b.avgIDF, b.avgIDFSet = sum, true
+	}
+	return sum
+}
@@ -0,0 +1,54 @@
+package bm25
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the index. They can be matched with errors.Is, even when wrapped
+// with additional context.
+var (
+	ErrEmptyQuery     = errors.New("query cannot be empty")
+	ErrEmptyCorpus    = errors.New("corpus cannot be empty")
+	ErrEmptyDocument  = errors.New("document cannot be empty")
+	ErrEmptyTerm      = errors.New("term cannot be empty")
+	ErrEmptyDocIDs    = errors.New("document IDs cannot be empty")
+	ErrInvalidDocID   = errors.New("invalid document ID")
+	ErrNilTokenizer   = errors.New("tokenizer function cannot be nil")
+	ErrNilBase        = errors.New("base cannot be nil")
+	ErrFrozen         = errors.New("index is frozen")
+	ErrNotImplemented = errors.New("not implemented")
+	ErrUnknownVariant = errors.New("unknown variant")
+)
+
+// ErrInvalidParam is returned when a parameter is outside of its valid range. It can be
+// matched with errors.As.
+type ErrInvalidParam struct {
+	Name   string
+	Value  any
+	Reason string
+}
+
+// Error returns the error message, e.g. "k1 must be non-negative (got -1)".
+func (e *ErrInvalidParam) Error() string {
+	return fmt.Sprintf("%s %s (got %v)", e.Name, e.Reason, e.Value)
+}
+
+// invalidParam creates a new ErrInvalidParam.
+func invalidParam(name string, value any, reason string) error {
+	return &ErrInvalidParam{Name: name, Value: value, Reason: reason}
+}
+
+// validateDocIDs checks that the given document IDs are non-empty and within the corpus.
+func (b *Bm25Base) validateDocIDs(docIDs []int) error {
+	if len(docIDs) == 0 {
+		return ErrEmptyDocIDs
+	}
+
+	for _, docID := range docIDs {
+		if docID < 0 || docID >= b.corpusSize {
+			return fmt.Errorf("%w: %d", ErrInvalidDocID, docID)
+		}
+	}
+	return nil
+}
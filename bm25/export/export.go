@@ -0,0 +1,148 @@
+// Package export writes the rankings of an index, either full query-document score
+// matrices or top-N result tables, to tabular files for analysis in tools such as pandas
+// or DuckDB. Rows are written as they are computed, one query at a time, so the output is
+// never built in memory.
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// Writer writes the rows of a table. Values are strings, ints or float64s. CSV and
+// Parquet writers are provided; other formats can be plugged in by implementing Writer.
+type Writer interface {
+	WriteHeader(columns []string) error
+	WriteRow(values ...any) error
+	Flush() error
+}
+
+// Query is a tokenized query with the ID it is exported under.
+type Query struct {
+	ID     string
+	Tokens []string
+}
+
+// externalIDs is implemented by the indexes that keep external document IDs.
+type externalIDs interface {
+	ExternalID(docID int) string
+}
+
+// ScoreColumns are the columns written by Scores.
+var ScoreColumns = []string{"query_id", "doc_id", "external_id", "score"}
+
+// TopNColumns are the columns written by TopN.
+var TopNColumns = []string{"query_id", "rank", "doc_id", "external_id", "score"}
+
+// ScoreOptions configures Scores.
+type ScoreOptions struct {
+	// SkipZero omits documents with a score of 0, which turns the dense matrix into a
+	// sparse one for queries matching few documents.
+	SkipZero bool
+}
+
+// Scores writes the score of every document for every query, in long format: one row per
+// query and document, in query order and then internal ID order. The external ID column
+// is empty if the index has no external IDs.
+func Scores(ctx context.Context, w Writer, index bm25.BM25, queries []Query, opts ScoreOptions) error {
+	if err := w.WriteHeader(ScoreColumns); err != nil {
+		return err
+	}
+
+	ids, _ := index.(externalIDs)
+	for _, query := range queries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		scores, err := index.GetScores(query.Tokens)
+		if err != nil {
+			return fmt.Errorf("query %s: %w", query.ID, err)
+		}
+		for docID, score := range scores {
+			if opts.SkipZero && score == 0 {
+				continue
+			}
+			if err := w.WriteRow(query.ID, docID, externalID(ids, docID), score); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// TopN writes the top n results of every query, as returned by Search, with their rank
+// starting at 1.
+func TopN(ctx context.Context, w Writer, index bm25.BM25, queries []Query, n int) error {
+	if err := w.WriteHeader(TopNColumns); err != nil {
+		return err
+	}
+
+	ids, _ := index.(externalIDs)
+	for _, query := range queries {
+		resp, err := index.Search(ctx, bm25.SearchRequest{Query: query.Tokens, N: n})
+		if err != nil {
+			return fmt.Errorf("query %s: %w", query.ID, err)
+		}
+		for rank, result := range resp.Results {
+			if err := w.WriteRow(query.ID, rank+1, result.DocID, externalID(ids, result.DocID), result.Score); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// externalID returns the external ID of a document, or "" if the index has none.
+func externalID(ids externalIDs, docID int) string {
+	if ids == nil {
+		return ""
+	}
+	return ids.ExternalID(docID)
+}
+
+// CSVWriter writes tables as CSV with a header row. Floats are written with the fewest
+// digits that parse back to the same value.
+type CSVWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+// NewCSVWriter creates a new CSVWriter.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+// WriteHeader writes the header row.
+func (c *CSVWriter) WriteHeader(columns []string) error {
+	return c.w.Write(columns)
+}
+
+// WriteRow writes a row.
+func (c *CSVWriter) WriteRow(values ...any) error {
+	c.record = c.record[:0]
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			c.record = append(c.record, v)
+		case int:
+			c.record = append(c.record, strconv.Itoa(v))
+		case float64:
+			c.record = append(c.record, strconv.FormatFloat(v, 'g', -1, 64))
+		default:
+			return fmt.Errorf("unsupported value %v of type %T", value, value)
+		}
+	}
+	return c.w.Write(c.record)
+}
+
+// Flush writes any buffered rows to the underlying writer.
+func (c *CSVWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
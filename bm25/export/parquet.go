@@ -0,0 +1,383 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// DefaultRowGroupSize is the number of rows the ParquetWriter buffers per row group if
+// no size is given.
+const DefaultRowGroupSize = 65536
+
+// parquetMagic starts and ends every Parquet file.
+var parquetMagic = []byte("PAR1")
+
+// Parquet physical types, repetition types, converted types, encodings, codecs and page
+// types, as numbered by the Parquet format.
+const (
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetUTF8     = 0
+
+	parquetPlain = 0
+	parquetRLE   = 3
+
+	parquetUncompressed = 0
+	parquetDataPage     = 0
+)
+
+// ParquetWriter writes tables as uncompressed, PLAIN encoded Parquet files with one
+// required column per header column. Strings are written as UTF-8 byte arrays, ints as
+// INT64 and float64s as DOUBLE; the column types are taken from the first row, and every
+// later row must have the same types. Columns of a table without rows are written as
+// strings.
+//
+// Rows are buffered until a row group is full, so memory is bounded by the row group
+// size rather than by the table. Close must be called after the table is written to
+// write the file footer.
+type ParquetWriter struct {
+	w            io.Writer
+	rowGroupSize int
+	offset       int64
+
+	columns   []string
+	types     []int
+	values    []bytes.Buffer
+	rows      int
+	totalRows int64
+	rowGroups []parquetRowGroup
+	closed    bool
+}
+
+// parquetRowGroup is the metadata of a written row group.
+type parquetRowGroup struct {
+	columns   []parquetColumnChunk
+	totalSize int64
+	rows      int64
+}
+
+// parquetColumnChunk is the metadata of a written column chunk.
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+// NewParquetWriter creates a new ParquetWriter that writes row groups of rowGroupSize
+// rows, or DefaultRowGroupSize rows if rowGroupSize is 0.
+func NewParquetWriter(w io.Writer, rowGroupSize int) (*ParquetWriter, error) {
+	if rowGroupSize < 0 {
+		return nil, fmt.Errorf("invalid row group size %d: must be non-negative", rowGroupSize)
+	}
+	if rowGroupSize == 0 {
+		rowGroupSize = DefaultRowGroupSize
+	}
+	return &ParquetWriter{w: w, rowGroupSize: rowGroupSize}, nil
+}
+
+// WriteHeader sets the columns of the table. A Parquet file holds a single table, so the
+// header can only be written once.
+func (p *ParquetWriter) WriteHeader(columns []string) error {
+	if p.columns != nil {
+		return errors.New("parquet header already written")
+	}
+	if len(columns) == 0 {
+		return errors.New("parquet table must have at least one column")
+	}
+	if err := p.write(parquetMagic); err != nil {
+		return err
+	}
+	p.columns = append([]string(nil), columns...)
+	p.values = make([]bytes.Buffer, len(columns))
+	return nil
+}
+
+// WriteRow buffers a row, and writes the buffered rows as a row group once it is full.
+func (p *ParquetWriter) WriteRow(values ...any) error {
+	if p.columns == nil {
+		return errors.New("parquet header not written")
+	}
+	if p.closed {
+		return errors.New("parquet writer closed")
+	}
+	if len(values) != len(p.columns) {
+		return fmt.Errorf("row has %d values, but the table has %d columns", len(values), len(p.columns))
+	}
+	types := make([]int, len(values))
+	for i, value := range values {
+		typ, err := parquetType(value)
+		if err != nil {
+			return err
+		}
+		if p.types != nil && typ != p.types[i] {
+			return fmt.Errorf("column %s: unexpected value %v of type %T", p.columns[i], value, value)
+		}
+		types[i] = typ
+	}
+	if p.types == nil {
+		p.types = types
+	}
+
+	for i, value := range values {
+		buf := &p.values[i]
+		switch v := value.(type) {
+		case string:
+			buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+			buf.WriteString(v)
+		case int:
+			buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+		case float64:
+			buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)))
+		}
+	}
+
+	p.rows++
+	if p.rows == p.rowGroupSize {
+		return p.Flush()
+	}
+	return nil
+}
+
+// parquetType returns the physical type a value is written as.
+func parquetType(value any) (int, error) {
+	switch value.(type) {
+	case string:
+		return parquetByteArray, nil
+	case int:
+		return parquetInt64, nil
+	case float64:
+		return parquetDouble, nil
+	default:
+		return 0, fmt.Errorf("unsupported value %v of type %T", value, value)
+	}
+}
+
+// Flush writes the buffered rows as a row group.
+func (p *ParquetWriter) Flush() error {
+	if p.rows == 0 {
+		return nil
+	}
+
+	group := parquetRowGroup{columns: make([]parquetColumnChunk, len(p.columns)), rows: int64(p.rows)}
+	for i := range p.values {
+		page := p.values[i].Bytes()
+		var header thriftWriter
+		header.fieldI32(1, parquetDataPage)
+		header.fieldI32(2, int32(len(page)))
+		header.fieldI32(3, int32(len(page)))
+		header.fieldStruct(5)
+		header.fieldI32(1, int32(p.rows))
+		header.fieldI32(2, parquetPlain)
+		header.fieldI32(3, parquetRLE)
+		header.fieldI32(4, parquetRLE)
+		header.structEnd()
+		header.structEnd()
+
+		chunk := parquetColumnChunk{offset: p.offset, size: int64(header.buf.Len() + len(page)), values: int64(p.rows)}
+		if err := p.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := p.write(page); err != nil {
+			return err
+		}
+		p.values[i].Reset()
+		group.columns[i] = chunk
+		group.totalSize += chunk.size
+	}
+
+	p.rowGroups = append(p.rowGroups, group)
+	p.totalRows += int64(p.rows)
+	p.rows = 0
+	return nil
+}
+
+// Close writes the buffered rows and the file footer. The underlying writer is not
+// closed.
+func (p *ParquetWriter) Close() error {
+	if p.closed {
+		return nil
+	}
+	if p.columns == nil {
+		return errors.New("parquet header not written")
+	}
+	if err := p.Flush(); err != nil {
+		return err
+	}
+	p.closed = true
+
+	types := p.types
+	if types == nil {
+		types = make([]int, len(p.columns))
+		for i := range types {
+			types[i] = parquetByteArray
+		}
+	}
+
+	var meta thriftWriter
+	meta.fieldI32(1, 1)
+	meta.fieldList(2, thriftStruct, len(p.columns)+1)
+	meta.beginStruct()
+	meta.fieldBinary(4, "schema")
+	meta.fieldI32(5, int32(len(p.columns)))
+	meta.structEnd()
+	for i, column := range p.columns {
+		meta.beginStruct()
+		meta.fieldI32(1, int32(types[i]))
+		meta.fieldI32(3, parquetRequired)
+		meta.fieldBinary(4, column)
+		if types[i] == parquetByteArray {
+			meta.fieldI32(6, parquetUTF8)
+		}
+		meta.structEnd()
+	}
+	meta.fieldI64(3, p.totalRows)
+	meta.fieldList(4, thriftStruct, len(p.rowGroups))
+	for _, group := range p.rowGroups {
+		meta.beginStruct()
+		meta.fieldList(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			meta.beginStruct()
+			meta.fieldI64(2, chunk.offset)
+			meta.fieldStruct(3)
+			meta.fieldI32(1, int32(types[i]))
+			meta.fieldList(2, thriftI32, 2)
+			meta.i32(parquetPlain)
+			meta.i32(parquetRLE)
+			meta.fieldList(3, thriftBinary, 1)
+			meta.binary(p.columns[i])
+			meta.fieldI32(4, parquetUncompressed)
+			meta.fieldI64(5, chunk.values)
+			meta.fieldI64(6, chunk.size)
+			meta.fieldI64(7, chunk.size)
+			meta.fieldI64(9, chunk.offset)
+			meta.structEnd()
+			meta.structEnd()
+		}
+		meta.fieldI64(2, group.totalSize)
+		meta.fieldI64(3, group.rows)
+		meta.structEnd()
+	}
+	meta.fieldBinary(6, "bm25-go export")
+	meta.structEnd()
+
+	if err := p.write(meta.buf.Bytes()); err != nil {
+		return err
+	}
+	if err := p.write(binary.LittleEndian.AppendUint32(nil, uint32(meta.buf.Len()))); err != nil {
+		return err
+	}
+	return p.write(parquetMagic)
+}
+
+// write writes b to the underlying writer and advances the file offset.
+func (p *ParquetWriter) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	return err
+}
+
+// Thrift compact protocol types, used for the Parquet page headers and file metadata.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs with the Thrift compact protocol. Fields must be written
+// in increasing ID order within a struct, and every struct, including the outermost one,
+// is ended with structEnd.
+type thriftWriter struct {
+	buf     bytes.Buffer
+	lastID  int16
+	idStack []int16
+}
+
+// fieldHeader writes the header of a field, using the short form when the field ID
+// follows the previous one closely enough.
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - t.lastID; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(uint64(zigzag(int64(id))))
+	}
+	t.lastID = id
+}
+
+func (t *thriftWriter) fieldI32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.i32(v)
+}
+
+func (t *thriftWriter) fieldI64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) fieldBinary(id int16, v string) {
+	t.fieldHeader(id, thriftBinary)
+	t.binary(v)
+}
+
+// fieldList writes the header of a list field; the n elements are written after it.
+// Struct elements are each written between beginStruct and structEnd.
+func (t *thriftWriter) fieldList(id int16, elemType byte, n int) {
+	t.fieldHeader(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		t.varint(uint64(n))
+	}
+}
+
+// fieldStruct writes the header of a struct field; its fields are written after it,
+// followed by structEnd.
+func (t *thriftWriter) fieldStruct(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.beginStruct()
+}
+
+// beginStruct starts a nested struct, whose field IDs are numbered from scratch.
+func (t *thriftWriter) beginStruct() {
+	t.idStack = append(t.idStack, t.lastID)
+	t.lastID = 0
+}
+
+// structEnd ends the current struct, after which the field IDs of the enclosing struct
+// continue.
+func (t *thriftWriter) structEnd() {
+	t.buf.WriteByte(0)
+	t.lastID = 0
+	if n := len(t.idStack); n > 0 {
+		t.lastID = t.idStack[n-1]
+		t.idStack = t.idStack[:n-1]
+	}
+}
+
+func (t *thriftWriter) i32(v int32) {
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) binary(v string) {
+	t.varint(uint64(len(v)))
+	t.buf.WriteString(v)
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	t.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+// zigzag maps signed integers to unsigned ones so small magnitudes encode in few bytes.
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
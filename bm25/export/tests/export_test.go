@@ -0,0 +1,162 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/export"
+)
+
+func newIndex(t *testing.T) *bm25.BM25Okapi {
+	t.Helper()
+	builder, err := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, doc := range []bm25.Document{{Text: "hello world"}, {Text: "a test"}, {ID: "doc-c", Text: "hello there"}} {
+		if _, err := builder.Add(doc); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	base, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	okapi, err := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return okapi
+}
+
+func TestScores(t *testing.T) {
+	okapi := newIndex(t)
+	queries := []export.Query{{ID: "q1", Tokens: []string{"hello"}}, {ID: "q2", Tokens: []string{"test"}}}
+	scores, _ := okapi.GetScores([]string{"hello"})
+
+	// Test case: Exporting the full score matrix
+	var out strings.Builder
+	if err := export.Scores(context.Background(), export.NewCSVWriter(&out), okapi, queries, export.ScoreOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 7 || lines[0] != "query_id,doc_id,external_id,score" {
+		t.Fatalf("Expected a header and 6 rows, but got:\n%s", out.String())
+	}
+	if expected := "q1,0,," + strconv.FormatFloat(scores[0], 'g', -1, 64); lines[1] != expected {
+		t.Errorf("Expected %q, but got %q", expected, lines[1])
+	}
+
+	// Test case: Skipping zero scores
+	out.Reset()
+	if err := export.Scores(context.Background(), export.NewCSVWriter(&out), okapi, queries, export.ScoreOptions{SkipZero: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 4 {
+		t.Errorf("Expected a header and 3 rows, but got:\n%s", out.String())
+	}
+
+	// Test case: A canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := export.Scores(ctx, export.NewCSVWriter(&out), okapi, queries, export.ScoreOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, but got %v", err)
+	}
+}
+
+func TestTopN(t *testing.T) {
+	okapi := newIndex(t)
+
+	// Test case: Exporting the top results with external IDs
+	var out strings.Builder
+	err := export.TopN(context.Background(), export.NewCSVWriter(&out), okapi, []export.Query{{ID: "q1", Tokens: []string{"there"}}}, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || lines[0] != "query_id,rank,doc_id,external_id,score" {
+		t.Fatalf("Expected a header and 2 rows, but got:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[1], "q1,1,2,doc-c,") {
+		t.Errorf("Expected document doc-c first, but got %q", lines[1])
+	}
+
+	// Test case: An empty query
+	err = export.TopN(context.Background(), export.NewCSVWriter(&out), okapi, []export.Query{{ID: "q2"}}, 2)
+	if !errors.Is(err, bm25.ErrEmptyQuery) || !strings.Contains(err.Error(), "q2") {
+		t.Errorf("Expected ErrEmptyQuery for q2, but got %v", err)
+	}
+}
+
+func TestParquetWriter(t *testing.T) {
+	okapi := newIndex(t)
+	queries := []export.Query{{ID: "q1", Tokens: []string{"hello"}}, {ID: "q2", Tokens: []string{"test"}}}
+	scores, _ := okapi.GetScores([]string{"hello"})
+
+	// Test case: Exporting the full score matrix in row groups of 4 rows
+	var out bytes.Buffer
+	w, err := export.NewParquetWriter(&out, 4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := export.Scores(context.Background(), w, okapi, queries, export.ScoreOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	file := out.Bytes()
+	if !bytes.HasPrefix(file, []byte("PAR1")) || !bytes.HasSuffix(file, []byte("PAR1")) {
+		t.Fatalf("Expected the Parquet magic at both ends of the file")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	if footerLen <= 0 || footerLen > len(file)-12 {
+		t.Fatalf("Expected a footer within the file, but got length %d", footerLen)
+	}
+	footer := file[len(file)-8-footerLen : len(file)-8]
+	for _, column := range export.ScoreColumns {
+		if !bytes.Contains(footer, []byte(column)) {
+			t.Errorf("Expected column %s in the footer", column)
+		}
+	}
+
+	// The score column of the first row group is PLAIN encoded: little-endian doubles.
+	var page []byte
+	for _, score := range scores {
+		page = binary.LittleEndian.AppendUint64(page, math.Float64bits(score))
+	}
+	if !bytes.Contains(file, page) {
+		t.Errorf("Expected the scores %v to be written as a PLAIN encoded page", scores)
+	}
+
+	// Test case: A row whose value types differ from the first row
+	w, _ = export.NewParquetWriter(&out, 0)
+	if err := w.WriteHeader([]string{"a"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := w.WriteRow(1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := w.WriteRow("x"); err == nil {
+		t.Errorf("Expected an error for a string in an int column, but got nil")
+	}
+
+	// Test case: A row before the header
+	w, _ = export.NewParquetWriter(&out, 0)
+	if err := w.WriteRow(1); err == nil {
+		t.Errorf("Expected an error for a row before the header, but got nil")
+	}
+
+	// Test case: A negative row group size
+	if _, err := export.NewParquetWriter(&out, -1); err == nil {
+		t.Errorf("Expected an error for a negative row group size, but got nil")
+	}
+}
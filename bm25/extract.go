@@ -0,0 +1,84 @@
+package bm25
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Extractor extracts the plain text of a document before it is tokenized, so markup
+// does not end up in the index as terms.
+type Extractor interface {
+	Extract(text string) string
+}
+
+// ExtractorFunc adapts a function to the Extractor interface.
+type ExtractorFunc func(text string) string
+
+// Extract calls f(text).
+func (f ExtractorFunc) Extract(text string) string {
+	return f(text)
+}
+
+var (
+	htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlRawTextPattern = regexp.MustCompile(`(?is)<(script|style|noscript|template)\b[^>]*>.*?</(script|style|noscript|template)\s*>`)
+	htmlTagPattern     = regexp.MustCompile(`(?s)</?[a-zA-Z!][^>]*>`)
+)
+
+// HTMLExtractor strips the tags and comments of HTML documents, drops the contents of
+// script and style elements and decodes character references. Tags are replaced by a
+// space, so the text of adjacent elements is not joined into a single term.
+type HTMLExtractor struct{}
+
+// Extract returns the plain text of an HTML document.
+func (HTMLExtractor) Extract(text string) string {
+	text = htmlCommentPattern.ReplaceAllString(text, " ")
+	text = htmlRawTextPattern.ReplaceAllString(text, " ")
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	return html.UnescapeString(text)
+}
+
+var (
+	mdFencePattern     = regexp.MustCompile("(?m)^[ \t]*(```|~~~).*$")
+	mdLinkDefPattern   = regexp.MustCompile(`(?m)^[ \t]{0,3}\[[^\]]+\]:[ \t]*\S+.*$`)
+	mdImagePattern     = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLinkPattern      = regexp.MustCompile(`\[([^\]]*)\](\([^)]*\)|\[[^\]]*\])`)
+	mdAutolinkPattern  = regexp.MustCompile(`<((https?|mailto):[^>\s]+)>`)
+	mdHeadingPattern   = regexp.MustCompile(`(?m)^[ \t]{0,3}#{1,6}[ \t]+|[ \t]+#+[ \t]*$`)
+	mdBlockPattern     = regexp.MustCompile(`(?m)^[ \t]*(>[ \t]?)+|^[ \t]*([-*+]|\d+[.)])[ \t]+`)
+	mdRulePattern      = regexp.MustCompile(`(?m)^[ \t]*([-*_=][ \t]*){3,}$`)
+	mdEmphasisPattern  = regexp.MustCompile("(\\*{1,3}|_{2,3}|~~|`+)")
+	mdTableRowPattern  = regexp.MustCompile(`(?m)^[ \t]*\|?([ \t]*:?-+:?[ \t]*\|)+[ \t]*:?-*:?[ \t]*$`)
+	mdTableCellPattern = regexp.MustCompile(`\|`)
+)
+
+// MarkdownExtractor converts Markdown documents to plain text. It keeps the text of
+// headings, links, images (their alt text), code and lists, and drops the syntax around
+// them, link targets and embedded HTML.
+type MarkdownExtractor struct{}
+
+// Extract returns the plain text of a Markdown document.
+func (MarkdownExtractor) Extract(text string) string {
+	text = mdFencePattern.ReplaceAllString(text, "")
+	text = mdLinkDefPattern.ReplaceAllString(text, "")
+	text = mdImagePattern.ReplaceAllString(text, "$1")
+	text = mdLinkPattern.ReplaceAllString(text, "$1")
+	text = mdAutolinkPattern.ReplaceAllString(text, "$1")
+	text = HTMLExtractor{}.Extract(text)
+	text = mdHeadingPattern.ReplaceAllString(text, "")
+	text = mdTableRowPattern.ReplaceAllString(text, "")
+	text = mdRulePattern.ReplaceAllString(text, "")
+	text = mdBlockPattern.ReplaceAllString(text, "")
+	text = mdEmphasisPattern.ReplaceAllString(text, "")
+	text = mdTableCellPattern.ReplaceAllString(text, " ")
+	return strings.TrimSpace(text)
+}
+
+// extract applies the extractor to the text, if one is configured.
+func extract(extractor Extractor, text string) string {
+	if extractor == nil {
+		return text
+	}
+	return extractor.Extract(text)
+}
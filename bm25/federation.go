@@ -0,0 +1,204 @@
+package bm25
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultRRFK is the default rank constant of reciprocal rank fusion, as proposed by
+// Cormack et al.
+const defaultRRFK = 60
+
+// Fusion selects how a Federation merges the rankings of its sources.
+type Fusion int
+
+const (
+	// FuseMax divides the scores of every source by its top score, so the best result of
+	// every source scores 1, and multiplies them by the weight of the source. Scores of
+	// different variants or corpora are not comparable before normalization.
+	FuseMax Fusion = iota
+	// FuseRRF scores the results by reciprocal rank fusion, the weight of the source
+	// divided by RRFK plus the rank. It ignores the scores, so it is robust to sources
+	// whose score distributions differ widely.
+	FuseRRF
+)
+
+// FederatedSource is an index searched by a Federation.
+type FederatedSource struct {
+	// Name identifies the source in the results.
+	Name  string
+	Index BM25
+
+	// Weight multiplies the fused scores of the source. Defaults to 1.
+	Weight float64
+}
+
+// FederationOptions configures a Federation.
+type FederationOptions struct {
+	Fusion Fusion
+
+	// RRFK is the rank constant of FuseRRF. Defaults to 60.
+	RRFK float64
+}
+
+// FederatedHit is the result of a single source for a federated result.
+type FederatedHit struct {
+	Source string  `json:"source"`
+	DocID  int     `json:"docId"`
+	Rank   int     `json:"rank"`  // Starting at 1
+	Score  float64 `json:"score"` // Raw score of the source
+}
+
+// FederatedResult is a result of a federated search. Results of different sources with
+// the same external ID are merged into one, with the fused scores added up.
+type FederatedResult struct {
+	ExternalID string         `json:"externalId,omitempty"`
+	Doc        string         `json:"doc"`
+	Score      float64        `json:"score"`
+	Hits       []FederatedHit `json:"hits"` // In the order of the sources
+}
+
+// FederatedResponse is the response of a federated search.
+type FederatedResponse struct {
+	Results []FederatedResult `json:"results"`
+	Took    time.Duration     `json:"took"`
+}
+
+// Federation searches several independent indexes, possibly of different variants and
+// with different analyzers, and merges their rankings into a single one. Search requests
+// are passed to every source as is, so a raw query Text is analyzed by every source with
+// its own analyzer, while document IDs in a Filter refer to the documents of every source.
+type Federation struct {
+	sources []FederatedSource
+	opts    FederationOptions
+}
+
+// externalIDSource is implemented by the indexes that keep external document IDs.
+type externalIDSource interface {
+	ExternalID(docID int) string
+}
+
+// NewFederation creates a new Federation of the given sources.
+func NewFederation(sources []FederatedSource, opts FederationOptions) (*Federation, error) {
+	if len(sources) == 0 {
+		return nil, invalidParam("sources", len(sources), "must not be empty")
+	}
+
+	sources = append([]FederatedSource(nil), sources...)
+	names := make(map[string]struct{}, len(sources))
+	for i, source := range sources {
+		if source.Index == nil {
+			return nil, fmt.Errorf("source %q: %w", source.Name, ErrNilBase)
+		}
+		if _, ok := names[source.Name]; ok {
+			return nil, invalidParam("name", source.Name, "must be unique")
+		}
+		names[source.Name] = struct{}{}
+
+		if source.Weight == 0 {
+			sources[i].Weight = 1
+		}
+		if sources[i].Weight < 0 {
+			return nil, invalidParam("weight", source.Weight, "must be non-negative")
+		}
+	}
+
+	if opts.Fusion < FuseMax || opts.Fusion > FuseRRF {
+		return nil, invalidParam("fusion", opts.Fusion, "must be FuseMax or FuseRRF")
+	}
+	if opts.RRFK == 0 {
+		opts.RRFK = defaultRRFK
+	}
+	if opts.RRFK < 0 {
+		return nil, invalidParam("RRFK", opts.RRFK, "must be non-negative")
+	}
+
+	return &Federation{sources: sources, opts: opts}, nil
+}
+
+// Search runs the request against all sources concurrently and returns the top req.N
+// results of the merged ranking. Every source contributes at most req.N results; results
+// scoring 0, which match none of the query terms, are left out. The merged ranking is by
+// score, so the normalization and sort keys of the request are ignored. The search fails
+// if any source fails.
+func (f *Federation) Search(ctx context.Context, req SearchRequest) (*FederatedResponse, error) {
+	start := time.Now()
+	req.Normalization = NormalizeNone
+	req.Sort = nil
+
+	responses := make([]*SearchResponse, len(f.sources))
+	errs := make([]error, len(f.sources))
+	var wg sync.WaitGroup
+	for i, source := range f.sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i], errs[i] = source.Index.Search(ctx, req)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("source %q: %w", source.Name, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	var results []FederatedResult
+	byID := make(map[string]int)
+	for i, source := range f.sources {
+		ids, _ := source.Index.(externalIDSource)
+		top := 0.0
+		for _, result := range responses[i].Results {
+			top = math.Max(top, result.Score)
+		}
+
+		for rank, result := range responses[i].Results {
+			if result.Score == 0 {
+				continue
+			}
+			hit := FederatedHit{Source: source.Name, DocID: result.DocID, Rank: rank + 1, Score: result.Score}
+			score := source.Weight / (f.opts.RRFK + float64(hit.Rank))
+			if f.opts.Fusion == FuseMax {
+				score = 0
+				if top > 0 {
+					score = source.Weight * result.Score / top
+				}
+			}
+
+			var externalID string
+			if ids != nil {
+				externalID = ids.ExternalID(result.DocID)
+			}
+			if j, ok := byID[externalID]; ok && externalID != "" {
+				results[j].Score += score
+				results[j].Hits = append(results[j].Hits, hit)
+				continue
+			}
+			if externalID != "" {
+				byID[externalID] = len(results)
+			}
+			results = append(results, FederatedResult{
+				ExternalID: externalID,
+				Doc:        result.Doc,
+				Score:      score,
+				Hits:       []FederatedHit{hit},
+			})
+		}
+	}
+
+	// Ties are broken by the order of the sources, then by rank, as the results were
+	// appended in that order
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > req.N {
+		results = results[:req.N]
+	}
+	return &FederatedResponse{Results: results, Took: time.Since(start)}, nil
+}
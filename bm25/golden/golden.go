@@ -0,0 +1,233 @@
+// Package golden records the scores an index gives to the top results of a set of
+// queries, and verifies a rebuilt or upgraded index against them, so library upgrades,
+// tokenizer changes or reindexing can be validated against production relevance.
+package golden
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// Version is the version of the file format written by Write.
+const Version = 1
+
+// ErrUnsupportedVersion is returned when reading a golden file of an unknown version.
+var ErrUnsupportedVersion = errors.New("unsupported golden file version")
+
+// Query is a tokenized query with the ID it is recorded under.
+type Query struct {
+	ID     string   `json:"id"`
+	Tokens []string `json:"tokens"`
+}
+
+// Result is a recorded (query, document, score) tuple.
+type Result struct {
+	DocID      int     `json:"docId"`
+	ExternalID string  `json:"externalId,omitempty"`
+	Score      float64 `json:"score"`
+}
+
+// QueryResults holds the recorded top results of a query, best first.
+type QueryResults struct {
+	Query   Query    `json:"query"`
+	Results []Result `json:"results"`
+}
+
+// Set is a set of recorded golden scores.
+type Set struct {
+	Version int            `json:"version"`
+	Queries []QueryResults `json:"queries"`
+}
+
+// externalIDs is implemented by the indexes that keep external document IDs.
+type externalIDs interface {
+	ExternalID(docID int) string
+	LookupID(id string) (int, bool)
+}
+
+// Record searches the index for the top n results of every query and records their
+// scores. Documents with an external ID are recorded with it, so they can be matched by
+// Verify even if the rebuilt index assigns different internal IDs.
+func Record(ctx context.Context, index bm25.BM25, queries []Query, n int) (*Set, error) {
+	ids, _ := index.(externalIDs)
+	set := &Set{Version: Version, Queries: make([]QueryResults, 0, len(queries))}
+	for _, query := range queries {
+		resp, err := index.Search(ctx, bm25.SearchRequest{Query: query.Tokens, N: n})
+		if err != nil {
+			return nil, fmt.Errorf("query %s: %w", query.ID, err)
+		}
+
+		results := make([]Result, len(resp.Results))
+		for i, result := range resp.Results {
+			results[i] = Result{DocID: result.DocID, Score: result.Score}
+			if ids != nil {
+				results[i].ExternalID = ids.ExternalID(result.DocID)
+			}
+		}
+		set.Queries = append(set.Queries, QueryResults{Query: query, Results: results})
+	}
+	return set, nil
+}
+
+// Write writes the set as JSON.
+func (s *Set) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// Read reads a set written by Write.
+func Read(r io.Reader) (*Set, error) {
+	var set Set
+	if err := json.NewDecoder(r).Decode(&set); err != nil {
+		return nil, err
+	}
+	if set.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, set.Version)
+	}
+	return &set, nil
+}
+
+// Mismatch is a recorded result whose score differs by more than the tolerance, or whose
+// document is missing from the verified index.
+type Mismatch struct {
+	QueryID    string
+	DocID      int
+	ExternalID string
+	Want       float64
+	Got        float64
+	Missing    bool
+}
+
+// Report is the outcome of Verify.
+type Report struct {
+	Checked    int
+	Mismatches []Mismatch
+}
+
+// OK reports whether every recorded score was reproduced within the tolerance.
+func (r *Report) OK() bool {
+	return len(r.Mismatches) == 0
+}
+
+// String formats the mismatches as a human-readable table.
+func (r *Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d of %d scores mismatched\n", len(r.Mismatches), r.Checked)
+	if len(r.Mismatches) == 0 {
+		return sb.String()
+	}
+
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Query\tDocument\tWant\tGot\n")
+	for _, m := range r.Mismatches {
+		doc := m.ExternalID
+		if doc == "" {
+			doc = fmt.Sprint(m.DocID)
+		}
+		got := fmt.Sprint(m.Got)
+		if m.Missing {
+			got = "missing"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%v\t%s\n", m.QueryID, doc, m.Want, got)
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// Verify scores the recorded documents of every query with Search and reports the
+// scores that differ from the recorded ones by more than tolerance. Documents recorded
+// with an external ID are looked up by it; they are reported as missing if the index does
+// not contain them. Only the recorded documents are checked, so documents added to the
+// index since recording do not cause mismatches.
+func Verify(ctx context.Context, index bm25.BM25, set *Set, tolerance float64) (*Report, error) {
+	if tolerance < 0 || math.IsNaN(tolerance) {
+		return nil, fmt.Errorf("invalid tolerance %v: must be non-negative", tolerance)
+	}
+
+	ids, _ := index.(externalIDs)
+	report := &Report{}
+	for _, query := range set.Queries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var docIDs []int
+		var recorded []Result
+		for _, result := range query.Results {
+			docID, ok := resolve(index, ids, result)
+			if !ok {
+				report.Checked++
+				report.Mismatches = append(report.Mismatches, Mismatch{
+					QueryID:    query.Query.ID,
+					DocID:      result.DocID,
+					ExternalID: result.ExternalID,
+					Want:       result.Score,
+					Missing:    true,
+				})
+				continue
+			}
+			docIDs = append(docIDs, docID)
+			recorded = append(recorded, result)
+		}
+		if len(docIDs) == 0 {
+			continue
+		}
+
+		scores, err := scoreDocs(ctx, index, query.Query.Tokens, docIDs)
+		if err != nil {
+			return nil, fmt.Errorf("query %s: %w", query.Query.ID, err)
+		}
+		for i, docID := range docIDs {
+			report.Checked++
+			if math.Abs(scores[docID]-recorded[i].Score) > tolerance {
+				report.Mismatches = append(report.Mismatches, Mismatch{
+					QueryID:    query.Query.ID,
+					DocID:      docID,
+					ExternalID: recorded[i].ExternalID,
+					Want:       recorded[i].Score,
+					Got:        scores[docID],
+				})
+			}
+		}
+	}
+	return report, nil
+}
+
+// scoreDocs scores the given documents with Search, like Record, restricted to them with
+// a filter. Documents missing from the response scored 0.
+func scoreDocs(ctx context.Context, index bm25.BM25, query []string, docIDs []int) (map[int]float64, error) {
+	scores := make(map[int]float64, len(docIDs))
+	for _, docID := range docIDs {
+		scores[docID] = 0
+	}
+
+	resp, err := index.Search(ctx, bm25.SearchRequest{
+		Query:  query,
+		N:      len(docIDs),
+		Filter: func(docID int) bool { _, ok := scores[docID]; return ok },
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range resp.Results {
+		scores[result.DocID] = result.Score
+	}
+	return scores, nil
+}
+
+// resolve returns the ID of a recorded document in the index.
+func resolve(index bm25.BM25, ids externalIDs, result Result) (int, bool) {
+	if result.ExternalID != "" && ids != nil {
+		return ids.LookupID(result.ExternalID)
+	}
+	return result.DocID, result.DocID >= 0 && result.DocID < index.CorpusSize()
+}
@@ -0,0 +1,99 @@
+package golden_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/golden"
+)
+
+func newIndex(t *testing.T, docs []bm25.Document, k1 float64) *bm25.BM25Okapi {
+	t.Helper()
+	builder, err := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, doc := range docs {
+		if _, err := builder.Add(doc); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	base, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	okapi, err := bm25.NewBM25OkapiFromBase(base, k1, 0.75)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return okapi
+}
+
+func TestRecordVerify(t *testing.T) {
+	docs := []bm25.Document{{ID: "a", Text: "hello world"}, {ID: "b", Text: "a test"}, {ID: "c", Text: "hello there hello"}}
+	queries := []golden.Query{{ID: "q1", Tokens: []string{"hello"}}, {ID: "q2", Tokens: []string{"test", "world"}}}
+	ctx := context.Background()
+
+	// Test case: Recorded scores survive a round trip and match the index
+	set, err := golden.Record(ctx, newIndex(t, docs, 1.5), queries, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(set.Queries) != 2 || len(set.Queries[0].Results) != 2 || set.Queries[0].Results[0].ExternalID != "c" {
+		t.Fatalf("Expected 'c' to rank first for q1, but got %+v", set.Queries)
+	}
+	var buf bytes.Buffer
+	if err := set.Write(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	set, err = golden.Read(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	report, err := golden.Verify(ctx, newIndex(t, docs, 1.5), set, 1e-9)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !report.OK() || report.Checked != 4 {
+		t.Errorf("Expected 4 matching scores, but got:\n%s", report)
+	}
+
+	// Test case: Documents are matched by external ID after reordering
+	reordered := []bm25.Document{docs[2], docs[1], docs[0]}
+	report, _ = golden.Verify(ctx, newIndex(t, reordered, 1.5), set, 1e-9)
+	if !report.OK() {
+		t.Errorf("Expected the reordered index to match, but got:\n%s", report)
+	}
+
+	// Test case: Changed parameters and removed documents are reported
+	report, _ = golden.Verify(ctx, newIndex(t, docs[1:], 1.2), set, 1e-9)
+	if report.OK() || report.Checked != 4 {
+		t.Fatalf("Expected mismatches for 4 scores, but got:\n%s", report)
+	}
+	missing := 0
+	for _, m := range report.Mismatches {
+		if m.Missing {
+			missing++
+			if m.ExternalID != "a" {
+				t.Errorf("Expected only 'a' to be missing, but got %+v", m)
+			}
+		}
+	}
+	if missing != 2 {
+		t.Errorf("Expected 'a' to be missing for both queries, but got:\n%s", report)
+	}
+
+	// Test case: Invalid tolerance and unknown versions
+	_, err = golden.Verify(ctx, newIndex(t, docs, 1.5), set, -1)
+	if err == nil {
+		t.Errorf("Expected an error for a negative tolerance, but got nil")
+	}
+	_, err = golden.Read(strings.NewReader(`{"version": 99}`))
+	if !errors.Is(err, golden.ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion, but got %v", err)
+	}
+}
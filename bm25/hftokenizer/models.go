@@ -0,0 +1,422 @@
+package hftokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// defaultMaxInputCharsPerWord is the longest word WordPiece splits, if not configured.
+const defaultMaxInputCharsPerWord = 100
+
+// unigramUnknownPenalty is subtracted from the lowest piece score to score unknown
+// characters in the Unigram model.
+const unigramUnknownPenalty = 10.0
+
+// modelJSON holds the options of all supported models.
+type modelJSON struct {
+	Type                    string            `json:"type"`
+	Vocab                   json.RawMessage   `json:"vocab"`
+	Merges                  []json.RawMessage `json:"merges"`
+	UnkToken                *string           `json:"unk_token"`
+	UnkID                   *int              `json:"unk_id"`
+	ContinuingSubwordPrefix *string           `json:"continuing_subword_prefix"`
+	EndOfWordSuffix         *string           `json:"end_of_word_suffix"`
+	MaxInputCharsPerWord    int               `json:"max_input_chars_per_word"`
+	ByteFallback            bool              `json:"byte_fallback"`
+	IgnoreMerges            bool              `json:"ignore_merges"`
+}
+
+// parseModel parses a model.
+func parseModel(raw json.RawMessage) (model, error) {
+	if isNull(raw) {
+		return nil, fmt.Errorf("tokenizer has no model: %w", ErrUnsupported)
+	}
+
+	var spec modelJSON
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, fmt.Errorf("parsing model: %w", err)
+	}
+
+	// Older files omit the type, which the layout of the model then tells
+	if spec.Type == "" {
+		switch {
+		case spec.Merges != nil:
+			spec.Type = "BPE"
+		case strings.HasPrefix(strings.TrimSpace(string(spec.Vocab)), "["):
+			spec.Type = "Unigram"
+		default:
+			spec.Type = "WordPiece"
+		}
+	}
+
+	switch spec.Type {
+	case "WordPiece":
+		return parseWordPiece(spec)
+	case "BPE":
+		return parseBPE(spec)
+	case "Unigram":
+		return parseUnigram(spec)
+	}
+	return nil, fmt.Errorf("model %q: %w", spec.Type, ErrUnsupported)
+}
+
+// parseVocab parses a vocabulary mapping tokens to IDs.
+func parseVocab(raw json.RawMessage) (map[string]struct{}, error) {
+	var ids map[string]int
+	if err := json.Unmarshal(raw, &ids); err != nil {
+		return nil, fmt.Errorf("parsing vocabulary: %w", err)
+	}
+
+	vocab := make(map[string]struct{}, len(ids))
+	for token := range ids {
+		vocab[token] = struct{}{}
+	}
+	return vocab, nil
+}
+
+// wordPiece splits words greedily into the longest tokens of its vocabulary.
+type wordPiece struct {
+	vocab    map[string]struct{}
+	unk      string
+	prefix   string
+	maxChars int
+}
+
+// parseWordPiece parses a WordPiece model.
+func parseWordPiece(spec modelJSON) (*wordPiece, error) {
+	vocab, err := parseVocab(spec.Vocab)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &wordPiece{vocab: vocab, unk: "[UNK]", prefix: "##", maxChars: spec.MaxInputCharsPerWord}
+	if spec.UnkToken != nil {
+		m.unk = *spec.UnkToken
+	}
+	if spec.ContinuingSubwordPrefix != nil {
+		m.prefix = *spec.ContinuingSubwordPrefix
+	}
+	if m.maxChars <= 0 {
+		m.maxChars = defaultMaxInputCharsPerWord
+	}
+	return m, nil
+}
+
+func (m *wordPiece) unknown() string {
+	return m.unk
+}
+
+// tokenize splits a word into the longest matching tokens from left to right. A word
+// that cannot be split completely becomes a single unknown token.
+func (m *wordPiece) tokenize(word string) []string {
+	runes := []rune(word)
+	if len(runes) > m.maxChars {
+		return []string{m.unk}
+	}
+
+	var tokens []string
+	for start := 0; start < len(runes); {
+		end, token := len(runes), ""
+		for ; end > start; end-- {
+			candidate := string(runes[start:end])
+			if start > 0 {
+				candidate = m.prefix + candidate
+			}
+			if _, ok := m.vocab[candidate]; ok {
+				token = candidate
+				break
+			}
+		}
+		if token == "" {
+			return []string{m.unk}
+		}
+		tokens = append(tokens, token)
+		start = end
+	}
+	return tokens
+}
+
+// bpe merges the characters of a word pairwise until no merge applies. Merges are ranked
+// either by their order in the merge list or, for SentencePiece models, by the score of
+// the merged piece.
+type bpe struct {
+	vocab        map[string]struct{}
+	ranks        map[[2]string]int
+	scores       map[string]float64
+	unk          string
+	prefix       string
+	suffix       string
+	byteFallback bool
+	ignoreMerges bool
+}
+
+// parseBPE parses a BPE model. Merges are listed either as "a b" strings or as pairs.
+func parseBPE(spec modelJSON) (*bpe, error) {
+	vocab, err := parseVocab(spec.Vocab)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &bpe{
+		vocab:        vocab,
+		ranks:        make(map[[2]string]int, len(spec.Merges)),
+		byteFallback: spec.ByteFallback,
+		ignoreMerges: spec.IgnoreMerges,
+	}
+	if spec.UnkToken != nil {
+		m.unk = *spec.UnkToken
+	}
+	if spec.ContinuingSubwordPrefix != nil {
+		m.prefix = *spec.ContinuingSubwordPrefix
+	}
+	if spec.EndOfWordSuffix != nil {
+		m.suffix = *spec.EndOfWordSuffix
+	}
+
+	for rank, raw := range spec.Merges {
+		var pair [2]string
+		var merge string
+		if err := json.Unmarshal(raw, &merge); err == nil {
+			left, right, ok := strings.Cut(merge, " ")
+			if !ok {
+				return nil, fmt.Errorf("parsing merge %d: %q is not a pair", rank, merge)
+			}
+			pair = [2]string{left, right}
+		} else if err := json.Unmarshal(raw, &pair); err != nil {
+			return nil, fmt.Errorf("parsing merge %d: %w", rank, err)
+		}
+		if _, ok := m.ranks[pair]; !ok {
+			m.ranks[pair] = rank
+		}
+	}
+	return m, nil
+}
+
+func (m *bpe) unknown() string {
+	return m.unk
+}
+
+// merged returns the token two adjacent symbols merge into.
+func (m *bpe) merged(left, right string) string {
+	return left + strings.TrimPrefix(right, m.prefix)
+}
+
+// rank returns the priority of merging two adjacent symbols, lower first.
+func (m *bpe) rank(left, right string) (float64, bool) {
+	if m.scores != nil {
+		score, ok := m.scores[m.merged(left, right)]
+		return -score, ok
+	}
+	rank, ok := m.ranks[[2]string{left, right}]
+	return float64(rank), ok
+}
+
+// tokenize applies the best ranked merge to the symbols of a word until none applies,
+// preferring the leftmost pair on ties.
+func (m *bpe) tokenize(word string) []string {
+	if word == "" {
+		return nil
+	}
+	if _, ok := m.vocab[word]; ok && m.ignoreMerges {
+		return []string{word}
+	}
+
+	runes := []rune(word)
+	symbols := make([]string, len(runes))
+	for i, r := range runes {
+		symbols[i] = string(r)
+		if i > 0 {
+			symbols[i] = m.prefix + symbols[i]
+		}
+	}
+	symbols[len(symbols)-1] += m.suffix
+
+	for len(symbols) > 1 {
+		best, bestRank := -1, math.Inf(1)
+		for i := 0; i+1 < len(symbols); i++ {
+			if rank, ok := m.rank(symbols[i], symbols[i+1]); ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		symbols[best] = m.merged(symbols[best], symbols[best+1])
+		symbols = append(symbols[:best+1], symbols[best+2:]...)
+	}
+
+	tokens := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if _, ok := m.vocab[symbol]; ok {
+			tokens = append(tokens, symbol)
+			continue
+		}
+		tokens = append(tokens, m.fallback(symbol)...)
+	}
+	return tokens
+}
+
+// fallback returns the tokens of a symbol that is not in the vocabulary: its bytes, if
+// byte fallback is enabled, or else the unknown token, if there is one.
+func (m *bpe) fallback(symbol string) []string {
+	if m.byteFallback {
+		symbol = strings.TrimPrefix(symbol, m.prefix)
+		bytes := make([]string, 0, len(symbol))
+		for i := 0; i < len(symbol); i++ {
+			token := fmt.Sprintf("<0x%02X>", symbol[i])
+			if _, ok := m.vocab[token]; !ok {
+				bytes = nil
+				break
+			}
+			bytes = append(bytes, token)
+		}
+		if bytes != nil {
+			return bytes
+		}
+	}
+	if m.unk != "" {
+		return []string{m.unk}
+	}
+	return nil
+}
+
+// unigram segments a word into the sequence of pieces with the highest total score.
+type unigram struct {
+	scores    map[string]float64
+	maxPiece  int
+	unk       string
+	unkScore  float64
+	fallbacks map[string]struct{}
+}
+
+// parseUnigram parses a Unigram model, whose vocabulary lists pieces with their scores.
+func parseUnigram(spec modelJSON) (*unigram, error) {
+	var pieces [][2]any
+	if err := json.Unmarshal(spec.Vocab, &pieces); err != nil {
+		return nil, fmt.Errorf("parsing vocabulary: %w", err)
+	}
+
+	m := newUnigram()
+	for id, piece := range pieces {
+		token, ok1 := piece[0].(string)
+		score, ok2 := piece[1].(float64)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("parsing vocabulary: piece %d is not a [token, score] pair", id)
+		}
+		if spec.UnkID != nil && *spec.UnkID == id {
+			m.unk = token
+			continue
+		}
+		m.add(token, score, spec.ByteFallback)
+	}
+	m.finish()
+	return m, nil
+}
+
+// newUnigram creates an empty Unigram model.
+func newUnigram() *unigram {
+	return &unigram{scores: make(map[string]float64), fallbacks: make(map[string]struct{})}
+}
+
+// add adds a piece to the model. Byte pieces are only used for byte fallback.
+func (m *unigram) add(piece string, score float64, byteFallback bool) {
+	if isBytePiece(piece) {
+		if byteFallback {
+			m.fallbacks[piece] = struct{}{}
+		}
+		return
+	}
+	m.scores[piece] = score
+	m.maxPiece = max(m.maxPiece, len([]rune(piece)))
+}
+
+// finish derives the score of unknown characters once all pieces are added.
+func (m *unigram) finish() {
+	lowest := 0.0
+	for _, score := range m.scores {
+		lowest = min(lowest, score)
+	}
+	m.unkScore = lowest - unigramUnknownPenalty
+}
+
+// isBytePiece reports whether a piece is a byte fallback piece such as <0x41>.
+func isBytePiece(piece string) bool {
+	return len(piece) == 6 && strings.HasPrefix(piece, "<0x") && piece[5] == '>'
+}
+
+func (m *unigram) unknown() string {
+	return m.unk
+}
+
+// tokenize finds the best segmentation with the Viterbi algorithm. Characters that no
+// piece covers are unknown; consecutive unknown characters become a single unknown token.
+func (m *unigram) tokenize(word string) []string {
+	runes := []rune(word)
+	n := len(runes)
+	if n == 0 {
+		return nil
+	}
+
+	best := make([]float64, n+1)
+	prev := make([]int, n+1)
+	unknown := make([]bool, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = math.Inf(-1)
+	}
+
+	for end := 1; end <= n; end++ {
+		for start := max(0, end-m.maxPiece); start < end; start++ {
+			if math.IsInf(best[start], -1) {
+				continue
+			}
+			if score, ok := m.scores[string(runes[start:end])]; ok && best[start]+score > best[end] {
+				best[end], prev[end], unknown[end] = best[start]+score, start, false
+			}
+		}
+		if math.IsInf(best[end], -1) {
+			best[end], prev[end], unknown[end] = best[end-1]+m.unkScore, end-1, true
+		}
+	}
+
+	var tokens []string
+	for end := n; end > 0; end = prev[end] {
+		if !unknown[end] {
+			tokens = append(tokens, string(runes[prev[end]:end]))
+			continue
+		}
+		if fallback := m.fallback(string(runes[prev[end]:end])); fallback != nil {
+			for i := len(fallback) - 1; i >= 0; i-- {
+				tokens = append(tokens, fallback[i])
+			}
+			continue
+		}
+		if m.unk == "" || len(tokens) > 0 && tokens[len(tokens)-1] == m.unk {
+			continue
+		}
+		tokens = append(tokens, m.unk)
+	}
+
+	for i, j := 0, len(tokens)-1; i < j; i, j = i+1, j-1 {
+		tokens[i], tokens[j] = tokens[j], tokens[i]
+	}
+	return tokens
+}
+
+// fallback returns the byte pieces of an unknown character, if the model has them all.
+func (m *unigram) fallback(char string) []string {
+	if len(m.fallbacks) == 0 {
+		return nil
+	}
+
+	bytes := make([]string, 0, len(char))
+	for i := 0; i < len(char); i++ {
+		token := fmt.Sprintf("<0x%02X>", char[i])
+		if _, ok := m.fallbacks[token]; !ok {
+			return nil
+		}
+		bytes = append(bytes, token)
+	}
+	return bytes
+}
@@ -0,0 +1,202 @@
+package hftokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// normalizer rewrites a text before it is pre-tokenized.
+type normalizer func(text string) string
+
+// normalizerJSON holds the options of all supported normalizers.
+type normalizerJSON struct {
+	Type               string            `json:"type"`
+	CleanText          *bool             `json:"clean_text"`
+	HandleChineseChars *bool             `json:"handle_chinese_chars"`
+	StripAccents       *bool             `json:"strip_accents"`
+	Lowercase          *bool             `json:"lowercase"`
+	Normalizers        []json.RawMessage `json:"normalizers"`
+	Prepend            string            `json:"prepend"`
+	Content            string            `json:"content"`
+	Pattern            struct {
+		String *string `json:"String"`
+	} `json:"pattern"`
+	Left  bool `json:"left"`
+	Right bool `json:"right"`
+}
+
+// parseNormalizer parses a normalizer into the sequence of steps it applies.
+func parseNormalizer(raw json.RawMessage) ([]normalizer, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+
+	var spec normalizerJSON
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, fmt.Errorf("parsing normalizer: %w", err)
+	}
+
+	switch spec.Type {
+	case "Sequence":
+		var steps []normalizer
+		for _, child := range spec.Normalizers {
+			childSteps, err := parseNormalizer(child)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, childSteps...)
+		}
+		return steps, nil
+	case "BertNormalizer":
+		lowercase := spec.Lowercase == nil || *spec.Lowercase
+		// Accents are stripped along with lowercasing, unless configured explicitly
+		stripAccents := lowercase
+		if spec.StripAccents != nil {
+			stripAccents = *spec.StripAccents
+		}
+
+		var steps []normalizer
+		if spec.CleanText == nil || *spec.CleanText {
+			steps = append(steps, cleanText)
+		}
+		if spec.HandleChineseChars == nil || *spec.HandleChineseChars {
+			steps = append(steps, padChineseChars)
+		}
+		if stripAccents {
+			steps = append(steps, stripAccentsText)
+		}
+		if lowercase {
+			steps = append(steps, strings.ToLower)
+		}
+		return steps, nil
+	case "Lowercase":
+		return []normalizer{strings.ToLower}, nil
+	case "StripAccents":
+		return []normalizer{stripAccentsText}, nil
+	case "NFC", "NFD", "NFKC", "NFKD":
+		// Not available in the standard library, see the package documentation
+		return nil, nil
+	case "Prepend":
+		prefix := spec.Prepend
+		return []normalizer{func(text string) string {
+			if text == "" {
+				return text
+			}
+			return prefix + text
+		}}, nil
+	case "Replace":
+		if spec.Pattern.String == nil {
+			return nil, fmt.Errorf("normalizer Replace with a regex pattern: %w", ErrUnsupported)
+		}
+		pattern, content := *spec.Pattern.String, spec.Content
+		return []normalizer{func(text string) string {
+			return strings.ReplaceAll(text, pattern, content)
+		}}, nil
+	case "Strip":
+		left, right := spec.Left, spec.Right
+		return []normalizer{func(text string) string {
+			if left {
+				text = strings.TrimLeftFunc(text, unicode.IsSpace)
+			}
+			if right {
+				text = strings.TrimRightFunc(text, unicode.IsSpace)
+			}
+			return text
+		}}, nil
+	}
+	return nil, fmt.Errorf("normalizer %q: %w", spec.Type, ErrUnsupported)
+}
+
+// cleanText removes control characters and replaces all whitespace with spaces.
+func cleanText(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar:
+			return -1
+		case r == '\t' || r == '\n' || r == '\r':
+			return ' '
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// padChineseChars surrounds CJK ideographs with spaces, so each becomes its own word.
+func padChineseChars(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if isChineseChar(r) {
+			sb.WriteByte(' ')
+			sb.WriteRune(r)
+			sb.WriteByte(' ')
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// isChineseChar reports whether a rune is in one of the CJK ideograph blocks.
+func isChineseChar(r rune) bool {
+	return (r >= 0x4E00 && r <= 0x9FFF) ||
+		(r >= 0x3400 && r <= 0x4DBF) ||
+		(r >= 0x20000 && r <= 0x2A6DF) ||
+		(r >= 0x2A700 && r <= 0x2B73F) ||
+		(r >= 0x2B740 && r <= 0x2B81F) ||
+		(r >= 0x2B820 && r <= 0x2CEAF) ||
+		(r >= 0xF900 && r <= 0xFAFF) ||
+		(r >= 0x2F800 && r <= 0x2FA1F)
+}
+
+// stripAccentsText removes combining marks and folds precomposed Latin letters to their
+// base letter, which matches decomposing the text and dropping the marks.
+func stripAccentsText(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if base, ok := accentFolds[r]; ok {
+			sb.WriteRune(base)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// accentFolds maps precomposed Latin letters to the letter they decompose to.
+var accentFolds = func() map[rune]rune {
+	folds := make(map[rune]rune)
+	for base, accented := range map[rune]string{
+		'A': "ÀÁÂÃÄÅĀĂĄǍ", 'a': "àáâãäåāăąǎ",
+		'C': "ÇĆĈĊČ", 'c': "çćĉċč",
+		'D': "Ď", 'd': "ď",
+		'E': "ÈÉÊËĒĔĖĘĚ", 'e': "èéêëēĕėęě",
+		'G': "ĜĞĠĢ", 'g': "ĝğġģ",
+		'H': "Ĥ", 'h': "ĥ",
+		'I': "ÌÍÎÏĨĪĬĮİǏ", 'i': "ìíîïĩīĭįǐ",
+		'J': "Ĵ", 'j': "ĵ",
+		'K': "Ķ", 'k': "ķ",
+		'L': "ĹĻĽ", 'l': "ĺļľ",
+		'N': "ÑŃŅŇ", 'n': "ñńņň",
+		'O': "ÒÓÔÕÖŌŎŐƠǑ", 'o': "òóôõöōŏőơǒ",
+		'R': "ŔŖŘ", 'r': "ŕŗř",
+		'S': "ŚŜŞŠ", 's': "śŝşš",
+		'T': "ŢŤ", 't': "ţť",
+		'U': "ÙÚÛÜŨŪŬŮŰŲƯǓ", 'u': "ùúûüũūŭůűųưǔ",
+		'W': "Ŵ", 'w': "ŵ",
+		'Y': "ÝŶŸ", 'y': "ýÿŷ",
+		'Z': "ŹŻŽ", 'z': "źżž",
+	} {
+		for _, r := range accented {
+			folds[r] = base
+		}
+	}
+	return folds
+}()
@@ -0,0 +1,335 @@
+package hftokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// preTokenizer splits a normalized text into words, which the model tokenizes separately.
+type preTokenizer func(text string) []string
+
+// preTokenizerJSON holds the options of all supported pre-tokenizers.
+type preTokenizerJSON struct {
+	Type             string            `json:"type"`
+	PreTokenizers    []json.RawMessage `json:"pretokenizers"`
+	AddPrefixSpace   *bool             `json:"add_prefix_space"`
+	UseRegex         *bool             `json:"use_regex"`
+	Replacement      string            `json:"replacement"`
+	PrependScheme    string            `json:"prepend_scheme"`
+	Split            *bool             `json:"split"`
+	IndividualDigits bool              `json:"individual_digits"`
+}
+
+// parsePreTokenizer parses a pre-tokenizer.
+func parsePreTokenizer(raw json.RawMessage) (preTokenizer, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+
+	var spec preTokenizerJSON
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, fmt.Errorf("parsing pre-tokenizer: %w", err)
+	}
+
+	switch spec.Type {
+	case "Sequence":
+		var steps []preTokenizer
+		for _, child := range spec.PreTokenizers {
+			step, err := parsePreTokenizer(child)
+			if err != nil {
+				return nil, err
+			}
+			if step != nil {
+				steps = append(steps, step)
+			}
+		}
+		return func(text string) []string {
+			words := []string{text}
+			for _, step := range steps {
+				var next []string
+				for _, word := range words {
+					next = append(next, step(word)...)
+				}
+				words = next
+			}
+			return words
+		}, nil
+	case "BertPreTokenizer":
+		return splitBert, nil
+	case "Whitespace":
+		return splitWordsAndSymbols, nil
+	case "WhitespaceSplit":
+		return strings.Fields, nil
+	case "Punctuation":
+		return func(text string) []string {
+			return isolate(text, isPunctuation)
+		}, nil
+	case "Digits":
+		if spec.IndividualDigits {
+			return func(text string) []string {
+				return isolate(text, unicode.IsDigit)
+			}, nil
+		}
+		return splitDigitRuns, nil
+	case "ByteLevel":
+		addPrefixSpace := spec.AddPrefixSpace == nil || *spec.AddPrefixSpace
+		useRegex := spec.UseRegex == nil || *spec.UseRegex
+		return func(text string) []string {
+			return splitByteLevel(text, addPrefixSpace, useRegex)
+		}, nil
+	case "Metaspace":
+		replacement := spec.Replacement
+		if replacement == "" {
+			replacement = "▁"
+		}
+		scheme := spec.PrependScheme
+		if scheme == "" {
+			scheme = "always"
+			if spec.AddPrefixSpace != nil && !*spec.AddPrefixSpace {
+				scheme = "never"
+			}
+		}
+		split := spec.Split == nil || *spec.Split
+		return func(text string) []string {
+			return splitMetaspace(text, replacement, scheme != "never", split)
+		}, nil
+	}
+	return nil, fmt.Errorf("pre-tokenizer %q: %w", spec.Type, ErrUnsupported)
+}
+
+// splitBert splits a text on whitespace and isolates every punctuation character.
+func splitBert(text string) []string {
+	var words []string
+	for _, field := range strings.Fields(text) {
+		words = append(words, isolate(field, isPunctuation)...)
+	}
+	return words
+}
+
+// isolate splits a text into runs of characters, making every character matching
+// isolated a word of its own.
+func isolate(text string, isolated func(rune) bool) []string {
+	var words []string
+	start := 0
+	for i, r := range text {
+		if !isolated(r) {
+			continue
+		}
+		if i > start {
+			words = append(words, text[start:i])
+		}
+		end := i + len(string(r))
+		words = append(words, text[i:end])
+		start = end
+	}
+	if start < len(text) {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// isPunctuation reports whether a rune is punctuation, counting all non-alphanumeric
+// ASCII characters as punctuation like BERT does.
+func isPunctuation(r rune) bool {
+	if (r >= 33 && r <= 47) || (r >= 58 && r <= 64) || (r >= 91 && r <= 96) || (r >= 123 && r <= 126) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+// isWordChar reports whether a rune matches \w.
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.M, r) || unicode.Is(unicode.Pc, r)
+}
+
+// splitWordsAndSymbols splits a text into runs of word characters and runs of symbols,
+// dropping whitespace, like the regex \w+|[^\w\s]+.
+func splitWordsAndSymbols(text string) []string {
+	return splitRuns(text, func(r rune) int {
+		switch {
+		case unicode.IsSpace(r):
+			return 0
+		case isWordChar(r):
+			return 1
+		}
+		return 2
+	})
+}
+
+// splitDigitRuns isolates runs of digits from the rest of a text.
+func splitDigitRuns(text string) []string {
+	return splitRuns(text, func(r rune) int {
+		if unicode.IsDigit(r) {
+			return 1
+		}
+		return 2
+	})
+}
+
+// splitRuns splits a text into maximal runs of characters of the same class, dropping
+// characters of class zero.
+func splitRuns(text string, class func(rune) int) []string {
+	var words []string
+	start, current := 0, 0
+	for i, r := range text {
+		c := class(r)
+		if c != current {
+			if current != 0 {
+				words = append(words, text[start:i])
+			}
+			start, current = i, c
+		}
+	}
+	if current != 0 {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// splitMetaspace replaces spaces with the replacement character and splits the text in
+// front of every replacement, so each word starts with it.
+func splitMetaspace(text, replacement string, prepend, split bool) []string {
+	text = strings.ReplaceAll(text, " ", replacement)
+	if prepend && !strings.HasPrefix(text, replacement) {
+		text = replacement + text
+	}
+	if !split {
+		return []string{text}
+	}
+
+	var words []string
+	start := 0
+	for i := 0; i < len(text); {
+		if !strings.HasPrefix(text[i:], replacement) {
+			i++
+			continue
+		}
+		if i > start {
+			words = append(words, text[start:i])
+			start = i
+		}
+		i += len(replacement)
+	}
+	if start < len(text) {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+// splitByteLevel splits a text like GPT-2 and maps the bytes of every word to the
+// printable characters of the byte-level vocabulary.
+func splitByteLevel(text string, addPrefixSpace, useRegex bool) []string {
+	if addPrefixSpace && !strings.HasPrefix(text, " ") {
+		text = " " + text
+	}
+
+	words := []string{text}
+	if useRegex {
+		words = splitGPT2(text)
+	}
+	for i, word := range words {
+		words[i] = byteLevelEncode(word)
+	}
+	return words
+}
+
+// gpt2Contractions are the suffixes the GPT-2 pattern splits off as words of their own.
+var gpt2Contractions = []string{"'s", "'t", "'re", "'ve", "'m", "'ll", "'d"}
+
+// splitGPT2 splits a text like the GPT-2 pattern
+//
+//	's|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+
+//
+// which the regexp package cannot express, as it lacks lookahead.
+func splitGPT2(text string) []string {
+	runes := []rune(text)
+	var words []string
+	for i := 0; i < len(runes); {
+		start := i
+
+		if runes[i] == '\'' {
+			if suffix := matchContraction(runes[i:]); suffix > 0 {
+				words = append(words, string(runes[i:i+suffix]))
+				i += suffix
+				continue
+			}
+		}
+
+		if unicode.IsSpace(runes[i]) && !(runes[i] == ' ' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1])) {
+			end := i
+			for end < len(runes) && unicode.IsSpace(runes[end]) {
+				end++
+			}
+			// Leave the last whitespace character to the following word, where a space
+			// becomes its prefix
+			if end < len(runes) && end-i > 1 {
+				end--
+			}
+			words = append(words, string(runes[i:end]))
+			i = end
+			continue
+		}
+
+		if runes[i] == ' ' {
+			i++
+		}
+		class := gpt2Class(runes[i])
+		for i < len(runes) && gpt2Class(runes[i]) == class {
+			i++
+		}
+		words = append(words, string(runes[start:i]))
+	}
+	return words
+}
+
+// matchContraction returns the length of the contraction at the start of runes, or zero.
+func matchContraction(runes []rune) int {
+	for _, contraction := range gpt2Contractions {
+		n := len(contraction)
+		if len(runes) >= n && string(runes[:n]) == contraction {
+			return n
+		}
+	}
+	return 0
+}
+
+// gpt2Class returns the character class a GPT-2 word is made of: letters, numbers,
+// other symbols, or whitespace.
+func gpt2Class(r rune) int {
+	switch {
+	case unicode.IsLetter(r):
+		return 1
+	case unicode.IsNumber(r):
+		return 2
+	case unicode.IsSpace(r):
+		return 0
+	}
+	return 3
+}
+
+// byteLevelAlphabet maps every byte to the printable character that represents it in a
+// byte-level vocabulary, e.g. the space to "Ġ".
+var byteLevelAlphabet = func() [256]rune {
+	var alphabet [256]rune
+	n := 0
+	for b := 0; b < 256; b++ {
+		if (b >= '!' && b <= '~') || (b >= 0xA1 && b <= 0xAC) || (b >= 0xAE && b <= 0xFF) {
+			alphabet[b] = rune(b)
+			continue
+		}
+		alphabet[b] = rune(256 + n)
+		n++
+	}
+	return alphabet
+}()
+
+// byteLevelEncode maps the bytes of a word to the byte-level alphabet.
+func byteLevelEncode(word string) string {
+	var sb strings.Builder
+	for i := 0; i < len(word); i++ {
+		sb.WriteRune(byteLevelAlphabet[word[i]])
+	}
+	return sb.String()
+}
@@ -0,0 +1,184 @@
+package hftokenizer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SentencePiece model types, from the trainer spec.
+const (
+	spModelUnigram = 1
+	spModelBPE     = 2
+)
+
+// SentencePiece piece types.
+const (
+	spPieceNormal      = 1
+	spPieceUnknown     = 2
+	spPieceControl     = 3
+	spPieceUserDefined = 4
+	spPieceUnused      = 5
+	spPieceByte        = 6
+)
+
+// errTruncated is returned for SentencePiece models that end in the middle of a field.
+var errTruncated = errors.New("truncated SentencePiece model")
+
+// spPiece is a piece of a SentencePiece model.
+type spPiece struct {
+	piece     string
+	score     float64
+	pieceType int
+}
+
+// ParseSentencePiece parses a SentencePiece model, i.e. the contents of a ".model" file.
+// Unigram and BPE models are supported. As with tokenizer.json files, the text is not
+// Unicode normalized.
+func ParseSentencePiece(data []byte) (*Tokenizer, error) {
+	var pieces []spPiece
+	modelType := spModelUnigram
+	addDummyPrefix, removeExtraWhitespace := true, true
+
+	err := readProto(data, func(field int, value uint64, bytes []byte) error {
+		switch field {
+		case 1: // pieces
+			piece := spPiece{pieceType: spPieceNormal}
+			err := readProto(bytes, func(field int, value uint64, bytes []byte) error {
+				switch field {
+				case 1:
+					piece.piece = string(bytes)
+				case 2:
+					piece.score = float64(math.Float32frombits(uint32(value)))
+				case 3:
+					piece.pieceType = int(value)
+				}
+				return nil
+			})
+			pieces = append(pieces, piece)
+			return err
+		case 2: // trainer_spec
+			return readProto(bytes, func(field int, value uint64, bytes []byte) error {
+				if field == 3 {
+					modelType = int(value)
+				}
+				return nil
+			})
+		case 3: // normalizer_spec
+			return readProto(bytes, func(field int, value uint64, bytes []byte) error {
+				switch field {
+				case 3:
+					addDummyPrefix = value != 0
+				case 4:
+					removeExtraWhitespace = value != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parsing SentencePiece model: %w", err)
+	}
+
+	t := &Tokenizer{}
+	if removeExtraWhitespace {
+		t.normalizers = append(t.normalizers, func(text string) string {
+			return strings.Join(strings.Fields(text), " ")
+		})
+	}
+	t.preTokenizer = func(text string) []string {
+		text = strings.ReplaceAll(text, " ", "▁")
+		if addDummyPrefix && text != "" && !strings.HasPrefix(text, "▁") {
+			text = "▁" + text
+		}
+		if text == "" {
+			return nil
+		}
+		return []string{text}
+	}
+
+	switch modelType {
+	case spModelUnigram:
+		m := newUnigram()
+		for _, piece := range pieces {
+			switch piece.pieceType {
+			case spPieceUnknown:
+				m.unk = piece.piece
+			case spPieceNormal, spPieceUserDefined:
+				m.add(piece.piece, piece.score, false)
+			case spPieceByte:
+				m.add(piece.piece, piece.score, true)
+			}
+		}
+		m.finish()
+		t.model = m
+	case spModelBPE:
+		m := &bpe{vocab: make(map[string]struct{}), scores: make(map[string]float64)}
+		for _, piece := range pieces {
+			switch piece.pieceType {
+			case spPieceUnknown:
+				m.unk = piece.piece
+			case spPieceByte:
+				m.vocab[piece.piece] = struct{}{}
+				m.byteFallback = true
+			case spPieceNormal, spPieceUserDefined:
+				m.vocab[piece.piece] = struct{}{}
+				m.scores[piece.piece] = piece.score
+			}
+		}
+		t.model = m
+	default:
+		return nil, fmt.Errorf("SentencePiece model type %d: %w", modelType, ErrUnsupported)
+	}
+	return t, nil
+}
+
+// readProto calls fn for every field of a protobuf message. Varint and fixed-width values
+// are passed as value, length-delimited ones as bytes.
+func readProto(data []byte, fn func(field int, value uint64, bytes []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errTruncated
+		}
+		data = data[n:]
+
+		field := int(key >> 3)
+		var value uint64
+		var bytes []byte
+		switch key & 7 {
+		case 0: // varint
+			value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errTruncated
+			}
+			data = data[n:]
+		case 1: // 64-bit
+			if len(data) < 8 {
+				return errTruncated
+			}
+			value, data = binary.LittleEndian.Uint64(data), data[8:]
+		case 2: // length-delimited
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errTruncated
+			}
+			bytes, data = data[n:n+int(length)], data[n+int(length):]
+		case 5: // 32-bit
+			if len(data) < 4 {
+				return errTruncated
+			}
+			value, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		default:
+			return fmt.Errorf("field %d has unsupported wire type %d", field, key&7)
+		}
+
+		if err := fn(field, value, bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
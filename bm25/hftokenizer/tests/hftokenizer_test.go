@@ -0,0 +1,197 @@
+package hftokenizer_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/hftokenizer"
+)
+
+const wordPieceJSON = `{
+	"normalizer": {"type": "BertNormalizer", "clean_text": true, "handle_chinese_chars": true, "strip_accents": null, "lowercase": true},
+	"pre_tokenizer": {"type": "BertPreTokenizer"},
+	"post_processor": {"type": "TemplateProcessing"},
+	"model": {
+		"type": "WordPiece",
+		"unk_token": "[UNK]",
+		"continuing_subword_prefix": "##",
+		"max_input_chars_per_word": 100,
+		"vocab": {"[UNK]": 0, "the": 1, "un": 2, "##aff": 3, "##able": 4, ",": 5, "cafe": 6, "中": 7}
+	}
+}`
+
+func TestWordPiece(t *testing.T) {
+	tokenizer, err := hftokenizer.Parse([]byte(wordPieceJSON))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Lowercasing, accent stripping, punctuation and subword splitting
+	tokens := tokenizer.Tokenize("The unaffable, Café\tXyz中")
+	expected := []string{"the", "un", "##aff", "##able", ",", "cafe", "[UNK]", "中"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected tokens %v, but got %v", expected, tokens)
+	}
+
+	// Test case: Dropping unknown tokens
+	tokenizer.SkipUnknown = true
+	tokens = tokenizer.Tokenize("the xyz")
+	if !reflect.DeepEqual(tokens, []string{"the"}) {
+		t.Errorf("Expected unknown tokens to be skipped, but got %v", tokens)
+	}
+}
+
+func TestByteLevelBPE(t *testing.T) {
+	for _, merges := range []string{
+		`["h e", "l l", "he ll", "hell o", "Ġ hello", "' s"]`,
+		`[["h", "e"], ["l", "l"], ["he", "ll"], ["hell", "o"], ["Ġ", "hello"], ["'", "s"]]`,
+	} {
+		spec := `{
+			"normalizer": null,
+			"pre_tokenizer": {"type": "ByteLevel", "add_prefix_space": false, "use_regex": true},
+			"model": {
+				"type": "BPE",
+				"vocab": {"h": 0, "e": 1, "l": 2, "o": 3, "Ġ": 4, "he": 5, "ll": 6, "hell": 7, "hello": 8, "Ġhello": 9, "Ċ": 10, "'s": 11},
+				"merges": ` + merges + `
+			}
+		}`
+		tokenizer, err := hftokenizer.Parse([]byte(spec))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// Test case: Spaces are mapped to "Ġ" and prefix the following word
+		tokens := tokenizer.Tokenize("hello hello\nhello's")
+		expected := []string{"hello", "Ġhello", "Ċ", "hello", "'s"}
+		if !reflect.DeepEqual(tokens, expected) {
+			t.Errorf("Expected tokens %v, but got %v", expected, tokens)
+		}
+	}
+}
+
+func TestUnigramMetaspace(t *testing.T) {
+	spec := `{
+		"pre_tokenizer": {"type": "Metaspace", "replacement": "▁", "prepend_scheme": "always", "split": true},
+		"model": {
+			"type": "Unigram",
+			"unk_id": 0,
+			"vocab": [["<unk>", 0.0], ["▁hello", -1.0], ["▁world", -1.5], ["▁", -2.0], ["h", -3.0], ["e", -3.0], ["l", -3.0], ["o", -3.0], ["▁he", -2.0], ["llo", -2.0]]
+		}
+	}`
+	tokenizer, err := hftokenizer.Parse([]byte(spec))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: The segmentation with the highest score wins
+	tokens := tokenizer.Tokenize("hello world")
+	expected := []string{"▁hello", "▁world"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected tokens %v, but got %v", expected, tokens)
+	}
+
+	// Test case: Consecutive unknown characters are fused into one unknown token
+	tokens = tokenizer.Tokenize("hello zz")
+	expected = []string{"▁hello", "▁", "<unk>"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected tokens %v, but got %v", expected, tokens)
+	}
+}
+
+func TestUnsupportedComponent(t *testing.T) {
+	// Test case: Unsupported components are reported rather than silently ignored
+	_, err := hftokenizer.Parse([]byte(`{"pre_tokenizer": {"type": "Split"}, "model": {"type": "WordPiece", "vocab": {}}}`))
+	if !errors.Is(err, hftokenizer.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, but got %v", err)
+	}
+}
+
+// appendField appends a length-delimited protobuf field.
+func appendField(buf []byte, field int, data []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field<<3|2))
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// sentencePieceModel encodes a minimal SentencePiece model with the given pieces.
+func sentencePieceModel(modelType int, pieces []string, scores []float32, types []int) []byte {
+	var model []byte
+	for i, piece := range pieces {
+		var entry []byte
+		entry = appendField(entry, 1, []byte(piece))
+		entry = binary.AppendUvarint(entry, 2<<3|5)
+		entry = binary.LittleEndian.AppendUint32(entry, math.Float32bits(scores[i]))
+		entry = binary.AppendUvarint(entry, 3<<3)
+		entry = binary.AppendUvarint(entry, uint64(types[i]))
+		model = appendField(model, 1, entry)
+	}
+	trainerSpec := binary.AppendUvarint([]byte{3 << 3}, uint64(modelType))
+	return appendField(model, 2, trainerSpec)
+}
+
+func TestSentencePiece(t *testing.T) {
+	pieces := []string{"<unk>", "<s>", "▁hello", "▁wor", "ld", "▁"}
+	scores := []float32{0, 0, -1, -2, -2, -3}
+	types := []int{2, 3, 1, 1, 1, 1}
+
+	// Test case: Unigram model loaded from a .model file
+	path := filepath.Join(t.TempDir(), "spm.model")
+	if err := os.WriteFile(path, sentencePieceModel(1, pieces, scores, types), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tokenizer, err := hftokenizer.Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tokens := tokenizer.Tokenize("hello   world")
+	expected := []string{"▁hello", "▁wor", "ld"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected tokens %v, but got %v", expected, tokens)
+	}
+
+	// Test case: BPE model merging by piece score
+	pieces = []string{"<unk>", "▁", "h", "i", "▁h", "▁hi"}
+	scores = []float32{0, -5, -5, -5, -2, -1}
+	types = []int{2, 1, 1, 1, 1, 1}
+	tokenizer, err = hftokenizer.ParseSentencePiece(sentencePieceModel(2, pieces, scores, types))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tokens = tokenizer.Tokenize("hi")
+	if !reflect.DeepEqual(tokens, []string{"▁hi"}) {
+		t.Errorf("Expected tokens [▁hi], but got %v", tokens)
+	}
+
+	// Test case: Truncated model
+	_, err = hftokenizer.ParseSentencePiece([]byte{0x0a, 0x10})
+	if err == nil {
+		t.Errorf("Expected an error for a truncated model")
+	}
+}
+
+func TestTokenizerWithIndex(t *testing.T) {
+	tokenizer, err := hftokenizer.Parse([]byte(wordPieceJSON))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	corpus := []string{"the unaffable cafe", "the cafe", "the the"}
+
+	// Test case: Subword tokens are counted as stored, without re-tokenizing the document
+	index, err := bm25.NewBM25Okapi(corpus, tokenizer.Tokenize, 1.5, 0.75, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scores, err := index.GetScores(tokenizer.Tokenize("unaffable"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if scores[0] <= 0 || scores[1] != 0 || scores[2] != 0 {
+		t.Errorf("Expected only the first document to match, but got scores %v", scores)
+	}
+}
@@ -0,0 +1,119 @@
+// Package hftokenizer loads HuggingFace tokenizer.json files and SentencePiece models,
+// so that the token statistics of a BM25 index align exactly with the tokens a
+// downstream transformer, e.g. a reranker, sees.
+//
+// The WordPiece, BPE (including byte-level BPE) and Unigram models are supported, with
+// the common normalizers and pre-tokenizers. Unicode normalization forms (NFC, NFD,
+// NFKC, NFKD) are not applied, as the standard library does not implement them; texts
+// that are already normalized, which covers most text, tokenize identically. Post
+// processors, which add special tokens such as [CLS] and [SEP], are ignored.
+package hftokenizer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrUnsupported is returned for tokenizer components that are not supported.
+var ErrUnsupported = errors.New("unsupported tokenizer component")
+
+// model splits a pre-tokenized word into tokens.
+type model interface {
+	tokenize(word string) []string
+	unknown() string
+}
+
+// Tokenizer tokenizes text like the HuggingFace tokenizer it was loaded from.
+type Tokenizer struct {
+	normalizers  []normalizer
+	preTokenizer preTokenizer
+	model        model
+
+	// SkipUnknown drops the unknown token from the output. Unknown tokens match each
+	// other in a BM25 index, so documents with unrelated unknown words would match.
+	SkipUnknown bool
+}
+
+// Load loads a tokenizer from a tokenizer.json file, or from a SentencePiece model if the
+// file name ends in ".model".
+func Load(path string) (*Tokenizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(path) > 6 && path[len(path)-6:] == ".model" {
+		return ParseSentencePiece(data)
+	}
+	return Parse(data)
+}
+
+// tokenizerJSON is the layout of a tokenizer.json file.
+type tokenizerJSON struct {
+	Normalizer   json.RawMessage `json:"normalizer"`
+	PreTokenizer json.RawMessage `json:"pre_tokenizer"`
+	Model        json.RawMessage `json:"model"`
+}
+
+// Parse parses the contents of a tokenizer.json file.
+func Parse(data []byte) (*Tokenizer, error) {
+	var spec tokenizerJSON
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parsing tokenizer: %w", err)
+	}
+
+	t := &Tokenizer{}
+	var err error
+	if t.normalizers, err = parseNormalizer(spec.Normalizer); err != nil {
+		return nil, err
+	}
+	if t.preTokenizer, err = parsePreTokenizer(spec.PreTokenizer); err != nil {
+		return nil, err
+	}
+	if t.model, err = parseModel(spec.Model); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Tokenize splits a text into tokens. It can be passed as the tokenizer of an index.
+func (t *Tokenizer) Tokenize(text string) []string {
+	for _, normalize := range t.normalizers {
+		text = normalize(text)
+	}
+
+	words := []string{text}
+	if t.preTokenizer != nil {
+		words = t.preTokenizer(text)
+	}
+
+	tokens := []string{}
+	unk := t.model.unknown()
+	for _, word := range words {
+		for _, token := range t.model.tokenize(word) {
+			if t.SkipUnknown && token == unk {
+				continue
+			}
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// typed reads the type of a tokenizer component.
+func typed(raw json.RawMessage) (string, error) {
+	var component struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &component); err != nil {
+		return "", err
+	}
+	return component.Type, nil
+}
+
+// isNull reports whether a component is absent.
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
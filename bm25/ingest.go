@@ -0,0 +1,61 @@
+package bm25
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+)
+
+// ErrDocumentRejected is returned for a document rejected by an ingest hook, see OnIngest.
+// Hooks reject a document by returning an error wrapping it, e.g. to drop duplicates.
+var ErrDocumentRejected = errors.New("document rejected")
+
+// IngestHook transforms, enriches or rejects a document before it is tokenized, see
+// OnIngest. It can modify the document in place; its Metadata is a copy owned by the
+// index. Returning an error rejects the document.
+type IngestHook func(doc *Document) error
+
+// IndexEvent describes a document that has been indexed, see OnDocumentIndexed.
+type IndexEvent struct {
+	DocID       int     // Internal ID of the document
+	ID          string  // External ID of the document, if it has one
+	Length      int     // Number of terms of the document
+	UniqueTerms int     // Number of distinct terms of the document
+	CorpusSize  int     // Corpus size after the document was added
+	AvgDocLen   float64 // Average document length after the document was added
+}
+
+// OnIngest registers a hook that is run on every document added with AddDocument,
+// UpdateDocument, the bulk mutations or a Builder, before it is validated and tokenized,
+// e.g. to scrub personal data, normalize its text or add metadata. Hooks run in the order
+// they were registered; the first one that returns an error rejects the document, which
+// is not added. Unlike the hooks of OnDocumentAdded, ingest hooks are kept by clones.
+func (b *Bm25Base) OnIngest(hook IngestHook) {
+	b.ingestHooks = append(b.ingestHooks, hook)
+}
+
+// OnDocumentIndexed registers a hook that is called with the statistics of every document
+// once it has been indexed and the corpus statistics have been updated. For a batch, the
+// hooks are called after the whole batch has been committed.
+func (b *Bm25Base) OnDocumentIndexed(hook func(event IndexEvent)) {
+	b.addHooks = append(b.addHooks, hook)
+}
+
+// ingest runs the ingest hooks on a document.
+func (b *Bm25Base) ingest(doc Document) (Document, error) {
+	if len(b.ingestHooks) == 0 {
+		return doc, nil
+	}
+
+	// The hooks must not modify the metadata of the caller
+	doc.Metadata = maps.Clone(doc.Metadata)
+	for _, hook := range b.ingestHooks {
+		if err := hook(&doc); err != nil {
+			if doc.ID != "" {
+				return doc, fmt.Errorf("document %q: %w", doc.ID, err)
+			}
+			return doc, err
+		}
+	}
+	return doc, nil
+}
@@ -0,0 +1,167 @@
+package bm25
+
+import "math"
+
+// KeywordFilter restricts a search to the documents whose keyword field contains one of
+// the values. A keyword field is a metadata field holding a string or a list of strings,
+// e.g. an ID, tags or an enum-like value. Its values are matched exactly, without
+// tokenization, and are not part of the document text, so they do not count towards the
+// document length.
+type KeywordFilter struct {
+	Field  string
+	Values []string
+}
+
+// KeywordBoost adds a constant to the score of the documents whose keyword field contains
+// the value, e.g. to rank documents tagged "official" higher. A document matching none
+// of the query terms becomes a match through a positive boost.
+type KeywordBoost struct {
+	Field string
+	Value string
+	Boost float64
+}
+
+// keywordIndex maps the values of a keyword field to the IDs of the documents containing
+// them, in ascending order.
+type keywordIndex map[string][]int
+
+// IndexKeywords builds the indexes of the given keyword fields. Keyword filters and
+// boosts build the index of a field on first use, so calling this upfront only moves
+// that cost out of the first search, and makes it available to frozen copies.
+func (b *Bm25Base) IndexKeywords(fields ...string) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	for _, field := range fields {
+		if _, err := b.keywordIndexFor(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keywordIndexFor returns the index of a keyword field, building it if needed. The index
+// is cached unless the caches are read-only.
+func (b *Bm25Base) keywordIndexFor(field string) (keywordIndex, error) {
+	if index, ok := b.keywords[field]; ok {
+		return index, nil
+	}
+
+	index := make(keywordIndex)
+	for docID, metadata := range b.metadata {
+		value, ok := metadata[field]
+		if !ok || value == nil {
+			continue
+		}
+
+		values, ok := keywordValues(value)
+		if !ok {
+			return nil, invalidParam("metadata."+field, value, "must be a string or a list of strings")
+		}
+		for _, v := range values {
+			if docIDs := index[v]; len(docIDs) == 0 || docIDs[len(docIDs)-1] != docID {
+				index[v] = append(docIDs, docID)
+			}
+		}
+	}
+
+	if b.cachesWritable() {
+		if b.keywords == nil {
+			b.keywords = make(map[string]keywordIndex)
+		}
+		b.keywords[field] = index
+	}
+	return index, nil
+}
+
+// keywordValues returns the values of a keyword field: a string, a list of strings, or a
+// list of strings decoded from JSON.
+func keywordValues(value any) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, true
+	case []string:
+		return v, true
+	case []any:
+		values := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values[i] = s
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// keywordMask returns a mask of the documents matching all keyword filters, or nil if
+// there are none.
+func (b *Bm25Base) keywordMask(filters []KeywordFilter) ([]bool, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	var mask []bool
+	for _, filter := range filters {
+		if filter.Field == "" {
+			return nil, invalidParam("field", filter.Field, "must not be empty")
+		}
+		index, err := b.keywordIndexFor(filter.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		matches := make([]bool, b.corpusSize)
+		for _, value := range filter.Values {
+			for _, docID := range index[value] {
+				matches[docID] = true
+			}
+		}
+		if mask == nil {
+			mask = matches
+			continue
+		}
+		for i := range mask {
+			mask[i] = mask[i] && matches[i]
+		}
+	}
+	return mask, nil
+}
+
+// validateKeywordBoosts checks that the boosts have a field and a finite boost.
+func validateKeywordBoosts(boosts []KeywordBoost) error {
+	for _, boost := range boosts {
+		if boost.Field == "" {
+			return invalidParam("field", boost.Field, "must not be empty")
+		}
+		if math.IsNaN(boost.Boost) || math.IsInf(boost.Boost, 0) {
+			return invalidParam("boost", boost.Boost, "must be finite")
+		}
+	}
+	return nil
+}
+
+// applyKeywordBoosts adds the keyword boosts to the scores of the matching documents, and
+// adds the positively boosted ones to matched if it is not nil. If contributions is not
+// nil, the boosts of every document are recorded in it.
+func (b *Bm25Base) applyKeywordBoosts(scores []float64, boosts []KeywordBoost, matched *Bitmap, contributions map[int][]TermContribution) error {
+	for _, boost := range boosts {
+		index, err := b.keywordIndexFor(boost.Field)
+		if err != nil {
+			return err
+		}
+		for _, docID := range index[boost.Value] {
+			scores[docID] += boost.Boost
+			if matched != nil && boost.Boost > 0 {
+				matched.Add(docID)
+			}
+			if contributions != nil {
+				contributions[docID] = append(contributions[docID], TermContribution{Term: boost.Field + ":" + boost.Value, Score: boost.Boost})
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package bm25
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrNoSamples is returned when a language detector is trained without samples.
+var ErrNoSamples = errors.New("at least one sample is required")
+
+// LanguageDetector detects the language of a text, so documents and queries without a
+// language tag can be routed to the analyzer of their language. Detect returns the
+// language tag, or an empty string if the language cannot be determined.
+type LanguageDetector interface {
+	Detect(text string) string
+}
+
+// LanguageDetectorFunc adapts a function to the LanguageDetector interface.
+type LanguageDetectorFunc func(text string) string
+
+// Detect calls f(text).
+func (f LanguageDetectorFunc) Detect(text string) string {
+	return f(text)
+}
+
+// SetLanguageDetector sets the detector used by Analyze for the documents and queries
+// without a language tag, when language analyzers are set. Texts whose language cannot
+// be detected are tokenized with the tokenizer of the index. Passing nil disables
+// detection. Like the analyzers, the detector is not saved in snapshots.
+func (b *Bm25Base) SetLanguageDetector(detector LanguageDetector) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	b.detector = detector
+	return nil
+}
+
+// NgramDetector detects languages from the character trigrams of a text, scored with a
+// naive Bayes model trained on a sample text per language. It is accurate for sentences
+// and documents, but unreliable for queries of one or two words, whose language is best
+// passed explicitly.
+type NgramDetector struct {
+	languages []string
+	profiles  map[string]map[string]float64 // Log probabilities of the trigrams
+	unseen    map[string]float64            // Log probability of an unseen trigram
+	minGrams  int
+}
+
+// NewNgramDetector trains a detector on a sample text per language. Texts with fewer
+// than minTrigrams trigrams are not detected.
+func NewNgramDetector(samples map[string]string, minTrigrams int) (*NgramDetector, error) {
+	if len(samples) == 0 {
+		return nil, ErrNoSamples
+	}
+	if minTrigrams < 0 {
+		return nil, invalidParam("minTrigrams", minTrigrams, "must be non-negative")
+	}
+
+	d := &NgramDetector{
+		profiles: make(map[string]map[string]float64, len(samples)),
+		unseen:   make(map[string]float64, len(samples)),
+		minGrams: max(1, minTrigrams),
+	}
+	for language, sample := range samples {
+		counts := make(map[string]int)
+		total := 0
+		forEachTrigram(sample, func(trigram string) {
+			counts[trigram]++
+			total++
+		})
+		if total == 0 {
+			return nil, invalidParam("samples", language, "sample has no trigrams")
+		}
+
+		// Add-one smoothing, with one extra slot for the unseen trigrams
+		denominator := math.Log(float64(total + len(counts) + 1))
+		profile := make(map[string]float64, len(counts))
+		for trigram, count := range counts {
+			profile[trigram] = math.Log(float64(count+1)) - denominator
+		}
+		d.profiles[language] = profile
+		d.unseen[language] = -denominator
+		d.languages = append(d.languages, language)
+	}
+	sort.Strings(d.languages)
+	return d, nil
+}
+
+// Detect returns the most likely language of the text, or an empty string if the text
+// is too short.
+func (d *NgramDetector) Detect(text string) string {
+	scores := make([]float64, len(d.languages))
+	grams := 0
+	forEachTrigram(text, func(trigram string) {
+		grams++
+		for i, language := range d.languages {
+			if p, ok := d.profiles[language][trigram]; ok {
+				scores[i] += p
+			} else {
+				scores[i] += d.unseen[language]
+			}
+		}
+	})
+	if grams < d.minGrams {
+		return ""
+	}
+
+	best := 0
+	for i := range scores {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	return d.languages[best]
+}
+
+// forEachTrigram calls fn for every character trigram of the lowercased words of a text,
+// each word padded with a space on both sides. Words containing digits are skipped.
+func forEachTrigram(text string, fn func(trigram string)) {
+	for _, word := range splitWords(strings.ToLower(text)) {
+		if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			continue
+		}
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			fn(string(runes[i : i+3]))
+		}
+	}
+}
+
+var (
+	defaultDetector     *NgramDetector
+	defaultDetectorOnce sync.Once
+)
+
+// DefaultLanguageDetector returns an NgramDetector for the languages of the built-in
+// analyzers, see LanguageAnalyzer, trained on a short built-in sample of each. Texts with
+// fewer than 8 trigrams, about two words, are not detected.
+func DefaultLanguageDetector() *NgramDetector {
+	defaultDetectorOnce.Do(func() {
+		defaultDetector, _ = NewNgramDetector(languageSamples, 8)
+	})
+	return defaultDetector
+}
+
+// languageSamples are the training texts of the default language detector.
+var languageSamples = map[string]string{
+	"de": `Die Suche in großen Dokumentensammlungen ist eine der wichtigsten Aufgaben der
+		Informatik. Wenn ein Benutzer eine Anfrage stellt, werden die Dokumente nach ihrer
+		Relevanz sortiert, und die besten Ergebnisse erscheinen zuerst. Dabei spielt nicht nur
+		die Häufigkeit der Wörter eine Rolle, sondern auch die Länge der Texte. Heute wird das
+		Wetter in der ganzen Stadt schön, aber morgen soll es regnen. Wir haben uns über die
+		Nachricht sehr gefreut und sind gleich zu unseren Freunden gefahren. Ich weiß nicht,
+		ob sie schon zu Hause sind oder noch bei der Arbeit. Das Buch, das auf dem Tisch liegt,
+		gehört meiner Schwester, die gerne Geschichten über Reisen und fremde Länder liest.`,
+	"en": `Searching large collections of documents is one of the most important tasks in
+		computer science. When a user submits a query, the documents are ranked by their
+		relevance, and the best results are shown first. Not only the frequency of the words
+		matters, but also the length of the texts. The weather will be nice in the whole city
+		today, but it should rain tomorrow. We were very happy about the news and went to see
+		our friends right away. I do not know whether they are already at home or still at
+		work. The book lying on the table belongs to my sister, who likes reading stories
+		about travelling and foreign countries.`,
+	"es": `La búsqueda en grandes colecciones de documentos es una de las tareas más
+		importantes de la informática. Cuando un usuario hace una consulta, los documentos se
+		ordenan según su relevancia y los mejores resultados aparecen primero. No solo importa
+		la frecuencia de las palabras, sino también la longitud de los textos. Hoy hará buen
+		tiempo en toda la ciudad, pero mañana debería llover. Nos alegramos mucho de la noticia
+		y fuimos enseguida a ver a nuestros amigos. No sé si ya están en casa o todavía en el
+		trabajo. El libro que está sobre la mesa es de mi hermana, que lee con gusto historias
+		sobre viajes y países lejanos.`,
+	"fr": `La recherche dans de grandes collections de documents est l'une des tâches les plus
+		importantes de l'informatique. Lorsqu'un utilisateur pose une question, les documents
+		sont classés selon leur pertinence et les meilleurs résultats apparaissent en premier.
+		Ce n'est pas seulement la fréquence des mots qui compte, mais aussi la longueur des
+		textes. Aujourd'hui il fera beau dans toute la ville, mais demain il devrait pleuvoir.
+		Nous étions très heureux de la nouvelle et nous sommes allés voir nos amis tout de
+		suite. Je ne sais pas s'ils sont déjà à la maison ou encore au travail. Le livre qui se
+		trouve sur la table appartient à ma sœur, qui aime lire des histoires de voyages et de
+		pays étrangers.`,
+}
@@ -0,0 +1,239 @@
+package bm25
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// LanguageAnalyzers returns the languages with a built-in analyzer, see LanguageAnalyzer.
+func LanguageAnalyzers() []string {
+	languages := make([]string, 0, len(languageStemmers))
+	for language := range languageStemmers {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// LanguageAnalyzer returns the built-in analyzer of a language, identified by its ISO
+// 639-1 code: "de", "en", "es" or "fr". It splits the text on characters other than
+// letters and digits, lowercases the tokens, removes the most common stopwords of the
+// language and applies a light stemmer, which removes plural and inflectional suffixes
+// only, so it rarely conflates unrelated words.
+func LanguageAnalyzer(language string) (Analyzer, bool) {
+	stem, ok := languageStemmers[language]
+	if !ok {
+		return Analyzer{}, false
+	}
+
+	return Analyzer{
+		Tokenizer: splitWords,
+		Filters: []TokenFilter{
+			LowercaseFilter,
+			StopwordFilter(languageStopwords[language]),
+			StemFilter(stem),
+		},
+	}, true
+}
+
+// SetLanguageAnalyzers routes the documents and queries tagged with one of the given
+// languages to its analyzer, so a multilingual corpus can be indexed with language-aware
+// stopwords and stemming. Documents without a language, or with a language that has no
+// analyzer, are tokenized with the tokenizer of the index. Documents already in the index
+// are not analyzed again. Passing nil removes the routing. Like the tokenizer, the
+// analyzers are not saved in snapshots and have to be set again after loading one.
+func (b *Bm25Base) SetLanguageAnalyzers(analyzers map[string]Analyzer) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	for language, analyzer := range analyzers {
+		if err := analyzer.validate(); err != nil {
+			return fmt.Errorf("analyzer for language %q: %w", language, err)
+		}
+	}
+
+	b.analyzers = maps.Clone(analyzers)
+	return nil
+}
+
+// Analyze returns the tokens of a text in the given language, using the analyzer
+// registered for it with SetLanguageAnalyzers, or the tokenizer of the index. Queries
+// have to be analyzed like the documents they search, so a query in a given language is
+// tokenized with Analyze(query, language). If the language is empty, it is detected by
+// the detector set with SetLanguageDetector, if any.
+func (b *Bm25Base) Analyze(text string, language string) []string {
+	if language == "" && b.detector != nil && len(b.analyzers) > 0 {
+		language = b.detector.Detect(text)
+	}
+	if analyzer, ok := b.analyzers[language]; ok && language != "" {
+		return analyzer.Analyze(text)
+	}
+	return b.tokenizer(text)
+}
+
+// splitWords splits a text on characters other than letters and digits.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// languageStemmers are the light stemmers of the built-in language analyzers.
+var languageStemmers = map[string]func(string) string{
+	"de": stemGerman,
+	"en": stemEnglish,
+	"es": stemSpanish,
+	"fr": stemFrench,
+}
+
+// languageStopwords are the stopwords of the built-in language analyzers.
+var languageStopwords = map[string][]string{
+	"de": strings.Fields(`aber als am an auch auf aus bei bin bis bist da dann das dass dem den der des die
+		dies doch du durch ein eine einem einen einer eines er es für hat hatte ich ihr im in ist
+		ja kann mit nach nicht noch nur oder sich sie sind so über um und uns vom von vor war wie
+		wir wird zu zum zur`),
+	"en": strings.Fields(`a an and are as at be but by for if in into is it no not of on or such that the
+		their then there these they this to was will with`),
+	"es": strings.Fields(`a al como con de del el en es esta este ha la las le lo los más mi no o para
+		pero por que se sin su sus también un una y ya`),
+	"fr": strings.Fields(`à au aux avec ce ces dans de des du elle en est et il ils je la le les leur
+		mais ne nous on ou par pas pour qu que qui sa se ses son sur un une vous`),
+}
+
+// stemEnglish removes the plural suffixes of English words, e.g. "queries" becomes
+// "query" and "documents" becomes "document".
+func stemEnglish(word string) string {
+	s := []rune(word)
+	n := len(s)
+	if n < 3 || s[n-1] != 's' {
+		return word
+	}
+
+	switch s[n-2] {
+	case 'u', 's':
+		return word
+	case 'e':
+		if n > 3 && s[n-3] == 'i' && s[n-4] != 'a' && s[n-4] != 'e' {
+			return string(s[:n-3]) + "y"
+		}
+		if s[n-3] == 'i' || s[n-3] == 'a' || s[n-3] == 'o' || s[n-3] == 'e' {
+			return word
+		}
+	}
+	return string(s[:n-1])
+}
+
+// stemGerman removes the inflectional suffixes of German words and folds umlauts, e.g.
+// "Häusern" becomes "haus".
+func stemGerman(word string) string {
+	s := []rune(word)
+	for i, r := range s {
+		switch r {
+		case 'ä', 'à', 'á', 'â':
+			s[i] = 'a'
+		case 'ö', 'ò', 'ó', 'ô':
+			s[i] = 'o'
+		case 'ï', 'ì', 'í', 'î':
+			s[i] = 'i'
+		case 'ü', 'ù', 'ú', 'û':
+			s[i] = 'u'
+		}
+	}
+
+	sEnding := func(r rune) bool { return strings.ContainsRune("bdfghklmnrt", r) }
+	stEnding := func(r rune) bool { return strings.ContainsRune("bdfghklmnt", r) }
+
+	n := len(s)
+	switch {
+	case n > 5 && string(s[n-3:]) == "ern":
+		n -= 3
+	case n > 4 && s[n-2] == 'e' && strings.ContainsRune("emnrs", s[n-1]):
+		n -= 2
+	case n > 3 && s[n-1] == 'e':
+		n--
+	case n > 3 && s[n-1] == 's' && sEnding(s[n-2]):
+		n--
+	}
+
+	switch {
+	case n > 5 && string(s[n-3:n]) == "est":
+		n -= 3
+	case n > 4 && s[n-2] == 'e' && (s[n-1] == 'r' || s[n-1] == 'n'):
+		n -= 2
+	case n > 4 && s[n-2] == 's' && s[n-1] == 't' && stEnding(s[n-3]):
+		n -= 2
+	}
+	return string(s[:n])
+}
+
+// stemFrench removes the plural and feminine suffixes of French words, e.g. "chevaux"
+// becomes "cheval" and "maisons" becomes "maison".
+func stemFrench(word string) string {
+	s := []rune(word)
+	n := len(s)
+	if n < 6 {
+		return word
+	}
+
+	if s[n-1] == 'x' {
+		if s[n-3] == 'a' && s[n-2] == 'u' {
+			s[n-2] = 'l'
+		}
+		return string(s[:n-1])
+	}
+
+	for _, suffix := range []rune{'s', 'r', 'e', 'é'} {
+		if s[n-1] == suffix {
+			n--
+		}
+	}
+	if s[n-1] == s[n-2] && unicode.IsLetter(s[n-1]) {
+		n--
+	}
+	return string(s[:n])
+}
+
+// stemSpanish removes the gender and plural suffixes of Spanish words and strips
+// accents, e.g. "canciones" becomes "cancion".
+func stemSpanish(word string) string {
+	s := []rune(word)
+	n := len(s)
+	if n < 5 {
+		return word
+	}
+
+	for i, r := range s {
+		switch r {
+		case 'à', 'á', 'â', 'ä':
+			s[i] = 'a'
+		case 'ò', 'ó', 'ô', 'ö':
+			s[i] = 'o'
+		case 'è', 'é', 'ê', 'ë':
+			s[i] = 'e'
+		case 'ù', 'ú', 'û', 'ü':
+			s[i] = 'u'
+		case 'ì', 'í', 'î', 'ï':
+			s[i] = 'i'
+		}
+	}
+
+	switch s[n-1] {
+	case 'o', 'a', 'e':
+		n--
+	case 's':
+		switch {
+		case s[n-2] == 'e' && s[n-3] == 's' && s[n-4] == 'e':
+			n -= 2
+		case s[n-2] == 'e' && s[n-3] == 'c':
+			s[n-3] = 'z'
+			n -= 2
+		case s[n-2] == 'o' || s[n-2] == 'a' || s[n-2] == 'e':
+			n -= 2
+		}
+	}
+	return string(s[:n])
+}
@@ -0,0 +1,305 @@
+package bm25
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sync"
+)
+
+// OpenSnapshot opens an index written with WriteSnapshot without reading the tokens of
+// its documents upfront. Only the settings, vocabulary, corpus statistics and document
+// attributes are read, so a large index becomes queryable in a fraction of the time a
+// full ReadSnapshot takes. The tokens are read in chunks of documents on first access
+// and kept afterwards: GetBatchScores and Rescore only read the chunks of the given
+// documents, while methods that scan the whole corpus, such as GetScores, read all
+// chunks on their first call. Call Preload to read them all upfront.
+//
+// r must stay readable until all chunks are read. A chunk that cannot be read, e.g.
+// because it is corrupted, is scored as an empty document; Search and Preload return the
+// error. Snapshots written in a format version before 3 are read in full.
+func OpenSnapshot(r io.ReaderAt, tokenizer func(string) []string, logger *log.Logger, opts SnapshotOptions) (*Bm25Base, error) {
+	if tokenizer == nil {
+		return nil, ErrNilTokenizer
+	}
+
+	sr := &sectionReaderAt{r: r}
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(sr, magic); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if string(magic) != snapshotMagic {
+		return ReadSnapshotWithOptions(io.NewSectionReader(r, 0, math.MaxInt64), tokenizer, logger, opts)
+	}
+	version, compressor, aead, err := readSnapshotHeader(sr, opts)
+	if err != nil {
+		return nil, err
+	}
+	if version < 3 {
+		return ReadSnapshotWithOptions(io.NewSectionReader(r, 0, math.MaxInt64), tokenizer, logger, opts)
+	}
+
+	lazy := &lazyCorpus{r: r, version: version, compressor: compressor, aead: aead, logger: logger}
+	snap := &snapshot{}
+	var docLengths []int
+	var docFreqs []uint64
+	attributes := 0
+	progress := newSnapshotProgress(opts, 0)
+	for seq := uint64(0); ; seq++ {
+		header := make([]byte, 9)
+		if _, err := r.ReadAt(header, sr.off); err != nil {
+			return nil, fmt.Errorf("%w: truncated section header: %w", ErrInvalidSnapshot, err)
+		}
+		if header[0] == sectionTokens {
+			// Skip the payload and checksum of the section
+			length := binary.LittleEndian.Uint64(header[1:])
+			if length > math.MaxInt64/2 {
+				return nil, fmt.Errorf("%w: invalid section length", ErrInvalidSnapshot)
+			}
+			lazy.chunks = append(lazy.chunks, &lazyChunk{offset: sr.off, seq: seq})
+			sr.off += int64(len(header)) + int64(length) + 4
+			progress.report(attributes, sr.off)
+			continue
+		}
+
+		kind, payload, err := readSection(sr, version, compressor, aead, seq)
+		if err != nil {
+			return nil, err
+		}
+		if kind == sectionEnd {
+			progress.report(attributes, sr.off)
+			break
+		}
+
+		d := &snapshotDecoder{buf: payload}
+		switch kind {
+		case sectionSettings:
+			if err := unmarshalSettings(payload, snap); err != nil {
+				return nil, err
+			}
+		case sectionVocabulary:
+			for len(d.buf) > 0 && d.err == nil {
+				lazy.vocabulary = append(lazy.vocabulary, d.string())
+			}
+		case sectionStats:
+			lazy.chunkDocs = int(d.uvarint())
+			if n := d.uvarint(); n <= uint64(len(d.buf)) {
+				docLengths = make([]int, n)
+			} else {
+				d.fail("document count")
+			}
+			for i := range docLengths {
+				docLengths[i] = int(d.uvarint())
+			}
+			progress.setTotal(len(docLengths))
+			if n := d.uvarint(); n <= uint64(len(d.buf)) {
+				docFreqs = make([]uint64, n)
+			} else {
+				d.fail("term count")
+			}
+			for i := range docFreqs {
+				docFreqs[i] = d.uvarint()
+			}
+		case sectionAttributes:
+			for ; len(d.buf) > 0 && d.err == nil; attributes++ {
+				d.attributes(snap, attributes)
+			}
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		progress.report(attributes, sr.off)
+	}
+
+	if len(docLengths) == 0 {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, ErrEmptyCorpus)
+	}
+	if len(docFreqs) != len(lazy.vocabulary) || lazy.chunkDocs <= 0 || len(lazy.chunks) != (len(docLengths)+lazy.chunkDocs-1)/lazy.chunkDocs {
+		return nil, fmt.Errorf("%w: statistics do not match the documents", ErrInvalidSnapshot)
+	}
+	if attributes > len(docLengths) {
+		return nil, fmt.Errorf("%w: more document attributes than documents", ErrInvalidSnapshot)
+	}
+	lazy.docLengths = docLengths
+
+	base := &Bm25Base{
+		corpus:     make([][]string, len(docLengths)),
+		corpusSize: len(docLengths),
+		docLengths: docLengths,
+		termFreqs:  make(map[string]int, len(docFreqs)),
+		idfCache:   make(map[string]float64),
+		tokenizer:  tokenizer,
+		logger:     logger,
+		lazy:       lazy,
+	}
+	totalDocLen := 0
+	for _, length := range docLengths {
+		totalDocLen += length
+	}
+	base.avgDocLen = float64(totalDocLen) / float64(base.corpusSize)
+	for termID, docFreq := range docFreqs {
+		base.termFreqs[lazy.vocabulary[termID]] = int(docFreq)
+	}
+	for docID := range docLengths {
+		var doc Document
+		if docID < len(snap.ExternalIDs) {
+			doc.ID = snap.ExternalIDs[docID]
+		}
+		if docID < len(snap.Metadata) {
+			doc.Metadata = snap.Metadata[docID]
+		}
+		if err := base.setDocumentInfo(docID, doc); err != nil {
+			return nil, fmt.Errorf("%w: %w: %q", ErrInvalidSnapshot, err, doc.ID)
+		}
+	}
+	if err := applySnapshotSettings(base, snap); err != nil {
+		return nil, err
+	}
+
+	if logger != nil {
+		logger.Printf("Opened snapshot lazily, corpus size: %d, chunks: %d", base.corpusSize, len(lazy.chunks))
+	}
+	return base, nil
+}
+
+// Preload reads the tokens of all documents of an index opened with OpenSnapshot, and
+// returns the first error of reading a chunk. Afterwards, the snapshot is not read
+// anymore. It does nothing for other indexes.
+func (b *Bm25Base) Preload() error {
+	if b.lazy == nil {
+		return nil
+	}
+	for i := range b.lazy.chunks {
+		b.lazy.load(i)
+	}
+	return b.loadErr()
+}
+
+// doc returns the tokens of a document, reading them from the snapshot first if the
+// index was opened lazily.
+func (b *Bm25Base) doc(docID int) []string {
+	if b.lazy != nil && docID < len(b.lazy.docLengths) {
+		return b.lazy.doc(docID)
+	}
+	return b.corpus[docID]
+}
+
+// loadErr returns the first error of reading a chunk of a lazily opened index.
+func (b *Bm25Base) loadErr() error {
+	if b.lazy == nil {
+		return nil
+	}
+	b.lazy.mu.Lock()
+	defer b.lazy.mu.Unlock()
+	return b.lazy.err
+}
+
+// lazyCorpus holds the tokens of the documents of an index opened with OpenSnapshot.
+// Chunks are read at most once and never modified afterwards, so clones and frozen
+// copies of the index share them, and concurrent readers are safe.
+type lazyCorpus struct {
+	r          io.ReaderAt
+	version    uint16
+	compressor Compressor
+	aead       cipher.AEAD
+	vocabulary []string
+	docLengths []int
+	chunkDocs  int
+	chunks     []*lazyChunk
+	logger     *log.Logger
+
+	mu  sync.Mutex
+	err error
+}
+
+// lazyChunk is a tokens section of a snapshot.
+type lazyChunk struct {
+	offset int64
+	seq    uint64
+	once   sync.Once
+	docs   [][]string
+}
+
+// doc returns the tokens of a document, reading its chunk if needed.
+func (l *lazyCorpus) doc(docID int) []string {
+	chunk := l.load(docID / l.chunkDocs)
+	if i := docID % l.chunkDocs; i < len(chunk.docs) {
+		return chunk.docs[i]
+	}
+	return nil
+}
+
+// load reads the chunk with the given index, unless it has been read before.
+func (l *lazyCorpus) load(i int) *lazyChunk {
+	chunk := l.chunks[i]
+	chunk.once.Do(func() {
+		docs, err := l.read(chunk, i*l.chunkDocs)
+		if err != nil {
+			if l.logger != nil {
+				l.logger.Printf("Error reading snapshot section at offset %d: %v", chunk.offset, err)
+			}
+			l.mu.Lock()
+			if l.err == nil {
+				l.err = err
+			}
+			l.mu.Unlock()
+			return
+		}
+		chunk.docs = docs
+	})
+	return chunk
+}
+
+// read reads and decodes a chunk starting at the given document, checking it against the
+// corpus statistics.
+func (l *lazyCorpus) read(chunk *lazyChunk, start int) ([][]string, error) {
+	_, payload, err := readSection(&sectionReaderAt{r: l.r, off: chunk.offset}, l.version, l.compressor, l.aead, chunk.seq)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([][]string, 0, min(l.chunkDocs, len(l.docLengths)-start))
+	d := &snapshotDecoder{buf: payload}
+	for len(d.buf) > 0 && d.err == nil {
+		tokens := d.tokens(l.vocabulary)
+		docID := start + len(docs)
+		if d.err == nil && (docID >= len(l.docLengths) || len(tokens) != l.docLengths[docID]) {
+			d.fail("document length")
+		}
+		docs = append(docs, tokens)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if len(docs) != cap(docs) {
+		return nil, fmt.Errorf("%w: chunk at offset %d has %d documents, expected %d", ErrInvalidSnapshot, chunk.offset, len(docs), cap(docs))
+	}
+	return docs, nil
+}
+
+// sectionReaderAt reads sequentially from an io.ReaderAt, keeping track of the offset.
+type sectionReaderAt struct {
+	r   io.ReaderAt
+	off int64
+}
+
+func (s *sectionReaderAt) Read(p []byte) (int, error) {
+	n, err := s.r.ReadAt(p, s.off)
+	s.off += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+func (s *sectionReaderAt) ReadByte() (byte, error) {
+	var b [1]byte
+	if _, err := io.ReadFull(s, b[:]); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
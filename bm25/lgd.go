@@ -0,0 +1,235 @@
+package bm25
+
+import (
+	"context"
+	"log"
+	"math"
+)
+
+// LGD is an implementation of the log-logistic model of Clinchant and Gaussier, an
+// information-based model often compared with the BM25 family. The frequency of a term
+// in a document is normalized as tf log(1 + c avgdl / dl), and the score of the term is
+// log((tfn + λ) / λ), where λ is the fraction of the documents containing the term, so
+// rare terms and repeated terms both score higher, with a logarithmic saturation.
+type LGD struct {
+	*Bm25Base
+	c float64
+}
+
+// NewLGD creates a new instance of the LGD struct.
+func NewLGD(corpus []string, tokenizer func(string) []string, c float64, logger *log.Logger) (*LGD, error) {
+	if err := validateLGDParams(c); err != nil {
+		return nil, err
+	}
+
+	base, err := NewBM25Base(corpus, tokenizer, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLGDFromBase(base, c)
+}
+
+// NewLGDFromBase creates a new instance of the LGD struct on top of an existing Bm25Base.
+func NewLGDFromBase(base *Bm25Base, c float64) (*LGD, error) {
+	if base == nil {
+		return nil, ErrNilBase
+	}
+
+	if err := validateLGDParams(c); err != nil {
+		return nil, err
+	}
+
+	return &LGD{
+		Bm25Base: base,
+		c:        c,
+	}, nil
+}
+
+// LGDParamSpecs returns the specs of the parameters of the LGD variant.
+func LGDParamSpecs() []ParamSpec {
+	return []ParamSpec{
+		{
+			Name:        "c",
+			Default:     1,
+			Min:         0,
+			Max:         math.Inf(1),
+			Description: "Document length normalization of the term frequencies. Higher values normalize less.",
+		},
+	}
+}
+
+// validateLGDParams validates the parameters of the LGD variant.
+func validateLGDParams(c float64) error {
+	return validateSpecs(LGDParamSpecs(), c)
+}
+
+// Params returns the parameters of the index.
+func (l *LGD) Params() map[string]float64 {
+	return map[string]float64{
+		"c": l.c,
+	}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (l *LGD) ParamSpecs() []ParamSpec {
+	return LGDParamSpecs()
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params.
+func (l *LGD) withParams(params map[string]float64) (BM25, error) {
+	overridden, err := NewLGDFromBase(l.Bm25Base, params["c"])
+	if err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
+// termScore returns the score of a term occurring tf times in a document of the given
+// length, given the fraction lambda of the documents containing the term.
+func (l *LGD) termScore(tf float64, docLen int, lambda float64) float64 {
+	if tf == 0 || docLen == 0 {
+		return 0
+	}
+	tfn := tf * math.Log1p(l.c*l.avgDocLen/float64(docLen))
+	return math.Log((tfn + lambda) / lambda)
+}
+
+// lambda returns the fraction of the documents containing a term, or false if the term
+// is in no document.
+func (l *LGD) lambda(term string) (float64, bool) {
+	docFreq, _ := l.docFreq(term)
+	if docFreq == 0 {
+		return 0, false
+	}
+	return float64(docFreq) / float64(l.corpusSize), true
+}
+
+// GetScores returns the LGD scores for the given query.
+func (l *LGD) GetScores(query []string) ([]float64, error) {
+	return l.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (l *LGD) GetScoresInto(dst []float64, query []string) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	scores := l.scoreBuffer(dst)
+	for _, q := range query {
+		if l.isStopword(q) {
+			continue
+		}
+
+		lambda, ok := l.lambda(q)
+		if !ok {
+			l.logf(LogDebug, "Term '%s' is not in the corpus", q)
+			continue
+		}
+		weight := l.termWeight(q)
+
+		for i, docLen := range l.docLengths {
+			scores[i] += weight * l.termScore(l.termFrequency(i, q), docLen, lambda)
+		}
+	}
+
+	l.excludeExpired(scores, nil)
+	return scores, nil
+}
+
+// GetBatchScores returns the LGD scores for the given query and a subset of documents.
+func (l *LGD) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if err := l.validateDocIDs(docIDs); err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(docIDs))
+	for _, q := range query {
+		if l.isStopword(q) {
+			continue
+		}
+
+		lambda, ok := l.lambda(q)
+		if !ok {
+			l.logf(LogDebug, "Term '%s' is not in the corpus", q)
+			continue
+		}
+		weight := l.termWeight(q)
+
+		for i, docID := range docIDs {
+			scores[i] += weight * l.termScore(l.termFrequency(docID, q), l.docLengths[docID], lambda)
+		}
+	}
+
+	l.excludeExpired(scores, docIDs)
+	return scores, nil
+}
+
+// GetTopN returns the top N documents for the given query.
+func (l *LGD) GetTopN(query []string, n int) ([]string, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if n <= 0 {
+		l.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
+		return []string{}, nil
+	}
+
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := l.GetScoresInto(*buf, query)
+	if err != nil {
+		return nil, err
+	}
+	*buf = scores
+
+	topNIndices, err := TopNIndices(scores, n)
+	if err != nil {
+		return nil, err
+	}
+
+	topDocs := make([]string, len(topNIndices))
+	for i, idx := range topNIndices {
+		doc, err := l.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
+	}
+
+	return topDocs, nil
+}
+
+// Search runs the given search request against the index.
+func (l *LGD) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return l.search(ctx, l, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (l *LGD) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return l.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the LGD instance.
+func (l *LGD) Clone() *LGD {
+	clone := *l
+	clone.Bm25Base = l.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the LGD instance that is safe for concurrent use.
+func (l *LGD) Freeze() *LGD {
+	frozen := *l
+	frozen.Bm25Base = l.Bm25Base.Freeze()
+	return &frozen
+}
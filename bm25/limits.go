@@ -0,0 +1,196 @@
+package bm25
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrResourceLimit is returned when a search would exceed the limits of its request.
+var ErrResourceLimit = errors.New("search exceeds resource limit")
+
+// SearchLimits bounds the resources a single search may use, so one pathological query,
+// e.g. with a huge subword expansion or a giant candidate set, cannot starve other
+// searches of a shared server. Zero values leave a resource unbounded.
+type SearchLimits struct {
+	// MaxGoroutines is the number of goroutines scoring the query terms concurrently.
+	// Defaults to 1, which scores the terms one after another. Each goroutine holds the
+	// scores of its term for the whole corpus.
+	MaxGoroutines int
+
+	// MaxScoredDocs, if positive, keeps only that many of the documents matching the
+	// query, those with the highest scores, as the candidates to sort and return, and
+	// marks the response as truncated if there were more. Documents matching none of the
+	// query terms are then not returned.
+	MaxScoredDocs int
+
+	// MaxMemory, if positive, bounds the estimated size in bytes of the buffers of the
+	// search. MaxGoroutines is lowered to fit the bound; if the search does not fit with
+	// a single goroutine, it fails with ErrResourceLimit before any term is scored.
+	MaxMemory int64
+}
+
+// validate checks that the limits are non-negative.
+func (l SearchLimits) validate() error {
+	if l.MaxGoroutines < 0 {
+		return invalidParam("maxGoroutines", l.MaxGoroutines, "must be non-negative")
+	}
+	if l.MaxScoredDocs < 0 {
+		return invalidParam("maxScoredDocs", l.MaxScoredDocs, "must be non-negative")
+	}
+	if l.MaxMemory < 0 {
+		return invalidParam("maxMemory", l.MaxMemory, "must be non-negative")
+	}
+	return nil
+}
+
+// SetDefaultLimits sets the limits of the searches whose request leaves them at zero, e.g.
+// the MaxGoroutines chosen by Tune. Limits set in a request take precedence.
+func (b *Bm25Base) SetDefaultLimits(limits SearchLimits) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+	if err := limits.validate(); err != nil {
+		return err
+	}
+
+	b.limits = limits
+	return nil
+}
+
+// DefaultLimits returns the limits of the searches whose request leaves them at zero.
+func (b *Bm25Base) DefaultLimits() SearchLimits {
+	return b.limits
+}
+
+// withDefaults returns the limits with their zero values replaced by the defaults.
+func (l SearchLimits) withDefaults(defaults SearchLimits) SearchLimits {
+	if l.MaxGoroutines == 0 {
+		l.MaxGoroutines = defaults.MaxGoroutines
+	}
+	if l.MaxScoredDocs == 0 {
+		l.MaxScoredDocs = defaults.MaxScoredDocs
+	}
+	if l.MaxMemory == 0 {
+		l.MaxMemory = defaults.MaxMemory
+	}
+	return l
+}
+
+// searchWorkers returns the number of goroutines scoring the terms of a search, lowered
+// until the estimated memory of the search fits its limit.
+func (b *Bm25Base) searchWorkers(req SearchRequest) (int, error) {
+	workers := max(1, min(req.Limits.MaxGoroutines, len(req.Query)))
+	if req.Limits.MaxMemory == 0 {
+		return workers, nil
+	}
+
+	for ; workers > 0; workers-- {
+		if b.searchMemory(req, workers) <= req.Limits.MaxMemory {
+			return workers, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: an estimated %d bytes exceed the limit of %d bytes", ErrResourceLimit, b.searchMemory(req, 1), req.Limits.MaxMemory)
+}
+
+// searchMemory estimates the bytes held by the per-document buffers of a search with the
+// given number of goroutines: the accumulated scores and candidates, the scores and term
+// frequencies of the terms being scored, the term scores kept for explanations and the
+// hits of the coordination factor.
+func (b *Bm25Base) searchMemory(req SearchRequest, workers int) int64 {
+	perDoc := int64(b.corpusSize) * 8
+	memory := 2*perDoc + int64(workers)*2*perDoc
+	if req.Explain {
+		memory += int64(len(req.Query)) * perDoc
+	}
+	if req.Coord > 0 {
+		memory += perDoc + int64(len(req.Query))*int64(b.corpusSize)
+	}
+	if len(req.Ranges) > 0 {
+		memory += 2 * int64(b.corpusSize)
+	}
+	return memory
+}
+
+// scoresInto is implemented by the variants that can score into a caller-provided buffer.
+type scoresInto interface {
+	GetScoresInto(dst []float64, query []string) ([]float64, error)
+}
+
+// scoreTerms returns the scores of every term of the query separately, scoring them
+// concurrently if there are several. If buffers is not nil, the scores of the k-th term
+// are stored in buffers[k], if the variant supports it.
+func scoreTerms(bm25 BM25, terms []string, buffers []*[]float64) ([][]float64, error) {
+	scores := make([][]float64, len(terms))
+	errs := make([]error, len(terms))
+	score := func(k int) {
+		if into, ok := bm25.(scoresInto); ok && buffers != nil {
+			*buffers[k], errs[k] = into.GetScoresInto(*buffers[k], terms[k:k+1])
+			scores[k] = *buffers[k]
+			return
+		}
+		scores[k], errs[k] = bm25.GetScores(terms[k : k+1])
+	}
+
+	if len(terms) == 1 {
+		score(0)
+		return scores, errs[0]
+	}
+
+	var wg sync.WaitGroup
+	for k := range terms {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			score(k)
+		}()
+	}
+	wg.Wait()
+	return scores, errors.Join(errs...)
+}
+
+// candidateHeap collects the documents with the highest scores, up to a maximum number,
+// see SearchLimits.MaxScoredDocs. It is a min-heap, so the worst candidate is evicted
+// first; of equal scores, the higher document ID is evicted.
+type candidateHeap struct {
+	docIDs []int
+	scores []float64
+	max    int
+}
+
+func (h *candidateHeap) Len() int      { return len(h.docIDs) }
+func (h *candidateHeap) Swap(i, j int) { h.docIDs[i], h.docIDs[j] = h.docIDs[j], h.docIDs[i] }
+func (h *candidateHeap) Push(x any)    { h.docIDs = append(h.docIDs, x.(int)) }
+
+func (h *candidateHeap) Less(i, j int) bool {
+	return h.worse(h.docIDs[i], h.docIDs[j])
+}
+
+func (h *candidateHeap) Pop() any {
+	docID := h.docIDs[len(h.docIDs)-1]
+	h.docIDs = h.docIDs[:len(h.docIDs)-1]
+	return docID
+}
+
+// worse reports whether document a ranks below document b.
+func (h *candidateHeap) worse(a, b int) bool {
+	if h.scores[a] != h.scores[b] {
+		return h.scores[a] < h.scores[b]
+	}
+	return a > b
+}
+
+// add offers a document, and reports false if a document was evicted or rejected
+// because the heap is full.
+func (h *candidateHeap) add(docID int) bool {
+	if len(h.docIDs) < h.max {
+		heap.Push(h, docID)
+		return true
+	}
+	if h.worse(h.docIDs[0], docID) {
+		h.docIDs[0] = docID
+		heap.Fix(h, 0)
+	}
+	return false
+}
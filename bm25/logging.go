@@ -0,0 +1,73 @@
+package bm25
+
+import "sync/atomic"
+
+// LogLevel controls how much an index writes to its logger.
+type LogLevel int
+
+const (
+	// LogInfo logs the index lifecycle only: builds, compactions, optimizations and the
+	// like. It is the default, and is cheap enough for production.
+	LogInfo LogLevel = iota
+	// LogQueries additionally logs a summary line per search and misused query methods,
+	// e.g. calls to GetTopN with n <= 0.
+	LogQueries
+	// LogDebug additionally logs the details of every query term, e.g. every IDF computed,
+	// which adds several lines per term and query. Use LogOptions.SampleEvery to enable it
+	// on a busy index.
+	LogDebug
+)
+
+// LogOptions configures the logging of an index.
+type LogOptions struct {
+	Level LogLevel
+
+	// SampleEvery, if greater than 1, logs only one in every SampleEvery debug messages.
+	// Messages of the other levels are never sampled.
+	SampleEvery int
+}
+
+// logConfig holds the logging configuration of an index. It is shared by the clones of
+// the index, so the sampling counter is too.
+type logConfig struct {
+	opts    LogOptions
+	counter atomic.Uint64
+}
+
+// SetLogOptions sets the level and the sampling of the messages written to the logger of
+// the index. Without a logger, nothing is logged whatever the level.
+func (b *Bm25Base) SetLogOptions(opts LogOptions) error {
+	if opts.Level < LogInfo || opts.Level > LogDebug {
+		return invalidParam("level", opts.Level, "must be LogInfo, LogQueries or LogDebug")
+	}
+	if opts.SampleEvery < 0 {
+		return invalidParam("sampleEvery", opts.SampleEvery, "must be non-negative")
+	}
+
+	b.logging = &logConfig{opts: opts}
+	return nil
+}
+
+// logEnabled reports whether the messages of the given level are logged.
+func (b *Bm25Base) logEnabled(level LogLevel) bool {
+	if b.logger == nil {
+		return false
+	}
+	if b.logging == nil {
+		return level == LogInfo
+	}
+	return level <= b.logging.opts.Level
+}
+
+// logf logs a message of the given level, if enabled. Debug messages are sampled.
+func (b *Bm25Base) logf(level LogLevel, format string, args ...any) {
+	if !b.logEnabled(level) {
+		return
+	}
+	if level == LogDebug && b.logging.opts.SampleEvery > 1 {
+		if (b.logging.counter.Add(1)-1)%uint64(b.logging.opts.SampleEvery) != 0 {
+			return
+		}
+	}
+	b.logger.Printf(format, args...)
+}
@@ -0,0 +1,74 @@
+package bm25
+
+// Approximate sizes, in bytes, used to estimate the memory held by an index.
+const (
+	stringHeaderBytes = 16
+	sliceHeaderBytes  = 24
+	intBytes          = 8
+	float64Bytes      = 8
+	mapEntryBytes     = 16 // Per-entry overhead of a Go map (hash bits, buckets, load factor)
+)
+
+// MemStats holds an estimate of the memory used by an index, together with the counts
+// the estimate is derived from.
+type MemStats struct {
+	Docs     int
+	Terms    int
+	Postings int // Number of (term, document) pairs
+
+	PostingsBytes   int64
+	VocabularyBytes int64
+	DocStorageBytes int64
+	CacheBytes      int64
+	TotalBytes      int64
+}
+
+// MemStats returns an estimate of the memory used by the index. The figures are derived
+// from the sizes of the index structures rather than measured on the heap, so they are
+// meant for capacity planning rather than exact accounting.
+func (b *Bm25Base) MemStats() MemStats {
+	var stats MemStats
+	stats.Docs = b.corpusSize
+
+	// Term strings are interned, so their bytes are counted once, with the vocabulary
+	if b.termFreqs != nil {
+		for term, termFreq := range b.termFreqs {
+			stats.Terms++
+			stats.Postings += termFreq
+			stats.VocabularyBytes += int64(len(term)) + stringHeaderBytes + intBytes + mapEntryBytes
+		}
+	}
+	if b.termDict != nil {
+		if b.termFreqs == nil {
+			stats.Terms = b.termDict.Len()
+			for i := 0; i < b.termDict.Len(); i++ {
+				stats.Postings += b.termDict.DocFreq(i)
+			}
+		}
+		stats.VocabularyBytes += int64(len(b.termDict.data)) + int64(len(b.termDict.offsets)+len(b.termDict.freqs))*4
+	}
+
+	// Scoring scans the stored document tokens; posting lists are only materialized for
+	// Retrieve
+	if b.impacts != nil {
+		for _, postings := range b.impacts.postings {
+			stats.PostingsBytes += sliceHeaderBytes + int64(len(postings))*(intBytes+4)
+		}
+	}
+	for _, doc := range b.corpus {
+		stats.DocStorageBytes += sliceHeaderBytes + int64(len(doc))*stringHeaderBytes
+	}
+	stats.DocStorageBytes += int64(len(b.docLengths)) * intBytes
+	for _, id := range b.externalIDs {
+		stats.DocStorageBytes += int64(len(id)) + stringHeaderBytes + intBytes + mapEntryBytes
+	}
+	for _, dv := range b.docValues {
+		stats.DocStorageBytes += int64(dv.Len()) * (intBytes + float64Bytes)
+	}
+
+	stats.CacheBytes = int64(len(b.idfCache)+len(b.collFreqs)) * (stringHeaderBytes + float64Bytes + mapEntryBytes)
+	stats.CacheBytes += int64(len(b.tfidfNorms)) * float64Bytes
+
+	stats.TotalBytes = stats.PostingsBytes + stats.VocabularyBytes + stats.DocStorageBytes + stats.CacheBytes
+	return stats
+}
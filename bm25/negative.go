@@ -0,0 +1,50 @@
+package bm25
+
+import "math"
+
+// defaultNegativeBoost is the negative boost applied to the documents containing a
+// negative term if the request sets none.
+const defaultNegativeBoost = 0.5
+
+// validateNegativeBoost checks the NegativeBoost of a SearchRequest.
+func validateNegativeBoost(boost *float64) error {
+	if boost == nil {
+		return nil
+	}
+	if *boost < 0 || *boost >= 1 || math.IsNaN(*boost) {
+		return invalidParam("negativeBoost", *boost, "must be at least 0 and less than 1")
+	}
+	return nil
+}
+
+// demoteScore returns a score lowered by the negative boost: positive scores are
+// multiplied by it, and negative scores lowered by the same fraction of their magnitude,
+// so demoted documents always move down.
+func demoteScore(score float64, boost float64) float64 {
+	return score - (1-boost)*math.Abs(score)
+}
+
+// demote lowers the scores of the documents matching the query that contain any of the
+// negative terms by the negative boost, see demoteScore, and returns the negative boost
+// the documents were demoted by, or nil if there are no negative terms.
+func (b *Bm25Base) demote(scores []float64, matched *Bitmap, terms []string, boost *float64) map[int]float64 {
+	if len(terms) == 0 {
+		return nil
+	}
+	factor := defaultNegativeBoost
+	if boost != nil {
+		factor = *boost
+	}
+
+	demotions := make(map[int]float64)
+	for _, docID := range matched.AppendDocIDs(nil) {
+		for _, term := range terms {
+			if b.HasTerm(docID, term) {
+				demotions[docID] = factor
+				scores[docID] = demoteScore(scores[docID], factor)
+				break
+			}
+		}
+	}
+	return demotions
+}
@@ -0,0 +1,185 @@
+// Package objstore persists index snapshots in object storage, so stateless search pods
+// can pull the latest index at boot instead of building it. Snapshots are written under
+// a prefix together with a manifest pointing to the latest one, which records its size
+// and SHA-256 checksum; loading verifies both before the index is returned.
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+var (
+	// ErrNotFound is returned for objects that do not exist.
+	ErrNotFound = errors.New("object not found")
+
+	// ErrChecksumMismatch is returned when a downloaded snapshot does not match its manifest.
+	ErrChecksumMismatch = errors.New("snapshot checksum mismatch")
+)
+
+// Bucket is an object storage bucket. Put must not make an object visible until it has
+// been written completely.
+type Bucket interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// DirBucket is a Bucket in a local directory, e.g. for development or a shared volume.
+type DirBucket string
+
+// Put writes an object to a temporary file and renames it into place.
+func (d DirBucket) Put(ctx context.Context, key string, r io.Reader) error {
+	path := filepath.Join(string(d), filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Get opens an object.
+func (d DirBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(string(d), filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return f, err
+}
+
+// Manifest describes a snapshot.
+type Manifest struct {
+	Key       string    `json:"key"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// latestKey is the key of the manifest of the latest snapshot, relative to the prefix.
+const latestKey = "LATEST"
+
+// Snapshots stores index snapshots in a bucket, under the given prefix, e.g. "search/".
+// Options configures how snapshots are written and read, e.g. to encrypt them.
+type Snapshots struct {
+	Bucket  Bucket
+	Prefix  string
+	Options bm25.SnapshotOptions
+}
+
+// Save uploads a snapshot of the index, streaming it into the bucket, and then makes it
+// the latest snapshot. Older snapshots are kept.
+func (s *Snapshots) Save(ctx context.Context, base *bm25.Bm25Base) (Manifest, error) {
+	if base == nil {
+		return Manifest{}, bm25.ErrNilBase
+	}
+
+	now := time.Now().UTC()
+	m := Manifest{Key: s.Prefix + "snapshots/" + now.Format("20060102T150405.000000000Z") + ".snap", CreatedAt: now}
+
+	pr, pw := io.Pipe()
+	counter := &hashingWriter{hash: sha256.New()}
+	go func() {
+		pw.CloseWithError(base.WriteSnapshotWithOptions(io.MultiWriter(pw, counter), s.Options))
+	}()
+	if err := s.Bucket.Put(ctx, m.Key, pr); err != nil {
+		pr.CloseWithError(err)
+		return Manifest{}, fmt.Errorf("uploading snapshot: %w", err)
+	}
+	m.Size, m.SHA256 = counter.n, hex.EncodeToString(counter.hash.Sum(nil))
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if err := s.Bucket.Put(ctx, s.Prefix+latestKey, bytes.NewReader(data)); err != nil {
+		return Manifest{}, fmt.Errorf("uploading manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Latest returns the manifest of the latest snapshot. It returns ErrNotFound if no
+// snapshot was saved yet.
+func (s *Snapshots) Latest(ctx context.Context) (Manifest, error) {
+	r, err := s.Bucket.Get(ctx, s.Prefix+latestKey)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer r.Close()
+
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return Manifest{}, fmt.Errorf("reading manifest: %w", err)
+	}
+	return m, nil
+}
+
+// LoadLatest downloads and restores the latest snapshot.
+func (s *Snapshots) LoadLatest(ctx context.Context, tokenizer func(string) []string, logger *log.Logger) (*bm25.Bm25Base, Manifest, error) {
+	m, err := s.Latest(ctx)
+	if err != nil {
+		return nil, Manifest{}, err
+	}
+	base, err := s.Load(ctx, m, tokenizer, logger)
+	return base, m, err
+}
+
+// Load downloads and restores a snapshot, verifying its size and checksum.
+func (s *Snapshots) Load(ctx context.Context, m Manifest, tokenizer func(string) []string, logger *log.Logger) (*bm25.Bm25Base, error) {
+	r, err := s.Bucket.Get(ctx, m.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	counter := &hashingWriter{hash: sha256.New()}
+	tee := io.TeeReader(r, counter)
+	base, err := bm25.ReadSnapshotWithOptions(tee, tokenizer, logger, s.Options)
+	// Read to the end, so the checksum covers the whole object
+	if _, copyErr := io.Copy(io.Discard, tee); copyErr != nil {
+		return nil, fmt.Errorf("downloading snapshot: %w", copyErr)
+	}
+
+	if counter.n != m.Size || hex.EncodeToString(counter.hash.Sum(nil)) != m.SHA256 {
+		return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, m.Key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return base, nil
+}
+
+// hashingWriter hashes and counts the bytes written to it.
+type hashingWriter struct {
+	hash hash.Hash
+	n    int64
+}
+
+func (w *hashingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return w.hash.Write(p)
+}
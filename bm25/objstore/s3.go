@@ -0,0 +1,325 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Part sizes of multipart uploads. S3 requires all parts but the last to be at least 5 MiB.
+const (
+	DefaultPartSize = 16 << 20
+	MinPartSize     = 5 << 20
+)
+
+// maxErrorBody is the number of bytes of an error response included in the error.
+const maxErrorBody = 512
+
+// S3Bucket is a bucket of S3 or an S3-compatible object storage, e.g. MinIO or Ceph,
+// addressed with path-style URLs. Requests are signed with AWS Signature Version 4.
+// Objects larger than a part are uploaded in parts, each checked by the server against
+// its Content-MD5, so a corrupted upload is rejected rather than stored.
+type S3Bucket struct {
+	// Endpoint is the URL of the storage, e.g. https://s3.eu-central-1.amazonaws.com or
+	// http://localhost:9000.
+	Endpoint string
+
+	// Region is the region the requests are signed for, e.g. eu-central-1.
+	Region string
+
+	// Bucket is the name of the bucket.
+	Bucket string
+
+	// AccessKeyID, SecretAccessKey and, for temporary credentials, SessionToken sign the
+	// requests.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// PartSize is the size of the parts of multipart uploads. Defaults to
+	// DefaultPartSize; smaller values are raised to MinPartSize.
+	PartSize int
+
+	// HTTPClient sends the requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Put uploads an object, in parts if it is larger than a part. A failed multipart upload
+// is aborted, so its parts do not linger in the bucket.
+func (s *S3Bucket) Put(ctx context.Context, key string, r io.Reader) error {
+	partSize := s.PartSize
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	partSize = max(partSize, MinPartSize)
+
+	first, err := readPart(r, partSize)
+	if err != nil {
+		return err
+	}
+	if len(first) < partSize {
+		_, err := s.do(ctx, http.MethodPut, key, nil, first, http.StatusOK)
+		return err
+	}
+
+	body, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(body, &initiated); err != nil || initiated.UploadID == "" {
+		return fmt.Errorf("initiating upload of %s: invalid response %q", key, truncate(body))
+	}
+
+	if err := s.uploadParts(ctx, key, initiated.UploadID, first, r, partSize); err != nil {
+		abort := url.Values{"uploadId": {initiated.UploadID}}
+		_, _ = s.do(context.WithoutCancel(ctx), http.MethodDelete, key, abort, nil, http.StatusNoContent)
+		return err
+	}
+	return nil
+}
+
+// completedPart is a part listed in the request completing a multipart upload.
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// uploadParts uploads the parts of a multipart upload, starting with the part already
+// read, and completes the upload.
+func (s *S3Bucket) uploadParts(ctx context.Context, key, uploadID string, part []byte, r io.Reader, partSize int) error {
+	var parts []completedPart
+	for number := 1; len(part) > 0; number++ {
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		etag, err := s.doPart(ctx, key, query, part)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, completedPart{PartNumber: number, ETag: etag})
+
+		if len(part) < partSize {
+			break
+		}
+		if part, err = readPart(r, partSize); err != nil {
+			return err
+		}
+	}
+
+	complete, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	body, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, complete, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	// Completing an upload can fail after the response status was sent
+	if bytes.Contains(body, []byte("<Error>")) {
+		return fmt.Errorf("completing upload of %s: %s", key, truncate(body))
+	}
+	return nil
+}
+
+// doPart uploads a part and returns its ETag.
+func (s *S3Bucket) doPart(ctx context.Context, key string, query url.Values, part []byte) (string, error) {
+	resp, err := s.send(ctx, http.MethodPut, key, query, part)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// Get downloads an object. It returns ErrNotFound if the object does not exist.
+func (s *S3Bucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.send(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// do sends a request and returns the body of the response, which must have the expected status.
+func (s *S3Bucket) do(ctx context.Context, method, key string, query url.Values, payload []byte, status int) ([]byte, error) {
+	resp, err := s.send(ctx, method, key, query, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp, status); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// send sends a signed request for an object.
+func (s *S3Bucket) send(ctx context.Context, method, key string, query url.Values, payload []byte) (*http.Response, error) {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", s.Endpoint, err)
+	}
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" + s.Bucket + "/" + key
+	endpoint.RawPath = escapePath(endpoint.Path)
+	endpoint.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil && method == http.MethodPut {
+		sum := md5.Sum(payload)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	s.sign(req, payload, time.Now().UTC())
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// sign adds the AWS Signature Version 4 headers to a request.
+func (s *S3Bucket) sign(req *http.Request, payload []byte, now time.Time) {
+	payloadHash := sha256.Sum256(payload)
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	fmt.Fprintf(&canonical, "%s\n%s\n%s\n", req.Method, req.URL.EscapedPath(), req.URL.RawQuery)
+	for _, name := range names {
+		fmt.Fprintf(&canonical, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+	fmt.Fprintf(&canonical, "\n%s\n%s", signedHeaders, hex.EncodeToString(payloadHash[:]))
+
+	date := amzDate[:8]
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical.String()))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	for _, part := range []string{s.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapePath escapes a path as required by Signature Version 4: every byte except
+// unreserved characters and slashes is percent-encoded.
+func escapePath(path string) string {
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c == '/' || isUnreserved(c) {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// canonicalQuery encodes a query string sorted by key, with every parameter in key=value
+// form, as required by Signature Version 4.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var params []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			params = append(params, escapePath(key)+"="+strings.ReplaceAll(escapePath(value), "/", "%2F"))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~'
+}
+
+// readPart reads up to partSize bytes. It only returns fewer at the end of the reader.
+func readPart(r io.Reader, partSize int) ([]byte, error) {
+	part := make([]byte, partSize)
+	n, err := io.ReadFull(r, part)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return part[:n], nil
+}
+
+// checkStatus returns an error for a response without the expected status.
+func checkStatus(resp *http.Response, status int) error {
+	if resp.StatusCode == status || status == http.StatusNoContent && resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return fmt.Errorf("%s %s: %s: %s", resp.Request.Method, resp.Request.URL.Redacted(), resp.Status, bytes.TrimSpace(msg))
+}
+
+// truncate shortens a response body for an error message.
+func truncate(body []byte) string {
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	return string(bytes.TrimSpace(body))
+}
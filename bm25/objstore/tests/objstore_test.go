@@ -0,0 +1,225 @@
+package objstore_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/objstore"
+)
+
+// fakeS3 is a minimal S3 server supporting plain and multipart uploads.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+	parts   int
+	aborted int
+	failAt  int // Part number to reject, or 0
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: make(map[string][]byte), uploads: make(map[string]map[int][]byte)}
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		http.Error(w, "missing signature", http.StatusForbidden)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	if md5Header := r.Header.Get("Content-MD5"); md5Header != "" {
+		sum := md5.Sum(body)
+		if md5Header != base64.StdEncoding.EncodeToString(sum[:]) {
+			http.Error(w, "BadDigest", http.StatusBadRequest)
+			return
+		}
+	}
+
+	key := r.URL.Path
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet:
+		data, ok := s.objects[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		id := fmt.Sprintf("upload-%d", len(s.uploads))
+		s.uploads[id] = make(map[int][]byte)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		var number int
+		fmt.Sscan(query.Get("partNumber"), &number)
+		if number == s.failAt {
+			http.Error(w, "InternalError", http.StatusInternalServerError)
+			return
+		}
+		s.uploads[query.Get("uploadId")][number] = body
+		s.parts++
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, number))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &complete); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		parts := s.uploads[query.Get("uploadId")]
+		var data []byte
+		for _, part := range complete.Parts {
+			data = append(data, parts[part.PartNumber]...)
+		}
+		s.objects[key] = data
+		delete(s.uploads, query.Get("uploadId"))
+		io.WriteString(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(s.uploads, query.Get("uploadId"))
+		s.aborted++
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		s.objects[key] = body
+	default:
+		http.Error(w, "unsupported", http.StatusBadRequest)
+	}
+}
+
+func newBucket(server *httptest.Server) *objstore.S3Bucket {
+	return &objstore.S3Bucket{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          "indexes",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		PartSize:        objstore.MinPartSize,
+	}
+}
+
+func TestS3Bucket(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	bucket := newBucket(server)
+	ctx := context.Background()
+
+	// Test case: Small objects are uploaded in a single request
+	if err := bucket.Put(ctx, "small object", strings.NewReader("hello")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data := fake.objects["/indexes/small object"]; string(data) != "hello" {
+		t.Errorf("Expected the object to be stored, but got %q", data)
+	}
+
+	// Test case: Large objects are uploaded in parts
+	large := bytes.Repeat([]byte("0123456789"), objstore.MinPartSize/4)
+	if err := bucket.Put(ctx, "dir/large", bytes.NewReader(large)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fake.parts != 3 {
+		t.Errorf("Expected 3 parts, but got %d", fake.parts)
+	}
+	r, err := bucket.Get(ctx, "dir/large")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if !bytes.Equal(data, large) {
+		t.Errorf("Expected the downloaded object to match the upload")
+	}
+
+	// Test case: Failed uploads are aborted
+	fake.failAt = 2
+	if err := bucket.Put(ctx, "failed", bytes.NewReader(large)); err == nil {
+		t.Errorf("Expected an error for a failed part, but got nil")
+	}
+	if fake.aborted != 1 || len(fake.uploads) != 0 {
+		t.Errorf("Expected the upload to be aborted, but got %d aborts", fake.aborted)
+	}
+
+	// Test case: Missing objects
+	if _, err := bucket.Get(ctx, "missing"); !errors.Is(err, objstore.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, but got %v", err)
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	corpus := []string{"hello world", "hello there", "goodbye world"}
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0.75, nil)
+	ctx := context.Background()
+
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	buckets := map[string]objstore.Bucket{
+		"s3":  newBucket(server),
+		"dir": objstore.DirBucket(t.TempDir()),
+	}
+	names := make([]string, 0, len(buckets))
+	for name := range buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		snapshots := &objstore.Snapshots{Bucket: buckets[name], Prefix: "search/"}
+
+		// Test case: Loading before a snapshot was saved
+		if _, _, err := snapshots.LoadLatest(ctx, tokenizer, nil); !errors.Is(err, objstore.ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound, but got %v", name, err)
+		}
+
+		// Test case: Saving and loading the latest snapshot
+		saved, err := snapshots.Save(ctx, okapi.Bm25Base)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if !strings.HasPrefix(saved.Key, "search/snapshots/") || saved.Size == 0 || len(saved.SHA256) != 64 {
+			t.Errorf("%s: unexpected manifest %+v", name, saved)
+		}
+		base, m, err := snapshots.LoadLatest(ctx, tokenizer, nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if m.Key != saved.Key {
+			t.Errorf("%s: expected the latest snapshot %s, but got %s", name, saved.Key, m.Key)
+		}
+		restored, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+		expected, _ := okapi.GetScores([]string{"hello"})
+		scores, _ := restored.GetScores([]string{"hello"})
+		if !reflect.DeepEqual(scores, expected) {
+			t.Errorf("%s: expected scores %v, but got %v", name, expected, scores)
+		}
+
+		// Test case: Corrupted snapshots are rejected
+		corrupted := saved
+		corrupted.SHA256 = strings.Repeat("0", 64)
+		if _, err := snapshots.Load(ctx, corrupted, tokenizer, nil); !errors.Is(err, objstore.ErrChecksumMismatch) {
+			t.Errorf("%s: expected ErrChecksumMismatch, but got %v", name, err)
+		}
+	}
+}
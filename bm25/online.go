@@ -0,0 +1,105 @@
+package bm25
+
+import (
+	"context"
+	"sync"
+)
+
+// documentAdder is implemented by the BM25 variants that support adding documents.
+type documentAdder interface {
+	BM25
+	AddDocument(doc Document) (int, error)
+	baseIndex() *Bm25Base
+}
+
+// baseIndex returns the Bm25Base itself. It is promoted to every variant embedding it.
+func (b *Bm25Base) baseIndex() *Bm25Base {
+	return b
+}
+
+// OnlineIndex is an index whose corpus statistics update continuously as documents
+// stream in, for append-only logs and feeds. Documents can be added while searches are
+// running; every search sees the statistics of all documents added before it started.
+// It is safe for concurrent use.
+//
+// The IDF cache of the wrapped index is bypassed, as its entries would be invalidated by
+// every added document, and concurrent searches must not write to it.
+type OnlineIndex struct {
+	mu    sync.RWMutex
+	index documentAdder
+}
+
+// NewOnlineIndex wraps a BM25 variant in an OnlineIndex. The index must not be used
+// directly anymore afterwards.
+func NewOnlineIndex(index BM25) (*OnlineIndex, error) {
+	adder, ok := index.(documentAdder)
+	if !ok {
+		return nil, ErrNotImplemented
+	}
+
+	base := adder.baseIndex()
+	if base.frozen {
+		return nil, ErrFrozen
+	}
+	base.shared = true
+	clear(base.idfCache)
+	clear(base.collFreqs)
+
+	return &OnlineIndex{index: adder}, nil
+}
+
+// Add adds a document to the index and returns its internal ID.
+func (o *OnlineIndex) Add(doc Document) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.index.AddDocument(doc)
+}
+
+// Search runs the given search request against the current state of the index.
+func (o *OnlineIndex) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.index.Search(ctx, req)
+}
+
+// GetScores returns the scores of all documents for the given query.
+func (o *OnlineIndex) GetScores(query []string) ([]float64, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.index.GetScores(query)
+}
+
+// GetTopN returns the top N documents for the given query.
+func (o *OnlineIndex) GetTopN(query []string, n int) ([]string, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.index.GetTopN(query, n)
+}
+
+// IDF returns the current IDF of the term.
+func (o *OnlineIndex) IDF(term string) (float64, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.index.IDF(term)
+}
+
+// CorpusSize returns the current number of documents.
+func (o *OnlineIndex) CorpusSize() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.index.CorpusSize()
+}
+
+// AvgDocLen returns the current average document length.
+func (o *OnlineIndex) AvgDocLen() float64 {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.index.AvgDocLen()
+}
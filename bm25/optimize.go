@@ -0,0 +1,507 @@
+package bm25
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrIndexClosed is returned when a SegmentedIndex is used after Close.
+var ErrIndexClosed = errors.New("index is closed")
+
+// Optimize prepares the index for read-heavy use after a batch of writes: it reads the
+// remaining documents of an index opened with OpenSnapshot, removes the expired
+// documents like Compact, and compacts the vocabulary like CompactVocabulary. A Bm25Base
+// keeps a single corpus, so there is nothing to merge; a SegmentedIndex merges its
+// segments with its own Optimize. Documents can still be added afterwards. It returns the
+// number of removed documents.
+func (b *Bm25Base) Optimize() (int, error) {
+	if b.frozen {
+		return 0, ErrFrozen
+	}
+
+	if err := b.Preload(); err != nil {
+		return 0, err
+	}
+	removed, err := b.Compact()
+	if err != nil {
+		return 0, err
+	}
+	if err := b.CompactVocabulary(); err != nil {
+		return removed, err
+	}
+
+	if b.logger != nil {
+		b.logger.Printf("Optimized index, corpus size: %d, vocabulary size: %d", b.corpusSize, b.termDict.Len())
+	}
+	return removed, nil
+}
+
+// MergePolicy configures the background merges of a SegmentedIndex.
+type MergePolicy struct {
+	// SegmentsPerTier is the number of adjacent segments of the same tier that are merged
+	// into one. The tier of a segment of n documents is the floor of the logarithm of n to
+	// the base SegmentsPerTier, so small segments are merged often and large ones rarely.
+	// Defaults to 10.
+	SegmentsPerTier int
+
+	// MaxConcurrentMerges limits the background merges running at the same time.
+	// Defaults to 1.
+	MaxConcurrentMerges int
+
+	// MaxMergeBytesPerSec throttles the background merges, which reindex the text of the
+	// merged documents, to this many bytes per second in total, so they leave resources to
+	// searches and writes. 0 disables throttling.
+	MaxMergeBytesPerSec float64
+
+	// Interval is how often the merger looks for segments to merge. Defaults to 1 second.
+	Interval time.Duration
+}
+
+// segment is an immutable part of a SegmentedIndex.
+type segment struct {
+	docs    []Document
+	index   BM25
+	base    *Bm25Base
+	merging bool
+}
+
+// SegmentedIndex indexes every batch of added documents as a new, immutable segment, so
+// adding documents never rewrites the documents already indexed. A background merger
+// merges adjacent segments of similar size by a tiered policy, and Optimize merges all of
+// them into one. It is safe for concurrent use.
+//
+// Document IDs number the documents in the order they were added, and stay the same when
+// segments are merged. Every segment computes its own collection statistics, like the
+// shards of a ShardRouter, so scores only match those of a single index over all the
+// documents once the segments have been merged into one; Optimize does that for
+// read-heavy deployments. Expired documents are excluded from scoring, but keep their
+// place in their segment.
+type SegmentedIndex struct {
+	mu       sync.RWMutex
+	merged   *sync.Cond // Signaled when a merge finishes
+	segments []*segment
+	ids      map[string]struct{}
+	running  int
+
+	variant   *registeredVariant
+	params    map[string]float64
+	tokenizer func(string) []string
+	logger    *log.Logger
+	policy    MergePolicy
+	throttle  *mergeThrottle
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSegmentedIndex creates an empty SegmentedIndex of the registered variant with the
+// given name, e.g. "bm25plus", and starts its background merger. Parameters missing from
+// params keep their default values. Close stops the merger.
+func NewSegmentedIndex(variant string, tokenizer func(string) []string, params map[string]float64, policy MergePolicy, logger *log.Logger) (*SegmentedIndex, error) {
+	v, err := lookupVariant(variant)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateParams(v.specs, params); err != nil {
+		return nil, err
+	}
+	if tokenizer == nil {
+		return nil, ErrNilTokenizer
+	}
+
+	if policy.SegmentsPerTier == 0 {
+		policy.SegmentsPerTier = 10
+	}
+	if policy.SegmentsPerTier < 2 {
+		return nil, invalidParam("segmentsPerTier", policy.SegmentsPerTier, "must be at least 2")
+	}
+	if policy.MaxConcurrentMerges == 0 {
+		policy.MaxConcurrentMerges = 1
+	}
+	if policy.MaxConcurrentMerges < 0 {
+		return nil, invalidParam("maxConcurrentMerges", policy.MaxConcurrentMerges, "must be positive")
+	}
+	if policy.MaxMergeBytesPerSec < 0 || math.IsNaN(policy.MaxMergeBytesPerSec) {
+		return nil, invalidParam("maxMergeBytesPerSec", policy.MaxMergeBytesPerSec, "must be non-negative")
+	}
+	if policy.Interval == 0 {
+		policy.Interval = time.Second
+	}
+	if policy.Interval < 0 {
+		return nil, invalidParam("interval", policy.Interval, "must be positive")
+	}
+
+	s := &SegmentedIndex{
+		ids:       make(map[string]struct{}),
+		variant:   v,
+		params:    ParamsWithDefaults(v.specs, params),
+		tokenizer: tokenizer,
+		logger:    logger,
+		policy:    policy,
+	}
+	if policy.MaxMergeBytesPerSec > 0 {
+		s.throttle = &mergeThrottle{rate: policy.MaxMergeBytesPerSec}
+	}
+	s.merged = sync.NewCond(&s.mu)
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	s.wg.Add(1)
+	go s.runMerger()
+	return s, nil
+}
+
+// Add indexes a batch of documents as a new segment. It returns their document IDs.
+// The batch is added atomically: if a document cannot be indexed, none of them is.
+func (s *SegmentedIndex) Add(docs ...Document) ([]int, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	seg, err := s.buildSegment(context.Background(), docs, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx.Err() != nil {
+		return nil, ErrIndexClosed
+	}
+	for _, doc := range docs {
+		if _, ok := s.ids[doc.ID]; ok && doc.ID != "" {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateID, doc.ID)
+		}
+	}
+	for _, doc := range docs {
+		if doc.ID != "" {
+			s.ids[doc.ID] = struct{}{}
+		}
+	}
+
+	first := s.corpusSize()
+	s.segments = append(s.segments, seg)
+	docIDs := make([]int, len(docs))
+	for i := range docIDs {
+		docIDs[i] = first + i
+	}
+	return docIDs, nil
+}
+
+// Search runs the request against every segment and returns the top req.N results by
+// score. A Filter sees the document IDs of the SegmentedIndex. As every segment ranks its
+// documents on its own, results cannot be sorted by document values and their scores are
+// not normalized.
+func (s *SegmentedIndex) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	start := time.Now()
+	if req.N <= 0 {
+		return nil, invalidParam("n", req.N, "must be a positive integer")
+	}
+	if len(req.Sort) > 0 {
+		return nil, invalidParam("sort", req.Sort, "is not supported by a SegmentedIndex")
+	}
+	req.Normalization = NormalizeNone
+
+	s.mu.RLock()
+	segments := append([]*segment(nil), s.segments...)
+	s.mu.RUnlock()
+
+	resp := &SearchResponse{}
+	unknown := make(map[string]int)
+	offset := 0
+	for _, seg := range segments {
+		segReq := req
+		if req.Filter != nil {
+			first := offset
+			segReq.Filter = func(docID int) bool { return req.Filter(first + docID) }
+		}
+		segResp, err := seg.index.Search(ctx, segReq)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range segResp.Results {
+			result.DocID += offset
+			resp.Results = append(resp.Results, result)
+		}
+		resp.Truncated = resp.Truncated || segResp.Truncated
+		for _, term := range segResp.UnknownTerms {
+			unknown[term]++
+		}
+		offset += seg.base.CorpusSize()
+	}
+
+	// A term is only unknown if no segment knows it
+	for term, n := range unknown {
+		if n == len(segments) {
+			resp.UnknownTerms = append(resp.UnknownTerms, term)
+		}
+	}
+	sort.Strings(resp.UnknownTerms)
+
+	sort.Slice(resp.Results, func(i, j int) bool {
+		if resp.Results[i].Score != resp.Results[j].Score {
+			return resp.Results[i].Score > resp.Results[j].Score
+		}
+		return resp.Results[i].DocID < resp.Results[j].DocID
+	})
+	if len(resp.Results) > req.N {
+		resp.Results = resp.Results[:req.N]
+	}
+	resp.Took = time.Since(start)
+	return resp, nil
+}
+
+// CorpusSize returns the number of documents in all segments.
+func (s *SegmentedIndex) CorpusSize() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.corpusSize()
+}
+
+// corpusSize returns the number of documents in all segments. The caller must hold the
+// lock.
+func (s *SegmentedIndex) corpusSize() int {
+	size := 0
+	for _, seg := range s.segments {
+		size += seg.base.CorpusSize()
+	}
+	return size
+}
+
+// SegmentSizes returns the number of documents of every segment, in document ID order.
+func (s *SegmentedIndex) SegmentSizes() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sizes := make([]int, len(s.segments))
+	for i, seg := range s.segments {
+		sizes[i] = seg.base.CorpusSize()
+	}
+	return sizes
+}
+
+// Optimize merges all segments into one, for read-heavy deployments. It waits for the
+// running background merges first, and is not throttled. Documents added while it runs
+// are left in segments of their own.
+func (s *SegmentedIndex) Optimize(ctx context.Context) error {
+	s.mu.Lock()
+	for s.running > 0 && s.ctx.Err() == nil {
+		s.merged.Wait()
+	}
+	if s.ctx.Err() != nil {
+		s.mu.Unlock()
+		return ErrIndexClosed
+	}
+	if len(s.segments) < 2 {
+		s.mu.Unlock()
+		return nil
+	}
+	run := append([]*segment(nil), s.segments...)
+	s.startMerge(run)
+	s.mu.Unlock()
+
+	return s.merge(ctx, run, false)
+}
+
+// Close stops the background merger and waits for the running merges, which are
+// abandoned. The index cannot be used anymore afterwards.
+func (s *SegmentedIndex) Close() error {
+	s.cancel()
+	s.mu.Lock()
+	s.merged.Broadcast()
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+// runMerger starts merges by the merge policy until the index is closed.
+func (s *SegmentedIndex) runMerger() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		for s.running < s.policy.MaxConcurrentMerges {
+			run := s.pickMerge()
+			if run == nil {
+				break
+			}
+			s.startMerge(run)
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				if err := s.merge(s.ctx, run, true); err != nil && s.logger != nil && s.ctx.Err() == nil {
+					s.logger.Printf("Error merging %d segments: %v", len(run), err)
+				}
+			}()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// pickMerge returns the first run of SegmentsPerTier adjacent segments of the same tier
+// that are not being merged, or nil if there is none. The caller must hold the lock.
+func (s *SegmentedIndex) pickMerge() []*segment {
+	n := s.policy.SegmentsPerTier
+	for i := 0; i+n <= len(s.segments); i++ {
+		tier := s.tier(s.segments[i])
+		j := i
+		for j < i+n && !s.segments[j].merging && s.tier(s.segments[j]) == tier {
+			j++
+		}
+		if j == i+n {
+			return append([]*segment(nil), s.segments[i:j]...)
+		}
+	}
+	return nil
+}
+
+// tier returns the tier of a segment by its number of documents.
+func (s *SegmentedIndex) tier(seg *segment) int {
+	return int(math.Log(float64(seg.base.CorpusSize())) / math.Log(float64(s.policy.SegmentsPerTier)))
+}
+
+// startMerge marks the segments of a run as being merged. The caller must hold the lock.
+func (s *SegmentedIndex) startMerge(run []*segment) {
+	for _, seg := range run {
+		seg.merging = true
+	}
+	s.running++
+}
+
+// merge merges a run of adjacent segments into one and replaces them with it. The run
+// must have been marked with startMerge.
+func (s *SegmentedIndex) merge(ctx context.Context, run []*segment, throttled bool) error {
+	var docs []Document
+	var expiresAt []time.Time
+	for _, seg := range run {
+		for docID := range seg.base.CorpusSize() {
+			if docID < len(seg.base.expiresAt) && !seg.base.expiresAt[docID].IsZero() {
+				expiresAt = growTo(expiresAt, len(docs))
+				expiresAt[len(docs)] = seg.base.expiresAt[docID]
+			}
+			docs = append(docs, seg.docs[docID])
+		}
+	}
+
+	throttle := s.throttle
+	if !throttled {
+		throttle = nil
+	}
+	merged, err := s.buildSegment(ctx, docs, expiresAt, throttle)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.merged.Broadcast()
+	s.running--
+	for _, seg := range run {
+		seg.merging = false
+	}
+	if err != nil {
+		return err
+	}
+
+	first := -1
+	for i, seg := range s.segments {
+		if seg == run[0] {
+			first = i
+			break
+		}
+	}
+	segments := append(append(append([]*segment(nil), s.segments[:first]...), merged), s.segments[first+len(run):]...)
+	s.segments = segments
+
+	if s.logger != nil {
+		s.logger.Printf("Merged %d segments into one of %d documents, segments: %d", len(run), len(docs), len(s.segments))
+	}
+	return nil
+}
+
+// buildSegment indexes documents as a segment. The expiry times of documents that have
+// been indexed before are kept, rather than starting their TTL over.
+func (s *SegmentedIndex) buildSegment(ctx context.Context, docs []Document, expiresAt []time.Time, throttle *mergeThrottle) (*segment, error) {
+	builder, err := NewBuilder(s.tokenizer, nil, BuildOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		if err := throttle.wait(ctx, documentBytes(doc)); err != nil {
+			return nil, err
+		}
+		if _, err := builder.Add(doc); err != nil {
+			return nil, err
+		}
+	}
+	base, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	for docID, t := range expiresAt {
+		if !t.IsZero() {
+			base.expiresAt = growTo(base.expiresAt, docID)
+			base.expiresAt[docID] = t
+		}
+	}
+
+	index, err := s.variant.factory(base, s.params)
+	if err != nil {
+		return nil, err
+	}
+	return &segment{docs: docs, index: index, base: base}, nil
+}
+
+// documentBytes returns the size of the text or tokens of a document.
+func documentBytes(doc Document) int {
+	n := len(doc.Text)
+	for _, token := range doc.Tokens {
+		n += len(token.Term)
+	}
+	return n
+}
+
+// mergeThrottle limits the rate at which merges reindex bytes, shared by all running
+// merges.
+type mergeThrottle struct {
+	mu   sync.Mutex
+	rate float64   // Bytes per second
+	next time.Time // When the bytes reserved so far have been paid for
+}
+
+// wait reserves n bytes and waits until the rate allows them. A nil throttle does not
+// wait.
+func (t *mergeThrottle) wait(ctx context.Context, n int) error {
+	if t == nil {
+		return ctx.Err()
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	t.next = t.next.Add(time.Duration(float64(n) / t.rate * float64(time.Second)))
+	delay := t.next.Sub(now)
+	t.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
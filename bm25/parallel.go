@@ -1,46 +1,58 @@
 package bm25
 
-import (
-	"errors"
-	"sync"
-)
+import "sync"
 
 // GetScoresParallel returns the BM25 scores for the given query using parallel computation.
+// The scores of the terms are added in query order, see SetSummation.
 func (b *Bm25Base) GetScoresParallel(query []string, bm25 BM25) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
+	if b.cachesWritable() {
+		// Fill the IDF cache upfront, so the terms scored concurrently only read from it
+		for _, q := range query {
+			_, _ = b.IDF(q)
+		}
+	}
+
+	// Every term is scored into its own slice, and the slices are added in query order
 	var wg sync.WaitGroup
-	scores := make([]float64, b.corpusSize)
+	termScores := make([][]float64, len(query))
 	wg.Add(len(query))
 
-	for _, q := range query {
-		go func(q string) {
+	for k, q := range query {
+		go func(k int, q string) {
 			defer wg.Done()
+			if b.isStopword(q) {
+				return
+			}
+
 			qFreq := make([]float64, b.corpusSize)
-			for i, doc := range b.corpus {
-				docStr := JoinTokens(doc, " ")
-				freq, _ := CountTermFreq(q, docStr, b.tokenizer) // Ignore the error for now
-				qFreq[i] = float64(freq)
+			for i := range b.corpus {
+				qFreq[i] = b.termFrequency(i, q)
 			}
 
-			idf, err := b.IDF(q)
+			idf, err := b.queryIDF(q)
 			if err != nil {
-				if b.logger != nil {
-					b.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-				}
+				b.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 				return
 			}
 
 			for i, docLen := range b.docLengths {
-				k := computeK(bm25, docLen)
-				scores[i] += idf * computeScore(bm25, qFreq[i], k)
+				qFreq[i] = idf * computeScore(bm25, qFreq[i], computeK(bm25, docLen))
 			}
-		}(q)
+			termScores[k] = qFreq
+		}(k, q)
 	}
 
 	wg.Wait()
+	scores := make([]float64, b.corpusSize)
+	sum := b.newTermSum(scores)
+	for _, qScores := range termScores {
+		sum.add(qScores)
+	}
+	b.excludeExpired(scores, nil)
 	return scores, nil
 }
 
@@ -66,7 +78,7 @@ func computeK(bm25 BM25, docLen int) float64 {
 func computeScore(bm25 BM25, qFreq, k float64) float64 {
 	switch bm25 := bm25.(type) {
 	case *BM25Okapi:
-		return qFreq / (qFreq + k)
+		return (qFreq * (bm25.k1 + 1)) / (qFreq + k)
 	case *BM25L:
 		return qFreq / (qFreq + k)
 	case *BM25Plus:
@@ -83,66 +95,68 @@ func computeScore(bm25 BM25, qFreq, k float64) float64 {
 // GetBatchScoresParallel returns the BM25 scores for the given query and a subset of documents using parallel computation.
 func (b *Bm25Base) GetBatchScoresParallel(query []string, docIDs []int, bm25 BM25) ([]float64, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
+	}
+
+	if err := b.validateDocIDs(docIDs); err != nil {
+		return nil, err
 	}
 
-	if len(docIDs) == 0 {
-		return nil, errors.New("document IDs cannot be empty")
+	if b.cachesWritable() {
+		// Fill the IDF cache upfront, so the terms scored concurrently only read from it
+		for _, q := range query {
+			_, _ = b.IDF(q)
+		}
 	}
 
+	// Every term is scored into its own slice, and the slices are added in query order
 	var wg sync.WaitGroup
-	scores := make([]float64, len(docIDs))
+	termScores := make([][]float64, len(query))
 	wg.Add(len(query))
 
-	for _, q := range query {
-		go func(q string) {
+	for k, q := range query {
+		go func(k int, q string) {
 			defer wg.Done()
+			if b.isStopword(q) {
+				return
+			}
+
 			qFreq := make([]float64, len(docIDs))
 			for i, docID := range docIDs {
-				if docID < 0 || docID >= b.corpusSize {
-					if b.logger != nil {
-						b.logger.Printf("Invalid document ID: %d", docID)
-					}
-					continue
-				}
-				docStr := JoinTokens(b.corpus[docID], " ")
-				freq, _ := CountTermFreq(q, docStr, b.tokenizer) // Ignore the error for now
-				qFreq[i] = float64(freq)
+				qFreq[i] = b.termFrequency(docID, q)
 			}
 
-			idf, err := b.IDF(q)
+			idf, err := b.queryIDF(q)
 			if err != nil {
-				if b.logger != nil {
-					b.logger.Printf("Error calculating IDF for term '%s': %v", q, err)
-				}
+				b.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
 				return
 			}
 
 			for i, docID := range docIDs {
-				if docID < 0 || docID >= b.corpusSize {
-					continue
-				}
-				docLen := b.docLengths[docID]
-				k := computeK(bm25, docLen)
-				scores[i] += idf * computeScore(bm25, qFreq[i], k)
+				qFreq[i] = idf * computeScore(bm25, qFreq[i], computeK(bm25, b.docLengths[docID]))
 			}
-		}(q)
+			termScores[k] = qFreq
+		}(k, q)
 	}
 
 	wg.Wait()
+	scores := make([]float64, len(docIDs))
+	sum := b.newTermSum(scores)
+	for _, qScores := range termScores {
+		sum.add(qScores)
+	}
+	b.excludeExpired(scores, docIDs)
 	return scores, nil
 }
 
 // GetTopNParallel returns the top N documents for the given query using parallel computation.
 func (b *Bm25Base) GetTopNParallel(query []string, n int, bm25 BM25) ([]string, error) {
 	if len(query) == 0 {
-		return nil, errors.New("query cannot be empty")
+		return nil, ErrEmptyQuery
 	}
 
 	if n <= 0 {
-		if b.logger != nil {
-			b.logger.Printf("Invalid value for n: %d. Returning empty slice.", n)
-		}
+		b.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
 		return []string{}, nil
 	}
 
@@ -158,7 +172,11 @@ func (b *Bm25Base) GetTopNParallel(query []string, n int, bm25 BM25) ([]string,
 
 	topDocs := make([]string, len(topNIndices))
 	for i, idx := range topNIndices {
-		topDocs[i] = JoinTokens(b.corpus[idx], " ")
+		doc, err := b.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
 	}
 
 	return topDocs, nil
@@ -0,0 +1,136 @@
+package bm25
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+)
+
+// ParamSpec describes a tunable parameter of a BM25 variant: its valid range, its
+// default value and its meaning.
+type ParamSpec struct {
+	Name        string
+	Default     float64
+	Min         float64
+	Max         float64 // math.Inf(1) if unbounded
+	Description string
+
+	// ExclusiveMax excludes Max itself from the valid range.
+	ExclusiveMax bool
+}
+
+// Common parameter specs shared by the variants.
+var (
+	k1ParamSpec = ParamSpec{
+		Name:        "k1",
+		Default:     1.5,
+		Min:         0,
+		Max:         math.Inf(1),
+		Description: "Term frequency saturation. Higher values let repeated terms keep adding to the score.",
+	}
+	bParamSpec = ParamSpec{
+		Name:        "b",
+		Default:     0.75,
+		Min:         0,
+		Max:         1,
+		Description: "Document length normalization, from none (0) to full (1).",
+	}
+)
+
+// Validate checks that the value is within the valid range of the parameter.
+func (s ParamSpec) Validate(value float64) error {
+	if math.IsNaN(value) || value < s.Min || value > s.Max || (s.ExclusiveMax && value == s.Max) {
+		if s.Min == 0 && math.IsInf(s.Max, 1) {
+			return invalidParam(s.Name, value, "must be non-negative")
+		}
+		if s.ExclusiveMax {
+			return invalidParam(s.Name, value, fmt.Sprintf("must be at least %g and below %g", s.Min, s.Max))
+		}
+		return invalidParam(s.Name, value, fmt.Sprintf("must be between %g and %g", s.Min, s.Max))
+	}
+	return nil
+}
+
+// ValidateParams checks the given parameter values against the specs of a variant.
+// Parameters missing from params are not checked; unknown parameters are an error.
+func ValidateParams(specs []ParamSpec, params map[string]float64) error {
+	known := make(map[string]ParamSpec, len(specs))
+	for _, spec := range specs {
+		known[spec.Name] = spec
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		spec, ok := known[name]
+		if !ok {
+			return invalidParam(name, params[name], "is not a known parameter")
+		}
+		if err := spec.Validate(params[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParamsWithDefaults returns a copy of params in which every parameter missing from
+// params is set to its default value.
+func ParamsWithDefaults(specs []ParamSpec, params map[string]float64) map[string]float64 {
+	result := make(map[string]float64, len(specs))
+	for _, spec := range specs {
+		result[spec.Name] = spec.Default
+	}
+	for name, value := range params {
+		result[name] = value
+	}
+	return result
+}
+
+// validateSpecs validates the parameter values, given in the order of the specs.
+func validateSpecs(specs []ParamSpec, values ...float64) error {
+	for i, spec := range specs {
+		if err := spec.Validate(values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Params is not implemented by the base; it returns an empty map.
+func (b *Bm25Base) Params() map[string]float64 {
+	return map[string]float64{}
+}
+
+// ParamSpecs is not implemented by the base; it returns nil.
+func (b *Bm25Base) ParamSpecs() []ParamSpec {
+	return nil
+}
+
+// paramOverrider is implemented by the variants whose parameters can be overridden per
+// search, see SearchRequest.Params.
+type paramOverrider interface {
+	// withParams returns a copy of the variant with the given parameters, sharing its
+	// base. All parameters of the variant are given.
+	withParams(params map[string]float64) (BM25, error)
+}
+
+// overrideParams returns a copy of the variant with the given parameters overriding its
+// own, leaving the variant unchanged.
+func overrideParams(bm25 BM25, params map[string]float64) (BM25, error) {
+	overrider, ok := bm25.(paramOverrider)
+	if !ok {
+		return nil, fmt.Errorf("%w: parameter overrides", ErrNotImplemented)
+	}
+	if err := ValidateParams(bm25.ParamSpecs(), params); err != nil {
+		return nil, err
+	}
+
+	merged := bm25.Params()
+	maps.Copy(merged, params)
+	return overrider.withParams(merged)
+}
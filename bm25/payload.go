@@ -0,0 +1,92 @@
+package bm25
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Token is a term of a document with a payload, see Document.Tokens.
+type Token struct {
+	Term string
+
+	// Payload scales the contribution of the occurrence to the term frequency, e.g. by
+	// the confidence of an entity recognized by an upstream NLP model, so an occurrence
+	// with a payload of 0.5 counts half. 0 is treated as 1, so tokens without a payload
+	// count fully.
+	Payload float64
+}
+
+// splitTokens returns the terms of the tokens of a document and their payloads. The
+// payloads are nil if all tokens count fully.
+func splitTokens(tokens []Token) ([]string, []float64, error) {
+	terms := make([]string, len(tokens))
+	var payloads []float64
+	for i, token := range tokens {
+		if token.Term == "" {
+			return nil, nil, invalidParam("tokens", i, "must not contain empty terms")
+		}
+		if token.Payload < 0 || math.IsNaN(token.Payload) || math.IsInf(token.Payload, 0) {
+			return nil, nil, invalidParam("payload", token.Payload, fmt.Sprintf("of token %q must be a non-negative finite number", token.Term))
+		}
+
+		terms[i] = token.Term
+		if token.Payload != 0 && token.Payload != 1 && payloads == nil {
+			payloads = make([]float64, len(tokens))
+			for j := 0; j < i; j++ {
+				payloads[j] = 1
+			}
+		}
+		if payloads != nil {
+			payloads[i] = token.Payload
+			if payloads[i] == 0 {
+				payloads[i] = 1
+			}
+		}
+	}
+	return terms, payloads, nil
+}
+
+// analyzeDocument returns the tokens of a document and their payloads, see
+// Document.Tokens.
+func (b *Bm25Base) analyzeDocument(doc Document) ([]string, []float64, error) {
+	if doc.Tokens != nil {
+		return splitTokens(doc.Tokens)
+	}
+	return b.Analyze(doc.Text, doc.Language), nil, nil
+}
+
+// setPayloads records the payloads of the tokens of a document.
+func (b *Bm25Base) setPayloads(docID int, payloads []float64) {
+	if payloads == nil {
+		return
+	}
+	if b.payloads == nil {
+		b.payloads = make(map[int][]float64)
+	}
+	b.payloads[docID] = payloads
+}
+
+// Payloads returns the payloads of the tokens of a document, in token order, or nil if
+// the document was added without payloads.
+func (b *Bm25Base) Payloads(docID int) []float64 {
+	return slices.Clone(b.payloads[docID])
+}
+
+// rawTermFrequency returns the frequency of a term in a document, with every occurrence
+// scaled by its payload.
+func (b *Bm25Base) rawTermFrequency(docID int, term string) float64 {
+	doc := b.doc(docID)
+	payloads, ok := b.payloads[docID]
+	if !ok {
+		return float64(countTokens(doc, term))
+	}
+
+	tf := 0.0
+	for i, token := range doc {
+		if token == term {
+			tf += payloads[i]
+		}
+	}
+	return tf
+}
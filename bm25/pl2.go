@@ -0,0 +1,238 @@
+package bm25
+
+import (
+	"context"
+	"log"
+	"math"
+)
+
+// PL2 is an implementation of the PL2 model of the Divergence From Randomness framework
+// by Amati and van Rijsbergen. It scores a term by how much its frequency in a document
+// diverges from a Poisson distribution of its occurrences across the corpus, with the
+// frequency normalized as tf log2(1 + c avgdl / dl) and the gain of one more occurrence
+// given by the Laplace after-effect 1 / (tfn + 1), as implemented by Terrier.
+type PL2 struct {
+	*Bm25Base
+	c float64
+}
+
+// NewPL2 creates a new instance of the PL2 struct.
+func NewPL2(corpus []string, tokenizer func(string) []string, c float64, logger *log.Logger) (*PL2, error) {
+	if err := validatePL2Params(c); err != nil {
+		return nil, err
+	}
+
+	base, err := NewBM25Base(corpus, tokenizer, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPL2FromBase(base, c)
+}
+
+// NewPL2FromBase creates a new instance of the PL2 struct on top of an existing Bm25Base.
+func NewPL2FromBase(base *Bm25Base, c float64) (*PL2, error) {
+	if base == nil {
+		return nil, ErrNilBase
+	}
+
+	if err := validatePL2Params(c); err != nil {
+		return nil, err
+	}
+
+	return &PL2{
+		Bm25Base: base,
+		c:        c,
+	}, nil
+}
+
+// PL2ParamSpecs returns the specs of the parameters of the PL2 variant.
+func PL2ParamSpecs() []ParamSpec {
+	return []ParamSpec{
+		{
+			Name:        "c",
+			Default:     1,
+			Min:         0,
+			Max:         math.Inf(1),
+			Description: "Document length normalization of the term frequencies. Higher values normalize less.",
+		},
+	}
+}
+
+// validatePL2Params validates the parameters of the PL2 variant.
+func validatePL2Params(c float64) error {
+	return validateSpecs(PL2ParamSpecs(), c)
+}
+
+// Params returns the parameters of the index.
+func (p *PL2) Params() map[string]float64 {
+	return map[string]float64{
+		"c": p.c,
+	}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (p *PL2) ParamSpecs() []ParamSpec {
+	return PL2ParamSpecs()
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params.
+func (p *PL2) withParams(params map[string]float64) (BM25, error) {
+	overridden, err := NewPL2FromBase(p.Bm25Base, params["c"])
+	if err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
+// termScore returns the score of a term occurring tf times in a document of the given
+// length, given the mean number of occurrences lambda of the term per document.
+func (p *PL2) termScore(tf float64, docLen int, lambda float64) float64 {
+	if tf == 0 || docLen == 0 {
+		return 0
+	}
+	tfn := tf * math.Log2(1+p.c*p.avgDocLen/float64(docLen))
+	if tfn == 0 {
+		return 0
+	}
+	return (tfn*math.Log2(tfn/lambda) + (lambda-tfn)*log2E + 0.5*math.Log2(2*math.Pi*tfn)) / (tfn + 1)
+}
+
+// lambda returns the mean number of occurrences of a term per document, or false if the
+// term is in no document.
+func (p *PL2) lambda(term string) (float64, bool) {
+	freq := p.collectionFrequency(term)
+	if freq == 0 {
+		return 0, false
+	}
+	return freq / float64(p.corpusSize), true
+}
+
+// GetScores returns the PL2 scores for the given query.
+func (p *PL2) GetScores(query []string) ([]float64, error) {
+	return p.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (p *PL2) GetScoresInto(dst []float64, query []string) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	scores := p.scoreBuffer(dst)
+	for _, q := range query {
+		if p.isStopword(q) {
+			continue
+		}
+
+		lambda, ok := p.lambda(q)
+		if !ok {
+			p.logf(LogDebug, "Term '%s' is not in the corpus", q)
+			continue
+		}
+		weight := p.termWeight(q)
+
+		for i, docLen := range p.docLengths {
+			scores[i] += weight * p.termScore(p.termFrequency(i, q), docLen, lambda)
+		}
+	}
+
+	p.excludeExpired(scores, nil)
+	return scores, nil
+}
+
+// GetBatchScores returns the PL2 scores for the given query and a subset of documents.
+func (p *PL2) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if err := p.validateDocIDs(docIDs); err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(docIDs))
+	for _, q := range query {
+		if p.isStopword(q) {
+			continue
+		}
+
+		lambda, ok := p.lambda(q)
+		if !ok {
+			p.logf(LogDebug, "Term '%s' is not in the corpus", q)
+			continue
+		}
+		weight := p.termWeight(q)
+
+		for i, docID := range docIDs {
+			scores[i] += weight * p.termScore(p.termFrequency(docID, q), p.docLengths[docID], lambda)
+		}
+	}
+
+	p.excludeExpired(scores, docIDs)
+	return scores, nil
+}
+
+// GetTopN returns the top N documents for the given query.
+func (p *PL2) GetTopN(query []string, n int) ([]string, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if n <= 0 {
+		p.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
+		return []string{}, nil
+	}
+
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := p.GetScoresInto(*buf, query)
+	if err != nil {
+		return nil, err
+	}
+	*buf = scores
+
+	topNIndices, err := TopNIndices(scores, n)
+	if err != nil {
+		return nil, err
+	}
+
+	topDocs := make([]string, len(topNIndices))
+	for i, idx := range topNIndices {
+		doc, err := p.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
+	}
+
+	return topDocs, nil
+}
+
+// Search runs the given search request against the index.
+func (p *PL2) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return p.search(ctx, p, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (p *PL2) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return p.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the PL2 instance.
+func (p *PL2) Clone() *PL2 {
+	clone := *p
+	clone.Bm25Base = p.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the PL2 instance that is safe for concurrent use.
+func (p *PL2) Freeze() *PL2 {
+	frozen := *p
+	frozen.Bm25Base = p.Bm25Base.Freeze()
+	return &frozen
+}
@@ -0,0 +1,19 @@
+package bm25
+
+import "sync"
+
+// scoreBuffers recycles the score buffers of GetTopN and Search, which are only needed
+// until the best documents are selected, so queries do not allocate a buffer the size of
+// the corpus every time.
+var scoreBuffers = sync.Pool{New: func() any { return new([]float64) }}
+
+// scoreBuffer returns dst resized to the corpus size and zeroed, allocating a new slice
+// only if dst is too small.
+func (b *Bm25Base) scoreBuffer(dst []float64) []float64 {
+	if cap(dst) < b.corpusSize {
+		return make([]float64, b.corpusSize)
+	}
+	dst = dst[:b.corpusSize]
+	clear(dst)
+	return dst
+}
@@ -0,0 +1,73 @@
+package bm25
+
+import (
+	"math"
+	"slices"
+)
+
+// defaultPositionWindow is the default number of leading tokens of a document in which
+// matches are boosted.
+const defaultPositionWindow = 50
+
+// PositionBoost boosts the matches occurring early in a document, e.g. in the title of a
+// document made of its title followed by its body, as the leading text is usually the
+// most indicative of its relevance.
+type PositionBoost struct {
+	// Boost is the maximum boost: the score of a query term in a document whose first
+	// token is the term is multiplied by 1 + Boost, or raised by Boost times its magnitude
+	// if it is negative. Zero disables the boost.
+	Boost float64
+
+	// Window is the number of leading tokens in which matches are boosted. The boost
+	// decreases linearly with the position of the first occurrence of the term, down to
+	// none at the end of the window. Defaults to 50.
+	Window int
+}
+
+// validate checks the position boost of a search request.
+func (p PositionBoost) validate() error {
+	if p.Boost < 0 || math.IsNaN(p.Boost) || math.IsInf(p.Boost, 0) {
+		return invalidParam("positionBoost.boost", p.Boost, "must be a non-negative finite number")
+	}
+	if p.Window < 0 {
+		return invalidParam("positionBoost.window", p.Window, "must be a non-negative integer")
+	}
+	return nil
+}
+
+// apply raises the scores of a query term by the boost of the position of its first
+// occurrence in every matching document. Field-scoped terms are located within their
+// field, see termLocator.
+func (p PositionBoost) apply(b *Bm25Base, bm25 BM25, token string, scores []float64) {
+	if p.Boost == 0 {
+		return
+	}
+	window := p.Window
+	if window == 0 {
+		window = defaultPositionWindow
+	}
+
+	locator, _ := bm25.(termLocator)
+	for docID, score := range scores {
+		if score == 0 {
+			continue
+		}
+		doc, term := b.doc(docID), token
+		if locator != nil {
+			doc, term = locator.locateTerm(docID, token)
+		}
+		if pos := slices.Index(doc[:min(window, len(doc))], term); pos >= 0 {
+			// Negative scores are raised by the same fraction of their magnitude, so early
+			// matches always move up
+			scores[docID] += p.Boost * (1 - float64(pos)/float64(window)) * math.Abs(score)
+		}
+	}
+}
+
+// termLocator is implemented by the indexes whose query tokens are not plain terms, e.g.
+// the field-scoped terms of BM25F.
+type termLocator interface {
+	// locateTerm returns the tokens of a document a query token is matched against, and
+	// the term it matches.
+	locateTerm(docID int, token string) ([]string, string)
+}
@@ -0,0 +1,400 @@
+package querydsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// idField is the field name under which queries refer to the external document ID.
+const idField = "_id"
+
+// clause decides whether a document matches a query clause.
+type clause interface {
+	matches(base *bm25.Bm25Base, docID int) bool
+}
+
+// parseClause parses a query clause. The terms of match clauses in a scoring context are
+// appended to terms.
+func (p *Parser) parseClause(raw json.RawMessage, scoring bool, terms *[]string) (clause, error) {
+	kind, body, err := singleKey("query clause", raw)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case "match_all":
+		return matchAll{}, nil
+	case "match":
+		return p.parseMatch(body, scoring, terms)
+	case "multi_match":
+		return p.parseMultiMatch(body, scoring, terms)
+	case "bool":
+		return p.parseBool(body, scoring, terms)
+	case "term":
+		return parseTerm(body)
+	case "terms":
+		return parseTerms(body)
+	case "range":
+		return parseRange(body)
+	case "exists":
+		var exists struct {
+			Field string `json:"field"`
+		}
+		if err := json.Unmarshal(body, &exists); err != nil || exists.Field == "" {
+			return nil, fmt.Errorf("exists: a field is required")
+		}
+		return existsClause{field: exists.Field}, nil
+	case "ids":
+		var ids struct {
+			Values []string `json:"values"`
+		}
+		if err := json.Unmarshal(body, &ids); err != nil {
+			return nil, fmt.Errorf("ids: %w", err)
+		}
+		values := make([]any, len(ids.Values))
+		for i, id := range ids.Values {
+			values[i] = id
+		}
+		return termsClause{field: idField, values: values}, nil
+	default:
+		return nil, fmt.Errorf("%s: %w", kind, ErrUnsupported)
+	}
+}
+
+// singleKey splits an object with a single key, e.g. {"title": ...}, into the key and
+// its value.
+func singleKey(what string, body json.RawMessage) (string, json.RawMessage, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil || len(object) != 1 {
+		return "", nil, fmt.Errorf("%s: expected an object with a single key, got %s", what, body)
+	}
+
+	var key string
+	for key = range object {
+	}
+	return key, object[key], nil
+}
+
+// matchOptions are the options of match and multi_match queries.
+type matchOptions struct {
+	Query    string   `json:"query"`
+	Operator string   `json:"operator"`
+	Fields   []string `json:"fields"`
+}
+
+// parseMatch parses a match query: {"field": "text"} or {"field": {"query": "text", ...}}.
+func (p *Parser) parseMatch(body json.RawMessage, scoring bool, terms *[]string) (clause, error) {
+	field, value, err := singleKey("match", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts matchOptions
+	if err := json.Unmarshal(value, &opts.Query); err != nil {
+		if err := json.Unmarshal(value, &opts); err != nil {
+			return nil, fmt.Errorf("match: %w", err)
+		}
+	}
+	return p.newMatch([]string{field}, opts, scoring, terms)
+}
+
+// parseMultiMatch parses a multi_match query: {"query": "text", "fields": [...]}.
+func (p *Parser) parseMultiMatch(body json.RawMessage, scoring bool, terms *[]string) (clause, error) {
+	var opts matchOptions
+	if err := json.Unmarshal(body, &opts); err != nil {
+		return nil, fmt.Errorf("multi_match: %w", err)
+	}
+
+	fields := make([]string, len(opts.Fields))
+	for i, field := range opts.Fields {
+		fields[i], _, _ = strings.Cut(field, "^") // Boosts are not supported and ignored
+	}
+	return p.newMatch(fields, opts, scoring, terms)
+}
+
+// newMatch tokenizes the text of a match query and records its terms for scoring.
+func (p *Parser) newMatch(fields []string, opts matchOptions, scoring bool, terms *[]string) (clause, error) {
+	switch opts.Operator {
+	case "", "or", "OR", "and", "AND":
+	default:
+		return nil, fmt.Errorf("match operator %q: %w", opts.Operator, ErrUnsupported)
+	}
+
+	tokens := p.Tokenizer(opts.Query)
+	if scoring {
+		for _, token := range tokens {
+			if !p.FieldScoped || len(fields) == 0 {
+				*terms = append(*terms, token)
+				continue
+			}
+			for _, field := range fields {
+				*terms = append(*terms, field+":"+token)
+			}
+		}
+	}
+	return matchClause{terms: tokens, all: strings.EqualFold(opts.Operator, "and")}, nil
+}
+
+// boolQuery is the layout of a bool query. Every occurrence type takes a clause or a list
+// of clauses.
+type boolQuery struct {
+	Must               json.RawMessage `json:"must"`
+	Should             json.RawMessage `json:"should"`
+	Filter             json.RawMessage `json:"filter"`
+	MustNot            json.RawMessage `json:"must_not"`
+	MinimumShouldMatch *int            `json:"minimum_should_match"`
+}
+
+// parseBool parses a bool query.
+func (p *Parser) parseBool(body json.RawMessage, scoring bool, terms *[]string) (clause, error) {
+	var query boolQuery
+	if err := json.Unmarshal(body, &query); err != nil {
+		return nil, fmt.Errorf("bool: %w", err)
+	}
+
+	var b boolClause
+	var err error
+	if b.must, err = p.parseClauses(query.Must, scoring, terms); err != nil {
+		return nil, err
+	}
+	if b.should, err = p.parseClauses(query.Should, scoring, terms); err != nil {
+		return nil, err
+	}
+	if b.filter, err = p.parseClauses(query.Filter, false, terms); err != nil {
+		return nil, err
+	}
+	if b.mustNot, err = p.parseClauses(query.MustNot, false, terms); err != nil {
+		return nil, err
+	}
+
+	// Without required clauses, at least one optional clause has to match
+	if len(b.must) == 0 && len(b.filter) == 0 && len(b.should) > 0 {
+		b.minimumShouldMatch = 1
+	}
+	if query.MinimumShouldMatch != nil {
+		b.minimumShouldMatch = *query.MinimumShouldMatch
+	}
+	return b, nil
+}
+
+// parseClauses parses a clause or a list of clauses.
+func (p *Parser) parseClauses(raw json.RawMessage, scoring bool, terms *[]string) ([]clause, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var raws []json.RawMessage
+	if err := json.Unmarshal(raw, &raws); err != nil {
+		raws = []json.RawMessage{raw}
+	}
+
+	clauses := make([]clause, len(raws))
+	for i, raw := range raws {
+		c, err := p.parseClause(raw, scoring, terms)
+		if err != nil {
+			return nil, err
+		}
+		clauses[i] = c
+	}
+	return clauses, nil
+}
+
+// parseTerm parses a term query: {"field": value} or {"field": {"value": value}}.
+func parseTerm(body json.RawMessage) (clause, error) {
+	field, raw, err := singleKey("term", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var options struct {
+		Value any `json:"value"`
+	}
+	if err := unmarshalNumber(raw, &options); err != nil || options.Value == nil {
+		if err := unmarshalNumber(raw, &options.Value); err != nil {
+			return nil, fmt.Errorf("term: %w", err)
+		}
+	}
+	return termsClause{field: field, values: []any{options.Value}}, nil
+}
+
+// parseTerms parses a terms query: {"field": [values]}.
+func parseTerms(body json.RawMessage) (clause, error) {
+	field, raw, err := singleKey("terms", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []any
+	if err := unmarshalNumber(raw, &values); err != nil {
+		return nil, fmt.Errorf("terms: %w", err)
+	}
+	return termsClause{field: field, values: values}, nil
+}
+
+// parseRange parses a range query: {"field": {"gte": ..., "lt": ...}}. Date bounds are
+// RFC 3339 timestamps or plain dates.
+func parseRange(body json.RawMessage) (clause, error) {
+	field, raw, err := singleKey("range", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var bounds map[string]any
+	if err := unmarshalNumber(raw, &bounds); err != nil {
+		return nil, fmt.Errorf("range: %w", err)
+	}
+
+	r := bm25.RangeFilter{Field: field}
+	for op, bound := range bounds {
+		if s, ok := bound.(string); ok {
+			if bound, ok = parseDate(s); !ok {
+				return nil, fmt.Errorf("range: invalid date %q", s)
+			}
+		}
+		switch op {
+		case "gte", "gt":
+			r.Min, r.ExclusiveMin = bound, op == "gt"
+		case "lte", "lt":
+			r.Max, r.ExclusiveMax = bound, op == "lt"
+		default:
+			return nil, fmt.Errorf("range option %q: %w", op, ErrUnsupported)
+		}
+	}
+	return rangeClause{filter: r}, nil
+}
+
+// parseDate parses an RFC 3339 timestamp or a plain date.
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// unmarshalNumber decodes JSON, keeping numbers as json.Number.
+func unmarshalNumber(raw json.RawMessage, v any) error {
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// matchAll matches every document.
+type matchAll struct{}
+
+func (matchAll) matches(*bm25.Bm25Base, int) bool {
+	return true
+}
+
+// matchClause matches the documents containing any, or all, of its terms.
+type matchClause struct {
+	terms []string
+	all   bool
+}
+
+func (c matchClause) matches(base *bm25.Bm25Base, docID int) bool {
+	if len(c.terms) == 0 {
+		return false
+	}
+
+	for _, term := range c.terms {
+		found := base.HasTerm(docID, term)
+		if found && !c.all {
+			return true
+		}
+		if !found && c.all {
+			return false
+		}
+	}
+	return c.all
+}
+
+// boolClause combines clauses like a bool query.
+type boolClause struct {
+	must, should, filter, mustNot []clause
+	minimumShouldMatch            int
+}
+
+func (c boolClause) matches(base *bm25.Bm25Base, docID int) bool {
+	for _, required := range c.must {
+		if !required.matches(base, docID) {
+			return false
+		}
+	}
+	for _, required := range c.filter {
+		if !required.matches(base, docID) {
+			return false
+		}
+	}
+	for _, excluded := range c.mustNot {
+		if excluded.matches(base, docID) {
+			return false
+		}
+	}
+
+	matched := 0
+	for _, optional := range c.should {
+		if matched >= c.minimumShouldMatch {
+			break
+		}
+		if optional.matches(base, docID) {
+			matched++
+		}
+	}
+	return matched >= c.minimumShouldMatch
+}
+
+// termsClause matches the documents whose field equals one of its values. Fields
+// without metadata match documents containing a value as a term.
+type termsClause struct {
+	field  string
+	values []any
+}
+
+func (c termsClause) matches(base *bm25.Bm25Base, docID int) bool {
+	var actual any
+	var ok bool
+	if c.field == idField {
+		actual, ok = base.ExternalID(docID), true
+	} else {
+		actual, ok = base.Metadata(docID)[c.field]
+	}
+
+	for _, value := range c.values {
+		if !ok {
+			if term, isString := value.(string); isString && base.HasTerm(docID, term) {
+				return true
+			}
+			continue
+		}
+		if fmt.Sprint(actual) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeClause matches the documents whose field lies within a range.
+type rangeClause struct {
+	filter bm25.RangeFilter
+}
+
+func (c rangeClause) matches(base *bm25.Bm25Base, docID int) bool {
+	value, ok := base.Metadata(docID)[c.filter.Field]
+	return ok && c.filter.Contains(value)
+}
+
+// existsClause matches the documents with a value for a field.
+type existsClause struct {
+	field string
+}
+
+func (c existsClause) matches(base *bm25.Bm25Base, docID int) bool {
+	value, ok := base.Metadata(docID)[c.field]
+	return ok && value != nil
+}
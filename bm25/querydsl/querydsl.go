@@ -0,0 +1,145 @@
+// Package querydsl parses a subset of the Elasticsearch and OpenSearch JSON query DSL into
+// search requests, so simple keyword search workloads can move to this library without
+// rewriting their queries.
+//
+// The supported queries are match, multi_match, bool (must, should, filter, must_not and
+// minimum_should_match), term, terms, range, exists, ids and match_all, with size and
+// sort. Match queries contribute their terms to the BM25 query; all clauses together
+// decide which documents match. Term, terms, range and exists queries read the metadata
+// of the documents, with "_id" referring to the external ID; a term query on a field
+// without metadata matches the documents containing the term.
+package querydsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// DefaultSize is the number of results of a search body without a size, as in Elasticsearch.
+const DefaultSize = 10
+
+// ErrUnsupported is returned for queries and options outside of the supported subset.
+var ErrUnsupported = errors.New("unsupported query")
+
+// Parser parses search bodies.
+type Parser struct {
+	// Tokenizer tokenizes the text of match queries. It should be the tokenizer of the index.
+	Tokenizer func(string) []string
+
+	// FieldScoped emits the terms of match queries as "field:term", for BM25F indexes.
+	// Clauses then still match documents containing the term in any field.
+	FieldScoped bool
+}
+
+// searchBody is the layout of a search request body.
+type searchBody struct {
+	Query json.RawMessage `json:"query"`
+	Size  *int            `json:"size"`
+	From  int             `json:"from"`
+	Sort  json.RawMessage `json:"sort"`
+}
+
+// Parse parses a search body, e.g. {"query": {"match": {"body": "quick fox"}}, "size": 5},
+// into a search request against base.
+func (p *Parser) Parse(body []byte, base *bm25.Bm25Base) (bm25.SearchRequest, error) {
+	if p.Tokenizer == nil {
+		return bm25.SearchRequest{}, bm25.ErrNilTokenizer
+	}
+	if base == nil {
+		return bm25.SearchRequest{}, bm25.ErrNilBase
+	}
+
+	var search searchBody
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&search); err != nil {
+		return bm25.SearchRequest{}, fmt.Errorf("parsing search body: %w", err)
+	}
+	if search.From != 0 {
+		return bm25.SearchRequest{}, fmt.Errorf("from: %w", ErrUnsupported)
+	}
+
+	root := clause(matchAll{})
+	var terms []string
+	if len(search.Query) > 0 {
+		var err error
+		if root, err = p.parseClause(search.Query, true, &terms); err != nil {
+			return bm25.SearchRequest{}, err
+		}
+	}
+	if len(terms) == 0 {
+		return bm25.SearchRequest{}, fmt.Errorf("query without match clauses: %w", ErrUnsupported)
+	}
+
+	req := bm25.SearchRequest{
+		Query: terms,
+		N:     DefaultSize,
+		Filter: func(docID int) bool {
+			return root.matches(base, docID)
+		},
+	}
+	if search.Size != nil {
+		req.N = *search.Size
+	}
+
+	sort, err := parseSort(search.Sort)
+	if err != nil {
+		return bm25.SearchRequest{}, err
+	}
+	req.Sort = sort
+	return req, nil
+}
+
+// parseSort parses the sort option: a key or a list of keys, each a field name or an
+// object mapping a field to its order.
+func parseSort(raw json.RawMessage) ([]bm25.SortField, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var keys []json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		keys = []json.RawMessage{raw}
+	}
+
+	var fields []bm25.SortField
+	for _, key := range keys {
+		var name string
+		if err := json.Unmarshal(key, &name); err == nil {
+			fields = append(fields, bm25.SortField{Field: name, Descending: name == bm25.ScoreField})
+			continue
+		}
+
+		var orders map[string]json.RawMessage
+		if err := json.Unmarshal(key, &orders); err != nil || len(orders) != 1 {
+			return nil, fmt.Errorf("invalid sort key %s", key)
+		}
+		for field, rawOrder := range orders {
+			var order string
+			if err := json.Unmarshal(rawOrder, &order); err != nil {
+				var options struct {
+					Order string `json:"order"`
+				}
+				if err := json.Unmarshal(rawOrder, &options); err != nil {
+					return nil, fmt.Errorf("invalid sort order of %s: %s", field, rawOrder)
+				}
+				order = options.Order
+			}
+
+			switch order {
+			case "asc", "desc":
+				fields = append(fields, bm25.SortField{Field: field, Descending: order == "desc"})
+			case "":
+				fields = append(fields, bm25.SortField{Field: field, Descending: field == bm25.ScoreField})
+			default:
+				return nil, fmt.Errorf("invalid sort order %q of %s", order, field)
+			}
+		}
+	}
+	return fields, nil
+}
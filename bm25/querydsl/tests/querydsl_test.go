@@ -0,0 +1,128 @@
+package querydsl_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/querydsl"
+)
+
+func newIndex(t *testing.T) *bm25.BM25Okapi {
+	t.Helper()
+	tokenizer := func(s string) []string { return strings.Fields(strings.ToLower(s)) }
+	builder, _ := bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{})
+	docs := []bm25.Document{
+		{ID: "a", Text: "quick brown fox", Metadata: map[string]any{"lang": "en", "year": 2020, "published": time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)}},
+		{ID: "b", Text: "quick red fox jumps", Metadata: map[string]any{"lang": "de", "year": 2022}},
+		{ID: "c", Text: "lazy brown dog", Metadata: map[string]any{"lang": "en", "year": 2023}},
+		{ID: "d", Text: "quick quick dog"},
+	}
+	for _, doc := range docs {
+		if _, err := builder.Add(doc); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	base, _ := builder.Build()
+	okapi, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	return okapi
+}
+
+// search parses the body and returns the external IDs of the results.
+func search(t *testing.T, okapi *bm25.BM25Okapi, body string) ([]string, error) {
+	t.Helper()
+	parser := &querydsl.Parser{Tokenizer: func(s string) []string { return strings.Fields(strings.ToLower(s)) }}
+	req, err := parser.Parse([]byte(body), okapi.Bm25Base)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := okapi.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ids := []string{}
+	for _, result := range resp.Results {
+		ids = append(ids, okapi.ExternalID(result.DocID))
+	}
+	return ids, nil
+}
+
+func TestParse(t *testing.T) {
+	okapi := newIndex(t)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{"Match", `{"query": {"match": {"body": "fox"}}}`, []string{"a", "b"}},
+		{"Match with and operator", `{"query": {"match": {"body": {"query": "quick dog", "operator": "and"}}}}`, []string{"d"}},
+		{"Multi match with size", `{"query": {"multi_match": {"query": "brown", "fields": ["title^2", "body"]}}, "size": 1}`, []string{"a"}},
+		{"Bool with term filter", `{"query": {"bool": {"must": {"match": {"body": "quick"}}, "filter": [{"term": {"lang": "en"}}]}}}`, []string{"a"}},
+		{"Bool with must not", `{"query": {"bool": {"must": [{"match": {"body": "quick"}}], "must_not": {"exists": {"field": "lang"}}}}}`, []string{"d"}},
+		{"Should without must", `{"query": {"bool": {"should": [{"match": {"body": "lazy"}}, {"match": {"body": "jumps"}}]}}}`, []string{"b", "c"}},
+		{"Numeric range and terms", `{"query": {"bool": {"must": {"match": {"body": "quick brown"}}, "filter": [{"range": {"year": {"gte": 2021}}}, {"terms": {"lang": ["de", "fr"]}}]}}}`, []string{"b"}},
+		{"Date range", `{"query": {"bool": {"must": {"match": {"body": "fox"}}, "filter": {"range": {"published": {"lt": "2021-01-01"}}}}}}`, []string{"a"}},
+		{"Ids", `{"query": {"bool": {"must": {"match": {"body": "quick"}}, "filter": {"ids": {"values": ["b", "d"]}}}}}`, []string{"d", "b"}},
+		{"Term on text", `{"query": {"bool": {"must": {"match": {"body": "fox"}}, "filter": {"term": {"body": {"value": "red"}}}}}}`, []string{"b"}},
+		{"Sort by metadata", `{"query": {"match": {"body": "quick"}}, "sort": [{"year": {"order": "desc"}}]}`, []string{"b", "a", "d"}},
+	}
+
+	for _, test := range tests {
+		// Test case: Parsing and running a supported query
+		ids, err := search(t, okapi, test.body)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if test.name == "Should without must" || test.name == "Match" {
+			// Both documents match a single term; only the set is checked
+			if len(ids) == 2 && ids[0] > ids[1] {
+				ids[0], ids[1] = ids[1], ids[0]
+			}
+		}
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%s: expected %v, but got %v", test.name, test.expected, ids)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	okapi := newIndex(t)
+
+	// Test case: Unsupported queries and options
+	for _, body := range []string{
+		`{"query": {"match_phrase": {"body": "quick fox"}}}`,
+		`{"query": {"match": {"body": "fox"}}, "from": 10}`,
+		`{"query": {"term": {"lang": "en"}}}`,
+	} {
+		if _, err := search(t, okapi, body); !errors.Is(err, querydsl.ErrUnsupported) {
+			t.Errorf("Expected ErrUnsupported for %s, but got %v", body, err)
+		}
+	}
+
+	// Test case: Malformed bodies
+	for _, body := range []string{
+		`{"query": {"match": {"a": "x", "b": "y"}}}`,
+		`{"query": {"range": {"year": {"gte": "not a date"}}}}`,
+		`{"aggs": {}}`,
+	} {
+		if _, err := search(t, okapi, body); err == nil {
+			t.Errorf("Expected an error for %s", body)
+		}
+	}
+
+	// Test case: Field-scoped terms for BM25F
+	parser := &querydsl.Parser{Tokenizer: strings.Fields, FieldScoped: true}
+	req, err := parser.Parse([]byte(`{"query": {"multi_match": {"query": "fox", "fields": ["title", "body"]}}}`), okapi.Bm25Base)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(req.Query, []string{"title:fox", "body:fox"}) {
+		t.Errorf("Expected field-scoped terms, but got %v", req.Query)
+	}
+}
@@ -0,0 +1,89 @@
+package bm25
+
+import (
+	"context"
+	"slices"
+)
+
+// QueryHook classifies or rewrites a query before it is run, see OnQuery. It can modify
+// the request in place, e.g. its Query terms, N or Params, and returns the intent of the
+// query, e.g. "navigational", or "" to leave it unclassified. The Query of the request
+// already holds the tokens of its Text. Returning an error fails the search.
+type QueryHook func(ctx context.Context, req *SearchRequest) (string, error)
+
+// QueryRewrite records how the query hooks of an index changed the terms of a query.
+type QueryRewrite struct {
+	Original []string `json:"original"`
+	Query    []string `json:"query"`
+}
+
+// OnQuery registers a hook that is run on every search request before the documents are
+// retrieved, e.g. to classify the intent of the query or strip filler words. Hooks run in
+// the order they were registered, each seeing the changes of the previous ones; the
+// intent of the last hook that returns one is the Intent of the response, and the
+// Rewrite of the response records the terms before and after the hooks if they changed
+// them. Like ingest hooks, query hooks are kept by clones.
+func (b *Bm25Base) OnQuery(hook QueryHook) {
+	b.queryHooks = append(b.queryHooks, hook)
+}
+
+// rewriteQuery runs the query hooks on a request. It returns the intent of the query and
+// the rewrite of its terms, or nil if they did not change.
+func (b *Bm25Base) rewriteQuery(ctx context.Context, req *SearchRequest) (string, *QueryRewrite, error) {
+	if len(b.queryHooks) == 0 {
+		return "", nil, nil
+	}
+
+	// The hooks must not modify the query of the caller
+	original := slices.Clone(req.Query)
+	req.Query = slices.Clone(req.Query)
+	var intent string
+	for _, hook := range b.queryHooks {
+		hookIntent, err := hook(ctx, req)
+		if err != nil {
+			return "", nil, err
+		}
+		if hookIntent != "" {
+			intent = hookIntent
+		}
+	}
+
+	if slices.Equal(original, req.Query) {
+		return intent, nil, nil
+	}
+	return intent, &QueryRewrite{Original: original, Query: slices.Clone(req.Query)}, nil
+}
+
+// StripTermsHook returns a query hook that removes the given terms from queries, e.g.
+// filler words like "how" or "find" that carry no meaning for retrieval. A query made only
+// of such terms is left unchanged rather than emptied.
+func StripTermsHook(terms ...string) QueryHook {
+	strip := make(map[string]struct{}, len(terms))
+	for _, term := range terms {
+		strip[term] = struct{}{}
+	}
+	return func(ctx context.Context, req *SearchRequest) (string, error) {
+		kept := keepTokens(slices.Clone(req.Query), func(term string) bool {
+			_, ok := strip[term]
+			return !ok
+		})
+		if len(kept) > 0 {
+			req.Query = kept
+		}
+		return "", nil
+	}
+}
+
+// IntentHook returns a query hook that classifies the queries containing any of the given
+// cue terms with the given intent, e.g. "navigational" for queries containing "login" or
+// "homepage". Hooks for several intents can be combined; the last matching one wins.
+func IntentHook(intent string, cues ...string) QueryHook {
+	return func(ctx context.Context, req *SearchRequest) (string, error) {
+		for _, term := range req.Query {
+			if slices.Contains(cues, term) {
+				return intent, nil
+			}
+		}
+		return "", nil
+	}
+}
@@ -0,0 +1,306 @@
+package bm25
+
+import (
+	"context"
+	"log"
+	"math"
+)
+
+// Smoothing selects how a QueryLikelihood index smooths the language model of every
+// document with the language model of the corpus.
+type Smoothing int
+
+const (
+	DirichletSmoothing     Smoothing = iota // Bayesian smoothing with a Dirichlet prior, with parameter mu
+	JelinekMercerSmoothing                  // Linear interpolation, with parameter lambda
+)
+
+// String returns the name of the smoothing method.
+func (s Smoothing) String() string {
+	switch s {
+	case DirichletSmoothing:
+		return "Dirichlet"
+	case JelinekMercerSmoothing:
+		return "JelinekMercer"
+	default:
+		return "unknown"
+	}
+}
+
+// QueryLikelihood is a language model scorer: documents are ranked by the likelihood of
+// the query under their language model, smoothed with the language model of the corpus.
+// It uses the same index and statistics as the BM25 variants, so their rankings can be
+// compared and fused directly.
+//
+// Like Lucene, it scores documents with the rank-equivalent sum over the matching query
+// terms of log(1 + tf / (mu p(t|C))) + log(mu / (dl + mu)) for Dirichlet smoothing,
+// floored at 0 per term, and log(1 + (1 - lambda) tf / dl / (lambda p(t|C))) for
+// Jelinek-Mercer smoothing, where p(t|C) is the fraction of the tokens of the corpus that
+// are the term. Documents matching none of the query terms score 0.
+type QueryLikelihood struct {
+	*Bm25Base
+	smoothing Smoothing
+	param     float64 // mu for Dirichlet smoothing, lambda for Jelinek-Mercer smoothing
+}
+
+// NewQLDirichlet creates a QueryLikelihood index with Dirichlet smoothing. Values of mu
+// around the average document length, or up to 2000 for long documents, work well.
+func NewQLDirichlet(corpus []string, tokenizer func(string) []string, mu float64, logger *log.Logger) (*QueryLikelihood, error) {
+	return newQueryLikelihood(corpus, tokenizer, DirichletSmoothing, mu, logger)
+}
+
+// NewQLJelinekMercer creates a QueryLikelihood index with Jelinek-Mercer smoothing. Values
+// of lambda around 0.1 work well for short queries, and around 0.7 for long ones.
+func NewQLJelinekMercer(corpus []string, tokenizer func(string) []string, lambda float64, logger *log.Logger) (*QueryLikelihood, error) {
+	return newQueryLikelihood(corpus, tokenizer, JelinekMercerSmoothing, lambda, logger)
+}
+
+// newQueryLikelihood creates a QueryLikelihood index with the given smoothing.
+func newQueryLikelihood(corpus []string, tokenizer func(string) []string, smoothing Smoothing, param float64, logger *log.Logger) (*QueryLikelihood, error) {
+	if err := validateQueryLikelihoodParams(smoothing, param); err != nil {
+		return nil, err
+	}
+
+	base, err := NewBM25Base(corpus, tokenizer, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewQueryLikelihoodFromBase(base, smoothing, param)
+}
+
+// NewQueryLikelihoodFromBase creates a QueryLikelihood index on top of an existing
+// Bm25Base. param is mu for Dirichlet smoothing and lambda for Jelinek-Mercer smoothing.
+func NewQueryLikelihoodFromBase(base *Bm25Base, smoothing Smoothing, param float64) (*QueryLikelihood, error) {
+	if base == nil {
+		return nil, ErrNilBase
+	}
+
+	if err := validateQueryLikelihoodParams(smoothing, param); err != nil {
+		return nil, err
+	}
+
+	return &QueryLikelihood{
+		Bm25Base:  base,
+		smoothing: smoothing,
+		param:     param,
+	}, nil
+}
+
+// QueryLikelihoodParamSpecs returns the specs of the parameters of a QueryLikelihood index
+// with the given smoothing.
+func QueryLikelihoodParamSpecs(smoothing Smoothing) []ParamSpec {
+	if smoothing == JelinekMercerSmoothing {
+		return []ParamSpec{{
+			Name:         "lambda",
+			Default:      0.1,
+			Min:          0,
+			Max:          1,
+			ExclusiveMax: true,
+			Description:  "Weight of the corpus language model. Must be positive; at 1, the documents would be ignored.",
+		}}
+	}
+	return []ParamSpec{{
+		Name:        "mu",
+		Default:     2000,
+		Min:         0,
+		Max:         math.Inf(1),
+		Description: "Dirichlet prior, in tokens, of the corpus language model. Must be positive.",
+	}}
+}
+
+// validateQueryLikelihoodParams validates the smoothing and parameter of a
+// QueryLikelihood index.
+func validateQueryLikelihoodParams(smoothing Smoothing, param float64) error {
+	if smoothing < DirichletSmoothing || smoothing > JelinekMercerSmoothing {
+		return invalidParam("smoothing", smoothing, "must be DirichletSmoothing or JelinekMercerSmoothing")
+	}
+	spec := QueryLikelihoodParamSpecs(smoothing)[0]
+	if err := spec.Validate(param); err != nil {
+		return err
+	}
+	if param == 0 {
+		return invalidParam(spec.Name, param, "must be positive")
+	}
+	return nil
+}
+
+// Smoothing returns the smoothing method of the index.
+func (q *QueryLikelihood) Smoothing() Smoothing {
+	return q.smoothing
+}
+
+// Params returns the parameters of the index.
+func (q *QueryLikelihood) Params() map[string]float64 {
+	return map[string]float64{
+		q.ParamSpecs()[0].Name: q.param,
+	}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (q *QueryLikelihood) ParamSpecs() []ParamSpec {
+	return QueryLikelihoodParamSpecs(q.smoothing)
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params.
+func (q *QueryLikelihood) withParams(params map[string]float64) (BM25, error) {
+	overridden, err := NewQueryLikelihoodFromBase(q.Bm25Base, q.smoothing, params[q.ParamSpecs()[0].Name])
+	if err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
+// collectionProbability returns the smoothed probability p(t|C) of a term in the language
+// model of the corpus, or false if the term is in no document.
+func (q *QueryLikelihood) collectionProbability(term string) (float64, bool) {
+	freq := q.collectionFrequency(term)
+	if freq == 0 {
+		return 0, false
+	}
+	totalTokens := q.avgDocLen * float64(q.corpusSize)
+	return (freq + 1) / (totalTokens + 1), true
+}
+
+// termScore returns the score of a term occurring tf times in a document of the given
+// length, given the probability of the term in the corpus.
+func (q *QueryLikelihood) termScore(tf float64, docLen int, collectionProb float64) float64 {
+	if tf == 0 || docLen == 0 {
+		return 0
+	}
+	if q.smoothing == JelinekMercerSmoothing {
+		return math.Log1p((1 - q.param) * tf / float64(docLen) / (q.param * collectionProb))
+	}
+	score := math.Log1p(tf/(q.param*collectionProb)) + math.Log(q.param/(float64(docLen)+q.param))
+	return math.Max(score, 0)
+}
+
+// GetScores returns the query likelihood scores for the given query.
+func (q *QueryLikelihood) GetScores(query []string) ([]float64, error) {
+	return q.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (q *QueryLikelihood) GetScoresInto(dst []float64, query []string) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	scores := q.scoreBuffer(dst)
+	for _, term := range query {
+		if q.isStopword(term) {
+			continue
+		}
+
+		collectionProb, ok := q.collectionProbability(term)
+		if !ok {
+			q.logf(LogDebug, "Term '%s' is not in the corpus", term)
+			continue
+		}
+		weight := q.termWeight(term)
+
+		for i, docLen := range q.docLengths {
+			scores[i] += weight * q.termScore(q.termFrequency(i, term), docLen, collectionProb)
+		}
+	}
+
+	q.excludeExpired(scores, nil)
+	return scores, nil
+}
+
+// GetBatchScores returns the query likelihood scores for the given query and a subset of
+// documents.
+func (q *QueryLikelihood) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if err := q.validateDocIDs(docIDs); err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(docIDs))
+	for _, term := range query {
+		if q.isStopword(term) {
+			continue
+		}
+
+		collectionProb, ok := q.collectionProbability(term)
+		if !ok {
+			q.logf(LogDebug, "Term '%s' is not in the corpus", term)
+			continue
+		}
+		weight := q.termWeight(term)
+
+		for i, docID := range docIDs {
+			scores[i] += weight * q.termScore(q.termFrequency(docID, term), q.docLengths[docID], collectionProb)
+		}
+	}
+
+	q.excludeExpired(scores, docIDs)
+	return scores, nil
+}
+
+// GetTopN returns the top N documents for the given query.
+func (q *QueryLikelihood) GetTopN(query []string, n int) ([]string, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if n <= 0 {
+		q.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
+		return []string{}, nil
+	}
+
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := q.GetScoresInto(*buf, query)
+	if err != nil {
+		return nil, err
+	}
+	*buf = scores
+
+	topNIndices, err := TopNIndices(scores, n)
+	if err != nil {
+		return nil, err
+	}
+
+	topDocs := make([]string, len(topNIndices))
+	for i, idx := range topNIndices {
+		doc, err := q.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
+	}
+
+	return topDocs, nil
+}
+
+// Search runs the given search request against the index.
+func (q *QueryLikelihood) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return q.search(ctx, q, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (q *QueryLikelihood) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return q.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the QueryLikelihood instance.
+func (q *QueryLikelihood) Clone() *QueryLikelihood {
+	clone := *q
+	clone.Bm25Base = q.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the QueryLikelihood instance that is safe for concurrent use.
+func (q *QueryLikelihood) Freeze() *QueryLikelihood {
+	frozen := *q
+	frozen.Bm25Base = q.Bm25Base.Freeze()
+	return &frozen
+}
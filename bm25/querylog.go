@@ -0,0 +1,151 @@
+package bm25
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryLogEntry records a single search.
+type QueryLogEntry struct {
+	Time     time.Time
+	Query    []string
+	Took     time.Duration
+	Results  int
+	TopScore float64 // Raw score of the best result, before normalization
+	Slow     bool    // Whether the search took at least the slow query threshold
+	Err      error
+}
+
+// QueryLogSink receives the entries of a query log. Sinks are called synchronously from
+// Search, possibly by several goroutines at once, so they should be fast and safe for
+// concurrent use.
+type QueryLogSink interface {
+	LogQuery(entry QueryLogEntry)
+}
+
+// QueryLogSinkFunc adapts a function to the QueryLogSink interface.
+type QueryLogSinkFunc func(entry QueryLogEntry)
+
+// LogQuery calls f(entry).
+func (f QueryLogSinkFunc) LogQuery(entry QueryLogEntry) {
+	f(entry)
+}
+
+// QueryLog configures the logging of the searches run against an index.
+type QueryLog struct {
+	// Sink, if set, receives an entry for every search.
+	Sink QueryLogSink
+
+	// SlowSink, if set, receives an entry for every search that took at least
+	// SlowThreshold, e.g. to alert on latency regressions.
+	SlowSink      QueryLogSink
+	SlowThreshold time.Duration
+}
+
+// SetQueryLog enables logging of the searches run against the index. Passing a nil
+// QueryLog disables it again.
+func (b *Bm25Base) SetQueryLog(queryLog *QueryLog) {
+	b.queryLog = queryLog
+}
+
+// logQuery records a search in the query log, if one is configured, and logs a summary
+// line if the log level is at least LogQueries.
+func (b *Bm25Base) logQuery(query []string, start time.Time, resp *SearchResponse, topScore float64, err error) {
+	summary := b.logEnabled(LogQueries)
+	if b.queryLog == nil && !summary {
+		return
+	}
+
+	entry := QueryLogEntry{
+		Time:     start,
+		Query:    query,
+		Took:     time.Since(start),
+		TopScore: topScore,
+		Err:      err,
+	}
+	if resp != nil {
+		entry.Took = resp.Took
+		entry.Results = len(resp.Results)
+	}
+	if b.queryLog != nil {
+		entry.Slow = b.queryLog.SlowThreshold > 0 && entry.Took >= b.queryLog.SlowThreshold
+	}
+	if summary {
+		logQueryEntry(b.logger, entry)
+	}
+	if b.queryLog == nil {
+		return
+	}
+	if b.queryLog.Sink != nil {
+		b.queryLog.Sink.LogQuery(entry)
+	}
+	if entry.Slow && b.queryLog.SlowSink != nil {
+		b.queryLog.SlowSink.LogQuery(entry)
+	}
+}
+
+// NewLoggerSink creates a QueryLogSink writing one line per search to the given logger.
+func NewLoggerSink(logger *log.Logger) QueryLogSink {
+	return QueryLogSinkFunc(func(entry QueryLogEntry) {
+		logQueryEntry(logger, entry)
+	})
+}
+
+// logQueryEntry writes a query log entry to the logger as a single line.
+func logQueryEntry(logger *log.Logger, entry QueryLogEntry) {
+	prefix := "Query"
+	if entry.Slow {
+		prefix = "Slow query"
+	}
+	if entry.Err != nil {
+		logger.Printf("%s '%s' failed after %s: %v", prefix, strings.Join(entry.Query, " "), entry.Took, entry.Err)
+		return
+	}
+	logger.Printf("%s '%s' took %s, %d results, top score %.4f", prefix, strings.Join(entry.Query, " "), entry.Took, entry.Results, entry.TopScore)
+}
+
+// NewJSONSink creates a QueryLogSink writing one JSON object per search to the given
+// writer, for ingestion by log pipelines. Writes are serialized.
+func NewJSONSink(w io.Writer) QueryLogSink {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return QueryLogSinkFunc(func(entry QueryLogEntry) {
+		record := struct {
+			Time     time.Time `json:"time"`
+			Query    []string  `json:"query"`
+			TookMS   float64   `json:"took_ms"`
+			Results  int       `json:"results"`
+			TopScore float64   `json:"top_score"`
+			Slow     bool      `json:"slow,omitempty"`
+			Error    string    `json:"error,omitempty"`
+		}{
+			Time:     entry.Time,
+			Query:    entry.Query,
+			TookMS:   float64(entry.Took) / float64(time.Millisecond),
+			Results:  entry.Results,
+			TopScore: entry.TopScore,
+			Slow:     entry.Slow,
+		}
+		if entry.Err != nil {
+			record.Error = entry.Err.Error()
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(record) // A failing sink must not fail the search
+	})
+}
+
+// MultiSink creates a QueryLogSink forwarding every entry to all of the given sinks, e.g.
+// to both a logger and an Analytics collector.
+func MultiSink(sinks ...QueryLogSink) QueryLogSink {
+	return QueryLogSinkFunc(func(entry QueryLogEntry) {
+		for _, sink := range sinks {
+			sink.LogQuery(entry)
+		}
+	})
+}
@@ -0,0 +1,381 @@
+package bm25
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+)
+
+// RankBM25Variant selects the class of Python's rank_bm25 package a RankBM25 index
+// reproduces.
+type RankBM25Variant int
+
+const (
+	RankBM25Okapi RankBM25Variant = iota // rank_bm25.BM25Okapi
+	RankBM25L                            // rank_bm25.BM25L
+	RankBM25Plus                         // rank_bm25.BM25Plus
+)
+
+// String returns the name of the rank_bm25 class.
+func (v RankBM25Variant) String() string {
+	switch v {
+	case RankBM25Okapi:
+		return "BM25Okapi"
+	case RankBM25L:
+		return "BM25L"
+	case RankBM25Plus:
+		return "BM25Plus"
+	default:
+		return "unknown"
+	}
+}
+
+// RankBM25 is a compatibility mode whose scores match those of Python's rank_bm25 package
+// (version 0.2.2) on the same tokenized corpus and query, so pipelines ported from Python
+// can be diffed exactly. It differs from the native variants in the following ways:
+//
+//   - The IDF of BM25Okapi is log(N - n + 0.5) - log(n + 0.5), which is negative for terms
+//     in more than half of the documents. Negative values are replaced by epsilon times
+//     the average IDF over the vocabulary, including the negative values. BM25L uses
+//     log(N + 1) - log(n + 0.5) and BM25Plus log(N + 1) - log(n).
+//   - BM25L and BM25Plus use the term frequency formulas of rank_bm25.
+//   - Term frequencies are raw counts; the saturation function, stopwords and term
+//     weights of the index are not applied.
+//   - GetTopN breaks ties like numpy.argsort(scores)[::-1] with a stable sort: among
+//     documents with equal scores, the one added last comes first. numpy's default sort
+//     is not stable, so ties in rank_bm25 itself only match with kind="stable".
+//
+// Repeated query terms are counted once per occurrence, as in rank_bm25. Empty queries
+// return ErrEmptyQuery instead of zero scores. Search runs the regular search pipeline on
+// top of these scores.
+type RankBM25 struct {
+	*Bm25Base
+	variant RankBM25Variant
+	k1      float64
+	b       float64
+	param   float64 // epsilon for BM25Okapi, delta otherwise
+}
+
+// NewRankBM25Okapi creates a RankBM25 index scoring like rank_bm25.BM25Okapi, whose
+// defaults are k1 = 1.5, b = 0.75 and epsilon = 0.25.
+func NewRankBM25Okapi(corpus []string, tokenizer func(string) []string, k1 float64, b float64, epsilon float64, logger *log.Logger) (*RankBM25, error) {
+	return newRankBM25(corpus, tokenizer, RankBM25Okapi, k1, b, epsilon, logger)
+}
+
+// NewRankBM25L creates a RankBM25 index scoring like rank_bm25.BM25L, whose defaults are
+// k1 = 1.5, b = 0.75 and delta = 0.5.
+func NewRankBM25L(corpus []string, tokenizer func(string) []string, k1 float64, b float64, delta float64, logger *log.Logger) (*RankBM25, error) {
+	return newRankBM25(corpus, tokenizer, RankBM25L, k1, b, delta, logger)
+}
+
+// NewRankBM25Plus creates a RankBM25 index scoring like rank_bm25.BM25Plus, whose
+// defaults are k1 = 1.5, b = 0.75 and delta = 1.
+func NewRankBM25Plus(corpus []string, tokenizer func(string) []string, k1 float64, b float64, delta float64, logger *log.Logger) (*RankBM25, error) {
+	return newRankBM25(corpus, tokenizer, RankBM25Plus, k1, b, delta, logger)
+}
+
+// newRankBM25 creates a RankBM25 index on top of a new Bm25Base.
+func newRankBM25(corpus []string, tokenizer func(string) []string, variant RankBM25Variant, k1 float64, b float64, param float64, logger *log.Logger) (*RankBM25, error) {
+	if err := validateRankBM25Params(variant, k1, b, param); err != nil {
+		return nil, err
+	}
+
+	base, err := NewBM25Base(corpus, tokenizer, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRankBM25FromBase(base, variant, k1, b, param)
+}
+
+// NewRankBM25FromBase creates a RankBM25 index on top of an existing Bm25Base. param is
+// epsilon for RankBM25Okapi and delta for the other variants.
+func NewRankBM25FromBase(base *Bm25Base, variant RankBM25Variant, k1 float64, b float64, param float64) (*RankBM25, error) {
+	if base == nil {
+		return nil, ErrNilBase
+	}
+
+	if err := validateRankBM25Params(variant, k1, b, param); err != nil {
+		return nil, err
+	}
+
+	return &RankBM25{
+		Bm25Base: base,
+		variant:  variant,
+		k1:       k1,
+		b:        b,
+		param:    param,
+	}, nil
+}
+
+// RankBM25ParamSpecs returns the specs of the parameters of the given RankBM25 variant.
+func RankBM25ParamSpecs(variant RankBM25Variant) []ParamSpec {
+	param := ParamSpec{
+		Name:        "delta",
+		Default:     0.5,
+		Min:         0,
+		Max:         math.Inf(1),
+		Description: "Constant added to the term frequency component.",
+	}
+	switch variant {
+	case RankBM25Okapi:
+		param = ParamSpec{
+			Name:        "epsilon",
+			Default:     0.25,
+			Min:         0,
+			Max:         math.Inf(1),
+			Description: "Factor of the average IDF that replaces negative IDF values.",
+		}
+	case RankBM25Plus:
+		param.Default = 1
+	}
+	return []ParamSpec{k1ParamSpec, bParamSpec, param}
+}
+
+// validateRankBM25Params validates the variant and parameters of a RankBM25 index.
+func validateRankBM25Params(variant RankBM25Variant, k1 float64, b float64, param float64) error {
+	if variant < RankBM25Okapi || variant > RankBM25Plus {
+		return invalidParam("variant", variant, "must be RankBM25Okapi, RankBM25L or RankBM25Plus")
+	}
+	return validateSpecs(RankBM25ParamSpecs(variant), k1, b, param)
+}
+
+// Variant returns the rank_bm25 class the index reproduces.
+func (r *RankBM25) Variant() RankBM25Variant {
+	return r.variant
+}
+
+// Params returns the parameters of the index.
+func (r *RankBM25) Params() map[string]float64 {
+	specs := r.ParamSpecs()
+	return map[string]float64{
+		specs[0].Name: r.k1,
+		specs[1].Name: r.b,
+		specs[2].Name: r.param,
+	}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (r *RankBM25) ParamSpecs() []ParamSpec {
+	return RankBM25ParamSpecs(r.variant)
+}
+
+// withParams returns a copy of the index with the given parameters, see
+// SearchRequest.Params.
+func (r *RankBM25) withParams(params map[string]float64) (BM25, error) {
+	specs := r.ParamSpecs()
+	overridden, err := NewRankBM25FromBase(r.Bm25Base, r.variant, params[specs[0].Name], params[specs[1].Name], params[specs[2].Name])
+	if err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
+// IDF returns the IDF of the given term as computed by rank_bm25. Terms that are not in
+// the vocabulary have an IDF of 0.
+func (r *RankBM25) IDF(term string) (float64, error) {
+	if term == "" {
+		return 0, ErrEmptyTerm
+	}
+
+	termFreq, ok := r.docFreq(term)
+	if !ok || termFreq == 0 {
+		return 0, nil
+	}
+
+	n := float64(r.corpusSize)
+	switch r.variant {
+	case RankBM25L:
+		return math.Log(n+1) - math.Log(float64(termFreq)+0.5), nil
+	case RankBM25Plus:
+		return math.Log(n+1) - math.Log(float64(termFreq)), nil
+	}
+
+	idf := rankBM25OkapiIDF(r.corpusSize, termFreq)
+	if idf < 0 {
+		idf = r.param * r.rankBM25AverageIDF()
+	}
+	return idf, nil
+}
+
+// rankBM25OkapiIDF computes the IDF of rank_bm25.BM25Okapi of a term appearing in
+// termFreq of corpusSize documents, before negative values are replaced.
+func rankBM25OkapiIDF(corpusSize int, termFreq int) float64 {
+	return math.Log(float64(corpusSize)-float64(termFreq)+0.5) - math.Log(float64(termFreq)+0.5)
+}
+
+// rankBM25AverageIDF returns the average IDF of rank_bm25.BM25Okapi over the vocabulary.
+// The IDF values are summed in the order the terms first appear in the corpus, like
+// rank_bm25 does, so the floating point sum is the same. It is computed on first use and
+// cached, unless the caches are read-only.
+func (b *Bm25Base) rankBM25AverageIDF() float64 {
+	if b.rankAvgIDF != nil {
+		return *b.rankAvgIDF
+	}
+
+	var sum float64
+	var count int
+	seen := make(map[string]struct{})
+	for docID := 0; docID < b.corpusSize; docID++ {
+		forEachDistinct(b.doc(docID), func(token string) {
+			if _, ok := seen[token]; ok {
+				return
+			}
+			seen[token] = struct{}{}
+			if termFreq, ok := b.docFreq(token); ok && termFreq > 0 {
+				sum += rankBM25OkapiIDF(b.corpusSize, termFreq)
+				count++
+			}
+		})
+	}
+	if count > 0 {
+		sum /= float64(count)
+	}
+
+	if b.cachesWritable() {
+		b.rankAvgIDF = &sum
+	}
+	return sum
+}
+
+// GetScores returns the scores of rank_bm25 for the given query.
+func (r *RankBM25) GetScores(query []string) ([]float64, error) {
+	return r.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus.
+func (r *RankBM25) GetScoresInto(dst []float64, query []string) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	scores := r.scoreBuffer(dst)
+	for _, q := range query {
+		if err := r.addTermScores(scores, q, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	r.excludeExpired(scores, nil)
+	return scores, nil
+}
+
+// GetBatchScores returns the scores of rank_bm25 for the given query and a subset of
+// documents.
+func (r *RankBM25) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if err := r.validateDocIDs(docIDs); err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(docIDs))
+	for _, q := range query {
+		if err := r.addTermScores(scores, q, docIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	r.excludeExpired(scores, docIDs)
+	return scores, nil
+}
+
+// addTermScores adds the scores of a query term to the scores of all documents, or of
+// the documents in docIDs if it is not nil. The operations are evaluated in the order
+// rank_bm25 evaluates them, so the results are bit-identical.
+func (r *RankBM25) addTermScores(scores []float64, term string, docIDs []int) error {
+	idf, err := r.IDF(term)
+	if err != nil {
+		return err
+	}
+
+	for i := range scores {
+		docID := i
+		if docIDs != nil {
+			docID = docIDs[i]
+		}
+		tf := r.rawTermFrequency(docID, term)
+		norm := 1 - r.b + r.b*float64(r.docLengths[docID])/r.avgDocLen
+
+		switch r.variant {
+		case RankBM25Okapi:
+			scores[i] += idf * (tf * (r.k1 + 1) / (tf + r.k1*norm))
+		case RankBM25L:
+			ctd := tf / norm
+			scores[i] += idf * (r.k1 + 1) * (ctd + r.param) / (r.k1 + ctd + r.param)
+		case RankBM25Plus:
+			scores[i] += idf * (r.param + (tf*(r.k1+1))/(r.k1*norm+tf))
+		}
+	}
+	return nil
+}
+
+// GetTopN returns the top N documents for the given query, ordered like
+// numpy.argsort(scores, kind="stable")[::-1][:n].
+func (r *RankBM25) GetTopN(query []string, n int) ([]string, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if n <= 0 {
+		r.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
+		return []string{}, nil
+	}
+
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := r.GetScoresInto(*buf, query)
+	if err != nil {
+		return nil, err
+	}
+	*buf = scores
+
+	indices := make([]int, len(scores))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		a, b := indices[i], indices[j]
+		return scores[a] > scores[b] || (scores[a] == scores[b] && a > b)
+	})
+
+	topDocs := make([]string, min(n, len(indices)))
+	for i := range topDocs {
+		doc, err := r.docText(indices[i])
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
+	}
+
+	return topDocs, nil
+}
+
+// Search runs the given search request against the index.
+func (r *RankBM25) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	if r.variant == RankBM25Okapi && r.cachesWritable() {
+		// Compute the average IDF upfront, so terms scored concurrently only read it
+		r.rankBM25AverageIDF()
+	}
+	return r.search(ctx, r, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (r *RankBM25) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return r.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Freeze returns an immutable, read-only copy of the index that is safe for concurrent use.
+func (r *RankBM25) Freeze() *RankBM25 {
+	if r.variant == RankBM25Okapi && r.cachesWritable() {
+		r.rankBM25AverageIDF()
+	}
+	frozen := *r
+	frozen.Bm25Base = r.Bm25Base.Freeze()
+	return &frozen
+}
@@ -0,0 +1,230 @@
+package bm25
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// VariantFactory creates a variant on top of a base, given the values of all of its
+// parameters, with the missing ones set to their defaults, see New.
+type VariantFactory func(base *Bm25Base, params map[string]float64) (BM25, error)
+
+// registeredVariant is a variant that can be created by name.
+type registeredVariant struct {
+	name    string
+	specs   []ParamSpec
+	factory VariantFactory
+}
+
+// variants holds the registered variants by name and alias, see RegisterVariant.
+var variants = struct {
+	sync.RWMutex
+	byName map[string]*registeredVariant
+}{byName: make(map[string]*registeredVariant)}
+
+func init() {
+	builtins := []struct {
+		names   []string
+		specs   []ParamSpec
+		factory VariantFactory
+	}{
+		{[]string{"okapi", "bm25", "bm25okapi"}, BM25OkapiParamSpecs(), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewBM25OkapiFromBase(base, p["k1"], p["b"])
+		}},
+		{[]string{"l", "bm25l"}, BM25LParamSpecs(), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewBM25LFromBase(base, p["k1"], p["b"])
+		}},
+		{[]string{"plus", "bm25plus"}, BM25PlusParamSpecs(), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewBM25PlusFromBase(base, p["k1"], p["b"], p["delta"], p["epsilon"])
+		}},
+		{[]string{"adpt", "bm25adpt"}, BM25AdptParamSpecs(), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewBM25AdptFromBase(base, p["k1"], p["b"], p["delta"])
+		}},
+		{[]string{"adptk1", "bm25adptk1"}, BM25AdptK1ParamSpecs(), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewBM25AdptK1FromBase(base, p["k1"], p["b"])
+		}},
+		{[]string{"t", "bm25t"}, BM25TParamSpecs(), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewBM25TFromBase(base, p["k1"], p["b"], p["delta"])
+		}},
+		{[]string{"tk1", "bm25tk1"}, BM25TK1ParamSpecs(), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewBM25TK1FromBase(base, p["b"])
+		}},
+		{[]string{"lgd"}, LGDParamSpecs(), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewLGDFromBase(base, p["c"])
+		}},
+		{[]string{"pl2"}, PL2ParamSpecs(), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewPL2FromBase(base, p["c"])
+		}},
+		{[]string{"dph"}, DPHParamSpecs(), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewDPHFromBase(base)
+		}},
+		{[]string{"ql", "qldirichlet"}, QueryLikelihoodParamSpecs(DirichletSmoothing), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewQueryLikelihoodFromBase(base, DirichletSmoothing, p["mu"])
+		}},
+		{[]string{"qljm", "qljelinekmercer"}, QueryLikelihoodParamSpecs(JelinekMercerSmoothing), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewQueryLikelihoodFromBase(base, JelinekMercerSmoothing, p["lambda"])
+		}},
+		{[]string{"tfidf"}, TFIDFParamSpecs(), func(base *Bm25Base, p map[string]float64) (BM25, error) {
+			return NewTFIDFFromBase(base)
+		}},
+	}
+	for _, builtin := range builtins {
+		if err := RegisterVariant(builtin.names[0], builtin.specs, builtin.factory, builtin.names[1:]...); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterVariant registers a variant, so New, config files and anything else selecting
+// variants by name can create it. Names are case-insensitive, and the aliases are
+// alternative names of the variant, e.g. "bm25plus" for "plus". Registering a name twice
+// is an error.
+func RegisterVariant(name string, specs []ParamSpec, factory VariantFactory, aliases ...string) error {
+	if factory == nil {
+		return invalidParam("factory", factory, "cannot be nil")
+	}
+
+	names := append([]string{name}, aliases...)
+	for i, n := range names {
+		if n = strings.ToLower(n); n == "" {
+			return invalidParam("name", n, "cannot be empty")
+		}
+		names[i] = n
+	}
+
+	variants.Lock()
+	defer variants.Unlock()
+	for _, n := range names {
+		if _, ok := variants.byName[n]; ok {
+			return invalidParam("name", n, "is already registered")
+		}
+	}
+	variant := &registeredVariant{name: names[0], specs: slices.Clone(specs), factory: factory}
+	for _, n := range names {
+		variants.byName[n] = variant
+	}
+	return nil
+}
+
+// Variants returns the sorted names of the registered variants, without their aliases.
+func Variants() []string {
+	variants.RLock()
+	defer variants.RUnlock()
+	var names []string
+	for name, variant := range variants.byName {
+		if name == variant.name {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// lookupVariant returns the registered variant with the given name or alias.
+func lookupVariant(name string) (*registeredVariant, error) {
+	variants.RLock()
+	defer variants.RUnlock()
+	variant, ok := variants.byName[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownVariant, name)
+	}
+	return variant, nil
+}
+
+// VariantName returns the name a registered variant was registered with, given its name
+// or one of its aliases in any case, e.g. "plus" for "BM25Plus".
+func VariantName(name string) (string, error) {
+	variant, err := lookupVariant(name)
+	if err != nil {
+		return "", err
+	}
+	return variant.name, nil
+}
+
+// VariantParamSpecs returns the specs of the parameters of the registered variant with
+// the given name or alias.
+func VariantParamSpecs(name string) ([]ParamSpec, error) {
+	variant, err := lookupVariant(name)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(variant.specs), nil
+}
+
+// Option configures the index created by New.
+type Option func(*options)
+
+// options holds the options of New.
+type options struct {
+	base      *Bm25Base
+	corpus    []string
+	tokenizer func(string) []string
+	logger    *log.Logger
+	params    map[string]float64
+}
+
+// WithBase creates the variant on top of an existing Bm25Base, e.g. one built with a
+// Builder or read from a snapshot, instead of indexing a corpus.
+func WithBase(base *Bm25Base) Option {
+	return func(o *options) {
+		o.base = base
+	}
+}
+
+// WithCorpus indexes the given corpus with the tokenizer.
+func WithCorpus(corpus []string, tokenizer func(string) []string) Option {
+	return func(o *options) {
+		o.corpus = corpus
+		o.tokenizer = tokenizer
+	}
+}
+
+// WithLogger sets the logger of the index created from a corpus.
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithParams sets parameters of the variant. Parameters missing from params keep their
+// default values, and unknown parameters are an error. Repeated options are merged.
+func WithParams(params map[string]float64) Option {
+	return func(o *options) {
+		if o.params == nil {
+			o.params = make(map[string]float64, len(params))
+		}
+		maps.Copy(o.params, params)
+	}
+}
+
+// New creates the registered variant with the given name or alias, e.g. "bm25plus", on
+// top of the base given with WithBase or the corpus given with WithCorpus.
+func New(name string, opts ...Option) (BM25, error) {
+	variant, err := lookupVariant(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := ValidateParams(variant.specs, o.params); err != nil {
+		return nil, err
+	}
+
+	base := o.base
+	if base == nil {
+		if o.corpus == nil {
+			return nil, fmt.Errorf("%w: New requires WithBase or WithCorpus", ErrEmptyCorpus)
+		}
+		if base, err = NewBM25Base(o.corpus, o.tokenizer, o.logger); err != nil {
+			return nil, err
+		}
+	}
+	return variant.factory(base, ParamsWithDefaults(variant.specs, o.params))
+}
@@ -0,0 +1,227 @@
+package bm25
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrOplogTruncated is returned when the oplog entries a replica asks for have been
+	// discarded by the primary. The replica has to be bootstrapped from a new snapshot.
+	ErrOplogTruncated = errors.New("oplog entries have been discarded")
+
+	// ErrOplogGap is returned when a replica is given oplog entries that do not follow the
+	// last entry it applied.
+	ErrOplogGap = errors.New("oplog entries do not follow the applied ones")
+)
+
+// OplogEntry is a batch of documents added to a primary, shipped to its replicas.
+// Entries are numbered from 1 in the order they were applied. The TTL of a document runs
+// from the time it is added to every index, so it expires slightly later on replicas.
+type OplogEntry struct {
+	Seq  uint64     `json:"seq"`
+	Time time.Time  `json:"time"`
+	Docs []Document `json:"docs"`
+}
+
+// OplogBatch is a batch of oplog entries returned by a primary, with the position of the
+// primary at the time, so replicas can tell how far behind they are.
+type OplogBatch struct {
+	Entries []OplogEntry `json:"entries"`
+	Head    uint64       `json:"head"` // Sequence number of the last entry of the primary
+
+	// Pending is the time of the first entry following the batch, if the batch does not
+	// reach the head, i.e. of the oldest write a replica applying it still misses.
+	Pending time.Time `json:"pending"`
+}
+
+// Primary is the writer of a replicated index. It adds documents to a CopyOnWriteIndex
+// and records every batch in an in-memory oplog, which replicas poll to stay up to date.
+// A new replica is bootstrapped from a snapshot taken with WriteSnapshot, and then
+// applies the entries following it. Only document additions are replicated: changes made
+// with Update, e.g. settings or compactions, require bootstrapping the replicas again. It
+// is safe for concurrent use.
+type Primary struct {
+	mu      sync.Mutex // Serializes writers and snapshots with the oplog
+	index   *CopyOnWriteIndex
+	entries []OplogEntry
+	retain  int
+	head    uint64
+}
+
+// NewPrimary creates a new Primary writing to the given index and retaining the last
+// retain oplog entries. Replicas falling further behind have to be bootstrapped again.
+func NewPrimary(index *CopyOnWriteIndex, retain int) (*Primary, error) {
+	if index == nil {
+		return nil, ErrNilBase
+	}
+	if retain <= 0 {
+		return nil, invalidParam("retain", retain, "must be a positive integer")
+	}
+
+	return &Primary{index: index, retain: retain}, nil
+}
+
+// Index returns the index written by the primary.
+func (p *Primary) Index() *CopyOnWriteIndex {
+	return p.index
+}
+
+// Add adds a batch of documents to the index, like CopyOnWriteIndex.Add, and records the
+// documents that were added in the oplog.
+func (p *Primary) Add(docs ...Document) ([]int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	docIDs, err := p.index.Add(docs...)
+	if len(docIDs) > 0 {
+		p.head++
+		p.entries = append(p.entries, OplogEntry{
+			Seq:  p.head,
+			Time: time.Now(),
+			Docs: append([]Document(nil), docs[:len(docIDs)]...),
+		})
+		if len(p.entries) > p.retain {
+			p.entries = append(p.entries[:0:0], p.entries[len(p.entries)-p.retain:]...)
+		}
+	}
+	return docIDs, err
+}
+
+// Entries returns at most limit oplog entries following the entry after, or all of them
+// if limit is not positive. It returns ErrOplogTruncated if some of them have been
+// discarded.
+func (p *Primary) Entries(after uint64, limit int) (OplogBatch, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	batch := OplogBatch{Head: p.head}
+	if after > p.head {
+		return batch, invalidParam("after", after, fmt.Sprintf("must be at most the head %d", p.head))
+	}
+	if after == p.head {
+		return batch, nil
+	}
+	if len(p.entries) == 0 || p.entries[0].Seq > after+1 {
+		return batch, ErrOplogTruncated
+	}
+
+	entries := p.entries[after+1-p.entries[0].Seq:]
+	if limit > 0 && len(entries) > limit {
+		batch.Pending = entries[limit].Time
+		entries = entries[:limit]
+	}
+	batch.Entries = append([]OplogEntry(nil), entries...)
+	return batch, nil
+}
+
+// WriteSnapshot writes a snapshot of the published index, see Bm25Base.WriteSnapshot, and
+// returns the sequence number of the last oplog entry it contains, from which a replica
+// restored from it has to resume.
+func (p *Primary) WriteSnapshot(w io.Writer) (uint64, error) {
+	p.mu.Lock()
+	current, head := p.index.Current(), p.head
+	p.mu.Unlock()
+
+	// The published version is frozen, so it can be written without holding the lock
+	return head, current.(documentAdder).baseIndex().WriteSnapshot(w)
+}
+
+// ReplicationLag tells how far a replica is behind its primary, as of its last sync.
+type ReplicationLag struct {
+	// Entries is the number of oplog entries the replica has not applied yet.
+	Entries uint64
+
+	// Behind is the age of the oldest write the replica has not applied, at its last
+	// sync, or 0 if the replica was up to date.
+	Behind time.Duration
+
+	// LastSync is the time the replica last applied a batch, so the lag may have grown
+	// since if the primary kept writing.
+	LastSync time.Time
+}
+
+// Replica is a read replica of a Primary. It serves searches from a CopyOnWriteIndex and
+// applies the oplog batches polled from the primary, in order. It is safe for concurrent
+// use.
+type Replica struct {
+	mu      sync.Mutex // Serializes the application of batches
+	index   *CopyOnWriteIndex
+	applied uint64
+	lag     ReplicationLag
+}
+
+// NewReplica creates a new Replica from an index restored from a snapshot of the primary,
+// e.g. with ReadSnapshot and the constructor of the variant of the primary, and the
+// sequence number returned by Primary.WriteSnapshot. The index must not be used directly
+// anymore afterwards.
+func NewReplica[T freezableIndex[T]](index T, seq uint64) (*Replica, error) {
+	cow, err := NewCopyOnWriteIndex(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replica{index: cow, applied: seq}, nil
+}
+
+// Index returns the index of the replica, to search it.
+func (r *Replica) Index() *CopyOnWriteIndex {
+	return r.index
+}
+
+// Applied returns the sequence number of the last oplog entry applied by the replica,
+// to be passed to Primary.Entries on the next poll.
+func (r *Replica) Applied() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.applied
+}
+
+// Apply applies a batch of oplog entries polled from the primary and publishes their
+// documents together. Entries that have already been applied are skipped, so a batch can
+// be retried. It returns ErrOplogGap if the entries do not follow the last applied one.
+// If the documents cannot be added, which only happens if the replica has diverged from
+// the primary, it has to be bootstrapped again.
+func (r *Replica) Apply(batch OplogBatch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var docs []Document
+	applied := r.applied
+	for _, entry := range batch.Entries {
+		if entry.Seq <= applied {
+			continue
+		}
+		if entry.Seq != applied+1 {
+			return fmt.Errorf("%w: got entry %d after entry %d", ErrOplogGap, entry.Seq, applied)
+		}
+		docs = append(docs, entry.Docs...)
+		applied = entry.Seq
+	}
+
+	if len(docs) > 0 {
+		if _, err := r.index.Add(docs...); err != nil {
+			return err
+		}
+	}
+	r.applied = applied
+
+	r.lag = ReplicationLag{LastSync: time.Now()}
+	if batch.Head > r.applied {
+		r.lag.Entries = batch.Head - r.applied
+		if !batch.Pending.IsZero() {
+			r.lag.Behind = r.lag.LastSync.Sub(batch.Pending)
+		}
+	}
+	return nil
+}
+
+// Lag returns how far the replica was behind the primary at its last sync.
+func (r *Replica) Lag() ReplicationLag {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lag
+}
@@ -0,0 +1,179 @@
+package bm25
+
+import (
+	"context"
+	"sort"
+)
+
+// retrieveDepthFactor is the number of postings per requested candidate that Retrieve
+// reads from the impact-ordered posting list of every query term.
+const retrieveDepthFactor = 4
+
+// Candidate is a document returned by Retrieve, with its approximate score, or by
+// Rescore, with its exact score.
+type Candidate struct {
+	DocID int
+	Score float64
+}
+
+// RescoreOptions configures Rescore.
+type RescoreOptions struct {
+	// Index applies its exact scoring model to the candidates. It must be built on the
+	// same base the candidates were retrieved from, usually it is the variant Retrieve
+	// was called on.
+	Index BM25
+
+	// Query holds the tokenized query terms.
+	Query []string
+
+	// N is the maximum number of candidates to return. Values below 1 return all of them.
+	N int
+}
+
+// posting is an entry of an impact-ordered posting list: a document and the saturated,
+// length-normalized frequency of the term in it.
+type posting struct {
+	docID  int
+	impact float32
+}
+
+// impactIndex holds a posting list per term, with the highest impacts first.
+type impactIndex struct {
+	postings map[string][]posting
+}
+
+// Retrieve returns up to k candidate documents for the query, ranked by an approximate
+// score. Only the highest impact postings of every query term are read, so documents
+// that match many query terms weakly may be missed; Rescore then applies the exact
+// scoring model to the candidates. Impacts are computed with the default k1 and b,
+// independent of the variant.
+//
+// The impact index is built on the first call. Frozen and shared indexes do not cache
+// it, so call Retrieve once before freezing or sharing an index to build it up front.
+func (b *Bm25Base) Retrieve(ctx context.Context, query []string, k int) ([]Candidate, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if k <= 0 {
+		return nil, invalidParam("k", k, "must be a positive integer")
+	}
+
+	index := b.impactIndex()
+	depth := k * retrieveDepthFactor
+	scores := make(map[int]float64)
+	for _, q := range query {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if b.isStopword(q) {
+			continue
+		}
+
+		idf, err := b.queryIDF(q)
+		if err != nil {
+			b.logf(LogDebug, "Error calculating IDF for term '%s': %v", q, err)
+			continue
+		}
+
+		postings := index.postings[q]
+		for _, p := range postings[:Min(depth, len(postings))] {
+			if !b.Expired(p.docID) {
+				scores[p.docID] += idf * float64(p.impact)
+			}
+		}
+	}
+
+	candidates := make([]Candidate, 0, len(scores))
+	for docID, score := range scores {
+		candidates = append(candidates, Candidate{DocID: docID, Score: score})
+	}
+	sortCandidatesByScore(candidates)
+	return candidates[:Min(k, len(candidates))], nil
+}
+
+// Rescore scores the candidates with the exact scoring model of opts.Index and returns
+// them ranked by their exact score, which is the score GetScores gives them.
+func (b *Bm25Base) Rescore(candidates []Candidate, opts RescoreOptions) ([]Candidate, error) {
+	if opts.Index == nil {
+		return nil, ErrNilBase
+	}
+
+	if len(candidates) == 0 {
+		return []Candidate{}, nil
+	}
+
+	docIDs := make([]int, len(candidates))
+	for i, candidate := range candidates {
+		docIDs[i] = candidate.DocID
+	}
+
+	scores, err := opts.Index.GetBatchScores(opts.Query, docIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rescored := make([]Candidate, len(candidates))
+	for i, docID := range docIDs {
+		rescored[i] = Candidate{DocID: docID, Score: scores[i]}
+	}
+	sortCandidatesByScore(rescored)
+
+	if opts.N > 0 {
+		rescored = rescored[:Min(opts.N, len(rescored))]
+	}
+	return rescored, nil
+}
+
+// sortCandidatesByScore sorts candidates by descending score, breaking ties by document ID.
+func sortCandidatesByScore(candidates []Candidate) {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].DocID < candidates[j].DocID
+	})
+}
+
+// impactIndex returns the impact-ordered posting lists of the corpus, building them if
+// they are not cached.
+func (b *Bm25Base) impactIndex() *impactIndex {
+	if b.impacts != nil {
+		return b.impacts
+	}
+
+	k1, bNorm := k1ParamSpec.Default, bParamSpec.Default
+	index := &impactIndex{postings: make(map[string][]posting)}
+	termFreqs := make(map[string]float64)
+	for docID := range b.corpus {
+		clear(termFreqs)
+		payloads := b.payloads[docID]
+		for i, token := range b.doc(docID) {
+			if payloads != nil {
+				termFreqs[token] += payloads[i]
+			} else {
+				termFreqs[token]++
+			}
+		}
+
+		k := k1 * (1 - bNorm + bNorm*float64(b.docLengths[docID])/b.avgDocLen)
+		for term, termFreq := range termFreqs {
+			tf := b.saturate(termFreq)
+			index.postings[term] = append(index.postings[term], posting{docID: docID, impact: float32(tf / (tf + k))})
+		}
+	}
+
+	for _, postings := range index.postings {
+		sort.Slice(postings, func(i, j int) bool {
+			if postings[i].impact != postings[j].impact {
+				return postings[i].impact > postings[j].impact
+			}
+			return postings[i].docID < postings[j].docID
+		})
+	}
+
+	if b.cachesWritable() {
+		b.impacts = index
+	}
+	return index
+}
@@ -0,0 +1,81 @@
+package bm25
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Sample builds a smaller index from a reproducible sample of the corpus, e.g. to run
+// parameter sweeps on 5% of a large corpus. The documents are sorted by length and split
+// into as many equally sized strata as the sample has documents, one of which is drawn
+// at random from each stratum. This keeps the document length distribution of the
+// corpus, and draws every document with the same probability, so the document frequency
+// of a term relative to the corpus size is preserved in expectation.
+//
+// The same seed always yields the same sample. The sample keeps the external IDs,
+// metadata and excluded stopwords of the documents; Sample also returns the IDs of the
+// sampled documents in the original index, in their order in the sample.
+func (b *Bm25Base) Sample(fraction float64, seed int64) (*Bm25Base, []int, error) {
+	if fraction <= 0 || fraction > 1 {
+		return nil, nil, invalidParam("fraction", fraction, "must be between 0 (exclusive) and 1")
+	}
+
+	docIDs := make([]int, b.corpusSize)
+	for i := range docIDs {
+		docIDs[i] = i
+	}
+	sort.SliceStable(docIDs, func(i, j int) bool {
+		return b.docLengths[docIDs[i]] < b.docLengths[docIDs[j]]
+	})
+
+	rng := rand.New(rand.NewSource(seed))
+	n := max(1, int(math.Round(fraction*float64(b.corpusSize))))
+	step := float64(b.corpusSize) / float64(n)
+	sampled := make([]int, n)
+	for i := range sampled {
+		lo, hi := int(float64(i)*step), int(float64(i+1)*step)
+		sampled[i] = docIDs[lo+rng.Intn(max(1, hi-lo))]
+	}
+	sort.Ints(sampled)
+
+	builder, err := NewBuilder(b.tokenizer, b.logger, BuildOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, docID := range sampled {
+		doc := Document{ID: b.ExternalID(docID), Metadata: b.Metadata(docID)}
+		if _, err := builder.AddTokens(b.doc(docID), doc); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sample, err := builder.Build()
+	if err != nil {
+		return nil, nil, err
+	}
+	sample.stopwords = b.stopwords
+	sample.termWeights = b.termWeights
+	sample.saturation = b.saturation
+	sample.epsilon, sample.epsilonSet = b.epsilon, b.epsilonSet
+
+	return sample, sampled, nil
+}
+
+// SampleQueries returns a reproducible random sample of n queries, without replacement
+// and in their original order. If there are at most n queries, all of them are returned.
+func SampleQueries(queries [][]string, n int, seed int64) [][]string {
+	if n >= len(queries) {
+		return append([][]string(nil), queries...)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	indices := rng.Perm(len(queries))[:max(n, 0)]
+	sort.Ints(indices)
+
+	sample := make([][]string, len(indices))
+	for i, idx := range indices {
+		sample[i] = queries[idx]
+	}
+	return sample
+}
@@ -0,0 +1,97 @@
+package bm25
+
+import "math"
+
+// Saturation transforms the raw frequency of a term in a document before the k1 curve
+// of a variant saturates it. Damping the frequency helps in domains where raw term
+// frequencies are unreliable, e.g. with keyword stuffing or templated boilerplate.
+type Saturation interface {
+	Saturate(tf float64) float64
+}
+
+// SaturationFunc adapts a function to the Saturation interface.
+type SaturationFunc func(tf float64) float64
+
+// Saturate calls fn(tf).
+func (fn SaturationFunc) Saturate(tf float64) float64 {
+	return fn(tf)
+}
+
+var (
+	// StandardSaturation leaves term frequencies unchanged, so only the k1 curve of the
+	// variant saturates them. It is the default.
+	StandardSaturation Saturation = SaturationFunc(func(tf float64) float64 {
+		return tf
+	})
+
+	// LogSaturation replaces a term frequency tf with 1 + ln(tf).
+	LogSaturation Saturation = SaturationFunc(func(tf float64) float64 {
+		if tf <= 0 {
+			return 0
+		}
+		return 1 + math.Log(tf)
+	})
+
+	// BooleanSaturation only counts whether a term occurs in a document.
+	BooleanSaturation Saturation = SaturationFunc(func(tf float64) float64 {
+		if tf <= 0 {
+			return 0
+		}
+		return 1
+	})
+)
+
+// PiecewiseSaturation returns a Saturation that keeps term frequencies up to knee and
+// only counts occurrences beyond it with the given slope. A slope of 0 caps term
+// frequencies at knee.
+func PiecewiseSaturation(knee float64, slope float64) (Saturation, error) {
+	if knee <= 0 || math.IsInf(knee, 0) || math.IsNaN(knee) {
+		return nil, invalidParam("knee", knee, "must be a positive finite number")
+	}
+	if slope < 0 || slope > 1 || math.IsNaN(slope) {
+		return nil, invalidParam("slope", slope, "must be between 0 and 1")
+	}
+
+	return SaturationFunc(func(tf float64) float64 {
+		if tf <= knee {
+			return tf
+		}
+		return knee + slope*(tf-knee)
+	}), nil
+}
+
+// SetSaturation sets the transformation applied to term frequencies when scoring.
+// Passing nil restores StandardSaturation.
+func (b *Bm25Base) SetSaturation(saturation Saturation) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	b.saturation = saturation
+	b.settings++
+	b.impacts = nil // Impacts and TF-IDF norms are computed from the saturated term frequencies
+	b.tfidfNorms = nil
+	return nil
+}
+
+// Saturation returns the transformation applied to term frequencies when scoring.
+func (b *Bm25Base) Saturation() Saturation {
+	if b.saturation == nil {
+		return StandardSaturation
+	}
+	return b.saturation
+}
+
+// saturate applies the configured saturation to a term frequency.
+func (b *Bm25Base) saturate(tf float64) float64 {
+	if b.saturation == nil {
+		return tf
+	}
+	return b.saturation.Saturate(tf)
+}
+
+// termFrequency returns the saturated frequency of a term in a document, weighted by the
+// payloads of its occurrences.
+func (b *Bm25Base) termFrequency(docID int, term string) float64 {
+	return b.saturate(b.rawTermFrequency(docID, term))
+}
@@ -0,0 +1,282 @@
+package bm25
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ErrSchemaViolation is returned when a document does not match the schema of the index.
+// It can be matched with errors.Is; the violations are SchemaErrors.
+var ErrSchemaViolation = errors.New("document violates the schema")
+
+// FieldType is the type of a field of a Schema.
+type FieldType int
+
+const (
+	// FieldText is a string field that is analyzed.
+	FieldText FieldType = iota
+	// FieldKeyword is a string field, or a list of strings, that is matched exactly.
+	FieldKeyword
+	// FieldNumber is a numeric field, e.g. for range filters or sorting.
+	FieldNumber
+	// FieldTime is a time.Time field, or an RFC 3339 string.
+	FieldTime
+)
+
+// String returns the name of the field type.
+func (t FieldType) String() string {
+	switch t {
+	case FieldText:
+		return "text"
+	case FieldKeyword:
+		return "keyword"
+	case FieldNumber:
+		return "number"
+	case FieldTime:
+		return "time"
+	default:
+		return fmt.Sprintf("FieldType(%d)", int(t))
+	}
+}
+
+// FieldSchema describes a field of the documents.
+type FieldSchema struct {
+	Name     string
+	Type     FieldType
+	Required bool
+
+	// Analyzer, if set, analyzes a text field of a BM25F index, see
+	// NewBM25FWithAnalyzers. Keyword fields are analyzed with KeywordTokenizer.
+	Analyzer *Analyzer
+
+	// Weight is the weight of a text or keyword field of a BM25F index. Defaults to 1.
+	Weight float64
+}
+
+// Schema describes the fields of the documents of an index, so documents with misspelled,
+// missing or mistyped fields are rejected when they are added instead of silently
+// becoming unsearchable. For documents added with AddDocument or a Builder, the fields
+// are the keys of their Metadata; for a BM25F index, see NewBM25FWithSchema, they are the
+// fields of its corpus.
+type Schema struct {
+	Fields []FieldSchema
+
+	// AllowUnknown accepts the fields missing from the schema instead of rejecting them.
+	AllowUnknown bool
+}
+
+// SchemaError is a violation of a Schema by a field of a document. It matches
+// ErrSchemaViolation.
+type SchemaError struct {
+	Field  string
+	Reason string
+}
+
+// Error returns the error message, e.g. `field "year" must be of type number`.
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("field %q %s", e.Field, e.Reason)
+}
+
+// Is reports whether the target is ErrSchemaViolation.
+func (e *SchemaError) Is(target error) bool {
+	return target == ErrSchemaViolation
+}
+
+// validate checks the schema itself.
+func (s *Schema) validate() error {
+	names := make(map[string]struct{}, len(s.Fields))
+	for _, field := range s.Fields {
+		if field.Name == "" {
+			return invalidParam("name", field.Name, "must not be empty")
+		}
+		if _, ok := names[field.Name]; ok {
+			return invalidParam("name", field.Name, "must be unique")
+		}
+		names[field.Name] = struct{}{}
+
+		if field.Type < FieldText || field.Type > FieldTime {
+			return invalidParam("type", field.Type, fmt.Sprintf("of field %q is not a known field type", field.Name))
+		}
+		if field.Weight < 0 {
+			return invalidParam("weight", field.Weight, fmt.Sprintf("of field %q must be non-negative", field.Name))
+		}
+		if field.Analyzer != nil {
+			if field.Type != FieldText {
+				return invalidParam("analyzer", field.Name, "is only supported by text fields")
+			}
+			if err := field.Analyzer.validate(); err != nil {
+				return fmt.Errorf("analyzer for field %q: %w", field.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// field returns the schema of the field with the given name.
+func (s *Schema) field(name string) (FieldSchema, bool) {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return FieldSchema{}, false
+}
+
+// ValidateMetadata checks the metadata of a document against the schema. It returns all
+// violations, sorted by field, joined into one error.
+func (s *Schema) ValidateMetadata(metadata map[string]any) error {
+	return s.check(slices.Collect(maps.Keys(metadata)), func(name string, t FieldType) bool {
+		return metadataHasType(metadata[name], t)
+	})
+}
+
+// ValidateFields checks the fields of a document of a BM25F index against the schema.
+// Number and time fields must hold a number or an RFC 3339 time. It returns all
+// violations, sorted by field, joined into one error.
+func (s *Schema) ValidateFields(fields map[string]string) error {
+	return s.check(slices.Collect(maps.Keys(fields)), func(name string, t FieldType) bool {
+		return textHasType(fields[name], t)
+	})
+}
+
+// check checks the fields present in a document, using hasType to check their types.
+func (s *Schema) check(present []string, hasType func(name string, t FieldType) bool) error {
+	sort.Strings(present)
+	var errs []error
+	for _, field := range s.Fields {
+		_, ok := slices.BinarySearch(present, field.Name)
+		switch {
+		case !ok && field.Required:
+			errs = append(errs, &SchemaError{Field: field.Name, Reason: "is required"})
+		case ok && !hasType(field.Name, field.Type):
+			errs = append(errs, &SchemaError{Field: field.Name, Reason: "must be of type " + field.Type.String()})
+		}
+	}
+	if !s.AllowUnknown {
+		for _, name := range present {
+			if _, ok := s.field(name); !ok {
+				errs = append(errs, &SchemaError{Field: name, Reason: "is not in the schema"})
+			}
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].(*SchemaError).Field < errs[j].(*SchemaError).Field
+	})
+	return errors.Join(errs...)
+}
+
+// metadataHasType reports whether a metadata value is of the given field type.
+func metadataHasType(value any, t FieldType) bool {
+	switch t {
+	case FieldText:
+		_, ok := value.(string)
+		return ok
+	case FieldKeyword:
+		_, ok := keywordValues(value)
+		return ok
+	case FieldNumber:
+		kind, _, _, ok := toDocValue(value)
+		_, isString := value.(string)
+		return ok && kind == numericValue && !isString
+	default:
+		kind, _, _, ok := toDocValue(value)
+		return ok && kind == timeValue
+	}
+}
+
+// textHasType reports whether the text of a field is of the given field type.
+func textHasType(text string, t FieldType) bool {
+	switch t {
+	case FieldNumber:
+		_, err := strconv.ParseFloat(text, 64)
+		return err == nil
+	case FieldTime:
+		_, err := time.Parse(time.RFC3339Nano, text)
+		return err == nil
+	default:
+		return true
+	}
+}
+
+// SetSchema sets the schema the metadata of the documents added with AddDocument is
+// validated against; documents that do not match are rejected with the violations.
+// Documents already in the index are not validated. Passing nil removes the schema.
+// Like the analyzers, the schema is not saved in snapshots.
+func (b *Bm25Base) SetSchema(schema *Schema) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+	if schema == nil {
+		b.schema = nil
+		return nil
+	}
+	if err := schema.validate(); err != nil {
+		return err
+	}
+
+	b.schema = &Schema{Fields: slices.Clone(schema.Fields), AllowUnknown: schema.AllowUnknown}
+	return nil
+}
+
+// Schema returns the schema of the index, or nil if it has none.
+func (b *Bm25Base) Schema() *Schema {
+	return b.schema
+}
+
+// NewBM25FWithSchema creates a new instance of the BM25F struct whose fields, weights
+// and analyzers are given by a schema, see NewBM25FWithAnalyzers. Text and keyword fields
+// are indexed; number and time fields are only validated. Every document is validated
+// against the schema, and the violations of all documents are returned together, so a
+// corpus can be fixed in one pass.
+func NewBM25FWithSchema(corpus []map[string]string, tokenizer func(string) []string, schema *Schema, k1 float64, b float64, logger *log.Logger) (*BM25F, error) {
+	if schema == nil {
+		return nil, invalidParam("schema", schema, "must not be nil")
+	}
+	if err := schema.validate(); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for i, doc := range corpus {
+		if err := schema.ValidateFields(doc); err != nil {
+			errs = append(errs, fmt.Errorf("document at index %d: %w", i, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	weights := make(map[string]float64)
+	analyzers := make(map[string]Analyzer)
+	for _, field := range schema.Fields {
+		if field.Type != FieldText && field.Type != FieldKeyword {
+			continue
+		}
+		weights[field.Name] = field.Weight
+		if field.Weight == 0 {
+			weights[field.Name] = 1
+		}
+		switch {
+		case field.Analyzer != nil:
+			analyzers[field.Name] = *field.Analyzer
+		case field.Type == FieldKeyword:
+			analyzers[field.Name] = Analyzer{Tokenizer: KeywordTokenizer}
+		}
+	}
+
+	f, err := NewBM25FWithAnalyzers(corpus, tokenizer, weights, analyzers, k1, b, logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.SetSchema(schema); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
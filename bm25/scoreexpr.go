@@ -0,0 +1,414 @@
+package bm25
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidExpression is returned for score expressions that cannot be compiled.
+var ErrInvalidExpression = errors.New("invalid score expression")
+
+// ScoreExpression is a compiled expression computing the final score of a document from
+// its BM25 score and its metadata, see CompileScoreExpression.
+type ScoreExpression struct {
+	source string
+	eval   exprFunc
+}
+
+// exprFunc evaluates a node of a score expression for a document.
+type exprFunc func(env *exprEnv) float64
+
+// exprEnv holds the document a score expression is evaluated for.
+type exprEnv struct {
+	base  *Bm25Base
+	docID int
+	score float64
+	now   time.Time
+}
+
+// CompileScoreExpression compiles a score expression, so ranking tweaks can be changed
+// without code changes, e.g.
+//
+//	bm25 * 0.8 + field(popularity) * 0.2 + recency(ts, 7d)
+//
+// Expressions combine numbers, the BM25 score bm25, parentheses and the operators +, -,
+// * and / with the following functions:
+//
+//   - field(name) is the numeric metadata field name of the document, the Unix time in
+//     seconds for time fields, or 0 if the document does not have it.
+//   - recency(name, halfLife) decays from 1 for a time field at the time of the search to
+//     0.5 after halfLife, and is 0 if the document does not have it.
+//   - log, log1p, sqrt, exp and abs take one argument, min and max two.
+//
+// Durations are numbers with a unit of ms, s, m, h, d or w, e.g. 7d, and evaluate to
+// seconds. Divisions by zero evaluate to 0.
+func CompileScoreExpression(expr string) (*ScoreExpression, error) {
+	p := &exprParser{src: expr}
+	p.next()
+	eval, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.err != nil || p.tok.kind != exprEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return &ScoreExpression{source: expr, eval: eval}, nil
+}
+
+// String returns the source of the expression.
+func (e *ScoreExpression) String() string {
+	return e.source
+}
+
+// apply replaces the scores of the documents matching the query with the value of the
+// expression. Documents that do not match keep their score, e.g. 0 for most variants.
+func (e *ScoreExpression) apply(b *Bm25Base, scores []float64, matched *Bitmap) {
+	env := &exprEnv{base: b, now: time.Now()}
+	for _, docID := range matched.AppendDocIDs(nil) {
+		env.docID, env.score = docID, scores[docID]
+		value := e.eval(env)
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			value = 0
+		}
+		scores[docID] = value
+	}
+}
+
+// exprTokenKind is the kind of a token of a score expression.
+type exprTokenKind int
+
+const (
+	exprEOF exprTokenKind = iota
+	exprNumber
+	exprIdent
+	exprString
+	exprOperator
+)
+
+// exprToken is a token of a score expression.
+type exprToken struct {
+	kind   exprTokenKind
+	text   string
+	value  float64
+	offset int
+}
+
+// String returns the token for error messages.
+func (t exprToken) String() string {
+	if t.kind == exprEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// exprParser is a recursive descent parser of score expressions, compiling them into
+// closures.
+type exprParser struct {
+	src string
+	pos int
+	tok exprToken
+	err error
+}
+
+// durationUnits are the units of duration literals, in seconds.
+var durationUnits = map[string]float64{
+	"ms": 0.001, "s": 1, "m": 60, "h": 3600, "d": 86400, "w": 7 * 86400,
+}
+
+// next advances to the next token. Lexing errors are reported by the next call to expect
+// or by the parse functions through p.err.
+func (p *exprParser) next() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos == len(p.src) {
+		p.tok = exprToken{kind: exprEOF, offset: start}
+		return
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		value, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil && p.err == nil {
+			p.err = fmt.Errorf("%w at offset %d: invalid number %q", ErrInvalidExpression, start, p.src[start:p.pos])
+		}
+		unitStart := p.pos
+		for p.pos < len(p.src) && isIdentChar(p.src[p.pos]) {
+			p.pos++
+		}
+		if unit := p.src[unitStart:p.pos]; unit != "" {
+			scale, ok := durationUnits[unit]
+			if !ok && p.err == nil {
+				p.err = fmt.Errorf("%w at offset %d: unknown duration unit %q", ErrInvalidExpression, unitStart, unit)
+			}
+			value *= scale
+		}
+		p.tok = exprToken{kind: exprNumber, text: p.src[start:p.pos], value: value, offset: start}
+	case isIdentChar(c):
+		for p.pos < len(p.src) && isIdentChar(p.src[p.pos]) {
+			p.pos++
+		}
+		p.tok = exprToken{kind: exprIdent, text: p.src[start:p.pos], offset: start}
+	case c == '"' || c == '\'':
+		end := strings.IndexByte(p.src[p.pos+1:], c)
+		if end < 0 {
+			if p.err == nil {
+				p.err = fmt.Errorf("%w at offset %d: unterminated string", ErrInvalidExpression, start)
+			}
+			p.pos = len(p.src)
+			p.tok = exprToken{kind: exprEOF, offset: start}
+			return
+		}
+		p.pos += end + 2
+		p.tok = exprToken{kind: exprString, text: p.src[start+1 : p.pos-1], offset: start}
+	default:
+		p.pos++
+		p.tok = exprToken{kind: exprOperator, text: string(c), offset: start}
+	}
+}
+
+// isIdentChar reports whether c can be part of an identifier.
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// errorf returns a syntax error at the current token, or the pending lexing error.
+func (p *exprParser) errorf(format string, args ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	return fmt.Errorf("%w at offset %d: %s", ErrInvalidExpression, p.tok.offset, fmt.Sprintf(format, args...))
+}
+
+// expect consumes the given operator.
+func (p *exprParser) expect(op string) error {
+	if p.err != nil || p.tok.kind != exprOperator || p.tok.text != op {
+		return p.errorf("expected %q, got %s", op, p.tok)
+	}
+	p.next()
+	return nil
+}
+
+// isOperator reports whether the current token is one of the given operators.
+func (p *exprParser) isOperator(ops string) bool {
+	return p.tok.kind == exprOperator && strings.Contains(ops, p.tok.text)
+}
+
+// parseSum parses a sum or difference of products.
+func (p *exprParser) parseSum() (exprFunc, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("+-") {
+		op := p.tok.text
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "+" {
+			left = func(env *exprEnv) float64 { return l(env) + right(env) }
+		} else {
+			left = func(env *exprEnv) float64 { return l(env) - right(env) }
+		}
+	}
+	return left, nil
+}
+
+// parseProduct parses a product or quotient of unary expressions.
+func (p *exprParser) parseProduct() (exprFunc, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("*/") {
+		op := p.tok.text
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "*" {
+			left = func(env *exprEnv) float64 { return l(env) * right(env) }
+		} else {
+			left = func(env *exprEnv) float64 {
+				divisor := right(env)
+				if divisor == 0 {
+					return 0
+				}
+				return l(env) / divisor
+			}
+		}
+	}
+	return left, nil
+}
+
+// parseUnary parses a negation or an operand.
+func (p *exprParser) parseUnary() (exprFunc, error) {
+	if p.isOperator("-") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(env *exprEnv) float64 { return -operand(env) }, nil
+	}
+	return p.parseOperand()
+}
+
+// parseOperand parses a number, bm25, a function call or a parenthesized expression.
+func (p *exprParser) parseOperand() (exprFunc, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	tok := p.tok
+	switch {
+	case tok.kind == exprNumber:
+		p.next()
+		return func(*exprEnv) float64 { return tok.value }, nil
+	case tok.kind == exprOperator && tok.text == "(":
+		p.next()
+		inner, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	case tok.kind == exprIdent && tok.text == "bm25":
+		p.next()
+		return func(env *exprEnv) float64 { return env.score }, nil
+	case tok.kind == exprIdent:
+		p.next()
+		return p.parseCall(tok)
+	default:
+		return nil, p.errorf("unexpected %s", tok)
+	}
+}
+
+// exprFunctions maps the math functions of score expressions to their number of
+// arguments.
+var exprFunctions = map[string]int{"log": 1, "log1p": 1, "sqrt": 1, "exp": 1, "abs": 1, "min": 2, "max": 2}
+
+// parseCall parses the arguments of a function call.
+func (p *exprParser) parseCall(name exprToken) (exprFunc, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	switch name.text {
+	case "field", "recency":
+		field, err := p.parseFieldName()
+		if err != nil {
+			return nil, err
+		}
+		if name.text == "field" {
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return func(env *exprEnv) float64 { return env.field(field) }, nil
+		}
+
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		halfLife, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return func(env *exprEnv) float64 { return env.recency(field, halfLife(env)) }, nil
+	}
+
+	arity, ok := exprFunctions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("%w at offset %d: unknown function %q", ErrInvalidExpression, name.offset, name.text)
+	}
+	args := make([]exprFunc, arity)
+	for i := range args {
+		if i > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		args[i] = arg
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	x := args[0]
+	switch name.text {
+	case "log":
+		return func(env *exprEnv) float64 { return math.Log(x(env)) }, nil
+	case "log1p":
+		return func(env *exprEnv) float64 { return math.Log1p(x(env)) }, nil
+	case "sqrt":
+		return func(env *exprEnv) float64 { return math.Sqrt(x(env)) }, nil
+	case "exp":
+		return func(env *exprEnv) float64 { return math.Exp(x(env)) }, nil
+	case "abs":
+		return func(env *exprEnv) float64 { return math.Abs(x(env)) }, nil
+	case "min":
+		y := args[1]
+		return func(env *exprEnv) float64 { return math.Min(x(env), y(env)) }, nil
+	default:
+		y := args[1]
+		return func(env *exprEnv) float64 { return math.Max(x(env), y(env)) }, nil
+	}
+}
+
+// parseFieldName parses the name of a metadata field, bare or quoted.
+func (p *exprParser) parseFieldName() (string, error) {
+	if p.err != nil || p.tok.kind != exprIdent && p.tok.kind != exprString || p.tok.text == "" {
+		return "", p.errorf("expected a field name, got %s", p.tok)
+	}
+	name := p.tok.text
+	p.next()
+	return name, nil
+}
+
+// field returns the value of a numeric or time metadata field, or 0.
+func (env *exprEnv) field(name string) float64 {
+	kind, num, nanos, ok := toDocValue(env.base.Metadata(env.docID)[name])
+	switch {
+	case !ok:
+		return 0
+	case kind == timeValue:
+		return float64(nanos) / float64(time.Second)
+	default:
+		return num
+	}
+}
+
+// recency returns the exponential decay of the age of a time metadata field, which is 1
+// at the time of the search and 0.5 after halfLife seconds, or 0 if there is no time.
+func (env *exprEnv) recency(name string, halfLife float64) float64 {
+	kind, _, nanos, ok := toDocValue(env.base.Metadata(env.docID)[name])
+	if !ok || kind != timeValue || halfLife <= 0 {
+		return 0
+	}
+	age := env.now.Sub(time.Unix(0, nanos)).Seconds()
+	return math.Exp2(-math.Max(age, 0) / halfLife)
+}
@@ -0,0 +1,610 @@
+package bm25
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Normalization selects how the scores in a SearchResponse are normalized.
+type Normalization int
+
+const (
+	// NormalizeNone returns the raw scores.
+	NormalizeNone Normalization = iota
+	// NormalizeMax divides all scores by the highest score.
+	NormalizeMax
+	// NormalizeMinMax scales all scores linearly to the range [0, 1].
+	NormalizeMinMax
+)
+
+// SearchRequest describes a search against an index.
+type SearchRequest struct {
+	// Query holds the tokenized query terms.
+	Query []string
+
+	// Text, if set, is a raw query text whose tokens are appended to Query. It is analyzed
+	// like the documents, with the analyzer of Language if language analyzers are set, see
+	// Analyze, or with ParseQuery for BM25F indexes.
+	Text     string
+	Language string
+
+	// Analyzer, if set, overrides the analyzer of Text for this search only, e.g. to skip
+	// stemming for an exact search. The index is not analyzed again, so only tokens that
+	// are in the vocabulary match: an unstemmed token only matches documents of a stemmed
+	// index in which the stemmer left the word unchanged. Tokens that are not in the
+	// vocabulary are reported in the UnknownTerms of the response.
+	Analyzer *Analyzer `json:"-"`
+
+	// N is the maximum number of results to return.
+	N int
+
+	// Params overrides parameters of the variant, e.g. k1 or b, for this search only,
+	// so they can be tuned per request without changing the index. The names are those
+	// of the ParamSpecs of the index, and the parameters that are not overridden keep
+	// the values of the index.
+	Params map[string]float64
+
+	// Filter, if set, restricts the search to the documents for which it returns true.
+	Filter func(docID int) bool `json:"-"`
+
+	// Ranges restricts the search to the documents whose metadata lies within all of the
+	// given ranges. They are resolved against sorted doc-value indexes before the
+	// documents are ranked, and before Filter is called.
+	Ranges []RangeFilter
+
+	// Keywords restricts the search to the documents matching all of the given keyword
+	// filters, resolved like Ranges.
+	Keywords []KeywordFilter
+
+	// KeywordBoosts add constants to the scores of the documents with the given keyword
+	// values, after the coordination factor is applied.
+	KeywordBoosts []KeywordBoost
+
+	// Timeout, if positive, bounds the duration of the search.
+	Timeout time.Duration
+
+	// Explain attaches a breakdown of its score to every result.
+	Explain bool
+
+	// Normalization selects how the returned scores are normalized.
+	Normalization Normalization
+
+	// Coord, if positive, multiplies every score by the fraction of the distinct query
+	// terms the document matches, raised to the power of Coord. This is the coordination
+	// factor of classic Lucene for a Coord of 1; higher values penalize documents matching
+	// only some of the terms more strongly, so a single very rare term cannot outrank
+	// documents matching the whole query.
+	Coord float64
+
+	// Sort orders the results by the given keys instead of by descending score. Use
+	// ScoreField to sort by score, e.g. to break ties between equal scores by date. If
+	// the keys do not include ScoreField, only documents matching at least one query
+	// term are returned, so BM25 acts as the match predicate of a "newest matching" view.
+	Sort []SortField
+
+	// ShingleBoost, if positive, multiplies the scores of the query terms that are
+	// shingles, see ShingleFilter, so documents containing phrases of the query rank
+	// higher. The query has to be analyzed with the shingle filter of the index.
+	ShingleBoost float64
+
+	// PositionBoost boosts the matches occurring early in the documents.
+	PositionBoost PositionBoost
+
+	// NegativeTerms demote the documents containing any of them instead of excluding
+	// them: their positive scores are multiplied by NegativeBoost, and their negative
+	// scores lowered by the same fraction of their magnitude. NegativeBoost must be at
+	// least 0 and less than 1, and defaults to 0.5 if nil. This is softer than a Filter,
+	// for terms that usually, but not always, indicate an irrelevant document.
+	NegativeTerms []string
+	NegativeBoost *float64
+
+	// ScoreExpression, if set, computes the final scores of the documents matching the
+	// query from their scores after the boosts, available as bm25, and their metadata,
+	// e.g. "bm25 * 0.8 + field(popularity) * 0.2". It is compiled once per search, see
+	// CompileScoreExpression.
+	ScoreExpression string
+
+	// Limits bounds the resources the search may use.
+	Limits SearchLimits
+}
+
+// ScoreField is the name under which a SortField refers to the score of a document.
+const ScoreField = "_score"
+
+// SortField is a key to sort search results by: ScoreField or a numeric or date
+// metadata field. Documents without a value for a metadata field sort last.
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// SearchResult is a single document matched by a search.
+type SearchResult struct {
+	DocID       int          `json:"docId"`
+	Doc         string       `json:"doc"`
+	Score       float64      `json:"score"`
+	Explanation *Explanation `json:"explanation,omitempty"`
+}
+
+// Explanation breaks the score of a document down into the contributions of the query
+// terms. It marshals to JSON with the contributions nested under "terms", for relevance
+// debugging tools.
+type Explanation struct {
+	Score float64            `json:"score"`
+	Terms []TermContribution `json:"terms"`
+
+	// Coord is the coordination factor the sum of the term contributions was multiplied
+	// by, or 0 if the request did not set one.
+	Coord float64 `json:"coord,omitempty"`
+
+	// DocLength is the number of tokens of the document.
+	DocLength int `json:"docLength"`
+
+	// Demotion is the negative boost the score was demoted by because the document
+	// contains a negative term of the request, or nil if it was not demoted.
+	Demotion *float64 `json:"demotion,omitempty"`
+
+	// Keywords holds the keyword boosts added to the score, with the "field:value" they
+	// matched as their term.
+	Keywords []TermContribution `json:"keywords,omitempty"`
+}
+
+// TermContribution is the part of a score contributed by a single query term, with the
+// statistics it was computed from.
+type TermContribution struct {
+	Term      string  `json:"term"`
+	Score     float64 `json:"score"`
+	IDF       float64 `json:"idf"`
+	Frequency int     `json:"frequency"` // Occurrences of the term in the document
+}
+
+// SearchResponse holds the results of a search, best match first.
+type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+	Took    time.Duration  `json:"took"`
+
+	// Truncated reports that the search stopped collecting results at the MaxScoredDocs
+	// limit of the request.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// UnknownTerms lists the tokens produced by the Analyzer of the request that are not
+	// in the vocabulary of the index, and therefore match no document.
+	UnknownTerms []string `json:"unknownTerms,omitempty"`
+
+	// DictionaryVersion is the version of the QueryDictionary of the index the query was
+	// rewritten with, or 0 if there is none.
+	DictionaryVersion uint64 `json:"dictionaryVersion,omitempty"`
+
+	// Intent is the intent of the query given by the query hooks of the index, and Rewrite
+	// records the terms of the query before and after the hooks if they changed them, see
+	// OnQuery.
+	Intent  string        `json:"intent,omitempty"`
+	Rewrite *QueryRewrite `json:"rewrite,omitempty"`
+
+	// Cached reports that the response was served from a ResultCache.
+	Cached bool `json:"cached,omitempty"`
+}
+
+// Search is not implemented by the base; it is implemented by each BM25 variant.
+func (b *Bm25Base) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return nil, ErrNotImplemented
+}
+
+// search runs a search request using the scoring of the given BM25 variant. The query is
+// scored one term at a time, so the context is honored between terms and the
+// contribution of every term is available for explanations.
+func (b *Bm25Base) search(ctx context.Context, bm25 BM25, req SearchRequest) (*SearchResponse, error) {
+	start := time.Now()
+	var unknownTerms []string
+	if req.Text != "" {
+		tokens, err := b.analyzeQueryText(bm25, req)
+		if err != nil {
+			return nil, err
+		}
+		if req.Analyzer != nil {
+			unknownTerms = b.unknownTerms(tokens)
+		}
+		req.Query = append(slices.Clip(req.Query), tokens...)
+	}
+
+	intent, rewrite, err := b.rewriteQuery(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Params) > 0 {
+		if bm25, err = overrideParams(bm25, req.Params); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, topScore, err := b.runSearch(ctx, bm25, req, start)
+	if resp != nil {
+		resp.UnknownTerms = unknownTerms
+		resp.Intent = intent
+		resp.Rewrite = rewrite
+	}
+	b.logQuery(req.Query, start, resp, topScore, err)
+	return resp, err
+}
+
+// runSearch runs a search request and also returns the raw score of the best result.
+func (b *Bm25Base) runSearch(ctx context.Context, bm25 BM25, req SearchRequest, start time.Time) (*SearchResponse, float64, error) {
+	if len(req.Query) == 0 {
+		return nil, 0, ErrEmptyQuery
+	}
+
+	if req.N <= 0 {
+		return nil, 0, invalidParam("n", req.N, "must be a positive integer")
+	}
+
+	if req.Coord < 0 || math.IsNaN(req.Coord) || math.IsInf(req.Coord, 0) {
+		return nil, 0, invalidParam("coord", req.Coord, "must be a non-negative finite number")
+	}
+
+	if err := req.Limits.validate(); err != nil {
+		return nil, 0, err
+	}
+	req.Limits = req.Limits.withDefaults(b.limits)
+
+	if err := validateKeywordBoosts(req.KeywordBoosts); err != nil {
+		return nil, 0, err
+	}
+	if err := validateShingleBoost(req.ShingleBoost); err != nil {
+		return nil, 0, err
+	}
+	if err := validateNegativeBoost(req.NegativeBoost); err != nil {
+		return nil, 0, err
+	}
+	if err := req.PositionBoost.validate(); err != nil {
+		return nil, 0, err
+	}
+	var expr *ScoreExpression
+	if req.ScoreExpression != "" {
+		var err error
+		if expr, err = CompileScoreExpression(req.ScoreExpression); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+
+	mask, err := b.rangeMask(req.Ranges)
+	if err != nil {
+		return nil, 0, err
+	}
+	keywordMask, err := b.keywordMask(req.Keywords)
+	if err != nil {
+		return nil, 0, err
+	}
+	if mask == nil {
+		mask = keywordMask
+	} else if keywordMask != nil {
+		for i := range mask {
+			mask[i] = mask[i] && keywordMask[i]
+		}
+	}
+
+	// The dictionary version is captured once, so the whole search sees the same one
+	dictionary := b.dictionary.snapshot()
+	req.Query = dictionary.removeStopwords(req.Query)
+	entityBoosts := dictionary.entityBoosts(req.Query)
+	expand := func(query []string) []string { return b.ExpandQuery(dictionary.expandSynonyms(query)) }
+	coord := b.newCoordinator(req.Query, req.Coord, expand)
+	req.Query = expand(req.Query)
+	if binder, ok := bm25.(queryBinder); ok {
+		bm25 = binder.bindQuery(req.Query)
+	}
+	workers, err := b.searchWorkers(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if workers > 1 && b.cachesWritable() {
+		// Fill the IDF cache upfront, so the terms scored concurrently only read from it.
+		// Errors are reported by GetScores.
+		for _, q := range req.Query {
+			_, _ = b.IDF(q)
+		}
+	}
+
+	// Terms are scored in batches of one term per goroutine, and their scores added in
+	// query order, so the sums do not depend on the number of goroutines. Unless the term
+	// scores are kept for explanations, the buffers are reused across batches and searches
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores := b.scoreBuffer(*buf)
+	*buf = scores
+	var buffers []*[]float64
+	if !req.Explain {
+		buffers = make([]*[]float64, workers)
+		for i := range buffers {
+			buffers[i] = scoreBuffers.Get().(*[]float64)
+		}
+		defer func() {
+			for _, buf := range buffers {
+				scoreBuffers.Put(buf)
+			}
+		}()
+	}
+	sum := b.newTermSum(scores)
+	var termScores [][]float64
+
+	// Without ranking by score, only the documents matching a query term are results.
+	// Matches are decided by the presence of the terms, see matchedDocs
+	matchOnly := len(req.Sort) > 0 && !sortsByScore(req.Sort) || req.Limits.MaxScoredDocs > 0
+	var matched *Bitmap
+	if matchOnly || len(req.NegativeTerms) > 0 || expr != nil {
+		matched = NewBitmap(b.corpusSize)
+	}
+	for start := 0; start < len(req.Query); start += workers {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+
+		batch := req.Query[start:Min(start+workers, len(req.Query))]
+		batchScores, err := scoreTerms(bm25, batch, buffers)
+		if err != nil {
+			return nil, 0, err
+		}
+		for k, qScores := range batchScores {
+			boostShingle(batch[k], qScores, req.ShingleBoost)
+			req.PositionBoost.apply(b, bm25, batch[k], qScores)
+			if boost, ok := entityBoosts[batch[k]]; ok {
+				scaleScores(qScores, boost)
+			}
+			sum.add(qScores)
+			if req.Explain {
+				termScores = append(termScores, qScores)
+			}
+			if coord != nil || matched != nil {
+				docs := b.matchedDocs(bm25, batch[k])
+				if coord != nil {
+					coord.record(batch[k], docs)
+				}
+				if matched != nil {
+					matched.addAll(docs)
+				}
+			}
+		}
+	}
+	if err := b.loadErr(); err != nil {
+		return nil, 0, err
+	}
+
+	var coordFactors []float64
+	if coord != nil {
+		coordFactors = make([]float64, b.corpusSize)
+		for i := range scores {
+			coordFactors[i] = coord.factor(i)
+			scores[i] *= coordFactors[i]
+		}
+	}
+	demotions := b.demote(scores, matched, req.NegativeTerms, req.NegativeBoost)
+
+	var boosts map[int][]TermContribution
+	if req.Explain && len(req.KeywordBoosts) > 0 {
+		boosts = make(map[int][]TermContribution)
+	}
+	// Positively boosted documents are matches even without a query term
+	if err := b.applyKeywordBoosts(scores, req.KeywordBoosts, matched, boosts); err != nil {
+		return nil, 0, err
+	}
+	if expr != nil {
+		expr.apply(b, scores, matched)
+	}
+
+	candidates := make([]int, 0, b.corpusSize)
+	var capped *candidateHeap
+	if req.Limits.MaxScoredDocs > 0 {
+		capped = &candidateHeap{scores: scores, max: req.Limits.MaxScoredDocs}
+	}
+	truncated := false
+	for i := range scores {
+		if matchOnly && !matched.Contains(i) || b.Expired(i) {
+			continue
+		}
+		if (mask == nil || mask[i]) && (req.Filter == nil || req.Filter(i)) {
+			if capped != nil {
+				if !capped.add(i) {
+					truncated = true
+				}
+				continue
+			}
+			candidates = append(candidates, i)
+		}
+	}
+	if capped != nil {
+		candidates = capped.docIDs
+	}
+
+	normalize := normalizer(req.Normalization, scores, candidates)
+
+	if err := b.sortCandidates(candidates, scores, req.Sort); err != nil {
+		return nil, 0, err
+	}
+	candidates = candidates[:Min(req.N, len(candidates))]
+
+	resp := &SearchResponse{Results: make([]SearchResult, len(candidates)), Truncated: truncated}
+	if dictionary != nil {
+		resp.DictionaryVersion = dictionary.version
+	}
+	for i, docID := range candidates {
+		doc, err := b.docText(docID)
+		if err != nil {
+			return nil, 0, err
+		}
+		resp.Results[i] = SearchResult{
+			DocID: docID,
+			Doc:   doc,
+			Score: normalize(scores[docID]),
+		}
+
+		if req.Explain {
+			resp.Results[i].Explanation = b.explain(bm25, req.Query, docID, scores[docID], termScores, coordFactors)
+			resp.Results[i].Explanation.Keywords = boosts[docID]
+			if boost, ok := demotions[docID]; ok {
+				resp.Results[i].Explanation.Demotion = &boost
+			}
+		}
+	}
+
+	var topScore float64
+	if len(candidates) > 0 {
+		topScore = scores[candidates[0]]
+	}
+
+	resp.Took = time.Since(start)
+	return resp, topScore, nil
+}
+
+// explain builds the explanation of the score of a document from the scores of the
+// individual query terms.
+func (b *Bm25Base) explain(bm25 BM25, query []string, docID int, score float64, termScores [][]float64, coordFactors []float64) *Explanation {
+	explanation := &Explanation{Score: score, DocLength: b.docLengths[docID]}
+	if coordFactors != nil {
+		explanation.Coord = coordFactors[docID]
+	}
+
+	doc := b.doc(docID)
+	explainer, _ := bm25.(termExplainer)
+	for k, q := range query {
+		contribution := TermContribution{Term: q, Score: termScores[k][docID]}
+		switch {
+		case explainer != nil:
+			contribution.IDF, contribution.Frequency = explainer.explainTerm(docID, q)
+		case q != "":
+			contribution.IDF, _ = bm25.IDF(q)
+			contribution.Frequency = countTokens(doc, q)
+		}
+		explanation.Terms = append(explanation.Terms, contribution)
+	}
+	return explanation
+}
+
+// termExplainer is implemented by the indexes whose query tokens are not plain terms,
+// e.g. the field-scoped terms of BM25F.
+type termExplainer interface {
+	// explainTerm returns the IDF of a query token and its frequency in a document.
+	explainTerm(docID int, token string) (float64, int)
+}
+
+// queryBinder is implemented by the indexes whose term scores depend on the whole query,
+// which a search scores one term at a time.
+type queryBinder interface {
+	bindQuery(query []string) BM25
+}
+
+// queryParser is implemented by the indexes with their own query syntax.
+type queryParser interface {
+	ParseQuery(query string) []string
+}
+
+// analyzeQueryText returns the tokens of the text of a search request.
+func (b *Bm25Base) analyzeQueryText(bm25 BM25, req SearchRequest) ([]string, error) {
+	if req.Analyzer != nil {
+		if err := req.Analyzer.validate(); err != nil {
+			return nil, err
+		}
+		return req.Analyzer.Analyze(req.Text), nil
+	}
+	if parser, ok := bm25.(queryParser); ok {
+		return parser.ParseQuery(req.Text), nil
+	}
+	return b.Analyze(req.Text, req.Language), nil
+}
+
+// unknownTerms returns the distinct tokens that are not in the vocabulary.
+func (b *Bm25Base) unknownTerms(tokens []string) []string {
+	var unknown []string
+	for _, token := range tokens {
+		if termFreq, ok := b.docFreq(token); (!ok || termFreq == 0) && !slices.Contains(unknown, token) {
+			unknown = append(unknown, token)
+		}
+	}
+	return unknown
+}
+
+// sortsByScore reports whether the sort keys include the score.
+func sortsByScore(keys []SortField) bool {
+	for _, key := range keys {
+		if key.Field == ScoreField {
+			return true
+		}
+	}
+	return false
+}
+
+// sortCandidates sorts the candidate documents by the given keys, or by descending score
+// if there are none. Metadata fields are compared by their rank in the doc-value index.
+func (b *Bm25Base) sortCandidates(candidates []int, scores []float64, keys []SortField) error {
+	if len(keys) == 0 {
+		keys = []SortField{{Field: ScoreField, Descending: true}}
+	}
+
+	ranks := make([][]int, len(keys))
+	for k, key := range keys {
+		if key.Field == ScoreField {
+			continue
+		}
+
+		dv, err := b.docValuesFor(key.Field)
+		if err != nil {
+			return err
+		}
+		ranks[k] = dv.ranks(b.corpusSize)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		di, dj := candidates[i], candidates[j]
+		for k, key := range keys {
+			if key.Field == ScoreField {
+				if scores[di] != scores[dj] {
+					return scores[di] > scores[dj] == key.Descending
+				}
+				continue
+			}
+
+			ri, rj := ranks[k][di], ranks[k][dj]
+			switch {
+			case ri == rj:
+				continue
+			case ri < 0 || rj < 0:
+				return rj < 0 // Missing values sort last
+			default:
+				return ri > rj == key.Descending
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+// normalizer returns a function normalizing scores according to the given mode, based on
+// the scores of the candidate documents.
+func normalizer(mode Normalization, scores []float64, candidates []int) func(float64) float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, docID := range candidates {
+		lo = math.Min(lo, scores[docID])
+		hi = math.Max(hi, scores[docID])
+	}
+
+	switch mode {
+	case NormalizeMax:
+		if hi <= 0 {
+			break
+		}
+		return func(score float64) float64 { return score / hi }
+	case NormalizeMinMax:
+		if hi <= lo {
+			return func(float64) float64 { return 0 }
+		}
+		return func(score float64) float64 { return (score - lo) / (hi - lo) }
+	}
+	return func(score float64) float64 { return score }
+}
@@ -0,0 +1,363 @@
+package bm25
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultVirtualNodes is the default number of points of every shard on the hash ring,
+// which keeps the shards within about 10% of their fair share of the documents.
+const defaultVirtualNodes = 128
+
+// ErrMissingID is returned when a document without an external ID is added to a
+// ShardRouter, which routes documents by their ID.
+var ErrMissingID = errors.New("document ID is required")
+
+// ShardIndex is an index serving as a shard of a ShardRouter, e.g. a CopyOnWriteIndex.
+type ShardIndex interface {
+	Add(docs ...Document) ([]int, error)
+
+	// Current returns an immutable version of the shard, which is searched and whose
+	// external IDs identify the results.
+	Current() BM25
+}
+
+// idLookup is implemented by the indexes that look documents up by external ID.
+type idLookup interface {
+	LookupID(id string) (int, bool)
+}
+
+// ShardedResult is a result of a sharded search.
+type ShardedResult struct {
+	SearchResult
+	Shard      string `json:"shard"`
+	ExternalID string `json:"externalId"`
+}
+
+// ShardedResponse is the response of a sharded search.
+type ShardedResponse struct {
+	Results []ShardedResult `json:"results"`
+	Took    time.Duration   `json:"took"`
+}
+
+// ringPoint is a point of a shard on the hash ring.
+type ringPoint struct {
+	hash  uint64
+	shard string
+}
+
+// ShardRouter distributes documents over shards by consistent hashing of their external
+// IDs, and fans out searches to all shards. Adding a shard only moves the documents that
+// hash to it, about 1/n of them for n shards, away from their current shard; the other
+// documents stay where they are.
+//
+// Every shard computes its own collection statistics, such as the IDF of the terms and
+// the average document length, so the scores of different shards are only comparable if
+// the documents are spread evenly, which hashing ensures for large corpora. It is safe for
+// concurrent use.
+type ShardRouter struct {
+	mu           sync.RWMutex
+	shards       map[string]ShardIndex
+	ring         []ringPoint
+	virtualNodes int
+}
+
+// NewShardRouter creates a new ShardRouter placing every shard at virtualNodes points
+// of the hash ring; more points spread the documents more evenly. A virtualNodes of 0
+// defaults to 128.
+func NewShardRouter(virtualNodes int) (*ShardRouter, error) {
+	if virtualNodes == 0 {
+		virtualNodes = defaultVirtualNodes
+	}
+	if virtualNodes < 0 {
+		return nil, invalidParam("virtualNodes", virtualNodes, "must be a positive integer")
+	}
+
+	return &ShardRouter{shards: make(map[string]ShardIndex), virtualNodes: virtualNodes}, nil
+}
+
+// AddShard adds a shard to the router. New documents whose IDs hash to it are routed to
+// it from now on; documents added before stay in their shard until they are moved, see
+// Moved, and searches keep returning them from there in the meantime.
+func (r *ShardRouter) AddShard(name string, index ShardIndex) error {
+	if index == nil {
+		return fmt.Errorf("shard %q: %w", name, ErrNilBase)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.shards[name]; ok {
+		return invalidParam("name", name, "must be unique")
+	}
+	r.shards[name] = index
+	for i := 0; i < r.virtualNodes; i++ {
+		r.ring = append(r.ring, ringPoint{hash: hashKey(name + "#" + strconv.Itoa(i)), shard: name})
+	}
+	sort.Slice(r.ring, func(i, j int) bool {
+		if r.ring[i].hash != r.ring[j].hash {
+			return r.ring[i].hash < r.ring[j].hash
+		}
+		return r.ring[i].shard < r.ring[j].shard
+	})
+	return nil
+}
+
+// Shards returns the names of the shards, sorted alphabetically.
+func (r *ShardRouter) Shards() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.shards))
+	for name := range r.shards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ShardFor returns the name of the shard a document ID is routed to, or an empty string
+// if there are no shards.
+func (r *ShardRouter) ShardFor(id string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.shardFor(id)
+}
+
+// shardFor returns the shard owning the first point of the ring at or after the hash of
+// the ID, wrapping around.
+func (r *ShardRouter) shardFor(id string) string {
+	if len(r.ring) == 0 {
+		return ""
+	}
+
+	hash := hashKey(id)
+	i := sort.Search(len(r.ring), func(i int) bool { return r.ring[i].hash >= hash })
+	if i == len(r.ring) {
+		i = 0
+	}
+	return r.ring[i].shard
+}
+
+// Moved returns the IDs, among the given ones, that are not routed to the shard they are
+// stored in, e.g. after adding a shard. stored returns the shard a document is stored in.
+// Re-adding the moved documents with Add stores them in their new shard, after which they
+// are only returned from there.
+func (r *ShardRouter) Moved(ids []string, stored func(id string) string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var moved []string
+	for _, id := range ids {
+		if r.shardFor(id) != stored(id) {
+			moved = append(moved, id)
+		}
+	}
+	return moved
+}
+
+// Add routes every document to its shard by its external ID, which is required, and adds
+// the documents of every shard as one batch. It stops at the first shard failing.
+func (r *ShardRouter) Add(docs ...Document) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.shards) == 0 {
+		return invalidParam("shards", 0, "must not be empty")
+	}
+
+	batches := make(map[string][]Document)
+	var order []string
+	for _, doc := range docs {
+		if doc.ID == "" {
+			return ErrMissingID
+		}
+		shard := r.shardFor(doc.ID)
+		if _, ok := batches[shard]; !ok {
+			order = append(order, shard)
+		}
+		batches[shard] = append(batches[shard], doc)
+	}
+
+	for _, shard := range order {
+		if _, err := r.shards[shard].Add(batches[shard]...); err != nil {
+			return fmt.Errorf("shard %q: %w", shard, err)
+		}
+	}
+	return nil
+}
+
+// Search runs the request against all shards concurrently and returns the top req.N
+// results by score, or in the order of req.Sort, comparing the metadata values of the
+// documents across shards. Ties are broken by shard name and document ID. A document stored in several shards, because it has been moved to
+// the shard it is routed to but not removed from its former one, is only returned from
+// the shard it is routed to. The search fails if any shard fails.
+func (r *ShardRouter) Search(ctx context.Context, req SearchRequest) (*ShardedResponse, error) {
+	start := time.Now()
+	r.mu.RLock()
+	names := make([]string, 0, len(r.shards))
+	for name := range r.shards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	versions := make([]BM25, len(names))
+	for i, name := range names {
+		versions[i] = r.shards[name].Current()
+	}
+	r.mu.RUnlock()
+
+	responses := make([]*SearchResponse, len(versions))
+	errs := make([]error, len(versions))
+	var wg sync.WaitGroup
+	for i, version := range versions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i], errs[i] = version.Search(ctx, req)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("shard %q: %w", names[i], errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	// A document is skipped in a shard other than the one it is routed to, if that shard
+	// holds it too
+	shardOf := make(map[string]int, len(names))
+	for i, name := range names {
+		shardOf[name] = i
+	}
+	keys := req.Sort
+	if len(keys) == 0 {
+		keys = []SortField{{Field: ScoreField, Descending: true}}
+	}
+	r.mu.RLock()
+	var results []ShardedResult
+	var values [][]sortValue
+	for i, resp := range responses {
+		ids, _ := versions[i].(externalIDSource)
+		for _, result := range resp.Results {
+			var externalID string
+			if ids != nil {
+				externalID = ids.ExternalID(result.DocID)
+			}
+			if externalID != "" {
+				if owner, ok := shardOf[r.shardFor(externalID)]; ok && owner != i {
+					if lookup, ok := versions[owner].(idLookup); ok {
+						if _, ok := lookup.LookupID(externalID); ok {
+							continue
+						}
+					}
+				}
+			}
+			results = append(results, ShardedResult{SearchResult: result, Shard: names[i], ExternalID: externalID})
+			values = append(values, sortValues(versions[i], result.DocID, keys))
+		}
+	}
+	r.mu.RUnlock()
+
+	// The results are merged in the order of the shards, comparing the values of the sort
+	// fields, as their ranks are local to every shard
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		ri, rj := results[order[i]], results[order[j]]
+		for k, key := range keys {
+			if key.Field == ScoreField {
+				if ri.Score != rj.Score {
+					return ri.Score > rj.Score == key.Descending
+				}
+				continue
+			}
+
+			vi, vj := values[order[i]][k], values[order[j]][k]
+			switch {
+			case !vi.ok && !vj.ok:
+				continue
+			case !vi.ok || !vj.ok:
+				return !vj.ok // Missing values sort last
+			}
+			if c := vi.compare(vj); c != 0 {
+				return c > 0 == key.Descending
+			}
+		}
+		if ri.Shard != rj.Shard {
+			return ri.Shard < rj.Shard
+		}
+		return ri.DocID < rj.DocID
+	})
+	sorted := make([]ShardedResult, len(results))
+	for i, j := range order {
+		sorted[i] = results[j]
+	}
+	results = sorted
+	if len(results) > req.N {
+		results = results[:req.N]
+	}
+	return &ShardedResponse{Results: results, Took: time.Since(start)}, nil
+}
+
+// metadataSource is implemented by the indexes that hold the metadata of documents.
+type metadataSource interface {
+	Metadata(docID int) map[string]any
+}
+
+// sortValue is the value of a sort field of a document, as returned by toDocValue.
+type sortValue struct {
+	kind docValueKind
+	num  float64
+	t    int64
+	ok   bool // The document has a value for the field
+}
+
+// sortValues returns the values of the sort fields of a document. Score fields and
+// fields the document has no value for are left unset.
+func sortValues(index BM25, docID int, keys []SortField) []sortValue {
+	values := make([]sortValue, len(keys))
+	source, ok := index.(metadataSource)
+	if !ok {
+		return values
+	}
+	metadata := source.Metadata(docID)
+	for k, key := range keys {
+		if value, ok := metadata[key.Field]; ok && key.Field != ScoreField {
+			v := &values[k]
+			v.kind, v.num, v.t, v.ok = toDocValue(value)
+		}
+	}
+	return values
+}
+
+// compare compares two sort values, ordering values of different kinds by kind.
+func (v sortValue) compare(other sortValue) int {
+	if v.kind != other.kind {
+		return cmp.Compare(v.kind, other.kind)
+	}
+	if v.kind == numericValue {
+		return cmp.Compare(v.num, other.num)
+	}
+	return cmp.Compare(v.t, other.t)
+}
+
+// hashKey hashes a key onto the ring. The FNV hash is mixed with the finalizer of
+// SplitMix64, as FNV alone spreads similar keys such as "shard#1" and "shard#2" poorly.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
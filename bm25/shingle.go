@@ -0,0 +1,88 @@
+package bm25
+
+import (
+	"math"
+	"strings"
+)
+
+// ShingleSeparator joins the words of a shingle, see ShingleFilter. Tokenizers splitting
+// on whitespace never produce it, so shingles cannot be confused with single words.
+const ShingleSeparator = " "
+
+// ShingleOptions configures ShingleFilter.
+type ShingleOptions struct {
+	// MinSize and MaxSize are the minimum and maximum number of words of a shingle. Both
+	// default to 2, i.e. bigrams.
+	MinSize int
+	MaxSize int
+
+	// ShinglesOnly drops the single words, so only the shingles are indexed.
+	ShinglesOnly bool
+}
+
+// ShingleFilter returns a filter adding shingles, i.e. runs of consecutive tokens joined
+// by ShingleSeparator, e.g. "new york" for "new" and "york", as additional terms. Indexing
+// shingles approximates phrase relevance without positional queries: a document
+// containing a phrase of the query matches its shingles, and scores higher than one
+// containing its words apart. The same filter has to be applied to the queries, e.g.
+// with an Analyzer, and SearchRequest.ShingleBoost weights the shingle matches. Shingles
+// count towards the document length, and the filter should be applied after the filters
+// removing tokens, so shingles only join tokens that are indexed.
+func ShingleFilter(opts ShingleOptions) (TokenFilter, error) {
+	if opts.MinSize == 0 {
+		opts.MinSize = 2
+	}
+	if opts.MaxSize == 0 {
+		opts.MaxSize = max(opts.MinSize, 2)
+	}
+	if opts.MinSize < 2 {
+		return nil, invalidParam("MinSize", opts.MinSize, "must be at least 2")
+	}
+	if opts.MaxSize < opts.MinSize {
+		return nil, invalidParam("MaxSize", opts.MaxSize, "must not be less than MinSize")
+	}
+
+	return func(tokens []string) []string {
+		shingled := make([]string, 0, len(tokens)*(opts.MaxSize-opts.MinSize+2))
+		for i, token := range tokens {
+			if !opts.ShinglesOnly {
+				shingled = append(shingled, token)
+			}
+			for size := opts.MinSize; size <= opts.MaxSize && i+size <= len(tokens); size++ {
+				shingled = append(shingled, strings.Join(tokens[i:i+size], ShingleSeparator))
+			}
+		}
+		return shingled
+	}, nil
+}
+
+// IsShingle reports whether a term is a shingle produced by ShingleFilter.
+func IsShingle(term string) bool {
+	return strings.Contains(term, ShingleSeparator)
+}
+
+// validateShingleBoost checks the ShingleBoost of a SearchRequest.
+func validateShingleBoost(boost float64) error {
+	if boost < 0 || math.IsNaN(boost) || math.IsInf(boost, 0) {
+		return invalidParam("shingleBoost", boost, "must be a non-negative finite number")
+	}
+	return nil
+}
+
+// boostShingle multiplies the scores of a query term by the shingle boost if the term is
+// a shingle.
+func boostShingle(term string, scores []float64, boost float64) {
+	if boost != 0 && IsShingle(term) {
+		scaleScores(scores, boost)
+	}
+}
+
+// scaleScores multiplies the scores of a query term by a boost.
+func scaleScores(scores []float64, boost float64) {
+	if boost == 1 {
+		return
+	}
+	for i := range scores {
+		scores[i] *= boost
+	}
+}
@@ -0,0 +1,170 @@
+package bm25
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// ErrInvalidSnapshot is returned when a snapshot cannot be read.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+func init() {
+	// Metadata values of gob snapshots are stored as interfaces, whose concrete types gob
+	// must know
+	gob.Register(time.Time{})
+	gob.Register(json.Number(""))
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+}
+
+// snapshot is the persisted state of an index. The corpus statistics are derived from
+// the documents when the snapshot is read. Earlier versions wrote it with gob.
+type snapshot struct {
+	Corpus      [][]string
+	ExternalIDs []string
+	Metadata    []map[string]any
+	ExpiresAt   []time.Time
+	Stopwords   []string
+	TermWeights map[string]float64
+	Epsilon     float64
+	EpsilonSet  bool
+	SubwordOpts *SubwordOptions
+	Versions    map[string]uint64
+	Payloads    map[int][]float64
+}
+
+// WriteSnapshot writes the documents and settings of the index to w in the gzip-compressed
+// snapshot format, to be restored with ReadSnapshot. The tokenizer, logger, saturation
+// function, hooks, query log and document store are not part of the snapshot and have to
+// be set up again.
+func (b *Bm25Base) WriteSnapshot(w io.Writer) error {
+	return b.WriteSnapshotWithOptions(w, SnapshotOptions{})
+}
+
+// WriteSnapshotWithOptions is like WriteSnapshot, with the given options.
+func (b *Bm25Base) WriteSnapshotWithOptions(w io.Writer, opts SnapshotOptions) error {
+	if err := b.Preload(); err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	corpus := b.corpus
+	if b.lazy != nil {
+		corpus = make([][]string, b.corpusSize)
+		for docID := range corpus {
+			corpus[docID] = b.doc(docID)
+		}
+	}
+
+	snap := snapshot{
+		Corpus:      corpus,
+		ExternalIDs: b.externalIDs,
+		Metadata:    b.metadata,
+		ExpiresAt:   b.expiresAt,
+		Stopwords:   b.Stopwords(),
+		TermWeights: b.termWeights,
+		Epsilon:     b.epsilon,
+		EpsilonSet:  b.epsilonSet,
+		SubwordOpts: b.subwordOpts,
+		Versions:    b.versions,
+		Payloads:    b.payloads,
+	}
+	if err := writeSnapshotFormat(w, &snap, opts); err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	return nil
+}
+
+// ReadSnapshot restores an index written with WriteSnapshot. The tokenizer is used for
+// documents added afterwards and should be the one the index was built with. Snapshots
+// of a newer format version return ErrUnsupportedVersion, and corrupted snapshots
+// ErrInvalidSnapshot. Snapshots written with gob by earlier versions are still read.
+func ReadSnapshot(r io.Reader, tokenizer func(string) []string, logger *log.Logger) (*Bm25Base, error) {
+	return ReadSnapshotWithOptions(r, tokenizer, logger, SnapshotOptions{})
+}
+
+// ReadSnapshotWithOptions is like ReadSnapshot, with the given options. Encrypted
+// snapshots require the Keys option.
+func ReadSnapshotWithOptions(r io.Reader, tokenizer func(string) []string, logger *log.Logger, opts SnapshotOptions) (*Bm25Base, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(snapshotMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+
+	if string(magic) != snapshotMagic {
+		var snap snapshot
+		if err := gob.NewDecoder(br).Decode(&snap); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+		return restoreSnapshot(&snap, tokenizer, logger)
+	}
+
+	if _, err := br.Discard(len(snapshotMagic)); err != nil {
+		return nil, err
+	}
+	snap, err := readSnapshotFormat(br, opts)
+	if err != nil {
+		return nil, err
+	}
+	return restoreSnapshot(snap, tokenizer, logger)
+}
+
+// restoreSnapshot rebuilds an index from its persisted state.
+func restoreSnapshot(snap *snapshot, tokenizer func(string) []string, logger *log.Logger) (*Bm25Base, error) {
+	if len(snap.ExternalIDs) > len(snap.Corpus) || len(snap.Metadata) > len(snap.Corpus) || len(snap.ExpiresAt) > len(snap.Corpus) {
+		return nil, fmt.Errorf("%w: more document attributes than documents", ErrInvalidSnapshot)
+	}
+
+	builder, err := NewBuilder(tokenizer, logger, BuildOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for docID, tokens := range snap.Corpus {
+		var doc Document
+		if docID < len(snap.ExternalIDs) {
+			doc.ID = snap.ExternalIDs[docID]
+		}
+		if docID < len(snap.Metadata) {
+			doc.Metadata = snap.Metadata[docID]
+		}
+		if _, err := builder.AddTokens(tokens, doc); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+	}
+
+	base, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	if err := applySnapshotSettings(base, snap); err != nil {
+		return nil, err
+	}
+	return base, nil
+}
+
+// applySnapshotSettings restores the expiry times, document versions, payloads and
+// settings of a snapshot.
+func applySnapshotSettings(base *Bm25Base, snap *snapshot) error {
+	if len(snap.ExpiresAt) > 0 {
+		base.expiresAt = snap.ExpiresAt
+	}
+	if err := base.ExcludeStopwords(snap.Stopwords); err != nil {
+		return err
+	}
+	base.termWeights = snap.TermWeights
+	base.epsilon, base.epsilonSet = snap.Epsilon, snap.EpsilonSet
+	base.subwordOpts = snap.SubwordOpts
+	base.versions = snap.Versions
+	for docID, payloads := range snap.Payloads {
+		if docID < 0 || docID >= base.corpusSize || len(payloads) != base.docLengths[docID] {
+			return fmt.Errorf("%w: payloads do not match document %d", ErrInvalidSnapshot, docID)
+		}
+	}
+	base.payloads = snap.Payloads
+	return nil
+}
@@ -0,0 +1,99 @@
+package bm25
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMissingKey is returned when an encrypted snapshot is read without a KeyProvider.
+	ErrMissingKey = errors.New("snapshot is encrypted, but no key was provided")
+
+	// ErrDecryption is returned when a snapshot cannot be decrypted, e.g. with a wrong key.
+	ErrDecryption = errors.New("snapshot decryption failed")
+)
+
+// KeyProvider supplies the AES keys snapshots are encrypted with. A provider backed by a
+// key management service typically implements envelope encryption: NewKey generates a
+// data key and has the service encrypt it, and DecryptKey has the service decrypt it.
+type KeyProvider interface {
+	// NewKey returns a new 16, 24 or 32 byte key for a snapshot, together with the
+	// encrypted form of the key, which is stored in the snapshot.
+	NewKey() (key, encryptedKey []byte, err error)
+
+	// DecryptKey returns the key of a snapshot from its stored, encrypted form.
+	DecryptKey(encryptedKey []byte) ([]byte, error)
+}
+
+// StaticKey is a KeyProvider that encrypts every snapshot with a random data key, which
+// is itself stored encrypted with the static key. The static key must be 16, 24 or 32
+// bytes long, for AES-128, AES-192 or AES-256.
+type StaticKey []byte
+
+// NewKey generates a random 32 byte data key and encrypts it with the static key.
+func (k StaticKey) NewKey() ([]byte, []byte, error) {
+	aead, err := newAEAD(k)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	encryptedKey, err := seal(aead, key, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, encryptedKey, nil
+}
+
+// DecryptKey decrypts a data key with the static key.
+func (k StaticKey) DecryptKey(encryptedKey []byte) ([]byte, error) {
+	aead, err := newAEAD(k)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, encryptedKey, nil)
+}
+
+// newAEAD returns the AES-GCM cipher of a key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, invalidParam("key", fmt.Sprintf("%d bytes", len(key)), "must be 16, 24 or 32 bytes long")
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, which is prepended to the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a ciphertext sealed by seal.
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: ciphertext too short", ErrDecryption)
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecryption, err)
+	}
+	return plaintext, nil
+}
+
+// sectionAAD returns the additional data authenticating the kind and position of a
+// snapshot section.
+func sectionAAD(kind byte, seq uint64) []byte {
+	return binary.LittleEndian.AppendUint64([]byte{kind}, seq)
+}
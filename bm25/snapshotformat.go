@@ -0,0 +1,831 @@
+package bm25
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// The snapshot format starts with a magic header, the format version, the compression of
+// the sections that follow, flags and, for encrypted snapshots, the encrypted data key.
+// Every section consists of its kind, the length of its stored payload, the payload and a
+// CRC-32C checksum of the stored payload. The payloads of encrypted snapshots are sealed
+// with AES-GCM after compression, with the kind and position of the section as
+// additional data, so sections cannot be swapped undetected. Documents
+// and vocabulary are split into sections of bounded size, so neither has to be encoded
+// or checked in one piece. The corpus statistics and the attributes of the documents
+// precede their tokens, so OpenSnapshot can serve queries before the tokens are read.
+// Readers skip sections of unknown kinds, so later minor additions stay readable, and
+// reject snapshots with a newer format version.
+const (
+	snapshotMagic = "BM25SNAP"
+
+	// SnapshotVersion is the version of the snapshot format written by WriteSnapshot.
+	// Version 2 added encryption, version 3 split the documents into statistics,
+	// attribute and token sections, and version 4 seals the empty sections of encrypted
+	// snapshots too, including the end marker.
+	SnapshotVersion = 4
+
+	// sealedEmptyVersion is the first version sealing empty sections.
+	sealedEmptyVersion = 4
+
+	flagEncrypted = 1 << 0
+
+	snapshotChunkDocs  = 1 << 16
+	snapshotChunkTerms = 1 << 18
+)
+
+// Kinds of snapshot sections.
+const (
+	sectionEnd byte = iota
+	sectionSettings
+	sectionVocabulary
+	sectionDocuments // Tokens and attributes of documents, before version 3
+	sectionStats
+	sectionAttributes
+	sectionTokens
+)
+
+var (
+	// ErrUnsupportedVersion is returned for snapshots written in a newer format version.
+	ErrUnsupportedVersion = errors.New("unsupported snapshot version")
+
+	// ErrUnsupportedCompression is returned for compressions without a registered Compressor.
+	ErrUnsupportedCompression = errors.New("unsupported snapshot compression")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Compression identifies the compression of the sections of a snapshot.
+type Compression uint8
+
+const (
+	// CompressionGzip compresses snapshots with gzip. It is the default.
+	CompressionGzip Compression = iota
+	// CompressionNone stores snapshots uncompressed.
+	CompressionNone
+	// CompressionZstd compresses snapshots with Zstandard. It requires a Compressor to
+	// be registered with RegisterCompressor, e.g. one based on github.com/klauspost/compress.
+	CompressionZstd
+)
+
+// Compressor creates the writers compressing and the readers decompressing a snapshot.
+type Compressor struct {
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[Compression]Compressor{
+		CompressionGzip: {
+			NewWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+			NewReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		},
+		CompressionNone: {
+			NewWriter: func(w io.Writer) (io.WriteCloser, error) { return nopWriteCloser{w}, nil },
+			NewReader: func(r io.Reader) (io.ReadCloser, error) { return io.NopCloser(r), nil },
+		},
+	}
+)
+
+// RegisterCompressor registers the Compressor of a compression, replacing any registered
+// before.
+func RegisterCompressor(c Compression, compressor Compressor) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[c] = compressor
+}
+
+// compressorFor returns the registered Compressor of a compression.
+func compressorFor(c Compression) (Compressor, error) {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	compressor, ok := compressors[c]
+	if !ok {
+		return Compressor{}, fmt.Errorf("%w: %d", ErrUnsupportedCompression, c)
+	}
+	return compressor, nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// SnapshotOptions configures how a snapshot is written or read.
+type SnapshotOptions struct {
+	// Compression selects the compression of the snapshot. Defaults to gzip. It is
+	// ignored when reading, as the compression is recorded in the snapshot.
+	Compression Compression
+
+	// Keys, if set, encrypts the snapshot with AES-GCM when writing, and provides the key
+	// of encrypted snapshots when reading.
+	Keys KeyProvider
+
+	// Progress, if set, is called after every section of the snapshot is written or read.
+	// It is not called for snapshots in the gob format of earlier versions.
+	Progress func(SnapshotProgress)
+}
+
+// SnapshotProgress reports the progress of writing or reading a snapshot.
+type SnapshotProgress struct {
+	// DocsProcessed is the number of documents whose tokens have been written or read.
+	// OpenSnapshot does not read the tokens upfront, so it counts the documents whose
+	// attributes have been read instead.
+	DocsProcessed int
+
+	// TotalDocs is the number of documents in the snapshot. When reading, it is 0 until
+	// the corpus statistics are read, and stays 0 for format versions before 3.
+	TotalDocs int
+
+	// Bytes is the number of bytes of the snapshot written or read, including its header.
+	Bytes   int64
+	Elapsed time.Duration
+}
+
+// snapshotProgress forwards the progress of writing or reading a snapshot to the
+// progress callback. A nil snapshotProgress discards all updates.
+type snapshotProgress struct {
+	fn       func(SnapshotProgress)
+	start    time.Time
+	progress SnapshotProgress
+}
+
+// newSnapshotProgress creates a snapshotProgress for the given options, or returns nil if
+// no progress callback is configured.
+func newSnapshotProgress(opts SnapshotOptions, totalDocs int) *snapshotProgress {
+	if opts.Progress == nil {
+		return nil
+	}
+	return &snapshotProgress{fn: opts.Progress, start: time.Now(), progress: SnapshotProgress{TotalDocs: totalDocs}}
+}
+
+// setTotal sets the number of documents in the snapshot, once it is known.
+func (p *snapshotProgress) setTotal(totalDocs int) {
+	if p != nil {
+		p.progress.TotalDocs = totalDocs
+	}
+}
+
+// report reports the total number of processed documents and bytes.
+func (p *snapshotProgress) report(docs int, bytes int64) {
+	if p == nil {
+		return
+	}
+	p.progress.DocsProcessed = docs
+	p.progress.Bytes = bytes
+	p.progress.Elapsed = time.Since(p.start)
+	p.fn(p.progress)
+}
+
+// writeSnapshotFormat writes a snapshot in the binary snapshot format.
+func writeSnapshotFormat(w io.Writer, snap *snapshot, opts SnapshotOptions) error {
+	compressor, err := compressorFor(opts.Compression)
+	if err != nil {
+		return err
+	}
+
+	header := append([]byte(snapshotMagic), 0, 0, byte(opts.Compression), 0)
+	binary.LittleEndian.PutUint16(header[len(snapshotMagic):], SnapshotVersion)
+	sw := &sectionWriter{w: w, compressor: compressor, progress: newSnapshotProgress(opts, len(snap.Corpus))}
+	if opts.Keys != nil {
+		key, encryptedKey, err := opts.Keys.NewKey()
+		if err != nil {
+			return fmt.Errorf("creating data key: %w", err)
+		}
+		if sw.aead, err = newAEAD(key); err != nil {
+			return err
+		}
+		header[len(header)-1] |= flagEncrypted
+		header = appendString(header, string(encryptedKey))
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	sw.written = int64(len(header))
+
+	settings, err := json.Marshal(snapshotSettings{
+		Stopwords:   snap.Stopwords,
+		TermWeights: snap.TermWeights,
+		Epsilon:     snap.Epsilon,
+		EpsilonSet:  snap.EpsilonSet,
+		SubwordOpts: snap.SubwordOpts,
+		Versions:    snap.Versions,
+		Payloads:    snap.Payloads,
+	})
+	if err != nil {
+		return err
+	}
+	if err := sw.write(sectionSettings, settings); err != nil {
+		return err
+	}
+
+	// Documents refer to their terms by their index in the vocabulary, in order of first
+	// occurrence
+	termIDs := make(map[string]uint64)
+	var chunk []byte
+	var terms uint64
+	for _, doc := range snap.Corpus {
+		for _, token := range doc {
+			if _, ok := termIDs[token]; ok {
+				continue
+			}
+			termIDs[token] = uint64(len(termIDs))
+			chunk = appendString(chunk, token)
+			if terms++; terms%snapshotChunkTerms == 0 {
+				if err := sw.write(sectionVocabulary, chunk); err != nil {
+					return err
+				}
+				chunk = chunk[:0]
+			}
+		}
+	}
+	if len(chunk) > 0 {
+		if err := sw.write(sectionVocabulary, chunk); err != nil {
+			return err
+		}
+	}
+
+	chunk = appendSnapshotStats(chunk[:0], snap, termIDs)
+	if err := sw.write(sectionStats, chunk); err != nil {
+		return err
+	}
+
+	for start := 0; start < len(snap.Corpus); start += snapshotChunkDocs {
+		chunk = chunk[:0]
+		for docID := start; docID < min(start+snapshotChunkDocs, len(snap.Corpus)); docID++ {
+			if chunk, err = appendSnapshotAttributes(chunk, snap, docID); err != nil {
+				return err
+			}
+		}
+		if err := sw.write(sectionAttributes, chunk); err != nil {
+			return err
+		}
+	}
+
+	for start := 0; start < len(snap.Corpus); start += snapshotChunkDocs {
+		chunk = chunk[:0]
+		for _, doc := range snap.Corpus[start:min(start+snapshotChunkDocs, len(snap.Corpus))] {
+			chunk = binary.AppendUvarint(chunk, uint64(len(doc)))
+			for _, token := range doc {
+				chunk = binary.AppendUvarint(chunk, termIDs[token])
+			}
+		}
+		sw.docs = min(start+snapshotChunkDocs, len(snap.Corpus))
+		if err := sw.write(sectionTokens, chunk); err != nil {
+			return err
+		}
+	}
+	return sw.write(sectionEnd, nil)
+}
+
+// snapshotSettings is the layout of the settings section.
+type snapshotSettings struct {
+	Stopwords   []string           `json:"stopwords,omitempty"`
+	TermWeights map[string]float64 `json:"termWeights,omitempty"`
+	Epsilon     float64            `json:"epsilon,omitempty"`
+	EpsilonSet  bool               `json:"epsilonSet,omitempty"`
+	SubwordOpts *SubwordOptions    `json:"subwordOpts,omitempty"`
+	Versions    map[string]uint64  `json:"versions,omitempty"`
+	Payloads    map[int][]float64  `json:"payloads,omitempty"`
+}
+
+// unmarshalSettings decodes the settings section into the snapshot.
+func unmarshalSettings(payload []byte, snap *snapshot) error {
+	var settings snapshotSettings
+	if err := json.Unmarshal(payload, &settings); err != nil {
+		return fmt.Errorf("%w: settings: %w", ErrInvalidSnapshot, err)
+	}
+	snap.Stopwords, snap.TermWeights = settings.Stopwords, settings.TermWeights
+	snap.Epsilon, snap.EpsilonSet = settings.Epsilon, settings.EpsilonSet
+	snap.SubwordOpts, snap.Versions = settings.SubwordOpts, settings.Versions
+	snap.Payloads = settings.Payloads
+	return nil
+}
+
+// appendSnapshotStats encodes the corpus statistics: the number of documents per chunk,
+// the length of every document and the document frequency of every term of the
+// vocabulary.
+func appendSnapshotStats(buf []byte, snap *snapshot, termIDs map[string]uint64) []byte {
+	buf = binary.AppendUvarint(buf, snapshotChunkDocs)
+	buf = binary.AppendUvarint(buf, uint64(len(snap.Corpus)))
+	docFreqs := make([]uint64, len(termIDs))
+	for _, doc := range snap.Corpus {
+		buf = binary.AppendUvarint(buf, uint64(len(doc)))
+		forEachDistinct(doc, func(token string) {
+			docFreqs[termIDs[token]]++
+		})
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(docFreqs)))
+	for _, docFreq := range docFreqs {
+		buf = binary.AppendUvarint(buf, docFreq)
+	}
+	return buf
+}
+
+// appendSnapshotAttributes encodes the attributes of a document: its external ID, expiry
+// time and metadata.
+func appendSnapshotAttributes(buf []byte, snap *snapshot, docID int) ([]byte, error) {
+	var id string
+	if docID < len(snap.ExternalIDs) {
+		id = snap.ExternalIDs[docID]
+	}
+	buf = appendString(buf, id)
+
+	var expiresAt int64
+	if docID < len(snap.ExpiresAt) && !snap.ExpiresAt[docID].IsZero() {
+		expiresAt = snap.ExpiresAt[docID].UnixNano()
+	}
+	buf = binary.AppendVarint(buf, expiresAt)
+
+	var metadata map[string]any
+	if docID < len(snap.Metadata) {
+		metadata = snap.Metadata[docID]
+	}
+	if metadata == nil {
+		return binary.AppendUvarint(buf, 0), nil
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(metadata))+1)
+	for key, value := range metadata {
+		buf = appendString(buf, key)
+		var err error
+		if buf, err = appendValue(buf, value); err != nil {
+			return nil, fmt.Errorf("metadata %q of document %d: %w", key, docID, err)
+		}
+	}
+	return buf, nil
+}
+
+// Type tags of metadata values.
+const (
+	valueNil byte = iota
+	valueString
+	valueInt
+	valueFloat
+	valueBool
+	valueTime
+	valueJSON
+)
+
+// appendValue encodes a metadata value with its type tag. Integers are restored as int,
+// floats as float64, and values of other types are stored as JSON.
+func appendValue(buf []byte, value any) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, valueNil), nil
+	case string:
+		return appendString(append(buf, valueString), v), nil
+	case bool:
+		if v {
+			return append(buf, valueBool, 1), nil
+		}
+		return append(buf, valueBool, 0), nil
+	case time.Time:
+		data, err := v.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return appendString(append(buf, valueTime), string(data)), nil
+	case float32:
+		return binary.LittleEndian.AppendUint64(append(buf, valueFloat), math.Float64bits(float64(v))), nil
+	case float64:
+		return binary.LittleEndian.AppendUint64(append(buf, valueFloat), math.Float64bits(v)), nil
+	}
+	if n, ok := asInt64(value); ok {
+		return binary.AppendVarint(append(buf, valueInt), n), nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return appendString(append(buf, valueJSON), string(data)), nil
+}
+
+// asInt64 converts a value of an integer type to int64, if it fits.
+func asInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint:
+		return int64(v), v <= math.MaxInt64
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	default:
+		return 0, false
+	}
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// sectionWriter writes the sections of a snapshot.
+type sectionWriter struct {
+	w          io.Writer
+	compressor Compressor
+	aead       cipher.AEAD // nil for unencrypted snapshots
+	seq        uint64
+	stored     bytes.Buffer
+
+	progress *snapshotProgress
+	written  int64 // Bytes written so far, including the header
+	docs     int   // Documents whose tokens are in the sections written so far
+}
+
+// write compresses and writes a section. Sections of encrypted snapshots are sealed even
+// if they are empty, so the end marker cannot be forged after truncating a snapshot at a
+// section boundary.
+func (sw *sectionWriter) write(kind byte, payload []byte) error {
+	sw.stored.Reset()
+	if len(payload) > 0 {
+		cw, err := sw.compressor.NewWriter(&sw.stored)
+		if err != nil {
+			return err
+		}
+		if _, err := cw.Write(payload); err != nil {
+			return err
+		}
+		if err := cw.Close(); err != nil {
+			return err
+		}
+	}
+	if sw.aead != nil {
+		sealed, err := seal(sw.aead, sw.stored.Bytes(), sectionAAD(kind, sw.seq))
+		if err != nil {
+			return err
+		}
+		sw.stored.Reset()
+		sw.stored.Write(sealed)
+	}
+	sw.seq++
+
+	header := make([]byte, 9)
+	header[0] = kind
+	binary.LittleEndian.PutUint64(header[1:], uint64(sw.stored.Len()))
+	trailer := binary.LittleEndian.AppendUint32(nil, crc32.Checksum(sw.stored.Bytes(), castagnoli))
+	for _, data := range [][]byte{header, sw.stored.Bytes(), trailer} {
+		n, err := sw.w.Write(data)
+		sw.written += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	sw.progress.report(sw.docs, sw.written)
+	return nil
+}
+
+// readSnapshotFormat reads a snapshot in the binary snapshot format, after its magic header.
+func readSnapshotFormat(r *bufio.Reader, opts SnapshotOptions) (*snapshot, error) {
+	cr := &countingReader{r: r, n: int64(len(snapshotMagic))}
+	version, compressor, aead, err := readSnapshotHeader(cr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{}
+	var vocabulary []string
+	attributes := 0
+	progress := newSnapshotProgress(opts, 0)
+	for seq := uint64(0); ; seq++ {
+		kind, payload, err := readSection(cr, version, compressor, aead, seq)
+		if err != nil {
+			return nil, err
+		}
+
+		d := &snapshotDecoder{buf: payload}
+		switch kind {
+		case sectionEnd:
+			progress.report(len(snap.Corpus), cr.n)
+			return snap, nil
+		case sectionStats:
+			// The statistics are recomputed from the tokens, only the document count is
+			// taken for the progress
+			stats := &snapshotDecoder{buf: payload}
+			stats.uvarint()
+			progress.setTotal(int(stats.uvarint()))
+		case sectionSettings:
+			if err := unmarshalSettings(payload, snap); err != nil {
+				return nil, err
+			}
+		case sectionVocabulary:
+			for len(d.buf) > 0 && d.err == nil {
+				vocabulary = append(vocabulary, d.string())
+			}
+		case sectionDocuments:
+			for len(d.buf) > 0 && d.err == nil {
+				snap.Corpus = append(snap.Corpus, d.tokens(vocabulary))
+				d.attributes(snap, len(snap.Corpus)-1)
+			}
+		case sectionAttributes:
+			for ; len(d.buf) > 0 && d.err == nil; attributes++ {
+				d.attributes(snap, attributes)
+			}
+		case sectionTokens:
+			for len(d.buf) > 0 && d.err == nil {
+				snap.Corpus = append(snap.Corpus, d.tokens(vocabulary))
+			}
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		progress.report(len(snap.Corpus), cr.n)
+	}
+}
+
+// readSnapshotHeader reads the header of a snapshot after its magic, and returns the
+// format version, the Compressor and, for encrypted snapshots, the cipher of its sections.
+func readSnapshotHeader(r byteReader, opts SnapshotOptions) (uint16, Compressor, cipher.AEAD, error) {
+	header := make([]byte, 3)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, Compressor{}, nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	version := binary.LittleEndian.Uint16(header)
+	if version > SnapshotVersion {
+		return 0, Compressor{}, nil, fmt.Errorf("%w: version %d is newer than the supported version %d", ErrUnsupportedVersion, version, SnapshotVersion)
+	}
+	compressor, err := compressorFor(Compression(header[2]))
+	if err != nil {
+		return 0, Compressor{}, nil, err
+	}
+
+	var aead cipher.AEAD
+	if version >= 2 {
+		flags, err := r.ReadByte()
+		if err != nil {
+			return 0, Compressor{}, nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+		}
+		if flags&flagEncrypted != 0 {
+			if aead, err = readSnapshotKey(r, opts.Keys); err != nil {
+				return 0, Compressor{}, nil, err
+			}
+		}
+	}
+	return version, compressor, aead, nil
+}
+
+// byteReader is a reader that can also read single bytes, as needed for varints.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// countingReader is a byteReader counting the bytes read through it.
+type countingReader struct {
+	r byteReader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// readSnapshotKey reads the encrypted data key of a snapshot and returns the cipher
+// decrypting its sections.
+func readSnapshotKey(r byteReader, keys KeyProvider) (cipher.AEAD, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	var encryptedKey bytes.Buffer
+	if _, err := io.CopyN(&encryptedKey, r, int64(min(length, math.MaxInt64))); err != nil {
+		return nil, fmt.Errorf("%w: truncated data key: %w", ErrInvalidSnapshot, err)
+	}
+
+	if keys == nil {
+		return nil, ErrMissingKey
+	}
+	key, err := keys.DecryptKey(encryptedKey.Bytes())
+	if err != nil && !errors.Is(err, ErrDecryption) {
+		err = fmt.Errorf("%w: %w", ErrDecryption, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newAEAD(key)
+}
+
+// readSection reads a section of a snapshot of the given format version, verifies its
+// checksum, and decrypts and decompresses its payload.
+func readSection(r io.Reader, version uint16, compressor Compressor, aead cipher.AEAD, seq uint64) (byte, []byte, error) {
+	header := make([]byte, 9)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, fmt.Errorf("%w: truncated section header: %w", ErrInvalidSnapshot, err)
+	}
+	kind, length := header[0], binary.LittleEndian.Uint64(header[1:])
+
+	// The buffer grows with the data actually read, so a corrupted length cannot cause
+	// a huge allocation
+	var stored bytes.Buffer
+	if _, err := io.CopyN(&stored, r, int64(min(length, math.MaxInt64))); err != nil {
+		return 0, nil, fmt.Errorf("%w: truncated section: %w", ErrInvalidSnapshot, err)
+	}
+	trailer := make([]byte, 4)
+	if _, err := io.ReadFull(r, trailer); err != nil {
+		return 0, nil, fmt.Errorf("%w: truncated section checksum: %w", ErrInvalidSnapshot, err)
+	}
+	if crc32.Checksum(stored.Bytes(), castagnoli) != binary.LittleEndian.Uint32(trailer) {
+		return 0, nil, fmt.Errorf("%w: checksum mismatch in section of kind %d", ErrInvalidSnapshot, kind)
+	}
+	if aead != nil && (length > 0 || version >= sealedEmptyVersion) {
+		opened, err := open(aead, stored.Bytes(), sectionAAD(kind, seq))
+		if err != nil {
+			return 0, nil, err
+		}
+		stored.Reset()
+		stored.Write(opened)
+	}
+	if stored.Len() == 0 {
+		return kind, nil, nil
+	}
+
+	cr, err := compressor.NewReader(&stored)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	defer cr.Close()
+	payload, err := io.ReadAll(cr)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	return kind, payload, nil
+}
+
+// snapshotDecoder decodes the payload of a section. The first error is kept and stops
+// further decoding.
+type snapshotDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *snapshotDecoder) fail(what string) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: invalid %s", ErrInvalidSnapshot, what)
+	}
+	d.buf = nil
+}
+
+func (d *snapshotDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail("varint")
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *snapshotDecoder) varint() int64 {
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.fail("varint")
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *snapshotDecoder) bytes(n uint64) []byte {
+	if n > uint64(len(d.buf)) {
+		d.fail("length")
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *snapshotDecoder) string() string {
+	return string(d.bytes(d.uvarint()))
+}
+
+// tokens decodes the term IDs of a document and returns its tokens.
+func (d *snapshotDecoder) tokens(vocabulary []string) []string {
+	n := d.uvarint()
+	if n > uint64(len(d.buf)) {
+		d.fail("document length")
+		return nil
+	}
+	tokens := make([]string, n)
+	for i := range tokens {
+		termID := d.uvarint()
+		if termID >= uint64(len(vocabulary)) {
+			d.fail("term ID")
+			return nil
+		}
+		tokens[i] = vocabulary[termID]
+	}
+	return tokens
+}
+
+// attributes decodes the attributes of a document and sets them in the snapshot.
+func (d *snapshotDecoder) attributes(snap *snapshot, docID int) {
+	if id := d.string(); id != "" {
+		snap.ExternalIDs = growTo(snap.ExternalIDs, docID)
+		snap.ExternalIDs[docID] = id
+	}
+	if expiresAt := d.varint(); expiresAt != 0 {
+		snap.ExpiresAt = growTo(snap.ExpiresAt, docID)
+		snap.ExpiresAt[docID] = time.Unix(0, expiresAt)
+	}
+
+	fields := d.uvarint()
+	if fields == 0 {
+		return
+	}
+	if fields-1 > uint64(len(d.buf)) {
+		d.fail("metadata length")
+		return
+	}
+	metadata := make(map[string]any, fields-1)
+	for i := uint64(1); i < fields && d.err == nil; i++ {
+		key := d.string()
+		metadata[key] = d.value()
+	}
+	snap.Metadata = growTo(snap.Metadata, docID)
+	snap.Metadata[docID] = metadata
+}
+
+// value decodes a metadata value encoded by appendValue.
+func (d *snapshotDecoder) value() any {
+	tag := d.bytes(1)
+	if tag == nil {
+		return nil
+	}
+
+	switch tag[0] {
+	case valueNil:
+		return nil
+	case valueString:
+		return d.string()
+	case valueInt:
+		return int(d.varint())
+	case valueFloat:
+		b := d.bytes(8)
+		if b == nil {
+			return nil
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	case valueBool:
+		b := d.bytes(1)
+		return b != nil && b[0] == 1
+	case valueTime:
+		var t time.Time
+		if err := t.UnmarshalBinary(d.bytes(d.uvarint())); err != nil {
+			d.fail("time")
+		}
+		return t
+	case valueJSON:
+		var v any
+		if err := unmarshalJSONNumber(d.bytes(d.uvarint()), &v); err != nil {
+			d.fail("JSON value")
+		}
+		return v
+	default:
+		d.fail("value type")
+		return nil
+	}
+}
+
+// unmarshalJSONNumber decodes JSON, keeping numbers as json.Number.
+func unmarshalJSONNumber(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
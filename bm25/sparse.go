@@ -0,0 +1,101 @@
+package bm25
+
+// GetSparseScores returns the scores of the given index for the query, like GetScores,
+// but only for the documents with a non-zero score, as parallel slices of document IDs
+// in ascending order and their scores. Only documents containing at least one query
+// term are scored, so selective queries on large corpora neither allocate nor compute a
+// score for every document.
+func (b *Bm25Base) GetSparseScores(query []string, bm25 BM25) ([]int, []float64, error) {
+	if len(query) == 0 {
+		return nil, nil, ErrEmptyQuery
+	}
+
+	matches := b.matchingDocs(query)
+	if matches.Len() == 0 {
+		return []int{}, []float64{}, nil
+	}
+
+	docIDs, scores, err := GetBatchScoresSet(bm25, query, matches)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n := 0
+	for i, score := range scores {
+		if score != 0 {
+			docIDs[n], scores[n] = docIDs[i], score
+			n++
+		}
+	}
+	return docIDs[:n], scores[:n], nil
+}
+
+// matchingDocs returns the documents containing at least one of the query terms that is
+// not a stopword. The posting lists of Retrieve are used if they are built, otherwise
+// the stored tokens are scanned.
+func (b *Bm25Base) matchingDocs(query []string) *Bitmap {
+	terms := make(map[string]struct{}, len(query))
+	for _, q := range query {
+		if !b.isStopword(q) {
+			terms[q] = struct{}{}
+		}
+	}
+
+	matches := NewBitmap(b.corpusSize)
+	if b.impacts != nil {
+		for term := range terms {
+			for _, p := range b.impacts.postings[term] {
+				matches.Add(p.docID)
+			}
+		}
+		return matches
+	}
+
+	for docID := range b.corpus {
+		for _, token := range b.doc(docID) {
+			if _, ok := terms[token]; ok {
+				matches.Add(docID)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// termMatcher is implemented by the indexes whose query terms do not match the tokens of
+// the documents as they are, e.g. the field-scoped terms of BM25F.
+type termMatcher interface {
+	termDocs(term string) *Bitmap
+}
+
+// termDocs returns the documents containing a query term, or an empty set if the term is
+// a stopword.
+func (b *Bm25Base) termDocs(term string) *Bitmap {
+	docs := NewBitmap(b.corpusSize)
+	if b.isStopword(term) {
+		return docs
+	}
+	if b.impacts != nil {
+		for _, p := range b.impacts.postings[term] {
+			docs.Add(p.docID)
+		}
+		return docs
+	}
+	for docID := range b.corpus {
+		if countTokens(b.doc(docID), term) > 0 {
+			docs.Add(docID)
+		}
+	}
+	return docs
+}
+
+// matchedDocs returns the documents matching a term of a query run against the given
+// variant. Whether a document matches depends on the presence of the term and not on its
+// score, as some variants give documents without the term a non-zero score, e.g.
+// BM25Plus, and others give matching documents a score of zero or less.
+func (b *Bm25Base) matchedDocs(bm25 BM25, term string) *Bitmap {
+	if matcher, ok := bm25.(termMatcher); ok {
+		return matcher.termDocs(term)
+	}
+	return b.termDocs(term)
+}
@@ -0,0 +1,154 @@
+package sqlquery
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// expr is a condition of a WHERE clause.
+type expr interface {
+	matches(base *bm25.Bm25Base, docID int) bool
+}
+
+type andExpr struct{ left, right expr }
+
+func (e andExpr) matches(base *bm25.Bm25Base, docID int) bool {
+	return e.left.matches(base, docID) && e.right.matches(base, docID)
+}
+
+type orExpr struct{ left, right expr }
+
+func (e orExpr) matches(base *bm25.Bm25Base, docID int) bool {
+	return e.left.matches(base, docID) || e.right.matches(base, docID)
+}
+
+type notExpr struct{ e expr }
+
+func (e notExpr) matches(base *bm25.Bm25Base, docID int) bool {
+	return !e.e.matches(base, docID)
+}
+
+// matchExpr matches the documents containing any of its terms.
+type matchExpr struct {
+	terms []string
+}
+
+func (e matchExpr) matches(base *bm25.Bm25Base, docID int) bool {
+	for _, term := range e.terms {
+		if base.HasTerm(docID, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// nullExpr matches the documents without a value for a field.
+type nullExpr struct {
+	field string
+}
+
+func (e nullExpr) matches(base *bm25.Bm25Base, docID int) bool {
+	value, ok := fieldValue(base, docID, e.field)
+	return !ok || value == nil
+}
+
+// inExpr matches the documents whose field equals one of its values.
+type inExpr struct {
+	field  string
+	values []any
+}
+
+func (e inExpr) matches(base *bm25.Bm25Base, docID int) bool {
+	actual, ok := fieldValue(base, docID, e.field)
+	if !ok {
+		return false
+	}
+	for _, value := range e.values {
+		if equal(actual, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// comparison matches the documents whose field compares to a value with an ordering
+// operator. Equality is handled by inExpr.
+type comparison struct {
+	filter bm25.RangeFilter
+}
+
+func (e comparison) matches(base *bm25.Bm25Base, docID int) bool {
+	value, ok := fieldValue(base, docID, e.filter.Field)
+	return ok && e.filter.Contains(value)
+}
+
+// newComparison returns the condition comparing a field to a value. Strings compared
+// with an ordering operator are dates, either RFC 3339 timestamps or plain dates.
+func newComparison(field, op string, value any) (expr, error) {
+	switch op {
+	case "=":
+		return inExpr{field: field, values: []any{value}}, nil
+	case "!=", "<>":
+		return andExpr{notExpr{nullExpr{field: field}}, notExpr{inExpr{field: field, values: []any{value}}}}, nil
+	}
+
+	if s, ok := value.(string); ok {
+		t, ok := parseDate(s)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s %s %q: invalid date", ErrSyntax, field, op, s)
+		}
+		value = t
+	}
+
+	r := bm25.RangeFilter{Field: field}
+	switch op {
+	case "<", "<=":
+		r.Max, r.ExclusiveMax = value, op == "<"
+	case ">", ">=":
+		r.Min, r.ExclusiveMin = value, op == ">"
+	}
+	return comparison{filter: r}, nil
+}
+
+// fieldValue returns the value of a field of a document, with IDColumn referring to
+// its external ID.
+func fieldValue(base *bm25.Bm25Base, docID int, field string) (any, bool) {
+	if field == IDColumn {
+		return base.ExternalID(docID), true
+	}
+	value, ok := base.Metadata(docID)[field]
+	return value, ok
+}
+
+// equal reports whether a metadata value equals a literal. Numbers compare by value,
+// dates to RFC 3339 timestamps or plain dates, and anything else by its formatting.
+func equal(actual, literal any) bool {
+	switch v := actual.(type) {
+	case string:
+		return v == literal
+	case time.Time:
+		s, ok := literal.(string)
+		if !ok {
+			return false
+		}
+		t, ok := parseDate(s)
+		return ok && t.Equal(v)
+	}
+
+	if num, ok := literal.(float64); ok {
+		return bm25.RangeFilter{Min: num, Max: num}.Contains(actual)
+	}
+	return fmt.Sprint(actual) == fmt.Sprint(literal)
+}
+
+// parseDate parses an RFC 3339 timestamp or a plain date.
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,122 @@
+package sqlquery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tokenKind is the kind of a lexical token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenKeyword
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+// keywords are the reserved words of the query language. They are matched case-insensitively.
+var keywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "AND": true, "OR": true, "NOT": true,
+	"MATCH": true, "IN": true, "IS": true, "NULL": true, "ORDER": true, "BY": true,
+	"ASC": true, "DESC": true, "LIMIT": true,
+}
+
+// token is a lexical token. Keywords are upper-cased; quoted identifiers and strings
+// are unquoted.
+type token struct {
+	kind   tokenKind
+	text   string
+	num    float64
+	offset int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+// lex splits a query into tokens.
+func lex(query string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '\'' || c == '"':
+			// Strings are single-quoted and identifiers double-quoted; a doubled quote
+			// stands for the quote itself
+			var text strings.Builder
+			j := i + 1
+			for {
+				if j >= len(query) {
+					return nil, fmt.Errorf("%w at offset %d: unterminated quote", ErrSyntax, i)
+				}
+				if query[j] == c {
+					if j+1 < len(query) && query[j+1] == c {
+						text.WriteByte(c)
+						j += 2
+						continue
+					}
+					break
+				}
+				text.WriteByte(query[j])
+				j++
+			}
+			kind := tokenString
+			if c == '"' {
+				kind = tokenIdent
+			}
+			tokens = append(tokens, token{kind: kind, text: text.String(), offset: i})
+			i = j + 1
+
+		case c >= '0' && c <= '9' || c == '.' || c == '-':
+			j := i + 1
+			for j < len(query) && (query[j] >= '0' && query[j] <= '9' || query[j] == '.' || query[j] == 'e' || query[j] == 'E') {
+				j++
+			}
+			num, err := strconv.ParseFloat(query[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w at offset %d: invalid number %q", ErrSyntax, i, query[i:j])
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: query[i:j], num: num, offset: i})
+			i = j
+
+		case c == '_' || unicode.IsLetter(rune(c)) || c >= 0x80:
+			j := i
+			for j < len(query) && (query[j] == '_' || query[j] == '.' || query[j] >= 0x80 || unicode.IsLetter(rune(query[j])) || unicode.IsDigit(rune(query[j]))) {
+				j++
+			}
+			word := query[i:j]
+			if upper := strings.ToUpper(word); keywords[upper] {
+				tokens = append(tokens, token{kind: tokenKeyword, text: upper, offset: i})
+			} else {
+				tokens = append(tokens, token{kind: tokenIdent, text: word, offset: i})
+			}
+			i = j
+
+		default:
+			symbol := string(c)
+			if i+1 < len(query) {
+				switch two := query[i : i+2]; two {
+				case "!=", "<>", "<=", ">=":
+					symbol = two
+				}
+			}
+			if !strings.Contains("(),*=<>", symbol) && len(symbol) == 1 {
+				return nil, fmt.Errorf("%w at offset %d: unexpected character %q", ErrSyntax, i, c)
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, text: symbol, offset: i})
+			i += len(symbol)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, offset: len(query)}), nil
+}
@@ -0,0 +1,349 @@
+package sqlquery
+
+import (
+	"fmt"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// Statement is a parsed SELECT statement.
+type Statement struct {
+	// Columns holds the selected columns, with * expanded to id, doc and score.
+	Columns []string
+
+	// Table is the name of the index the statement reads from.
+	Table string
+
+	// Terms holds the tokenized text of the MATCH predicates that are not negated.
+	Terms []string
+
+	// OrderBy holds the sort keys, or nil to order by descending score.
+	OrderBy []bm25.SortField
+
+	// Limit is the maximum number of rows, or 0 for all matching documents.
+	Limit int
+
+	where expr
+}
+
+// parser is a recursive descent parser over the tokens of a statement.
+type parser struct {
+	tokens    []token
+	pos       int
+	tokenizer func(string) []string
+	negated   bool
+	stmt      *Statement
+}
+
+// Parse parses a statement, tokenizing the text of MATCH predicates with the tokenizer.
+func Parse(query string, tokenizer func(string) []string) (*Statement, error) {
+	if tokenizer == nil {
+		return nil, bm25.ErrNilTokenizer
+	}
+
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, tokenizer: tokenizer, stmt: &Statement{}}
+	if err := p.parseSelect(); err != nil {
+		return nil, err
+	}
+	if len(p.stmt.Terms) == 0 {
+		return nil, ErrNoMatch
+	}
+	return p.stmt, nil
+}
+
+func (p *parser) parseSelect() error {
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return err
+	}
+	for {
+		if p.acceptSymbol("*") {
+			p.stmt.Columns = append(p.stmt.Columns, IDColumn, DocColumn, ScoreColumn)
+		} else {
+			column, err := p.expectIdent()
+			if err != nil {
+				return err
+			}
+			p.stmt.Columns = append(p.stmt.Columns, column)
+		}
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return err
+	}
+	table, err := p.expectIdent()
+	if err != nil {
+		return err
+	}
+	p.stmt.Table = table
+
+	if p.acceptKeyword("WHERE") {
+		if p.stmt.where, err = p.parseOr(); err != nil {
+			return err
+		}
+	}
+
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return err
+		}
+		for {
+			column, err := p.expectIdent()
+			if err != nil {
+				return err
+			}
+			key := bm25.SortField{Field: column}
+			switch {
+			case column == ScoreColumn:
+				key.Field = bm25.ScoreField
+			case column == IDColumn || column == DocColumn:
+				return p.errorf("cannot order by %s", column)
+			}
+			if p.acceptKeyword("DESC") {
+				key.Descending = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			p.stmt.OrderBy = append(p.stmt.OrderBy, key)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("LIMIT") {
+		t := p.next()
+		if t.kind != tokenNumber || t.num < 1 || t.num != float64(int(t.num)) {
+			return fmt.Errorf("%w at offset %d: LIMIT must be a positive integer, got %s", ErrSyntax, t.offset, t)
+		}
+		p.stmt.Limit = int(t.num)
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return p.errorf("unexpected %s", t)
+	}
+	return nil
+}
+
+// parseOr parses a disjunction, the lowest precedence level of a condition.
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses a conjunction.
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+// parseNot parses a negation. The terms of negated MATCH predicates do not take part in
+// scoring.
+func (p *parser) parseNot() (expr, error) {
+	if !p.acceptKeyword("NOT") {
+		return p.parsePredicate()
+	}
+
+	p.negated = !p.negated
+	e, err := p.parseNot()
+	p.negated = !p.negated
+	if err != nil {
+		return nil, err
+	}
+	return notExpr{e}, nil
+}
+
+// parsePredicate parses a parenthesized condition, a MATCH predicate or a comparison.
+func (p *parser) parsePredicate() (expr, error) {
+	if p.acceptSymbol("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+
+	if p.acceptKeyword("MATCH") {
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		t := p.next()
+		if t.kind != tokenString {
+			return nil, fmt.Errorf("%w at offset %d: MATCH takes a string, got %s", ErrSyntax, t.offset, t)
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		terms := p.tokenizer(t.text)
+		if !p.negated {
+			p.stmt.Terms = append(p.stmt.Terms, terms...)
+		}
+		return matchExpr{terms: terms}, nil
+	}
+
+	field, err := p.expectIdent()
+	if err != nil {
+		return nil, err
+	}
+	if field == ScoreColumn || field == DocColumn {
+		return nil, p.errorf("cannot filter on %s", field)
+	}
+
+	switch {
+	case p.acceptKeyword("IS"):
+		not := p.acceptKeyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		var e expr = nullExpr{field: field}
+		if not {
+			e = notExpr{e}
+		}
+		return e, nil
+
+	case p.acceptKeyword("NOT"):
+		if err := p.expectKeyword("IN"); err != nil {
+			return nil, err
+		}
+		in, err := p.parseIn(field)
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{in}, nil
+
+	case p.acceptKeyword("IN"):
+		return p.parseIn(field)
+	}
+
+	op := p.next()
+	switch op.text {
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+	default:
+		return nil, fmt.Errorf("%w at offset %d: expected a comparison operator, got %s", ErrSyntax, op.offset, op)
+	}
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	return newComparison(field, op.text, value)
+}
+
+// parseIn parses the value list of an IN predicate.
+func (p *parser) parseIn(field string) (expr, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	var values []any
+	for {
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	return inExpr{field: field, values: values}, nil
+}
+
+// parseLiteral parses a string or a number.
+func (p *parser) parseLiteral() (any, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenNumber:
+		return t.num, nil
+	default:
+		return nil, fmt.Errorf("%w at offset %d: expected a string or a number, got %s", ErrSyntax, t.offset, t)
+	}
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) acceptKeyword(keyword string) bool {
+	if t := p.peek(); t.kind == tokenKeyword && t.text == keyword {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) acceptSymbol(symbol string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == symbol {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return p.errorf("expected %s, got %s", keyword, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	if !p.acceptSymbol(symbol) {
+		return p.errorf("expected %q, got %s", symbol, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) expectIdent() (string, error) {
+	t := p.peek()
+	if t.kind != tokenIdent {
+		return "", p.errorf("expected a name, got %s", t)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// errorf returns a syntax error at the offset of the current token.
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at offset %d: %s", ErrSyntax, p.peek().offset, fmt.Sprintf(format, args...))
+}
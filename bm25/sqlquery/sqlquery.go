@@ -0,0 +1,134 @@
+// Package sqlquery runs SQL-like queries against indexes, for users more at home in SQL
+// than in Go:
+//
+//	SELECT doc, score FROM idx WHERE MATCH('go channels') AND lang = 'en' LIMIT 10
+//
+// A statement selects from a single index. The WHERE clause combines MATCH predicates,
+// comparisons (=, !=, <>, <, <=, >, >=), IN lists and IS [NOT] NULL checks with AND, OR,
+// NOT and parentheses, and must contain at least one MATCH predicate that is not negated:
+// the text of those predicates forms the BM25 query that ranks the rows. Comparisons read
+// the metadata of the documents; strings compared with an ordering operator are dates.
+//
+// The columns id, doc and score are the external ID, the text and the score of a
+// document, and every other column a metadata field. Rows are ordered by descending
+// score unless ORDER BY names other keys, and without LIMIT all matching documents are
+// returned. Keywords are case-insensitive; names containing spaces or keywords can be
+// double-quoted.
+package sqlquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// The built-in columns of every index.
+const (
+	IDColumn    = "id"
+	DocColumn   = "doc"
+	ScoreColumn = "score"
+)
+
+var (
+	// ErrSyntax is returned for statements that cannot be parsed.
+	ErrSyntax = errors.New("syntax error")
+
+	// ErrNoMatch is returned for statements without a MATCH predicate to rank by.
+	ErrNoMatch = errors.New("WHERE clause must contain a MATCH predicate")
+
+	// ErrUnknownTable is returned for statements reading from an index that is not registered.
+	ErrUnknownTable = errors.New("unknown table")
+)
+
+// Request returns the search request that ranks the rows of the statement.
+func (s *Statement) Request(base *bm25.Bm25Base) bm25.SearchRequest {
+	req := bm25.SearchRequest{
+		Query: s.Terms,
+		N:     s.Limit,
+		Sort:  s.OrderBy,
+		Filter: func(docID int) bool {
+			return s.where.matches(base, docID)
+		},
+	}
+	if req.N == 0 {
+		req.N = max(base.CorpusSize(), 1)
+	}
+	return req
+}
+
+// Result holds the rows returned by a statement, one value per column.
+type Result struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// table is an index registered with a DB.
+type table struct {
+	index bm25.BM25
+	base  *bm25.Bm25Base
+}
+
+// DB runs statements against a set of named indexes. Register must not be called
+// concurrently with Query.
+type DB struct {
+	tokenizer func(string) []string
+	tables    map[string]table
+}
+
+// NewDB creates a DB that tokenizes the text of MATCH predicates with the tokenizer. It
+// should be the tokenizer of the indexes.
+func NewDB(tokenizer func(string) []string) (*DB, error) {
+	if tokenizer == nil {
+		return nil, bm25.ErrNilTokenizer
+	}
+	return &DB{tokenizer: tokenizer, tables: make(map[string]table)}, nil
+}
+
+// Register makes an index available to statements under the given name. base must be
+// the Bm25Base of the index.
+func (db *DB) Register(name string, index bm25.BM25, base *bm25.Bm25Base) error {
+	if base == nil {
+		return bm25.ErrNilBase
+	}
+	db.tables[name] = table{index: index, base: base}
+	return nil
+}
+
+// Query parses a statement and runs it.
+func (db *DB) Query(ctx context.Context, query string) (*Result, error) {
+	stmt, err := Parse(query, db.tokenizer)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := db.tables[stmt.Table]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTable, stmt.Table)
+	}
+
+	resp, err := t.index.Search(ctx, stmt.Request(t.base))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Columns: stmt.Columns, Rows: make([][]any, len(resp.Results))}
+	for i, r := range resp.Results {
+		row := make([]any, len(stmt.Columns))
+		for j, column := range stmt.Columns {
+			switch column {
+			case IDColumn:
+				row[j] = t.base.ExternalID(r.DocID)
+			case DocColumn:
+				row[j] = r.Doc
+			case ScoreColumn:
+				row[j] = r.Score
+			default:
+				row[j] = t.base.Metadata(r.DocID)[column]
+			}
+		}
+		result.Rows[i] = row
+	}
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package sqlquery_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/sqlquery"
+)
+
+func tokenize(s string) []string {
+	return strings.Fields(strings.ToLower(s))
+}
+
+func newDB(t *testing.T) *sqlquery.DB {
+	t.Helper()
+	builder, _ := bm25.NewBuilder(tokenize, nil, bm25.BuildOptions{})
+	docs := []bm25.Document{
+		{ID: "a", Text: "go channels and goroutines", Metadata: map[string]any{"lang": "en", "stars": 120, "updated": time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}},
+		{ID: "b", Text: "go kanäle und goroutinen", Metadata: map[string]any{"lang": "de", "stars": 40}},
+		{ID: "c", Text: "rust channels", Metadata: map[string]any{"lang": "en", "stars": 300, "updated": time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)}},
+		{ID: "d", Text: "python generators"},
+	}
+	for _, doc := range docs {
+		if _, err := builder.Add(doc); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	base, _ := builder.Build()
+	okapi, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+
+	db, err := sqlquery.NewDB(tokenize)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := db.Register("idx", okapi, okapi.Bm25Base); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return db
+}
+
+// ids returns the first column of every row.
+func ids(result *sqlquery.Result) []any {
+	column := []any{}
+	for _, row := range result.Rows {
+		column = append(column, row[0])
+	}
+	return column
+}
+
+func TestQuery(t *testing.T) {
+	db := newDB(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		query    string
+		expected []any
+	}{
+		{"SELECT id FROM idx WHERE MATCH('go channels') AND lang='en' LIMIT 10", []any{"a", "c"}},
+		{"select id from idx where match('go') limit 1", []any{"a"}},
+		{"SELECT id FROM idx WHERE MATCH('channels') AND NOT lang = 'de' ORDER BY stars DESC", []any{"c", "a"}},
+		{"SELECT id FROM idx WHERE MATCH('go channels') AND (stars >= 100 OR lang IN ('de'))", []any{"a", "c", "b"}},
+		{"SELECT id FROM idx WHERE MATCH('channels python') AND lang IS NULL", []any{"d"}},
+		{"SELECT id FROM idx WHERE MATCH('channels') AND updated < '2024-01-01'", []any{"c"}},
+		{"SELECT id FROM idx WHERE MATCH('go') AND id NOT IN ('a')", []any{"b"}},
+		{"SELECT id FROM idx WHERE MATCH('go channels') AND lang <> 'en'", []any{"b"}},
+		{"SELECT id FROM idx WHERE MATCH('go') AND NOT MATCH('goroutinen')", []any{"a"}},
+		{"SELECT id FROM idx WHERE MATCH('channels') AND stars = 300", []any{"c"}},
+	}
+
+	for _, test := range tests {
+		// Test case: Running a statement
+		result, err := db.Query(ctx, test.query)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.query, err)
+			continue
+		}
+		if got := ids(result); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %v, but got %v", test.query, test.expected, got)
+		}
+	}
+
+	// Test case: Selected columns
+	result, err := db.Query(ctx, `SELECT *, stars, "lang" FROM idx WHERE MATCH('rust') LIMIT 5`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Columns, []string{"id", "doc", "score", "stars", "lang"}) {
+		t.Errorf("Unexpected columns: %v", result.Columns)
+	}
+	if len(result.Rows) != 1 {
+		t.Fatalf("Expected 1 row, but got %d", len(result.Rows))
+	}
+	row := result.Rows[0]
+	if row[0] != "c" || row[1] != "rust channels" || row[3] != 300 || row[4] != "en" {
+		t.Errorf("Unexpected row: %v", row)
+	}
+	if score, ok := row[2].(float64); !ok || score <= 0 {
+		t.Errorf("Expected a positive score, but got %v", row[2])
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	db := newDB(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		query    string
+		expected error
+	}{
+		{"SELECT id FROM idx WHERE lang = 'en'", sqlquery.ErrNoMatch},
+		{"SELECT id FROM idx WHERE NOT MATCH('go')", sqlquery.ErrNoMatch},
+		{"SELECT id FROM other WHERE MATCH('go')", sqlquery.ErrUnknownTable},
+		{"SELECT id FROM idx WHERE MATCH('go') LIMIT 0", sqlquery.ErrSyntax},
+		{"SELECT id FROM idx WHERE MATCH('go", sqlquery.ErrSyntax},
+		{"SELECT id FROM idx WHERE MATCH('go') AND score > 1", sqlquery.ErrSyntax},
+		{"SELECT id FROM idx WHERE MATCH('go') AND updated > 'yesterday'", sqlquery.ErrSyntax},
+		{"SELECT id FROM idx WHERE MATCH('go') GROUP BY lang", sqlquery.ErrSyntax},
+		{"SELECT id idx", sqlquery.ErrSyntax},
+	}
+
+	for _, test := range tests {
+		// Test case: Invalid statements
+		if _, err := db.Query(ctx, test.query); !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v, but got %v", test.query, test.expected, err)
+		}
+	}
+}
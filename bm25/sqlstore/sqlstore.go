@@ -0,0 +1,190 @@
+// Package sqlstore keeps the original documents and their metadata in a SQL table, so a
+// small deployment gets durable storage and BM25 retrieval from a single embedded SQLite
+// file: the index fetches the documents it returns through the DocStore interface, and
+// is rebuilt from the table on startup.
+//
+// The store works with any database/sql driver for SQLite, e.g. modernc.org/sqlite or
+// github.com/mattn/go-sqlite3, which the application imports, so this module stays free
+// of cgo and third-party dependencies:
+//
+//	db, err := sql.Open("sqlite", "docs.db")
+//	store, err := sqlstore.New(ctx, db, "documents")
+//	builder, err := bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{})
+//	_, err = store.Load(ctx, builder)
+//	base, err := builder.Build()
+//	err = base.SetDocStore(store)
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// ErrNotFound is returned for documents that are not in the store.
+var ErrNotFound = errors.New("document not found")
+
+// tableName matches the table names New accepts, as they cannot be passed as parameters.
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Store stores documents in a SQL table, keyed by their internal ID. It implements
+// bm25.DocStore. The TTL of documents is not stored.
+type Store struct {
+	db    *sql.DB
+	table string
+}
+
+// New returns a store using the given table, creating it if it does not exist.
+func New(ctx context.Context, db *sql.DB, table string) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("db cannot be nil")
+	}
+	if !tableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	s := &Store{db: db, table: table}
+	_, err := db.ExecContext(ctx, s.query(`CREATE TABLE IF NOT EXISTS {table} (
+		doc_id INTEGER PRIMARY KEY,
+		ext_id TEXT,
+		text TEXT NOT NULL,
+		metadata TEXT
+	)`))
+	if err != nil {
+		return nil, fmt.Errorf("creating table %s: %w", table, err)
+	}
+	return s, nil
+}
+
+// query returns a statement with the name of the table filled in.
+func (s *Store) query(statement string) string {
+	return strings.ReplaceAll(statement, "{table}", s.table)
+}
+
+// Put stores a document under the given internal ID, replacing any document stored
+// under it before. Metadata is stored as JSON.
+func (s *Store) Put(ctx context.Context, docID int, doc bm25.Document) error {
+	var extID, metadata sql.NullString
+	if doc.ID != "" {
+		extID = sql.NullString{String: doc.ID, Valid: true}
+	}
+	if doc.Metadata != nil {
+		data, err := json.Marshal(doc.Metadata)
+		if err != nil {
+			return fmt.Errorf("encoding metadata of document %d: %w", docID, err)
+		}
+		metadata = sql.NullString{String: string(data), Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, s.query(`INSERT OR REPLACE INTO {table} (doc_id, ext_id, text, metadata) VALUES (?, ?, ?, ?)`),
+		docID, extID, doc.Text, metadata)
+	if err != nil {
+		return fmt.Errorf("storing document %d: %w", docID, err)
+	}
+	return nil
+}
+
+// Add adds a document to an index and stores it under its new internal ID.
+func (s *Store) Add(ctx context.Context, base *bm25.Bm25Base, doc bm25.Document) (int, error) {
+	if base == nil {
+		return 0, bm25.ErrNilBase
+	}
+
+	docID, err := base.AddDocument(doc)
+	if err != nil {
+		return 0, err
+	}
+	return docID, s.Put(ctx, docID, doc)
+}
+
+// Get returns the text of the document with the given internal ID.
+func (s *Store) Get(docID int) (string, error) {
+	return s.GetContext(context.Background(), docID)
+}
+
+// GetContext is like Get, with a context.
+func (s *Store) GetContext(ctx context.Context, docID int) (string, error) {
+	var text string
+	err := s.db.QueryRowContext(ctx, s.query(`SELECT text FROM {table} WHERE doc_id = ?`), docID).Scan(&text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %d", ErrNotFound, docID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("fetching document %d: %w", docID, err)
+	}
+	return text, nil
+}
+
+// Metadata returns the metadata of the document with the given internal ID, or nil if
+// it has none. Numbers are returned as json.Number and dates as RFC 3339 strings, both
+// of which range filters and sorting accept.
+func (s *Store) Metadata(ctx context.Context, docID int) (map[string]any, error) {
+	var metadata sql.NullString
+	err := s.db.QueryRowContext(ctx, s.query(`SELECT metadata FROM {table} WHERE doc_id = ?`), docID).Scan(&metadata)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, docID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching metadata of document %d: %w", docID, err)
+	}
+	return decodeMetadata(docID, metadata)
+}
+
+// Load adds all stored documents to a builder, in the order of their internal IDs, and
+// returns their number. The builder must be empty and the stored IDs contiguous from 0,
+// so the documents keep their IDs in the rebuilt index.
+func (s *Store) Load(ctx context.Context, builder *bm25.Builder) (int, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(`SELECT doc_id, ext_id, text, metadata FROM {table} ORDER BY doc_id`))
+	if err != nil {
+		return 0, fmt.Errorf("loading documents: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var docID int
+		var extID, metadata sql.NullString
+		var doc bm25.Document
+		if err := rows.Scan(&docID, &extID, &doc.Text, &metadata); err != nil {
+			return count, fmt.Errorf("loading documents: %w", err)
+		}
+		doc.ID = extID.String
+		if doc.Metadata, err = decodeMetadata(docID, metadata); err != nil {
+			return count, err
+		}
+
+		added, err := builder.Add(doc)
+		if err != nil {
+			return count, fmt.Errorf("adding document %d: %w", docID, err)
+		}
+		if added != docID {
+			return count, fmt.Errorf("document %d was added as %d: stored IDs must be contiguous from 0", docID, added)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("loading documents: %w", err)
+	}
+	return count, nil
+}
+
+// decodeMetadata decodes the stored JSON metadata of a document.
+func decodeMetadata(docID int, metadata sql.NullString) (map[string]any, error) {
+	if !metadata.Valid {
+		return nil, nil
+	}
+
+	var decoded map[string]any
+	decoder := json.NewDecoder(strings.NewReader(metadata.String))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("decoding metadata of document %d: %w", docID, err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,116 @@
+package sqlstore_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fakeDriver is an in-memory database/sql driver understanding just the statements the
+// store issues, so the tests do not depend on a SQLite driver.
+type fakeDriver struct {
+	mu     sync.Mutex
+	tables map[string]map[int64][]driver.Value // DSN -> doc_id -> row
+}
+
+var fake = &fakeDriver{tables: make(map[string]map[int64][]driver.Value)}
+
+func init() {
+	sql.Register("fake", fake)
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{driver: d, name: name}, nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+	name   string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.conn.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS"):
+		if d.tables[s.conn.name] == nil {
+			d.tables[s.conn.name] = make(map[int64][]driver.Value)
+		}
+	case strings.HasPrefix(s.query, "INSERT OR REPLACE INTO"):
+		d.tables[s.conn.name][args[0].(int64)] = args
+	default:
+		return nil, fmt.Errorf("unsupported statement %q", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.conn.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	table := d.tables[s.conn.name]
+
+	switch {
+	case strings.HasPrefix(s.query, "SELECT doc_id, ext_id, text, metadata"):
+		rows := &fakeRows{columns: []string{"doc_id", "ext_id", "text", "metadata"}}
+		for _, row := range table {
+			rows.rows = append(rows.rows, row)
+		}
+		sort.Slice(rows.rows, func(i, j int) bool { return rows.rows[i][0].(int64) < rows.rows[j][0].(int64) })
+		return rows, nil
+	case strings.HasPrefix(s.query, "SELECT text"), strings.HasPrefix(s.query, "SELECT metadata"):
+		column := 2
+		if strings.HasPrefix(s.query, "SELECT metadata") {
+			column = 3
+		}
+		rows := &fakeRows{columns: []string{"value"}}
+		if row, ok := table[args[0].(int64)]; ok {
+			rows.rows = append(rows.rows, []driver.Value{row[column]})
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unsupported query %q", s.query)
+	}
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,90 @@
+package sqlstore_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/sqlstore"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	tokenizer := func(s string) []string { return strings.Fields(strings.ToLower(s)) }
+	db, err := sql.Open("fake", t.Name())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	// Test case: Invalid table names
+	if _, err := sqlstore.New(ctx, db, "docs; DROP TABLE users"); err == nil {
+		t.Errorf("Expected an error for an invalid table name, but got nil")
+	}
+
+	store, err := sqlstore.New(ctx, db, "documents")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Adding documents to a live index stores them
+	builder, _ := bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{})
+	if _, err := builder.Add(bm25.Document{Text: "placeholder"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	base, _ := builder.Build()
+	if err := store.Put(ctx, 0, bm25.Document{Text: "The Quick Brown Fox"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	docID, err := store.Add(ctx, base, bm25.Document{ID: "doc-1", Text: "A Lazy Dog", Metadata: map[string]any{"year": 2024}})
+	if err != nil || docID != 1 {
+		t.Fatalf("Expected document 1, but got %d (error: %v)", docID, err)
+	}
+
+	// Test case: Fetching text and metadata
+	text, err := store.Get(1)
+	if err != nil || text != "A Lazy Dog" {
+		t.Errorf("Expected the original text, but got %q (error: %v)", text, err)
+	}
+	metadata, err := store.Metadata(ctx, 1)
+	if err != nil || metadata["year"] == nil || metadata["year"].(interface{ String() string }).String() != "2024" {
+		t.Errorf("Expected the stored metadata, but got %v (error: %v)", metadata, err)
+	}
+	if _, err := store.Get(7); !errors.Is(err, sqlstore.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, but got %v", err)
+	}
+
+	// Test case: Rebuilding the index from the store
+	builder, _ = bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{})
+	count, err := store.Load(ctx, builder)
+	if err != nil || count != 2 {
+		t.Fatalf("Expected 2 documents, but got %d (error: %v)", count, err)
+	}
+	rebuilt, _ := builder.Build()
+	if id, ok := rebuilt.LookupID("doc-1"); !ok || id != 1 {
+		t.Errorf("Expected doc-1 to keep ID 1, but got %d", id)
+	}
+	if err := rebuilt.IndexDocValues("year"); err != nil {
+		t.Errorf("Expected stored metadata to be indexable, but got %v", err)
+	}
+
+	// Test case: Returning the original documents from search results
+	okapi, _ := bm25.NewBM25OkapiFromBase(rebuilt, 1.5, 0.75)
+	if err := okapi.SetDocStore(store); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, err := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"fox"}, N: 1})
+	if err != nil || resp.Results[0].Doc != "The Quick Brown Fox" {
+		t.Errorf("Expected the original text, but got %+v (error: %v)", resp, err)
+	}
+
+	// Test case: Loading into a non-empty builder
+	builder, _ = bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{})
+	_, _ = builder.Add(bm25.Document{Text: "existing"})
+	if _, err := store.Load(ctx, builder); err == nil {
+		t.Errorf("Expected an error for a non-empty builder, but got nil")
+	}
+}
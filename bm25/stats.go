@@ -0,0 +1,112 @@
+package bm25
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Percentiles summarizes a distribution of integer values.
+type Percentiles struct {
+	Min int
+	P50 int
+	P90 int
+	P99 int
+	Max int
+}
+
+// TermStat holds the statistics of a single vocabulary term.
+type TermStat struct {
+	Term    string
+	DocFreq int
+}
+
+// Stats is a report on the statistics of an index, meant for debugging relevance and for
+// operational dashboards.
+type Stats struct {
+	CorpusSize     int
+	VocabularySize int
+	AvgDocLen      float64
+	DocLengths     Percentiles
+
+	// PostingLengths is the distribution of the number of documents per term.
+	PostingLengths Percentiles
+
+	// TopTerms holds the terms with the highest document frequency, most frequent first.
+	TopTerms []TermStat
+}
+
+// Stats computes a statistics report for the index, including the topTerms most frequent terms.
+func (b *Bm25Base) Stats(topTerms int) Stats {
+	stats := Stats{
+		CorpusSize: b.corpusSize,
+		AvgDocLen:  b.avgDocLen,
+		DocLengths: computePercentiles(append([]int(nil), b.docLengths...)),
+	}
+
+	var terms []TermStat
+	var postingLengths []int
+	b.forEachTerm(func(term string, termFreq int) {
+		terms = append(terms, TermStat{Term: term, DocFreq: termFreq})
+		postingLengths = append(postingLengths, termFreq)
+	})
+	stats.VocabularySize = len(terms)
+	stats.PostingLengths = computePercentiles(postingLengths)
+
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].DocFreq != terms[j].DocFreq {
+			return terms[i].DocFreq > terms[j].DocFreq
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	stats.TopTerms = terms[:Min(max(topTerms, 0), len(terms))]
+
+	return stats
+}
+
+// String formats the report as a human-readable table.
+func (s Stats) String() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Corpus size:\t%d\n", s.CorpusSize)
+	fmt.Fprintf(w, "Vocabulary size:\t%d\n", s.VocabularySize)
+	fmt.Fprintf(w, "Average document length:\t%.2f\n", s.AvgDocLen)
+	fmt.Fprintf(w, "Document lengths:\t%s\n", s.DocLengths)
+	fmt.Fprintf(w, "Posting list lengths:\t%s\n", s.PostingLengths)
+	if len(s.TopTerms) > 0 {
+		fmt.Fprintf(w, "Most frequent terms:\t\n")
+		for _, term := range s.TopTerms {
+			fmt.Fprintf(w, "  %s\t%d\n", term.Term, term.DocFreq)
+		}
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// String formats the percentiles on a single line.
+func (p Percentiles) String() string {
+	return fmt.Sprintf("min=%d p50=%d p90=%d p99=%d max=%d", p.Min, p.P50, p.P90, p.P99, p.Max)
+}
+
+// computePercentiles computes the percentiles of the given values using the nearest-rank
+// method. The slice is sorted in place.
+func computePercentiles(values []int) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+
+	sort.Ints(values)
+	rank := func(p float64) int {
+		return values[int(math.Ceil(p/100*float64(len(values))))-1]
+	}
+
+	return Percentiles{
+		Min: values[0],
+		P50: rank(50),
+		P90: rank(90),
+		P99: rank(99),
+		Max: values[len(values)-1],
+	}
+}
@@ -0,0 +1,90 @@
+package bm25
+
+import "sort"
+
+// DetectStopwords returns the corpus-specific stopwords: terms that appear in at least
+// minDocRatio of the documents and whose IDF is at most maxIDF, so they contribute next
+// to nothing to the ranking. The list is sorted by descending document frequency.
+func (b *Bm25Base) DetectStopwords(minDocRatio float64, maxIDF float64) ([]string, error) {
+	if minDocRatio <= 0 || minDocRatio > 1 {
+		return nil, invalidParam("minDocRatio", minDocRatio, "must be between 0 (exclusive) and 1")
+	}
+
+	stopwords := []string{}
+	termFreqs := make(map[string]int)
+	b.forEachTerm(func(term string, termFreq int) {
+		if float64(termFreq)/float64(b.corpusSize) < minDocRatio {
+			return
+		}
+
+		idf, _ := b.IDF(term) // Vocabulary terms are never empty
+		if idf <= maxIDF {
+			stopwords = append(stopwords, term)
+			termFreqs[term] = termFreq
+		}
+	})
+
+	sort.Slice(stopwords, func(i, j int) bool {
+		fi, fj := termFreqs[stopwords[i]], termFreqs[stopwords[j]]
+		if fi != fj {
+			return fi > fj
+		}
+		return stopwords[i] < stopwords[j]
+	})
+
+	if b.logger != nil {
+		b.logger.Printf("Detected %d stopwords: %v", len(stopwords), stopwords)
+	}
+
+	return stopwords, nil
+}
+
+// ExcludeStopwords excludes the given terms from scoring. Passing nil or an empty slice
+// clears any previously excluded stopwords.
+func (b *Bm25Base) ExcludeStopwords(stopwords []string) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	b.settings++
+	if len(stopwords) == 0 {
+		b.stopwords = nil
+		return nil
+	}
+
+	b.stopwords = make(map[string]struct{}, len(stopwords))
+	for _, term := range stopwords {
+		b.stopwords[term] = struct{}{}
+	}
+	return nil
+}
+
+// ExcludeDetectedStopwords detects the corpus-specific stopwords using DetectStopwords,
+// excludes them from scoring and returns the detected list for review.
+func (b *Bm25Base) ExcludeDetectedStopwords(minDocRatio float64, maxIDF float64) ([]string, error) {
+	stopwords, err := b.DetectStopwords(minDocRatio, maxIDF)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.ExcludeStopwords(stopwords); err != nil {
+		return nil, err
+	}
+	return stopwords, nil
+}
+
+// Stopwords returns the terms currently excluded from scoring, sorted alphabetically.
+func (b *Bm25Base) Stopwords() []string {
+	stopwords := make([]string, 0, len(b.stopwords))
+	for term := range b.stopwords {
+		stopwords = append(stopwords, term)
+	}
+	sort.Strings(stopwords)
+	return stopwords
+}
+
+// isStopword reports whether the term has been excluded from scoring.
+func (b *Bm25Base) isStopword(term string) bool {
+	_, ok := b.stopwords[term]
+	return ok
+}
@@ -0,0 +1,152 @@
+package bm25
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrDuplicateSubscription is returned when a subscription is registered under a name that is already in use.
+var ErrDuplicateSubscription = errors.New("duplicate subscription name")
+
+// Match is a notification that a newly added document matches a saved query.
+type Match struct {
+	Subscription string
+	DocID        int
+	ExternalID   string
+	Score        float64
+}
+
+// documentNotifier is implemented by indexes that report the documents added to them.
+type documentNotifier interface {
+	OnDocumentAdded(hook func(docID int))
+	ExternalID(docID int) string
+	rewriteTerms(query []string) []string
+}
+
+// subscription is a saved query with the callback notified about its matches.
+type subscription struct {
+	query     []string
+	threshold float64
+	notify    func(Match)
+}
+
+// SubscriptionManager holds named saved queries and notifies their subscribers whenever
+// a document added to the index with AddDocument scores above the threshold of a query.
+// It is safe for concurrent use, but matches are delivered on the goroutine adding the
+// document, so slow subscribers delay ingestion.
+type SubscriptionManager struct {
+	mu    sync.RWMutex
+	index BM25
+	subs  map[string]*subscription
+}
+
+// NewSubscriptionManager creates a new SubscriptionManager for the given index, which
+// must be a BM25 variant built on a Bm25Base.
+func NewSubscriptionManager(index BM25) (*SubscriptionManager, error) {
+	notifier, ok := index.(documentNotifier)
+	if !ok {
+		return nil, ErrNotImplemented
+	}
+
+	m := &SubscriptionManager{
+		index: index,
+		subs:  make(map[string]*subscription),
+	}
+	notifier.OnDocumentAdded(m.documentAdded)
+	return m, nil
+}
+
+// Subscribe registers a saved query under the given name. The callback is called for
+// every added document whose score for the query exceeds the threshold.
+func (m *SubscriptionManager) Subscribe(name string, query []string, threshold float64, callback func(Match)) error {
+	if len(query) == 0 {
+		return ErrEmptyQuery
+	}
+
+	if callback == nil {
+		return invalidParam("callback", callback, "cannot be nil")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.subs[name]; ok {
+		return ErrDuplicateSubscription
+	}
+
+	m.subs[name] = &subscription{
+		query:     append([]string(nil), query...),
+		threshold: threshold,
+		notify:    callback,
+	}
+	return nil
+}
+
+// SubscribeChan registers a saved query whose matches are sent to the given channel.
+// Sends block until the match is received, so the channel should be buffered.
+func (m *SubscriptionManager) SubscribeChan(name string, query []string, threshold float64, ch chan<- Match) error {
+	if ch == nil {
+		return invalidParam("ch", ch, "cannot be nil")
+	}
+
+	return m.Subscribe(name, query, threshold, func(match Match) { ch <- match })
+}
+
+// Unsubscribe removes the saved query with the given name and reports whether it existed.
+func (m *SubscriptionManager) Unsubscribe(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.subs[name]
+	delete(m.subs, name)
+	return ok
+}
+
+// Subscriptions returns the names of all saved queries, sorted alphabetically.
+func (m *SubscriptionManager) Subscriptions() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.subs))
+	for name := range m.subs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// documentAdded scores a newly added document against all saved queries and notifies
+// the subscribers of the matching ones, in alphabetical order of their names. The
+// queries are rewritten and scored like a search, so the scores compare to those of
+// Search.
+func (m *SubscriptionManager) documentAdded(docID int) {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.subs))
+	subs := make(map[string]*subscription, len(m.subs))
+	for name, sub := range m.subs {
+		names = append(names, name)
+		subs[name] = sub
+	}
+	m.mu.RUnlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		sub := subs[name]
+		query := m.index.(documentNotifier).rewriteTerms(sub.query)
+		if len(query) == 0 {
+			continue
+		}
+		scores, err := m.index.GetBatchScores(query, []int{docID})
+		if err != nil || scores[0] <= sub.threshold {
+			continue
+		}
+
+		sub.notify(Match{
+			Subscription: name,
+			DocID:        docID,
+			ExternalID:   m.index.(documentNotifier).ExternalID(docID),
+			Score:        scores[0],
+		})
+	}
+}
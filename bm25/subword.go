@@ -0,0 +1,171 @@
+package bm25
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// SubwordOptions configures the subword fallback for out-of-vocabulary query terms.
+type SubwordOptions struct {
+	// N is the length of the character n-grams. Defaults to 3.
+	N int
+
+	// MinSimilarity is the minimum Dice coefficient between the n-grams of a query term
+	// and those of a vocabulary term for the latter to be used. Defaults to 0.5.
+	MinSimilarity float64
+
+	// MaxExpansions is the maximum number of vocabulary terms a query term falls back
+	// to. Defaults to 3.
+	MaxExpansions int
+}
+
+// subwordIndex maps the character n-grams of the vocabulary to the terms containing them.
+type subwordIndex struct {
+	opts   SubwordOptions
+	grams  map[string][]string
+	counts map[string]int // Number of distinct n-grams of every term
+}
+
+// EnableSubwordFallback indexes the character n-grams of the vocabulary, so query terms
+// that appear in no document fall back to the vocabulary terms sharing most of their
+// n-grams, e.g. a misspelling or a rare inflection to its common forms. Search applies
+// the fallback automatically; ExpandQuery applies it to queries for the other scoring
+// methods.
+func (b *Bm25Base) EnableSubwordFallback(opts SubwordOptions) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	if opts.N == 0 {
+		opts.N = 3
+	}
+	if opts.MinSimilarity == 0 {
+		opts.MinSimilarity = 0.5
+	}
+	if opts.MaxExpansions == 0 {
+		opts.MaxExpansions = 3
+	}
+	if opts.N < 1 {
+		return invalidParam("N", opts.N, "must be a positive integer")
+	}
+	if opts.MinSimilarity < 0 || opts.MinSimilarity > 1 {
+		return invalidParam("MinSimilarity", opts.MinSimilarity, "must be between 0 and 1")
+	}
+	if opts.MaxExpansions < 1 {
+		return invalidParam("MaxExpansions", opts.MaxExpansions, "must be a positive integer")
+	}
+
+	b.subwordOpts = &opts
+	b.subwords = nil
+	b.subwordIndex()
+	return nil
+}
+
+// ExpandQuery replaces every query term that appears in no document with the vocabulary
+// terms it falls back to, if the subword fallback is enabled. Other terms are kept.
+func (b *Bm25Base) ExpandQuery(query []string) []string {
+	if b.subwordOpts == nil {
+		return query
+	}
+
+	expanded := make([]string, 0, len(query))
+	for _, term := range query {
+		if termFreq, _ := b.docFreq(term); termFreq > 0 || term == "" {
+			expanded = append(expanded, term)
+			continue
+		}
+		expanded = append(expanded, b.SubwordExpand(term)...)
+	}
+	return expanded
+}
+
+// SubwordExpand returns the vocabulary terms a term falls back to, most similar first.
+// It returns nil if the subword fallback is not enabled.
+func (b *Bm25Base) SubwordExpand(term string) []string {
+	index := b.subwordIndex()
+	if index == nil {
+		return nil
+	}
+
+	grams := charNGrams(term, index.opts.N)
+	shared := make(map[string]int)
+	for gram := range grams {
+		for _, candidate := range index.grams[gram] {
+			shared[candidate]++
+		}
+	}
+
+	type match struct {
+		term       string
+		similarity float64
+	}
+	var matches []match
+	for candidate, count := range shared {
+		similarity := 2 * float64(count) / float64(len(grams)+index.counts[candidate])
+		if similarity >= index.opts.MinSimilarity {
+			matches = append(matches, match{candidate, similarity})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].similarity != matches[j].similarity {
+			return matches[i].similarity > matches[j].similarity
+		}
+		return matches[i].term < matches[j].term
+	})
+
+	terms := make([]string, 0, Min(len(matches), index.opts.MaxExpansions))
+	for _, m := range matches[:Min(len(matches), index.opts.MaxExpansions)] {
+		terms = append(terms, m.term)
+	}
+	return terms
+}
+
+// subwordIndex returns the n-gram index of the vocabulary, building it if needed. The
+// index is cached unless the caches are read-only, and dropped whenever the vocabulary
+// changes.
+func (b *Bm25Base) subwordIndex() *subwordIndex {
+	if b.subwordOpts == nil {
+		return nil
+	}
+	if b.subwords != nil {
+		return b.subwords
+	}
+
+	index := &subwordIndex{
+		opts:   *b.subwordOpts,
+		grams:  make(map[string][]string),
+		counts: make(map[string]int),
+	}
+	b.forEachTerm(func(term string, termFreq int) {
+		grams := charNGrams(term, index.opts.N)
+		index.counts[term] = len(grams)
+		for gram := range grams {
+			index.grams[gram] = append(index.grams[gram], term)
+		}
+	})
+
+	if b.cachesWritable() {
+		b.subwords = index
+	}
+	return index
+}
+
+// charNGrams returns the distinct character n-grams of a term, padded with boundary
+// markers so that prefixes and suffixes form n-grams of their own. Terms shorter than n
+// yield the padded term itself.
+func charNGrams(term string, n int) map[string]struct{} {
+	runes := make([]rune, 0, utf8.RuneCountInString(term)+2)
+	runes = append(runes, '<')
+	runes = append(runes, []rune(term)...)
+	runes = append(runes, '>')
+
+	grams := make(map[string]struct{})
+	if len(runes) <= n {
+		grams[string(runes)] = struct{}{}
+		return grams
+	}
+	for i := 0; i+n <= len(runes); i++ {
+		grams[string(runes[i:i+n])] = struct{}{}
+	}
+	return grams
+}
@@ -0,0 +1,69 @@
+package bm25
+
+// Summation selects how the scores of the query terms are added up. Whatever the
+// summation, the term scores are added in query order, so Search and the parallel
+// methods return the same scores whatever the number of goroutines.
+type Summation int
+
+const (
+	// SumOrdered adds the term scores in query order. It is the default, and gives the
+	// same scores as GetScores.
+	SumOrdered Summation = iota
+	// SumKahan adds the term scores in query order with Kahan compensated summation,
+	// which keeps the rounding error independent of the number of query terms, e.g. for
+	// long expanded queries. It needs a second buffer of the size of the corpus.
+	SumKahan
+)
+
+// SetSummation sets how Search and the parallel methods add up the scores of the query
+// terms. Like the saturation, it is not saved in snapshots.
+func (b *Bm25Base) SetSummation(summation Summation) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+	if summation < SumOrdered || summation > SumKahan {
+		return invalidParam("summation", summation, "must be SumOrdered or SumKahan")
+	}
+
+	b.summation = summation
+	b.settings++
+	return nil
+}
+
+// Summation returns how the scores of the query terms are added up.
+func (b *Bm25Base) Summation() Summation {
+	return b.summation
+}
+
+// termSum adds the scores of the query terms to the scores of the documents, one term at
+// a time.
+type termSum struct {
+	scores       []float64
+	compensation []float64 // Lost low-order bits of every score, for SumKahan
+}
+
+// newTermSum returns a termSum adding to the given scores with the summation of the index.
+func (b *Bm25Base) newTermSum(scores []float64) *termSum {
+	sum := &termSum{scores: scores}
+	if b.summation == SumKahan {
+		sum.compensation = make([]float64, len(scores))
+	}
+	return sum
+}
+
+// add adds the scores of a term.
+func (s *termSum) add(termScores []float64) {
+	if s.compensation == nil {
+		for i, score := range termScores {
+			s.scores[i] += score
+		}
+		return
+	}
+
+	for i, score := range termScores {
+		y := score - s.compensation[i]
+		t := s.scores[i] + y
+		s.compensation[i] = (t - s.scores[i]) - y
+		s.scores[i] = t
+	}
+}
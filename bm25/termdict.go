@@ -0,0 +1,190 @@
+package bm25
+
+import (
+	"sort"
+	"strings"
+)
+
+// TermDict is a compact, immutable term dictionary. The terms are stored sorted in a
+// single string with an offset table instead of as individual map keys, which removes
+// the per-entry overhead of a Go map. Because the terms are sorted, every prefix maps to
+// a contiguous range of entries, so the dictionary doubles as an implicit trie that
+// prefix and fuzzy expansion can descend without scanning all terms.
+type TermDict struct {
+	data    string
+	offsets []uint32
+	freqs   []uint32
+}
+
+// NewTermDict creates a new TermDict from a map of terms to document frequencies.
+func NewTermDict(termFreqs map[string]int) *TermDict {
+	terms := make([]string, 0, len(termFreqs))
+	size := 0
+	for term := range termFreqs {
+		terms = append(terms, term)
+		size += len(term)
+	}
+	sort.Strings(terms)
+
+	var sb strings.Builder
+	sb.Grow(size)
+	d := &TermDict{
+		offsets: make([]uint32, len(terms)+1),
+		freqs:   make([]uint32, len(terms)),
+	}
+	for i, term := range terms {
+		d.offsets[i] = uint32(sb.Len())
+		d.freqs[i] = uint32(termFreqs[term])
+		sb.WriteString(term)
+	}
+	d.offsets[len(terms)] = uint32(sb.Len())
+	d.data = sb.String()
+
+	return d
+}
+
+// Len returns the number of terms in the dictionary.
+func (d *TermDict) Len() int {
+	return len(d.freqs)
+}
+
+// Term returns the i-th term in sorted order.
+func (d *TermDict) Term(i int) string {
+	return d.data[d.offsets[i]:d.offsets[i+1]]
+}
+
+// DocFreq returns the document frequency of the i-th term in sorted order.
+func (d *TermDict) DocFreq(i int) int {
+	return int(d.freqs[i])
+}
+
+// Lookup returns the document frequency of the given term and whether it is present.
+func (d *TermDict) Lookup(term string) (int, bool) {
+	i := sort.Search(d.Len(), func(i int) bool { return d.Term(i) >= term })
+	if i < d.Len() && d.Term(i) == term {
+		return d.DocFreq(i), true
+	}
+	return 0, false
+}
+
+// PrefixSearch returns all terms starting with the given prefix, in sorted order.
+func (d *TermDict) PrefixSearch(prefix string) []string {
+	lo, hi := d.prefixRange(0, d.Len(), prefix)
+	terms := make([]string, 0, hi-lo)
+	for i := lo; i < hi; i++ {
+		terms = append(terms, d.Term(i))
+	}
+	return terms
+}
+
+// FuzzySearch returns all terms within maxEdits Levenshtein edits of the given term, in
+// sorted order. Edits are counted on bytes, so a multi-byte character may count as more
+// than one edit.
+func (d *TermDict) FuzzySearch(term string, maxEdits int) []string {
+	if maxEdits < 0 {
+		return []string{}
+	}
+
+	row := make([]int, len(term)+1)
+	for i := range row {
+		row[i] = i
+	}
+
+	terms := []string{}
+	d.fuzzyDescend(0, d.Len(), 0, term, row, maxEdits, &terms)
+	return terms
+}
+
+// prefixRange returns the range of entries within [lo, hi) that start with prefix.
+func (d *TermDict) prefixRange(lo, hi int, prefix string) (int, int) {
+	start := lo + sort.Search(hi-lo, func(i int) bool { return d.Term(lo+i) >= prefix })
+	end := start + sort.Search(hi-start, func(i int) bool { return !strings.HasPrefix(d.Term(start+i), prefix) })
+	return start, end
+}
+
+// fuzzyDescend walks the implicit trie node covering [lo, hi), whose entries all share
+// their first depth bytes, carrying the Levenshtein row of that shared prefix.
+func (d *TermDict) fuzzyDescend(lo, hi, depth int, term string, row []int, maxEdits int, terms *[]string) {
+	i := lo
+	if i < hi && len(d.Term(i)) == depth {
+		if row[len(term)] <= maxEdits {
+			*terms = append(*terms, d.Term(i))
+		}
+		i++
+	}
+
+	for i < hi {
+		c := d.Term(i)[depth]
+		j := i + sort.Search(hi-i, func(k int) bool { return d.Term(i + k)[depth] != c })
+
+		next := make([]int, len(row))
+		next[0] = row[0] + 1
+		best := next[0]
+		for k := 1; k < len(row); k++ {
+			cost := 1
+			if term[k-1] == c {
+				cost = 0
+			}
+			next[k] = min(next[k-1]+1, row[k]+1, row[k-1]+cost)
+			best = min(best, next[k])
+		}
+
+		if best <= maxEdits {
+			d.fuzzyDescend(i, j, depth+1, term, next, maxEdits, terms)
+		}
+		i = j
+	}
+}
+
+// Vocabulary returns the corpus vocabulary as a TermDict. The dictionary is built on
+// first use and cached.
+func (b *Bm25Base) Vocabulary() *TermDict {
+	if b.termDict == nil {
+		b.termDict = NewTermDict(b.termFreqs)
+	}
+	return b.termDict
+}
+
+// CompactVocabulary replaces the map holding the document frequencies with the compact
+// TermDict, reducing the memory used by large vocabularies.
+func (b *Bm25Base) CompactVocabulary() error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	b.Vocabulary()
+	b.termFreqs = nil
+	return nil
+}
+
+// ExpandPrefix returns all vocabulary terms starting with the given prefix.
+func (b *Bm25Base) ExpandPrefix(prefix string) []string {
+	return b.Vocabulary().PrefixSearch(prefix)
+}
+
+// ExpandFuzzy returns all vocabulary terms within maxEdits edits of the given term.
+func (b *Bm25Base) ExpandFuzzy(term string, maxEdits int) []string {
+	return b.Vocabulary().FuzzySearch(term, maxEdits)
+}
+
+// docFreq returns the number of documents containing the term.
+func (b *Bm25Base) docFreq(term string) (int, bool) {
+	if b.termFreqs == nil {
+		return b.termDict.Lookup(term)
+	}
+	termFreq, ok := b.termFreqs[term]
+	return termFreq, ok
+}
+
+// forEachTerm calls fn for every vocabulary term and its document frequency.
+func (b *Bm25Base) forEachTerm(fn func(term string, termFreq int)) {
+	if b.termFreqs == nil {
+		for i := 0; i < b.termDict.Len(); i++ {
+			fn(b.termDict.Term(i), b.termDict.DocFreq(i))
+		}
+		return
+	}
+	for term, termFreq := range b.termFreqs {
+		fn(term, termFreq)
+	}
+}
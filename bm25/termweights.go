@@ -0,0 +1,57 @@
+package bm25
+
+import (
+	"maps"
+	"math"
+)
+
+// SetTermWeights sets externally learned term weights, e.g. from SPLADE or from query
+// log learning. At query time, the IDF of every weighted term is multiplied by its
+// weight, so a weight of 0 disables a term and weights above 1 boost it. Terms without a
+// weight keep their IDF. Passing nil or an empty map clears all weights.
+func (b *Bm25Base) SetTermWeights(weights map[string]float64) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	for term, weight := range weights {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return invalidParam("weights."+term, weight, "must be a non-negative finite number")
+		}
+	}
+
+	b.settings++
+	if len(weights) == 0 {
+		b.termWeights = nil
+		return nil
+	}
+
+	b.termWeights = maps.Clone(weights)
+	return nil
+}
+
+// TermWeights returns a copy of the term weights set with SetTermWeights.
+func (b *Bm25Base) TermWeights() map[string]float64 {
+	weights := maps.Clone(b.termWeights)
+	if weights == nil {
+		weights = map[string]float64{}
+	}
+	return weights
+}
+
+// termWeight returns the weight of a term, which is 1 for terms without a weight.
+func (b *Bm25Base) termWeight(term string) float64 {
+	if weight, ok := b.termWeights[term]; ok {
+		return weight
+	}
+	return 1
+}
+
+// queryIDF returns the IDF of a query term scaled by its term weight.
+func (b *Bm25Base) queryIDF(term string) (float64, error) {
+	idf, err := b.IDF(term)
+	if err != nil {
+		return 0, err
+	}
+	return idf * b.termWeight(term), nil
+}
@@ -0,0 +1,89 @@
+package bm25_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestRankingMetrics(t *testing.T) {
+	// Test case: Identical and disjoint rankings
+	if rbo := bm25.RankBiasedOverlap([]int{1, 2, 3}, []int{1, 2, 3}, 0.9); math.Abs(rbo-1) > 1e-9 {
+		t.Errorf("Expected RBO 1 for identical rankings, but got %.4f", rbo)
+	}
+	if rbo := bm25.RankBiasedOverlap([]int{1, 2}, []int{3, 4}, 0.9); rbo != 0 {
+		t.Errorf("Expected RBO 0 for disjoint rankings, but got %.4f", rbo)
+	}
+
+	// Test case: Disagreement at the top weighs more than at the bottom
+	top := bm25.RankBiasedOverlap([]int{1, 2, 3, 4}, []int{2, 1, 3, 4}, 0.9)
+	bottom := bm25.RankBiasedOverlap([]int{1, 2, 3, 4}, []int{1, 2, 4, 3}, 0.9)
+	if top >= bottom {
+		t.Errorf("Expected a swap at the top to lower RBO more, but got %.4f >= %.4f", top, bottom)
+	}
+
+	// Test case: NDCG of a perfect, a reversed and an irrelevant ranking
+	relevance := map[int]float64{1: 3, 2: 1}
+	if ndcg := bm25.NDCG([]int{1, 2, 5}, relevance, 3); math.Abs(ndcg-1) > 1e-9 {
+		t.Errorf("Expected NDCG 1 for the ideal ranking, but got %.4f", ndcg)
+	}
+	if ndcg := bm25.NDCG([]int{2, 1}, relevance, 3); ndcg >= 1 || ndcg <= 0 {
+		t.Errorf("Expected NDCG between 0 and 1 for a reversed ranking, but got %.4f", ndcg)
+	}
+	if ndcg := bm25.NDCG([]int{5, 6}, relevance, 3); ndcg != 0 {
+		t.Errorf("Expected NDCG 0 for an irrelevant ranking, but got %.4f", ndcg)
+	}
+}
+
+func TestABTest(t *testing.T) {
+	corpus := []string{"hello world", "hello hello hello there friend of mine", "this is a test", "hello"}
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0.75, nil)
+	flat, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0, nil)
+
+	// Test case: Creating a test with an invalid traffic split
+	_, err := bm25.NewABTest(okapi, flat, bm25.ABTestOptions{ServeB: 2})
+	if err == nil {
+		t.Errorf("Expected an error for ServeB 2, but got nil")
+	}
+
+	// Test case: Every search is recorded and served from the configured variant
+	var recorded []bm25.ABComparison
+	ab, err := bm25.NewABTest(okapi, flat, bm25.ABTestOptions{ServeB: 1, Record: func(c bm25.ABComparison) { recorded = append(recorded, c) }})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req := bm25.SearchRequest{Query: []string{"hello"}, N: 3}
+	resp, err := ab.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected, _ := flat.Search(context.Background(), req)
+	if resp.Results[0].DocID != expected.Results[0].DocID {
+		t.Errorf("Expected the results of variant B, but got %+v", resp.Results)
+	}
+	if len(recorded) != 1 || !recorded[0].ServedB || len(recorded[0].A) != 3 || len(recorded[0].B) != 3 {
+		t.Errorf("Expected one recorded comparison served from B, but got %+v", recorded)
+	}
+	if recorded[0].RBO >= 1 {
+		t.Errorf("Expected the length normalization to change the ranking, but got RBO %.4f", recorded[0].RBO)
+	}
+
+	// Test case: Evaluating judged queries reports the NDCG delta
+	summary, err := ab.Evaluate(context.Background(), []bm25.JudgedQuery{
+		{Query: []string{"hello"}, Relevance: map[int]float64{3: 2, 0: 1}},
+		{Query: []string{"test"}, Relevance: map[int]float64{2: 1}},
+	}, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.Queries != 2 || summary.Wins+summary.Losses+summary.Ties != 2 {
+		t.Errorf("Expected 2 evaluated queries, but got %+v", summary)
+	}
+	if math.Abs(summary.NDCGDelta-(summary.NDCGB-summary.NDCGA)) > 1e-9 || summary.Losses != 1 {
+		t.Errorf("Expected variant B to lose on the first query, but got %+v", summary)
+	}
+}
@@ -0,0 +1,78 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestAdmissionController(t *testing.T) {
+	// Test case: Invalid options
+	var paramErr *bm25.ErrInvalidParam
+	for _, opts := range []bm25.AdmissionOptions{{}, {MaxConcurrent: 1, MaxQueued: -1}, {MaxConcurrent: 1, QueueTimeout: -time.Second}} {
+		if _, err := bm25.NewAdmissionController(opts); !errors.As(err, &paramErr) {
+			t.Errorf("Expected ErrInvalidParam for options %+v, but got %v", opts, err)
+		}
+	}
+
+	var rejections []error
+	controller, _ := bm25.NewAdmissionController(bm25.AdmissionOptions{
+		MaxConcurrent: 1,
+		MaxQueued:     1,
+		QueueTimeout:  20 * time.Millisecond,
+		OnRejected:    func(err error) { rejections = append(rejections, err) },
+	})
+	ctx := context.Background()
+
+	// Test case: Searches beyond the limit wait in the queue, and are rejected once it is full
+	release, err := controller.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	admitted := make(chan error)
+	go func() {
+		release, err := controller.Acquire(ctx)
+		if err == nil {
+			release()
+		}
+		admitted <- err
+	}()
+	for controller.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := controller.Acquire(ctx); !errors.Is(err, bm25.ErrOverloaded) {
+		t.Errorf("Expected ErrOverloaded, but got %v", err)
+	}
+	release()
+	if err := <-admitted; err != nil {
+		t.Errorf("Expected the queued search to be admitted, but got %v", err)
+	}
+
+	// Test case: Searches waiting longer than the queue timeout fail
+	release, _ = controller.Acquire(ctx)
+	if _, err := controller.Acquire(ctx); !errors.Is(err, bm25.ErrQueueTimeout) {
+		t.Errorf("Expected ErrQueueTimeout, but got %v", err)
+	}
+	release()
+
+	stats := controller.Stats()
+	expected := bm25.AdmissionStats{Admitted: 3, Queued: 2, Rejected: 1, TimedOut: 1}
+	if stats != expected {
+		t.Errorf("Expected stats %+v, but got %+v", expected, stats)
+	}
+	if len(rejections) != 2 {
+		t.Errorf("Expected 2 rejections to be reported, but got %v", rejections)
+	}
+
+	// Test case: Searching through the controller
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world", "this is a test"}, tokenizer, 1.5, 0.75, nil)
+	resp, err := controller.Search(ctx, okapi, bm25.SearchRequest{Query: []string{"hello"}, N: 1})
+	if err != nil || resp.Results[0].DocID != 0 || controller.Stats().Running != 0 {
+		t.Errorf("Expected the search to run and release its slot, but got error %v", err)
+	}
+}
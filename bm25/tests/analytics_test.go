@@ -0,0 +1,85 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestAnalytics(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world", "this is a test", "hello there"}, tokenizer, 1.5, 0.75, nil)
+
+	analytics := bm25.NewAnalytics()
+	okapi.SetQueryLog(&bm25.QueryLog{Sink: analytics})
+
+	search := func(query ...string) {
+		okapi.Search(context.Background(), bm25.SearchRequest{Query: query, N: 2, Filter: func(docID int) bool {
+			return query[0] != "missing"
+		}})
+	}
+	search("hello", "world")
+	search("hello")
+	search("hello")
+	search("missing")
+	analytics.RecordClick([]string{"hello"}, 2)
+	analytics.RecordClick([]string{"hello"}, 0)
+	analytics.RecordClick([]string{"hello", "world"}, 0)
+
+	// Test case: Searches, zero-result searches and clicks are counted
+	report := analytics.Report(2)
+	if report.Searches != 4 || report.ZeroResults != 1 || report.Clicks != 3 {
+		t.Errorf("Expected 4 searches, 1 without results and 3 clicks, but got %d, %d and %d", report.Searches, report.ZeroResults, report.Clicks)
+	}
+	if rate := report.ClickThroughRate(); rate != 0.75 {
+		t.Errorf("Expected a click-through rate of 0.75, but got %.2f", rate)
+	}
+
+	// Test case: Terms, queries and documents are ranked by popularity
+	if len(report.TopTerms) != 2 || report.TopTerms[0] != (bm25.TermCount{Term: "hello", Count: 3}) {
+		t.Errorf("Expected 'hello' as the top term, but got %v", report.TopTerms)
+	}
+	if report.TopQueries[0] != (bm25.QueryCount{Query: "hello", Searches: 2, Clicks: 2}) {
+		t.Errorf("Expected 'hello' as the top query, but got %v", report.TopQueries)
+	}
+	if len(report.ZeroResultQueries) != 1 || report.ZeroResultQueries[0].Query != "missing" {
+		t.Errorf("Expected 'missing' as the only zero-result query, but got %v", report.ZeroResultQueries)
+	}
+	if report.TopClickedDocs[0] != (bm25.DocClicks{DocID: 0, Clicks: 2}) {
+		t.Errorf("Expected document 0 as the top clicked document, but got %v", report.TopClickedDocs)
+	}
+
+	// Test case: Resetting starts a new reporting period
+	analytics.ReportAndReset(2)
+	if report := analytics.Report(2); report.Searches != 0 || len(report.TopTerms) != 0 {
+		t.Errorf("Expected an empty report after a reset, but got %+v", report)
+	}
+
+	// Test case: Periodic reports are delivered until stopped
+	reports := make(chan bm25.AnalyticsReport, 10)
+	stop, err := analytics.StartReporting(time.Millisecond, 5, func(r bm25.AnalyticsReport) { reports <- r })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	search("hello")
+	select {
+	case <-reports:
+	case <-time.After(time.Second):
+		t.Errorf("Expected a periodic report within a second")
+	}
+	stop()
+	stop()
+
+	// Test case: Reporting with an invalid interval or without a callback
+	var paramErr *bm25.ErrInvalidParam
+	if _, err := analytics.StartReporting(0, 5, func(bm25.AnalyticsReport) {}); !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam for an interval of 0, but got %v", err)
+	}
+	if _, err := analytics.StartReporting(time.Millisecond, 5, nil); !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam for a nil callback, but got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package bm25_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestCharFilters(t *testing.T) {
+	// Test case: HTML entities are decoded
+	if text := bm25.HTMLEntityFilter("caf&eacute; &lt;b&gt; &amp; &#8364;5"); text != "café <b> & €5" {
+		t.Errorf("Expected decoded entities, but got %q", text)
+	}
+
+	// Test case: URLs are stripped
+	if text := bm25.URLStripFilter("see https://example.com/path?q=1, or www.example.org."); strings.Join(strings.Fields(text), " ") != "see or" {
+		t.Errorf("Expected the URLs to be removed, but got %q", text)
+	}
+
+	// Test case: Regular expression replacements can refer to submatches
+	filter := bm25.RegexpReplaceFilter(regexp.MustCompile(`(\w+)@(\w+)\.com`), "$1 at $2")
+	if text := filter("mail bob@example.com"); text != "mail bob at example" {
+		t.Errorf("Expected the replacement, but got %q", text)
+	}
+
+	// Test case: Char filters run in order before the tokenizer
+	analyzer := bm25.Analyzer{
+		CharFilters: []bm25.CharFilter{bm25.HTMLEntityFilter, bm25.RegexpReplaceFilter(regexp.MustCompile(`[<>]`), " ")},
+		Tokenizer:   strings.Fields,
+		Filters:     []bm25.TokenFilter{bm25.LowercaseFilter},
+	}
+	tokens := analyzer.Analyze("&lt;B&gt;Bold&lt;/B&gt; Text")
+	expected := []string{"b", "bold", "/b", "text"}
+	if strings.Join(tokens, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected tokens %v, but got %v", expected, tokens)
+	}
+
+}
+
+func TestTokenFilters(t *testing.T) {
+	// Test case: Tokens outside the length range are removed, counting characters
+	tokens := bm25.LengthFilter(2, 5)([]string{"a", "ab", "äöü", "abcdef", "abcde"})
+	if strings.Join(tokens, "|") != "ab|äöü|abcde" {
+		t.Errorf("Expected the tokens of length 2 to 5, but got %v", tokens)
+	}
+	if tokens := bm25.LengthFilter(1, 0)([]string{"a", strings.Repeat("x", 500)}); len(tokens) != 2 {
+		t.Errorf("Expected no upper limit, but got %d tokens", len(tokens))
+	}
+
+	// Test case: Numbers are removed, tokens with digits and letters are kept
+	tokens = bm25.NumericFilter([]string{"42", "3.14", "-1,000", "mp3", "2024-01-01", "-", "x"})
+	if strings.Join(tokens, "|") != "mp3|-|x" {
+		t.Errorf("Expected the numbers to be removed, but got %v", tokens)
+	}
+
+	// Test case: Pattern filters keep or drop the matching tokens
+	hex := regexp.MustCompile(`^[0-9a-f]{6,}$`)
+	if tokens := bm25.DropPatternFilter(hex)([]string{"fix", "a1b2c3d4", "bug"}); strings.Join(tokens, "|") != "fix|bug" {
+		t.Errorf("Expected the hashes to be dropped, but got %v", tokens)
+	}
+	if tokens := bm25.KeepPatternFilter(hex)([]string{"fix", "a1b2c3d4", "bug"}); strings.Join(tokens, "|") != "a1b2c3d4" {
+		t.Errorf("Expected only the hashes to be kept, but got %v", tokens)
+	}
+}
+
+func TestASCIIFolding(t *testing.T) {
+	// Test case: Accented letters, special letters and ligatures are folded
+	for text, expected := range map[string]string{
+		"café":        "cafe",
+		"Straße":      "Strasse",
+		"Ærøskøbing":  "AEroskobing",
+		"Łódź":        "Lodz",
+		"œuvre":       "oeuvre",
+		"ﬁnancial":    "financial",
+		"naïve":       "naive",
+		"e\u0301":     "e",
+		"plain ascii": "plain ascii",
+		"東京":          "東京",
+	} {
+		if folded := bm25.FoldASCII(text); folded != expected {
+			t.Errorf("Expected %q to fold to %q, but got %q", text, expected, folded)
+		}
+	}
+
+	// Test case: Accented and unaccented words match after folding
+	analyzer := bm25.Analyzer{
+		Tokenizer: strings.Fields,
+		Filters:   []bm25.TokenFilter{bm25.LowercaseFilter, bm25.ASCIIFoldingFilter},
+	}
+	if a, b := analyzer.Analyze("Crème Brûlée"), analyzer.Analyze("creme brulee"); strings.Join(a, " ") != strings.Join(b, " ") {
+		t.Errorf("Expected the same tokens, but got %v and %v", a, b)
+	}
+}
+
+func TestNumberUnitFilter(t *testing.T) {
+	analyzer := bm25.Analyzer{Tokenizer: strings.Fields, Filters: []bm25.TokenFilter{bm25.NumberUnitFilter(nil)}}
+
+	// Test case: Formatting variants of a quantity produce the same token
+	for _, text := range []string{"3.5GB", "3.50 gb", "3.5 Gigabytes", "3.5 gigabyte"} {
+		if tokens := analyzer.Analyze(text); len(tokens) != 1 || tokens[0] != "3.5gb" {
+			t.Errorf("Expected %q to become [3.5gb], but got %v", text, tokens)
+		}
+	}
+
+	// Test case: Numbers are canonicalized, other tokens are left alone
+	tokens := analyzer.Analyze("1,024 items at 50 percent 007 v1.2 for 2.0 hours")
+	expected := []string{"1024", "items", "at", "50%", "007", "v1.2", "for", "2h"}
+	if strings.Join(tokens, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected tokens %v, but got %v", expected, tokens)
+	}
+
+	// Test case: Custom units replace the defaults
+	units := bm25.DefaultUnits()
+	units["rpm"] = "rpm"
+	units["gb"] = "gigabyte"
+	custom := bm25.NumberUnitFilter(units)
+	if tokens := custom([]string{"7200", "RPM", "2gb"}); strings.Join(tokens, "|") != "7200rpm|2gigabyte" {
+		t.Errorf("Expected the custom units, but got %v", tokens)
+	}
+	if units := bm25.DefaultUnits(); units["gb"] != "gb" {
+		t.Errorf("Expected the default units to be unchanged, but got %q", units["gb"])
+	}
+}
+
+func TestDecompoundFilter(t *testing.T) {
+	dictionary := []string{"fuß", "Fußball", "ball", "welt", "meister", "meisterschaft", "arbeit", "platz", "haus"}
+	filter, err := bm25.DecompoundFilter(dictionary, bm25.DecompoundOptions{LinkingElements: []string{"s"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Compounds are followed by the fewest dictionary words covering them
+	tokens := filter([]string{"die", "fußballweltmeisterschaft", "beginnt"})
+	expected := []string{"die", "fußballweltmeisterschaft", "fußball", "welt", "meisterschaft", "beginnt"}
+	if strings.Join(tokens, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected tokens %v, but got %v", expected, tokens)
+	}
+
+	// Test case: Linking elements join parts without being part of them
+	if tokens := filter([]string{"Arbeitsplatz"}); strings.Join(tokens, "|") != "Arbeitsplatz|arbeit|platz" {
+		t.Errorf("Expected the parts around the linking element, but got %v", tokens)
+	}
+
+	// Test case: Words that cannot be split entirely are left alone
+	tokens = filter([]string{"hausboot", "weltweit", "platz"})
+	if strings.Join(tokens, "|") != "hausboot|weltweit|platz" {
+		t.Errorf("Expected no decomposition, but got %v", tokens)
+	}
+
+	// Test case: A compound matches a query for one of its parts
+	analyzer := bm25.Analyzer{Tokenizer: strings.Fields, Filters: []bm25.TokenFilter{bm25.LowercaseFilter, filter}}
+	index, err := bm25.NewBM25Okapi([]string{"Fußballweltmeisterschaft 2026", "Handball Turnier"}, func(text string) []string {
+		return analyzer.Analyze(text)
+	}, 1.5, 0.75, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scores, err := index.GetScores(analyzer.Analyze("Fußball"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if scores[0] <= scores[1] {
+		t.Errorf("Expected the compound to match its part, but got scores %v", scores)
+	}
+
+	// Test case: Invalid options are rejected
+	if _, err := bm25.DecompoundFilter(dictionary, bm25.DecompoundOptions{MinPartLength: -1}); err == nil {
+		t.Errorf("Expected an error for a negative part length")
+	}
+}
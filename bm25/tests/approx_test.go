@@ -0,0 +1,74 @@
+package bm25_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestApproxIndex(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+
+	// Test case: Creating an index with invalid options
+	_, err := bm25.NewApproxIndex(tokenizer, 1.5, 0.75, bm25.ApproxOptions{Width: -1})
+	var paramErr *bm25.ErrInvalidParam
+	if !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam, but got %v", err)
+	}
+
+	approx, err := bm25.NewApproxIndex(tokenizer, 1.5, 0.75, bm25.ApproxOptions{Width: 4096, ReservoirSize: 100, Seed: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Scoring against an empty index
+	if _, err := approx.Score([]string{"hello"}, []string{"hello"}); !errors.Is(err, bm25.ErrEmptyCorpus) {
+		t.Errorf("Expected ErrEmptyCorpus, but got %v", err)
+	}
+
+	corpus := make([]string, 2000)
+	for i := range corpus {
+		corpus[i] = fmt.Sprintf("doc%d common term%d", i, i%20)
+		if i%4 == 0 {
+			corpus[i] += " quarter"
+		}
+		if err := approx.Add(corpus[i]); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	exact, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0.75, nil)
+
+	// Test case: Document frequencies are estimated without undercounting
+	for term, expected := range map[string]int{"common": 2000, "quarter": 500, "term3": 100, "doc7": 1, "missing": 0} {
+		df := approx.DocFreq(term)
+		if df < expected || df > expected+20 {
+			t.Errorf("Expected a document frequency close to %d for '%s', but got %d", expected, term, df)
+		}
+	}
+
+	// Test case: Corpus statistics and scores are close to the exact index
+	if approx.CorpusSize() != 2000 || approx.AvgDocLen() != exact.AvgDocLen() {
+		t.Errorf("Expected 2000 documents of average length %.2f, but got %d of %.2f", exact.AvgDocLen(), approx.CorpusSize(), approx.AvgDocLen())
+	}
+	if p := approx.DocLengthPercentiles(); p.Min != 3 || p.Max != 4 {
+		t.Errorf("Expected document lengths between 3 and 4, but got %v", p)
+	}
+	query := []string{"quarter", "term4"}
+	scores, _ := exact.GetScores(query)
+	score, err := approx.Score(query, tokenizer(corpus[4]))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(score-scores[4]) > 0.05 {
+		t.Errorf("Expected a score close to %.4f, but got %.4f", scores[4], score)
+	}
+
+	// Test case: Memory stays bounded by the options
+	if approx.MemoryBytes() != 4096*4*4+100*8 {
+		t.Errorf("Expected %d bytes, but got %d", 4096*4*4+100*8, approx.MemoryBytes())
+	}
+}
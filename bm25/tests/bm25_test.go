@@ -1,6 +1,7 @@
 package bm25_test
 
 import (
+	"math"
 	"strings"
 	"testing"
 
@@ -107,3 +108,65 @@ func TestIDF(t *testing.T) {
 		t.Errorf("Expected IDF 0.69314718055994529 for the term 'hello', but got %.2f", idf)
 	}
 }
+
+func TestNewBM25BaseReusedTokenizerBuffer(t *testing.T) {
+	// Test case: A tokenizer that reuses its output slice must not corrupt earlier documents
+	corpus := []string{"hello world", "this is a test"}
+	buf := make([]string, 0, 8)
+	tokenizer := func(s string) []string {
+		buf = append(buf[:0], strings.Split(s, " ")...)
+		return buf
+	}
+	okapi, err := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0.75, nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	for _, doc := range corpus {
+		topDocs, err := okapi.GetTopN(strings.Split(doc, " ")[:1], 1)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if len(topDocs) != 1 || topDocs[0] != doc {
+			t.Errorf("Expected top document '%s', but got %v", doc, topDocs)
+		}
+	}
+}
+
+func TestTermFrequencyCountsStoredTokens(t *testing.T) {
+	// Test case: Term frequencies are counted on the stored tokens instead of re-tokenizing the joined document
+	corpus := []string{"new york,boston", "boston,chicago", "chicago"}
+	tokenizer := func(s string) []string { return strings.Split(s, ",") }
+	okapi, err := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0.75, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Joining the stored tokens with spaces and tokenizing again loses the multi-word token,
+	// so counting on the joined document used to give the first document a score of 0.
+	before, err := bm25.CountTermFreq("new york", bm25.JoinTokens(tokenizer(corpus[0]), " "), tokenizer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if before != 0 {
+		t.Errorf("Expected term frequency 0 on the re-tokenized document, but got %d", before)
+	}
+
+	idf, err := okapi.IDF("new york")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	avgdl := 5.0 / 3.0
+	want := idf * (1 * (1.5 + 1)) / (1 + 1.5*(1-0.75+0.75*2/avgdl))
+
+	scores, err := okapi.GetScores([]string{"new york"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(scores[0]-want) > 1e-9 {
+		t.Errorf("Expected score %f for the document with the stored token, but got %f", want, scores[0])
+	}
+	if scores[1] != 0 || scores[2] != 0 {
+		t.Errorf("Expected score 0 for documents without the token, but got %v", scores[1:])
+	}
+}
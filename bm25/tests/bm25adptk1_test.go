@@ -0,0 +1,96 @@
+package bm25_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestNewBM25AdptK1(t *testing.T) {
+	corpus := []string{"hello world", "this is a test"}
+
+	// Test case: Creating a new BM25AdptK1 instance with negative k1
+	if _, err := bm25.NewBM25AdptK1(corpus, strings.Fields, -1.0, 0.75, nil); err == nil {
+		t.Errorf("Expected an error for negative k1, but got nil")
+	}
+
+	// Test case: Creating a new BM25AdptK1 instance with b outside the range [0, 1]
+	if _, err := bm25.NewBM25AdptK1(corpus, strings.Fields, 1.2, 1.5, nil); err == nil {
+		t.Errorf("Expected an error for b outside the range [0, 1], but got nil")
+	}
+
+	// Test case: Creating a new BM25AdptK1 instance on a nil base
+	if _, err := bm25.NewBM25AdptK1FromBase(nil, 1.2, 0.75); err == nil {
+		t.Errorf("Expected an error for a nil base, but got nil")
+	}
+}
+
+func TestBM25AdptK1GetScores(t *testing.T) {
+	// "go" occurs once in 8 documents, twice in 4, three times in 2 and four times in 1
+	corpus := make([]string, 0, 40)
+	for _, count := range []struct{ tf, docs int }{{1, 8}, {2, 4}, {3, 2}, {4, 1}} {
+		for i := 0; i < count.docs; i++ {
+			corpus = append(corpus, strings.Repeat("go ", count.tf)+"gopher")
+		}
+	}
+	corpus = append(corpus, "rust borrow checker")
+	for len(corpus) < 40 {
+		corpus = append(corpus, "python notebooks")
+	}
+	adpt, _ := bm25.NewBM25AdptK1(corpus, strings.Fields, 1.2, 0, nil)
+
+	// Test case: A term occurring once falls back to the k1 of the index
+	if k1 := adpt.TermK1("rust"); k1 != 1.2 {
+		t.Errorf("Expected the fallback k1 1.2, but got %v", k1)
+	}
+
+	// Test case: A term repeated in several documents gets a fitted k1
+	k1 := adpt.TermK1("go")
+	if k1 == 1.2 || k1 <= 0.1 || k1 >= 10 {
+		t.Errorf("Expected a fitted k1 within (0, 10), but got %v", k1)
+	}
+
+	// Test case: The score of a single occurrence is the information gain of the term
+	scores, err := adpt.GetScores([]string{"rust"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	gain := math.Log2(0.5/2) - math.Log2(1.5/41)
+	if math.Abs(scores[15]-gain) > 1e-9 || scores[0] != 0 {
+		t.Errorf("Expected a score of %v for document 15 only, but got %v", gain, scores)
+	}
+
+	// Test case: More occurrences score higher, with diminishing returns
+	scores, err = adpt.GetScores([]string{"go"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	once, twice, thrice := scores[0], scores[8], scores[12]
+	if !(once > 0 && twice > once && thrice > twice) {
+		t.Errorf("Expected the scores to increase with the frequency, but got %v", scores)
+	}
+	if thrice-twice >= twice-once {
+		t.Errorf("Expected diminishing returns, but got %v, %v and %v", once, twice, thrice)
+	}
+
+	// Test case: Batch scores match the scores of the whole corpus
+	batch, err := adpt.GetBatchScores([]string{"go"}, []int{0, 14})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch[0] != scores[0] || batch[1] != scores[14] {
+		t.Errorf("Expected batch scores %v, but got %v", []float64{scores[0], scores[14]}, batch)
+	}
+
+	// Test case: Searching ranks like the scores
+	resp, err := adpt.Search(context.Background(), bm25.SearchRequest{Query: []string{"go"}, N: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Results[0].DocID != 14 {
+		t.Errorf("Expected document 14 to rank first, but got %d", resp.Results[0].DocID)
+	}
+}
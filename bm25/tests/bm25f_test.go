@@ -0,0 +1,216 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestNewBM25F(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	corpus := []map[string]string{{"title": "hello", "body": "world"}}
+
+	// Test case: Creating an index without fields or with a negative weight
+	_, err := bm25.NewBM25F(corpus, tokenizer, nil, 1.2, 0.75, nil)
+	var paramErr *bm25.ErrInvalidParam
+	if !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam for missing weights, but got %v", err)
+	}
+	_, err = bm25.NewBM25F(corpus, tokenizer, map[string]float64{"title": -1}, 1.2, 0.75, nil)
+	if !errors.As(err, &paramErr) || paramErr.Name != "weights.title" {
+		t.Errorf("Expected ErrInvalidParam for weights.title, but got %v", err)
+	}
+
+	// Test case: Creating an index with a document without indexed text
+	_, err = bm25.NewBM25F([]map[string]string{{"other": "text"}}, tokenizer, map[string]float64{"title": 1}, 1.2, 0.75, nil)
+	if !errors.Is(err, bm25.ErrEmptyDocument) {
+		t.Errorf("Expected ErrEmptyDocument, but got %v", err)
+	}
+}
+
+func TestBM25FScores(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	corpus := []map[string]string{
+		{"title": "rust async", "body": "an introduction to futures"},
+		{"title": "python", "body": "rust is mentioned here with async code"},
+		{"title": "go", "body": "goroutines and channels"},
+	}
+	f, err := bm25.NewBM25F(corpus, tokenizer, map[string]float64{"title": 3, "body": 1}, 1.2, 0.75, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Matches in a heavily weighted field rank higher
+	scores, err := f.GetScores([]string{"rust"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if scores[0] <= scores[1] || scores[2] != 0 {
+		t.Errorf("Expected the title match to rank first and no score for document 2, but got %v", scores)
+	}
+
+	// Test case: Parsing a field-scoped query
+	query := f.ParseQuery(`title:rust body:"async code" channels unknown:x`)
+	expected := []string{"title:rust", "body:async", "body:code", "channels", "unknown:x"}
+	if !reflect.DeepEqual(query, expected) {
+		t.Errorf("Expected %q, but got %q", expected, query)
+	}
+
+	// Test case: Field-scoped terms only match within their field
+	scores, _ = f.GetScores([]string{"title:rust"})
+	if scores[0] == 0 || scores[1] != 0 {
+		t.Errorf("Expected only document 0 to match title:rust, but got %v", scores)
+	}
+	scores, _ = f.GetScores(f.ParseQuery("body:async"))
+	if scores[0] != 0 || scores[1] == 0 {
+		t.Errorf("Expected only document 1 to match body:async, but got %v", scores)
+	}
+	fieldScores, err := f.GetFieldScores([]bm25.FieldTerm{{Field: "body", Term: "async"}})
+	if err != nil || !reflect.DeepEqual(fieldScores, scores) {
+		t.Errorf("Expected %v, but got %v (error: %v)", scores, fieldScores, err)
+	}
+	_, err = f.GetFieldScores([]bm25.FieldTerm{{Field: "author", Term: "async"}})
+	if err == nil {
+		t.Errorf("Expected an error for an unknown field, but got nil")
+	}
+
+	// Test case: Batch scores agree with the full scores
+	scores, _ = f.GetScores([]string{"rust", "async"})
+	batch, err := f.GetBatchScores([]string{"rust", "async"}, []int{2, 0})
+	if err != nil || batch[0] != scores[2] || batch[1] != scores[0] {
+		t.Errorf("Expected [%.2f %.2f], but got %v (error: %v)", scores[2], scores[0], batch, err)
+	}
+
+	// Test case: Searching with a field-scoped query
+	resp, err := f.Search(context.Background(), bm25.SearchRequest{Query: f.ParseQuery("title:go channels"), N: 1})
+	if err != nil || len(resp.Results) != 1 || resp.Results[0].DocID != 2 {
+		t.Errorf("Expected document 2 first, but got %+v (error: %v)", resp, err)
+	}
+
+	// Test case: Explanations resolve field-scoped terms for their IDF and frequency
+	resp, err = f.Search(context.Background(), bm25.SearchRequest{Query: []string{"title:rust", "body:rust"}, N: 1, Explain: true})
+	if err != nil || len(resp.Results) != 1 || resp.Results[0].DocID != 0 {
+		t.Fatalf("Expected document 0 first, but got %+v (error: %v)", resp, err)
+	}
+	idf, _ := f.IDF("rust")
+	terms := resp.Results[0].Explanation.Terms
+	if terms[0].IDF != idf || terms[0].Frequency != 1 || terms[0].Score == 0 {
+		t.Errorf("Expected IDF %.2f and frequency 1 for title:rust, but got %+v", idf, terms[0])
+	}
+	if terms[1].IDF != idf || terms[1].Frequency != 0 || terms[1].Score != 0 {
+		t.Errorf("Expected IDF %.2f and frequency 0 for body:rust, but got %+v", idf, terms[1])
+	}
+
+	// Test case: Field weights are reported as parameters
+	if w := f.Params()["weights.title"]; w != 3 {
+		t.Errorf("Expected a title weight of 3, but got %.2f", w)
+	}
+}
+
+func TestBM25FFieldNormalization(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	corpus := []map[string]string{
+		{"title": "rust", "body": "a b c d e f g h"},
+		{"title": "rust guide for beginners", "body": "a b"},
+		{"title": "go", "body": "channels"},
+		{"title": "python", "body": "snakes"},
+	}
+	f, err := bm25.NewBM25F(corpus, tokenizer, map[string]float64{"title": 1, "body": 1}, 1.2, 0.75, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Fields are normalized by their own average length
+	if avg := f.FieldAvgLen("title"); avg != 1.75 {
+		t.Errorf("Expected an average title length of 1.75, but got %.2f", avg)
+	}
+	if avg := f.FieldAvgLen("body"); avg != 3 {
+		t.Errorf("Expected an average body length of 3, but got %.2f", avg)
+	}
+
+	// Test case: A short title outranks a long one, regardless of the body lengths
+	scores, _ := f.GetScores([]string{"title:rust"})
+	if scores[0] <= scores[1] {
+		t.Errorf("Expected the short title to rank first, but got %v", scores)
+	}
+
+	// Test case: Disabling the normalization of a single field
+	if err := f.SetFieldB("title", 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scores, _ = f.GetScores([]string{"title:rust"})
+	if scores[0] != scores[1] {
+		t.Errorf("Expected equal scores without title normalization, but got %v", scores)
+	}
+	if b := f.Params()["b.title"]; b != 0 {
+		t.Errorf("Expected b.title to be 0, but got %.2f", b)
+	}
+	if b := f.Params()["b.body"]; b != 0.75 {
+		t.Errorf("Expected b.body to default to 0.75, but got %.2f", b)
+	}
+
+	// Test case: Invalid field parameters
+	var paramErr *bm25.ErrInvalidParam
+	if err := f.SetFieldB("title", 1.5); !errors.As(err, &paramErr) || paramErr.Name != "b.title" {
+		t.Errorf("Expected ErrInvalidParam for b.title, but got %v", err)
+	}
+	if err := f.SetFieldB("author", 0.5); err == nil {
+		t.Errorf("Expected an error for an unknown field, but got nil")
+	}
+	if err := f.Freeze().SetFieldB("body", 0.5); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+}
+
+func TestBM25FAnalyzers(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	en, _ := bm25.LanguageAnalyzer("en")
+	analyzers := map[string]bm25.Analyzer{
+		"body": en,
+		"tag":  {Tokenizer: bm25.KeywordTokenizer, Filters: []bm25.TokenFilter{bm25.LowercaseFilter}},
+	}
+	corpus := []map[string]string{
+		{"title": "Tokio", "body": "Running async tasks", "tag": "Rust Async"},
+		{"title": "Asyncio", "body": "The event loop runs coroutines", "tag": "python"},
+	}
+	weights := map[string]float64{"title": 2, "body": 1, "tag": 1}
+
+	// Test case: An analyzer for a field that is not indexed
+	_, err := bm25.NewBM25FWithAnalyzers(corpus, tokenizer, weights, map[string]bm25.Analyzer{"other": en}, 1.2, 0.75, nil)
+	var paramErr *bm25.ErrInvalidParam
+	if !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam, but got %v", err)
+	}
+
+	f, err := bm25.NewBM25FWithAnalyzers(corpus, tokenizer, weights, analyzers, 1.2, 0.75, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Restricted clauses are analyzed with the analyzer of their field
+	if tokens := f.ParseQuery(`tag:"Rust Async" body:tasks`); !reflect.DeepEqual(tokens, []string{"tag:rust async", "body:task"}) {
+		t.Errorf("Unexpected tokens %v", tokens)
+	}
+
+	// Test case: Unrestricted clauses are analyzed for every field
+	if tokens := f.ParseQuery("tasks"); !reflect.DeepEqual(tokens, []string{"body:task", "tag:tasks", "title:tasks"}) {
+		t.Errorf("Unexpected tokens %v", tokens)
+	}
+
+	// Test case: Documents match through the analyzers of their fields
+	scores, err := f.GetScores(f.ParseQuery("task runs"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if scores[0] <= 0 || scores[1] <= 0 {
+		t.Errorf("Expected both documents to match the stemmed body, but got %v", scores)
+	}
+	scores, _ = f.GetScores(f.ParseQuery(`tag:"rust async"`))
+	if scores[0] <= 0 || scores[1] != 0 {
+		t.Errorf("Expected only the first document to match the tag, but got %v", scores)
+	}
+}
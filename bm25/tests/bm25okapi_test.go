@@ -1,6 +1,7 @@
 package bm25_test
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -173,3 +174,33 @@ func TestBM25OkapiGetTopN(t *testing.T) {
 		}
 	}
 }
+
+func TestBM25OkapiGetScoresInto(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world", "this is a test", "hello there"}, tokenizer, 1.5, 0.75, nil)
+	query := []string{"hello", "test"}
+	expected, _ := okapi.GetScores(query)
+
+	// Test case: Scores are stored in dst, overwriting its previous contents
+	dst := []float64{9, 9, 9, 9}
+	scores, err := okapi.GetScoresInto(dst, query)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(scores, expected) || &scores[0] != &dst[0] {
+		t.Errorf("Expected scores %v in dst, but got %v", expected, scores)
+	}
+
+	// Test case: A small dst is replaced
+	if scores, _ := okapi.GetScoresInto(make([]float64, 1), query); !reflect.DeepEqual(scores, expected) {
+		t.Errorf("Expected scores %v, but got %v", expected, scores)
+	}
+
+	// Test case: Reusing dst does not allocate
+	allocs := testing.AllocsPerRun(100, func() {
+		dst, _ = okapi.GetScoresInto(dst, query)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, but got %.1f", allocs)
+	}
+}
@@ -0,0 +1,79 @@
+package bm25_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestNewBM25TK1(t *testing.T) {
+	corpus := []string{"hello world", "this is a test"}
+
+	// Test case: Creating a new BM25TK1 instance with b outside the range [0, 1]
+	if _, err := bm25.NewBM25TK1(corpus, strings.Fields, 1.5, nil); err == nil {
+		t.Errorf("Expected an error for b outside the range [0, 1], but got nil")
+	}
+
+	// Test case: Creating a new BM25TK1 instance with valid inputs
+	if _, err := bm25.NewBM25TK1(corpus, strings.Fields, 0.75, nil); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestBM25TK1GetScores(t *testing.T) {
+	corpus := []string{
+		"go gopher",
+		"go go go go go go tutorial",
+		"rust borrow checker",
+		"rust",
+		"python notebooks",
+	}
+	tk1, _ := bm25.NewBM25TK1(corpus, strings.Fields, 0, nil)
+
+	// Test case: The k1 of a term matches the mean log frequency of its documents
+	k1 := tk1.TermK1("go")
+	mean := (math.Log(2) + math.Log(7)) / 2
+	if got := k1 * math.Log(k1) / (k1 - 1); math.Abs(got-mean) > 1e-6 {
+		t.Errorf("Expected k1 log(k1) / (k1 - 1) to be %v, but got %v for k1 %v", mean, got, k1)
+	}
+
+	// Test case: Terms repeated within documents saturate more slowly
+	if rust := tk1.TermK1("rust"); rust >= k1 {
+		t.Errorf("Expected a lower k1 for a term occurring once per document, but got %v and %v", rust, k1)
+	}
+	if k1 := tk1.TermK1("java"); k1 != 0 {
+		t.Errorf("Expected a k1 of 0 for an unknown term, but got %v", k1)
+	}
+
+	// Test case: Scores follow the BM25 saturation with the k1 of the term
+	scores, err := tk1.GetScores([]string{"go"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	idf, _ := tk1.IDF("go")
+	expected := idf * (k1 + 1) * 6 / (k1 + 6)
+	if math.Abs(scores[1]-expected) > 1e-9 || scores[2] != 0 {
+		t.Errorf("Expected a score of %v for document 1, but got %v", expected, scores)
+	}
+
+	// Test case: Batch scores match the scores of the whole corpus
+	batch, err := tk1.GetBatchScores([]string{"go"}, []int{1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch[0] != scores[1] {
+		t.Errorf("Expected a batch score of %v, but got %v", scores[1], batch[0])
+	}
+
+	// Test case: Searching ranks like the scores
+	resp, err := tk1.Search(context.Background(), bm25.SearchRequest{Query: []string{"go"}, N: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Results[0].DocID != 1 {
+		t.Errorf("Expected document 1 to rank first, but got %d", resp.Results[0].DocID)
+	}
+}
@@ -0,0 +1,126 @@
+package bm25_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestNewBM25BaseWithOptions(t *testing.T) {
+	corpus := make([]string, 100)
+	for i := range corpus {
+		corpus[i] = fmt.Sprintf("doc %d term%d shared term%d", i, i%7, i%3)
+	}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+
+	serial, err := bm25.NewBM25Base(corpus, tokenizer, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Building in parallel yields the same statistics as a serial build
+	parallel, err := bm25.NewBM25BaseWithOptions(corpus, tokenizer, nil, bm25.BuildOptions{Workers: 8})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if parallel.CorpusSize() != serial.CorpusSize() {
+		t.Errorf("Expected corpus size %d, but got %d", serial.CorpusSize(), parallel.CorpusSize())
+	}
+	if parallel.AvgDocLen() != serial.AvgDocLen() {
+		t.Errorf("Expected average document length %.2f, but got %.2f", serial.AvgDocLen(), parallel.AvgDocLen())
+	}
+	for i, length := range serial.DocLengths() {
+		if parallel.DocLengths()[i] != length {
+			t.Errorf("Expected document length %d at index %d, but got %d", length, i, parallel.DocLengths()[i])
+		}
+	}
+	for _, term := range []string{"doc", "term0", "term6", "shared", "42"} {
+		expected, _ := serial.IDF(term)
+		idf, _ := parallel.IDF(term)
+		if idf != expected {
+			t.Errorf("Expected IDF %.2f for the term '%s', but got %.2f", expected, term, idf)
+		}
+	}
+
+	// Test case: Building in parallel reports the first document that failed to tokenize
+	failing := func(s string) []string {
+		if strings.HasSuffix(s, "term1") || strings.Contains(s, "doc 9 ") {
+			return []string{}
+		}
+		return tokenizer(s)
+	}
+	_, err = bm25.NewBM25BaseWithOptions(corpus, failing, nil, bm25.BuildOptions{Workers: 8})
+	if err == nil || !strings.Contains(err.Error(), "index 1") {
+		t.Errorf("Expected an error for the document at index 1, but got %v", err)
+	}
+}
+
+func TestNewBM25OkapiFromBase(t *testing.T) {
+	corpus := []string{"hello world", "this is a test"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+
+	// Test case: Creating a variant from a nil base
+	_, err := bm25.NewBM25OkapiFromBase(nil, 1.2, 0.75)
+	if err == nil {
+		t.Errorf("Expected an error for a nil base, but got nil")
+	}
+
+	base, _ := bm25.NewBM25BaseWithOptions(corpus, tokenizer, nil, bm25.BuildOptions{Workers: 2})
+
+	// Test case: Creating a variant from a base with invalid parameters
+	_, err = bm25.NewBM25OkapiFromBase(base, -1.0, 0.75)
+	if err == nil {
+		t.Errorf("Expected an error for negative k1, but got nil")
+	}
+
+	// Test case: A variant built from a base scores like one built from the corpus
+	fromBase, err := bm25.NewBM25OkapiFromBase(base, 1.2, 0.75)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	direct, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0.75, nil)
+	expected, _ := direct.GetScores([]string{"hello", "test"})
+	scores, err := fromBase.GetScores([]string{"hello", "test"})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for i, score := range scores {
+		if score != expected[i] {
+			t.Errorf("Expected score %.2f at index %d, but got %.2f", expected[i], i, score)
+		}
+	}
+}
+
+func TestBuildProgress(t *testing.T) {
+	corpus := make([]string, 100)
+	for i := range corpus {
+		corpus[i] = fmt.Sprintf("doc %d", i)
+	}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+
+	// Test case: Progress callbacks cover every document and token of the corpus
+	var updates []bm25.BuildProgress
+	opts := bm25.BuildOptions{
+		Workers:          4,
+		ProgressInterval: 5,
+		Progress:         func(p bm25.BuildProgress) { updates = append(updates, p) },
+	}
+	_, err := bm25.NewBM25BaseWithOptions(corpus, tokenizer, nil, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(updates) != 20 {
+		t.Errorf("Expected 20 progress updates, but got %d", len(updates))
+	}
+	for i := 1; i < len(updates); i++ {
+		if updates[i].DocsProcessed <= updates[i-1].DocsProcessed {
+			t.Errorf("Expected increasing document counts, but got %d after %d", updates[i].DocsProcessed, updates[i-1].DocsProcessed)
+		}
+	}
+	last := updates[len(updates)-1]
+	if last.DocsProcessed != 100 || last.TotalDocs != 100 || last.TokensSeen != 200 {
+		t.Errorf("Expected 100/100 documents and 200 tokens, but got %d/%d documents and %d tokens", last.DocsProcessed, last.TotalDocs, last.TokensSeen)
+	}
+}
@@ -0,0 +1,117 @@
+package bm25_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestBuilder(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+
+	// Test case: Creating a builder without a tokenizer
+	_, err := bm25.NewBuilder(nil, nil, bm25.BuildOptions{})
+	if !errors.Is(err, bm25.ErrNilTokenizer) {
+		t.Errorf("Expected ErrNilTokenizer, but got %v", err)
+	}
+
+	builder, err := bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Building an empty index
+	_, err = builder.Build()
+	if !errors.Is(err, bm25.ErrEmptyCorpus) {
+		t.Errorf("Expected ErrEmptyCorpus, but got %v", err)
+	}
+
+	docs := []bm25.Document{
+		{ID: "a", Text: "hello world", Metadata: map[string]any{"lang": "en"}},
+		{Text: "this is a test"},
+		{ID: "c", Text: "hello there"},
+	}
+	for i, doc := range docs {
+		docID, err := builder.Add(doc)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if docID != i {
+			t.Errorf("Expected document ID %d, but got %d", i, docID)
+		}
+	}
+
+	// Test case: Adding a document with a duplicate ID or without tokens
+	_, err = builder.Add(bm25.Document{ID: "a", Text: "again"})
+	if !errors.Is(err, bm25.ErrDuplicateID) {
+		t.Errorf("Expected ErrDuplicateID, but got %v", err)
+	}
+	_, err = builder.Add(bm25.Document{Text: ""})
+	if !errors.Is(err, bm25.ErrEmptyDocument) {
+		t.Errorf("Expected ErrEmptyDocument, but got %v", err)
+	}
+
+	base, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: A built index matches one built from the full corpus
+	expected, _ := bm25.NewBM25Base([]string{"hello world", "this is a test", "hello there"}, tokenizer, nil)
+	if base.CorpusSize() != expected.CorpusSize() || base.AvgDocLen() != expected.AvgDocLen() {
+		t.Errorf("Expected %d documents of average length %.2f, but got %d of %.2f", expected.CorpusSize(), expected.AvgDocLen(), base.CorpusSize(), base.AvgDocLen())
+	}
+	idf, _ := base.IDF("hello")
+	expectedIDF, _ := expected.IDF("hello")
+	if idf != expectedIDF {
+		t.Errorf("Expected IDF %.2f for 'hello', but got %.2f", expectedIDF, idf)
+	}
+
+	// Test case: External IDs and metadata are kept per document
+	if docID, ok := base.LookupID("c"); !ok || docID != 2 {
+		t.Errorf("Expected document ID 2 for 'c', but got %d (present: %v)", docID, ok)
+	}
+	if _, ok := base.LookupID("b"); ok {
+		t.Errorf("Expected 'b' to be unknown")
+	}
+	if id := base.ExternalID(1); id != "" {
+		t.Errorf("Expected no external ID for document 1, but got %q", id)
+	}
+	if lang := base.Metadata(0)["lang"]; lang != "en" {
+		t.Errorf("Expected metadata lang=en for document 0, but got %v", lang)
+	}
+	if base.Metadata(2) != nil {
+		t.Errorf("Expected no metadata for document 2, but got %v", base.Metadata(2))
+	}
+
+	// Test case: Using the builder after Build
+	_, err = builder.Add(bm25.Document{Text: "late"})
+	if !errors.Is(err, bm25.ErrBuilderDone) {
+		t.Errorf("Expected ErrBuilderDone, but got %v", err)
+	}
+}
+
+func TestBuilderProgress(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+
+	// Test case: Progress is reported every interval and once more on Build
+	var updates []bm25.BuildProgress
+	builder, _ := bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{
+		ProgressInterval: 4,
+		Progress:         func(p bm25.BuildProgress) { updates = append(updates, p) },
+	})
+	for i := 0; i < 10; i++ {
+		builder.Add(bm25.Document{Text: "one two"})
+	}
+	builder.Build()
+
+	if len(updates) != 3 {
+		t.Fatalf("Expected 3 progress updates, but got %d", len(updates))
+	}
+	last := updates[len(updates)-1]
+	if last.DocsProcessed != 10 || last.TokensSeen != 20 {
+		t.Errorf("Expected 10 documents and 20 tokens, but got %d documents and %d tokens", last.DocsProcessed, last.TokensSeen)
+	}
+}
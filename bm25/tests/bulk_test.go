@@ -0,0 +1,94 @@
+package bm25_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestBulkMutations(t *testing.T) {
+	base, _ := bm25.NewBM25Base([]string{"hello world"}, strings.Fields, nil)
+	var added []int
+	base.OnDocumentAdded(func(docID int) { added = append(added, docID) })
+
+	// Test case: Failing documents are reported without stopping the batch
+	result, err := base.BulkAdd([]bm25.Document{
+		{ID: "a", Text: "first doc"},
+		{ID: "b", Text: "   "},
+		{ID: "a", Text: "duplicate id"},
+		{ID: "c", Text: "third doc here"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Failed != 2 || len(result.Items) != 4 {
+		t.Fatalf("Expected 2 of 4 operations to fail, but got %+v", result)
+	}
+	if !errors.Is(result.Items[1].Err, bm25.ErrEmptyDocument) || !errors.Is(result.Items[2].Err, bm25.ErrDuplicateID) {
+		t.Errorf("Expected the errors of the failed operations, but got %+v", result.Items)
+	}
+	if result.Items[0].DocID != 1 || result.Items[3].DocID != 2 || result.Items[2].DocID != -1 || result.Items[0].Version != 1 {
+		t.Errorf("Expected the internal IDs and versions of the added documents, but got %+v", result.Items)
+	}
+	if err := result.Err(); err == nil || !strings.Contains(err.Error(), "operation 2: ") {
+		t.Errorf("Expected the joined errors, but got %v", err)
+	}
+
+	// Test case: The statistics match adding the documents one at a time
+	if math.Abs(base.AvgDocLen()-7.0/3) > 1e-9 || base.CorpusSize() != 3 {
+		t.Errorf("Expected an average length of 7/3 over 3 documents, but got %.4f over %d", base.AvgDocLen(), base.CorpusSize())
+	}
+	if idf, _ := base.IDF("doc"); idf <= 0 {
+		t.Errorf("Expected the IDF cache to be reset, but got %.4f", idf)
+	}
+	if len(added) != 2 || added[0] != 1 || added[1] != 2 {
+		t.Errorf("Expected the hooks to be called for the added documents, but got %v", added)
+	}
+
+	// Test case: Updates with stale versions fail individually
+	result, _ = base.BulkUpdate([]bm25.DocumentUpdate{
+		{Doc: bm25.Document{ID: "a", Text: "first doc updated"}, Expected: 1},
+		{Doc: bm25.Document{ID: "c", Text: "stale"}, Expected: 5},
+		{Doc: bm25.Document{ID: "d", Text: "new doc"}},
+	})
+	if result.Failed != 1 || !errors.Is(result.Items[1].Err, bm25.ErrVersionConflict) {
+		t.Errorf("Expected the stale update to fail, but got %+v", result.Items)
+	}
+	if result.Items[0].Version != 2 || result.Items[2].Version != 1 {
+		t.Errorf("Expected versions 2 and 1, but got %+v", result.Items)
+	}
+
+	// Test case: Deletions report missing documents
+	result, _ = base.BulkDelete([]bm25.DocumentDelete{{ID: "a", Expected: 2}, {ID: "missing"}})
+	if result.Failed != 1 || !errors.Is(result.Items[1].Err, bm25.ErrDocumentNotFound) || result.Items[0].Version != 2 {
+		t.Errorf("Expected the missing document to fail, but got %+v", result.Items)
+	}
+	if _, ok := base.LookupID("a"); ok {
+		t.Errorf("Expected document a to be deleted")
+	}
+
+	// Test case: Frozen indexes are rejected
+	if _, err := base.Freeze().BulkAdd(nil); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+}
+
+func TestCopyOnWriteBulk(t *testing.T) {
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world"}, strings.Fields, 1.5, 0.75, nil)
+	index, _ := bm25.NewCopyOnWriteIndex(okapi)
+
+	// Test case: A bulk mutation is published as one version
+	result, err := index.BulkAdd([]bm25.Document{{ID: "a", Text: "one"}, {ID: "b", Text: "two"}})
+	if err != nil || result.Failed != 0 {
+		t.Fatalf("Unexpected error: %v %+v", err, result)
+	}
+	if index.Version() != 1 || index.CorpusSize() != 3 {
+		t.Errorf("Expected 3 documents in version 1, but got %d in version %d", index.CorpusSize(), index.Version())
+	}
+	if result, _ := index.BulkDelete([]bm25.DocumentDelete{{ID: "a"}}); result.Failed != 0 || index.Version() != 2 {
+		t.Errorf("Expected the deletion to be published, but got %+v in version %d", result, index.Version())
+	}
+}
@@ -0,0 +1,176 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestResultCache(t *testing.T) {
+	builder, _ := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	for i, text := range []string{"red apple pie", "green apple", "apple tart recipe"} {
+		builder.Add(bm25.Document{Text: text, Metadata: map[string]any{"year": 2019 + 2*i}})
+	}
+	base, _ := builder.Build()
+	okapi, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	cache, err := bm25.NewResultCache(okapi, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	search := func(req bm25.SearchRequest) *bm25.SearchResponse {
+		resp, err := cache.Search(ctx, req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	// Test case: An identical request is served from the cache
+	req := bm25.SearchRequest{Query: []string{"apple"}, N: 3}
+	first := search(req)
+	second := search(req)
+	if first.Cached || !second.Cached || len(second.Results) != len(first.Results) {
+		t.Errorf("Expected the second response to be cached, but got %v and %v", first.Cached, second.Cached)
+	}
+
+	// Test case: Filters, N and parameters are part of the key
+	filtered := req
+	filtered.Ranges = []bm25.RangeFilter{{Field: "year", Min: 2020}}
+	if resp := search(filtered); resp.Cached || len(resp.Results) != 2 {
+		t.Errorf("Expected 2 uncached results, but got %d (cached: %v)", len(resp.Results), resp.Cached)
+	}
+	paged := req
+	paged.N = 1
+	if resp := search(paged); resp.Cached || len(resp.Results) != 1 {
+		t.Errorf("Expected 1 uncached result, but got %d (cached: %v)", len(resp.Results), resp.Cached)
+	}
+	tuned := req
+	tuned.Params = map[string]float64{"k1": 0.5}
+	if resp := search(tuned); resp.Cached {
+		t.Errorf("Expected a request with other parameters not to be cached")
+	}
+
+	// Test case: The least recently used responses are evicted
+	if stats := cache.Stats(); stats.Entries != 2 {
+		t.Errorf("Expected 2 entries, but got %d", stats.Entries)
+	}
+
+	// Test case: Changing a field invalidates the requests depending on it
+	search(filtered)
+	if resp := search(filtered); !resp.Cached {
+		t.Errorf("Expected the filtered request to be cached")
+	}
+	cache.InvalidateFields("year")
+	if resp := search(filtered); resp.Cached {
+		t.Errorf("Expected the filtered request to be invalidated")
+	}
+	if resp := search(tuned); !resp.Cached {
+		t.Errorf("Expected the unfiltered request to stay cached")
+	}
+
+	// Test case: Requests with a Filter function are not cached
+	withFilter := req
+	withFilter.Filter = func(docID int) bool { return true }
+	search(withFilter)
+	if resp := search(withFilter); resp.Cached {
+		t.Errorf("Expected a request with a Filter not to be cached")
+	}
+
+	// Test case: Adding a document invalidates the cache
+	all := bm25.SearchRequest{Query: []string{"apple"}, N: 10}
+	search(all)
+	if _, err := okapi.AddDocument(bm25.Document{Text: "apple crumble"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp := search(all); resp.Cached || len(resp.Results) != 4 {
+		t.Errorf("Expected 4 uncached results, but got %d (cached: %v)", len(resp.Results), resp.Cached)
+	}
+
+	// Test case: A new version of a CopyOnWriteIndex invalidates the cache
+	cow, _ := bm25.NewCopyOnWriteIndex(okapi.Clone())
+	cowCache, _ := bm25.NewResultCache(cow, 10)
+	cowCache.Search(ctx, all)
+	if _, err := cow.Add(bm25.Document{Text: "apple strudel"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, err := cowCache.Search(ctx, all)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Cached || len(resp.Results) != 5 {
+		t.Errorf("Expected 5 uncached results, but got %d (cached: %v)", len(resp.Results), resp.Cached)
+	}
+
+	// Test case: Changing the settings of a variant invalidates the cache
+	search(all)
+	if err := okapi.SetTermWeights(map[string]float64{"apple": 2}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp := search(all); resp.Cached {
+		t.Errorf("Expected the request to be invalidated by the term weights")
+	}
+	if err := okapi.ExcludeStopwords([]string{"apple"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp := search(all); resp.Cached {
+		t.Errorf("Expected the request to be invalidated by the stopwords")
+	}
+
+	// Test case: Updating the QueryDictionary invalidates the cache
+	dictionary := bm25.NewQueryDictionary()
+	if err := cow.Update(func(base *bm25.Bm25Base) error { return base.SetQueryDictionary(dictionary) }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cowCache.Search(ctx, all)
+	if _, err := dictionary.SetSynonyms(map[string][]string{"apple": {"pear"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, err = cowCache.Search(ctx, all)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Cached || resp.DictionaryVersion != 1 {
+		t.Errorf("Expected an uncached response for dictionary version 1, but got version %d (cached: %v)", resp.DictionaryVersion, resp.Cached)
+	}
+
+	// Test case: Responses are dropped once one of their documents expires
+	ttl, _ := bm25.NewBM25Okapi([]string{"apple pie"}, strings.Fields, 1.5, 0.75, nil)
+	ttlCache, _ := bm25.NewResultCache(ttl, 10)
+	if _, err := ttl.AddDocument(bm25.Document{Text: "apple tart", TTL: 20 * time.Millisecond}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ttlCache.Search(ctx, all)
+	if resp, _ := ttlCache.Search(ctx, all); !resp.Cached {
+		t.Errorf("Expected the request to be cached before the document expires")
+	}
+	time.Sleep(30 * time.Millisecond)
+	resp, err = ttlCache.Search(ctx, all)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Cached || len(resp.Results) != 1 {
+		t.Errorf("Expected 1 uncached result after the document expired, but got %d (cached: %v)", len(resp.Results), resp.Cached)
+	}
+
+	// Test case: Warmup caches the responses of the sample requests
+	warm, _ := bm25.NewResultCache(okapi, 10)
+	if err := warm.Warmup(ctx, []bm25.SearchRequest{req, paged}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp, _ := warm.Search(ctx, paged); !resp.Cached || warm.Stats().Entries != 2 {
+		t.Errorf("Expected 2 warmed entries serving the request, but got %+v (cached: %v)", warm.Stats(), resp.Cached)
+	}
+	if err := warm.Warmup(ctx, []bm25.SearchRequest{{N: 1}}); !errors.Is(err, bm25.ErrEmptyQuery) {
+		t.Errorf("Expected ErrEmptyQuery, but got %v", err)
+	}
+
+	// Test case: The capacity must be positive
+	if _, err := bm25.NewResultCache(okapi, 0); err == nil {
+		t.Errorf("Expected an error for a capacity of 0")
+	}
+}
@@ -0,0 +1,81 @@
+package bm25_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestClone(t *testing.T) {
+	corpus := []string{"the cat sat", "the dog ran", "the bird flew"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	bm25, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0.75, nil)
+
+	// Test case: Mutating a clone leaves the original untouched
+	clone := bm25.Clone()
+	err := clone.ExcludeStopwords([]string{"the"})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(bm25.Stopwords()) != 0 {
+		t.Errorf("Expected no stopwords on the original, but got %v", bm25.Stopwords())
+	}
+
+	// Test case: A clone scores like the original
+	expected, _ := bm25.GetScores([]string{"cat", "dog"})
+	scores, err := clone.GetScores([]string{"cat", "dog"})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for i, score := range scores {
+		if score != expected[i] {
+			t.Errorf("Expected score %.2f at index %d, but got %.2f", expected[i], i, score)
+		}
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	corpus := []string{"the cat sat", "the dog ran", "the bird flew"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	bm25, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0.75, nil)
+	frozen := bm25.Freeze()
+
+	// Test case: A frozen index rejects modifications
+	if !frozen.IsFrozen() {
+		t.Errorf("Expected the index to be frozen")
+	}
+	if err := frozen.ExcludeStopwords([]string{"the"}); err == nil {
+		t.Errorf("Expected an error when modifying a frozen index, but got nil")
+	}
+	if err := frozen.CompactVocabulary(); err == nil {
+		t.Errorf("Expected an error when compacting a frozen index, but got nil")
+	}
+
+	// Test case: A clone of a frozen index is mutable again
+	if frozen.Clone().IsFrozen() {
+		t.Errorf("Expected a clone of a frozen index to be mutable")
+	}
+
+	// Test case: A frozen index can be queried concurrently
+	expected, _ := bm25.GetScores([]string{"cat", "dog"})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scores, err := frozen.GetScores([]string{"cat", "dog"})
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			for i, score := range scores {
+				if score != expected[i] {
+					t.Errorf("Expected score %.2f at index %d, but got %.2f", expected[i], i, score)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
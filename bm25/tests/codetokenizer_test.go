@@ -0,0 +1,47 @@
+package bm25_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestCodeTokenizer(t *testing.T) {
+	tokenize := bm25.NewCodeTokenizer(bm25.CodeTokenizerOptions{})
+
+	// Test case: Splitting camelCase, snake_case and acronyms, dropping delimiters
+	tokens := tokenize("func parseHTTPRequest(raw_input []byte) (*http.Request, error) { return utf8Decode(x) }")
+	expected := []string{"func", "parse", "HTTP", "Request", "raw", "input", "byte", "http", "Request", "error", "return", "utf8", "Decode", "x"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %q, but got %q", expected, tokens)
+	}
+
+	// Test case: Lowercasing, keeping identifiers and emitting operators
+	tokenize = bm25.NewCodeTokenizer(bm25.CodeTokenizerOptions{Lowercase: true, KeepIdentifiers: true, Symbols: true})
+	tokens = tokenize("if userID != nil && a->b == std::max(x)")
+	expected = []string{"if", "userid", "user", "id", "!=", "nil", "&&", "a", "->", "b", "==", "std", "::", "max", "x"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %q, but got %q", expected, tokens)
+	}
+
+	// Test case: Dropping short identifier parts
+	tokenize = bm25.NewCodeTokenizer(bm25.CodeTokenizerOptions{MinLength: 2})
+	tokens = tokenize("x_coord = getX()")
+	expected = []string{"coord", "get"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %q, but got %q", expected, tokens)
+	}
+
+	// Test case: Code search ranks the document defining the identifier first
+	corpus := []string{"func getUserName() string", "func setTimeout(ms int)", "var user_count int"}
+	lower := bm25.NewCodeTokenizer(bm25.CodeTokenizerOptions{Lowercase: true})
+	okapi, err := bm25.NewBM25Okapi(corpus, lower, 1.5, 0.75, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scores, _ := okapi.GetScores(lower("user_name"))
+	if scores[0] <= scores[1] || scores[0] <= scores[2] {
+		t.Errorf("Expected the first document to score highest, but got %v", scores)
+	}
+}
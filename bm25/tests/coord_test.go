@@ -0,0 +1,89 @@
+package bm25_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestSearchCoord(t *testing.T) {
+	corpus := []string{
+		"zebra stripes",
+		"cat dog park",
+		"cat dog house",
+		"cat garden",
+		"dog garden",
+		"cat dog",
+	}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0.75, nil)
+	ctx := context.Background()
+	query := []string{"zebra", "cat", "dog"}
+
+	// Test case: Without a coordination factor, the rare term wins
+	resp, err := okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Results[0].DocID != 0 {
+		t.Fatalf("Expected document 0 as the best match without coord, but got %d", resp.Results[0].DocID)
+	}
+
+	// Test case: With a coordination factor, documents matching more terms win
+	resp, err = okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 3, Coord: 2, Explain: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Results[0].DocID != 5 {
+		t.Errorf("Expected document 5 as the best match with coord, but got %d", resp.Results[0].DocID)
+	}
+
+	// Test case: Scores are scaled by the fraction of matched terms
+	scores, _ := okapi.GetScores(query)
+	for _, result := range resp.Results {
+		matched := 0.0
+		for _, term := range query {
+			if strings.Contains(" "+corpus[result.DocID]+" ", " "+term+" ") {
+				matched++
+			}
+		}
+		expected := scores[result.DocID] * math.Pow(matched/3, 2)
+		if math.Abs(result.Score-expected) > 1e-9 {
+			t.Errorf("Expected score %.4f for document %d, but got %.4f", expected, result.DocID, result.Score)
+		}
+		if math.Abs(result.Explanation.Coord-math.Pow(matched/3, 2)) > 1e-9 {
+			t.Errorf("Expected coord %.4f for document %d, but got %.4f", math.Pow(matched/3, 2), result.DocID, result.Explanation.Coord)
+		}
+	}
+
+	// Test case: Duplicate query terms count once
+	dup, _ := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"cat", "cat", "dog"}, N: 6, Coord: 1, Explain: true})
+	for _, result := range dup.Results {
+		if result.DocID == 3 && result.Explanation.Coord != 0.5 {
+			t.Errorf("Expected coord 0.5 for document 3, but got %.2f", result.Explanation.Coord)
+		}
+	}
+
+	// Test case: Documents match by the presence of the terms, not by their score, as
+	// BM25Plus scores documents without the terms too
+	plus, _ := bm25.NewBM25PlusFromBase(okapi.Bm25Base, 1.5, 0.75, 1, 0.25)
+	resp, err = plus.Search(ctx, bm25.SearchRequest{Query: []string{"zebra", "stripes"}, N: 6, Coord: 1, Explain: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, result := range resp.Results {
+		if result.DocID != 0 && result.Score != 0 {
+			t.Errorf("Expected document %d without the terms to score 0, but got %v (coord %v)", result.DocID, result.Score, result.Explanation.Coord)
+		}
+	}
+
+	// Test case: Invalid coordination factors
+	for _, coord := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if _, err := okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 3, Coord: coord}); err == nil {
+			t.Errorf("Expected an error for coord %v, but got nil", coord)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package bm25_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestEstimateCost(t *testing.T) {
+	corpus := make([]string, 0, 20)
+	for i := 0; i < 19; i++ {
+		corpus = append(corpus, "the news of the day")
+	}
+	corpus = append(corpus, "the rare zeppelin")
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.5, 0.75, nil)
+
+	// Test case: A rare term is cheap
+	cost := okapi.EstimateCost([]string{"zeppelin"})
+	if cost.Terms != 1 || cost.Postings != 1 || cost.Class != bm25.LatencyLow {
+		t.Errorf("Expected 1 term with 1 posting of low latency, but got %+v", cost)
+	}
+
+	// Test case: A common term scans most of the corpus
+	cost = okapi.EstimateCost([]string{"news"})
+	if cost.Postings != 19 || cost.Class != bm25.LatencyMedium {
+		t.Errorf("Expected 19 postings of medium latency, but got %+v", cost)
+	}
+
+	// Test case: Several common terms scan more postings than there are documents
+	cost = okapi.EstimateCost([]string{"the", "news", "day"})
+	if cost.Postings != 58 || cost.Class != bm25.LatencyHigh || cost.Class.String() != "high" {
+		t.Errorf("Expected 58 postings of high latency, but got %+v", cost)
+	}
+
+	// Test case: Excluded stopwords are not scanned
+	if err := okapi.ExcludeStopwords([]string{"the"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cost = okapi.EstimateCost([]string{"the", "zeppelin"})
+	if cost.Stopwords != 1 || cost.Postings != 1 {
+		t.Errorf("Expected 1 stopword and 1 posting, but got %+v", cost)
+	}
+
+	// Test case: Synonyms are expanded like a search expands them
+	dictionary := bm25.NewQueryDictionary()
+	if _, err := dictionary.SetSynonyms(map[string][]string{"airship": {"zeppelin"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := okapi.SetQueryDictionary(dictionary); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cost = okapi.EstimateCost([]string{"airship"})
+	if cost.Terms != 2 || cost.Postings != 1 {
+		t.Errorf("Expected 2 terms with 1 posting, but got %+v", cost)
+	}
+}
@@ -0,0 +1,75 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestCopyOnWriteIndex(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world", "this is a test"}, tokenizer, 1.5, 0.75, nil)
+
+	// Test case: Wrapping a frozen index
+	if _, err := bm25.NewCopyOnWriteIndex(okapi.Freeze()); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen for a frozen index, but got %v", err)
+	}
+
+	index, err := bm25.NewCopyOnWriteIndex(okapi)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: A batch is published at once, and read by the writer afterwards
+	pinned := index.Current()
+	docIDs, err := index.Add(bm25.Document{Text: "hello again"}, bm25.Document{Text: "hello there"})
+	if err != nil || len(docIDs) != 2 || docIDs[1] != 3 {
+		t.Fatalf("Expected documents 2 and 3, but got %v (error: %v)", docIDs, err)
+	}
+	if index.CorpusSize() != 4 || index.Version() != 1 {
+		t.Errorf("Expected version 1 with 4 documents, but got version %d with %d", index.Version(), index.CorpusSize())
+	}
+	if pinned.CorpusSize() != 2 {
+		t.Errorf("Expected earlier versions to stay unchanged, but got %d documents", pinned.CorpusSize())
+	}
+
+	// Test case: Searches do not wait for a running batch, and do not see it
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- index.Update(func(base *bm25.Bm25Base) error {
+			close(started)
+			<-release
+			_, err := base.AddDocument(bm25.Document{Text: "hello world again"})
+			return err
+		})
+	}()
+	<-started
+	searched := make(chan *bm25.SearchResponse)
+	go func() {
+		resp, _ := index.Search(context.Background(), bm25.SearchRequest{Query: []string{"hello"}, N: 10})
+		searched <- resp
+	}()
+	select {
+	case resp := <-searched:
+		if resp == nil || len(resp.Results) != 4 {
+			t.Errorf("Expected the 4 documents of the published version, but got %v", resp)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the search not to wait for the writer")
+	}
+	close(release)
+	if err := <-done; err != nil || index.CorpusSize() != 5 || index.Version() != 2 {
+		t.Errorf("Expected version 2 with 5 documents, but got version %d with %d (error: %v)", index.Version(), index.CorpusSize(), err)
+	}
+
+	// Test case: The documents of a batch before a failing one are published
+	docIDs, err = index.Add(bm25.Document{Text: "hello"}, bm25.Document{Text: ""})
+	if !errors.Is(err, bm25.ErrEmptyDocument) || len(docIDs) != 1 || index.CorpusSize() != 6 {
+		t.Errorf("Expected ErrEmptyDocument after 1 published document, but got %v with %d documents", err, index.CorpusSize())
+	}
+}
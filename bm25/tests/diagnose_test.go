@@ -0,0 +1,52 @@
+package bm25_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestDiagnoseQuery(t *testing.T) {
+	corpus := []string{"the cat sat", "the dog ran", "the cat ran", "a bird sang"}
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.5, 0.75, nil)
+	okapi.ExcludeStopwords([]string{"a"})
+
+	// Test case: Every term is classified, once, in query order
+	diagnostics, err := okapi.DiagnoseQuery([]string{"the", "cat", "fish", "a", "cat"}, 0.5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(diagnostics.Terms) != 4 {
+		t.Fatalf("Expected 4 distinct terms, but got %v", diagnostics.Terms)
+	}
+	if !reflect.DeepEqual(diagnostics.Matched(), []string{"cat"}) {
+		t.Errorf("Expected [cat] to match, but got %v", diagnostics.Matched())
+	}
+	if !reflect.DeepEqual(diagnostics.OutOfVocabulary(), []string{"fish"}) {
+		t.Errorf("Expected [fish] to be out of vocabulary, but got %v", diagnostics.OutOfVocabulary())
+	}
+	if !reflect.DeepEqual(diagnostics.Stopwords(), []string{"a"}) {
+		t.Errorf("Expected [a] to be a stopword, but got %v", diagnostics.Stopwords())
+	}
+	if !reflect.DeepEqual(diagnostics.LowIDF(), []string{"the"}) {
+		t.Errorf("Expected [the] to have a low IDF, but got %v", diagnostics.LowIDF())
+	}
+
+	cat := diagnostics.Terms[1]
+	idf, _ := okapi.IDF("cat")
+	if cat.DocFreq != 2 || cat.IDF != idf {
+		t.Errorf("Expected document frequency 2 and IDF %f for 'cat', but got %+v", idf, cat)
+	}
+	if !strings.Contains(diagnostics.String(), "out of vocabulary") {
+		t.Errorf("Expected the report to list the out-of-vocabulary term, but got:\n%s", diagnostics)
+	}
+
+	// Test case: Invalid queries
+	_, err = okapi.DiagnoseQuery([]string{"cat", ""}, 0.1)
+	if !errors.Is(err, bm25.ErrEmptyTerm) {
+		t.Errorf("Expected ErrEmptyTerm, but got %v", err)
+	}
+}
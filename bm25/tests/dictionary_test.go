@@ -0,0 +1,147 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestQueryDictionary(t *testing.T) {
+	corpus := []string{"the car is red", "an automobile for sale", "the red bike", "a blue boat"}
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.5, 0.75, nil)
+	index, _ := bm25.NewCopyOnWriteIndex(okapi)
+	dictionary := bm25.NewQueryDictionary()
+	if err := index.Update(func(base *bm25.Bm25Base) error { return base.SetQueryDictionary(dictionary) }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	// Test case: Without synonyms, only the literal term matches
+	resp, _ := index.Search(ctx, bm25.SearchRequest{Query: []string{"car"}, N: 4})
+	if resp.DictionaryVersion != 0 || resp.Results[1].Score > 0 {
+		t.Errorf("Expected only document 0 to match version 0, but got %+v", resp)
+	}
+
+	// Test case: Synonyms take effect on the published version without republishing it
+	published := index.Version()
+	version, err := dictionary.SetSynonyms(map[string][]string{"car": {"automobile"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, _ = index.Search(ctx, bm25.SearchRequest{Query: []string{"car"}, N: 4})
+	if resp.DictionaryVersion != version || index.Version() != published {
+		t.Errorf("Expected version %d of the dictionary on version %d of the index, but got %d on %d", version, published, resp.DictionaryVersion, index.Version())
+	}
+	if resp.Results[1].Score <= 0 {
+		t.Errorf("Expected the synonym to match a second document, but got %+v", resp.Results)
+	}
+
+	// Test case: A synonym counts as the term it expands for the coordination factor
+	resp, _ = index.Search(ctx, bm25.SearchRequest{Query: []string{"car", "sale"}, N: 4, Coord: 1, Explain: true})
+	if resp.Results[0].DocID != 1 || resp.Results[0].Explanation.Coord != 1 {
+		t.Errorf("Expected document 1 to match all query terms, but got %+v", resp.Results[0])
+	}
+
+	// Test case: Stopwords are removed from queries, unless nothing else is left
+	dictionary.SetStopwords([]string{"the", "red"})
+	resp, _ = index.Search(ctx, bm25.SearchRequest{Query: []string{"the", "red", "boat"}, N: 4})
+	if resp.Results[0].DocID != 3 || resp.Results[1].Score > 0 {
+		t.Errorf("Expected only document 3 to match, but got %+v", resp.Results)
+	}
+	resp, _ = index.Search(ctx, bm25.SearchRequest{Query: []string{"red"}, N: 4})
+	if resp.Results[1].Score <= 0 {
+		t.Errorf("Expected a stopword-only query to be kept, but got %+v", resp.Results)
+	}
+	if got := dictionary.Stopwords(); !reflect.DeepEqual(got, []string{"red", "the"}) {
+		t.Errorf("Expected the sorted stopwords, but got %v", got)
+	}
+	if got := dictionary.Synonyms(); !reflect.DeepEqual(got, map[string][]string{"car": {"automobile"}}) {
+		t.Errorf("Expected the synonyms, but got %v", got)
+	}
+
+	// Test case: Searches run consistently while the dictionary is updated
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := index.Search(ctx, bm25.SearchRequest{Query: []string{"car", "bike"}, N: 2}); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}
+		}()
+	}
+	for j := 0; j < 50; j++ {
+		dictionary.SetSynonyms(map[string][]string{"bike": {"boat"}})
+		dictionary.SetStopwords(nil)
+	}
+	wg.Wait()
+
+	// Test case: Invalid synonyms and frozen indexes are rejected
+	if _, err := dictionary.SetSynonyms(map[string][]string{"car": {""}}); err == nil {
+		t.Errorf("Expected an error for an empty synonym, but got nil")
+	}
+	if err := okapi.Freeze().SetQueryDictionary(nil); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+}
+
+func TestEntityBoosts(t *testing.T) {
+	corpus := []string{
+		"apple pie recipe with cinnamon",
+		"apple iphone review and pie chart",
+		"tim cook presents the new iphone",
+		"how to cook for tim",
+	}
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.5, 0.75, nil)
+	dictionary := bm25.NewQueryDictionary()
+	if err := okapi.SetQueryDictionary(dictionary); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	score := func(query string, docID int) float64 {
+		resp, err := okapi.Search(ctx, bm25.SearchRequest{Query: strings.Fields(query), N: len(corpus)})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, result := range resp.Results {
+			if result.DocID == docID {
+				return result.Score
+			}
+		}
+		return 0
+	}
+	before := score("iphone pie", 1)
+
+	// Test case: Matches of an entity term are boosted without changing the index
+	if _, err := dictionary.SetEntities(map[string]float64{"iphone": 2, "tim cook": 3}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, _ := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"iphone", "pie"}, N: 2})
+	if resp.Results[0].DocID != 1 {
+		t.Errorf("Expected the entity match to rank first, but got %+v", resp.Results)
+	}
+	if after := score("iphone pie", 1); after <= before {
+		t.Errorf("Expected the entity to raise the score from %v, but got %v", before, after)
+	}
+
+	// Test case: The terms of an entity phrase are boosted only when the phrase occurs
+	plain := score("cook tim", 2)
+	if boosted := score("tim cook", 2); boosted <= plain {
+		t.Errorf("Expected the phrase to be boosted, but got %v and %v", boosted, plain)
+	}
+
+	// Test case: Invalid entities are rejected
+	if _, err := dictionary.SetEntities(map[string]float64{"x": -1}); err == nil {
+		t.Errorf("Expected an error for a negative boost")
+	}
+	if entities := dictionary.Entities(); len(entities) != 2 || entities["tim cook"] != 3 {
+		t.Errorf("Expected the entities to be unchanged, but got %v", entities)
+	}
+}
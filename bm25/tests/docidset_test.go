@@ -0,0 +1,94 @@
+package bm25_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// fakeRoaring mimics the ToArray method of a roaring bitmap.
+type fakeRoaring []uint32
+
+func (f fakeRoaring) ToArray() []uint32 {
+	return f
+}
+
+func TestBitmap(t *testing.T) {
+	bitmap := bm25.NewBitmap(10)
+
+	// Test case: Adding, removing and growing beyond the initial size
+	bitmap.Add(3)
+	bitmap.Add(130)
+	bitmap.Add(3)
+	bitmap.Add(7)
+	bitmap.Remove(7)
+	if !bitmap.Contains(3) || !bitmap.Contains(130) || bitmap.Contains(7) || bitmap.Contains(-1) {
+		t.Errorf("Unexpected bitmap contents %v", bitmap.AppendDocIDs(nil))
+	}
+	if bitmap.Len() != 2 {
+		t.Errorf("Expected 2 document IDs, but got %d", bitmap.Len())
+	}
+	if ids := bitmap.AppendDocIDs(nil); !reflect.DeepEqual(ids, []int{3, 130}) {
+		t.Errorf("Expected IDs [3 130], but got %v", ids)
+	}
+
+	// Test case: A nil bitmap is an empty set
+	var empty *bm25.Bitmap
+	empty.Remove(3)
+	if empty.Contains(3) || empty.Len() != 0 || len(empty.AppendDocIDs(nil)) != 0 {
+		t.Errorf("Expected a nil bitmap to be empty")
+	}
+}
+
+func TestGetBatchScoresSet(t *testing.T) {
+	corpus := []string{"a b c", "b c d", "c d e", "d e f", "e f g"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0.75, nil)
+	query := []string{"c", "e"}
+	all, _ := okapi.GetBatchScores(query, []int{0, 1, 2, 3, 4})
+
+	bitmap := bm25.NewBitmap(5)
+	bitmap.Add(4)
+	bitmap.Add(2)
+
+	tests := []struct {
+		name     string
+		set      bm25.DocIDSet
+		expected []int
+	}{
+		{"Slice with duplicates", bm25.DocIDs{3, 1, 3}, []int{1, 3}},
+		{"Range", bm25.DocIDRange{Start: 1, End: 4}, []int{1, 2, 3}},
+		{"Bitmap", bitmap, []int{2, 4}},
+		{"Roaring bitmap", bm25.RoaringSet(fakeRoaring{0, 4}), []int{0, 4}},
+	}
+
+	for _, test := range tests {
+		// Test case: Scoring a set of documents
+		docIDs, scores, err := bm25.GetBatchScoresSet(okapi, query, test.set)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(docIDs, test.expected) {
+			t.Errorf("%s: expected IDs %v, but got %v", test.name, test.expected, docIDs)
+		}
+		for i, docID := range docIDs {
+			if scores[i] != all[docID] {
+				t.Errorf("%s: expected score %f for document %d, but got %f", test.name, all[docID], docID, scores[i])
+			}
+		}
+	}
+
+	// Test case: Invalid and empty sets
+	if _, _, err := bm25.GetBatchScoresSet(okapi, query, bm25.DocIDRange{Start: 3, End: 6}); !errors.Is(err, bm25.ErrInvalidDocID) {
+		t.Errorf("Expected ErrInvalidDocID, but got %v", err)
+	}
+	for _, set := range []bm25.DocIDSet{bm25.NewBitmap(5), (*bm25.Bitmap)(nil), bm25.DocIDRange{Start: 2, End: 2}, nil} {
+		if _, _, err := bm25.GetBatchScoresSet(okapi, query, set); !errors.Is(err, bm25.ErrEmptyDocIDs) {
+			t.Errorf("Expected ErrEmptyDocIDs for %#v, but got %v", set, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestDocStore(t *testing.T) {
+	originals := []string{"Hello, World!", "This is a test.", "Hello there, World."}
+	corpus := []string{"hello world", "this is a test", "hello there world"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0.75, nil)
+	ctx := context.Background()
+
+	// Test case: Without a store, results hold the joined tokens
+	topN, _ := okapi.GetTopN([]string{"hello"}, 1)
+	if !reflect.DeepEqual(topN, []string{"hello world"}) {
+		t.Errorf("Expected the joined tokens, but got %q", topN)
+	}
+
+	// Test case: Only the returned documents are fetched from the store
+	var fetched []int
+	store := bm25.DocStoreFunc(func(docID int) (string, error) {
+		fetched = append(fetched, docID)
+		return originals[docID], nil
+	})
+	if err := okapi.SetDocStore(store); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	topN, err := okapi.GetTopN([]string{"hello"}, 1)
+	if err != nil || !reflect.DeepEqual(topN, []string{"Hello, World!"}) {
+		t.Errorf("Expected the original text, but got %q (error: %v)", topN, err)
+	}
+	resp, err := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"test"}, N: 1})
+	if err != nil || resp.Results[0].Doc != "This is a test." {
+		t.Errorf("Expected the original text, but got %+v (error: %v)", resp, err)
+	}
+	if !reflect.DeepEqual(fetched, []int{0, 1}) {
+		t.Errorf("Expected documents 0 and 1 to be fetched, but got %v", fetched)
+	}
+
+	// Test case: Store errors are returned
+	errUnavailable := errors.New("store unavailable")
+	_ = okapi.SetDocStore(bm25.DocStoreFunc(func(int) (string, error) { return "", errUnavailable }))
+	if _, err := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"test"}, N: 1}); !errors.Is(err, errUnavailable) {
+		t.Errorf("Expected the store error, but got %v", err)
+	}
+
+	// Test case: Frozen indexes keep their store but cannot change it
+	frozen := okapi.Freeze()
+	if frozen.DocStore() == nil {
+		t.Errorf("Expected the frozen index to keep its store")
+	}
+	if err := frozen.SetDocStore(nil); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestRangeFilters(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	docs := []bm25.Document{
+		{Text: "go release notes", Metadata: map[string]any{"stars": 10, "published": day(1)}},
+		{Text: "go tutorial", Metadata: map[string]any{"stars": 2.5, "published": day(5)}},
+		{Text: "go generics", Metadata: map[string]any{"stars": int64(7), "published": "2024-01-10T00:00:00Z"}},
+		{Text: "go modules"},
+	}
+	builder, _ := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	for _, doc := range docs {
+		builder.Add(doc)
+	}
+	base, _ := builder.Build()
+	okapi, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+
+	search := func(ranges ...bm25.RangeFilter) ([]int, error) {
+		resp, err := okapi.Search(context.Background(), bm25.SearchRequest{Query: []string{"go"}, N: 10, Ranges: ranges})
+		if err != nil {
+			return nil, err
+		}
+		docIDs := []int{}
+		for _, result := range resp.Results {
+			docIDs = append(docIDs, result.DocID)
+		}
+		return docIDs, nil
+	}
+
+	// Test case: Filtering on a numeric range with mixed numeric types
+	docIDs, err := search(bm25.RangeFilter{Field: "stars", Min: 5})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sort.Ints(docIDs)
+	if !reflect.DeepEqual(docIDs, []int{0, 2}) {
+		t.Errorf("Expected documents [0 2], but got %v", docIDs)
+	}
+
+	// Test case: Exclusive bounds
+	docIDs, _ = search(bm25.RangeFilter{Field: "stars", Min: 2.5, Max: 10, ExclusiveMin: true, ExclusiveMax: true})
+	if !reflect.DeepEqual(docIDs, []int{2}) {
+		t.Errorf("Expected documents [2], but got %v", docIDs)
+	}
+
+	// Test case: Filtering on a date range, combined with a numeric range
+	docIDs, _ = search(
+		bm25.RangeFilter{Field: "published", Min: day(2), Max: "2024-01-10T00:00:00Z"},
+		bm25.RangeFilter{Field: "stars", Max: 5},
+	)
+	if !reflect.DeepEqual(docIDs, []int{1}) {
+		t.Errorf("Expected documents [1], but got %v", docIDs)
+	}
+
+	// Test case: Filtering on a field without values matches nothing
+	docIDs, _ = search(bm25.RangeFilter{Field: "missing", Min: 1})
+	if len(docIDs) != 0 {
+		t.Errorf("Expected no documents, but got %v", docIDs)
+	}
+
+	// Test case: A bound of the wrong type is rejected
+	_, err = search(bm25.RangeFilter{Field: "published", Min: 3})
+	var paramErr *bm25.ErrInvalidParam
+	if !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam, but got %v", err)
+	}
+
+	// Test case: Indexing a field with values of mixed types
+	builder, _ = bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	builder.Add(bm25.Document{Text: "a", Metadata: map[string]any{"v": 1}})
+	builder.Add(bm25.Document{Text: "b", Metadata: map[string]any{"v": day(1)}})
+	base, _ = builder.Build()
+	if err := base.IndexDocValues("v"); !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam for mixed types, but got %v", err)
+	}
+}
+
+func TestSortByMetadata(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	docs := []bm25.Document{
+		{Text: "go go release", Metadata: map[string]any{"published": day(1)}},
+		{Text: "go tutorial", Metadata: map[string]any{"published": day(5)}},
+		{Text: "go generics", Metadata: map[string]any{"published": day(3)}},
+		{Text: "go modules"},
+		{Text: "rust async", Metadata: map[string]any{"published": day(9)}},
+	}
+	builder, _ := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	for _, doc := range docs {
+		builder.Add(doc)
+	}
+	base, _ := builder.Build()
+	okapi, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+
+	search := func(keys ...bm25.SortField) []int {
+		resp, err := okapi.Search(context.Background(), bm25.SearchRequest{Query: []string{"go"}, N: 10, Sort: keys})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		docIDs := []int{}
+		for _, result := range resp.Results {
+			docIDs = append(docIDs, result.DocID)
+		}
+		return docIDs
+	}
+
+	// Test case: Sorting matching documents by date only, newest first
+	docIDs := search(bm25.SortField{Field: "published", Descending: true})
+	if !reflect.DeepEqual(docIDs, []int{1, 2, 0, 3}) {
+		t.Errorf("Expected documents [1 2 0 3], but got %v", docIDs)
+	}
+
+	// Test case: Documents are matched by the presence of the terms, not by their score,
+	// as BM25Plus scores documents without the terms too
+	plus, _ := bm25.NewBM25PlusFromBase(base, 1.5, 0.75, 1, 0.25)
+	resp, err := plus.Search(context.Background(), bm25.SearchRequest{Query: []string{"go"}, N: 10, Sort: []bm25.SortField{{Field: "published"}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 4 {
+		t.Errorf("Expected the 4 documents containing the term, but got %v", resp.Results)
+	}
+
+	// Test case: Sorting by score, breaking ties by date
+	docIDs = search(bm25.SortField{Field: bm25.ScoreField, Descending: true}, bm25.SortField{Field: "published"})
+	if docIDs[0] != 0 || len(docIDs) != 5 {
+		t.Errorf("Expected document 0 first among 5 results, but got %v", docIDs)
+	}
+	if !reflect.DeepEqual(docIDs[1:4], []int{2, 1, 3}) {
+		t.Errorf("Expected the tied documents in date order [2 1 3], but got %v", docIDs[1:4])
+	}
+}
@@ -0,0 +1,56 @@
+package bm25_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestDPHGetScores(t *testing.T) {
+	corpus := []string{
+		"divergence from randomness divergence",
+		"randomness in games",
+		"probabilistic models of retrieval",
+		"divergence",
+	}
+	dph, _ := bm25.NewDPH(corpus, strings.Fields, nil)
+
+	// Test case: Scores follow the DPH formula
+	scores, err := dph.GetScores([]string{"divergence"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	avgDocLen := 12.0 / 4
+	f := 2.0 / 4
+	norm := (1 - f) * (1 - f) / 3
+	expected := norm * (2*math.Log2(2*avgDocLen/4*4/3) + 0.5*math.Log2(2*math.Pi*2*(1-f)))
+	if math.Abs(scores[0]-expected) > 1e-9 {
+		t.Errorf("Expected a score of %v, but got %v", expected, scores[0])
+	}
+
+	// Test case: A document made only of the term carries no information
+	if scores[3] != 0 {
+		t.Errorf("Expected a score of 0 for document 3, but got %v", scores[3])
+	}
+
+	// Test case: DPH has no parameters to override
+	if len(dph.Params()) != 0 || len(dph.ParamSpecs()) != 0 {
+		t.Errorf("Expected no parameters, but got %v", dph.Params())
+	}
+	_, err = dph.Search(context.Background(), bm25.SearchRequest{Query: []string{"divergence"}, N: 1, Params: map[string]float64{"k1": 1}})
+	if err == nil {
+		t.Errorf("Expected an error for an unknown parameter, but got nil")
+	}
+
+	// Test case: Searching ranks the repeated term first
+	resp, err := dph.Search(context.Background(), bm25.SearchRequest{Query: []string{"divergence"}, N: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Results[0].DocID != 0 {
+		t.Errorf("Expected document 0 to rank first, but got %d", resp.Results[0].DocID)
+	}
+}
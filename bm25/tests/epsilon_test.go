@@ -0,0 +1,57 @@
+package bm25_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestNewBM25OkapiWithEpsilon(t *testing.T) {
+	corpus := []string{"the cat sat", "the dog ran", "the bird"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+
+	// Test case: Creating a new BM25Okapi instance with negative epsilon
+	_, err := bm25.NewBM25OkapiWithEpsilon(corpus, tokenizer, 1.2, 0.75, -0.25, nil)
+	if err == nil {
+		t.Errorf("Expected an error for negative epsilon, but got nil")
+	}
+
+	okapi, err := bm25.NewBM25OkapiWithEpsilon(corpus, tokenizer, 1.2, 0.75, 0.25, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: A term present in all documents gets epsilon times the average IDF
+	rare := math.Log((3.0-1.0+0.5)/(1.0+0.5) + 1.0)
+	common := math.Log(0.5 / (3.0 + 0.5))
+	expected := 0.25 * (5*rare + common) / 6
+	idf, err := okapi.IDF("the")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if math.Abs(idf-expected) > 1e-12 {
+		t.Errorf("Expected IDF %.4f for the term 'the', but got %.4f", expected, idf)
+	}
+
+	// Test case: Other terms keep their IDF
+	idf, _ = okapi.IDF("cat")
+	if math.Abs(idf-rare) > 1e-12 {
+		t.Errorf("Expected IDF %.4f for the term 'cat', but got %.4f", rare, idf)
+	}
+
+	// Test case: Scores are never negative
+	scores, _ := okapi.GetScores([]string{"the"})
+	for i, score := range scores {
+		if score < 0 {
+			t.Errorf("Expected a non-negative score at index %d, but got %.2f", i, score)
+		}
+	}
+
+	// Test case: A frozen copy keeps the epsilon floor
+	idf, _ = okapi.Freeze().IDF("the")
+	if math.Abs(idf-expected) > 1e-12 {
+		t.Errorf("Expected IDF %.4f for the term 'the' on a frozen copy, but got %.4f", expected, idf)
+	}
+}
@@ -0,0 +1,65 @@
+package bm25_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	corpus := []string{"hello world", "this is a test"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+
+	// Test case: Matching construction errors
+	_, err := bm25.NewBM25Base([]string{}, tokenizer, nil)
+	if !errors.Is(err, bm25.ErrEmptyCorpus) {
+		t.Errorf("Expected ErrEmptyCorpus, but got %v", err)
+	}
+	_, err = bm25.NewBM25Base(corpus, nil, nil)
+	if !errors.Is(err, bm25.ErrNilTokenizer) {
+		t.Errorf("Expected ErrNilTokenizer, but got %v", err)
+	}
+	_, err = bm25.NewBM25Base(corpus, func(s string) []string { return nil }, nil)
+	if !errors.Is(err, bm25.ErrEmptyDocument) {
+		t.Errorf("Expected ErrEmptyDocument, but got %v", err)
+	}
+
+	// Test case: Matching invalid parameters
+	_, err = bm25.NewBM25Plus(corpus, tokenizer, 1.2, 1.5, 1.0, 0.25, nil)
+	var paramErr *bm25.ErrInvalidParam
+	if !errors.As(err, &paramErr) {
+		t.Fatalf("Expected ErrInvalidParam, but got %v", err)
+	}
+	if paramErr.Name != "b" || paramErr.Value != 1.5 {
+		t.Errorf("Expected parameter b with value 1.5, but got %s with value %v", paramErr.Name, paramErr.Value)
+	}
+
+	// Test case: Matching query errors
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0.75, nil)
+	_, err = okapi.GetScores([]string{})
+	if !errors.Is(err, bm25.ErrEmptyQuery) {
+		t.Errorf("Expected ErrEmptyQuery, but got %v", err)
+	}
+	_, err = okapi.GetBatchScores([]string{"hello"}, []int{})
+	if !errors.Is(err, bm25.ErrEmptyDocIDs) {
+		t.Errorf("Expected ErrEmptyDocIDs, but got %v", err)
+	}
+	_, err = okapi.GetBatchScores([]string{"hello"}, []int{0, 2})
+	if !errors.Is(err, bm25.ErrInvalidDocID) {
+		t.Errorf("Expected ErrInvalidDocID, but got %v", err)
+	}
+	_, err = okapi.GetBatchScoresBatched([]string{"hello"}, []int{-1}, okapi, 1)
+	if !errors.Is(err, bm25.ErrInvalidDocID) {
+		t.Errorf("Expected ErrInvalidDocID, but got %v", err)
+	}
+	_, err = okapi.IDF("")
+	if !errors.Is(err, bm25.ErrEmptyTerm) {
+		t.Errorf("Expected ErrEmptyTerm, but got %v", err)
+	}
+	err = okapi.Freeze().ExcludeStopwords([]string{"hello"})
+	if !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package bm25_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestHTMLExtractor(t *testing.T) {
+	input := `<html><head><style>body { color: red }</style><script>var x = "<b>";</script></head>
+<body><!-- nav --><h1>Hello&nbsp;World</h1><p>Tom &amp; Jerry<br/>cartoon</p></body></html>`
+
+	// Test case: Tags, comments, scripts and styles are removed and entities decoded
+	fields := strings.Fields(bm25.HTMLExtractor{}.Extract(input))
+	expected := []string{"Hello", "World", "Tom", "&", "Jerry", "cartoon"}
+	if strings.Join(fields, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %q, but got %q", expected, fields)
+	}
+}
+
+func TestMarkdownExtractor(t *testing.T) {
+	input := "# Getting **started**\n\n" +
+		"> Read the [guide](https://example.com/guide) first.\n\n" +
+		"- item `one`\n" +
+		"1. ![logo](logo.png)\n\n" +
+		"```go\nfmt.Println()\n```\n\n" +
+		"| a | b |\n|---|---|\n| c | d |\n\n" +
+		"---\n" +
+		"[ref]: https://example.com\n"
+
+	// Test case: Markdown syntax and link targets are dropped, text is kept
+	fields := strings.Fields(bm25.MarkdownExtractor{}.Extract(input))
+	expected := []string{"Getting", "started", "Read", "the", "guide", "first.", "item", "one", "logo", "fmt.Println()", "a", "b", "c", "d"}
+	if strings.Join(fields, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %q, but got %q", expected, fields)
+	}
+}
+
+func TestBuildWithExtractor(t *testing.T) {
+	corpus := []string{"<p>hello <b>world</b></p>", "<div class=\"test\">this is a test</div>"}
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+
+	// Test case: Markup is not indexed when building with an extractor
+	base, err := bm25.NewBM25BaseWithOptions(corpus, tokenizer, nil, bm25.BuildOptions{Extractor: bm25.HTMLExtractor{}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, term := range base.ExpandPrefix("") {
+		if strings.ContainsAny(term, "<>") {
+			t.Errorf("Expected no markup in the vocabulary, but got '%s'", term)
+		}
+	}
+	if base.DocLengths()[0] != 2 {
+		t.Errorf("Expected document length 2, but got %d", base.DocLengths()[0])
+	}
+
+	// Test case: The builder applies the extractor to added documents
+	builder, _ := bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{Extractor: bm25.ExtractorFunc(strings.ToLower)})
+	builder.Add(bm25.Document{Text: "Hello World"})
+	base, _ = builder.Build()
+	if terms := base.ExpandPrefix("h"); len(terms) != 1 || terms[0] != "hello" {
+		t.Errorf("Expected [hello], but got %v", terms)
+	}
+}
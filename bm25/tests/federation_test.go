@@ -0,0 +1,90 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestFederation(t *testing.T) {
+	news, _ := bm25.NewBM25Okapi([]string{"go release notes", "rust release", "weather report"}, strings.Fields, 1.5, 0.75, nil)
+	docs, _ := bm25.NewBM25Plus([]string{"go concurrency go channels", "go modules", "python packaging"}, strings.Fields, 1.5, 0.75, 1, 0.25, nil)
+	ctx := context.Background()
+
+	// Test case: FuseMax normalizes every source by its top score, and leaves out the
+	// documents without a score
+	federation, err := bm25.NewFederation([]bm25.FederatedSource{{Name: "news", Index: news}, {Name: "docs", Index: docs, Weight: 0.5}}, bm25.FederationOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, err := federation.Search(ctx, bm25.SearchRequest{Query: []string{"go"}, N: 5})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 4 {
+		t.Fatalf("Expected the 4 results with a score, but got %+v", resp.Results)
+	}
+	if top := resp.Results[0]; top.Score != 1 || top.Doc != "go release notes" || top.Hits[0].Source != "news" || top.Hits[0].Rank != 1 {
+		t.Errorf("Expected the top news result first with score 1, but got %+v", top)
+	}
+	if second := resp.Results[1]; second.Score != 0.5 || second.Hits[0].Source != "docs" || second.Doc != "go concurrency go channels" {
+		t.Errorf("Expected the top docs result second with score 0.5, but got %+v", second)
+	}
+
+	// Test case: FuseRRF scores the results by rank
+	federation, _ = bm25.NewFederation([]bm25.FederatedSource{{Name: "news", Index: news}, {Name: "docs", Index: docs}}, bm25.FederationOptions{Fusion: bm25.FuseRRF})
+	resp, _ = federation.Search(ctx, bm25.SearchRequest{Query: []string{"go"}, N: 4})
+	if len(resp.Results) != 4 || resp.Results[0].Score != 1.0/61 || resp.Results[1].Score != 1.0/61 || resp.Results[2].Score != 1.0/62 {
+		t.Errorf("Expected reciprocal rank scores, but got %+v", resp.Results)
+	}
+	if resp.Results[0].Hits[0].Source != "news" || resp.Results[1].Hits[0].Source != "docs" {
+		t.Errorf("Expected ties to be broken by source order, but got %+v", resp.Results)
+	}
+
+	// Test case: Results with the same external ID are merged
+	builder, _ := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	builder.Add(bm25.Document{ID: "a", Text: "go release notes"})
+	builder.Add(bm25.Document{ID: "b", Text: "go modules"})
+	base, _ := builder.Build()
+	okapi, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	plus, _ := bm25.NewBM25PlusFromBase(base, 1.5, 0.75, 1, 0.25)
+	federation, _ = bm25.NewFederation([]bm25.FederatedSource{{Name: "okapi", Index: okapi}, {Name: "plus", Index: plus}}, bm25.FederationOptions{Fusion: bm25.FuseRRF})
+	resp, _ = federation.Search(ctx, bm25.SearchRequest{Query: []string{"modules"}, N: 5})
+	if len(resp.Results) != 2 || resp.Results[0].ExternalID != "b" || len(resp.Results[0].Hits) != 2 || resp.Results[0].Score != 2.0/61 {
+		t.Errorf("Expected 2 merged results with 'b' first, but got %+v", resp.Results)
+	}
+
+	// Test case: The sort keys of the request do not change the ranking or the top score
+	builder, _ = bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	builder.Add(bm25.Document{ID: "a", Text: "go release notes", Metadata: map[string]any{"ts": 1}})
+	builder.Add(bm25.Document{ID: "b", Text: "go modules", Metadata: map[string]any{"ts": 2}})
+	builder.Add(bm25.Document{ID: "c", Text: "python packaging", Metadata: map[string]any{"ts": 3}})
+	builder.Add(bm25.Document{ID: "d", Text: "weather report", Metadata: map[string]any{"ts": 4}})
+	base, _ = builder.Build()
+	okapi, _ = bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	federation, _ = bm25.NewFederation([]bm25.FederatedSource{{Name: "okapi", Index: okapi}}, bm25.FederationOptions{})
+	resp, _ = federation.Search(ctx, bm25.SearchRequest{Query: []string{"go"}, N: 5, Sort: []bm25.SortField{{Field: "ts"}}})
+	if len(resp.Results) != 2 || resp.Results[0].ExternalID != "b" || resp.Results[0].Score != 1 || resp.Results[1].Score >= 1 {
+		t.Errorf("Expected 'b' first with score 1, but got %+v", resp.Results)
+	}
+
+	// Test case: Errors of a source fail the search
+	_, err = federation.Search(ctx, bm25.SearchRequest{N: 5})
+	if !errors.Is(err, bm25.ErrEmptyQuery) || !strings.Contains(err.Error(), `source "okapi"`) {
+		t.Errorf("Expected ErrEmptyQuery with the source name, but got %v", err)
+	}
+
+	// Test case: Invalid sources
+	if _, err := bm25.NewFederation(nil, bm25.FederationOptions{}); err == nil {
+		t.Errorf("Expected an error for no sources, but got nil")
+	}
+	if _, err := bm25.NewFederation([]bm25.FederatedSource{{Name: "a", Index: okapi}, {Name: "a", Index: plus}}, bm25.FederationOptions{}); err == nil {
+		t.Errorf("Expected an error for duplicate names, but got nil")
+	}
+	if _, err := bm25.NewFederation([]bm25.FederatedSource{{Name: "a"}}, bm25.FederationOptions{}); !errors.Is(err, bm25.ErrNilBase) {
+		t.Errorf("Expected ErrNilBase, but got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package bm25_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestIngestHooks(t *testing.T) {
+	base, _ := bm25.NewBM25Base([]string{"hello world"}, strings.Fields, nil)
+	seen := make(map[string]bool)
+	base.OnIngest(func(doc *bm25.Document) error {
+		if seen[doc.Text] {
+			return fmt.Errorf("%w: duplicate text", bm25.ErrDocumentRejected)
+		}
+		seen[doc.Text] = true
+		return nil
+	})
+	base.OnIngest(func(doc *bm25.Document) error {
+		doc.Text = strings.ReplaceAll(doc.Text, "secret@example.com", "[email]")
+		if doc.Metadata == nil {
+			doc.Metadata = make(map[string]any)
+		}
+		doc.Metadata["scrubbed"] = true
+		return nil
+	})
+	var events []bm25.IndexEvent
+	base.OnDocumentIndexed(func(event bm25.IndexEvent) { events = append(events, event) })
+
+	// Test case: Hooks transform and enrich a document before it is tokenized
+	metadata := map[string]any{"source": "mail"}
+	docID, err := base.AddDocument(bm25.Document{ID: "a", Text: "mail secret@example.com mail", Metadata: metadata})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if base.HasTerm(docID, "secret@example.com") {
+		t.Errorf("Expected the scrubbed term not to be indexed")
+	}
+	if !base.HasTerm(docID, "[email]") {
+		t.Errorf("Expected the replacement term to be indexed")
+	}
+	if _, ok := metadata["scrubbed"]; ok {
+		t.Errorf("Expected the metadata of the caller to be left unchanged, but got %v", metadata)
+	}
+
+	// Test case: Post-index hooks are notified with the document statistics
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 index event, but got %d", len(events))
+	}
+	if e := events[0]; e.DocID != docID || e.ID != "a" || e.Length != 3 || e.UniqueTerms != 2 || e.CorpusSize != 2 || e.AvgDocLen != 2.5 {
+		t.Errorf("Unexpected index event: %+v", e)
+	}
+
+	// Test case: A rejected document is not added
+	_, err = base.AddDocument(bm25.Document{ID: "b", Text: "mail secret@example.com mail"})
+	if !errors.Is(err, bm25.ErrDocumentRejected) || !strings.Contains(err.Error(), `document "b"`) {
+		t.Errorf("Expected the document to be rejected, but got %v", err)
+	}
+	if base.CorpusSize() != 2 || len(events) != 1 {
+		t.Errorf("Expected the corpus to be unchanged, but got size %d and %d events", base.CorpusSize(), len(events))
+	}
+
+	// Test case: Hooks also apply to bulk mutations, reporting rejections per item
+	result, err := base.BulkAdd([]bm25.Document{{ID: "c", Text: "new text"}, {ID: "d", Text: "new text"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Failed != 1 || !errors.Is(result.Items[1].Err, bm25.ErrDocumentRejected) || len(events) != 2 {
+		t.Errorf("Expected the duplicate to be rejected, but got %+v", result.Items)
+	}
+}
+
+func TestIngestHooksBuilder(t *testing.T) {
+	lower := func(doc *bm25.Document) error {
+		if doc.Text == "" {
+			return bm25.ErrDocumentRejected
+		}
+		doc.Text = strings.ToLower(doc.Text)
+		return nil
+	}
+	builder, err := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{IngestHooks: []bm25.IngestHook{lower}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: The hooks of the build options apply to the documents of a Builder
+	if _, err := builder.Add(bm25.Document{Text: "Hello World"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := builder.Add(bm25.Document{}); !errors.Is(err, bm25.ErrDocumentRejected) {
+		t.Errorf("Expected the document to be rejected, but got %v", err)
+	}
+	base, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !base.HasTerm(0, "hello") {
+		t.Errorf("Expected the lowercased text to be indexed")
+	}
+
+	// Test case: Clones keep the ingest hooks
+	clone := base.Clone()
+	if _, err := clone.AddDocument(bm25.Document{Text: "ANOTHER Doc"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !clone.HasTerm(1, "another") {
+		t.Errorf("Expected the hooks to apply to the clone")
+	}
+}
@@ -0,0 +1,103 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestKeywordFields(t *testing.T) {
+	builder, _ := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	docs := []bm25.Document{
+		{Text: "rust async runtime", Metadata: map[string]any{"tags": []string{"rust", "official"}, "status": "stable"}},
+		{Text: "python async library", Metadata: map[string]any{"tags": []any{"python"}, "status": "beta"}},
+		{Text: "go async patterns", Metadata: map[string]any{"tags": []string{"go", "official"}, "status": "stable"}},
+		{Text: "unrelated text", Metadata: map[string]any{"status": 3}},
+	}
+	for _, doc := range docs {
+		if _, err := builder.Add(doc); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	base, _ := builder.Build()
+	okapi, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	ctx := context.Background()
+
+	// Test case: Keywords do not count towards the document length
+	if length := base.DocLengths()[0]; length != 3 {
+		t.Errorf("Expected a document length of 3, but got %d", length)
+	}
+
+	// Test case: Filtering by exact keyword values
+	resp, err := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"async"}, N: 10, Keywords: []bm25.KeywordFilter{{Field: "tags", Values: []string{"official", "python"}}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 3 {
+		t.Errorf("Expected 3 results, but got %d", len(resp.Results))
+	}
+	resp, _ = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"async"}, N: 10, Keywords: []bm25.KeywordFilter{
+		{Field: "tags", Values: []string{"official"}},
+		{Field: "tags", Values: []string{"go"}},
+	}})
+	if len(resp.Results) != 1 || resp.Results[0].DocID != 2 {
+		t.Errorf("Expected only document 2, but got %+v", resp.Results)
+	}
+
+	// Test case: Keyword matches are exact
+	resp, _ = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"async"}, N: 10, Keywords: []bm25.KeywordFilter{{Field: "tags", Values: []string{"Official"}}}})
+	if len(resp.Results) != 0 {
+		t.Errorf("Expected no results, but got %d", len(resp.Results))
+	}
+
+	// Test case: Boosting a keyword value
+	resp, err = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"async"}, N: 4, Explain: true, KeywordBoosts: []bm25.KeywordBoost{{Field: "tags", Value: "python", Boost: 2}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scores, _ := okapi.GetScores([]string{"async"})
+	if resp.Results[0].DocID != 1 || math.Abs(resp.Results[0].Score-(scores[1]+2)) > 1e-12 {
+		t.Errorf("Expected document 1 first with a boosted score, but got %+v", resp.Results[0])
+	}
+	if keywords := resp.Results[0].Explanation.Keywords; len(keywords) != 1 || keywords[0].Term != "tags:python" || keywords[0].Score != 2 {
+		t.Errorf("Expected the boost in the explanation, but got %+v", keywords)
+	}
+
+	// Test case: A positive boost makes a document without query terms a match, also when
+	// matches are decided by term presence
+	for _, limits := range []bm25.SearchLimits{{}, {MaxScoredDocs: 10}} {
+		resp, err = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"runtime"}, N: 4, Limits: limits, KeywordBoosts: []bm25.KeywordBoost{{Field: "tags", Value: "go", Boost: 5}}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(resp.Results) == 0 || resp.Results[0].DocID != 2 || resp.Results[0].Score != 5 {
+			t.Errorf("Expected the boosted document 2 first with limits %+v, but got %+v", limits, resp.Results)
+		}
+	}
+
+	// Test case: Fields with values other than strings
+	_, err = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"async"}, N: 4, Keywords: []bm25.KeywordFilter{{Field: "status", Values: []string{"stable"}}}})
+	var paramErr *bm25.ErrInvalidParam
+	if !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam, but got %v", err)
+	}
+
+	// Test case: A boost that is not finite
+	_, err = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"async"}, N: 4, KeywordBoosts: []bm25.KeywordBoost{{Field: "tags", Value: "go", Boost: math.Inf(1)}}})
+	if !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam, but got %v", err)
+	}
+
+	// Test case: Indexing keywords upfront for frozen copies
+	if err := base.IndexKeywords("tags"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, err = okapi.Freeze().Search(ctx, bm25.SearchRequest{Query: []string{"async"}, N: 10, Keywords: []bm25.KeywordFilter{{Field: "tags", Values: []string{"rust"}}}})
+	if err != nil || len(resp.Results) != 1 {
+		t.Errorf("Expected 1 result, but got %v, %v", resp, err)
+	}
+}
@@ -0,0 +1,89 @@
+package bm25_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestDefaultLanguageDetector(t *testing.T) {
+	detector := bm25.DefaultLanguageDetector()
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"The quick brown fox jumps over the lazy dog while the children are playing outside", "en"},
+		{"Der schnelle braune Fuchs springt über den faulen Hund, während die Kinder draußen spielen", "de"},
+		{"Le renard brun rapide saute par-dessus le chien paresseux pendant que les enfants jouent dehors", "fr"},
+		{"El rápido zorro marrón salta sobre el perro perezoso mientras los niños juegan afuera", "es"},
+		{"how to configure the database connection", "en"},
+		{"wie konfiguriere ich die Datenbankverbindung", "de"},
+		{"comment configurer la connexion à la base de données", "fr"},
+		{"cómo configurar la conexión a la base de datos", "es"},
+	}
+
+	for _, test := range tests {
+		// Test case: Detecting the language of sentences and longer queries
+		if language := detector.Detect(test.text); language != test.expected {
+			t.Errorf("Expected %q for %q, but got %q", test.expected, test.text, language)
+		}
+	}
+
+	// Test case: Texts that are too short are not detected
+	if language := detector.Detect("go 123"); language != "" {
+		t.Errorf("Expected no language, but got %q", language)
+	}
+}
+
+func TestNewNgramDetector(t *testing.T) {
+	// Test case: Training without samples
+	if _, err := bm25.NewNgramDetector(nil, 0); !errors.Is(err, bm25.ErrNoSamples) {
+		t.Errorf("Expected ErrNoSamples, but got %v", err)
+	}
+
+	// Test case: Training on custom samples
+	detector, err := bm25.NewNgramDetector(map[string]string{"a": "aaa aab aba", "b": "bbb bba bab"}, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if language := detector.Detect("abba bbab"); language != "b" {
+		t.Errorf("Expected %q, but got %q", "b", language)
+	}
+}
+
+func TestLanguageDetection(t *testing.T) {
+	en, _ := bm25.LanguageAnalyzer("en")
+	de, _ := bm25.LanguageAnalyzer("de")
+	builder, err := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{
+		LanguageAnalyzers: map[string]bm25.Analyzer{"en": en, "de": de},
+		LanguageDetector:  bm25.DefaultLanguageDetector(),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	builder.Add(bm25.Document{Text: "The houses of the city are old"})
+	builder.Add(bm25.Document{Text: "Die Häuser der Stadt sind alt"})
+	base, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Untagged documents are routed to the analyzer of the detected language
+	if lengths := base.DocLengths(); !reflect.DeepEqual(lengths, []int{3, 3}) {
+		t.Errorf("Expected document lengths [3 3], but got %v", lengths)
+	}
+	if tokens := base.Analyze("die alten Häuser", ""); !reflect.DeepEqual(tokens, []string{"alt", "haus"}) {
+		t.Errorf("Expected the query to be analyzed as German, but got %v", tokens)
+	}
+
+	// Test case: Disabling detection
+	if err := base.SetLanguageDetector(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tokens := base.Analyze("die alten Häuser", ""); len(tokens) != 3 {
+		t.Errorf("Expected the index tokenizer to be used, but got %v", tokens)
+	}
+}
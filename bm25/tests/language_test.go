@@ -0,0 +1,153 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestLanguageAnalyzer(t *testing.T) {
+	tests := []struct {
+		language string
+		text     string
+		expected []string
+	}{
+		{"en", "The Queries of the documents, classes and bus!", []string{"query", "document", "classe", "bus"}},
+		{"de", "Die Häuser mit den Gärten", []string{"haus", "gart"}},
+		{"fr", "Les chevaux dans les maisons", []string{"cheval", "maison"}},
+		{"es", "Las canciones de los niños", []string{"cancion", "niñ"}},
+	}
+
+	for _, test := range tests {
+		// Test case: Built-in analyzers remove stopwords and stem the tokens
+		analyzer, ok := bm25.LanguageAnalyzer(test.language)
+		if !ok {
+			t.Fatalf("Expected an analyzer for %q", test.language)
+		}
+		if tokens := analyzer.Analyze(test.text); !reflect.DeepEqual(tokens, test.expected) {
+			t.Errorf("Expected %v for %q, but got %v", test.expected, test.language, tokens)
+		}
+	}
+
+	// Test case: Unknown languages have no analyzer
+	if _, ok := bm25.LanguageAnalyzer("xx"); ok {
+		t.Errorf("Expected no analyzer for an unknown language")
+	}
+	if languages := bm25.LanguageAnalyzers(); !reflect.DeepEqual(languages, []string{"de", "en", "es", "fr"}) {
+		t.Errorf("Unexpected languages %v", languages)
+	}
+}
+
+func TestLanguageRouting(t *testing.T) {
+	en, _ := bm25.LanguageAnalyzer("en")
+	de, _ := bm25.LanguageAnalyzer("de")
+	analyzers := map[string]bm25.Analyzer{"en": en, "de": de}
+
+	// Test case: An analyzer without a tokenizer
+	_, err := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{LanguageAnalyzers: map[string]bm25.Analyzer{"en": {}}})
+	if !errors.Is(err, bm25.ErrNilTokenizer) {
+		t.Errorf("Expected ErrNilTokenizer, but got %v", err)
+	}
+
+	builder, err := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{LanguageAnalyzers: analyzers})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	docs := []bm25.Document{
+		{Text: "The houses", Language: "en"},
+		{Text: "Die Häuser", Language: "de"},
+		{Text: "The houses"},
+	}
+	for _, doc := range docs {
+		if _, err := builder.Add(doc); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	base, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Documents are routed to the analyzer of their language
+	if lengths := base.DocLengths(); !reflect.DeepEqual(lengths, []int{1, 1, 2}) {
+		t.Errorf("Expected document lengths [1 1 2], but got %v", lengths)
+	}
+	okapi, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	scores, _ := okapi.GetScores(base.Analyze("Häusern", "de"))
+	if scores[1] <= 0 || scores[0] != 0 || scores[2] != 0 {
+		t.Errorf("Expected only the German document to match, but got %v", scores)
+	}
+	scores, _ = okapi.GetScores(base.Analyze("house", "en"))
+	if scores[0] <= 0 || scores[1] != 0 {
+		t.Errorf("Expected the English document to match, but got %v", scores)
+	}
+
+	// Test case: Documents added later are routed too
+	docID, err := base.AddDocument(bm25.Document{Text: "Das Haus", Language: "de"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if length := base.DocLengths()[docID]; length != 1 {
+		t.Errorf("Expected a document length of 1, but got %d", length)
+	}
+
+	// Test case: Removing the routing
+	if err := base.SetLanguageAnalyzers(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tokens := base.Analyze("Die Häuser", "de"); !reflect.DeepEqual(tokens, []string{"Die", "Häuser"}) {
+		t.Errorf("Expected the index tokenizer to be used, but got %v", tokens)
+	}
+	if err := base.Freeze().SetLanguageAnalyzers(analyzers); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+}
+
+func TestSearchAnalyzerOverride(t *testing.T) {
+	en, _ := bm25.LanguageAnalyzer("en")
+	builder, _ := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{LanguageAnalyzers: map[string]bm25.Analyzer{"en": en}})
+	builder.Add(bm25.Document{Text: "The tasks are queued", Language: "en"})
+	builder.Add(bm25.Document{Text: "A single task", Language: "en"})
+	builder.Add(bm25.Document{Text: "Unrelated news", Language: "en"})
+	base, _ := builder.Build()
+	okapi, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	ctx := context.Background()
+
+	// Test case: The query text is analyzed like the documents of its language
+	resp, err := okapi.Search(ctx, bm25.SearchRequest{Text: "Tasks", Language: "en", N: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 3 || resp.Results[2].Score != 0 || resp.Results[1].Score == 0 {
+		t.Errorf("Expected two matching documents, but got %+v", resp.Results)
+	}
+
+	// Test case: Overriding the analyzer reports the terms missing from the vocabulary
+	exact := &bm25.Analyzer{Tokenizer: strings.Fields, Filters: []bm25.TokenFilter{bm25.LowercaseFilter}}
+	resp, err = okapi.Search(ctx, bm25.SearchRequest{Text: "single Tasks", Analyzer: exact, N: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(resp.UnknownTerms, []string{"tasks"}) {
+		t.Errorf("Expected the unknown term 'tasks', but got %v", resp.UnknownTerms)
+	}
+	if resp.Results[0].DocID != 1 {
+		t.Errorf("Expected document 1 to match 'single', but got %+v", resp.Results[0])
+	}
+
+	// Test case: An analyzer without a tokenizer
+	_, err = okapi.Search(ctx, bm25.SearchRequest{Text: "tasks", Analyzer: &bm25.Analyzer{}, N: 3})
+	if !errors.Is(err, bm25.ErrNilTokenizer) {
+		t.Errorf("Expected ErrNilTokenizer, but got %v", err)
+	}
+
+	// Test case: A text without tokens
+	_, err = okapi.Search(ctx, bm25.SearchRequest{Text: "the", Language: "en", N: 3})
+	if !errors.Is(err, bm25.ErrEmptyQuery) {
+		t.Errorf("Expected ErrEmptyQuery, but got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package bm25_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestNewLGD(t *testing.T) {
+	corpus := []string{"hello world", "this is a test"}
+
+	// Test case: Creating a new LGD instance with negative c
+	if _, err := bm25.NewLGD(corpus, strings.Fields, -1, nil); err == nil {
+		t.Errorf("Expected an error for negative c, but got nil")
+	}
+
+	// Test case: Creating a new LGD instance with valid inputs
+	if _, err := bm25.NewLGD(corpus, strings.Fields, 1, nil); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestLGDGetScores(t *testing.T) {
+	corpus := []string{"hello world", "hello hello there", "this is a test"}
+	lgd, _ := bm25.NewLGD(corpus, strings.Fields, 1, nil)
+
+	// Test case: Getting scores for an empty query
+	if _, err := lgd.GetScores(nil); err == nil {
+		t.Errorf("Expected an error for an empty query, but got nil")
+	}
+
+	// Test case: Scores follow the log-logistic model
+	scores, err := lgd.GetScores([]string{"hello"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	avgDocLen := 3.0
+	lambda := 2.0 / 3
+	for i, tf := range []float64{1, 2} {
+		docLen := float64(len(strings.Fields(corpus[i])))
+		tfn := tf * math.Log(1+avgDocLen/docLen)
+		if expected := math.Log((tfn + lambda) / lambda); math.Abs(scores[i]-expected) > 1e-9 {
+			t.Errorf("Expected score %v at index %d, but got %v", expected, i, scores[i])
+		}
+	}
+	if scores[2] != 0 {
+		t.Errorf("Expected a score of 0 for a document without the term, but got %v", scores[2])
+	}
+
+	// Test case: Rare terms score higher than common ones
+	rare, _ := lgd.GetScores([]string{"world"})
+	if rare[0] <= scores[0] {
+		t.Errorf("Expected the rare term to score higher, but got %v and %v", rare[0], scores[0])
+	}
+
+	// Test case: Batch scores match the scores of the whole corpus
+	batch, err := lgd.GetBatchScores([]string{"hello"}, []int{1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch[0] != scores[1] {
+		t.Errorf("Expected a batch score of %v, but got %v", scores[1], batch[0])
+	}
+
+	// Test case: Parameters can be overridden per search
+	resp, err := lgd.Search(context.Background(), bm25.SearchRequest{Query: []string{"hello"}, N: 2, Params: map[string]float64{"c": 4}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Results[0].DocID != 1 {
+		t.Errorf("Expected document 1 to rank first, but got %d", resp.Results[0].DocID)
+	}
+}
@@ -0,0 +1,62 @@
+package bm25_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestLogOptions(t *testing.T) {
+	var buf bytes.Buffer
+	corpus := []string{"hello world", "this is a test", "hello there world"}
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.2, 0.75, log.New(&buf, "", 0))
+	ctx := context.Background()
+	search := func() {
+		t.Helper()
+		buf.Reset()
+		if _, err := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"hello", "world", "test", "there"}, N: 2}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// Test case: Searches are not logged by default
+	search()
+	if buf.Len() != 0 {
+		t.Errorf("Expected no log lines, but got:\n%s", buf.String())
+	}
+
+	// Test case: LogQueries logs a single summary line per search
+	if err := okapi.SetLogOptions(bm25.LogOptions{Level: bm25.LogQueries}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	search()
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 1 || !strings.HasPrefix(lines[0], "Query 'hello world test there' took") {
+		t.Errorf("Expected a single summary line, but got:\n%s", buf.String())
+	}
+
+	// Test case: LogDebug logs every IDF, sampled
+	okapi, _ = bm25.NewBM25Okapi(corpus, strings.Fields, 1.2, 0.75, log.New(&buf, "", 0))
+	okapi.SetLogOptions(bm25.LogOptions{Level: bm25.LogDebug})
+	search()
+	if n := strings.Count(buf.String(), "IDF for term"); n != 4 {
+		t.Errorf("Expected 4 IDF lines, but got:\n%s", buf.String())
+	}
+	okapi, _ = bm25.NewBM25Okapi(corpus, strings.Fields, 1.2, 0.75, log.New(&buf, "", 0))
+	okapi.SetLogOptions(bm25.LogOptions{Level: bm25.LogDebug, SampleEvery: 2})
+	search()
+	if n := strings.Count(buf.String(), "IDF for term"); n != 2 {
+		t.Errorf("Expected 2 sampled IDF lines, but got:\n%s", buf.String())
+	}
+
+	// Test case: Invalid options
+	if err := okapi.SetLogOptions(bm25.LogOptions{Level: bm25.LogDebug + 1}); err == nil {
+		t.Errorf("Expected an error for an unknown level, but got nil")
+	}
+	if err := okapi.SetLogOptions(bm25.LogOptions{SampleEvery: -1}); err == nil {
+		t.Errorf("Expected an error for a negative sampling, but got nil")
+	}
+}
@@ -0,0 +1,45 @@
+package bm25_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestMemStats(t *testing.T) {
+	corpus := []string{"hello world", "this is a test", "hello there"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	base, _ := bm25.NewBM25Base(corpus, tokenizer, nil)
+
+	// Test case: Checking the counts
+	stats := base.MemStats()
+	if stats.Docs != 3 {
+		t.Errorf("Expected 3 documents, but got %d", stats.Docs)
+	}
+	if stats.Terms != 7 {
+		t.Errorf("Expected 7 terms, but got %d", stats.Terms)
+	}
+	if stats.Postings != 8 {
+		t.Errorf("Expected 8 postings, but got %d", stats.Postings)
+	}
+	if stats.TotalBytes != stats.PostingsBytes+stats.VocabularyBytes+stats.DocStorageBytes+stats.CacheBytes {
+		t.Errorf("Expected the total to be the sum of all sections, but got %d", stats.TotalBytes)
+	}
+
+	// Test case: Filling the IDF cache is accounted for
+	base.IDF("hello")
+	if base.MemStats().CacheBytes <= stats.CacheBytes {
+		t.Errorf("Expected the cache to grow after computing an IDF, but got %d bytes", base.MemStats().CacheBytes)
+	}
+
+	// Test case: Compacting the vocabulary reduces its estimated size
+	base.CompactVocabulary()
+	compacted := base.MemStats()
+	if compacted.Terms != stats.Terms || compacted.Postings != stats.Postings {
+		t.Errorf("Expected %d terms and %d postings, but got %d and %d", stats.Terms, stats.Postings, compacted.Terms, compacted.Postings)
+	}
+	if compacted.VocabularyBytes >= stats.VocabularyBytes {
+		t.Errorf("Expected fewer than %d vocabulary bytes, but got %d", stats.VocabularyBytes, compacted.VocabularyBytes)
+	}
+}
@@ -0,0 +1,78 @@
+package bm25_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestNegativeTerms(t *testing.T) {
+	corpus := []string{
+		"jaguar car dealer with the new jaguar models",
+		"jaguar habitat in the rainforest",
+		"the car wash",
+	}
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.5, 0.75, nil)
+	ctx := context.Background()
+	boost := func(value float64) *float64 { return &value }
+
+	plain, err := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"jaguar"}, N: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if plain.Results[0].DocID != 0 {
+		t.Fatalf("Expected document 0 to rank first without negative terms, but got %+v", plain.Results)
+	}
+
+	// Test case: Documents with a negative term are demoted, not excluded
+	resp, err := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"jaguar"}, N: 3, NegativeTerms: []string{"car"}, NegativeBoost: boost(0.1), Explain: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Results[0].DocID != 1 || resp.Results[1].DocID != 0 || resp.Results[1].Score <= 0 {
+		t.Errorf("Expected document 0 to be demoted below document 1, but got %+v", resp.Results)
+	}
+	if e := resp.Results[1].Explanation; e.Demotion == nil || *e.Demotion != 0.1 || resp.Results[0].Explanation.Demotion != nil {
+		t.Errorf("Expected the demotion in the explanation, but got %+v", e)
+	}
+
+	// Test case: The default boost halves the score
+	resp, _ = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"jaguar"}, N: 3, NegativeTerms: []string{"car"}})
+	for _, result := range resp.Results {
+		if result.DocID == 0 && result.Score != plain.Results[0].Score*0.5 {
+			t.Errorf("Expected the score to be halved, but got %v", result.Score)
+		}
+	}
+
+	// Test case: A boost of 0 demotes to a score of 0
+	resp, _ = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"jaguar"}, N: 3, NegativeTerms: []string{"car"}, NegativeBoost: boost(0)})
+	for _, result := range resp.Results {
+		if result.DocID == 0 && result.Score != 0 {
+			t.Errorf("Expected a score of 0, but got %v", result.Score)
+		}
+	}
+
+	// Test case: Documents with negative scores are demoted too, and only the documents
+	// containing a query term are, as BM25Plus scores documents without the terms too
+	negative, _ := okapi.GetScores([]string{"the"})
+	resp, _ = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"the"}, N: 3, NegativeTerms: []string{"wash"}})
+	for _, result := range resp.Results {
+		if result.DocID == 2 && (negative[2] >= 0 || result.Score != negative[2]*1.5) {
+			t.Errorf("Expected the negative score %v to be lowered by half, but got %v", negative[2], result.Score)
+		}
+	}
+	plus, _ := bm25.NewBM25PlusFromBase(okapi.Bm25Base, 1.5, 0.75, 1, 0.25)
+	resp, _ = plus.Search(ctx, bm25.SearchRequest{Query: []string{"rainforest"}, N: 3, NegativeTerms: []string{"car"}, Explain: true})
+	for _, result := range resp.Results {
+		if result.DocID != 1 && result.Explanation.Demotion != nil {
+			t.Errorf("Expected document %d without the query terms not to be demoted", result.DocID)
+		}
+	}
+
+	// Test case: Boosts outside [0, 1) are rejected
+	if _, err := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"jaguar"}, N: 3, NegativeTerms: []string{"car"}, NegativeBoost: boost(1.5)}); err == nil {
+		t.Errorf("Expected an error for a negative boost above 1")
+	}
+}
@@ -0,0 +1,68 @@
+package bm25_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestOnlineIndex(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world", "this is a test"}, tokenizer, 1.5, 0.75, nil)
+
+	// Test case: Wrapping a frozen index
+	if _, err := bm25.NewOnlineIndex(okapi.Freeze()); err == nil {
+		t.Errorf("Expected an error for a frozen index, but got nil")
+	}
+
+	online, err := bm25.NewOnlineIndex(okapi)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Queries always use the current statistics
+	before, _ := online.IDF("hello")
+	online.Add(bm25.Document{Text: "hello again"})
+	after, _ := online.IDF("hello")
+	expected, _ := bm25.NewBM25Okapi([]string{"hello world", "this is a test", "hello again"}, tokenizer, 1.5, 0.75, nil)
+	expectedIDF, _ := expected.IDF("hello")
+	if after != expectedIDF || after == before {
+		t.Errorf("Expected the IDF to change from %.4f to %.4f, but got %.4f", before, expectedIDF, after)
+	}
+	if online.CorpusSize() != 3 || online.AvgDocLen() != expected.AvgDocLen() {
+		t.Errorf("Expected 3 documents of average length %.2f, but got %d of %.2f", expected.AvgDocLen(), online.CorpusSize(), online.AvgDocLen())
+	}
+
+	// Test case: Documents can be added while searches are running
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				online.Add(bm25.Document{Text: fmt.Sprintf("hello stream %d %d", w, i)})
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, err := online.Search(context.Background(), bm25.SearchRequest{Query: []string{"hello", "stream"}, N: 5}); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if online.CorpusSize() != 203 {
+		t.Errorf("Expected 203 documents, but got %d", online.CorpusSize())
+	}
+	scores, _ := online.GetScores([]string{"stream"})
+	if len(scores) != 203 || scores[0] != 0 || scores[202] <= 0 {
+		t.Errorf("Expected scores for all 203 documents, matching only the streamed ones")
+	}
+}
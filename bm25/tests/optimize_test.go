@@ -0,0 +1,175 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// waitForSegments polls the segment sizes of an index until they match the expected ones.
+func waitForSegments(t *testing.T, index *bm25.SegmentedIndex, expected []int, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !slices.Equal(index.SegmentSizes(), expected) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected segments %v, but got %v", expected, index.SegmentSizes())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSegmentedIndex(t *testing.T) {
+	corpus := []string{"hello world", "this is a test", "hello there", "another test"}
+	index, err := bm25.NewSegmentedIndex("okapi", strings.Fields, nil, bm25.MergePolicy{Interval: time.Hour}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer index.Close()
+
+	// Test case: Every batch is a segment, and document IDs continue across segments
+	if _, err := index.Add(bm25.Document{ID: "a", Text: corpus[0]}, bm25.Document{Text: corpus[1]}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	docIDs, err := index.Add(bm25.Document{ID: "c", Text: corpus[2]}, bm25.Document{Text: corpus[3]})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !slices.Equal(docIDs, []int{2, 3}) || !slices.Equal(index.SegmentSizes(), []int{2, 2}) {
+		t.Errorf("Expected documents [2 3] in segments [2 2], but got %v in %v", docIDs, index.SegmentSizes())
+	}
+
+	// Test case: Searches merge the results of all segments, and filters see the document IDs of the index
+	resp, err := index.Search(context.Background(), bm25.SearchRequest{Query: []string{"hello"}, N: 10, Filter: func(docID int) bool { return docID != 0 }})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 3 || resp.Results[0].DocID != 2 || resp.Results[0].Doc != corpus[2] {
+		t.Errorf("Expected document 2 first, without document 0, but got %v", resp.Results)
+	}
+
+	// Test case: Adding a document with an ID already in use in another segment
+	if _, err := index.Add(bm25.Document{ID: "a", Text: "hello again"}); !errors.Is(err, bm25.ErrDuplicateID) {
+		t.Errorf("Expected ErrDuplicateID, but got %v", err)
+	}
+
+	// Test case: Optimize merges down to one segment, which scores like a single index
+	if err := index.Optimize(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !slices.Equal(index.SegmentSizes(), []int{4}) {
+		t.Errorf("Expected a single segment of 4 documents, but got %v", index.SegmentSizes())
+	}
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.5, 0.75, nil)
+	scores, _ := okapi.GetScores([]string{"test"})
+	resp, err = index.Search(context.Background(), bm25.SearchRequest{Query: []string{"test"}, N: 10})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, result := range resp.Results {
+		if math.Abs(result.Score-scores[result.DocID]) > 1e-9 {
+			t.Errorf("Expected score %f for document %d, but got %f", scores[result.DocID], result.DocID, result.Score)
+		}
+	}
+
+	// Test case: Sorting by document values
+	if _, err := index.Search(context.Background(), bm25.SearchRequest{Query: []string{"test"}, N: 10, Sort: []bm25.SortField{{Field: "published"}}}); err == nil {
+		t.Errorf("Expected an error for sorting a segmented index, but got nil")
+	}
+
+	// Test case: Using the index after Close
+	index.Close()
+	if _, err := index.Add(bm25.Document{Text: "hello again"}); !errors.Is(err, bm25.ErrIndexClosed) {
+		t.Errorf("Expected ErrIndexClosed, but got %v", err)
+	}
+}
+
+func TestSegmentedIndexBackgroundMerges(t *testing.T) {
+	// Test case: Adjacent segments of the same tier are merged, tier by tier
+	index, err := bm25.NewSegmentedIndex("bm25plus", strings.Fields, nil, bm25.MergePolicy{SegmentsPerTier: 2, Interval: time.Millisecond}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer index.Close()
+	for _, text := range []string{"hello world", "this is a test", "hello there", "another test"} {
+		if _, err := index.Add(bm25.Document{Text: text}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	waitForSegments(t, index, []int{4}, 5*time.Second)
+
+	resp, err := index.Search(context.Background(), bm25.SearchRequest{Query: []string{"there"}, N: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].DocID != 2 {
+		t.Errorf("Expected document 2 to keep its ID, but got %v", resp.Results)
+	}
+
+	// Test case: Throttled merges reindex at most MaxMergeBytesPerSec bytes per second
+	index, err = bm25.NewSegmentedIndex("okapi", strings.Fields, nil, bm25.MergePolicy{SegmentsPerTier: 2, MaxMergeBytesPerSec: 1000, Interval: time.Millisecond}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer index.Close()
+	text := strings.Repeat("hello world ", 25)
+	start := time.Now()
+	for range 2 {
+		if _, err := index.Add(bm25.Document{Text: text}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	waitForSegments(t, index, []int{2}, 5*time.Second)
+	if took := time.Since(start); took < 500*time.Millisecond {
+		t.Errorf("Expected merging 600 bytes at 1000 bytes per second to take at least 500ms, but took %v", took)
+	}
+}
+
+func TestSegmentedIndexKeepsExpiry(t *testing.T) {
+	// Test case: Merging does not start the TTL of documents over
+	index, err := bm25.NewSegmentedIndex("okapi", strings.Fields, nil, bm25.MergePolicy{Interval: time.Hour}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer index.Close()
+	if _, err := index.Add(bm25.Document{Text: "hello world", TTL: 20 * time.Millisecond}, bm25.Document{Text: "this is a test"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := index.Add(bm25.Document{Text: "hello there"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if err := index.Optimize(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	resp, err := index.Search(context.Background(), bm25.SearchRequest{Query: []string{"hello"}, N: 10})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, result := range resp.Results {
+		if result.DocID == 0 && result.Score != 0 {
+			t.Errorf("Expected the expired document to stay expired, but got score %f", result.Score)
+		}
+	}
+}
+
+func TestNewSegmentedIndexInvalid(t *testing.T) {
+	// Test case: An unknown variant
+	if _, err := bm25.NewSegmentedIndex("bm99", strings.Fields, nil, bm25.MergePolicy{}, nil); !errors.Is(err, bm25.ErrUnknownVariant) {
+		t.Errorf("Expected ErrUnknownVariant, but got %v", err)
+	}
+
+	// Test case: An invalid merge policy
+	var paramErr *bm25.ErrInvalidParam
+	for _, policy := range []bm25.MergePolicy{{SegmentsPerTier: 1}, {MaxConcurrentMerges: -1}, {MaxMergeBytesPerSec: -1}, {Interval: -time.Second}} {
+		if _, err := bm25.NewSegmentedIndex("okapi", strings.Fields, nil, policy, nil); !errors.As(err, &paramErr) {
+			t.Errorf("Expected ErrInvalidParam for %+v, but got %v", policy, err)
+		}
+	}
+}
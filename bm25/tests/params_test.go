@@ -0,0 +1,150 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestParams(t *testing.T) {
+	corpus := []string{"hello world", "this is a test"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	plus, _ := bm25.NewBM25Plus(corpus, tokenizer, 1.2, 0.75, 1.0, 0.25, nil)
+
+	// Test case: Reading the parameters through the common interface
+	var index bm25.BM25 = plus
+	params := index.Params()
+	expected := map[string]float64{"k1": 1.2, "b": 0.75, "delta": 1.0, "epsilon": 0.25}
+	if len(params) != len(expected) {
+		t.Errorf("Expected %d parameters, but got %d", len(expected), len(params))
+	}
+	for name, value := range expected {
+		if params[name] != value {
+			t.Errorf("Expected parameter %s to be %.2f, but got %.2f", name, value, params[name])
+		}
+	}
+
+	// Test case: Every parameter has a spec
+	specs := index.ParamSpecs()
+	for _, spec := range specs {
+		if _, ok := params[spec.Name]; !ok {
+			t.Errorf("Expected a value for the parameter %s", spec.Name)
+		}
+		if spec.Description == "" {
+			t.Errorf("Expected a description for the parameter %s", spec.Name)
+		}
+		if err := spec.Validate(spec.Default); err != nil {
+			t.Errorf("Expected the default of %s to be valid, but got %v", spec.Name, err)
+		}
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	specs := bm25.BM25OkapiParamSpecs()
+
+	// Test case: Validating valid parameters
+	if err := bm25.ValidateParams(specs, map[string]float64{"k1": 2.0}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Test case: Validating out of range and unknown parameters
+	var paramErr *bm25.ErrInvalidParam
+	err := bm25.ValidateParams(specs, map[string]float64{"b": 1.5})
+	if !errors.As(err, &paramErr) || paramErr.Name != "b" {
+		t.Errorf("Expected an invalid parameter error for b, but got %v", err)
+	}
+	err = bm25.ValidateParams(specs, map[string]float64{"k1": math.NaN()})
+	if !errors.As(err, &paramErr) || paramErr.Name != "k1" {
+		t.Errorf("Expected an invalid parameter error for k1, but got %v", err)
+	}
+	err = bm25.ValidateParams(specs, map[string]float64{"delta": 1.0})
+	if !errors.As(err, &paramErr) || paramErr.Name != "delta" {
+		t.Errorf("Expected an invalid parameter error for delta, but got %v", err)
+	}
+
+	// Test case: Filling in defaults
+	params := bm25.ParamsWithDefaults(specs, map[string]float64{"k1": 2.0})
+	if params["k1"] != 2.0 || params["b"] != 0.75 {
+		t.Errorf("Expected k1 2.00 and b 0.75, but got %.2f and %.2f", params["k1"], params["b"])
+	}
+}
+
+func TestParamOverrides(t *testing.T) {
+	corpus := []string{
+		"the quick brown fox jumps over the lazy dog",
+		"the fox",
+		"a fox and another fox in a much longer document about foxes",
+	}
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.5, 0.75, nil)
+	tuned, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 0.9, 0.3, nil)
+	ctx := context.Background()
+	req := bm25.SearchRequest{Query: []string{"fox"}, N: 3}
+
+	// Test case: The overridden parameters score like an index built with them
+	overridden := req
+	overridden.Params = map[string]float64{"k1": 0.9, "b": 0.3}
+	got, err := okapi.Search(ctx, overridden)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want, err := tuned.Search(ctx, req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := range want.Results {
+		if got.Results[i].DocID != want.Results[i].DocID || math.Abs(got.Results[i].Score-want.Results[i].Score) > 1e-9 {
+			t.Errorf("Expected result %d to be %+v, but got %+v", i, want.Results[i], got.Results[i])
+		}
+	}
+
+	// Test case: The index keeps its parameters
+	if params := okapi.Params(); params["k1"] != 1.5 || params["b"] != 0.75 {
+		t.Errorf("Expected the parameters of the index to be unchanged, but got %v", params)
+	}
+
+	// Test case: Parameters that are not overridden keep the values of the index
+	partial := req
+	partial.Params = map[string]float64{"k1": 0.9}
+	got, err = okapi.Search(ctx, partial)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	kOnly, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 0.9, 0.75, nil)
+	want, _ = kOnly.Search(ctx, req)
+	if math.Abs(got.Results[0].Score-want.Results[0].Score) > 1e-9 {
+		t.Errorf("Expected a score of %v, but got %v", want.Results[0].Score, got.Results[0].Score)
+	}
+
+	// Test case: Variants with more parameters can override them too
+	plus, _ := bm25.NewBM25Plus(corpus, strings.Fields, 1.5, 0.75, 1.0, 0.25, nil)
+	plusTuned, _ := bm25.NewBM25Plus(corpus, strings.Fields, 1.5, 0.75, 0.5, 0.25, nil)
+	deltaReq := req
+	deltaReq.Params = map[string]float64{"delta": 0.5}
+	got, err = plus.Search(ctx, deltaReq)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want, _ = plusTuned.Search(ctx, req)
+	if math.Abs(got.Results[0].Score-want.Results[0].Score) > 1e-9 {
+		t.Errorf("Expected a score of %v, but got %v", want.Results[0].Score, got.Results[0].Score)
+	}
+
+	// Test case: Unknown parameters are rejected
+	unknown := req
+	unknown.Params = map[string]float64{"delta": 1}
+	var paramErr *bm25.ErrInvalidParam
+	if _, err := okapi.Search(ctx, unknown); !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam, but got %v", err)
+	}
+
+	// Test case: Out of range values are rejected
+	invalid := req
+	invalid.Params = map[string]float64{"b": 2}
+	if _, err := okapi.Search(ctx, invalid); !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam, but got %v", err)
+	}
+}
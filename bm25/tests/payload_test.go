@@ -0,0 +1,84 @@
+package bm25_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestPayloads(t *testing.T) {
+	base, err := bm25.NewBM25Base([]string{"unrelated filler text"}, strings.Fields, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	confident, err := base.AddDocument(bm25.Document{ID: "a", Tokens: []bm25.Token{{Term: "paris", Payload: 1}, {Term: "trip"}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	unsure, err := base.AddDocument(bm25.Document{ID: "b", Tokens: []bm25.Token{{Term: "paris", Payload: 0.25}, {Term: "trip"}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Payloads scale the contribution of their occurrences
+	okapi, err := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scores, err := okapi.GetScores([]string{"paris"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if scores[unsure] <= 0 || scores[unsure] >= scores[confident] {
+		t.Errorf("Expected the low-confidence occurrence to score lower, but got %v", scores)
+	}
+	if tripScores, _ := okapi.GetScores([]string{"trip"}); tripScores[confident] != tripScores[unsure] {
+		t.Errorf("Expected tokens without a payload to count fully, but got %v", tripScores)
+	}
+	if payloads := base.Payloads(unsure); len(payloads) != 2 || payloads[0] != 0.25 || payloads[1] != 1 {
+		t.Errorf("Expected the payloads of the document, but got %v", payloads)
+	}
+	if payloads := base.Payloads(confident); payloads != nil {
+		t.Errorf("Expected no payloads for a document whose tokens all count fully, but got %v", payloads)
+	}
+
+	// Test case: Invalid payloads are rejected
+	if _, err := base.AddDocument(bm25.Document{Tokens: []bm25.Token{{Term: "x", Payload: -1}}}); err == nil {
+		t.Errorf("Expected an error for a negative payload")
+	}
+
+	// Test case: Payloads survive snapshots and compaction
+	if err := base.DeleteDocument("a", 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := base.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	restored, err := bm25.ReadSnapshot(&buf, strings.Fields, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if removed, err := restored.Compact(); err != nil || removed != 1 {
+		t.Fatalf("Expected 1 removed document, but got %d, %v", removed, err)
+	}
+	docID, _ := restored.LookupID("b")
+	if payloads := restored.Payloads(docID); docID != 1 || len(payloads) != 2 || payloads[0] != 0.25 {
+		t.Errorf("Expected the payloads to be restored, but got %v", payloads)
+	}
+
+	// Test case: A Builder indexes the tokens of a document with their payloads
+	builder, _ := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{})
+	if _, err := builder.Add(bm25.Document{Text: "ignored", Tokens: []bm25.Token{{Term: "entity", Payload: 0.5}}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	built, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !built.HasTerm(0, "entity") || built.HasTerm(0, "ignored") || built.Payloads(0)[0] != 0.5 {
+		t.Errorf("Expected the tokens to be indexed instead of the text")
+	}
+}
@@ -0,0 +1,107 @@
+package bm25_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestNewPL2(t *testing.T) {
+	corpus := []string{"hello world", "this is a test"}
+
+	// Test case: Creating a new PL2 instance with negative c
+	if _, err := bm25.NewPL2(corpus, strings.Fields, -1, nil); err == nil {
+		t.Errorf("Expected an error for negative c, but got nil")
+	}
+
+	// Test case: Creating a new PL2 instance on a nil base
+	if _, err := bm25.NewPL2FromBase(nil, 1); err == nil {
+		t.Errorf("Expected an error for a nil base, but got nil")
+	}
+}
+
+func TestPL2GetScores(t *testing.T) {
+	corpus := []string{
+		"divergence from randomness divergence",
+		"randomness in games",
+		"probabilistic models of retrieval",
+		"language models",
+	}
+	pl2, _ := bm25.NewPL2(corpus, strings.Fields, 1, nil)
+
+	// Test case: Scores follow the PL2 formula
+	scores, err := pl2.GetScores([]string{"divergence"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	avgDocLen := 13.0 / 4
+	lambda := 2.0 / 4
+	tfn := 2 * math.Log2(1+avgDocLen/4)
+	expected := (tfn*math.Log2(tfn/lambda) + (lambda-tfn)/math.Ln2 + 0.5*math.Log2(2*math.Pi*tfn)) / (tfn + 1)
+	if math.Abs(scores[0]-expected) > 1e-9 {
+		t.Errorf("Expected a score of %v, but got %v", expected, scores[0])
+	}
+	for _, score := range scores[1:] {
+		if score != 0 {
+			t.Errorf("Expected a score of 0 for the documents without the term, but got %v", scores)
+		}
+	}
+
+	// Test case: Terms that are not in the corpus score 0
+	scores, err = pl2.GetScores([]string{"unknown"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if scores[0] != 0 {
+		t.Errorf("Expected a score of 0, but got %v", scores[0])
+	}
+
+	// Test case: Batch scores match the scores of the whole corpus
+	scores, _ = pl2.GetScores([]string{"randomness", "models"})
+	batch, err := pl2.GetBatchScores([]string{"randomness", "models"}, []int{1, 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch[0] != scores[1] || batch[1] != scores[3] {
+		t.Errorf("Expected batch scores %v, but got %v", []float64{scores[1], scores[3]}, batch)
+	}
+
+	// Test case: Searching ranks the repeated term first
+	resp, err := pl2.Search(context.Background(), bm25.SearchRequest{Query: []string{"divergence", "randomness"}, N: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Results[0].DocID != 0 {
+		t.Errorf("Expected document 0 to rank first, but got %d", resp.Results[0].DocID)
+	}
+}
+
+func TestPL2CollectionFrequencyCache(t *testing.T) {
+	corpus := []string{"hello world", "hello hello test", "another document"}
+	pl2, err := bm25.NewPL2(corpus, strings.Fields, 1, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := pl2.GetScores([]string{"hello"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Adding a document invalidates the cached collection frequencies
+	if _, err := pl2.AddDocument(bm25.Document{Text: "hello hello hello again"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected, _ := bm25.NewPL2(append(corpus, "hello hello hello again"), strings.Fields, 1, nil)
+	want, _ := expected.GetScores([]string{"hello"})
+	scores, err := pl2.GetScores([]string{"hello"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := range want {
+		if math.Abs(scores[i]-want[i]) > 1e-9 {
+			t.Errorf("Expected document %d to score %v, but got %v", i, want[i], scores[i])
+		}
+	}
+}
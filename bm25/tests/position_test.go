@@ -0,0 +1,82 @@
+package bm25_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestPositionBoost(t *testing.T) {
+	corpus := []string{
+		"kubernetes operators explained with examples for beginners",
+		"a long introduction to cloud computing that eventually covers kubernetes",
+		"nothing relevant here at all in this document",
+	}
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.5, 0.75, nil)
+	ctx := context.Background()
+	search := func(boost bm25.PositionBoost) []bm25.SearchResult {
+		resp, err := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"kubernetes"}, N: 3, PositionBoost: boost})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return resp.Results
+	}
+	plain := search(bm25.PositionBoost{})
+
+	// Test case: A match at the first token gets the full boost
+	boosted := search(bm25.PositionBoost{Boost: 1, Window: 10})
+	scores := make(map[int]float64)
+	for _, result := range boosted {
+		scores[result.DocID] = result.Score
+	}
+	plainScores := make(map[int]float64)
+	for _, result := range plain {
+		plainScores[result.DocID] = result.Score
+	}
+	if scores[0] != plainScores[0]*2 {
+		t.Errorf("Expected the score of document 0 to double, but got %v and %v", scores[0], plainScores[0])
+	}
+
+	// Test case: The boost decreases with the position, and ends with the window
+	if scores[1] != plainScores[1]*1.1 {
+		t.Errorf("Expected the match at position 9 to be boosted by 1.1, but got %v and %v", scores[1], plainScores[1])
+	}
+	if boosted := search(bm25.PositionBoost{Boost: 1, Window: 5}); boosted[1].Score != plainScores[boosted[1].DocID] {
+		t.Errorf("Expected no boost beyond the window, but got %+v", boosted)
+	}
+
+	// Test case: Negative term scores are raised rather than lowered
+	common, _ := bm25.NewBM25Okapi([]string{"the cat", "a dog and the cat", "one more the"}, strings.Fields, 1.5, 0.75, nil)
+	negative, _ := common.GetScores([]string{"the"})
+	resp, err := common.Search(ctx, bm25.SearchRequest{Query: []string{"the"}, N: 3, PositionBoost: bm25.PositionBoost{Boost: 0.5, Window: 10}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	raised := false
+	for _, result := range resp.Results {
+		raised = raised || result.DocID == 0 && result.Score == negative[0]/2
+	}
+	if negative[0] >= 0 || !raised {
+		t.Errorf("Expected the negative score %v of document 0 to be halved, but got %+v", negative[0], resp.Results)
+	}
+
+	// Test case: Field-scoped terms of BM25F are located within their field
+	f, _ := bm25.NewBM25F([]map[string]string{
+		{"title": "kubernetes guide", "body": "operators"},
+		{"title": "cloud", "body": "kubernetes operators"},
+	}, strings.Fields, map[string]float64{"title": 1, "body": 1}, 1.2, 0.75, nil)
+	for _, query := range []string{"title:kubernetes", "body:kubernetes"} {
+		plain, _ := f.Search(ctx, bm25.SearchRequest{Query: []string{query}, N: 1})
+		boosted, _ := f.Search(ctx, bm25.SearchRequest{Query: []string{query}, N: 1, PositionBoost: bm25.PositionBoost{Boost: 1, Window: 10}})
+		if len(plain.Results) != 1 || len(boosted.Results) != 1 || boosted.Results[0].Score != plain.Results[0].Score*2 {
+			t.Errorf("Expected the first token of the field to double the score of %s, but got %+v and %+v", query, plain.Results, boosted.Results)
+		}
+	}
+
+	// Test case: Invalid boosts are rejected
+	if _, err := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"kubernetes"}, N: 3, PositionBoost: bm25.PositionBoost{Boost: -1}}); err == nil {
+		t.Errorf("Expected an error for a negative position boost")
+	}
+}
@@ -0,0 +1,93 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestQueryHooks(t *testing.T) {
+	corpus := []string{
+		"how to configure the kubernetes scheduler",
+		"kubernetes login page",
+		"a guide to cooking pasta",
+	}
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.5, 0.75, nil)
+	okapi.OnQuery(bm25.StripTermsHook("how", "to", "find"))
+	okapi.OnQuery(bm25.IntentHook("informational", "configure", "guide"))
+	okapi.OnQuery(bm25.IntentHook("navigational", "login", "homepage"))
+	ctx := context.Background()
+
+	// Test case: Filler words are stripped and the rewrite is recorded
+	query := []string{"how", "to", "configure", "kubernetes"}
+	resp, err := okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Rewrite == nil || !slices.Equal(resp.Rewrite.Query, []string{"configure", "kubernetes"}) || !slices.Equal(resp.Rewrite.Original, query) {
+		t.Errorf("Expected the filler words to be stripped, but got %+v", resp.Rewrite)
+	}
+	if resp.Intent != "informational" {
+		t.Errorf("Expected the informational intent, but got %q", resp.Intent)
+	}
+	if !slices.Equal(query, []string{"how", "to", "configure", "kubernetes"}) {
+		t.Errorf("Expected the query of the caller to be unchanged, but got %v", query)
+	}
+
+	// Test case: The last matching intent wins and an unchanged query has no rewrite
+	resp, err = okapi.Search(ctx, bm25.SearchRequest{Text: "kubernetes login", N: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Intent != "navigational" || resp.Rewrite != nil {
+		t.Errorf("Expected the navigational intent without a rewrite, but got %q and %+v", resp.Intent, resp.Rewrite)
+	}
+
+	// Test case: A query made only of filler words is left unchanged
+	resp, err = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"how", "to"}, N: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Rewrite != nil || len(resp.Results) == 0 {
+		t.Errorf("Expected the query to be left unchanged, but got %+v", resp.Rewrite)
+	}
+
+	// Test case: Hooks can change the other fields of the request
+	tuned, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.5, 0.75, nil)
+	tuned.OnQuery(func(ctx context.Context, req *bm25.SearchRequest) (string, error) {
+		if len(req.Query) == 1 {
+			req.N = 1
+		}
+		return "", nil
+	})
+	resp, err = tuned.Search(ctx, bm25.SearchRequest{Query: []string{"kubernetes"}, N: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 1 {
+		t.Errorf("Expected 1 result, but got %d", len(resp.Results))
+	}
+
+	// Test case: An error of a hook fails the search
+	errRejected := errors.New("rejected")
+	tuned.OnQuery(func(ctx context.Context, req *bm25.SearchRequest) (string, error) {
+		return "", errRejected
+	})
+	if _, err := tuned.Search(ctx, bm25.SearchRequest{Query: []string{"kubernetes"}, N: 3}); !errors.Is(err, errRejected) {
+		t.Errorf("Expected the error of the hook, but got %v", err)
+	}
+
+	// Test case: Clones keep the query hooks
+	clone := okapi.Clone()
+	resp, err = clone.Search(ctx, bm25.SearchRequest{Query: []string{"find", "pasta"}, N: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Rewrite == nil {
+		t.Errorf("Expected the clone to rewrite the query")
+	}
+}
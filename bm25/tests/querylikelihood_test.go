@@ -0,0 +1,99 @@
+package bm25_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestNewQueryLikelihood(t *testing.T) {
+	corpus := []string{"hello world", "this is a test"}
+
+	// Test case: Creating a new QueryLikelihood instance with a mu of 0
+	if _, err := bm25.NewQLDirichlet(corpus, strings.Fields, 0, nil); err == nil {
+		t.Errorf("Expected an error for a mu of 0, but got nil")
+	}
+
+	// Test case: Creating a new QueryLikelihood instance with lambda outside the range (0, 1)
+	for _, lambda := range []float64{0, 1, 1.5} {
+		if _, err := bm25.NewQLJelinekMercer(corpus, strings.Fields, lambda, nil); err == nil {
+			t.Errorf("Expected an error for a lambda of %v, but got nil", lambda)
+		}
+	}
+
+	// Test case: A lambda of 1, which would ignore the documents, is rejected by the specs too
+	if err := bm25.ValidateParams(bm25.QueryLikelihoodParamSpecs(bm25.JelinekMercerSmoothing), map[string]float64{"lambda": 1}); err == nil {
+		t.Errorf("Expected an error for a lambda of 1, but got nil")
+	}
+	jm, _ := bm25.NewQLJelinekMercer(corpus, strings.Fields, 0.1, nil)
+	if _, err := jm.Search(context.Background(), bm25.SearchRequest{Query: []string{"hello"}, N: 1, Params: map[string]float64{"lambda": 1}}); err == nil {
+		t.Errorf("Expected an error for overriding lambda with 1, but got nil")
+	}
+
+	// Test case: Creating a new QueryLikelihood instance with an unknown smoothing
+	base, _ := bm25.NewBM25Base(corpus, strings.Fields, nil)
+	if _, err := bm25.NewQueryLikelihoodFromBase(base, bm25.Smoothing(5), 1); err == nil {
+		t.Errorf("Expected an error for an unknown smoothing, but got nil")
+	}
+}
+
+func TestQueryLikelihoodGetScores(t *testing.T) {
+	corpus := []string{
+		"language models for retrieval",
+		"language language models",
+		"probabilistic retrieval",
+		"cooking pasta at home tonight",
+	}
+	totalTokens := 14.0
+
+	// Test case: Dirichlet scores follow the rank-equivalent formula
+	dirichlet, _ := bm25.NewQLDirichlet(corpus, strings.Fields, 2, nil)
+	if params := dirichlet.Params(); params["mu"] != 2 {
+		t.Errorf("Expected a mu of 2, but got %v", params)
+	}
+	scores, err := dirichlet.GetScores([]string{"language"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	pC := (3 + 1) / (totalTokens + 1)
+	expected := math.Max(0, math.Log(1+2/(2*pC))+math.Log(2.0/(3+2)))
+	if math.Abs(scores[1]-expected) > 1e-9 || scores[3] != 0 {
+		t.Errorf("Expected a score of %v for document 1, but got %v", expected, scores)
+	}
+	if scores[1] <= scores[0] {
+		t.Errorf("Expected the repeated term to score higher, but got %v", scores)
+	}
+
+	// Test case: Jelinek-Mercer scores follow the rank-equivalent formula
+	jm, _ := bm25.NewQLJelinekMercer(corpus, strings.Fields, 0.5, nil)
+	scores, err = jm.GetScores([]string{"retrieval"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	pC = (2 + 1) / (totalTokens + 1)
+	expected = math.Log(1 + 0.5*1/2/(0.5*pC))
+	if math.Abs(scores[2]-expected) > 1e-9 || scores[2] <= scores[0] {
+		t.Errorf("Expected a score of %v for the shorter document 2, but got %v", expected, scores)
+	}
+
+	// Test case: Batch scores match the scores of the whole corpus
+	batch, err := jm.GetBatchScores([]string{"retrieval"}, []int{0, 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch[0] != scores[0] || batch[1] != scores[2] {
+		t.Errorf("Expected batch scores %v, but got %v", []float64{scores[0], scores[2]}, batch)
+	}
+
+	// Test case: The smoothing parameter can be overridden per search
+	resp, err := jm.Search(context.Background(), bm25.SearchRequest{Query: []string{"language", "retrieval"}, N: 3, Params: map[string]float64{"lambda": 0.9}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) == 0 || resp.Results[0].DocID == 3 {
+		t.Errorf("Expected a matching document to rank first, but got %+v", resp.Results)
+	}
+}
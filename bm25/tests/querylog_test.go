@@ -0,0 +1,71 @@
+package bm25_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestQueryLog(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world", "this is a test", "hello there"}, tokenizer, 1.5, 0.75, nil)
+
+	var entries, slow []bm25.QueryLogEntry
+	okapi.SetQueryLog(&bm25.QueryLog{
+		Sink:          bm25.QueryLogSinkFunc(func(e bm25.QueryLogEntry) { entries = append(entries, e) }),
+		SlowSink:      bm25.QueryLogSinkFunc(func(e bm25.QueryLogEntry) { slow = append(slow, e) }),
+		SlowThreshold: time.Hour,
+	})
+
+	// Test case: Every search is logged with its result count and top score
+	resp, _ := okapi.Search(context.Background(), bm25.SearchRequest{Query: []string{"hello"}, N: 2, Normalization: bm25.NormalizeMax})
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, but got %d", len(entries))
+	}
+	scores, _ := okapi.GetScores([]string{"hello"})
+	if entries[0].Results != 2 || entries[0].TopScore != scores[resp.Results[0].DocID] || entries[0].Slow {
+		t.Errorf("Unexpected log entry: %+v", entries[0])
+	}
+
+	// Test case: Failed searches are logged with their error
+	okapi.Search(context.Background(), bm25.SearchRequest{Query: []string{"hello"}})
+	if len(entries) != 2 || entries[1].Err == nil {
+		t.Errorf("Expected a log entry with an error, but got %+v", entries)
+	}
+
+	// Test case: Only searches above the threshold reach the slow query sink
+	if len(slow) != 0 {
+		t.Errorf("Expected no slow queries, but got %d", len(slow))
+	}
+	var buf bytes.Buffer
+	okapi.SetQueryLog(&bm25.QueryLog{SlowSink: bm25.NewJSONSink(&buf), SlowThreshold: time.Nanosecond})
+	okapi.Search(context.Background(), bm25.SearchRequest{Query: []string{"test"}, N: 1})
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if record["slow"] != true || record["results"] != 1.0 {
+		t.Errorf("Unexpected JSON record: %v", record)
+	}
+
+	// Test case: The logger sink writes a line per search
+	buf.Reset()
+	okapi.SetQueryLog(&bm25.QueryLog{Sink: bm25.NewLoggerSink(log.New(&buf, "", 0))})
+	okapi.Search(context.Background(), bm25.SearchRequest{Query: []string{"hello", "world"}, N: 1})
+	if !strings.HasPrefix(buf.String(), "Query 'hello world' took") {
+		t.Errorf("Unexpected log line: %q", buf.String())
+	}
+
+	// Test case: Disabling the query log
+	okapi.SetQueryLog(nil)
+	if _, err := okapi.Search(context.Background(), bm25.SearchRequest{Query: []string{}, N: 1}); !errors.Is(err, bm25.ErrEmptyQuery) {
+		t.Errorf("Expected ErrEmptyQuery, but got %v", err)
+	}
+}
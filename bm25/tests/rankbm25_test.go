@@ -0,0 +1,113 @@
+package bm25_test
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestRankBM25(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+
+	// Test case: The example of the rank_bm25 README
+	okapi, err := bm25.NewRankBM25Okapi([]string{"Hello there good man!", "It is quite windy in London", "How is the weather today?"}, tokenizer, 1.5, 0.75, 0.25, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scores, err := okapi.GetScores([]string{"windy", "London"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertScores(t, "README example", scores, []float64{0, 0.9372947225064051, 0})
+
+	// Test case: Golden scores computed in Python with the scoring code of rank_bm25 0.2.2
+	// and its default parameters
+	corpus := []string{"the cat sat on the mat", "the dog sat", "the cat and the dog", "a bird"}
+	okapi, _ = bm25.NewRankBM25Okapi(corpus, tokenizer, 1.5, 0.75, 0.25, nil)
+	l, _ := bm25.NewRankBM25L(corpus, tokenizer, 1.5, 0.75, 0.5, nil)
+	plus, _ := bm25.NewRankBM25Plus(corpus, tokenizer, 1.5, 0.75, 1, nil)
+	golden := []struct {
+		query []string
+		index *bm25.RankBM25
+		want  []float64
+	}{
+		{[]string{"the", "cat"}, okapi, []float64{0.11586979287346376, 0.10607797939119921, 0.12448820721942387, 0}},
+		{[]string{"the", "cat"}, l, []float64{1.2842979001485397, 0.9109066449107679, 1.3469419753083343, 0.6561388278116734}},
+		{[]string{"the", "cat"}, plus, []float64{2.80381724984281, 2.0026945232637967, 2.9262203733734102, 1.4271163556401456}},
+		{[]string{"sat", "dog", "dog"}, okapi, []float64{0, 0, 0, 0}},
+		{[]string{"sat", "dog", "dog"}, l, []float64{1.6462245538298697, 2.7849663504640656, 2.069814497505392, 1.2996509635498974}},
+		{[]string{"sat", "dog", "dog"}, plus, []float64{3.4968646298054487, 5.846192979422426, 4.396136432699597, 2.748872195622465}},
+		{[]string{"missing", "bird"}, okapi, []float64{0, 0, 0, 1.0932875617899402}},
+		{[]string{"missing", "bird"}, l, []float64{0.7524830027037099, 0.7524830027037099, 0.7524830027037099, 1.7557936729753232}},
+		{[]string{"missing", "bird"}, plus, []float64{1.6094379124341003, 1.6094379124341003, 1.6094379124341003, 3.686131992994229}},
+	}
+	for _, g := range golden {
+		name := g.index.Variant().String() + " " + strings.Join(g.query, " ")
+		scores, err := g.index.GetScores(g.query)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", name, err)
+		}
+		assertScores(t, name, scores, g.want)
+
+		// Test case: Batch scores match the scores of the same documents
+		batch, err := g.index.GetBatchScores(g.query, []int{3, 1})
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", name, err)
+		}
+		assertScores(t, name+" batch", batch, []float64{g.want[3], g.want[1]})
+	}
+
+	// Test case: Negative IDF values are replaced by epsilon times the average IDF
+	idf, _ := okapi.IDF("the")
+	expected := 0.25 * (5*(math.Log(3.5)-math.Log(1.5)) + 3*0 + (math.Log(1.5) - math.Log(3.5))) / 9
+	if math.Abs(idf-expected) > 1e-15 {
+		t.Errorf("Expected IDF %v for the term 'the', but got %v", expected, idf)
+	}
+
+	// Test case: Ties are ordered like a stable numpy.argsort reversed
+	top, err := l.GetTopN([]string{"missing", "bird"}, 4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := []string{corpus[3], corpus[2], corpus[1], corpus[0]}; !reflect.DeepEqual(top, want) {
+		t.Errorf("Expected %v, but got %v", want, top)
+	}
+
+	// Test case: Searching uses the compatible scores
+	resp, err := plus.Search(context.Background(), bm25.SearchRequest{Query: []string{"sat", "dog", "dog"}, N: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].DocID != 1 || math.Abs(resp.Results[0].Score-5.846192979422426) > 1e-12 {
+		t.Errorf("Expected document 1 with score 5.846, but got %+v", resp.Results)
+	}
+
+	// Test case: A frozen copy keeps the scores
+	scores, _ = okapi.Freeze().GetScores([]string{"the", "cat"})
+	assertScores(t, "frozen", scores, golden[0].want)
+
+	// Test case: Invalid parameters
+	if _, err := bm25.NewRankBM25Okapi(corpus, tokenizer, 1.5, 0.75, -1, nil); err == nil {
+		t.Errorf("Expected an error for a negative epsilon, but got nil")
+	}
+	if _, err := bm25.NewRankBM25FromBase(okapi.Bm25Base, bm25.RankBM25Variant(7), 1.5, 0.75, 0.5); err == nil {
+		t.Errorf("Expected an error for an unknown variant, but got nil")
+	}
+}
+
+// assertScores checks that scores match the expected scores up to rounding.
+func assertScores(t *testing.T, name string, scores []float64, expected []float64) {
+	t.Helper()
+	if len(scores) != len(expected) {
+		t.Fatalf("%s: expected %d scores, but got %d", name, len(expected), len(scores))
+	}
+	for i := range scores {
+		if math.Abs(scores[i]-expected[i]) > 1e-12 {
+			t.Errorf("%s: expected score %v at index %d, but got %v", name, expected[i], i, scores[i])
+		}
+	}
+}
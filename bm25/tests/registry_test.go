@@ -0,0 +1,87 @@
+package bm25_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestNew(t *testing.T) {
+	corpus := []string{"hello world", "this is a test"}
+
+	// Test case: A variant is created by alias with its parameters
+	index, err := bm25.New("BM25Plus", bm25.WithCorpus(corpus, strings.Fields), bm25.WithParams(map[string]float64{"k1": 1.2}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := index.(*bm25.BM25Plus); !ok {
+		t.Fatalf("Expected a *bm25.BM25Plus, but got %T", index)
+	}
+	if params := index.Params(); params["k1"] != 1.2 || params["b"] != 0.75 {
+		t.Errorf("Expected k1 1.2 and the default b 0.75, but got %v", params)
+	}
+
+	// Test case: A variant is created on top of an existing base
+	base, _ := bm25.NewBM25Base(corpus, strings.Fields, nil)
+	index, err = bm25.New("tfidf", bm25.WithBase(base))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if index.(*bm25.TFIDF).Bm25Base != base {
+		t.Errorf("Expected the variant to share the base")
+	}
+
+	// Test case: Unknown variants and parameters are errors
+	if _, err := bm25.New("bm26", bm25.WithBase(base)); !errors.Is(err, bm25.ErrUnknownVariant) {
+		t.Errorf("Expected ErrUnknownVariant, but got %v", err)
+	}
+	var paramErr *bm25.ErrInvalidParam
+	if _, err := bm25.New("okapi", bm25.WithBase(base), bm25.WithParams(map[string]float64{"mu": 1})); !errors.As(err, &paramErr) {
+		t.Errorf("Expected an ErrInvalidParam for an unknown parameter, but got %v", err)
+	}
+
+	// Test case: Aliases in any case resolve to the name the variant was registered with
+	for _, name := range []string{"plus", "BM25Plus", "bm25plus"} {
+		if canonical, err := bm25.VariantName(name); err != nil || canonical != "plus" {
+			t.Errorf("Expected variant plus for %s, but got %q (%v)", name, canonical, err)
+		}
+	}
+	if _, err := bm25.VariantName("bm26"); !errors.Is(err, bm25.ErrUnknownVariant) {
+		t.Errorf("Expected ErrUnknownVariant, but got %v", err)
+	}
+
+	// Test case: A corpus or a base is required
+	if _, err := bm25.New("okapi"); !errors.Is(err, bm25.ErrEmptyCorpus) {
+		t.Errorf("Expected ErrEmptyCorpus, but got %v", err)
+	}
+}
+
+func TestRegisterVariant(t *testing.T) {
+	factory := func(base *bm25.Bm25Base, params map[string]float64) (bm25.BM25, error) {
+		return bm25.NewBM25OkapiFromBase(base, params["k1"], 0)
+	}
+	specs := []bm25.ParamSpec{{Name: "k1", Default: 2, Max: 10}}
+
+	// Test case: A registered variant is listed and created by name
+	if err := bm25.RegisterVariant("test-unnormalized", specs, factory, "test-nolength"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if names := bm25.Variants(); !slices.Contains(names, "test-unnormalized") || slices.Contains(names, "test-nolength") {
+		t.Errorf("Expected the variant to be listed without its alias, but got %v", names)
+	}
+	index, err := bm25.New("test-nolength", bm25.WithCorpus([]string{"a b", "c"}, strings.Fields))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params := index.Params(); params["k1"] != 2 || params["b"] != 0 {
+		t.Errorf("Expected k1 2 and b 0, but got %v", params)
+	}
+
+	// Test case: Names cannot be registered twice
+	if err := bm25.RegisterVariant("okapi", specs, factory); err == nil {
+		t.Errorf("Expected an error for a registered name, but got nil")
+	}
+}
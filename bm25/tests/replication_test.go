@@ -0,0 +1,95 @@
+package bm25_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestReplication(t *testing.T) {
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world"}, strings.Fields, 1.5, 0.75, nil)
+	index, _ := bm25.NewCopyOnWriteIndex(okapi)
+	primary, err := bm25.NewPrimary(index, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	primary.Add(bm25.Document{ID: "a", Text: "hello there"})
+
+	// Test case: A replica is bootstrapped from a snapshot
+	var buf bytes.Buffer
+	seq, err := primary.WriteSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if seq != 1 {
+		t.Errorf("Expected the snapshot to contain entry 1, but got %d", seq)
+	}
+	base, err := bm25.ReadSnapshot(&buf, strings.Fields, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	restored, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	replica, err := bm25.NewReplica(restored, seq)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if replica.Index().CorpusSize() != 2 {
+		t.Errorf("Expected 2 documents, but got %d", replica.Index().CorpusSize())
+	}
+
+	// Test case: The replica applies the following entries in batches and reports its lag
+	primary.Add(bm25.Document{ID: "b", Text: "goodbye world"})
+	primary.Add(bm25.Document{ID: "c", Text: "hello again"}, bm25.Document{ID: "d", Text: "world peace"})
+	batch, err := primary.Entries(replica.Applied(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := replica.Apply(batch); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lag := replica.Lag(); lag.Entries != 1 || lag.Behind < 0 {
+		t.Errorf("Expected the replica to be 1 entry behind, but got %+v", lag)
+	}
+	if err := replica.Apply(batch); err != nil {
+		t.Errorf("Expected a retried batch to be skipped, but got %v", err)
+	}
+	batch, _ = primary.Entries(replica.Applied(), 0)
+	if err := replica.Apply(batch); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lag := replica.Lag(); lag.Entries != 0 || lag.Behind != 0 || replica.Applied() != 3 {
+		t.Errorf("Expected the replica to be up to date, but got %+v", lag)
+	}
+
+	// Test case: The replica ranks like the primary
+	want, _ := primary.Index().Search(ctx, bm25.SearchRequest{Query: []string{"world"}, N: 5})
+	got, _ := replica.Index().Search(ctx, bm25.SearchRequest{Query: []string{"world"}, N: 5})
+	want.Took, got.Took = 0, 0
+	if len(got.Results) != 5 {
+		t.Fatalf("Expected 5 results, but got %v", got.Results)
+	}
+	for i := range want.Results {
+		if got.Results[i].DocID != want.Results[i].DocID || got.Results[i].Score != want.Results[i].Score {
+			t.Errorf("Expected result %d to be %+v, but got %+v", i, want.Results[i], got.Results[i])
+		}
+	}
+
+	// Test case: Discarded entries and gaps
+	primary.Add(bm25.Document{ID: "e", Text: "one"})
+	primary.Add(bm25.Document{ID: "f", Text: "two"})
+	if _, err := primary.Entries(1, 0); !errors.Is(err, bm25.ErrOplogTruncated) {
+		t.Errorf("Expected ErrOplogTruncated, but got %v", err)
+	}
+	batch, _ = primary.Entries(4, 0)
+	if err := replica.Apply(batch); !errors.Is(err, bm25.ErrOplogGap) {
+		t.Errorf("Expected ErrOplogGap, but got %v", err)
+	}
+	if replica.Applied() != 3 || replica.Index().CorpusSize() != 5 {
+		t.Errorf("Expected a failed batch not to be applied, but got entry %d", replica.Applied())
+	}
+}
@@ -0,0 +1,80 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestRetrieveAndRescore(t *testing.T) {
+	corpus := []string{
+		"go concurrency patterns with channels",
+		"rust ownership and borrowing",
+		"go channels and goroutines go go",
+		"python asyncio event loop",
+		"channels in go select statement",
+	}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0.75, nil)
+	query := []string{"go", "channels"}
+
+	// Test case: Invalid arguments
+	if _, err := okapi.Retrieve(context.Background(), nil, 3); !errors.Is(err, bm25.ErrEmptyQuery) {
+		t.Errorf("Expected ErrEmptyQuery, but got %v", err)
+	}
+	if _, err := okapi.Retrieve(context.Background(), query, 0); err == nil {
+		t.Errorf("Expected an error for k = 0")
+	}
+
+	// Test case: Retrieving candidates matching the query
+	candidates, err := okapi.Retrieve(context.Background(), query, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(candidates) != 3 {
+		t.Fatalf("Expected 3 candidates, but got %v", candidates)
+	}
+	for _, candidate := range candidates {
+		if candidate.DocID == 1 || candidate.DocID == 3 {
+			t.Errorf("Expected only matching documents, but got %v", candidates)
+		}
+	}
+
+	// Test case: Rescoring applies the exact model, with the scores of GetScores
+	rescored, err := okapi.Rescore(candidates, bm25.RescoreOptions{Index: okapi, Query: query, N: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rescored) != 2 {
+		t.Fatalf("Expected 2 rescored candidates, but got %v", rescored)
+	}
+	exact, _ := okapi.GetScores(query)
+	for _, candidate := range rescored {
+		if candidate.Score != exact[candidate.DocID] {
+			t.Errorf("Expected exact score %f for document %d, but got %f", exact[candidate.DocID], candidate.DocID, candidate.Score)
+		}
+	}
+	if rescored[0].Score < rescored[1].Score {
+		t.Errorf("Expected candidates ranked by score, but got %v", rescored)
+	}
+
+	// Test case: Rescoring without an index
+	if _, err := okapi.Rescore(candidates, bm25.RescoreOptions{Query: query}); !errors.Is(err, bm25.ErrNilBase) {
+		t.Errorf("Expected ErrNilBase, but got %v", err)
+	}
+
+	// Test case: Cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := okapi.Retrieve(ctx, query, 3); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, but got %v", err)
+	}
+
+	// Test case: The impact index is counted in the memory statistics
+	if okapi.MemStats().PostingsBytes == 0 {
+		t.Errorf("Expected the impact postings to be counted")
+	}
+}
@@ -0,0 +1,83 @@
+package bm25_test
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestSample(t *testing.T) {
+	corpus := make([]string, 1000)
+	for i := range corpus {
+		words := []string{fmt.Sprintf("doc%d", i), "common"}
+		if i%10 == 0 {
+			words = append(words, "rare")
+		}
+		for j := 0; j < i%7; j++ {
+			words = append(words, "filler")
+		}
+		corpus[i] = strings.Join(words, " ")
+	}
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	base, _ := bm25.NewBM25Base(corpus, tokenizer, nil)
+
+	// Test case: Sampling an invalid fraction
+	if _, _, err := base.Sample(0, 1); err == nil {
+		t.Errorf("Expected an error for fraction 0, but got nil")
+	}
+
+	// Test case: The sample has the requested size and the same seed yields the same sample
+	sample, docIDs, err := base.Sample(0.05, 42)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sample.CorpusSize() != 50 || len(docIDs) != 50 {
+		t.Errorf("Expected 50 sampled documents, but got %d", sample.CorpusSize())
+	}
+	_, again, _ := base.Sample(0.05, 42)
+	if !reflect.DeepEqual(docIDs, again) {
+		t.Errorf("Expected the same sample for the same seed")
+	}
+	_, other, _ := base.Sample(0.05, 7)
+	if reflect.DeepEqual(docIDs, other) {
+		t.Errorf("Expected a different sample for a different seed")
+	}
+
+	// Test case: The sample preserves the length distribution and relative frequencies
+	if math.Abs(sample.AvgDocLen()-base.AvgDocLen()) > 0.2 {
+		t.Errorf("Expected an average document length close to %.2f, but got %.2f", base.AvgDocLen(), sample.AvgDocLen())
+	}
+	df, _ := sample.Vocabulary().Lookup("filler")
+	expected, _ := base.Vocabulary().Lookup("filler")
+	ratio, expectedRatio := float64(df)/50, float64(expected)/1000
+	if math.Abs(ratio-expectedRatio) > 0.03 {
+		t.Errorf("Expected a document ratio close to %.2f for 'filler', but got %.2f", expectedRatio, ratio)
+	}
+}
+
+func TestSampleQueries(t *testing.T) {
+	queries := [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}}
+
+	// Test case: Sampling fewer queries than available, in their original order
+	sample := bm25.SampleQueries(queries, 3, 1)
+	if len(sample) != 3 {
+		t.Fatalf("Expected 3 queries, but got %d", len(sample))
+	}
+	if !reflect.DeepEqual(sample, bm25.SampleQueries(queries, 3, 1)) {
+		t.Errorf("Expected the same sample for the same seed")
+	}
+	for i := 1; i < len(sample); i++ {
+		if sample[i][0] <= sample[i-1][0] {
+			t.Errorf("Expected the queries in their original order, but got %v", sample)
+		}
+	}
+
+	// Test case: Sampling more queries than available
+	if sample := bm25.SampleQueries(queries, 10, 1); len(sample) != 5 {
+		t.Errorf("Expected all 5 queries, but got %d", len(sample))
+	}
+}
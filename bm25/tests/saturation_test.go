@@ -0,0 +1,85 @@
+package bm25_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestSaturationFunctions(t *testing.T) {
+	// Test case: Built-in saturation functions
+	if got := bm25.StandardSaturation.Saturate(4); got != 4 {
+		t.Errorf("Expected standard saturation to keep tf 4, but got %f", got)
+	}
+	if got := bm25.LogSaturation.Saturate(math.E); math.Abs(got-2) > 1e-9 {
+		t.Errorf("Expected log saturation of e to be 2, but got %f", got)
+	}
+	if got := bm25.BooleanSaturation.Saturate(7); got != 1 {
+		t.Errorf("Expected boolean saturation of 7 to be 1, but got %f", got)
+	}
+	if got := bm25.LogSaturation.Saturate(0); got != 0 {
+		t.Errorf("Expected log saturation of 0 to be 0, but got %f", got)
+	}
+
+	// Test case: Piecewise saturation
+	piecewise, err := bm25.PiecewiseSaturation(2, 0.5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := piecewise.Saturate(1); got != 1 {
+		t.Errorf("Expected tf below the knee to be kept, but got %f", got)
+	}
+	if got := piecewise.Saturate(6); got != 4 {
+		t.Errorf("Expected tf 6 to saturate to 4, but got %f", got)
+	}
+
+	// Test case: Invalid piecewise parameters
+	if _, err := bm25.PiecewiseSaturation(0, 0.5); err == nil {
+		t.Errorf("Expected an error for a non-positive knee")
+	}
+	if _, err := bm25.PiecewiseSaturation(2, 1.5); err == nil {
+		t.Errorf("Expected an error for a slope above 1")
+	}
+}
+
+func TestSetSaturation(t *testing.T) {
+	corpus := []string{"spam spam spam spam offer", "spam offer today", "weather report today"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0, nil)
+
+	// Test case: With raw tf, repeating a term raises the score
+	scores, _ := okapi.GetScores([]string{"spam"})
+	if scores[0] <= scores[1] {
+		t.Errorf("Expected repetitions to raise the score, but got %v", scores)
+	}
+
+	// Test case: Boolean saturation ignores repetitions
+	if err := okapi.SetSaturation(bm25.BooleanSaturation); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scores, _ = okapi.GetScores([]string{"spam"})
+	if math.Abs(scores[0]-scores[1]) > 1e-9 || scores[2] != 0 {
+		t.Errorf("Expected equal scores for documents containing the term, but got %v", scores)
+	}
+	batch, _ := okapi.GetBatchScoresParallel([]string{"spam"}, []int{0, 1}, okapi)
+	if math.Abs(batch[0]-batch[1]) > 1e-9 {
+		t.Errorf("Expected the parallel scorer to saturate too, but got %v", batch)
+	}
+
+	// Test case: Frozen indexes keep their saturation
+	frozen := okapi.Freeze()
+	if err := frozen.SetSaturation(nil); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+
+	// Test case: Restoring the default
+	if err := okapi.SetSaturation(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := okapi.Saturation().Saturate(3); got != 3 {
+		t.Errorf("Expected the standard saturation, but got %f", got)
+	}
+}
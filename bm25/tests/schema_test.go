@@ -0,0 +1,122 @@
+package bm25_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestSchema(t *testing.T) {
+	schema := &bm25.Schema{Fields: []bm25.FieldSchema{
+		{Name: "title", Type: bm25.FieldText, Required: true},
+		{Name: "tags", Type: bm25.FieldKeyword},
+		{Name: "year", Type: bm25.FieldNumber},
+		{Name: "published", Type: bm25.FieldTime},
+	}}
+	builder, err := bm25.NewBuilder(strings.Fields, nil, bm25.BuildOptions{Schema: schema})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Documents matching the schema are added
+	_, err = builder.Add(bm25.Document{Text: "hello world", Metadata: map[string]any{
+		"title": "Hello", "tags": []string{"a", "b"}, "year": 2024, "published": time.Now(),
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	base, _ := builder.Build()
+
+	// Test case: All violations of a document are reported, sorted by field
+	_, err = base.AddDocument(bm25.Document{ID: "x", Text: "bad document", Metadata: map[string]any{
+		"tittle": "Typo", "year": "2024", "published": "2024-01-02T03:04:05Z",
+	}})
+	if !errors.Is(err, bm25.ErrSchemaViolation) {
+		t.Fatalf("Expected ErrSchemaViolation, but got %v", err)
+	}
+	expected := "field \"title\" is required\nfield \"tittle\" is not in the schema\nfield \"year\" must be of type number: \"x\""
+	if err.Error() != expected {
+		t.Errorf("Expected %q, but got %q", expected, err.Error())
+	}
+	var schemaErr *bm25.SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Field != "title" {
+		t.Errorf("Expected the first violation to be a SchemaError, but got %v", schemaErr)
+	}
+	if base.CorpusSize() != 1 {
+		t.Errorf("Expected the document to be rejected, but got %d documents", base.CorpusSize())
+	}
+
+	// Test case: Unknown fields are accepted if the schema allows them
+	base.SetSchema(&bm25.Schema{Fields: schema.Fields, AllowUnknown: true})
+	if _, err := base.AddDocument(bm25.Document{Text: "ok", Metadata: map[string]any{"title": "Ok", "extra": 1}}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Test case: Invalid schemas are rejected
+	for name, invalid := range map[string]*bm25.Schema{
+		"empty name":       {Fields: []bm25.FieldSchema{{Type: bm25.FieldText}}},
+		"duplicate name":   {Fields: []bm25.FieldSchema{{Name: "a"}, {Name: "a"}}},
+		"unknown type":     {Fields: []bm25.FieldSchema{{Name: "a", Type: bm25.FieldType(9)}}},
+		"keyword analyzer": {Fields: []bm25.FieldSchema{{Name: "a", Type: bm25.FieldKeyword, Analyzer: &bm25.Analyzer{Tokenizer: strings.Fields}}}},
+	} {
+		if err := base.SetSchema(invalid); err == nil {
+			t.Errorf("Expected an error for %s, but got nil", name)
+		}
+	}
+	if err := base.Freeze().SetSchema(nil); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+}
+
+func TestBM25FWithSchema(t *testing.T) {
+	schema := &bm25.Schema{Fields: []bm25.FieldSchema{
+		{Name: "title", Type: bm25.FieldText, Required: true, Weight: 2},
+		{Name: "body", Type: bm25.FieldText},
+		{Name: "tag", Type: bm25.FieldKeyword},
+		{Name: "year", Type: bm25.FieldNumber},
+	}}
+
+	// Test case: The violations of all documents are reported together
+	_, err := bm25.NewBM25FWithSchema([]map[string]string{
+		{"title": "ok", "year": "2024"},
+		{"body": "no title"},
+		{"title": "typo", "bdy": "x", "year": "last year"},
+	}, strings.Fields, schema, 1.5, 0.75, nil)
+	if !errors.Is(err, bm25.ErrSchemaViolation) {
+		t.Fatalf("Expected ErrSchemaViolation, but got %v", err)
+	}
+	for _, expected := range []string{
+		"document at index 1: field \"title\" is required",
+		"document at index 2: field \"bdy\" is not in the schema",
+		"field \"year\" must be of type number",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q in the error, but got %q", expected, err.Error())
+		}
+	}
+
+	// Test case: Text and keyword fields are indexed with their weights and analyzers
+	f, err := bm25.NewBM25FWithSchema([]map[string]string{
+		{"title": "go search", "body": "a library", "tag": "open source", "year": "2024"},
+		{"title": "rust", "body": "go fast", "tag": "open"},
+	}, strings.Fields, schema, 1.5, 0.75, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fields := f.Fields(); strings.Join(fields, ",") != "body,tag,title" {
+		t.Errorf("Expected the text and keyword fields, but got %v", fields)
+	}
+	if params := f.Params(); params["weights.title"] != 2 || params["weights.body"] != 1 {
+		t.Errorf("Expected the weights of the schema, but got %v", params)
+	}
+	scores, _ := f.GetScores(f.ParseQuery(`tag:"open source"`))
+	if scores[0] <= 0 || scores[1] != 0 {
+		t.Errorf("Expected the keyword field to match exactly, but got %v", scores)
+	}
+	if f.Schema() == nil {
+		t.Errorf("Expected the index to keep its schema")
+	}
+}
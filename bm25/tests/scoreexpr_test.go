@@ -0,0 +1,91 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestScoreExpression(t *testing.T) {
+	base, _ := bm25.NewBM25Base([]string{"unrelated filler"}, strings.Fields, nil)
+	now := time.Now()
+	docs := []bm25.Document{
+		{Text: "go tutorial", Metadata: map[string]any{"popularity": 10, "ts": now.Add(-14 * 24 * time.Hour)}},
+		{Text: "go tutorial", Metadata: map[string]any{"popularity": 90, "ts": now}},
+		{Text: "go tutorial"},
+	}
+	for _, doc := range docs {
+		if _, err := base.AddDocument(doc); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	okapi, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	ctx := context.Background()
+	search := func(expr string) map[int]float64 {
+		resp, err := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"go"}, N: 4, ScoreExpression: expr})
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", expr, err)
+		}
+		scores := make(map[int]float64)
+		for _, result := range resp.Results {
+			scores[result.DocID] = result.Score
+		}
+		return scores
+	}
+	plain := search("")
+
+	// Test case: Metadata fields are combined with the BM25 score
+	scores := search("bm25 * 0.8 + field(popularity) * 0.2")
+	if math.Abs(scores[2]-plain[2]*0.8-18) > 1e-9 || math.Abs(scores[3]-plain[3]*0.8) > 1e-9 {
+		t.Errorf("Unexpected scores %v for BM25 scores %v", scores, plain)
+	}
+	if scores[0] != 0 {
+		t.Errorf("Expected documents not matching the query to keep a score of 0, but got %v", scores[0])
+	}
+
+	// Test case: Recency decays by half after the half-life
+	scores = search("recency(ts, 7d)")
+	if math.Abs(scores[1]-0.25) > 1e-3 || math.Abs(scores[2]-1) > 1e-3 || scores[3] != 0 {
+		t.Errorf("Expected recency 0.25, 1 and 0, but got %v", scores)
+	}
+
+	// Test case: Operators follow the usual precedence, functions and division by zero
+	scores = search(`-(1 + 2) * 3 + max(log1p(0), sqrt(16)) / 2 + field("missing") / 0`)
+	if scores[1] != -7 {
+		t.Errorf("Expected -7, but got %v", scores[1])
+	}
+
+	// Test case: The expression applies to the documents containing a query term, not to
+	// those with a non-zero score, as BM25Plus scores documents without the terms too
+	plus, _ := bm25.NewBM25PlusFromBase(base, 1.5, 0.75, 1, 0.25)
+	plainPlus, _ := plus.GetScores([]string{"go"})
+	resp, err := plus.Search(ctx, bm25.SearchRequest{Query: []string{"go"}, N: 4, ScoreExpression: "5"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, result := range resp.Results {
+		expected := 5.0
+		if result.DocID == 0 {
+			expected = plainPlus[0]
+		}
+		if result.Score != expected {
+			t.Errorf("Expected a score of %v for document %d, but got %v", expected, result.DocID, result.Score)
+		}
+	}
+
+	// Test case: Invalid expressions are rejected with their offset
+	for _, expr := range []string{"bm25 +", "field()", "foo(1)", "recency(ts, 7x)", "(bm25", "bm25 $ 2", `field("ts`, `bm25 "x`} {
+		if _, err := bm25.CompileScoreExpression(expr); !errors.Is(err, bm25.ErrInvalidExpression) {
+			t.Errorf("Expected ErrInvalidExpression for %q, but got %v", expr, err)
+		}
+	}
+	_, err = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"go"}, N: 4, ScoreExpression: "bm25 *"})
+	if err == nil || !strings.Contains(err.Error(), "offset 6") {
+		t.Errorf("Expected a syntax error at offset 6, but got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package bm25_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestSearch(t *testing.T) {
+	corpus := []string{"hello world", "this is a test", "hello there world", "another test"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0.75, nil)
+	ctx := context.Background()
+
+	// Test case: Searching with an empty query or an invalid n
+	_, err := okapi.Search(ctx, bm25.SearchRequest{N: 1})
+	if err == nil {
+		t.Errorf("Expected an error for an empty query, but got nil")
+	}
+	_, err = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"hello"}})
+	if err == nil {
+		t.Errorf("Expected an error for n <= 0, but got nil")
+	}
+
+	// Test case: Search results match GetScores
+	query := []string{"hello", "world"}
+	expected, _ := okapi.GetScores(query)
+	resp, err := okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("Expected 2 results, but got %d", len(resp.Results))
+	}
+	if resp.Results[0].DocID != 0 || resp.Results[0].Doc != "hello world" {
+		t.Errorf("Expected document 0 as the best match, but got %d", resp.Results[0].DocID)
+	}
+	for _, result := range resp.Results {
+		if result.Score != expected[result.DocID] {
+			t.Errorf("Expected score %.2f for document %d, but got %.2f", expected[result.DocID], result.DocID, result.Score)
+		}
+	}
+
+	// Test case: Filtering documents
+	resp, _ = okapi.Search(ctx, bm25.SearchRequest{
+		Query:  query,
+		N:      10,
+		Filter: func(docID int) bool { return docID != 0 },
+	})
+	for _, result := range resp.Results {
+		if result.DocID == 0 {
+			t.Errorf("Expected document 0 to be filtered out")
+		}
+	}
+	if len(resp.Results) != 3 {
+		t.Errorf("Expected 3 results, but got %d", len(resp.Results))
+	}
+
+	// Test case: Explaining scores
+	resp, _ = okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 1, Explain: true})
+	explanation := resp.Results[0].Explanation
+	if explanation == nil || len(explanation.Terms) != 2 {
+		t.Fatalf("Expected an explanation with 2 terms, but got %v", explanation)
+	}
+	if sum := explanation.Terms[0].Score + explanation.Terms[1].Score; math.Abs(sum-explanation.Score) > 1e-12 {
+		t.Errorf("Expected the term contributions to sum to %.2f, but got %.2f", explanation.Score, sum)
+	}
+
+	// Test case: Explanations serialize to JSON with nested term contributions
+	data, err := json.Marshal(resp.Results[0])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var decoded struct {
+		Explanation struct {
+			DocLength int
+			Terms     []struct {
+				Term      string
+				IDF       float64
+				Frequency int
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.Explanation.DocLength != 2 || len(decoded.Explanation.Terms) != 2 {
+		t.Fatalf("Unexpected explanation %s", data)
+	}
+	idf, _ := okapi.IDF("hello")
+	if term := decoded.Explanation.Terms[0]; term.Term != "hello" || term.Frequency != 1 || term.IDF != idf {
+		t.Errorf("Expected the term 'hello' with frequency 1 and IDF %.2f, but got %s", idf, data)
+	}
+
+	// Test case: Normalizing scores
+	resp, _ = okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 4, Normalization: bm25.NormalizeMinMax})
+	if resp.Results[0].Score != 1.0 || resp.Results[3].Score != 0.0 {
+		t.Errorf("Expected scores between 1.00 and 0.00, but got %.2f and %.2f", resp.Results[0].Score, resp.Results[3].Score)
+	}
+	resp, _ = okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 1, Normalization: bm25.NormalizeMax})
+	if resp.Results[0].Score != 1.0 {
+		t.Errorf("Expected a top score of 1.00, but got %.2f", resp.Results[0].Score)
+	}
+
+	// Test case: Searching with a canceled context
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = okapi.Search(canceled, bm25.SearchRequest{Query: query, N: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, but got %v", err)
+	}
+}
+
+func TestSearchLimits(t *testing.T) {
+	corpus := []string{"hello world", "this is a test", "hello there world", "another test", "hello again"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0.75, nil)
+	ctx := context.Background()
+	query := []string{"hello", "world", "test", "again"}
+
+	// Test case: Negative limits
+	var paramErr *bm25.ErrInvalidParam
+	for _, limits := range []bm25.SearchLimits{{MaxGoroutines: -1}, {MaxScoredDocs: -1}, {MaxMemory: -1}} {
+		if _, err := okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 3, Limits: limits}); !errors.As(err, &paramErr) {
+			t.Errorf("Expected ErrInvalidParam for limits %+v, but got %v", limits, err)
+		}
+	}
+
+	// Test case: Scoring the terms concurrently returns the same results
+	sequential, _ := okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 5, Explain: true})
+	concurrent, err := okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 5, Explain: true, Limits: bm25.SearchLimits{MaxGoroutines: 3}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sequential.Took, concurrent.Took = 0, 0
+	if !reflect.DeepEqual(concurrent, sequential) {
+		t.Errorf("Expected the concurrent search to return %v, but got %v", sequential.Results, concurrent.Results)
+	}
+
+	// Test case: Only the best scoring documents up to the maximum are kept, whatever
+	// their IDs
+	resp, _ := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"hello"}, N: 5, Limits: bm25.SearchLimits{MaxScoredDocs: 2}})
+	if !resp.Truncated || len(resp.Results) != 2 || resp.Results[0].DocID+resp.Results[1].DocID != 4 {
+		t.Errorf("Expected the 2 best matching documents 0 and 4, but got %v (truncated: %t)", resp.Results, resp.Truncated)
+	}
+	resp, _ = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"hello"}, N: 5, Limits: bm25.SearchLimits{MaxScoredDocs: 3}})
+	if resp.Truncated || len(resp.Results) != 3 {
+		t.Errorf("Expected all 3 matching documents, but got %v (truncated: %t)", resp.Results, resp.Truncated)
+	}
+
+	// Test case: Searches exceeding the memory limit fail, or use fewer goroutines
+	_, err = okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 5, Limits: bm25.SearchLimits{MaxMemory: 100}})
+	if !errors.Is(err, bm25.ErrResourceLimit) {
+		t.Errorf("Expected ErrResourceLimit, but got %v", err)
+	}
+	_, err = okapi.Search(ctx, bm25.SearchRequest{Query: query, N: 5, Limits: bm25.SearchLimits{MaxGoroutines: 4, MaxMemory: 200}})
+	if err != nil {
+		t.Errorf("Expected the search to fit the limit with fewer goroutines, but got %v", err)
+	}
+}
+
+func TestSearchString(t *testing.T) {
+	corpus := []string{"Go Concurrency Patterns", "Rust ownership", "concurrency in practice"}
+	tokenizer := func(s string) []string { return strings.Fields(strings.ToLower(s)) }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0.75, nil)
+	ctx := context.Background()
+
+	// Test case: The query text is tokenized with the tokenizer of the index
+	resp, err := okapi.SearchString(ctx, "go CONCURRENCY patterns", 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected, _ := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"go", "concurrency", "patterns"}, N: 2})
+	resp.Took, expected.Took = 0, 0
+	if !reflect.DeepEqual(resp, expected) {
+		t.Errorf("Expected %v, but got %v", expected.Results, resp.Results)
+	}
+	if resp.Results[0].DocID != 0 {
+		t.Errorf("Expected document 0 first, but got %v", resp.Results)
+	}
+
+	// Test case: A query text without tokens
+	_, err = okapi.SearchString(ctx, "   ", 2)
+	if !errors.Is(err, bm25.ErrEmptyQuery) {
+		t.Errorf("Expected ErrEmptyQuery, but got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func newShard(t *testing.T) *bm25.CopyOnWriteIndex {
+	t.Helper()
+	okapi, err := bm25.NewBM25Okapi([]string{"placeholder"}, strings.Fields, 1.5, 0.75, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	index, err := bm25.NewCopyOnWriteIndex(okapi)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return index
+}
+
+func TestShardRouter(t *testing.T) {
+	router, err := bm25.NewShardRouter(0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	shards := map[string]*bm25.CopyOnWriteIndex{"s1": newShard(t), "s2": newShard(t), "s3": newShard(t)}
+	for _, name := range []string{"s1", "s2", "s3"} {
+		if err := router.AddShard(name, shards[name]); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// Test case: Documents are spread over the shards
+	var docs []bm25.Document
+	var ids []string
+	for i := 0; i < 300; i++ {
+		id := fmt.Sprintf("doc-%d", i)
+		ids = append(ids, id)
+		docs = append(docs, bm25.Document{ID: id, Text: fmt.Sprintf("common term%d", i%10)})
+	}
+	if err := router.Add(docs...); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, shard := range shards {
+		if n := shard.CorpusSize() - 1; n < 50 || n > 150 {
+			t.Errorf("Expected about 100 documents in shard %s, but got %d", name, n)
+		}
+	}
+	before := make(map[string]string)
+	for _, id := range ids {
+		before[id] = router.ShardFor(id)
+	}
+
+	// Test case: Adding a shard only moves documents to the new shard
+	shards["s4"] = newShard(t)
+	router.AddShard("s4", shards["s4"])
+	moved := router.Moved(ids, func(id string) string { return before[id] })
+	if len(moved) < 40 || len(moved) > 110 {
+		t.Errorf("Expected about a quarter of the documents to move, but got %d", len(moved))
+	}
+	for _, id := range moved {
+		if shard := router.ShardFor(id); shard != "s4" {
+			t.Errorf("Expected %s to move to s4, but got %s", id, shard)
+		}
+	}
+
+	// Test case: Searches fan out to all shards, and moved documents are returned once
+	ctx := context.Background()
+	resp, err := router.Search(ctx, bm25.SearchRequest{Query: []string{"term3"}, N: 100})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resp.Results) != 100 || resp.Results[0].ExternalID == "" {
+		t.Fatalf("Expected 100 results with external IDs, but got %d", len(resp.Results))
+	}
+	matching := 0
+	for _, result := range resp.Results {
+		if result.Score > 0 {
+			matching++
+		}
+	}
+	if matching != 30 {
+		t.Errorf("Expected the 30 matching documents, but got %d", matching)
+	}
+	var movedDocs []bm25.Document
+	for _, doc := range docs {
+		for _, id := range moved {
+			if doc.ID == id {
+				movedDocs = append(movedDocs, doc)
+			}
+		}
+	}
+	if err := router.Add(movedDocs...); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, _ = router.Search(ctx, bm25.SearchRequest{Query: []string{"term3"}, N: 400})
+	seen := make(map[string]bool)
+	for _, result := range resp.Results {
+		if result.ExternalID == "" {
+			continue
+		}
+		if seen[result.ExternalID] {
+			t.Errorf("Expected %s to be returned once, but got it twice", result.ExternalID)
+		}
+		seen[result.ExternalID] = true
+		if result.Shard != router.ShardFor(result.ExternalID) {
+			t.Errorf("Expected %s to be returned from %s, but got %s", result.ExternalID, router.ShardFor(result.ExternalID), result.Shard)
+		}
+	}
+	if len(seen) != 300 {
+		t.Errorf("Expected all 300 documents, but got %d", len(seen))
+	}
+
+	// Test case: Invalid documents and shards
+	if err := router.Add(bm25.Document{Text: "no id"}); !errors.Is(err, bm25.ErrMissingID) {
+		t.Errorf("Expected ErrMissingID, but got %v", err)
+	}
+	if err := router.AddShard("s1", newShard(t)); err == nil {
+		t.Errorf("Expected an error for a duplicate shard, but got nil")
+	}
+	if _, err := router.Search(ctx, bm25.SearchRequest{N: 5}); !errors.Is(err, bm25.ErrEmptyQuery) {
+		t.Errorf("Expected ErrEmptyQuery, but got %v", err)
+	}
+}
+
+func TestShardRouterSort(t *testing.T) {
+	router, err := bm25.NewShardRouter(0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, name := range []string{"s1", "s2"} {
+		if err := router.AddShard(name, newShard(t)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	var docs []bm25.Document
+	for i := 0; i < 8; i++ {
+		docs = append(docs, bm25.Document{ID: fmt.Sprintf("d%d", i), Text: fmt.Sprintf("common term%d", i), Metadata: map[string]any{"ts": i}})
+	}
+	if err := router.Add(docs...); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Results are merged across shards by the sort field rather than by score
+	resp, err := router.Search(context.Background(), bm25.SearchRequest{Query: []string{"common"}, N: 10, Sort: []bm25.SortField{{Field: "ts", Descending: true}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var ids []string
+	for _, result := range resp.Results {
+		if result.ExternalID != "" {
+			ids = append(ids, result.ExternalID)
+		}
+	}
+	if expected := "d7 d6 d5 d4 d3 d2 d1 d0"; strings.Join(ids, " ") != expected {
+		t.Errorf("Expected %s, but got %v", expected, ids)
+	}
+}
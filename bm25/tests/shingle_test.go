@@ -0,0 +1,76 @@
+package bm25_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestShingleFilter(t *testing.T) {
+	filter, err := bm25.ShingleFilter(bm25.ShingleOptions{MaxSize: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Bigrams and trigrams follow the word they start with
+	tokens := filter([]string{"new", "york", "city"})
+	expected := []string{"new", "new york", "new york city", "york", "york city", "city"}
+	if strings.Join(tokens, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected tokens %v, but got %v", expected, tokens)
+	}
+
+	// Test case: Only shingles are kept if requested
+	filter, _ = bm25.ShingleFilter(bm25.ShingleOptions{ShinglesOnly: true})
+	if tokens := filter([]string{"a", "b", "c"}); strings.Join(tokens, "|") != "a b|b c" {
+		t.Errorf("Expected only the bigrams, but got %v", tokens)
+	}
+	if !bm25.IsShingle("a b") || bm25.IsShingle("a") {
+		t.Errorf("Expected IsShingle to detect shingles")
+	}
+
+	// Test case: Invalid sizes are rejected
+	if _, err := bm25.ShingleFilter(bm25.ShingleOptions{MinSize: 1}); err == nil {
+		t.Errorf("Expected an error for a shingle size of 1")
+	}
+	if _, err := bm25.ShingleFilter(bm25.ShingleOptions{MinSize: 3, MaxSize: 2}); err == nil {
+		t.Errorf("Expected an error for a maximum size below the minimum size")
+	}
+}
+
+func TestShingleBoost(t *testing.T) {
+	filter, _ := bm25.ShingleFilter(bm25.ShingleOptions{})
+	analyzer := bm25.Analyzer{Tokenizer: strings.Fields, Filters: []bm25.TokenFilter{bm25.LowercaseFilter, filter}}
+	corpus := []string{
+		"york is a city and new ideas come from it",
+		"new york is a city",
+		"a city in the north of england",
+		"the weather is nice today",
+	}
+	index, err := bm25.NewBM25Okapi(corpus, analyzer.Analyze, 1.5, 0.75, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: The document containing the phrase ranks first, more so with a boost
+	search := func(boost float64) *bm25.SearchResponse {
+		resp, err := index.Search(context.Background(), bm25.SearchRequest{Text: "New York", N: 2, ShingleBoost: boost})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return resp
+	}
+	plain, boosted := search(0), search(3)
+	if plain.Results[0].DocID != 1 || boosted.Results[0].DocID != 1 {
+		t.Fatalf("Expected the phrase match to rank first, but got %+v and %+v", plain.Results, boosted.Results)
+	}
+	if boosted.Results[0].Score-boosted.Results[1].Score <= plain.Results[0].Score-plain.Results[1].Score {
+		t.Errorf("Expected the boost to widen the gap, but got %+v and %+v", plain.Results, boosted.Results)
+	}
+
+	// Test case: Negative boosts are rejected
+	if _, err := index.Search(context.Background(), bm25.SearchRequest{Text: "new york", N: 2, ShingleBoost: -1}); err == nil {
+		t.Errorf("Expected an error for a negative shingle boost")
+	}
+}
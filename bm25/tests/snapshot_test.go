@@ -0,0 +1,383 @@
+package bm25_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"hash/crc32"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestSnapshot(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	builder, _ := bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{})
+	docs := []bm25.Document{
+		{ID: "a", Text: "the quick brown fox", Metadata: map[string]any{"published": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), "stars": 4}},
+		{Text: "the lazy dog"},
+		{ID: "c", Text: "the quick dog jumps", TTL: time.Hour},
+	}
+	for _, doc := range docs {
+		if _, err := builder.Add(doc); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	base, _ := builder.Build()
+	_ = base.ExcludeStopwords([]string{"the"})
+	_ = base.SetTermWeights(map[string]float64{"fox": 2})
+	_ = base.SetEpsilon(0.25)
+
+	// Test case: Writing and restoring a snapshot
+	var buf bytes.Buffer
+	if err := base.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	restored, err := bm25.ReadSnapshot(&buf, tokenizer, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	original, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	okapi, _ := bm25.NewBM25OkapiFromBase(restored, 1.5, 0.75)
+	query := []string{"quick", "fox", "dog"}
+	expected, _ := original.GetScores(query)
+	scores, _ := okapi.GetScores(query)
+	if !reflect.DeepEqual(scores, expected) {
+		t.Errorf("Expected scores %v, but got %v", expected, scores)
+	}
+	if restored.CorpusSize() != 3 || restored.AvgDocLen() != base.AvgDocLen() {
+		t.Errorf("Expected the corpus statistics to be restored, but got %d documents of length %.2f", restored.CorpusSize(), restored.AvgDocLen())
+	}
+	if id, ok := restored.LookupID("c"); !ok || id != 2 {
+		t.Errorf("Expected external ID c to map to 2, but got %d", id)
+	}
+	if published := restored.Metadata(0)["published"]; published != docs[0].Metadata["published"] {
+		t.Errorf("Expected the metadata to be restored, but got %v", published)
+	}
+	if !reflect.DeepEqual(restored.Stopwords(), []string{"the"}) || restored.TermWeights()["fox"] != 2 {
+		t.Errorf("Expected the stopwords and term weights to be restored")
+	}
+	if epsilon, ok := restored.Epsilon(); !ok || epsilon != 0.25 {
+		t.Errorf("Expected an epsilon of 0.25, but got %.2f", epsilon)
+	}
+
+	// Test case: Restored indexes accept new documents
+	if _, err := restored.AddDocument(bm25.Document{Text: "a new fox"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Test case: Reading an invalid snapshot
+	if _, err := bm25.ReadSnapshot(strings.NewReader("not a snapshot"), tokenizer, nil); !errors.Is(err, bm25.ErrInvalidSnapshot) {
+		t.Errorf("Expected ErrInvalidSnapshot, but got %v", err)
+	}
+}
+
+func TestSnapshotFormat(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	builder, _ := bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{})
+	metadata := map[string]any{"count": 3, "big": int64(1) << 60, "ratio": 0.5, "draft": true, "title": "x", "tags": []any{"a", "b"}, "none": nil}
+	for i := 0; i < 50; i++ {
+		doc := bm25.Document{Text: "the quick brown fox jumps over the lazy dog"}
+		if i == 0 {
+			doc.Metadata = metadata
+		}
+		if _, err := builder.Add(doc); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	base, _ := builder.Build()
+
+	var gzipped, plain bytes.Buffer
+	if err := base.WriteSnapshot(&gzipped); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := base.WriteSnapshotWithOptions(&plain, bm25.SnapshotOptions{Compression: bm25.CompressionNone}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Snapshots start with a magic header and are compressed by default
+	if !bytes.HasPrefix(gzipped.Bytes(), []byte("BM25SNAP")) {
+		t.Errorf("Expected the magic header, but got %q", gzipped.Bytes()[:8])
+	}
+	if gzipped.Len() >= plain.Len() {
+		t.Errorf("Expected the compressed snapshot to be smaller, but got %d >= %d bytes", gzipped.Len(), plain.Len())
+	}
+
+	// Test case: Metadata values keep their types
+	restored, err := bm25.ReadSnapshot(bytes.NewReader(plain.Bytes()), tokenizer, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := restored.Metadata(0); !reflect.DeepEqual(got, map[string]any{"count": 3, "big": 1 << 60, "ratio": 0.5, "draft": true, "title": "x", "tags": []any{"a", "b"}, "none": nil}) {
+		t.Errorf("Unexpected metadata %v", got)
+	}
+
+	// Test case: Unknown sections are skipped
+	data := append([]byte(nil), plain.Bytes()[:plain.Len()-13]...)
+	data = append(data, 42, 3, 0, 0, 0, 0, 0, 0, 0, 'a', 'b', 'c')
+	data = binary.LittleEndian.AppendUint32(data, crc32.Checksum([]byte("abc"), crc32.MakeTable(crc32.Castagnoli)))
+	data = append(data, plain.Bytes()[plain.Len()-13:]...)
+	if _, err := bm25.ReadSnapshot(bytes.NewReader(data), tokenizer, nil); err != nil {
+		t.Errorf("Expected unknown sections to be skipped, but got %v", err)
+	}
+
+	// Test case: Corrupted snapshots are rejected
+	data = append([]byte(nil), gzipped.Bytes()...)
+	data[len(data)/2] ^= 0xff
+	if _, err := bm25.ReadSnapshot(bytes.NewReader(data), tokenizer, nil); !errors.Is(err, bm25.ErrInvalidSnapshot) {
+		t.Errorf("Expected ErrInvalidSnapshot for a corrupted snapshot, but got %v", err)
+	}
+	if _, err := bm25.ReadSnapshot(bytes.NewReader(gzipped.Bytes()[:gzipped.Len()-20]), tokenizer, nil); !errors.Is(err, bm25.ErrInvalidSnapshot) {
+		t.Errorf("Expected ErrInvalidSnapshot for a truncated snapshot, but got %v", err)
+	}
+
+	// Test case: Snapshots of a newer format version are rejected
+	data = append([]byte(nil), gzipped.Bytes()...)
+	binary.LittleEndian.PutUint16(data[8:], bm25.SnapshotVersion+1)
+	if _, err := bm25.ReadSnapshot(bytes.NewReader(data), tokenizer, nil); !errors.Is(err, bm25.ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion, but got %v", err)
+	}
+
+	// Test case: Compressions without a registered Compressor
+	err = base.WriteSnapshotWithOptions(io.Discard, bm25.SnapshotOptions{Compression: bm25.CompressionZstd})
+	if !errors.Is(err, bm25.ErrUnsupportedCompression) {
+		t.Errorf("Expected ErrUnsupportedCompression, but got %v", err)
+	}
+
+	// Test case: Reading a snapshot written with gob
+	var legacy bytes.Buffer
+	_ = gob.NewEncoder(&legacy).Encode(struct {
+		Corpus      [][]string
+		ExternalIDs []string
+	}{Corpus: [][]string{{"hello", "world"}, {"hello"}}, ExternalIDs: []string{"a", "b"}})
+	restored, err = bm25.ReadSnapshot(&legacy, tokenizer, nil)
+	if err != nil || restored.CorpusSize() != 2 || restored.ExternalID(1) != "b" {
+		t.Errorf("Expected the gob snapshot to be read, but got error %v", err)
+	}
+}
+
+func TestOpenSnapshot(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	builder, _ := bm25.NewBuilder(tokenizer, nil, bm25.BuildOptions{})
+	texts := []string{"the quick brown fox", "the lazy dog", "the quick dog jumps", "a fox and a dog"}
+	for i, text := range texts {
+		if _, err := builder.Add(bm25.Document{ID: string(rune('a' + i)), Text: text, Metadata: map[string]any{"n": i}}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	base, _ := builder.Build()
+	_ = base.ExcludeStopwords([]string{"the"})
+
+	var buf bytes.Buffer
+	if err := base.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: A lazily opened index scores like a fully read one
+	opened, err := bm25.OpenSnapshot(bytes.NewReader(buf.Bytes()), tokenizer, nil, bm25.SnapshotOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	idf, _ := opened.IDF("dog")
+	if expectedIDF, _ := base.IDF("dog"); opened.CorpusSize() != 4 || opened.AvgDocLen() != base.AvgDocLen() || idf != expectedIDF {
+		t.Errorf("Expected the corpus statistics to be read upfront, but got %d documents of length %.2f", opened.CorpusSize(), opened.AvgDocLen())
+	}
+	if id, ok := opened.LookupID("c"); !ok || id != 2 || opened.Metadata(3)["n"] != 3 {
+		t.Errorf("Expected the document attributes to be read upfront, but got ID %d and metadata %v", id, opened.Metadata(3))
+	}
+
+	original, _ := bm25.NewBM25OkapiFromBase(base, 1.5, 0.75)
+	okapi, _ := bm25.NewBM25OkapiFromBase(opened, 1.5, 0.75)
+	query := []string{"quick", "fox", "dog", "the"}
+	expected, _ := original.GetScores(query)
+	scores, _ := okapi.GetScores(query)
+	if !reflect.DeepEqual(scores, expected) {
+		t.Errorf("Expected scores %v, but got %v", expected, scores)
+	}
+	if tokens, _ := opened.DocumentTokens(1); !reflect.DeepEqual(tokens, []string{"the", "lazy", "dog"}) {
+		t.Errorf("Expected the tokens of document 1, but got %v", tokens)
+	}
+
+	// Test case: Lazily opened indexes can be extended and written again
+	if _, err := opened.AddDocument(bm25.Document{Text: "quick quick fox"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var rewritten bytes.Buffer
+	if err := opened.WriteSnapshot(&rewritten); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	restored, err := bm25.ReadSnapshot(&rewritten, tokenizer, nil)
+	if err != nil || restored.CorpusSize() != 5 || !restored.HasTerm(0, "brown") || !restored.HasTerm(4, "quick") {
+		t.Errorf("Expected the extended index to be written, but got error %v", err)
+	}
+
+	// Test case: The tokens are only read on first access, and read errors surface in
+	// Search and Preload
+	data := append([]byte(nil), buf.Bytes()...)
+	data[len(data)-14] ^= 0xff // Checksum of the last tokens section, before the end section
+	corrupted, err := bm25.OpenSnapshot(bytes.NewReader(data), tokenizer, nil, bm25.SnapshotOptions{})
+	if err != nil {
+		t.Fatalf("Expected the snapshot to open without reading the tokens, but got %v", err)
+	}
+	okapi, _ = bm25.NewBM25OkapiFromBase(corrupted, 1.5, 0.75)
+	if _, err := okapi.Search(context.Background(), bm25.SearchRequest{Query: query, N: 2}); !errors.Is(err, bm25.ErrInvalidSnapshot) {
+		t.Errorf("Expected ErrInvalidSnapshot from Search, but got %v", err)
+	}
+	if err := corrupted.Preload(); !errors.Is(err, bm25.ErrInvalidSnapshot) {
+		t.Errorf("Expected ErrInvalidSnapshot from Preload, but got %v", err)
+	}
+
+	// Test case: Snapshots written with gob are read in full
+	var legacy bytes.Buffer
+	_ = gob.NewEncoder(&legacy).Encode(struct{ Corpus [][]string }{Corpus: [][]string{{"hello", "world"}}})
+	opened, err = bm25.OpenSnapshot(bytes.NewReader(legacy.Bytes()), tokenizer, nil, bm25.SnapshotOptions{})
+	if err != nil || opened.CorpusSize() != 1 || opened.Preload() != nil {
+		t.Errorf("Expected the gob snapshot to be read, but got error %v", err)
+	}
+}
+
+func TestSnapshotEncryption(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	corpus := []string{"patient john doe", "patient jane roe", "visit notes"}
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0.75, nil)
+	key := bm25.StaticKey(bytes.Repeat([]byte{7}, 32))
+	opts := bm25.SnapshotOptions{Keys: key, Compression: bm25.CompressionNone}
+
+	// Test case: Encrypted snapshots do not contain the plain tokens
+	var buf bytes.Buffer
+	if err := okapi.WriteSnapshotWithOptions(&buf, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("patient")) {
+		t.Errorf("Expected the snapshot to be encrypted, but it contains plain tokens")
+	}
+
+	// Test case: Reading with the key
+	restored, err := bm25.ReadSnapshotWithOptions(bytes.NewReader(buf.Bytes()), tokenizer, nil, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if restored.CorpusSize() != 3 {
+		t.Errorf("Expected 3 documents, but got %d", restored.CorpusSize())
+	}
+
+	// Test case: The end marker is sealed, so a snapshot truncated at a section boundary
+	// cannot be completed with a forged one
+	const sealedEndLen = 9 + 12 + 16 + 4 // Header, nonce, tag and checksum
+	forged := append(bytes.Clone(buf.Bytes()[:buf.Len()-sealedEndLen]), make([]byte, 13)...)
+	if _, err := bm25.ReadSnapshotWithOptions(bytes.NewReader(forged), tokenizer, nil, opts); !errors.Is(err, bm25.ErrDecryption) {
+		t.Errorf("Expected ErrDecryption for a forged end marker, but got %v", err)
+	}
+	if _, err := bm25.OpenSnapshot(bytes.NewReader(forged), tokenizer, nil, opts); !errors.Is(err, bm25.ErrDecryption) {
+		t.Errorf("Expected ErrDecryption for a forged end marker when opening, but got %v", err)
+	}
+
+	// Test case: Snapshots of version 3 did not seal empty sections, and are still read
+	binary.LittleEndian.PutUint16(forged[8:], 3)
+	if _, err := bm25.ReadSnapshotWithOptions(bytes.NewReader(forged), tokenizer, nil, opts); err != nil {
+		t.Errorf("Expected the version 3 snapshot to be read, but got %v", err)
+	}
+
+	// Test case: Reading without a key or with a wrong key
+	if _, err := bm25.ReadSnapshot(bytes.NewReader(buf.Bytes()), tokenizer, nil); !errors.Is(err, bm25.ErrMissingKey) {
+		t.Errorf("Expected ErrMissingKey, but got %v", err)
+	}
+	wrong := bm25.SnapshotOptions{Keys: bm25.StaticKey(bytes.Repeat([]byte{8}, 32))}
+	if _, err := bm25.ReadSnapshotWithOptions(bytes.NewReader(buf.Bytes()), tokenizer, nil, wrong); !errors.Is(err, bm25.ErrDecryption) {
+		t.Errorf("Expected ErrDecryption, but got %v", err)
+	}
+
+	// Test case: Encrypting a compressed snapshot
+	var gzipped bytes.Buffer
+	_ = okapi.WriteSnapshotWithOptions(&gzipped, bm25.SnapshotOptions{Keys: key})
+	if _, err := bm25.ReadSnapshotWithOptions(&gzipped, tokenizer, nil, opts); err != nil {
+		t.Errorf("Expected the compressed, encrypted snapshot to be read, but got %v", err)
+	}
+
+	// Test case: Invalid key lengths
+	var paramErr *bm25.ErrInvalidParam
+	err = okapi.WriteSnapshotWithOptions(io.Discard, bm25.SnapshotOptions{Keys: bm25.StaticKey("short")})
+	if !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam for a short key, but got %v", err)
+	}
+
+	// Test case: Key providers backed by a key management service
+	kms := &fakeKMS{}
+	buf.Reset()
+	if err := okapi.WriteSnapshotWithOptions(&buf, bm25.SnapshotOptions{Keys: kms}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := bm25.ReadSnapshotWithOptions(&buf, tokenizer, nil, bm25.SnapshotOptions{Keys: kms}); err != nil || kms.decrypted != 1 {
+		t.Errorf("Expected the key to be decrypted by the provider, but got %d calls (error: %v)", kms.decrypted, err)
+	}
+}
+
+// fakeKMS is a KeyProvider that "encrypts" data keys by reversing them.
+type fakeKMS struct {
+	decrypted int
+}
+
+func (k *fakeKMS) NewKey() ([]byte, []byte, error) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	return key, reverse(key), nil
+}
+
+func (k *fakeKMS) DecryptKey(encryptedKey []byte) ([]byte, error) {
+	k.decrypted++
+	return reverse(encryptedKey), nil
+}
+
+func reverse(b []byte) []byte {
+	reversed := make([]byte, len(b))
+	for i := range b {
+		reversed[len(b)-1-i] = b[i]
+	}
+	return reversed
+}
+
+func TestSnapshotProgress(t *testing.T) {
+	base, _ := bm25.NewBM25Base([]string{"the quick brown fox", "the lazy dog", "the quick dog jumps"}, strings.Fields, nil)
+
+	// Test case: Writing reports every section, ending with all documents and bytes
+	var buf bytes.Buffer
+	var written []bm25.SnapshotProgress
+	if err := base.WriteSnapshotWithOptions(&buf, bm25.SnapshotOptions{Progress: func(p bm25.SnapshotProgress) { written = append(written, p) }}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(written) < 2 {
+		t.Fatalf("Expected a progress report per section, but got %+v", written)
+	}
+	if last := written[len(written)-1]; last.DocsProcessed != 3 || last.TotalDocs != 3 || last.Bytes != int64(buf.Len()) {
+		t.Errorf("Expected 3 of 3 documents in %d bytes, but got %+v", buf.Len(), last)
+	}
+	for i := 1; i < len(written); i++ {
+		if written[i].Bytes <= written[i-1].Bytes || written[i].Elapsed < written[i-1].Elapsed {
+			t.Errorf("Expected the bytes and elapsed time to grow, but got %+v after %+v", written[i], written[i-1])
+		}
+	}
+
+	// Test case: Reading reports the same totals
+	var read bm25.SnapshotProgress
+	if _, err := bm25.ReadSnapshotWithOptions(bytes.NewReader(buf.Bytes()), strings.Fields, nil, bm25.SnapshotOptions{Progress: func(p bm25.SnapshotProgress) { read = p }}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if read.DocsProcessed != 3 || read.TotalDocs != 3 || read.Bytes != int64(buf.Len()) {
+		t.Errorf("Expected 3 of 3 documents in %d bytes, but got %+v", buf.Len(), read)
+	}
+
+	// Test case: Opening lazily counts the documents whose attributes are read
+	var opened bm25.SnapshotProgress
+	if _, err := bm25.OpenSnapshot(bytes.NewReader(buf.Bytes()), strings.Fields, nil, bm25.SnapshotOptions{Progress: func(p bm25.SnapshotProgress) { opened = p }}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opened.DocsProcessed != 3 || opened.TotalDocs != 3 || opened.Bytes != int64(buf.Len()) {
+		t.Errorf("Expected 3 of 3 documents in %d bytes, but got %+v", buf.Len(), opened)
+	}
+}
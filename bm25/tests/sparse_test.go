@@ -0,0 +1,53 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestGetSparseScores(t *testing.T) {
+	corpus := []string{"a b c", "b c d", "c d e", "d e f", "e f g", "f g h"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0.75, nil)
+	query := []string{"a", "e"}
+	dense, _ := okapi.GetScores(query)
+
+	// Test case: Empty query
+	if _, _, err := okapi.GetSparseScores(nil, okapi); !errors.Is(err, bm25.ErrEmptyQuery) {
+		t.Errorf("Expected ErrEmptyQuery, but got %v", err)
+	}
+
+	// Test case: Only matching documents are returned, with the scores of GetScores
+	docIDs, scores, err := okapi.GetSparseScores(query, okapi)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(docIDs, []int{0, 2, 3, 4}) {
+		t.Errorf("Expected document IDs [0 2 3 4], but got %v", docIDs)
+	}
+	for i, docID := range docIDs {
+		if scores[i] != dense[docID] {
+			t.Errorf("Expected score %f for document %d, but got %f", dense[docID], docID, scores[i])
+		}
+	}
+
+	// Test case: The posting lists of Retrieve give the same result
+	if _, err := okapi.Retrieve(context.Background(), query, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	postingIDs, postingScores, _ := okapi.GetSparseScores(query, okapi)
+	if !reflect.DeepEqual(postingIDs, docIDs) || !reflect.DeepEqual(postingScores, scores) {
+		t.Errorf("Expected %v %v, but got %v %v", docIDs, scores, postingIDs, postingScores)
+	}
+
+	// Test case: No matching documents
+	docIDs, scores, err = okapi.GetSparseScores([]string{"z"}, okapi)
+	if err != nil || len(docIDs) != 0 || len(scores) != 0 {
+		t.Errorf("Expected no scores, but got %v %v (%v)", docIDs, scores, err)
+	}
+}
@@ -0,0 +1,40 @@
+package bm25_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestStats(t *testing.T) {
+	corpus := []string{"hello world", "this is a test", "hello there", "hello"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	base, _ := bm25.NewBM25Base(corpus, tokenizer, nil)
+
+	stats := base.Stats(2)
+
+	// Test case: Checking the corpus statistics
+	if stats.CorpusSize != 4 || stats.VocabularySize != 7 {
+		t.Errorf("Expected 4 documents and 7 terms, but got %d and %d", stats.CorpusSize, stats.VocabularySize)
+	}
+	expected := bm25.Percentiles{Min: 1, P50: 2, P90: 4, P99: 4, Max: 4}
+	if stats.DocLengths != expected {
+		t.Errorf("Expected document lengths %v, but got %v", expected, stats.DocLengths)
+	}
+	expected = bm25.Percentiles{Min: 1, P50: 1, P90: 3, P99: 3, Max: 3}
+	if stats.PostingLengths != expected {
+		t.Errorf("Expected posting list lengths %v, but got %v", expected, stats.PostingLengths)
+	}
+
+	// Test case: Checking the most frequent terms
+	if len(stats.TopTerms) != 2 || stats.TopTerms[0].Term != "hello" || stats.TopTerms[0].DocFreq != 3 {
+		t.Errorf("Expected 'hello' as the most frequent of 2 terms, but got %v", stats.TopTerms)
+	}
+
+	// Test case: Formatting the report
+	report := stats.String()
+	if !strings.Contains(report, "Vocabulary size:") || !strings.Contains(report, "hello") {
+		t.Errorf("Expected the report to contain the vocabulary size and top terms, but got:\n%s", report)
+	}
+}
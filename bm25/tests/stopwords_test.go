@@ -0,0 +1,67 @@
+package bm25_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestDetectStopwords(t *testing.T) {
+	corpus := []string{"the cat sat", "the dog ran", "the bird flew"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	base, _ := bm25.NewBM25Base(corpus, tokenizer, nil)
+
+	// Test case: Detecting stopwords with an invalid ratio
+	_, err := base.DetectStopwords(0, 0.1)
+	if err == nil {
+		t.Errorf("Expected an error for an invalid ratio, but got nil")
+	}
+
+	// Test case: Detecting stopwords in a corpus with a term present in all documents
+	stopwords, err := base.DetectStopwords(0.9, 0.1)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(stopwords) != 1 || stopwords[0] != "the" {
+		t.Errorf("Expected stopwords [the], but got %v", stopwords)
+	}
+
+	// Test case: Detecting stopwords does not exclude them
+	if len(base.Stopwords()) != 0 {
+		t.Errorf("Expected no excluded stopwords, but got %v", base.Stopwords())
+	}
+}
+
+func TestExcludeStopwords(t *testing.T) {
+	corpus := []string{"the cat sat", "the dog ran", "the bird flew"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	bm25, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0.75, nil)
+
+	// Test case: Excluding detected stopwords
+	stopwords, err := bm25.ExcludeDetectedStopwords(0.9, 0.1)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(stopwords) != 1 || stopwords[0] != "the" {
+		t.Errorf("Expected stopwords [the], but got %v", stopwords)
+	}
+
+	// Test case: Excluded stopwords do not contribute to the scores
+	scores, err := bm25.GetScores([]string{"the"})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for i, score := range scores {
+		if score != 0.0 {
+			t.Errorf("Expected score 0.00 at index %d, but got %.2f", i, score)
+		}
+	}
+
+	// Test case: Clearing the excluded stopwords
+	bm25.ExcludeStopwords(nil)
+	scores, _ = bm25.GetScores([]string{"the"})
+	if scores[0] == 0.0 {
+		t.Errorf("Expected a non-zero score after clearing stopwords, but got %.2f", scores[0])
+	}
+}
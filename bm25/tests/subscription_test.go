@@ -0,0 +1,108 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestAddDocument(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	base, _ := bm25.NewBM25Base([]string{"hello world", "this is a test"}, tokenizer, nil)
+	base.IDF("hello") // Populate the cache
+	base.CompactVocabulary()
+
+	// Test case: An added document updates the statistics like a rebuild would
+	docID, err := base.AddDocument(bm25.Document{ID: "c", Text: "hello there"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if docID != 2 {
+		t.Errorf("Expected document ID 2, but got %d", docID)
+	}
+	expected, _ := bm25.NewBM25Base([]string{"hello world", "this is a test", "hello there"}, tokenizer, nil)
+	if base.CorpusSize() != expected.CorpusSize() || base.AvgDocLen() != expected.AvgDocLen() {
+		t.Errorf("Expected %d documents of average length %.2f, but got %d of %.2f", expected.CorpusSize(), expected.AvgDocLen(), base.CorpusSize(), base.AvgDocLen())
+	}
+	for _, term := range []string{"hello", "there", "test"} {
+		idf, _ := base.IDF(term)
+		expectedIDF, _ := expected.IDF(term)
+		if idf != expectedIDF {
+			t.Errorf("Expected IDF %.2f for '%s', but got %.2f", expectedIDF, term, idf)
+		}
+	}
+	if id, ok := base.LookupID("c"); !ok || id != 2 {
+		t.Errorf("Expected document ID 2 for 'c', but got %d (present: %v)", id, ok)
+	}
+
+	// Test case: Adding to a frozen index
+	_, err = base.Freeze().AddDocument(bm25.Document{Text: "late"})
+	if !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+}
+
+func TestSubscriptionManager(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world", "this is a test", "another document"}, tokenizer, 1.5, 0.75, nil)
+
+	manager, err := bm25.NewSubscriptionManager(okapi)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var matches []bm25.Match
+	ch := make(chan bm25.Match, 10)
+	manager.Subscribe("greetings", []string{"hello"}, 0, func(m bm25.Match) { matches = append(matches, m) })
+	manager.SubscribeChan("tests", []string{"test"}, 0, ch)
+
+	// Test case: Registering a duplicate name or an empty query
+	if err := manager.Subscribe("tests", []string{"x"}, 0, func(bm25.Match) {}); !errors.Is(err, bm25.ErrDuplicateSubscription) {
+		t.Errorf("Expected ErrDuplicateSubscription, but got %v", err)
+	}
+	if err := manager.Subscribe("empty", nil, 0, func(bm25.Match) {}); !errors.Is(err, bm25.ErrEmptyQuery) {
+		t.Errorf("Expected ErrEmptyQuery, but got %v", err)
+	}
+	if names := manager.Subscriptions(); !reflect.DeepEqual(names, []string{"greetings", "tests"}) {
+		t.Errorf("Expected [greetings tests], but got %v", names)
+	}
+
+	// Test case: Only the subscriptions matching an added document are notified
+	okapi.AddDocument(bm25.Document{ID: "new", Text: "hello again"})
+	if len(matches) != 1 || matches[0].Subscription != "greetings" || matches[0].DocID != 3 || matches[0].ExternalID != "new" || matches[0].Score <= 0 {
+		t.Errorf("Expected a match of document 3 for 'greetings', but got %+v", matches)
+	}
+	if len(ch) != 0 {
+		t.Errorf("Expected no match for 'tests', but got %d", len(ch))
+	}
+
+	// Test case: Matches are scored like a search
+	resp, err := okapi.Search(context.Background(), bm25.SearchRequest{Query: []string{"hello"}, N: 10})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	i := slices.IndexFunc(resp.Results, func(result bm25.SearchResult) bool { return result.DocID == 3 })
+	if i < 0 || math.Abs(resp.Results[i].Score-matches[0].Score) > 1e-9 {
+		t.Errorf("Expected the score of the search, but got %v for %+v", matches[0].Score, resp.Results)
+	}
+
+	okapi.AddDocument(bm25.Document{Text: "a test run"})
+	if len(ch) != 1 || (<-ch).DocID != 4 {
+		t.Errorf("Expected a match of document 4 for 'tests'")
+	}
+
+	// Test case: Unsubscribed queries are no longer notified
+	if !manager.Unsubscribe("greetings") || manager.Unsubscribe("greetings") {
+		t.Errorf("Expected the first unsubscribe to succeed and the second to fail")
+	}
+	okapi.AddDocument(bm25.Document{Text: "hello once more"})
+	if len(matches) != 1 {
+		t.Errorf("Expected no further matches, but got %d", len(matches))
+	}
+}
@@ -0,0 +1,57 @@
+package bm25_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestSubwordFallback(t *testing.T) {
+	corpus := []string{"running shoes for trail", "a guide to databases", "the runner finished"}
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.5, 0.75, nil)
+
+	// Test case: Queries are unchanged while the fallback is disabled
+	if query := okapi.ExpandQuery([]string{"runnning"}); !reflect.DeepEqual(query, []string{"runnning"}) {
+		t.Errorf("Expected the query to be unchanged, but got %v", query)
+	}
+
+	// Test case: Enabling the fallback with invalid options
+	if err := okapi.EnableSubwordFallback(bm25.SubwordOptions{MinSimilarity: 2}); err == nil {
+		t.Errorf("Expected an error for MinSimilarity 2, but got nil")
+	}
+
+	if err := okapi.EnableSubwordFallback(bm25.SubwordOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Out-of-vocabulary terms fall back to similar vocabulary terms
+	if terms := okapi.SubwordExpand("runnning"); !reflect.DeepEqual(terms, []string{"running"}) {
+		t.Errorf("Expected [running], but got %v", terms)
+	}
+	if terms := okapi.SubwordExpand("database"); !reflect.DeepEqual(terms, []string{"databases"}) {
+		t.Errorf("Expected [databases], but got %v", terms)
+	}
+	query := okapi.ExpandQuery([]string{"trail", "databse", "xyz"})
+	if !reflect.DeepEqual(query, []string{"trail", "databases"}) {
+		t.Errorf("Expected [trail databases], but got %v", query)
+	}
+
+	// Test case: Search retrieves documents for a misspelled query
+	resp, err := okapi.Search(context.Background(), bm25.SearchRequest{Query: []string{"databse"}, N: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Results[0].DocID != 1 || resp.Results[0].Score <= 0 {
+		t.Errorf("Expected document 1 to match, but got %+v", resp.Results)
+	}
+
+	// Test case: Added documents extend the vocabulary used for the fallback
+	okapi.AddDocument(bm25.Document{Text: "kubernetes clusters"})
+	if terms := okapi.SubwordExpand("kubernets"); !reflect.DeepEqual(terms, []string{"kubernetes"}) {
+		t.Errorf("Expected [kubernetes], but got %v", terms)
+	}
+}
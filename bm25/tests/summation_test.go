@@ -0,0 +1,117 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// sumTerms adds the scores of every query term in query order, optionally with Kahan
+// summation.
+func sumTerms(t *testing.T, okapi *bm25.BM25Okapi, query []string, kahan bool) []float64 {
+	t.Helper()
+	sums := make([]float64, okapi.CorpusSize())
+	compensation := make([]float64, okapi.CorpusSize())
+	for _, q := range query {
+		scores, err := okapi.GetScores([]string{q})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for i, score := range scores {
+			if !kahan {
+				sums[i] += score
+				continue
+			}
+			y := score - compensation[i]
+			sum := sums[i] + y
+			compensation[i] = (sum - sums[i]) - y
+			sums[i] = sum
+		}
+	}
+	return sums
+}
+
+func TestSummation(t *testing.T) {
+	var corpus []string
+	for i := 0; i < 50; i++ {
+		corpus = append(corpus, fmt.Sprintf("t%d t%d t%d t%d", i%7, i%11, i%13, i%3))
+	}
+	var query []string
+	for i := 0; i < 13; i++ {
+		query = append(query, fmt.Sprintf("t%d", i))
+	}
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.2, 0.75, nil)
+	ctx := context.Background()
+
+	for _, kahan := range []bool{false, true} {
+		summation := bm25.SumOrdered
+		if kahan {
+			summation = bm25.SumKahan
+		}
+		if err := okapi.SetSummation(summation); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := sumTerms(t, okapi, query, kahan)
+
+		// Test case: Searches return the same scores whatever the number of goroutines
+		for _, workers := range []int{1, 4, 13} {
+			resp, err := okapi.Search(ctx, bm25.SearchRequest{Query: query, N: len(corpus), Limits: bm25.SearchLimits{MaxGoroutines: workers}})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, result := range resp.Results {
+				if result.Score != expected[result.DocID] {
+					t.Errorf("Expected score %v for document %d with %d goroutines (kahan: %t), but got %v", expected[result.DocID], result.DocID, workers, kahan, result.Score)
+				}
+			}
+		}
+
+		// Test case: Parallel scoring adds the terms in query order
+		single := make([]float64, len(corpus))
+		var terms [][]float64
+		for _, q := range query {
+			scores, _ := okapi.GetScoresParallel([]string{q}, okapi)
+			terms = append(terms, scores)
+		}
+		for i := range single {
+			var compensation float64
+			for _, scores := range terms {
+				if !kahan {
+					single[i] += scores[i]
+					continue
+				}
+				y := scores[i] - compensation
+				sum := single[i] + y
+				compensation = (sum - single[i]) - y
+				single[i] = sum
+			}
+		}
+		for run := 0; run < 5; run++ {
+			scores, err := okapi.GetScoresParallel(query, okapi)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for i := range scores {
+				if scores[i] != single[i] {
+					t.Fatalf("Expected score %v for document %d (kahan: %t), but got %v", single[i], i, kahan, scores[i])
+				}
+			}
+		}
+	}
+
+	// Test case: Invalid summations and frozen indexes
+	if err := okapi.SetSummation(bm25.SumKahan + 1); err == nil {
+		t.Errorf("Expected an error for an unknown summation, but got nil")
+	}
+	frozen := okapi.Freeze()
+	if err := frozen.SetSummation(bm25.SumOrdered); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+	if frozen.Summation() != bm25.SumKahan {
+		t.Errorf("Expected the frozen copy to keep SumKahan, but got %v", frozen.Summation())
+	}
+}
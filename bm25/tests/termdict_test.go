@@ -0,0 +1,74 @@
+package bm25_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestTermDict(t *testing.T) {
+	dict := bm25.NewTermDict(map[string]int{"car": 3, "cart": 1, "cat": 2, "dog": 1})
+
+	// Test case: Checking the number of terms
+	if dict.Len() != 4 {
+		t.Errorf("Expected 4 terms, but got %d", dict.Len())
+	}
+
+	// Test case: Looking up a present and a missing term
+	if freq, ok := dict.Lookup("cat"); !ok || freq != 2 {
+		t.Errorf("Expected document frequency 2 for 'cat', but got %d (present: %v)", freq, ok)
+	}
+	if _, ok := dict.Lookup("ca"); ok {
+		t.Errorf("Expected 'ca' to be missing from the dictionary")
+	}
+
+	// Test case: Searching by prefix
+	terms := dict.PrefixSearch("car")
+	if !reflect.DeepEqual(terms, []string{"car", "cart"}) {
+		t.Errorf("Expected [car cart], but got %v", terms)
+	}
+	terms = dict.PrefixSearch("z")
+	if len(terms) != 0 {
+		t.Errorf("Expected no terms for prefix 'z', but got %v", terms)
+	}
+
+	// Test case: Searching by edit distance
+	terms = dict.FuzzySearch("cas", 1)
+	if !reflect.DeepEqual(terms, []string{"car", "cat"}) {
+		t.Errorf("Expected [car cat], but got %v", terms)
+	}
+	terms = dict.FuzzySearch("dig", 0)
+	if len(terms) != 0 {
+		t.Errorf("Expected no terms for an exact search of 'dig', but got %v", terms)
+	}
+}
+
+func TestCompactVocabulary(t *testing.T) {
+	corpus := []string{"hello world", "this is a test", "hello there"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	base, _ := bm25.NewBM25Base(corpus, tokenizer, nil)
+
+	before, _ := base.IDF("hello")
+	base.CompactVocabulary()
+
+	// Test case: IDF is unchanged after compacting the vocabulary
+	after, err := base.IDF("hello")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if before != after {
+		t.Errorf("Expected IDF %.2f after compaction, but got %.2f", before, after)
+	}
+
+	// Test case: Expanding a prefix and a misspelled term against the vocabulary
+	terms := base.ExpandPrefix("th")
+	if !reflect.DeepEqual(terms, []string{"there", "this"}) {
+		t.Errorf("Expected [there this], but got %v", terms)
+	}
+	terms = base.ExpandFuzzy("helo", 1)
+	if !reflect.DeepEqual(terms, []string{"hello"}) {
+		t.Errorf("Expected [hello], but got %v", terms)
+	}
+}
@@ -0,0 +1,67 @@
+package bm25_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestSetTermWeights(t *testing.T) {
+	corpus := []string{"cat sat mat", "dog ran far", "bird flew high", "fish swam deep"}
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	okapi, _ := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0.75, nil)
+	query := []string{"cat", "dog"}
+
+	before, _ := okapi.GetScores(query)
+	parallelBefore, _ := okapi.GetScoresParallel(query, okapi)
+
+	// Test case: Rejecting negative weights
+	err := okapi.SetTermWeights(map[string]float64{"cat": -1})
+	var invalid *bm25.ErrInvalidParam
+	if !errors.As(err, &invalid) {
+		t.Errorf("Expected ErrInvalidParam, but got %v", err)
+	}
+
+	// Test case: Weights scale the IDF of their terms only
+	if err := okapi.SetTermWeights(map[string]float64{"cat": 2, "dog": 0}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	after, _ := okapi.GetScores(query)
+	if math.Abs(after[0]-2*before[0]) > 1e-9 {
+		t.Errorf("Expected the score of 'cat' to double from %f, but got %f", before[0], after[0])
+	}
+	if after[1] != 0 {
+		t.Errorf("Expected a weight of 0 to disable 'dog', but got score %f", after[1])
+	}
+	idf, _ := okapi.IDF("cat")
+	before, _ = okapi.GetScores([]string{"cat"})
+	if idf <= 0 || before[0] == 0 {
+		t.Errorf("Expected IDF to stay unweighted, but got %f", idf)
+	}
+
+	// Test case: Weights apply to the parallel scorer and to clones
+	parallel, _ := okapi.GetScoresParallel(query, okapi)
+	if math.Abs(parallel[0]-2*parallelBefore[0]) > 1e-9 || parallel[1] != 0 {
+		t.Errorf("Expected weighted parallel scores, but got %v from %v", parallel, parallelBefore)
+	}
+	clone := okapi.Clone()
+	if w := clone.TermWeights(); w["cat"] != 2 {
+		t.Errorf("Expected the clone to keep the weights, but got %v", w)
+	}
+
+	// Test case: Frozen indexes reject new weights
+	if err := okapi.Freeze().SetTermWeights(nil); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+
+	// Test case: Clearing the weights
+	if err := okapi.SetTermWeights(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(okapi.TermWeights()) != 0 {
+		t.Errorf("Expected no weights, but got %v", okapi.TermWeights())
+	}
+}
@@ -0,0 +1,152 @@
+package bm25_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestNewTFIDF(t *testing.T) {
+	// Test case: Creating a new TFIDF instance on a nil base
+	if _, err := bm25.NewTFIDFFromBase(nil); err == nil {
+		t.Errorf("Expected an error for a nil base, but got nil")
+	}
+
+	// Test case: Creating a new TFIDF instance with an empty corpus
+	if _, err := bm25.NewTFIDF([]string{}, strings.Fields, nil); err == nil {
+		t.Errorf("Expected an error for an empty corpus, but got nil")
+	}
+}
+
+func TestTFIDFGetScores(t *testing.T) {
+	corpus := []string{"apple banana", "apple apple cherry", "banana cherry date", "elderberry fig"}
+	tfidf, err := bm25.NewTFIDF(corpus, strings.Fields, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: A document with the terms of the query in the same proportions scores 1
+	query := []string{"apple", "banana"}
+	scores, err := tfidf.GetScores(query)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(scores[0]-1) > 1e-9 {
+		t.Errorf("Expected a score of 1 for document 0, but got %v", scores[0])
+	}
+
+	// Test case: All scores are in [0, 1], and unrelated documents score 0
+	for i, score := range scores {
+		if score < 0 || score > 1 {
+			t.Errorf("Expected the score of document %d to be in [0, 1], but got %v", i, score)
+		}
+	}
+	if scores[3] != 0 {
+		t.Errorf("Expected a score of 0 for document 3, but got %v", scores[3])
+	}
+
+	// Test case: The cosine of a single matching term is its share of the document vector
+	scores, err = tfidf.GetScores([]string{"date"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	idf := func(df float64) float64 { return math.Log(5/(df+1)) + 1 }
+	want := idf(1) / math.Sqrt(idf(2)*idf(2)+idf(2)*idf(2)+idf(1)*idf(1))
+	if math.Abs(scores[2]-want) > 1e-9 {
+		t.Errorf("Expected a score of %v for document 2, but got %v", want, scores[2])
+	}
+
+	// Test case: Batch scores match the scores of the whole corpus
+	batch, err := tfidf.GetBatchScores([]string{"date"}, []int{2, 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch[0] != scores[2] || batch[1] != 0 {
+		t.Errorf("Expected batch scores %v, but got %v", []float64{scores[2], 0}, batch)
+	}
+}
+
+func TestTFIDFSearch(t *testing.T) {
+	corpus := []string{"apple banana", "apple apple cherry", "banana cherry date", "elderberry fig"}
+	tfidf, _ := bm25.NewTFIDF(corpus, strings.Fields, nil)
+	query := []string{"apple", "cherry"}
+	scores, err := tfidf.GetScores(query)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Searching normalizes every term by the whole query, so the scores are the
+	// cosines and the term scores of the explanations add up to them
+	resp, err := tfidf.Search(context.Background(), bm25.SearchRequest{Query: query, N: 4, Explain: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Results[0].DocID != 1 {
+		t.Errorf("Expected document 1 to rank first, but got %d", resp.Results[0].DocID)
+	}
+	for _, result := range resp.Results {
+		if math.Abs(result.Score-scores[result.DocID]) > 1e-9 {
+			t.Errorf("Expected document %d to score %v, but got %v", result.DocID, scores[result.DocID], result.Score)
+		}
+		var sum float64
+		for _, term := range result.Explanation.Terms {
+			sum += term.Score
+		}
+		if math.Abs(sum-result.Score) > 1e-9 {
+			t.Errorf("Expected the term scores of document %d to add up to %v, but got %v", result.DocID, result.Score, sum)
+		}
+	}
+}
+
+func TestTFIDFDocNormCache(t *testing.T) {
+	corpus := []string{"apple banana", "apple apple cherry", "banana cherry date", "elderberry fig"}
+	tfidf, _ := bm25.NewTFIDF(corpus, strings.Fields, nil)
+	query := []string{"apple", "cherry"}
+	if _, err := tfidf.GetScores(query); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// assertScores compares the full and batch scores of the index to those of a new index
+	// over the given corpus.
+	assertScores := func(index *bm25.TFIDF, corpus []string, saturation bm25.Saturation) {
+		t.Helper()
+		expected, _ := bm25.NewTFIDF(corpus, strings.Fields, nil)
+		expected.SetSaturation(saturation)
+		want, _ := expected.GetScores(query)
+		scores, err := index.GetScores(query)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		batch, err := index.GetBatchScores(query, []int{1, 2})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for i := range want {
+			if math.Abs(scores[i]-want[i]) > 1e-9 {
+				t.Errorf("Expected document %d to score %v, but got %v", i, want[i], scores[i])
+			}
+		}
+		if math.Abs(batch[0]-want[1]) > 1e-9 || math.Abs(batch[1]-want[2]) > 1e-9 {
+			t.Errorf("Expected batch scores %v, but got %v", want[1:3], batch)
+		}
+	}
+
+	// Test case: Adding a document changes the IDFs, so the cached norms are recomputed
+	if _, err := tfidf.AddDocument(bm25.Document{Text: "cherry cherry grape"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	corpus = append(corpus, "cherry cherry grape")
+	assertScores(tfidf, corpus, bm25.StandardSaturation)
+
+	// Test case: Changing the saturation recomputes the cached norms
+	if err := tfidf.SetSaturation(bm25.LogSaturation); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertScores(tfidf, corpus, bm25.LogSaturation)
+
+	// Test case: A frozen copy, which cannot populate its caches, scores like the original
+	assertScores(tfidf.Freeze(), corpus, bm25.LogSaturation)
+}
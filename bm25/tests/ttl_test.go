@@ -0,0 +1,103 @@
+package bm25_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestDocumentTTL(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world", "this is a test"}, tokenizer, 1.5, 0.75, nil)
+
+	// Test case: Adding a document with a negative TTL
+	_, err := okapi.AddDocument(bm25.Document{Text: "hello", TTL: -time.Second})
+	var paramErr *bm25.ErrInvalidParam
+	if !errors.As(err, &paramErr) {
+		t.Errorf("Expected ErrInvalidParam, but got %v", err)
+	}
+
+	expiring, _ := okapi.AddDocument(bm25.Document{ID: "cached", Text: "hello cache", TTL: time.Millisecond})
+	lasting, _ := okapi.AddDocument(bm25.Document{ID: "kept", Text: "hello there", TTL: time.Hour})
+	time.Sleep(5 * time.Millisecond)
+
+	// Test case: Expired documents are excluded from scoring and search results
+	if !okapi.Expired(expiring) || okapi.Expired(lasting) || okapi.Expired(0) {
+		t.Errorf("Expected only document %d to be expired", expiring)
+	}
+	scores, _ := okapi.GetScores([]string{"hello"})
+	if scores[expiring] != 0 || scores[lasting] <= 0 {
+		t.Errorf("Expected a zero score for the expired document, but got %v", scores)
+	}
+	batch, _ := okapi.GetBatchScores([]string{"hello"}, []int{expiring})
+	if batch[0] != 0 {
+		t.Errorf("Expected a zero batch score for the expired document, but got %v", batch)
+	}
+	parallel, _ := okapi.GetScoresParallel([]string{"hello"}, okapi)
+	batched, _ := okapi.GetScoresBatched([]string{"hello"}, okapi, 2)
+	if parallel[expiring] != 0 || batched[expiring] != 0 || parallel[lasting] <= 0 {
+		t.Errorf("Expected the parallel and batched scores to exclude the expired document, but got %v and %v", parallel, batched)
+	}
+	resp, _ := okapi.Search(context.Background(), bm25.SearchRequest{Query: []string{"hello"}, N: 10})
+	for _, result := range resp.Results {
+		if result.DocID == expiring {
+			t.Errorf("Expected the expired document to be excluded from the results")
+		}
+	}
+
+	// Test case: Compaction reclaims expired documents and renumbers the rest
+	removed, err := okapi.Compact()
+	if err != nil || removed != 1 {
+		t.Errorf("Expected 1 removed document, but got %d (error: %v)", removed, err)
+	}
+	expected, _ := bm25.NewBM25Okapi([]string{"hello world", "this is a test", "hello there"}, tokenizer, 1.5, 0.75, nil)
+	if okapi.CorpusSize() != 3 || okapi.AvgDocLen() != expected.AvgDocLen() {
+		t.Errorf("Expected 3 documents of average length %.2f, but got %d of %.2f", expected.AvgDocLen(), okapi.CorpusSize(), okapi.AvgDocLen())
+	}
+	idf, _ := okapi.IDF("hello")
+	expectedIDF, _ := expected.IDF("hello")
+	if idf != expectedIDF {
+		t.Errorf("Expected IDF %.4f after compaction, but got %.4f", expectedIDF, idf)
+	}
+	if docID, ok := okapi.LookupID("kept"); !ok || docID != 2 {
+		t.Errorf("Expected 'kept' to be renumbered to 2, but got %d (present: %v)", docID, ok)
+	}
+	if _, ok := okapi.LookupID("cached"); ok {
+		t.Errorf("Expected 'cached' to be removed")
+	}
+	if removed, _ := okapi.Compact(); removed != 0 {
+		t.Errorf("Expected nothing to compact, but got %d", removed)
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world", "this is a test"}, tokenizer, 1.5, 0.75, nil)
+	_, _ = okapi.AddDocument(bm25.Document{Text: "hello cache", TTL: time.Millisecond})
+	time.Sleep(5 * time.Millisecond)
+	expected, _ := okapi.GetScores([]string{"hello", "test"})
+
+	// Test case: Optimizing removes expired documents and keeps the scores
+	removed, err := okapi.Optimize()
+	if err != nil || removed != 1 || okapi.CorpusSize() != 2 {
+		t.Fatalf("Expected 1 removed document, but got %d (error: %v)", removed, err)
+	}
+	scores, _ := okapi.GetScores([]string{"hello", "test"})
+	if len(scores) != 2 || scores[0] <= 0 || scores[1] <= 0 || expected[2] != 0 {
+		t.Errorf("Expected scores for the remaining documents, but got %v", scores)
+	}
+
+	// Test case: Documents can still be added after optimizing
+	if _, err := okapi.AddDocument(bm25.Document{Text: "hello again"}); err != nil || okapi.CorpusSize() != 3 {
+		t.Errorf("Expected the document to be added, but got error %v", err)
+	}
+
+	// Test case: Frozen indexes cannot be optimized
+	if _, err := okapi.Freeze().Optimize(); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+}
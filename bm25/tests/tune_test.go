@@ -0,0 +1,86 @@
+package bm25_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestTune(t *testing.T) {
+	var corpus []string
+	for i := 0; i < 200; i++ {
+		corpus = append(corpus, fmt.Sprintf("doc%d term%d term%d", i, i%7, i%5))
+	}
+	okapi, _ := bm25.NewBM25Okapi(corpus, strings.Fields, 1.5, 0.75, nil)
+	queries := [][]string{{"term1", "term2"}, {"term3"}, {"doc5", "term4", "term6"}}
+	ctx := context.Background()
+
+	// Test case: Every setting is measured, and the fastest one chosen
+	opts := bm25.TuneOptions{Concurrency: []int{1, 2}, Goroutines: []int{1, 3}, Duration: 20 * time.Millisecond}
+	tuning, err := bm25.Tune(ctx, okapi.Freeze(), queries, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tuning.Measurements) != 4 || tuning.CorpusSize != 200 {
+		t.Fatalf("Expected 4 measurements of 200 documents, but got %+v", tuning)
+	}
+	for _, m := range tuning.Measurements {
+		if m.QPS <= 0 || m.P99 < m.P50 {
+			t.Errorf("Expected a positive throughput and ordered percentiles, but got %+v", m)
+		}
+		if m.QPS > tuning.Measurements[0].QPS && tuning.Concurrency == 1 && tuning.Goroutines == 1 {
+			t.Errorf("Expected the fastest setting to be chosen, but got %+v", tuning)
+		}
+	}
+
+	// Test case: The tuning survives a round trip and sets the default limits
+	var buf bytes.Buffer
+	if err := tuning.Write(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	restored, err := bm25.ReadTuning(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if restored.Concurrency != tuning.Concurrency || restored.Goroutines != tuning.Goroutines {
+		t.Errorf("Expected %+v, but got %+v", tuning, restored)
+	}
+	okapi.SetDefaultLimits(bm25.SearchLimits{MaxScoredDocs: 50})
+	if err := restored.Apply(okapi.Bm25Base); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if limits := okapi.DefaultLimits(); limits.MaxGoroutines != tuning.Goroutines || limits.MaxScoredDocs != 50 {
+		t.Errorf("Expected the tuned goroutines and the other limits kept, but got %+v", limits)
+	}
+	if opts := restored.AdmissionOptions(8); opts.MaxConcurrent != tuning.Concurrency || opts.MaxQueued != 8 {
+		t.Errorf("Expected the tuned concurrency, but got %+v", opts)
+	}
+
+	// Test case: Default limits apply to the searches leaving them at zero
+	resp, _ := okapi.Search(ctx, bm25.SearchRequest{Query: []string{"term1"}, N: 200})
+	if !resp.Truncated || len(resp.Results) != 50 {
+		t.Errorf("Expected 50 truncated results, but got %d (truncated: %t)", len(resp.Results), resp.Truncated)
+	}
+	resp, _ = okapi.Search(ctx, bm25.SearchRequest{Query: []string{"term1"}, N: 200, Limits: bm25.SearchLimits{MaxScoredDocs: 10}})
+	if len(resp.Results) != 10 {
+		t.Errorf("Expected the request limits to take precedence, but got %d results", len(resp.Results))
+	}
+
+	// Test case: Unreachable latency targets and invalid options
+	opts.MaxLatency = time.Nanosecond
+	if _, err := bm25.Tune(ctx, okapi.Freeze(), queries, opts); !errors.Is(err, bm25.ErrNoTuning) {
+		t.Errorf("Expected ErrNoTuning, but got %v", err)
+	}
+	if _, err := bm25.Tune(ctx, okapi.Freeze(), queries, bm25.TuneOptions{Goroutines: []int{0}}); err == nil {
+		t.Errorf("Expected an error for 0 goroutines, but got nil")
+	}
+	if _, err := bm25.ReadTuning(strings.NewReader(`{"concurrency": 0}`)); err == nil {
+		t.Errorf("Expected an error for a tuning without concurrency, but got nil")
+	}
+}
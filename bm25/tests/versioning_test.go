@@ -0,0 +1,138 @@
+package bm25_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestDocumentVersioning(t *testing.T) {
+	base, _ := bm25.NewBM25Base([]string{"hello world"}, strings.Fields, nil)
+	docID, _ := base.AddDocument(bm25.Document{ID: "a", Text: "old text"})
+
+	// Test case: Added documents are at version 1
+	if version, ok := base.Version("a"); !ok || version != 1 {
+		t.Errorf("Expected version 1, but got %d (%t)", version, ok)
+	}
+	if _, ok := base.Version("missing"); ok {
+		t.Errorf("Expected no version for a missing document")
+	}
+
+	// Test case: Updating at the current version replaces the document
+	newID, version, err := base.UpdateDocument(bm25.Document{ID: "a", Text: "new text"}, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if version != 2 || newID == docID {
+		t.Errorf("Expected version 2 under a new internal ID, but got %d and %d", version, newID)
+	}
+	if id, _ := base.LookupID("a"); id != newID || !base.Expired(docID) || base.ExternalID(docID) != "" {
+		t.Errorf("Expected the ID to point to the new document and the old one to be expired")
+	}
+
+	// Test case: Updating or deleting at a stale version is rejected
+	if _, _, err := base.UpdateDocument(bm25.Document{ID: "a", Text: "lost update"}, 1); !errors.Is(err, bm25.ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict, but got %v", err)
+	}
+	if err := base.DeleteDocument("a", 1); !errors.Is(err, bm25.ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict, but got %v", err)
+	}
+	if _, _, err := base.UpdateDocument(bm25.Document{ID: "b", Text: "missing"}, 3); !errors.Is(err, bm25.ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict for a missing document, but got %v", err)
+	}
+	if _, _, err := base.UpdateDocument(bm25.Document{Text: "no id"}, 0); !errors.Is(err, bm25.ErrMissingID) {
+		t.Errorf("Expected ErrMissingID, but got %v", err)
+	}
+
+	// Test case: A failed update keeps the current document
+	if _, _, err := base.UpdateDocument(bm25.Document{ID: "a", Text: "   "}, 2); !errors.Is(err, bm25.ErrEmptyDocument) {
+		t.Errorf("Expected ErrEmptyDocument, but got %v", err)
+	}
+	if id, ok := base.LookupID("a"); !ok || id != newID {
+		t.Errorf("Expected the document to be kept, but got %d (%t)", id, ok)
+	}
+
+	// Test case: Upserting a new document without an expected version
+	if _, version, err := base.UpdateDocument(bm25.Document{ID: "b", Text: "brand new"}, 0); err != nil || version != 1 {
+		t.Errorf("Expected version 1, but got %d (%v)", version, err)
+	}
+
+	// Test case: A document added again after being deleted continues its versions
+	if err := base.DeleteDocument("a", 2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := base.DeleteDocument("a", 0); !errors.Is(err, bm25.ErrDocumentNotFound) {
+		t.Errorf("Expected ErrDocumentNotFound, but got %v", err)
+	}
+	base.AddDocument(bm25.Document{ID: "a", Text: "again"})
+	if version, _ := base.Version("a"); version != 3 {
+		t.Errorf("Expected version 3, but got %d", version)
+	}
+
+	// Test case: Versions and deletions survive snapshots and compaction
+	var buf bytes.Buffer
+	if err := base.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	restored, err := bm25.ReadSnapshot(&buf, strings.Fields, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if removed, _ := restored.Compact(); removed != 2 {
+		t.Errorf("Expected the 2 replaced and deleted documents to be removed, but got %d", removed)
+	}
+	if version, _ := restored.Version("a"); version != 3 {
+		t.Errorf("Expected version 3 after restoring, but got %d", version)
+	}
+	if err := base.Freeze().DeleteDocument("a", 0); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+}
+
+func TestCopyOnWriteVersioning(t *testing.T) {
+	okapi, _ := bm25.NewBM25Okapi([]string{"hello world", "other text"}, strings.Fields, 1.5, 0.75, nil)
+	index, _ := bm25.NewCopyOnWriteIndex(okapi)
+	if _, err := index.Add(bm25.Document{ID: "doc", Text: "counter 0"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Test case: Of concurrent writers updating the same version, only one succeeds
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := index.UpdateDocument(bm25.Document{ID: "doc", Text: "counter 1"}, 1)
+			if err != nil && !errors.Is(err, bm25.ErrVersionConflict) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				succeeded++
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Errorf("Expected exactly one update to succeed, but got %d", succeeded)
+	}
+
+	// Test case: Deleted documents are excluded from searches
+	if err := index.DeleteDocument("doc", 2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, _ := index.Search(context.Background(), bm25.SearchRequest{Query: []string{"counter"}, N: 4})
+	for _, result := range resp.Results {
+		if result.Score > 0 {
+			t.Errorf("Expected no document to match, but got %+v", result)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package bm25_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestWarmup(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	base, _ := bm25.NewBM25Base([]string{"the quick brown fox", "the lazy dog", "a quick dog"}, tokenizer, nil)
+
+	// Test case: Warmup fills the IDF cache with the terms of the queries
+	before := base.MemStats().CacheBytes
+	if err := base.Warmup([][]string{{"quick", "dog"}, {"fox", ""}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if after := base.MemStats().CacheBytes; after <= before {
+		t.Errorf("Expected the IDF cache to grow, but got %d bytes before and %d after", before, after)
+	}
+
+	// Test case: Frozen copies keep the warmed caches, but cannot be warmed up
+	frozen := base.Freeze()
+	if frozen.MemStats().CacheBytes != base.MemStats().CacheBytes {
+		t.Errorf("Expected the frozen copy to share the warmed caches")
+	}
+	if err := frozen.Warmup([][]string{{"dog"}}); !errors.Is(err, bm25.ErrFrozen) {
+		t.Errorf("Expected ErrFrozen, but got %v", err)
+	}
+
+	// Test case: Warmup reads the documents of a lazily opened index
+	var buf bytes.Buffer
+	_ = base.WriteSnapshot(&buf)
+	data := append([]byte(nil), buf.Bytes()...)
+	data[len(data)-14] ^= 0xff
+	opened, err := bm25.OpenSnapshot(bytes.NewReader(data), tokenizer, nil, bm25.SnapshotOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := opened.Warmup(nil); !errors.Is(err, bm25.ErrInvalidSnapshot) {
+		t.Errorf("Expected the corrupted documents to be read, but got %v", err)
+	}
+}
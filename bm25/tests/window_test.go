@@ -0,0 +1,68 @@
+package bm25_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+func TestWindowedIndex(t *testing.T) {
+	tokenizer := func(s string) []string { return strings.Fields(s) }
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	// Test case: Creating an index without a window or document limit
+	if _, err := bm25.NewWindowedIndex(tokenizer, 1.5, 0.75, bm25.WindowOptions{}); err == nil {
+		t.Errorf("Expected an error without Window and MaxDocs, but got nil")
+	}
+
+	window, err := bm25.NewWindowedIndex(tokenizer, 1.5, 0.75, bm25.WindowOptions{Window: 24 * time.Hour, BucketSize: time.Hour, Now: clock})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	window.Add(bm25.Document{ID: "old", Text: "disk error on node1"}, now.Add(-30*time.Hour))
+	window.Add(bm25.Document{ID: "a", Text: "disk error on node2"}, now.Add(-20*time.Hour))
+	window.Add(bm25.Document{ID: "b", Text: "network timeout on node3"}, now.Add(-time.Hour))
+	window.Add(bm25.Document{ID: "c", Text: "disk full on node4"}, now)
+
+	// Test case: Documents older than the window are ignored
+	if window.CorpusSize() != 3 {
+		t.Errorf("Expected 3 documents in the window, but got %d", window.CorpusSize())
+	}
+	results, err := window.Search([]string{"disk", "error"}, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "a" || results[1].ID != "c" {
+		t.Errorf("Expected documents [a c], but got %+v", results)
+	}
+
+	// Test case: Documents expire as time passes, with their statistics
+	now = now.Add(5 * time.Hour)
+	results, _ = window.Search([]string{"disk"}, 10)
+	if len(results) != 1 || results[0].ID != "c" || window.CorpusSize() != 2 {
+		t.Errorf("Expected only document c to remain matching, but got %+v", results)
+	}
+	single, _ := bm25.NewBM25Okapi([]string{"network timeout on node3", "disk full on node4"}, tokenizer, 1.5, 0.75, nil)
+	scores, _ := single.GetScores([]string{"disk"})
+	if results[0].Score != scores[1] {
+		t.Errorf("Expected score %.4f from the statistics of the window, but got %.4f", scores[1], results[0].Score)
+	}
+
+	// Test case: The oldest documents are evicted above MaxDocs
+	limited, _ := bm25.NewWindowedIndex(tokenizer, 1.5, 0.75, bm25.WindowOptions{MaxDocs: 2, Now: clock})
+	limited.Add(bm25.Document{ID: "1", Text: "one"}, now.Add(-time.Minute))
+	limited.Add(bm25.Document{ID: "3", Text: "three"}, now.Add(time.Minute))
+	limited.Add(bm25.Document{ID: "2", Text: "two"}, now)
+	results, _ = limited.Search([]string{"one", "two", "three"}, 10)
+	if len(results) != 2 || limited.CorpusSize() != 2 {
+		t.Errorf("Expected 2 documents, but got %+v", results)
+	}
+	for _, result := range results {
+		if result.ID == "1" {
+			t.Errorf("Expected the oldest document to be evicted")
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package bm25
+
+import (
+	"context"
+	"log"
+	"math"
+)
+
+// TFIDF is a classic TF-IDF vector space model, which scores documents by the cosine
+// similarity of their term vectors to the vector of the query. Terms are weighted by
+// their frequency times the smoothed IDF ln((N+1)/(df+1)) + 1, so every score is in
+// [0, 1], and a document made of the query terms only, in the same proportions, scores
+// 1. It is mostly useful as a baseline in evaluations, or where scores comparable across
+// queries are needed.
+//
+// Cosine scores depend on the whole query, as they are normalized by the length of its
+// vector: searches normalize every term by the whole query, so the term scores of an
+// explanation add up to the cosine, while GetScores called with a single term normalizes
+// by that term only.
+type TFIDF struct {
+	*Bm25Base
+	query *tfidfQuery
+}
+
+// tfidfQuery holds the norms a TFIDF index is bound to for a search, see bindQuery.
+type tfidfQuery struct {
+	norm     float64
+	docNorms []float64
+}
+
+// NewTFIDF creates a new instance of the TFIDF struct.
+func NewTFIDF(corpus []string, tokenizer func(string) []string, logger *log.Logger) (*TFIDF, error) {
+	base, err := NewBM25Base(corpus, tokenizer, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTFIDFFromBase(base)
+}
+
+// NewTFIDFFromBase creates a new instance of the TFIDF struct on top of an existing Bm25Base.
+func NewTFIDFFromBase(base *Bm25Base) (*TFIDF, error) {
+	if base == nil {
+		return nil, ErrNilBase
+	}
+
+	return &TFIDF{Bm25Base: base}, nil
+}
+
+// TFIDFParamSpecs returns the specs of the parameters of the TFIDF variant, which has
+// none.
+func TFIDFParamSpecs() []ParamSpec {
+	return []ParamSpec{}
+}
+
+// Params returns the parameters of the index, which has none.
+func (t *TFIDF) Params() map[string]float64 {
+	return map[string]float64{}
+}
+
+// ParamSpecs returns the specs of the parameters of the index.
+func (t *TFIDF) ParamSpecs() []ParamSpec {
+	return TFIDFParamSpecs()
+}
+
+// withParams returns the index itself, as it has no parameters, see
+// SearchRequest.Params.
+func (t *TFIDF) withParams(params map[string]float64) (BM25, error) {
+	return t, nil
+}
+
+// bindQuery returns a copy of the index which normalizes the scores of every term by the
+// whole query, so the term scores of a search add up to the cosine.
+func (t *TFIDF) bindQuery(query []string) BM25 {
+	bound := *t
+	bound.query = &tfidfQuery{
+		norm:     t.queryNorm(query),
+		docNorms: t.docNorms(),
+	}
+	return &bound
+}
+
+// termIDF returns the smoothed IDF of a term, and false if the term is in no document.
+func (t *TFIDF) termIDF(term string) (float64, bool) {
+	docFreq, _ := t.docFreq(term)
+	if docFreq == 0 {
+		return 0, false
+	}
+	return math.Log(float64(t.corpusSize+1)/float64(docFreq+1)) + 1, true
+}
+
+// queryWeight returns the weight of one occurrence of a term in the query vector, and
+// false if the term does not count towards the vector.
+func (t *TFIDF) queryWeight(term string) (float64, bool) {
+	if t.isStopword(term) {
+		return 0, false
+	}
+	idf, ok := t.termIDF(term)
+	if !ok {
+		return 0, false
+	}
+	return idf * t.termWeight(term), true
+}
+
+// queryNorm returns the length of the vector of a query, in which repeated terms count
+// several times.
+func (t *TFIDF) queryNorm(query []string) float64 {
+	if t.query != nil {
+		return t.query.norm
+	}
+
+	weights := make(map[string]float64, len(query))
+	for _, q := range query {
+		if weight, ok := t.queryWeight(q); ok {
+			weights[q] += weight
+		}
+	}
+	var sum float64
+	for _, weight := range weights {
+		sum += weight * weight
+	}
+	return math.Sqrt(sum)
+}
+
+// docNorm returns the length of the vector of a document, given a map to count its terms
+// into.
+func (t *TFIDF) docNorm(docID int, counts map[string]float64) float64 {
+	clear(counts)
+	payloads := t.payloads[docID]
+	for i, token := range t.doc(docID) {
+		if payloads != nil {
+			counts[token] += payloads[i]
+		} else {
+			counts[token]++
+		}
+	}
+
+	var sum float64
+	for term, tf := range counts {
+		idf, _ := t.termIDF(term)
+		weight := t.saturate(tf) * idf
+		sum += weight * weight
+	}
+	return math.Sqrt(sum)
+}
+
+// docNorms returns the lengths of the vectors of all documents. They are computed once
+// per state of the corpus and cached, unless the caches are read-only.
+func (t *TFIDF) docNorms() []float64 {
+	if t.query != nil {
+		return t.query.docNorms
+	}
+	if t.tfidfNorms != nil {
+		return t.tfidfNorms
+	}
+
+	norms := make([]float64, t.corpusSize)
+	counts := make(map[string]float64)
+	for i := range norms {
+		norms[i] = t.docNorm(i, counts)
+	}
+	if t.cachesWritable() {
+		t.tfidfNorms = norms
+	}
+	return norms
+}
+
+// termScore returns the share of a term in the cosine of a document, given the weight of
+// the term in the query and the norms of both vectors.
+func (t *TFIDF) termScore(tf float64, idf float64, queryWeight float64, queryNorm float64, docNorm float64) float64 {
+	if tf == 0 || queryNorm == 0 || docNorm == 0 {
+		return 0
+	}
+	return queryWeight * tf * idf / (queryNorm * docNorm)
+}
+
+// GetScores returns the cosine similarities of the documents to the given query.
+func (t *TFIDF) GetScores(query []string) ([]float64, error) {
+	return t.GetScoresInto(nil, query)
+}
+
+// GetScoresInto is like GetScores, but stores the scores in dst and returns it. dst is
+// reused if it has the capacity for the whole corpus, so reusing it across queries avoids
+// allocating on the query path.
+func (t *TFIDF) GetScoresInto(dst []float64, query []string) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	scores := t.scoreBuffer(dst)
+	queryNorm := t.queryNorm(query)
+	var docNorms []float64
+	for _, q := range query {
+		weight, ok := t.queryWeight(q)
+		if !ok {
+			if !t.isStopword(q) {
+				t.logf(LogDebug, "Term '%s' is not in the corpus", q)
+			}
+			continue
+		}
+		if docNorms == nil {
+			docNorms = t.docNorms()
+		}
+		idf, _ := t.termIDF(q)
+
+		for i := range scores {
+			scores[i] += t.termScore(t.termFrequency(i, q), idf, weight, queryNorm, docNorms[i])
+		}
+	}
+
+	t.excludeExpired(scores, nil)
+	return scores, nil
+}
+
+// GetBatchScores returns the cosine similarities of a subset of documents to the given
+// query.
+func (t *TFIDF) GetBatchScores(query []string, docIDs []int) ([]float64, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if err := t.validateDocIDs(docIDs); err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(docIDs))
+	queryNorm := t.queryNorm(query)
+
+	// The norms of all documents are only worth computing if they can be cached
+	var norms []float64
+	if t.query != nil || t.tfidfNorms != nil || t.cachesWritable() {
+		norms = t.docNorms()
+	}
+	docNorms := make([]float64, len(docIDs))
+	counts := make(map[string]float64)
+	for i, docID := range docIDs {
+		if norms != nil {
+			docNorms[i] = norms[docID]
+		} else {
+			docNorms[i] = t.docNorm(docID, counts)
+		}
+	}
+	for _, q := range query {
+		weight, ok := t.queryWeight(q)
+		if !ok {
+			if !t.isStopword(q) {
+				t.logf(LogDebug, "Term '%s' is not in the corpus", q)
+			}
+			continue
+		}
+		idf, _ := t.termIDF(q)
+
+		for i, docID := range docIDs {
+			scores[i] += t.termScore(t.termFrequency(docID, q), idf, weight, queryNorm, docNorms[i])
+		}
+	}
+
+	t.excludeExpired(scores, docIDs)
+	return scores, nil
+}
+
+// GetTopN returns the top N documents for the given query.
+func (t *TFIDF) GetTopN(query []string, n int) ([]string, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if n <= 0 {
+		t.logf(LogQueries, "Invalid value for n: %d. Returning empty slice.", n)
+		return []string{}, nil
+	}
+
+	buf := scoreBuffers.Get().(*[]float64)
+	defer scoreBuffers.Put(buf)
+	scores, err := t.GetScoresInto(*buf, query)
+	if err != nil {
+		return nil, err
+	}
+	*buf = scores
+
+	topNIndices, err := TopNIndices(scores, n)
+	if err != nil {
+		return nil, err
+	}
+
+	topDocs := make([]string, len(topNIndices))
+	for i, idx := range topNIndices {
+		doc, err := t.docText(idx)
+		if err != nil {
+			return nil, err
+		}
+		topDocs[i] = doc
+	}
+
+	return topDocs, nil
+}
+
+// Search runs the given search request against the index.
+func (t *TFIDF) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	return t.search(ctx, t, req)
+}
+
+// SearchString searches the index for the top n documents matching a raw query text,
+// which is tokenized like the documents, see SearchRequest.Text.
+func (t *TFIDF) SearchString(ctx context.Context, query string, n int) (*SearchResponse, error) {
+	return t.Search(ctx, SearchRequest{Text: query, N: n})
+}
+
+// Clone returns a deep copy of the TFIDF instance.
+func (t *TFIDF) Clone() *TFIDF {
+	clone := *t
+	clone.Bm25Base = t.Bm25Base.Clone()
+	return &clone
+}
+
+// Freeze returns an immutable, read-only copy of the TFIDF instance that is safe for concurrent use.
+func (t *TFIDF) Freeze() *TFIDF {
+	frozen := *t
+	frozen.Bm25Base = t.Bm25Base.Freeze()
+	if !t.frozen && frozen.tfidfNorms == nil {
+		// The copy cannot populate its caches, so the norms are computed before it is shared
+		frozen.tfidfNorms = frozen.docNorms()
+	}
+	return &frozen
+}
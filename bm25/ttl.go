@@ -0,0 +1,151 @@
+package bm25
+
+import "time"
+
+// Expired reports whether the document with the given internal ID has outlived its TTL.
+// Expired documents are excluded from scoring until Compact removes them.
+func (b *Bm25Base) Expired(docID int) bool {
+	return b.expiredAt(docID, time.Now())
+}
+
+// expiredAt reports whether the document with the given internal ID has outlived its
+// TTL at the given time.
+func (b *Bm25Base) expiredAt(docID int, now time.Time) bool {
+	if docID < 0 || docID >= len(b.expiresAt) || b.expiresAt[docID].IsZero() {
+		return false
+	}
+	return !now.Before(b.expiresAt[docID])
+}
+
+// Compact removes the expired documents from the index and reclaims their storage. Their
+// terms and lengths are removed from the corpus statistics, which keep counting them
+// until then. Compacting renumbers the remaining documents, so internal IDs obtained
+// before are invalid afterwards; external IDs are kept. It returns the number of
+// removed documents.
+func (b *Bm25Base) Compact() (int, error) {
+	if b.frozen {
+		return 0, ErrFrozen
+	}
+
+	// The expired documents are decided once, so a document expiring while compacting is
+	// either removed from the corpus and the statistics, or kept in both
+	now := time.Now()
+	keep := make([]int, 0, b.corpusSize)
+	expired := make([]bool, b.corpusSize)
+	for docID := 0; docID < b.corpusSize; docID++ {
+		if b.expiredAt(docID, now) {
+			expired[docID] = true
+		} else {
+			keep = append(keep, docID)
+		}
+	}
+	removed := b.corpusSize - len(keep)
+	if removed == 0 {
+		return 0, nil
+	}
+	if len(keep) == 0 {
+		return 0, ErrEmptyCorpus
+	}
+
+	b.ensureTermFreqs()
+	for docID, isExpired := range expired {
+		if isExpired {
+			forEachDistinct(b.doc(docID), func(token string) {
+				if b.termFreqs[token]--; b.termFreqs[token] == 0 {
+					delete(b.termFreqs, token)
+				}
+			})
+		}
+	}
+
+	corpus := make([][]string, len(keep))
+	docLengths := make([]int, len(keep))
+	var externalIDs []string
+	var metadata []map[string]any
+	var expiresAt []time.Time
+	var payloads map[int][]float64
+	totalDocLen := 0
+	for i, docID := range keep {
+		corpus[i] = b.doc(docID)
+		docLengths[i] = b.docLengths[docID]
+		totalDocLen += docLengths[i]
+		if id := b.ExternalID(docID); id != "" {
+			externalIDs = growTo(externalIDs, i)
+			externalIDs[i] = id
+		}
+		if m := b.Metadata(docID); m != nil {
+			metadata = growTo(metadata, i)
+			metadata[i] = m
+		}
+		if docID < len(b.expiresAt) && !b.expiresAt[docID].IsZero() {
+			expiresAt = growTo(expiresAt, i)
+			expiresAt[i] = b.expiresAt[docID]
+		}
+		if p, ok := b.payloads[docID]; ok {
+			if payloads == nil {
+				payloads = make(map[int][]float64)
+			}
+			payloads[i] = p
+		}
+	}
+
+	b.corpus, b.docLengths = corpus, docLengths
+	b.lazy = nil
+	b.externalIDs, b.metadata, b.expiresAt = externalIDs, metadata, expiresAt
+	b.payloads = payloads
+	b.idIndex = nil
+	for docID, id := range externalIDs {
+		if id != "" {
+			if b.idIndex == nil {
+				b.idIndex = make(map[string]int)
+			}
+			b.idIndex[id] = docID
+		}
+	}
+	b.corpusSize = len(keep)
+	b.avgDocLen = float64(totalDocLen) / float64(b.corpusSize)
+	b.invalidateStats()
+
+	if b.logger != nil {
+		b.logger.Printf("Compacted %d expired documents, corpus size: %d", removed, b.corpusSize)
+	}
+	return removed, nil
+}
+
+// excludeExpired zeroes the scores of expired documents. The scores are indexed by
+// document ID, or by position in docIDs if it is not nil.
+func (b *Bm25Base) excludeExpired(scores []float64, docIDs []int) {
+	if len(b.expiresAt) == 0 {
+		return
+	}
+
+	for i := range scores {
+		docID := i
+		if docIDs != nil {
+			docID = docIDs[i]
+		}
+		if b.Expired(docID) {
+			scores[i] = 0
+		}
+	}
+}
+
+// growTo extends a sparse per-document slice with zero values so that index i is valid.
+func growTo[T any](values []T, i int) []T {
+	for len(values) <= i {
+		var zero T
+		values = append(values, zero)
+	}
+	return values
+}
+
+// forEachDistinct calls fn once for every distinct token of a document.
+func forEachDistinct(tokens []string, fn func(token string)) {
+	seen := make(map[string]struct{}, len(tokens))
+	for _, token := range tokens {
+		if _, ok := seen[token]; !ok {
+			seen[token] = struct{}{}
+			fn(token)
+		}
+	}
+}
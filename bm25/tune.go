@@ -0,0 +1,248 @@
+package bm25
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoTuning is returned by Tune when no setting meets the latency target.
+var ErrNoTuning = errors.New("no setting meets the latency target")
+
+// TuneOptions configures Tune. Zero values select the defaults.
+type TuneOptions struct {
+	// Concurrency lists the numbers of concurrent searches to measure. Defaults to the
+	// powers of 2 up to twice GOMAXPROCS.
+	Concurrency []int
+
+	// Goroutines lists the values of SearchLimits.MaxGoroutines to measure. Defaults to
+	// the powers of 2 up to GOMAXPROCS.
+	Goroutines []int
+
+	// Duration is the time spent measuring every setting. Defaults to 500ms.
+	Duration time.Duration
+
+	// N is the number of results of every search. Defaults to 10.
+	N int
+
+	// MaxLatency, if positive, excludes the settings whose 99th percentile latency
+	// exceeds it, so throughput is not bought with tail latency.
+	MaxLatency time.Duration
+}
+
+// TuneMeasurement is the throughput and latency measured for a setting.
+type TuneMeasurement struct {
+	Concurrency int           `json:"concurrency"`
+	Goroutines  int           `json:"goroutines"`
+	QPS         float64       `json:"qps"`
+	P50         time.Duration `json:"p50"`
+	P99         time.Duration `json:"p99"`
+}
+
+// Tuning is the setting chosen by Tune, with the measurements it was chosen from and the
+// environment they were taken in. It is saved with Write, so the measurement does not
+// have to be repeated on every start, and should be measured again if GOMAXPROCS or the
+// size of the corpus change significantly.
+type Tuning struct {
+	// Concurrency is the number of concurrent searches with the highest throughput, to
+	// be used as AdmissionOptions.MaxConcurrent, see AdmissionOptions.
+	Concurrency int `json:"concurrency"`
+
+	// Goroutines is the SearchLimits.MaxGoroutines with the highest throughput, see
+	// Apply.
+	Goroutines int `json:"goroutines"`
+
+	Measurements []TuneMeasurement `json:"measurements"`
+	GOMAXPROCS   int               `json:"gomaxprocs"`
+	CorpusSize   int               `json:"corpusSize"`
+	Time         time.Time         `json:"time"`
+}
+
+// Tune measures the search throughput of the index for every combination of the number
+// of concurrent searches and of goroutines per search, on the current hardware and with
+// the given sample queries, and chooses the combination with the highest throughput. Ties
+// go to the setting using fewer goroutines. The index is searched concurrently, so it must
+// be safe for concurrent use, e.g. a frozen copy or a CopyOnWriteIndex.
+func Tune(ctx context.Context, index BM25, queries [][]string, opts TuneOptions) (*Tuning, error) {
+	if len(queries) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	// Warm up the caches, so the first setting measured is not penalized
+	for _, query := range queries {
+		if _, err := index.Search(ctx, SearchRequest{Query: query, N: opts.N}); err != nil {
+			return nil, err
+		}
+	}
+
+	tuning := &Tuning{
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		CorpusSize: index.CorpusSize(),
+		Time:       time.Now(),
+	}
+	best := -1
+	for _, concurrency := range opts.Concurrency {
+		for _, goroutines := range opts.Goroutines {
+			m, err := measureSetting(ctx, index, queries, opts, concurrency, goroutines)
+			if err != nil {
+				return nil, err
+			}
+			tuning.Measurements = append(tuning.Measurements, m)
+
+			if opts.MaxLatency > 0 && m.P99 > opts.MaxLatency {
+				continue
+			}
+			if best < 0 || m.QPS > tuning.Measurements[best].QPS {
+				best = len(tuning.Measurements) - 1
+			}
+		}
+	}
+	if best < 0 {
+		return tuning, ErrNoTuning
+	}
+
+	tuning.Concurrency = tuning.Measurements[best].Concurrency
+	tuning.Goroutines = tuning.Measurements[best].Goroutines
+	return tuning, nil
+}
+
+// withDefaults returns the options with their zero values replaced by the defaults.
+func (o TuneOptions) withDefaults() (TuneOptions, error) {
+	procs := runtime.GOMAXPROCS(0)
+	if len(o.Concurrency) == 0 {
+		o.Concurrency = powersOfTwo(2 * procs)
+	}
+	if len(o.Goroutines) == 0 {
+		o.Goroutines = powersOfTwo(procs)
+	}
+	if o.Duration == 0 {
+		o.Duration = 500 * time.Millisecond
+	}
+	if o.N == 0 {
+		o.N = 10
+	}
+
+	for _, c := range o.Concurrency {
+		if c <= 0 {
+			return o, invalidParam("concurrency", c, "must be a positive integer")
+		}
+	}
+	for _, g := range o.Goroutines {
+		if g <= 0 {
+			return o, invalidParam("goroutines", g, "must be a positive integer")
+		}
+	}
+	if o.Duration < 0 {
+		return o, invalidParam("duration", o.Duration, "must be positive")
+	}
+	if o.N < 0 {
+		return o, invalidParam("n", o.N, "must be a positive integer")
+	}
+	return o, nil
+}
+
+// powersOfTwo returns the powers of 2 up to n, and n itself.
+func powersOfTwo(n int) []int {
+	var values []int
+	for v := 1; v < n; v *= 2 {
+		values = append(values, v)
+	}
+	return append(values, max(n, 1))
+}
+
+// measureSetting runs searches with the given number of concurrent searches and
+// goroutines per search for the duration of the options, cycling through the queries.
+func measureSetting(ctx context.Context, index BM25, queries [][]string, opts TuneOptions, concurrency int, goroutines int) (TuneMeasurement, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var next atomic.Int64
+	latencies := make([][]time.Duration, concurrency)
+	errs := make([]error, concurrency)
+	deadline := time.Now().Add(opts.Duration)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) && ctx.Err() == nil {
+				query := queries[int(next.Add(1)-1)%len(queries)]
+				searchStart := time.Now()
+				_, err := index.Search(ctx, SearchRequest{Query: query, N: opts.N, Limits: SearchLimits{MaxGoroutines: goroutines}})
+				if err != nil {
+					errs[w] = err
+					cancel()
+					return
+				}
+				latencies[w] = append(latencies[w], time.Since(searchStart))
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	if err := errors.Join(errs...); err != nil {
+		return TuneMeasurement{}, err
+	}
+
+	var all []time.Duration
+	for _, l := range latencies {
+		all = append(all, l...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	m := TuneMeasurement{Concurrency: concurrency, Goroutines: goroutines}
+	if len(all) > 0 {
+		m.QPS = float64(len(all)) / elapsed.Seconds()
+		m.P50 = all[(len(all)-1)*50/100]
+		m.P99 = all[(len(all)-1)*99/100]
+	}
+	return m, nil
+}
+
+// Apply sets the chosen number of goroutines as the default MaxGoroutines of the index,
+// see SetDefaultLimits, keeping its other default limits. If Tune measured a frozen copy,
+// the tuning has to be applied to the writable index it was taken from.
+func (t *Tuning) Apply(b *Bm25Base) error {
+	limits := b.DefaultLimits()
+	limits.MaxGoroutines = t.Goroutines
+	return b.SetDefaultLimits(limits)
+}
+
+// AdmissionOptions returns admission options running the chosen number of concurrent
+// searches, with the given queue length.
+func (t *Tuning) AdmissionOptions(maxQueued int) AdmissionOptions {
+	return AdmissionOptions{MaxConcurrent: t.Concurrency, MaxQueued: maxQueued}
+}
+
+// Write writes the tuning as JSON.
+func (t *Tuning) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(t)
+}
+
+// ReadTuning reads a tuning written by Write.
+func ReadTuning(r io.Reader) (*Tuning, error) {
+	var tuning Tuning
+	if err := json.NewDecoder(r).Decode(&tuning); err != nil {
+		return nil, err
+	}
+	if tuning.Concurrency <= 0 {
+		return nil, invalidParam("concurrency", tuning.Concurrency, "must be a positive integer")
+	}
+	if tuning.Goroutines <= 0 {
+		return nil, invalidParam("goroutines", tuning.Goroutines, "must be a positive integer")
+	}
+	return &tuning, nil
+}
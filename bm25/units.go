@@ -0,0 +1,115 @@
+package bm25
+
+import (
+	"maps"
+	"regexp"
+	"strings"
+)
+
+// numberPattern matches a number, optionally with thousands separators, followed by an
+// optional unit, e.g. "1,024", "3.5GB" or "50%".
+var numberPattern = regexp.MustCompile(`^([+-]?(?:\d{1,3}(?:,\d{3})+|\d+)(?:\.\d+)?)([\p{L}%]*)$`)
+
+// defaultUnits maps the spellings of common units to their canonical symbols.
+var defaultUnits = func() map[string]string {
+	units := make(map[string]string)
+	for symbol, spellings := range map[string][]string{
+		"kb":  {"kilobyte", "kilobytes", "kbyte", "kbytes"},
+		"mb":  {"megabyte", "megabytes", "mbyte", "mbytes"},
+		"gb":  {"gigabyte", "gigabytes", "gbyte", "gbytes"},
+		"tb":  {"terabyte", "terabytes", "tbyte", "tbytes"},
+		"kib": {"kibibyte", "kibibytes"},
+		"mib": {"mebibyte", "mebibytes"},
+		"gib": {"gibibyte", "gibibytes"},
+		"tib": {"tebibyte", "tebibytes"},
+		"mm":  {"millimeter", "millimeters", "millimetre", "millimetres"},
+		"cm":  {"centimeter", "centimeters", "centimetre", "centimetres"},
+		"m":   {"meter", "meters", "metre", "metres"},
+		"km":  {"kilometer", "kilometers", "kilometre", "kilometres"},
+		"ft":  {"foot", "feet"},
+		"mg":  {"milligram", "milligrams"},
+		"g":   {"gram", "grams"},
+		"kg":  {"kilogram", "kilograms", "kilo", "kilos"},
+		"lb":  {"lbs", "pound", "pounds"},
+		"oz":  {"ounce", "ounces"},
+		"ml":  {"milliliter", "milliliters", "millilitre", "millilitres"},
+		"l":   {"liter", "liters", "litre", "litres"},
+		"ms":  {"millisecond", "milliseconds", "msec"},
+		"s":   {"sec", "secs", "second", "seconds"},
+		"min": {"mins", "minute", "minutes"},
+		"h":   {"hr", "hrs", "hour", "hours"},
+		"hz":  {"hertz"},
+		"khz": {"kilohertz"},
+		"mhz": {"megahertz"},
+		"ghz": {"gigahertz"},
+		"w":   {"watt", "watts"},
+		"kw":  {"kilowatt", "kilowatts"},
+		"v":   {"volt", "volts"},
+		"mah": {"milliamphour", "milliamphours"},
+		"%":   {"percent", "pct"},
+	} {
+		units[symbol] = symbol
+		for _, spelling := range spellings {
+			units[spelling] = symbol
+		}
+	}
+	return units
+}()
+
+// DefaultUnits returns the units recognized by NumberUnitFilter by default, mapping their
+// lowercase spellings, e.g. "gigabytes", to their canonical symbols, e.g. "gb". Units are
+// only canonicalized, not converted into each other.
+func DefaultUnits() map[string]string {
+	return maps.Clone(defaultUnits)
+}
+
+// NumberUnitFilter returns a filter canonicalizing numbers and the units following them,
+// so technical and product texts match across formatting variants: thousands separators
+// and trailing zeros are removed from numbers, and a number is joined with its unit,
+// whether attached or in the next token, e.g. "3.50GB", "3.5 gb" and "3.5 gigabytes" all
+// become "3.5gb". The units map their lowercase spellings to their canonical symbols; if
+// nil, DefaultUnits are used.
+func NumberUnitFilter(units map[string]string) TokenFilter {
+	if units == nil {
+		units = defaultUnits
+	}
+
+	return func(tokens []string) []string {
+		normalized := tokens[:0]
+		for i := 0; i < len(tokens); i++ {
+			match := numberPattern.FindStringSubmatch(tokens[i])
+			if match == nil {
+				normalized = append(normalized, tokens[i])
+				continue
+			}
+
+			number := canonicalNumber(match[1])
+			unit := strings.ToLower(match[2])
+			switch symbol, ok := units[unit]; {
+			case ok:
+				unit = symbol
+			case unit != "":
+				// A number with an unknown suffix, e.g. a version or a model name
+				normalized = append(normalized, tokens[i])
+				continue
+			case i+1 < len(tokens):
+				if symbol, ok := units[strings.ToLower(tokens[i+1])]; ok {
+					unit = symbol
+					i++
+				}
+			}
+			normalized = append(normalized, number+unit)
+		}
+		return normalized
+	}
+}
+
+// canonicalNumber returns a number without thousands separators and trailing zeros of its
+// fraction. Leading zeros are kept, as they are often significant in codes and IDs.
+func canonicalNumber(number string) string {
+	number = strings.ReplaceAll(number, ",", "")
+	if strings.Contains(number, ".") {
+		number = strings.TrimSuffix(strings.TrimRight(number, "0"), ".")
+	}
+	return number
+}
@@ -1,7 +1,6 @@
 package bm25
 
 import (
-    "errors"
     "sort"
     "strings"
 )
@@ -9,15 +8,15 @@ import (
 // CountTermFreq counts the frequency of a term in a document using the provided tokenizer function.
 func CountTermFreq(term string, doc string, tokenizer func(string) []string) (int, error) {
     if term == "" {
-        return 0, errors.New("term cannot be empty")
+        return 0, ErrEmptyTerm
     }
 
     if doc == "" {
-        return 0, errors.New("document cannot be empty")
+        return 0, ErrEmptyDocument
     }
 
     if tokenizer == nil {
-        return 0, errors.New("tokenizer function cannot be nil")
+        return 0, ErrNilTokenizer
     }
 
     tokens := tokenizer(doc)
@@ -33,7 +32,7 @@ func CountTermFreq(term string, doc string, tokenizer func(string) []string) (in
 // TopNIndices returns the indices of the top N scores in the given slice.
 func TopNIndices(scores []float64, n int) ([]int, error) {
     if n <= 0 {
-        return nil, errors.New("n must be a positive integer")
+        return nil, invalidParam("n", n, "must be a positive integer")
     }
 
     indices := make([]int, len(scores))
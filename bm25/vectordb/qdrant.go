@@ -0,0 +1,200 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Defaults of the QdrantClient.
+const (
+	DefaultSparseVectorName = "bm25"
+	DefaultBatchSize        = 256
+)
+
+// QdrantClient writes BM25 sparse vectors to a Qdrant collection and runs hybrid queries
+// that fuse them with dense vectors. The collection must define a sparse vector with
+// the name SparseVector.
+type QdrantClient struct {
+	// BaseURL is the URL of the Qdrant REST API, e.g. http://localhost:6333.
+	BaseURL string
+
+	// Collection is the name of the collection.
+	Collection string
+
+	// SparseVector is the name of the sparse vector. Defaults to "bm25".
+	SparseVector string
+
+	// APIKey, if set, is sent in the api-key header.
+	APIKey string
+
+	// BatchSize is the maximum number of points per upsert request. Defaults to 256.
+	BatchSize int
+
+	// HTTPClient sends the requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// QdrantPoint is a point to upsert into a Qdrant collection.
+type QdrantPoint struct {
+	ID      uint64
+	Vector  SparseVector
+	Payload map[string]any
+}
+
+// QdrantHybridQuery is a hybrid query that fuses a sparse and a dense prefetch.
+type QdrantHybridQuery struct {
+	// Sparse is the BM25 vector of the query, see Encoder.EncodeQuery.
+	Sparse SparseVector
+
+	// Dense is the dense vector of the query. If empty, only the sparse vector is used.
+	Dense []float32
+
+	// DenseVector is the name of the dense vector in the collection, or empty for the
+	// default vector.
+	DenseVector string
+
+	// Limit is the number of results to return.
+	Limit int
+
+	// PrefetchLimit is the number of candidates fetched by every prefetch. Defaults to
+	// four times Limit.
+	PrefetchLimit int
+
+	// Fusion is the fusion method, "rrf" (the default) or "dbsf".
+	Fusion string
+}
+
+// Upsert writes the points to the collection in batches of BatchSize points, waiting
+// until each batch is applied.
+func (c *QdrantClient) Upsert(ctx context.Context, points []QdrantPoint) error {
+	batchSize := c.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	for start := 0; start < len(points); start += batchSize {
+		batch := points[start:min(start+batchSize, len(points))]
+		body := struct {
+			Points []map[string]any `json:"points"`
+		}{Points: make([]map[string]any, len(batch))}
+		for i, point := range batch {
+			body.Points[i] = map[string]any{
+				"id":      point.ID,
+				"vector":  map[string]SparseVector{c.sparseVector(): point.Vector},
+				"payload": point.Payload,
+			}
+		}
+
+		if err := doJSON(ctx, c.HTTPClient, http.MethodPut, c.url("points?wait=true"), c.header(), body, nil); err != nil {
+			return fmt.Errorf("upserting points %d to %d: %w", start, start+len(batch)-1, err)
+		}
+	}
+	return nil
+}
+
+// SyncIndex encodes every document of the index and upserts it, using the internal
+// document ID as point ID. The external ID of a document, if it has one, is stored in
+// the "external_id" payload field. It returns the number of upserted points.
+func (c *QdrantClient) SyncIndex(ctx context.Context, encoder *Encoder) (int, error) {
+	points := make([]QdrantPoint, 0, encoder.base.CorpusSize())
+	for docID := 0; docID < encoder.base.CorpusSize(); docID++ {
+		if encoder.base.Expired(docID) {
+			continue
+		}
+		vector, err := encoder.EncodeDocument(docID)
+		if err != nil {
+			return 0, err
+		}
+
+		point := QdrantPoint{ID: uint64(docID), Vector: vector}
+		if id := encoder.base.ExternalID(docID); id != "" {
+			point.Payload = map[string]any{"external_id": id}
+		}
+		points = append(points, point)
+	}
+
+	if err := c.Upsert(ctx, points); err != nil {
+		return 0, err
+	}
+	return len(points), nil
+}
+
+// qdrantQueryResponse is the response of the query endpoint.
+type qdrantQueryResponse struct {
+	Result struct {
+		Points []struct {
+			ID      json.RawMessage `json:"id"` // An unsigned integer or a UUID string
+			Score   float64         `json:"score"`
+			Payload map[string]any  `json:"payload"`
+		} `json:"points"`
+	} `json:"result"`
+}
+
+// HybridQuery runs a hybrid query, fusing the results of the sparse and the dense
+// prefetch on the server.
+func (c *QdrantClient) HybridQuery(ctx context.Context, q QdrantHybridQuery) ([]Result, error) {
+	if q.Limit <= 0 {
+		return nil, fmt.Errorf("limit must be a positive integer (got %d)", q.Limit)
+	}
+
+	prefetchLimit := q.PrefetchLimit
+	if prefetchLimit <= 0 {
+		prefetchLimit = 4 * q.Limit
+	}
+	fusion := q.Fusion
+	if fusion == "" {
+		fusion = "rrf"
+	}
+
+	prefetch := []map[string]any{{"query": q.Sparse, "using": c.sparseVector(), "limit": prefetchLimit}}
+	if len(q.Dense) > 0 {
+		dense := map[string]any{"query": q.Dense, "limit": prefetchLimit}
+		if q.DenseVector != "" {
+			dense["using"] = q.DenseVector
+		}
+		prefetch = append(prefetch, dense)
+	}
+	body := map[string]any{
+		"prefetch":     prefetch,
+		"query":        map[string]string{"fusion": fusion},
+		"limit":        q.Limit,
+		"with_payload": true,
+	}
+
+	var resp qdrantQueryResponse
+	if err := doJSON(ctx, c.HTTPClient, http.MethodPost, c.url("points/query"), c.header(), body, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, len(resp.Result.Points))
+	for i, point := range resp.Result.Points {
+		results[i] = Result{ID: strings.Trim(string(point.ID), `"`), Score: point.Score, Payload: point.Payload}
+	}
+	return results, nil
+}
+
+// sparseVector returns the name of the sparse vector.
+func (c *QdrantClient) sparseVector() string {
+	if c.SparseVector == "" {
+		return DefaultSparseVectorName
+	}
+	return c.SparseVector
+}
+
+// url returns the URL of an endpoint of the collection.
+func (c *QdrantClient) url(endpoint string) string {
+	return strings.TrimRight(c.BaseURL, "/") + "/collections/" + url.PathEscape(c.Collection) + "/" + endpoint
+}
+
+// header returns the headers sent with every request.
+func (c *QdrantClient) header() http.Header {
+	header := http.Header{}
+	if c.APIKey != "" {
+		header.Set("api-key", c.APIKey)
+	}
+	return header
+}
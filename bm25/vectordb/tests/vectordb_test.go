@@ -0,0 +1,194 @@
+package vectordb_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+	"github.com/iwilltry42/bm25-go/bm25/vectordb"
+)
+
+var corpus = []string{
+	"the quick brown fox",
+	"the lazy dog sleeps",
+	"quick thinking saves the day",
+}
+
+func newIndex(t *testing.T) (*bm25.BM25Okapi, *vectordb.Encoder) {
+	t.Helper()
+	tokenizer := func(s string) []string { return strings.Split(s, " ") }
+	okapi, err := bm25.NewBM25Okapi(corpus, tokenizer, 1.2, 0.75, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	encoder, err := vectordb.NewEncoder(okapi.Bm25Base, okapi, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return okapi, encoder
+}
+
+// dot returns the dot product of two sparse vectors.
+func dot(a, b vectordb.SparseVector) float64 {
+	var sum float64
+	for i, index := range a.Indices {
+		for j, other := range b.Indices {
+			if index == other {
+				sum += float64(a.Values[i]) * float64(b.Values[j])
+			}
+		}
+	}
+	return sum
+}
+
+func TestEncoder(t *testing.T) {
+	okapi, encoder := newIndex(t)
+	query := []string{"quick", "fox"}
+	scores, _ := okapi.GetScores(query)
+
+	// Test case: The dot product of the vectors is the BM25 score
+	queryVector := encoder.EncodeQuery(query)
+	for docID, score := range scores {
+		docVector, err := encoder.EncodeDocument(docID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := dot(queryVector, docVector); math.Abs(got-score) > 1e-5 {
+			t.Errorf("Expected dot product %f for document %d, but got %f", score, docID, got)
+		}
+	}
+
+	// Test case: Indices are sorted
+	docVector, _ := encoder.EncodeDocument(0)
+	for i := 1; i < len(docVector.Indices); i++ {
+		if docVector.Indices[i-1] >= docVector.Indices[i] {
+			t.Errorf("Expected ascending indices, but got %v", docVector.Indices)
+		}
+	}
+
+	// Test case: Dictionary vocabulary skips unknown terms
+	dictEncoder, _ := vectordb.NewEncoder(okapi.Bm25Base, okapi, vectordb.DictVocabulary{Dict: okapi.Vocabulary()})
+	if v := dictEncoder.EncodeQuery([]string{"fox", "unknown"}); len(v.Indices) != 1 {
+		t.Errorf("Expected one known term, but got %v", v)
+	}
+
+	// Test case: Invalid document ID
+	if _, err := encoder.EncodeDocument(5); err == nil {
+		t.Errorf("Expected an error for an invalid document ID")
+	}
+}
+
+func TestQdrantClient(t *testing.T) {
+	_, encoder := newIndex(t)
+
+	var mu sync.Mutex
+	var upserts [][]map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("api-key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/collections/docs/points":
+			var req struct {
+				Points []map[string]any `json:"points"`
+			}
+			json.Unmarshal(body, &req)
+			mu.Lock()
+			upserts = append(upserts, req.Points)
+			mu.Unlock()
+			w.Write([]byte(`{"status": "ok"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/collections/docs/points/query":
+			if !strings.Contains(string(body), `"fusion":"rrf"`) || !strings.Contains(string(body), `"using":"bm25"`) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"result": {"points": [{"id": 1000000, "score": 0.9, "payload": {"external_id": "a"}}, {"id": "5c56c793-69f3-4fbf-87e6-c4bf54c28c26", "score": 0.5}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := &vectordb.QdrantClient{BaseURL: server.URL, Collection: "docs", APIKey: "secret", BatchSize: 2}
+
+	// Test case: Syncing the index in batches
+	n, err := client.SyncIndex(context.Background(), encoder)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n != 3 || len(upserts) != 2 || len(upserts[0]) != 2 || len(upserts[1]) != 1 {
+		t.Errorf("Expected 3 points in 2 batches, but got %d points in %v", n, upserts)
+	}
+
+	// Test case: Hybrid query with a dense vector
+	results, err := client.HybridQuery(context.Background(), vectordb.QdrantHybridQuery{
+		Sparse: encoder.EncodeQuery([]string{"quick"}),
+		Dense:  []float32{0.1, 0.2},
+		Limit:  2,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "1000000" || results[1].ID != "5c56c793-69f3-4fbf-87e6-c4bf54c28c26" {
+		t.Errorf("Unexpected results %v", results)
+	}
+
+	// Test case: Errors are reported with the status
+	client.APIKey = "wrong"
+	if _, err := client.HybridQuery(context.Background(), vectordb.QdrantHybridQuery{Limit: 1}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected an unauthorized error, but got %v", err)
+	}
+}
+
+func TestWeaviateClient(t *testing.T) {
+	okapi, _ := newIndex(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/v1/graphql" || !strings.Contains(req.Query, `valueText: ["0","2"]`) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data": {"Get": {"Doc": [
+			{"docId": "2", "_additional": {"distance": 0.1}},
+			{"docId": "0", "_additional": {"distance": 0.6}}
+		]}}}`))
+	}))
+	defer server.Close()
+
+	client := &vectordb.WeaviateClient{BaseURL: server.URL, Class: "Doc"}
+	candidates := []bm25.Candidate{{DocID: 0, Score: 2}, {DocID: 2, Score: 1}}
+
+	// Test case: Pure BM25 keeps the BM25 ranking
+	results, err := client.HybridQuery(context.Background(), okapi.Bm25Base, vectordb.WeaviateHybridQuery{Candidates: candidates, Alpha: 0, Limit: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if results[0].ID != "0" {
+		t.Errorf("Expected document 0 first, but got %v", results)
+	}
+
+	// Test case: Weighting the vector search reorders the candidates
+	results, _ = client.HybridQuery(context.Background(), okapi.Bm25Base, vectordb.WeaviateHybridQuery{Candidates: candidates, Alpha: 0.75, Limit: 1})
+	if len(results) != 1 || results[0].ID != "2" {
+		t.Errorf("Expected document 2 first, but got %v", results)
+	}
+
+	// Test case: Invalid class names are rejected
+	client.Class = "Doc { }"
+	if _, err := client.HybridQuery(context.Background(), okapi.Bm25Base, vectordb.WeaviateHybridQuery{Candidates: candidates, Limit: 1}); err == nil {
+		t.Errorf("Expected an error for an invalid class name")
+	}
+}
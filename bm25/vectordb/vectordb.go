@@ -0,0 +1,143 @@
+// Package vectordb combines BM25 with the dense retrieval of vector databases. It encodes
+// documents and queries as BM25 sparse vectors for Qdrant hybrid queries, and fuses BM25
+// candidates with the vector scores of Weaviate.
+package vectordb
+
+import (
+	"hash/fnv"
+	"sort"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// SparseVector is a sparse vector in the layout vector databases expect: parallel lists
+// of dimensions, in ascending order, and their values.
+type SparseVector struct {
+	Indices []uint32  `json:"indices"`
+	Values  []float32 `json:"values"`
+}
+
+// Result is a document returned by a hybrid query.
+type Result struct {
+	ID      string
+	Score   float64
+	Payload map[string]any
+}
+
+// Vocabulary maps terms to the dimensions of sparse vectors.
+type Vocabulary interface {
+	Index(term string) (uint32, bool)
+}
+
+// HashVocabulary maps every term to its 32-bit FNV-1a hash. It needs no state and stays
+// stable across processes and index updates; the rare hash collisions add up the
+// weights of the colliding terms.
+type HashVocabulary struct{}
+
+// Index returns the hash of the term.
+func (HashVocabulary) Index(term string) (uint32, bool) {
+	h := fnv.New32a()
+	h.Write([]byte(term))
+	return h.Sum32(), true
+}
+
+// DictVocabulary maps terms to their position in a TermDict, e.g. the vocabulary of the
+// index. Positions shift when terms are added, so the vectors have to be synced again
+// after the vocabulary changes.
+type DictVocabulary struct {
+	Dict *bm25.TermDict
+}
+
+// Index returns the position of the term in the dictionary.
+func (v DictVocabulary) Index(term string) (uint32, bool) {
+	i, ok := v.Dict.Lookup(term)
+	return uint32(i), ok
+}
+
+// Encoder encodes the documents of an index and queries as sparse vectors whose dot
+// product is the Okapi BM25 score of the document for the query.
+type Encoder struct {
+	base  *bm25.Bm25Base
+	index bm25.BM25
+	vocab Vocabulary
+	k1, b float64
+}
+
+// NewEncoder creates an Encoder for the documents of base, using the IDF and the k1 and b
+// parameters of index, which must be built on base. If vocab is nil, HashVocabulary is
+// used.
+func NewEncoder(base *bm25.Bm25Base, index bm25.BM25, vocab Vocabulary) (*Encoder, error) {
+	if base == nil || index == nil {
+		return nil, bm25.ErrNilBase
+	}
+	if vocab == nil {
+		vocab = HashVocabulary{}
+	}
+
+	e := &Encoder{base: base, index: index, vocab: vocab}
+	for _, spec := range bm25.BM25OkapiParamSpecs() {
+		value, ok := index.Params()[spec.Name]
+		if !ok {
+			value = spec.Default
+		}
+		switch spec.Name {
+		case "k1":
+			e.k1 = value
+		case "b":
+			e.b = value
+		}
+	}
+	return e, nil
+}
+
+// EncodeDocument returns the sparse vector of a document, weighting every term with its
+// IDF and its saturated, length-normalized frequency.
+func (e *Encoder) EncodeDocument(docID int) (SparseVector, error) {
+	termFreqs, err := e.base.TermFrequencies(docID)
+	if err != nil {
+		return SparseVector{}, err
+	}
+
+	docLen := float64(e.base.DocLengths()[docID])
+	k := e.k1 * (1 - e.b + e.b*docLen/e.base.AvgDocLen())
+	weights := make(map[uint32]float64, len(termFreqs))
+	for term, termFreq := range termFreqs {
+		index, ok := e.vocab.Index(term)
+		if !ok {
+			continue
+		}
+		idf, err := e.index.IDF(term)
+		if err != nil {
+			return SparseVector{}, err
+		}
+		tf := float64(termFreq)
+		weights[index] += idf * tf * (e.k1 + 1) / (tf + k)
+	}
+	return newSparseVector(weights), nil
+}
+
+// EncodeQuery returns the sparse vector of a tokenized query, counting every term.
+func (e *Encoder) EncodeQuery(query []string) SparseVector {
+	weights := make(map[uint32]float64, len(query))
+	for _, term := range query {
+		if index, ok := e.vocab.Index(term); ok {
+			weights[index]++
+		}
+	}
+	return newSparseVector(weights)
+}
+
+// newSparseVector converts weights into a SparseVector, dropping zero weights.
+func newSparseVector(weights map[uint32]float64) SparseVector {
+	v := SparseVector{Indices: make([]uint32, 0, len(weights)), Values: make([]float32, 0, len(weights))}
+	for index, weight := range weights {
+		if weight != 0 {
+			v.Indices = append(v.Indices, index)
+		}
+	}
+	sort.Slice(v.Indices, func(i, j int) bool { return v.Indices[i] < v.Indices[j] })
+	for _, index := range v.Indices {
+		v.Values = append(v.Values, float32(weights[index]))
+	}
+	return v
+}
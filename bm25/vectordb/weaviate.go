@@ -0,0 +1,204 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iwilltry42/bm25-go/bm25"
+)
+
+// DefaultIDProperty is the default property of Weaviate objects holding the document ID.
+const DefaultIDProperty = "docId"
+
+// graphQLName matches the class and property names that can be used in a query.
+var graphQLName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// WeaviateClient fuses BM25 candidates with the vector search of a Weaviate class.
+// Weaviate ranks keyword matches with its own BM25 implementation, so instead of
+// pushing vectors, the client restricts a vector search to the candidates of this
+// library and fuses both scores, like the relative score fusion of Weaviate.
+type WeaviateClient struct {
+	// BaseURL is the URL of the Weaviate REST API, e.g. http://localhost:8080.
+	BaseURL string
+
+	// Class is the name of the class.
+	Class string
+
+	// IDProperty is the text property holding the document ID: the external ID of the
+	// document, or its internal ID if it has none. Defaults to "docId".
+	IDProperty string
+
+	// APIKey, if set, is sent as bearer token.
+	APIKey string
+
+	// HTTPClient sends the requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// WeaviateHybridQuery is a hybrid query fusing BM25 candidates with a vector search.
+type WeaviateHybridQuery struct {
+	// Candidates are the BM25 results, e.g. from Retrieve or Rescore.
+	Candidates []bm25.Candidate
+
+	// Vector is the dense vector of the query.
+	Vector []float32
+
+	// Alpha weights the vector score against the BM25 score, from pure BM25 (0) to pure
+	// vector search (1).
+	Alpha float64
+
+	// Limit is the number of results to return.
+	Limit int
+}
+
+// weaviateResponse is the response of the GraphQL endpoint.
+type weaviateResponse struct {
+	Data struct {
+		Get map[string][]map[string]any `json:"Get"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// HybridQuery fetches the vector distances of the candidates and returns them ranked by
+// the fused score: both score lists are scaled to [0, 1] and combined with weight Alpha.
+// Candidates missing from Weaviate only keep their BM25 score. The documents of the
+// candidates are identified by base.
+func (c *WeaviateClient) HybridQuery(ctx context.Context, base *bm25.Bm25Base, q WeaviateHybridQuery) ([]Result, error) {
+	if q.Limit <= 0 {
+		return nil, fmt.Errorf("limit must be a positive integer (got %d)", q.Limit)
+	}
+	if q.Alpha < 0 || q.Alpha > 1 {
+		return nil, fmt.Errorf("alpha must be between 0 and 1 (got %v)", q.Alpha)
+	}
+	if len(q.Candidates) == 0 {
+		return []Result{}, nil
+	}
+
+	ids := make([]string, len(q.Candidates))
+	for i, candidate := range q.Candidates {
+		ids[i] = documentID(base, candidate.DocID)
+	}
+
+	similarities, err := c.vectorSimilarities(ctx, ids, q.Vector)
+	if err != nil {
+		return nil, err
+	}
+
+	keyword := make([]float64, len(ids))
+	vector := make([]float64, len(ids))
+	for i, candidate := range q.Candidates {
+		keyword[i] = candidate.Score
+		vector[i] = similarities[ids[i]]
+	}
+	scaleMinMax(keyword)
+	scaleMinMax(vector)
+
+	results := make([]Result, len(ids))
+	for i, id := range ids {
+		results[i] = Result{
+			ID:    id,
+			Score: (1-q.Alpha)*keyword[i] + q.Alpha*vector[i],
+			Payload: map[string]any{
+				"bm25_score": q.Candidates[i].Score,
+			},
+		}
+		if similarity, ok := similarities[id]; ok {
+			results[i].Payload["vector_similarity"] = similarity
+		}
+	}
+	sortResults(results)
+	return results[:min(q.Limit, len(results))], nil
+}
+
+// vectorSimilarities runs a vector search restricted to the given document IDs and
+// returns the cosine similarity of every document found.
+func (c *WeaviateClient) vectorSimilarities(ctx context.Context, ids []string, vector []float32) (map[string]float64, error) {
+	property := c.IDProperty
+	if property == "" {
+		property = DefaultIDProperty
+	}
+	if !graphQLName.MatchString(c.Class) || !graphQLName.MatchString(property) {
+		return nil, fmt.Errorf("invalid class %q or ID property %q", c.Class, property)
+	}
+
+	vectorJSON, _ := json.Marshal(vector)
+	idsJSON, _ := json.Marshal(ids)
+	query := fmt.Sprintf(
+		`{ Get { %s(nearVector: {vector: %s}, where: {path: ["%s"], operator: ContainsAny, valueText: %s}, limit: %d) { %s _additional { distance } } } }`,
+		c.Class, vectorJSON, property, idsJSON, len(ids), property,
+	)
+
+	header := http.Header{}
+	if c.APIKey != "" {
+		header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	var resp weaviateResponse
+	endpoint := strings.TrimRight(c.BaseURL, "/") + "/v1/graphql"
+	if err := doJSON(ctx, c.HTTPClient, http.MethodPost, endpoint, header, map[string]string{"query": query}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		messages := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			messages[i] = e.Message
+		}
+		return nil, errors.New("weaviate: " + strings.Join(messages, "; "))
+	}
+
+	similarities := make(map[string]float64)
+	for _, object := range resp.Data.Get[c.Class] {
+		id, _ := object[property].(string)
+		additional, _ := object["_additional"].(map[string]any)
+		distance, ok := additional["distance"].(float64)
+		if id != "" && ok {
+			similarities[id] = 1 - distance
+		}
+	}
+	return similarities, nil
+}
+
+// documentID returns the ID under which a document is stored: its external ID, or its
+// internal ID if it has none.
+func documentID(base *bm25.Bm25Base, docID int) string {
+	if id := base.ExternalID(docID); id != "" {
+		return id
+	}
+	return strconv.Itoa(docID)
+}
+
+// scaleMinMax scales the scores linearly to the range [0, 1]. Equal scores become 1, or
+// stay 0 if they are all zero.
+func scaleMinMax(scores []float64) {
+	lo, hi := scores[0], scores[0]
+	for _, score := range scores {
+		lo, hi = min(lo, score), max(hi, score)
+	}
+	for i, score := range scores {
+		switch {
+		case hi > lo:
+			scores[i] = (score - lo) / (hi - lo)
+		case hi != 0:
+			scores[i] = 1
+		}
+	}
+}
+
+// sortResults sorts results by descending score, breaking ties by ID.
+func sortResults(results []Result) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+}
@@ -0,0 +1,171 @@
+package bm25
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrVersionConflict is returned when a document is updated or deleted with an
+	// expected version that is not its current version, i.e. another writer changed it
+	// since it was read.
+	ErrVersionConflict = errors.New("document version conflict")
+
+	// ErrDocumentNotFound is returned when a document to be deleted does not exist.
+	ErrDocumentNotFound = errors.New("document not found")
+)
+
+// Version returns the version of the document with the given external ID. A document
+// is at version 1 when it is added, and every UpdateDocument increments its version.
+// Versions are kept when a document is deleted, so a document added again under the same
+// ID continues from the version of the deleted one and a stale writer cannot mistake it
+// for the document it read.
+func (b *Bm25Base) Version(id string) (uint64, bool) {
+	if _, ok := b.idIndex[id]; !ok {
+		return 0, false
+	}
+	if version, ok := b.versions[id]; ok {
+		return version, true
+	}
+	return 1, true
+}
+
+// UpdateDocument replaces the document with the external ID of doc, which is required,
+// or adds it if there is none. If expected is not 0, the document is only replaced if
+// it is at the expected version, and ErrVersionConflict is returned otherwise, so
+// concurrent writers do not overwrite each other's changes. It returns the internal ID
+// and the version of the new document.
+//
+// The replaced document is excluded from scoring like an expired one, and its terms
+// count towards the corpus statistics until Compact removes it.
+func (b *Bm25Base) UpdateDocument(doc Document, expected uint64) (int, uint64, error) {
+	if b.frozen {
+		return 0, 0, ErrFrozen
+	}
+
+	totalDocLen := b.avgDocLen * float64(b.corpusSize)
+	docID, version, err := b.updateDocument(doc, expected)
+	if err != nil {
+		return 0, 0, err
+	}
+	b.commitDocuments(totalDocLen, []int{docID})
+	return docID, version, nil
+}
+
+// updateDocument replaces or adds a document like UpdateDocument, without committing it,
+// see addDocument.
+func (b *Bm25Base) updateDocument(doc Document, expected uint64) (int, uint64, error) {
+	doc, err := b.ingest(doc)
+	if err != nil {
+		return 0, 0, err
+	}
+	if doc.ID == "" {
+		return 0, 0, ErrMissingID
+	}
+
+	current, exists := b.Version(doc.ID)
+	if expected != 0 && expected != current {
+		return 0, 0, versionConflict(doc.ID, current, expected)
+	}
+
+	// The ID is released for the new document, and only removed for good once the new
+	// document has been added
+	oldID := b.idIndex[doc.ID]
+	delete(b.idIndex, doc.ID)
+	docID, err := b.indexDocument(doc)
+	if err != nil {
+		if exists {
+			b.idIndex[doc.ID] = oldID
+		}
+		return 0, 0, err
+	}
+
+	if exists {
+		b.tombstone(oldID)
+		b.setVersion(doc.ID, current+1)
+	}
+	version, _ := b.Version(doc.ID)
+	return docID, version, nil
+}
+
+// DeleteDocument deletes the document with the given external ID. If expected is not 0,
+// the document is only deleted if it is at the expected version, and ErrVersionConflict
+// is returned otherwise. The deleted document is excluded from scoring like an expired
+// one, and its terms count towards the corpus statistics until Compact removes it.
+func (b *Bm25Base) DeleteDocument(id string, expected uint64) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+	return b.deleteDocument(id, expected)
+}
+
+// deleteDocument deletes a document like DeleteDocument.
+func (b *Bm25Base) deleteDocument(id string, expected uint64) error {
+	current, exists := b.Version(id)
+	if !exists {
+		return fmt.Errorf("%w: %q", ErrDocumentNotFound, id)
+	}
+	if expected != 0 && expected != current {
+		return versionConflict(id, current, expected)
+	}
+
+	docID := b.idIndex[id]
+	delete(b.idIndex, id)
+	b.tombstone(docID)
+	b.setVersion(id, current)
+	return nil
+}
+
+// tombstone expires a document immediately and detaches it from its external ID, which
+// can then be used by another document.
+func (b *Bm25Base) tombstone(docID int) {
+	b.externalIDs[docID] = ""
+	b.expiresAt = growTo(b.expiresAt, docID)
+	b.expiresAt[docID] = time.Now()
+}
+
+// setVersion records the version of the document with the given external ID.
+func (b *Bm25Base) setVersion(id string, version uint64) {
+	if b.versions == nil {
+		b.versions = make(map[string]uint64)
+	}
+	b.versions[id] = version
+}
+
+// versionConflict returns the error of a compare-and-set with a stale version.
+func versionConflict(id string, current uint64, expected uint64) error {
+	if current == 0 {
+		return fmt.Errorf("%w: document %q does not exist, expected version %d", ErrVersionConflict, id, expected)
+	}
+	return fmt.Errorf("%w: document %q is at version %d, expected version %d", ErrVersionConflict, id, current, expected)
+}
+
+// UpdateDocument replaces a document with compare-and-set semantics, see
+// Bm25Base.UpdateDocument, and publishes the change. Writers are serialized, so of two
+// writers updating the same version of a document, the second gets ErrVersionConflict.
+func (c *CopyOnWriteIndex) UpdateDocument(doc Document, expected uint64) (uint64, error) {
+	var version uint64
+	if err := c.checkDocuments(); err != nil {
+		return 0, err
+	}
+	err := c.Update(func(base *Bm25Base) error {
+		var err error
+		_, version, err = base.UpdateDocument(doc, expected)
+		return err
+	})
+	return version, err
+}
+
+// DeleteDocument deletes a document with compare-and-set semantics, see
+// Bm25Base.DeleteDocument, and publishes the change.
+func (c *CopyOnWriteIndex) DeleteDocument(id string, expected uint64) error {
+	return c.Update(func(base *Bm25Base) error {
+		return base.DeleteDocument(id, expected)
+	})
+}
+
+// UpdateDocument is not supported by BM25F, whose documents are made of several fields.
+func (f *BM25F) UpdateDocument(doc Document, expected uint64) (int, uint64, error) {
+	return 0, 0, ErrNotImplemented
+}
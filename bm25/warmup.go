@@ -0,0 +1,41 @@
+package bm25
+
+// Warmup prepares the index for a sample of expected queries, so the first queries after
+// a deployment do not pay for work that is otherwise done on first use: it reads the
+// remaining documents of an index opened with OpenSnapshot, builds the vocabulary and
+// the subword index, and fills the IDF cache with the terms of the queries, after
+// subword expansion. The impact-ordered posting lists of Retrieve are not built, as they
+// take about as much memory as the documents themselves; a single call to Retrieve
+// builds them. A ResultCache wrapping the index is filled with ResultCache.Warmup.
+//
+// Frozen copies share the caches filled before Freeze, so Warmup has to be called on the
+// index before it is frozen. It returns ErrFrozen for frozen indexes.
+func (b *Bm25Base) Warmup(queries [][]string) error {
+	if b.frozen {
+		return ErrFrozen
+	}
+
+	if err := b.Preload(); err != nil {
+		return err
+	}
+	b.Vocabulary()
+	if b.epsilonSet {
+		b.averageIDF()
+	}
+
+	for _, query := range queries {
+		for _, term := range b.ExpandQuery(query) {
+			if term == "" {
+				continue
+			}
+			if _, err := b.IDF(term); err != nil {
+				return err
+			}
+		}
+	}
+
+	if b.logger != nil {
+		b.logger.Printf("Warmed up %d queries, %d cached IDF values", len(queries), len(b.idfCache))
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package bm25
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// WindowOptions configures a WindowedIndex.
+type WindowOptions struct {
+	// Window is the age after which documents expire. Zero keeps documents regardless
+	// of their age.
+	Window time.Duration
+
+	// BucketSize is the time span of each segment. Documents expire a whole segment at a
+	// time, once its newest possible timestamp falls out of the window, so they are kept
+	// for up to Window+BucketSize. Defaults to Window/24.
+	BucketSize time.Duration
+
+	// MaxDocs, if positive, bounds the number of documents. The oldest documents are
+	// evicted first.
+	MaxDocs int
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// WindowResult is a document matched by a search of a WindowedIndex.
+type WindowResult struct {
+	ID        string
+	Timestamp time.Time
+	Doc       string
+	Score     float64
+}
+
+// windowSegment holds the documents of one time bucket, in insertion order. Evicted
+// documents are dropped from the front.
+type windowSegment struct {
+	start      time.Time
+	docs       [][]string
+	ids        []string
+	timestamps []time.Time
+}
+
+// WindowedIndex is an index over a sliding window of recent documents, e.g. the events of
+// the last 24 hours, kept as a ring of time-bucketed segments. Expired segments are
+// dropped as a whole, together with their share of the corpus statistics, so the index
+// does not grow without bounds. Documents are scored with Okapi BM25 against the
+// statistics of the documents currently in the window. It is safe for concurrent use.
+type WindowedIndex struct {
+	mu        sync.Mutex
+	tokenizer func(string) []string
+	k1        float64
+	b         float64
+	opts      WindowOptions
+
+	segments    []*windowSegment // Sorted by start time
+	termFreqs   map[string]int
+	corpusSize  int
+	totalDocLen int
+}
+
+// NewWindowedIndex creates a new, empty WindowedIndex.
+func NewWindowedIndex(tokenizer func(string) []string, k1 float64, b float64, opts WindowOptions) (*WindowedIndex, error) {
+	if tokenizer == nil {
+		return nil, ErrNilTokenizer
+	}
+
+	if err := validateBM25OkapiParams(k1, b); err != nil {
+		return nil, err
+	}
+
+	if opts.Window < 0 {
+		return nil, invalidParam("Window", opts.Window, "must be non-negative")
+	}
+	if opts.BucketSize < 0 {
+		return nil, invalidParam("BucketSize", opts.BucketSize, "must be non-negative")
+	}
+	if opts.MaxDocs < 0 {
+		return nil, invalidParam("MaxDocs", opts.MaxDocs, "must be non-negative")
+	}
+	if opts.Window == 0 && opts.MaxDocs == 0 {
+		return nil, invalidParam("Window", opts.Window, "or MaxDocs must be positive")
+	}
+
+	if opts.BucketSize == 0 {
+		opts.BucketSize = max(opts.Window/24, time.Second)
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return &WindowedIndex{
+		tokenizer: tokenizer,
+		k1:        k1,
+		b:         b,
+		opts:      opts,
+		termFreqs: make(map[string]int),
+	}, nil
+}
+
+// Add adds a document with the given timestamp to the index. Documents that are already
+// outside the window are ignored.
+func (w *WindowedIndex) Add(doc Document, timestamp time.Time) error {
+	tokens := w.tokenizer(doc.Text)
+	if len(tokens) == 0 {
+		return ErrEmptyDocument
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	start := timestamp.Truncate(w.opts.BucketSize)
+	if w.bucketExpired(start, w.opts.Now()) {
+		return nil
+	}
+
+	i := sort.Search(len(w.segments), func(i int) bool { return !w.segments[i].start.Before(start) })
+	if i == len(w.segments) || !w.segments[i].start.Equal(start) {
+		w.segments = append(w.segments, nil)
+		copy(w.segments[i+1:], w.segments[i:])
+		w.segments[i] = &windowSegment{start: start}
+	}
+
+	segment := w.segments[i]
+	segment.docs = append(segment.docs, tokens)
+	segment.ids = append(segment.ids, doc.ID)
+	segment.timestamps = append(segment.timestamps, timestamp)
+	w.addStats(tokens, 1)
+
+	w.expire(w.opts.Now())
+	return nil
+}
+
+// Expire drops the documents that have fallen out of the window, which Add and Search
+// also do on their own. Calling it periodically releases memory when no documents are
+// added.
+func (w *WindowedIndex) Expire() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.expire(w.opts.Now())
+}
+
+// CorpusSize returns the number of documents in the window.
+func (w *WindowedIndex) CorpusSize() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.corpusSize
+}
+
+// Search returns the top n documents in the window for the given query, best match first.
+// Only documents matching at least one query term are returned.
+func (w *WindowedIndex) Search(query []string, n int) ([]WindowResult, error) {
+	if len(query) == 0 {
+		return nil, ErrEmptyQuery
+	}
+
+	if n <= 0 {
+		return nil, invalidParam("n", n, "must be a positive integer")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.expire(w.opts.Now())
+	if w.corpusSize == 0 {
+		return []WindowResult{}, nil
+	}
+
+	idfs := make([]float64, len(query))
+	for k, q := range query {
+		idfs[k] = okapiIDF(w.corpusSize, w.termFreqs[q])
+	}
+
+	avgDocLen := float64(w.totalDocLen) / float64(w.corpusSize)
+	var results []WindowResult
+	for _, segment := range w.segments {
+		for i, doc := range segment.docs {
+			var score float64
+			for k, q := range query {
+				freq := countTokens(doc, q)
+				if freq == 0 {
+					continue
+				}
+				tf := float64(freq)
+				score += idfs[k] * (tf * (w.k1 + 1)) / (tf + w.k1*(1-w.b+w.b*float64(len(doc))/avgDocLen))
+			}
+			if score == 0 {
+				continue
+			}
+
+			results = append(results, WindowResult{
+				ID:        segment.ids[i],
+				Timestamp: segment.timestamps[i],
+				Doc:       JoinTokens(doc, " "),
+				Score:     score,
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Timestamp.After(results[j].Timestamp)
+	})
+	return results[:Min(n, len(results))], nil
+}
+
+// bucketExpired reports whether the bucket starting at start lies entirely outside the window.
+func (w *WindowedIndex) bucketExpired(start time.Time, now time.Time) bool {
+	return w.opts.Window > 0 && !start.Add(w.opts.BucketSize).After(now.Add(-w.opts.Window))
+}
+
+// expire drops the expired segments and evicts the oldest documents above MaxDocs. The
+// caller must hold the lock.
+func (w *WindowedIndex) expire(now time.Time) {
+	expired := 0
+	for expired < len(w.segments) && w.bucketExpired(w.segments[expired].start, now) {
+		for _, doc := range w.segments[expired].docs {
+			w.addStats(doc, -1)
+		}
+		expired++
+	}
+	w.segments = w.segments[expired:]
+
+	for w.opts.MaxDocs > 0 && w.corpusSize > w.opts.MaxDocs {
+		oldest := w.segments[0]
+		w.addStats(oldest.docs[0], -1)
+		oldest.docs, oldest.ids, oldest.timestamps = oldest.docs[1:], oldest.ids[1:], oldest.timestamps[1:]
+		if len(oldest.docs) == 0 {
+			w.segments = w.segments[1:]
+		}
+	}
+}
+
+// addStats adds (sign 1) or removes (sign -1) a document from the corpus statistics. The
+// caller must hold the lock.
+func (w *WindowedIndex) addStats(doc []string, sign int) {
+	w.corpusSize += sign
+	w.totalDocLen += sign * len(doc)
+
+	seen := make(map[string]struct{}, len(doc))
+	for _, token := range doc {
+		if _, ok := seen[token]; ok {
+			continue
+		}
+		seen[token] = struct{}{}
+
+		w.termFreqs[token] += sign
+		if w.termFreqs[token] == 0 {
+			delete(w.termFreqs, token)
+		}
+	}
+}